@@ -0,0 +1,238 @@
+// Copyright (c) 2025 Bytedance Ltd. and/or its affiliates
+// SPDX-License-Identifier: MIT
+
+// Package anthropicconv converts prompts of cozeloop to requests of Anthropic Messages API.
+// It does not depend on any Anthropic SDK, the types are the same as the Messages API in JSON.
+package anthropicconv
+
+import (
+	"encoding/json"
+	"strings"
+
+	"github.com/coze-dev/cozeloop-go/entity"
+	"github.com/coze-dev/cozeloop-go/internal/util"
+)
+
+const (
+	RoleUser      = "user"
+	RoleAssistant = "assistant"
+
+	ContentBlockTypeText       = "text"
+	ContentBlockTypeImage      = "image"
+	ContentBlockTypeToolUse    = "tool_use"
+	ContentBlockTypeToolResult = "tool_result"
+
+	ImageSourceTypeBase64 = "base64"
+	ImageSourceTypeURL    = "url"
+
+	// DefaultMaxTokens is used when max tokens is not set in prompt, as it is required by Messages API.
+	DefaultMaxTokens = 4096
+
+	defaultInputSchema = `{"type":"object"}`
+)
+
+// MessagesRequest is the request of Anthropic Messages API.
+type MessagesRequest struct {
+	Model       string      `json:"model"`
+	System      string      `json:"system,omitempty"`
+	Messages    []Message   `json:"messages"`
+	MaxTokens   int         `json:"max_tokens"`
+	Temperature *float64    `json:"temperature,omitempty"`
+	TopP        *float64    `json:"top_p,omitempty"`
+	TopK        *int        `json:"top_k,omitempty"`
+	Tools       []Tool      `json:"tools,omitempty"`
+	ToolChoice  *ToolChoice `json:"tool_choice,omitempty"`
+}
+
+type Message struct {
+	Role    string         `json:"role"`
+	Content []ContentBlock `json:"content"`
+}
+
+type ContentBlock struct {
+	Type string `json:"type"`
+	// Text is set for text block
+	Text string `json:"text,omitempty"`
+	// Source is set for image block
+	Source *ImageSource `json:"source,omitempty"`
+	// ID, Name and Input are set for tool_use block
+	ID    string          `json:"id,omitempty"`
+	Name  string          `json:"name,omitempty"`
+	Input json.RawMessage `json:"input,omitempty"`
+	// ToolUseID and Content are set for tool_result block
+	ToolUseID string `json:"tool_use_id,omitempty"`
+	Content   string `json:"content,omitempty"`
+}
+
+type ImageSource struct {
+	Type      string `json:"type"`
+	MediaType string `json:"media_type,omitempty"`
+	Data      string `json:"data,omitempty"`
+	URL       string `json:"url,omitempty"`
+}
+
+type Tool struct {
+	Name        string          `json:"name"`
+	Description string          `json:"description,omitempty"`
+	InputSchema json.RawMessage `json:"input_schema"`
+}
+
+type ToolChoice struct {
+	Type string `json:"type"`
+}
+
+// ToMessagesRequest converts the messages formatted from prompt by PromptFormat, with the tools, tool call config
+// and model config of prompt, to a Messages API request. Model of request is not set. System messages are joined
+// as the system prompt, tool messages are sent as tool results of user, and consecutive messages of the same role
+// are merged, as required by Messages API. Reasoning content, and json mode and penalties of model config
+// are dropped, as they are not supported.
+func ToMessagesRequest(prompt *entity.Prompt, messages []*entity.Message) *MessagesRequest {
+	req := &MessagesRequest{
+		MaxTokens: DefaultMaxTokens,
+	}
+	var systems []string
+	for _, message := range messages {
+		if message == nil {
+			continue
+		}
+		switch message.Role {
+		case entity.RoleSystem:
+			if content := util.PtrValue(message.Content); content != "" {
+				systems = append(systems, content)
+			}
+			for _, part := range message.Parts {
+				if part != nil && part.Type == entity.ContentTypeText && util.PtrValue(part.Text) != "" {
+					systems = append(systems, util.PtrValue(part.Text))
+				}
+			}
+		case entity.RoleUser, entity.RoleAssistant, entity.RoleTool:
+			req.Messages = appendMessage(req.Messages, toMessage(message))
+		default:
+		}
+	}
+	req.System = strings.Join(systems, "\n\n")
+	if prompt == nil {
+		return req
+	}
+	req.Tools = toTools(prompt.Tools)
+	if prompt.ToolCallConfig != nil && prompt.ToolCallConfig.ToolChoice != "" && len(req.Tools) > 0 {
+		req.ToolChoice = &ToolChoice{Type: string(prompt.ToolCallConfig.ToolChoice)}
+	}
+	if config := prompt.LLMConfig; config != nil {
+		if config.MaxTokens != nil && *config.MaxTokens > 0 {
+			req.MaxTokens = int(*config.MaxTokens)
+		}
+		req.Temperature = config.Temperature
+		req.TopP = config.TopP
+		if config.TopK != nil {
+			req.TopK = util.Ptr(int(*config.TopK))
+		}
+	}
+	return req
+}
+
+// appendMessage appends the message, or merges it into the last message if they are of the same role.
+func appendMessage(messages []Message, message Message) []Message {
+	if len(message.Content) == 0 {
+		return messages
+	}
+	if len(messages) > 0 && messages[len(messages)-1].Role == message.Role {
+		last := &messages[len(messages)-1]
+		last.Content = append(last.Content, message.Content...)
+		return messages
+	}
+	return append(messages, message)
+}
+
+func toMessage(message *entity.Message) Message {
+	if message.Role == entity.RoleTool {
+		return Message{
+			Role: RoleUser,
+			Content: []ContentBlock{{
+				Type:      ContentBlockTypeToolResult,
+				ToolUseID: util.PtrValue(message.ToolCallID),
+				Content:   util.PtrValue(message.Content),
+			}},
+		}
+	}
+
+	msg := Message{Role: string(message.Role)}
+	if content := util.PtrValue(message.Content); content != "" {
+		msg.Content = append(msg.Content, ContentBlock{Type: ContentBlockTypeText, Text: content})
+	}
+	for _, part := range message.Parts {
+		if block, ok := toContentBlock(part); ok {
+			msg.Content = append(msg.Content, block)
+		}
+	}
+	for _, toolCall := range message.ToolCalls {
+		if toolCall == nil || toolCall.FunctionCall == nil {
+			continue
+		}
+		input := util.PtrValue(toolCall.FunctionCall.Arguments)
+		if !json.Valid([]byte(input)) {
+			input = "{}"
+		}
+		msg.Content = append(msg.Content, ContentBlock{
+			Type:  ContentBlockTypeToolUse,
+			ID:    toolCall.ID,
+			Name:  toolCall.FunctionCall.Name,
+			Input: json.RawMessage(input),
+		})
+	}
+	return msg
+}
+
+func toContentBlock(part *entity.ContentPart) (ContentBlock, bool) {
+	if part == nil {
+		return ContentBlock{}, false
+	}
+	switch part.Type {
+	case entity.ContentTypeText:
+		return ContentBlock{Type: ContentBlockTypeText, Text: util.PtrValue(part.Text)}, true
+	case entity.ContentTypeImageURL:
+		return ContentBlock{Type: ContentBlockTypeImage, Source: toImageSource(util.PtrValue(part.ImageURL))}, true
+	case entity.ContentTypeBase64Data:
+		return ContentBlock{Type: ContentBlockTypeImage, Source: toImageSource(util.PtrValue(part.Base64Data))}, true
+	default:
+		return ContentBlock{}, false
+	}
+}
+
+// toImageSource converts the url to image source. Data URL like "data:image/png;base64,..." is sent as base64 data.
+func toImageSource(url string) *ImageSource {
+	if mediaType, data, ok := parseDataURL(url); ok {
+		return &ImageSource{Type: ImageSourceTypeBase64, MediaType: mediaType, Data: data}
+	}
+	return &ImageSource{Type: ImageSourceTypeURL, URL: url}
+}
+
+func parseDataURL(url string) (mediaType, data string, ok bool) {
+	if !strings.HasPrefix(url, "data:") {
+		return "", "", false
+	}
+	meta, data, found := strings.Cut(strings.TrimPrefix(url, "data:"), ",")
+	if !found || !strings.HasSuffix(meta, ";base64") {
+		return "", "", false
+	}
+	return strings.TrimSuffix(meta, ";base64"), data, true
+}
+
+func toTools(tools []*entity.Tool) []Tool {
+	var result []Tool
+	for _, tool := range tools {
+		if tool == nil || tool.Function == nil {
+			continue
+		}
+		schema := util.PtrValue(tool.Function.Parameters)
+		if schema == "" || !json.Valid([]byte(schema)) {
+			schema = defaultInputSchema
+		}
+		result = append(result, Tool{
+			Name:        tool.Function.Name,
+			Description: util.PtrValue(tool.Function.Description),
+			InputSchema: json.RawMessage(schema),
+		})
+	}
+	return result
+}
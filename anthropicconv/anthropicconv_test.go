@@ -0,0 +1,64 @@
+// Copyright (c) 2025 Bytedance Ltd. and/or its affiliates
+// SPDX-License-Identifier: MIT
+
+package anthropicconv
+
+import (
+	"encoding/json"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+
+	"github.com/coze-dev/cozeloop-go/entity"
+	"github.com/coze-dev/cozeloop-go/internal/util"
+)
+
+func TestToMessagesRequest(t *testing.T) {
+	Convey("Test convert prompt to request", t, func() {
+		prompt := &entity.Prompt{
+			Tools: []*entity.Tool{{
+				Type:     entity.ToolTypeFunction,
+				Function: &entity.Function{Name: "get_weather"},
+			}},
+			ToolCallConfig: &entity.ToolCallConfig{ToolChoice: entity.ToolChoiceTypeAuto},
+			LLMConfig: &entity.LLMConfig{
+				Temperature: util.Ptr(0.5),
+				TopK:        util.Ptr(int32(10)),
+			},
+		}
+		messages := []*entity.Message{
+			{Role: entity.RoleSystem, Content: util.Ptr("You are a helpful assistant.")},
+			{Role: entity.RoleUser, Parts: []*entity.ContentPart{
+				{Type: entity.ContentTypeText, Text: util.Ptr("what is it?")},
+				{Type: entity.ContentTypeBase64Data, Base64Data: util.Ptr("data:image/png;base64,aGVsbG8=")},
+			}},
+			{Role: entity.RoleAssistant, ToolCalls: []*entity.ToolCall{{
+				ID:           "call_1",
+				Type:         entity.ToolTypeFunction,
+				FunctionCall: &entity.FunctionCall{Name: "get_weather", Arguments: util.Ptr(`{"city":"Beijing"}`)},
+			}}},
+			{Role: entity.RoleTool, ToolCallID: util.Ptr("call_1"), Content: util.Ptr("sunny")},
+			{Role: entity.RoleUser, Content: util.Ptr("thanks")},
+		}
+
+		req := ToMessagesRequest(prompt, messages)
+		So(req.System, ShouldEqual, "You are a helpful assistant.")
+		So(req.MaxTokens, ShouldEqual, DefaultMaxTokens)
+		So(*req.TopK, ShouldEqual, 10)
+		So(req.ToolChoice.Type, ShouldEqual, "auto")
+		So(string(req.Tools[0].InputSchema), ShouldEqual, `{"type":"object"}`)
+
+		// tool result and the next user message are merged
+		So(len(req.Messages), ShouldEqual, 3)
+		So(req.Messages[0].Content[1].Source, ShouldResemble, &ImageSource{Type: ImageSourceTypeBase64, MediaType: "image/png", Data: "aGVsbG8="})
+		So(req.Messages[1].Content[0].Type, ShouldEqual, ContentBlockTypeToolUse)
+		So(string(req.Messages[1].Content[0].Input), ShouldEqual, `{"city":"Beijing"}`)
+		So(req.Messages[2].Role, ShouldEqual, RoleUser)
+		So(req.Messages[2].Content[0].Type, ShouldEqual, ContentBlockTypeToolResult)
+		So(req.Messages[2].Content[0].ToolUseID, ShouldEqual, "call_1")
+		So(req.Messages[2].Content[1].Text, ShouldEqual, "thanks")
+
+		_, err := json.Marshal(req)
+		So(err, ShouldBeNil)
+	})
+}
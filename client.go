@@ -11,18 +11,22 @@ import (
 	"fmt"
 	"net/http"
 	"os"
-	"os/signal"
+	"reflect"
+	"runtime/debug"
+	"strconv"
 	"strings"
 	"sync"
-	"syscall"
+	"sync/atomic"
 	"time"
 
 	"github.com/coze-dev/cozeloop-go/entity"
 	"github.com/coze-dev/cozeloop-go/internal/consts"
+	"github.com/coze-dev/cozeloop-go/internal/eval"
 	"github.com/coze-dev/cozeloop-go/internal/httpclient"
 	"github.com/coze-dev/cozeloop-go/internal/logger"
 	"github.com/coze-dev/cozeloop-go/internal/prompt"
 	"github.com/coze-dev/cozeloop-go/internal/trace"
+	"github.com/coze-dev/cozeloop-go/internal/util"
 )
 
 // Client interface of loop client.
@@ -32,11 +36,64 @@ type Client interface {
 	PromptClient
 	// TraceClient interface of trace client
 	TraceClient
+	// EvalClient interface of evaluation client
+	EvalClient
+	// DatasetClient interface of dataset client
+	DatasetClient
+	// TraceQueryClient interface of trace query client
+	TraceQueryClient
+	// PromptOptimizationClient interface of prompt optimization client
+	PromptOptimizationClient
 
 	// GetWorkspaceID return workspace id
 	GetWorkspaceID() string
 	// Close close the client. Should be called before program exit.
 	Close(ctx context.Context)
+	// Shutdown close the client like Close, and returns the report of spans flushed, dropped and pending.
+	// Spans not reported before ctx done are pending and lost. The refreshes and subscriptions of prompt caches
+	// are stopped.
+	Shutdown(ctx context.Context) (*ShutdownReport, error)
+	// Stats return the statistics of prompt cache and span reporting at the moment, for monitoring the SDK.
+	Stats() ClientStats
+	// UpdateConfig updates the options set in update at runtime, without recreating the client. It is thread-safe,
+	// and returns ErrInvalidParam without updating anything if any option is invalid.
+	UpdateConfig(update ConfigUpdate) error
+}
+
+// ConfigUpdate the options which can be updated at runtime by UpdateConfig, the options which are nil are not
+// changed. Note that clients created with the same options are shared, see NewClient, and the log level is global.
+type ConfigUpdate struct {
+	// LogLevel see SetLogLevel
+	LogLevel *LogLevel
+	// TraceSampleRatio see WithTraceSampleRatio, it takes effect on the spans started later
+	TraceSampleRatio *float64
+	// PromptCacheRefreshInterval see WithPromptCacheRefreshInterval, it must be positive
+	PromptCacheRefreshInterval *time.Duration
+	// CaptureContent see WithCaptureContent, it takes effect on the spans started later
+	CaptureContent *bool
+}
+
+func (u ConfigUpdate) check() error {
+	if u.TraceSampleRatio != nil && !validSampleRatio(*u.TraceSampleRatio) {
+		return ErrInvalidParam.Wrap(fmt.Errorf("trace sample ratio should be in [0, 1]: %v", *u.TraceSampleRatio))
+	}
+	if u.PromptCacheRefreshInterval != nil && *u.PromptCacheRefreshInterval <= 0 {
+		return ErrInvalidParam.Wrap(fmt.Errorf("prompt cache refresh interval should be positive: %v",
+			*u.PromptCacheRefreshInterval))
+	}
+	return nil
+}
+
+func validSampleRatio(ratio float64) bool {
+	return ratio >= 0 && ratio <= 1
+}
+
+// ClientStats statistics of client returned by Stats, which can be exported to dashboards and alerts.
+type ClientStats struct {
+	// Prompt statistics of prompt cache and its refreshes
+	Prompt PromptStats
+	// Trace statistics of spans and files reported, dropped and pending in queues
+	Trace TraceStats
 }
 
 type Option func(o *options)
@@ -44,28 +101,105 @@ type Option func(o *options)
 // HttpClient Interface of HttpClient, can use http.DefaultClient
 type HttpClient = httpclient.HTTPClient
 
+// ConnPoolConf tunes the connection pool of the http client created by SDK, see WithConnPoolConf.
+type ConnPoolConf = httpclient.PoolConf
+
+// Auth provides the access token of requests, which is sent as Bearer token in Authorization header.
+// Token is called for every request, so it should cache the token and refresh it when needed.
+type Auth = httpclient.Auth
+
+// HeaderAuth is an Auth which sets the auth headers of requests itself, for auth schemes other than Bearer token.
+type HeaderAuth = httpclient.HeaderAuth
+
+// AuthFunc is an adapter to use a func as Auth, such as a func reading the rotating token from secret manager.
+type AuthFunc = httpclient.AuthFunc
+
+// DeviceAuthCode the device code of oauth device flow, the user should open VerificationURL and enter UserCode.
+type DeviceAuthCode = httpclient.DeviceAuthCode
+
+// CircuitBreakerConf conf of the circuit breaker around requests to loop server, see WithCircuitBreaker.
+type CircuitBreakerConf = httpclient.CircuitBreakerConf
+
+// CircuitState state of circuit breaker, which is reported by CircuitBreakerConf.OnStateChange.
+type CircuitState = httpclient.CircuitState
+
+const (
+	CircuitClosed   = httpclient.CircuitClosed
+	CircuitOpen     = httpclient.CircuitOpen
+	CircuitHalfOpen = httpclient.CircuitHalfOpen
+)
+
 type options struct {
-	apiBaseURL    string
-	apiBasePath   *APIBasePath
-	workspaceID   string
-	httpClient    HttpClient
-	timeout       time.Duration
-	uploadTimeout time.Duration
+	apiBaseURL     string
+	apiBasePath    *APIBasePath
+	region         Region
+	workspaceID    string
+	httpClient     HttpClient
+	connPoolConf   *ConnPoolConf
+	timeout        time.Duration
+	uploadTimeout  time.Duration
+	circuitBreaker *CircuitBreakerConf
 
 	apiToken            string
 	jwtOAuthClientID    string
 	jwtOAuthPrivateKey  string
 	jwtOAuthPublicKeyID string
+	authProvider        Auth
+
+	oauthDeviceClientID    string
+	oauthDeviceCodeHandler func(ctx context.Context, code *DeviceAuthCode)
+	oauthTokenFile         string
 
 	ultraLargeReport bool
+	gzipTraceReport  bool
 
 	promptCacheMaxCount        int
 	promptCacheRefreshInterval time.Duration
 	promptTrace                bool
+	promptStaleWhileRevalidate bool
+	promptSubscription         bool
+	promptDeepCopy             bool
+	promptNotFoundCacheTTL     time.Duration
+	promptNotFoundError        bool
+	templateFuncs              map[string]any
+	promptJinja2Conf           *PromptJinja2Conf
+	promptFormatCache          *PromptFormatCacheConf
+	promptExecuteCache         *PromptExecuteCacheConf
+	promptTraceInputConf       *PromptTraceInputConf
+	localPromptDir             string
+	localPromptOnly            bool
 	exporter                   trace.Exporter
 	traceFinishEventProcessor  func(ctx context.Context, info *FinishEventInfo)
 	traceTagTruncateConf       *TagTruncateConf
+	traceMaxTagValueSize       int
+	traceMaxTagCount           int
+	traceTruncationPolicy      TruncationPolicy
 	traceQueueConf             *TraceQueueConf
+	traceMaxBufferedBytes      int64
+	traceBufferEvictionPolicy  BufferEvictionPolicy
+	traceIDGenerator           IDGenerator
+	traceClock                 Clock
+	traceSpanRedactor          SpanRedactor
+	traceCaptureContent        bool
+	traceSampleRatio           float64
+	traceTagLint               *TagLintConf
+	traceWireFormat            TraceWireFormat
+	traceIDNormalization       *TraceIDNormalizationConf
+	tracePersistentQueueDir    string
+	traceLeakDetection         *SpanLeakDetectionConf
+	traceModelPricing          *ModelPricing
+	traceBaggageConf           *BaggageConf
+	traceBaggageProcessor      BaggageProcessor
+	traceBlobStore             BlobStore
+	traceSpanProcessors        []SpanProcessor
+	traceDestinations          []*TraceDestination
+	traceDebug                 *DebugConf
+	traceDebugFile             string
+	traceResourceAttributes    map[string]string
+
+	noClientCache    bool
+	disabled         bool
+	promptFixtureDir string
 }
 
 func (o *options) MD5() string {
@@ -73,25 +207,184 @@ func (o *options) MD5() string {
 	separator := "\t"
 	h.Write([]byte(o.apiBaseURL + separator))
 	h.Write([]byte(fmt.Sprintf("%p", o.apiBasePath) + separator))
+	h.Write([]byte(string(o.region) + separator))
 	h.Write([]byte(o.workspaceID + separator))
 	h.Write([]byte(fmt.Sprintf("%p", o.httpClient) + separator))
+	h.Write([]byte(fmt.Sprintf("%p", o.connPoolConf) + separator))
 	h.Write([]byte(o.timeout.String() + separator))
 	h.Write([]byte(o.uploadTimeout.String() + separator))
+	h.Write([]byte(fmt.Sprintf("%p", o.circuitBreaker) + separator))
 	h.Write([]byte(o.apiToken + separator))
 	h.Write([]byte(o.jwtOAuthClientID + separator))
 	h.Write([]byte(o.jwtOAuthPrivateKey + separator))
 	h.Write([]byte(o.jwtOAuthPublicKeyID + separator))
+	h.Write([]byte(fmt.Sprintf("%p", o.authProvider) + separator))
+	h.Write([]byte(o.oauthDeviceClientID + separator))
+	h.Write([]byte(fmt.Sprintf("%p", o.oauthDeviceCodeHandler) + separator))
+	h.Write([]byte(o.oauthTokenFile + separator))
 	h.Write([]byte(fmt.Sprintf("%v", o.ultraLargeReport) + separator))
+	h.Write([]byte(fmt.Sprintf("%v", o.gzipTraceReport) + separator))
 	h.Write([]byte(fmt.Sprintf("%d", o.promptCacheMaxCount) + separator))
 	h.Write([]byte(o.promptCacheRefreshInterval.String() + separator))
 	h.Write([]byte(fmt.Sprintf("%v", o.promptTrace) + separator))
+	h.Write([]byte(fmt.Sprintf("%v", o.promptStaleWhileRevalidate) + separator))
+	h.Write([]byte(fmt.Sprintf("%v", o.promptSubscription) + separator))
+	h.Write([]byte(fmt.Sprintf("%v", o.promptDeepCopy) + separator))
+	h.Write([]byte(o.promptNotFoundCacheTTL.String() + separator))
+	h.Write([]byte(fmt.Sprintf("%v", o.promptNotFoundError) + separator))
+	h.Write([]byte(fmt.Sprintf("%p", o.templateFuncs) + separator))
+	h.Write([]byte(fmt.Sprintf("%p", o.promptJinja2Conf) + separator))
+	h.Write([]byte(fmt.Sprintf("%p", o.promptFormatCache) + separator))
+	h.Write([]byte(fmt.Sprintf("%p", o.promptExecuteCache) + separator))
+	h.Write([]byte(fmt.Sprintf("%p", o.promptTraceInputConf) + separator))
+	h.Write([]byte(o.localPromptDir + separator))
+	h.Write([]byte(fmt.Sprintf("%v", o.localPromptOnly) + separator))
 	h.Write([]byte(fmt.Sprintf("%p", o.exporter) + separator))
 	h.Write([]byte(fmt.Sprintf("%p", o.traceFinishEventProcessor) + separator))
 	h.Write([]byte(fmt.Sprintf("%p", o.traceTagTruncateConf) + separator))
+	h.Write([]byte(fmt.Sprintf("%d", o.traceMaxTagValueSize) + separator))
+	h.Write([]byte(fmt.Sprintf("%d", o.traceMaxTagCount) + separator))
+	h.Write([]byte(string(o.traceTruncationPolicy) + separator))
 	h.Write([]byte(fmt.Sprintf("%p", o.traceQueueConf) + separator))
+	h.Write([]byte(fmt.Sprintf("%d", o.traceMaxBufferedBytes) + separator))
+	h.Write([]byte(string(o.traceBufferEvictionPolicy) + separator))
+	h.Write([]byte(fmt.Sprintf("%p", o.traceIDGenerator) + separator))
+	h.Write([]byte(fmt.Sprintf("%p", o.traceClock) + separator))
+	h.Write([]byte(fmt.Sprintf("%p", o.traceSpanRedactor) + separator))
+	h.Write([]byte(fmt.Sprintf("%v", o.traceCaptureContent) + separator))
+	h.Write([]byte(fmt.Sprintf("%v", o.traceSampleRatio) + separator))
+	// hash tag lint by value, as the env enables it with a new conf for every client
+	if o.traceTagLint != nil {
+		h.Write([]byte(fmt.Sprintf("%v%p", true, o.traceTagLint.OnWarning) + separator))
+	} else {
+		h.Write([]byte(fmt.Sprintf("%v", false) + separator))
+	}
+	h.Write([]byte(string(o.traceWireFormat) + separator))
+	// hash id normalization by value, as the conf is usually built inline for every client
+	if o.traceIDNormalization != nil {
+		h.Write([]byte(fmt.Sprintf("%v%q%v", true, o.traceIDNormalization.VendorPrefixes,
+			o.traceIDNormalization.PadShortIDs) + separator))
+	} else {
+		h.Write([]byte(fmt.Sprintf("%v", false) + separator))
+	}
+	h.Write([]byte(o.tracePersistentQueueDir + separator))
+	h.Write([]byte(fmt.Sprintf("%p", o.traceLeakDetection) + separator))
+	h.Write([]byte(fmt.Sprintf("%p", o.traceModelPricing) + separator))
+	h.Write([]byte(fmt.Sprintf("%p", o.traceBaggageConf) + separator))
+	h.Write([]byte(fmt.Sprintf("%p", o.traceBaggageProcessor) + separator))
+	h.Write([]byte(fmt.Sprintf("%p", o.traceBlobStore) + separator))
+	h.Write([]byte(fmt.Sprintf("%v", o.traceSpanProcessors) + separator))
+	h.Write([]byte(fmt.Sprintf("%v", o.traceDestinations) + separator))
+	if o.traceDebug != nil {
+		h.Write([]byte(fmt.Sprintf("%v", *o.traceDebug) + separator))
+	}
+	h.Write([]byte(o.traceDebugFile + separator))
+	h.Write([]byte(fmt.Sprintf("%v", o.traceResourceAttributes) + separator))
+	h.Write([]byte(fmt.Sprintf("%v", o.noClientCache) + separator))
+	h.Write([]byte(fmt.Sprintf("%v", o.disabled) + separator))
+	h.Write([]byte(o.promptFixtureDir + separator))
 	return hex.EncodeToString(h.Sum(nil))
 }
 
+// hasFuncOptions returns whether any option is set with a func, which can not be hashed by MD5.
+func (o *options) hasFuncOptions() bool {
+	if o.oauthDeviceCodeHandler != nil || o.traceFinishEventProcessor != nil || o.traceSpanRedactor != nil ||
+		o.traceBaggageProcessor != nil || len(o.templateFuncs) > 0 ||
+		(o.traceTagLint != nil && o.traceTagLint.OnWarning != nil) {
+		return true
+	}
+	for _, v := range []interface{}{o.httpClient, o.authProvider, o.exporter, o.traceIDGenerator, o.traceClock,
+		o.traceBlobStore} {
+		if v != nil && reflect.ValueOf(v).Kind() == reflect.Func {
+			return true
+		}
+	}
+	for _, processor := range o.traceSpanProcessors {
+		if processor != nil && reflect.ValueOf(processor).Kind() == reflect.Func {
+			return true
+		}
+	}
+	return false
+}
+
+// tagTruncateConf merges WithMaxTagValueSize, WithMaxTagCount and WithTruncationPolicy into the conf
+// set by WithTraceTagTruncateConf, the conf set by user is not modified.
+func (o *options) tagTruncateConf() *trace.TagTruncateConf {
+	if o.traceMaxTagValueSize <= 0 && o.traceMaxTagCount <= 0 && o.traceTruncationPolicy == "" {
+		return (*trace.TagTruncateConf)(o.traceTagTruncateConf)
+	}
+	conf := &trace.TagTruncateConf{}
+	if o.traceTagTruncateConf != nil {
+		*conf = trace.TagTruncateConf(*o.traceTagTruncateConf)
+	}
+	if o.traceMaxTagValueSize > 0 {
+		conf.NormalFieldMaxByte = o.traceMaxTagValueSize
+	}
+	if o.traceMaxTagCount > 0 {
+		conf.MaxTagCount = o.traceMaxTagCount
+	}
+	if o.traceTruncationPolicy != "" {
+		conf.TruncationPolicy = o.traceTruncationPolicy
+	}
+	return conf
+}
+
+// destinations returns the secondary trace destinations.
+func (o *options) destinations() []*trace.Destination {
+	if len(o.traceDestinations) == 0 {
+		return nil
+	}
+	destinations := make([]*trace.Destination, 0, len(o.traceDestinations))
+	for _, dest := range o.traceDestinations {
+		destinations = append(destinations, &trace.Destination{
+			WorkspaceID: dest.WorkspaceID,
+			Exporter:    dest.Exporter,
+			QueueConf:   (*trace.QueueConf)(dest.QueueConf),
+		})
+	}
+	return destinations
+}
+
+// queueConf returns the conf of trace queues, with the buffer limit set by options.
+func (o *options) queueConf() *trace.QueueConf {
+	if o.traceMaxBufferedBytes <= 0 && o.traceBufferEvictionPolicy == "" {
+		return (*trace.QueueConf)(o.traceQueueConf)
+	}
+	conf := &trace.QueueConf{}
+	if o.traceQueueConf != nil {
+		*conf = trace.QueueConf(*o.traceQueueConf)
+	}
+	if o.traceMaxBufferedBytes > 0 {
+		conf.MaxBufferedBytes = o.traceMaxBufferedBytes
+	}
+	if o.traceBufferEvictionPolicy != "" {
+		conf.BufferEvictionPolicy = o.traceBufferEvictionPolicy
+	}
+	return conf
+}
+
+// debugOnly whether spans are only printed by debug exporter, and not reported.
+func (o *options) debugOnly() bool {
+	return o.traceDebug != nil && !o.traceDebug.AlsoReport
+}
+
+// debugConf returns the conf of debug exporter, with the file set by env opened as writer.
+func (o *options) debugConf() *trace.DebugConf {
+	if o.traceDebug == nil {
+		return nil
+	}
+	conf := *o.traceDebug
+	if conf.Writer == nil && o.traceDebugFile != "" {
+		file, err := os.OpenFile(o.traceDebugFile, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o644)
+		if err != nil {
+			logger.CtxWarnf(context.Background(), "open debug file %s failed, print spans to stdout instead: %v", o.traceDebugFile, err)
+		} else {
+			conf.Writer = file
+		}
+	}
+	return &conf
+}
+
 func defaultOptions() options {
 	opts := options{
 		apiBaseURL:                 CnBaseURL,
@@ -101,13 +394,21 @@ func defaultOptions() options {
 		ultraLargeReport:           false,
 		promptCacheMaxCount:        consts.DefaultPromptCacheMaxCount,
 		promptCacheRefreshInterval: consts.DefaultPromptCacheRefreshInterval,
+		promptNotFoundCacheTTL:     consts.DefaultPromptNotFoundCacheTTL,
 		promptTrace:                false,
+		promptDeepCopy:             true,
+		traceCaptureContent:        true,
+		traceSampleRatio:           1,
 	}
 	return opts
 }
 
 // NewClient creates a new loop client with the provided options.
 // The client is thread-safe. **Do not** create multiple instances.
+// Clients created with same options are shared, unless WithNoClientCache is set, or any option is set with a func,
+// such as WithSpanRedactor, as funcs can not be compared.
+// The first client created becomes the default client which backs the package-level functions,
+// unless WithNoClientCache is set. Use SetDefaultClient to choose the default client explicitly.
 func NewClient(opts ...Option) (Client, error) {
 	options := defaultOptions()
 	buildOptionsFromEnv(&options)
@@ -118,31 +419,62 @@ func NewClient(opts ...Option) (Client, error) {
 
 	options.apiBaseURL = strings.TrimRight(strings.TrimSpace(options.apiBaseURL), "/")
 
+	if options.disabled {
+		return newDisabledClient(options), nil
+	}
+
 	if err := checkOptions(&options); err != nil {
 		return &NoopClient{newClientError: err}, err
 	}
 
 	cacheKey := options.MD5()
-	if cachedClient, ok := clientCache.Load(cacheKey); ok {
-		logger.CtxWarnf(context.Background(), "You shouldn't creating a client with same options repeatedly, "+
-			"return the cached client instead.")
-		return cachedClient.(*loopClient), nil
+	// funcs can not be told apart by MD5, e.g. the closures of the same func with different captured values
+	cacheable := !options.noClientCache && !options.hasFuncOptions()
+	if cacheable {
+		if cachedClient, ok := clientCache.Load(cacheKey); ok {
+			logger.CtxWarnf(context.Background(), "You shouldn't creating a client with same options repeatedly, "+
+				"return the cached client instead.")
+			return cachedClient.(*loopClient), nil
+		}
+	}
+
+	// the pool is tuned only for the http client created by SDK
+	var pooledClient *http.Client
+	if options.connPoolConf != nil && options.httpClient == HttpClient(http.DefaultClient) {
+		pooledClient = httpclient.NewPooledHTTPClient(*options.connPoolConf)
+		options.httpClient = pooledClient
 	}
 
 	auth, err := buildAuth(options)
+	if errors.Is(err, ErrAuthInfoRequired) && options.debugOnly() {
+		// spans are not reported in debug mode, and prompt APIs fail with the error
+		auth, err = debugAuth{}, nil
+	}
 	if err != nil {
 		return &NoopClient{newClientError: err}, err
 	}
 
 	c := &loopClient{
-		workspaceID: options.workspaceID,
+		workspaceID:  options.workspaceID,
+		shutdownDone: make(chan struct{}),
+		pooledClient: pooledClient,
+	}
+	if cacheable {
+		c.cacheKey = cacheKey
 	}
 	httpClient := httpclient.NewClient(options.apiBaseURL, options.httpClient, auth,
 		&httpclient.ClientOptions{
 			Timeout:        options.timeout,
 			UploadTimeout:  options.uploadTimeout,
 			HeaderEnricher: createTraceHeaderEnricher(),
+			GzipRequest:    options.gzipTraceReport,
+			CircuitBreaker: options.circuitBreaker,
+		})
+	if options.connPoolConf != nil && options.connPoolConf.WarmConns > 0 {
+		util.GoSafe(context.Background(), func() {
+			httpClient.Warm(context.Background(), options.connPoolConf.WarmConns)
 		})
+	}
 	traceFinishEventProcessor := trace.DefaultFinishEventProcessor
 	if options.traceFinishEventProcessor != nil {
 		traceFinishEventProcessor = func(ctx context.Context, info *consts.FinishEventInfo) {
@@ -161,27 +493,63 @@ func NewClient(opts ...Option) (Client, error) {
 		UltraLargeReport:     options.ultraLargeReport,
 		Exporter:             options.exporter,
 		FinishEventProcessor: traceFinishEventProcessor,
-		TagTruncateConf:      (*trace.TagTruncateConf)(options.traceTagTruncateConf),
+		TagTruncateConf:      options.tagTruncateConf(),
 		SpanUploadPath:       spanUploadPath,
 		FileUploadPath:       fileUploadPath,
-		QueueConf:            (*trace.QueueConf)(options.traceQueueConf),
+		QueueConf:            options.queueConf(),
+		IDGenerator:          options.traceIDGenerator,
+		Clock:                options.traceClock,
+		SpanRedactor:         options.traceSpanRedactor,
+		NoCaptureContent:     !options.traceCaptureContent,
+		SampleRatio:          &options.traceSampleRatio,
+		TagLint:              options.traceTagLint,
+		WireFormat:           options.traceWireFormat,
+		IDNormalization:      options.traceIDNormalization,
+		PersistentQueueDir:   options.tracePersistentQueueDir,
+		LeakDetection:        options.traceLeakDetection,
+		ModelPricing:         options.traceModelPricing,
+		BaggageConf:          options.traceBaggageConf,
+		BaggageProcessor:     options.traceBaggageProcessor,
+		BlobStore:            options.traceBlobStore,
+		SpanProcessors:       options.traceSpanProcessors,
+		Destinations:         options.destinations(),
+		Debug:                options.debugConf(),
+		ResourceAttributes:   options.traceResourceAttributes,
 	})
 	c.promptProvider = prompt.NewPromptProvider(httpClient, c.traceProvider, prompt.Options{
 		WorkspaceID:                options.workspaceID,
 		PromptCacheMaxCount:        options.promptCacheMaxCount,
 		PromptCacheRefreshInterval: options.promptCacheRefreshInterval,
 		PromptTrace:                options.promptTrace,
+		PromptStaleWhileRevalidate: options.promptStaleWhileRevalidate,
+		PromptSubscription:         options.promptSubscription,
+		PromptShared:               !options.promptDeepCopy,
+		PromptNotFoundCacheTTL:     options.promptNotFoundCacheTTL,
+		PromptNotFoundError:        options.promptNotFoundError,
+		TemplateFuncs:              options.templateFuncs,
+		Jinja2:                     options.promptJinja2Conf,
+		FormatCache:                options.promptFormatCache,
+		ExecuteCache:               options.promptExecuteCache,
+		TraceInput:                 options.promptTraceInputConf,
+		LocalPromptDir:             options.localPromptDir,
+		LocalPromptOnly:            options.localPromptOnly,
+	})
+	c.evalProvider = eval.NewEvalProvider(httpClient, eval.Options{
+		WorkspaceID: options.workspaceID,
 	})
 
-	clientCache.Store(cacheKey, c)
+	if options.noClientCache {
+		return c, nil
+	}
+	if cacheable {
+		clientCache.Store(cacheKey, c)
+	}
 
-	var tempCli Client
-	defaultClientLock.RLock()
-	tempCli = defaultClient
-	defaultClientLock.RUnlock()
-	if tempCli == nil {
-		SetDefaultClient(c)
+	defaultClientLock.Lock()
+	if defaultClient == nil {
+		defaultClient = c
 	}
+	defaultClientLock.Unlock()
 	return c, nil
 }
 
@@ -214,13 +582,59 @@ func WithJWTOAuthPublicKeyID(publicKeyID string) Option {
 	}
 }
 
-// WithAPIBaseURL set api base url. Generally, there's no need to use it. Default is http://api.coze.cn
+// WithOAuthDeviceFlow authenticate users interactively with oauth device flow, for CLI tools where jwt oauth
+// is not available. You can get the client id of device app from https://www.coze.cn/open/oauth/apps.
+// The user is asked to authorize on first request. The flow keeps waiting for the user in background until the
+// device code expires, while requests wait for it until their own timeout, and the requests made meanwhile reuse
+// the same device code.
+func WithOAuthDeviceFlow(clientID string) Option {
+	return func(p *options) {
+		p.oauthDeviceClientID = clientID
+	}
+}
+
+// WithOAuthDeviceCodeHandler set the handler to show the verification url and user code of oauth device flow.
+// Default prints them to stderr.
+func WithOAuthDeviceCodeHandler(handler func(ctx context.Context, code *DeviceAuthCode)) Option {
+	return func(p *options) {
+		p.oauthDeviceCodeHandler = handler
+	}
+}
+
+// WithOAuthTokenFile set the file to persist the token of oauth device flow, so the user needn't authorize
+// again after restart until the refresh token expires. The file is readable only by current user.
+func WithOAuthTokenFile(path string) Option {
+	return func(p *options) {
+		p.oauthTokenFile = path
+	}
+}
+
+// WithAuthProvider set custom auth, such as rotating tokens from secret managers or custom auth schemes.
+// It takes precedence over api token and jwt oauth.
+func WithAuthProvider(auth Auth) Option {
+	return func(p *options) {
+		p.authProvider = auth
+	}
+}
+
+// WithAPIBaseURL set api base url. Generally, there's no need to use it, use WithRegion instead. It overrides
+// the base url of WithRegion if it is set after WithRegion. Default is https://api.coze.cn
 func WithAPIBaseURL(apiBaseURL string) Option {
 	return func(p *options) {
 		p.apiBaseURL = apiBaseURL
 	}
 }
 
+// WithRegion set the region of CozeLoop service, the api base url is set to the one of region, such as
+// ComBaseURL for RegionI18N. It overrides the base url of WithAPIBaseURL if it is set after WithAPIBaseURL.
+// Default is RegionCN.
+func WithRegion(region Region) Option {
+	return func(p *options) {
+		p.region = region
+		p.apiBaseURL = regionBaseURLs[region]
+	}
+}
+
 func WithAPIBasePath(apiBasePath *APIBasePath) Option {
 	return func(p *options) {
 		p.apiBasePath = apiBasePath
@@ -241,6 +655,16 @@ func WithHTTPClient(client HttpClient) Option {
 	}
 }
 
+// WithConnPoolConf tune the connection pool of the http client used for trace ingest, file upload and the other
+// requests, to avoid the connections closed and opened again under bursty export load, and open conf.WarmConns
+// connections in background when the client is created. It is ignored if WithHTTPClient is set, tune the transport
+// of that client instead. Default is nil, which uses http.DefaultClient.
+func WithConnPoolConf(conf *ConnPoolConf) Option {
+	return func(p *options) {
+		p.connPoolConf = conf
+	}
+}
+
 // WithTimeout set timeout when communicating with loop server. Default is 3s
 func WithTimeout(timeout time.Duration) Option {
 	return func(p *options) {
@@ -255,6 +679,15 @@ func WithUploadTimeout(timeout time.Duration) Option {
 	}
 }
 
+// WithCircuitBreaker set the circuit breaker around requests to loop server, such as getting prompts and reporting
+// traces. When the requests to an api keep failing or slow, they fail fast with ErrCircuitOpen rather than adding
+// latency to user requests, until probe requests succeed after the open duration. Default is disabled.
+func WithCircuitBreaker(conf *CircuitBreakerConf) Option {
+	return func(p *options) {
+		p.circuitBreaker = conf
+	}
+}
+
 // WithUltraLargeTraceReport set whether to report ultra large trace report. Default is false
 func WithUltraLargeTraceReport(enable bool) Option {
 	return func(p *options) {
@@ -262,6 +695,14 @@ func WithUltraLargeTraceReport(enable bool) Option {
 	}
 }
 
+// WithGzipTraceReport set whether to compress the span report request with gzip, which reduces
+// the egress bandwidth significantly for large inputs and outputs. Default is false
+func WithGzipTraceReport(enable bool) Option {
+	return func(p *options) {
+		p.gzipTraceReport = enable
+	}
+}
+
 // WithPromptCacheMaxCount set prompt cache max count. Default is 100
 func WithPromptCacheMaxCount(count int) Option {
 	return func(p *options) {
@@ -276,6 +717,54 @@ func WithPromptCacheRefreshInterval(interval time.Duration) Option {
 	}
 }
 
+// WithNoClientCache create an isolated client, which is neither shared with other clients created with
+// same options, nor set as the default client. It is useful for multi-tenant services which talk to
+// multiple workspaces with separate tokens. Use SetDefaultClient if it should back the package-level functions.
+func WithNoClientCache() Option {
+	return func(p *options) {
+		p.noClientCache = true
+	}
+}
+
+// WithDisabled create a disabled client, which never accesses CozeLoop service and needs no workspace id or auth,
+// for CI and local runs without credentials. Spans are noop, prompts are served from the fixture dir set by
+// WithPromptFixtureDir, and the other APIs return ErrClientDisabled. It can also be enabled by env
+// COZELOOP_DISABLED=1. Default is false.
+func WithDisabled(disabled bool) Option {
+	return func(p *options) {
+		p.disabled = disabled
+	}
+}
+
+// WithPromptFixtureDir set the directory of prompts served by the disabled client, see WithDisabled. The files are
+// looked up like WithLocalPromptDir, which is used if the fixture dir is not set. It can also be set by env
+// COZELOOP_PROMPT_FIXTURE_DIR. Default is empty, GetPrompt of the disabled client returns ErrClientDisabled.
+func WithPromptFixtureDir(dir string) Option {
+	return func(p *options) {
+		p.promptFixtureDir = dir
+	}
+}
+
+// WithLocalPromptDir set the directory of local prompt files, which GetPrompt consults before fetching the
+// prompt from CozeLoop, so that prompts can be developed offline and tests can pin the prompt content. The prompt
+// is read from file "<prompt_key>@<version>", "<prompt_key>@<label>" or "<prompt_key>" in order, with the
+// extension .json, .yaml or .yml, whose content is entity.Prompt in JSON or YAML. The files are read on every
+// GetPrompt, so the changes take effect immediately. It can also be set by env COZELOOP_LOCAL_PROMPT_DIR.
+// Default is empty, prompts are only fetched from CozeLoop.
+func WithLocalPromptDir(dir string) Option {
+	return func(p *options) {
+		p.localPromptDir = dir
+	}
+}
+
+// WithLocalPromptOnly set whether to get prompts only from the local prompt dir set by WithLocalPromptDir,
+// the prompt not found there is not fetched from CozeLoop. Default is false.
+func WithLocalPromptOnly(enable bool) Option {
+	return func(p *options) {
+		p.localPromptOnly = enable
+	}
+}
+
 // WithPromptTrace set whether to report trace when get and format prompt. Default is false
 func WithPromptTrace(enable bool) Option {
 	return func(p *options) {
@@ -283,6 +772,97 @@ func WithPromptTrace(enable bool) Option {
 	}
 }
 
+// WithPromptStaleWhileRevalidate set whether to return the cached prompt immediately when it is stale,
+// and refresh it in background. A cached prompt is stale when it has not been refreshed for more than
+// the prompt cache refresh interval. Default is false
+func WithPromptStaleWhileRevalidate(enable bool) Option {
+	return func(p *options) {
+		p.promptStaleWhileRevalidate = enable
+	}
+}
+
+// WithPromptSubscription set whether to subscribe the prompt changes from server, so that the cached prompts are
+// updated within seconds after a new version is published or a label is moved, instead of at the next refresh.
+// The cache is still refreshed every prompt cache refresh interval as fallback, and the subscription stops if the
+// server does not support it. One stream is kept per workspace, which is closed by Shutdown. Default is false
+func WithPromptSubscription(enable bool) Option {
+	return func(p *options) {
+		p.promptSubscription = enable
+	}
+}
+
+// WithPromptNotFoundCacheTTL set how long a missing prompt is cached as not found, so GetPrompt of a misconfigured
+// prompt key does not query the server every time. Set 0 to disable it. Default is 10 seconds
+func WithPromptNotFoundCacheTTL(ttl time.Duration) Option {
+	return func(p *options) {
+		p.promptNotFoundCacheTTL = ttl
+	}
+}
+
+// WithPromptNotFoundError set whether GetPrompt returns ErrPromptNotFound when the prompt does not exist, instead of
+// nil prompt and nil error. Default is false
+func WithPromptNotFoundError(enable bool) Option {
+	return func(p *options) {
+		p.promptNotFoundError = enable
+	}
+}
+
+// WithPromptDeepCopy set whether GetPrompt and MGetPrompts return a deep copy of the cached prompt, which can be
+// modified by caller. Disable it to return the cached prompt without copy, which saves the cost of large prompts,
+// but the prompt is shared by all callers and must be read only, call entity.Prompt.DeepCopy before modifying it.
+// Default is true
+func WithPromptDeepCopy(enable bool) Option {
+	return func(p *options) {
+		p.promptDeepCopy = enable
+	}
+}
+
+// WithTemplateFuncs register custom funcs used to render prompt templates. Each func should return one value,
+// or one value and an error. In normal template, funcs are applied in pipeline like {{name|trim|upper}}, and in
+// Jinja2 template, funcs can be used as filter {{ name | upper }} or function {{ upper(name) }}.
+func WithTemplateFuncs(funcs map[string]any) Option {
+	return func(p *options) {
+		p.templateFuncs = funcs
+	}
+}
+
+// WithPromptJinja2Conf set the whitespace control, allowed filters and render limits of Jinja2 templates, so that
+// templates pulled from prompt hub can't hang or exhaust the memory of service. Default is no whitespace control,
+// all filters allowed, 10000 loop iterations by range(), 100 depth of nested macro calls and no render timeout.
+func WithPromptJinja2Conf(conf *PromptJinja2Conf) Option {
+	return func(p *options) {
+		p.promptJinja2Conf = conf
+	}
+}
+
+// WithPromptFormatCache cache the results of PromptFormat by prompt version, template and variables, for services
+// formatting the same prompts with a small set of variables. It saves most for Jinja2 and large templates. Only
+// prompts with key and version, such as those returned by GetPrompt, are cached. Do not use it if template funcs are
+// not deterministic. Default is disabled.
+func WithPromptFormatCache(conf *PromptFormatCacheConf) Option {
+	return func(p *options) {
+		p.promptFormatCache = conf
+	}
+}
+
+// WithPromptExecuteCache cache the results of Execute by prompt version, variables, messages and llm config, for
+// deterministic prompts such as the ones with temperature 0 used in classification. Only requests with prompt
+// version are cached, whether the result is returned from cache is recorded in execute span. Default is disabled.
+func WithPromptExecuteCache(conf *PromptExecuteCacheConf) Option {
+	return func(p *options) {
+		p.promptExecuteCache = conf
+	}
+}
+
+// WithPromptTraceInputConf set the limits of variables recorded in the input of prompt template span, big text
+// variables are truncated and only the latest messages of placeholder variables are recorded. Default is 16KB per
+// text and 50 messages per placeholder.
+func WithPromptTraceInputConf(conf *PromptTraceInputConf) Option {
+	return func(p *options) {
+		p.promptTraceInputConf = conf
+	}
+}
+
 // WithExporter set custom trace exporter.
 func WithExporter(e trace.Exporter) Option {
 	return func(p *options) {
@@ -290,6 +870,16 @@ func WithExporter(e trace.Exporter) Option {
 	}
 }
 
+// WithBlobStore set the storage of span files, such as large input and output with WithUltraLargeTraceReport, and
+// multi-modality attachments, which are put to it directly instead of uploading through CozeLoop. Files are referred
+// by key in spans, so the storage should be the object storage configured for the workspace. It is ignored if
+// WithExporter is set. Default is nil.
+func WithBlobStore(store BlobStore) Option {
+	return func(p *options) {
+		p.traceBlobStore = store
+	}
+}
+
 // WithTraceFinishEventProcessor set custom finish event processor, after span finish.
 func WithTraceFinishEventProcessor(f func(ctx context.Context, info *FinishEventInfo)) Option {
 	return func(p *options) {
@@ -304,12 +894,234 @@ func WithTraceTagTruncateConf(conf *TagTruncateConf) Option {
 	}
 }
 
+// WithMaxTagValueSize set the max bytes of tag value, except input and output. Default is 1KB.
+func WithMaxTagValueSize(size int) Option {
+	return func(p *options) {
+		p.traceMaxTagValueSize = size
+	}
+}
+
+// WithMaxTagCount set the max count of custom tags in one span. Default is 50.
+func WithMaxTagCount(count int) Option {
+	return func(p *options) {
+		p.traceMaxTagCount = count
+	}
+}
+
+// WithTruncationPolicy set how to deal with the tag value exceeding the size limit. Default is TruncationPolicyTruncate,
+// or TruncationPolicyUploadFile if WithUltraLargeTraceReport is enabled.
+func WithTruncationPolicy(policy TruncationPolicy) Option {
+	return func(p *options) {
+		p.traceTruncationPolicy = policy
+	}
+}
+
 func WithTraceQueueConf(conf *TraceQueueConf) Option {
 	return func(p *options) {
 		p.traceQueueConf = conf
 	}
 }
 
+// WithTraceMaxBufferedBytes set the max bytes of spans and files buffered in trace queues, to protect small-memory
+// containers from OOM when CozeLoop is unavailable for a while. Items are dropped by the policy set by
+// WithTraceBufferEvictionPolicy when it is reached, and counted as dropped in Stats and ShutdownReport.
+// Default is 0, queues are only bounded by their lengths.
+func WithTraceMaxBufferedBytes(maxBytes int64) Option {
+	return func(p *options) {
+		p.traceMaxBufferedBytes = maxBytes
+	}
+}
+
+// WithTraceBufferEvictionPolicy set which items are dropped when the limit of WithTraceMaxBufferedBytes is reached.
+// Default is BufferEvictionPolicyDropNewest.
+func WithTraceBufferEvictionPolicy(policy BufferEvictionPolicy) Option {
+	return func(p *options) {
+		p.traceBufferEvictionPolicy = policy
+	}
+}
+
+// WithTraceIDGenerator set custom trace id and span id generator. Default generates random ids.
+// Use NewDeterministicIDGenerator for test fixtures or replay.
+func WithTraceIDGenerator(g IDGenerator) Option {
+	return func(p *options) {
+		p.traceIDGenerator = g
+	}
+}
+
+// WithTraceClock set the clock of the default start time and finish time of spans. Default is time.Now.
+// Use NewManualClock in tests to get deterministic span time and duration.
+func WithTraceClock(clock Clock) Option {
+	return func(p *options) {
+		p.traceClock = clock
+	}
+}
+
+// WithCaptureContent set whether to capture the content of input and output of spans. If it is disabled, every
+// string in input and output, such as the content of messages, is replaced with its hash and length, while the
+// structure of messages, tokens and latency are still captured. Multi-modality attachments are not uploaded either.
+// It is for the deployments where the raw content of users can not be exported. Default is true.
+func WithCaptureContent(enable bool) Option {
+	return func(p *options) {
+		p.traceCaptureContent = enable
+	}
+}
+
+// WithTraceSampleRatio set the ratio of traces reported, in [0, 1]. Whether a trace is reported is decided by the
+// hash of its trace id, so all spans of a trace are reported or dropped together, including the ones in other
+// services with the same ratio. Default is 1, which reports all traces.
+func WithTraceSampleRatio(ratio float64) Option {
+	return func(p *options) {
+		p.traceSampleRatio = ratio
+	}
+}
+
+// WithTagLint enable the lint of tags set by SetTags, which reports the misspelled keys of tracespec, such as
+// "input_token" for "input_tokens", and the values of wrong type, by logging warnings or conf.OnWarning if set.
+// The tags are still set as they are. Enable it in staging to catch broken instrumentation. Default is nil, which
+// disables it.
+func WithTagLint(conf *TagLintConf) Option {
+	return func(p *options) {
+		p.traceTagLint = conf
+	}
+}
+
+// WithTraceWireFormat set the encoding of spans reported to CozeLoop. TraceWireFormatMsgpack makes the payloads
+// smaller and cheaper to encode than JSON, and falls back to JSON if the ingest endpoint rejects it. It is ignored
+// if WithExporter is set. Default is TraceWireFormatJSON.
+func WithTraceWireFormat(format TraceWireFormat) Option {
+	return func(p *options) {
+		p.traceWireFormat = format
+	}
+}
+
+// WithTraceIDNormalization set how GetSpanFromHeader accepts the trace ids and span ids of upstreams in other
+// formats, such as uppercase ids, ids with vendor prefixes and 64-bit trace ids, which are normalized to 32 and 16
+// lowercase hex chars. Default is nil, which accepts only the ids of W3C trace context.
+func WithTraceIDNormalization(conf *TraceIDNormalizationConf) Option {
+	return func(p *options) {
+		p.traceIDNormalization = conf
+	}
+}
+
+// WithSpanRedactor set the redactor called for every span before export, which can mask sensitive data,
+// such as PII, in input, output and tags. Use NewPIIRedactor for common PII patterns.
+func WithSpanRedactor(r SpanRedactor) Option {
+	return func(p *options) {
+		p.traceSpanRedactor = r
+	}
+}
+
+// WithTracePersistentQueueDir set the dir to persist finished spans until they are exported, so spans not
+// exported yet survive process restart and are reported after next start. Default is empty, which disables it.
+// The dir must not be shared by processes running at the same time. Spans are written by a background goroutine
+// without fsync, so they survive process crash but may be lost on machine crash. Spans failed to export are
+// replayed after next start, while spans dropped by queues are not. Spans failed to replay 3 times are dropped, and
+// the file is capped at 256MB.
+func WithTracePersistentQueueDir(dir string) Option {
+	return func(p *options) {
+		p.tracePersistentQueueDir = dir
+	}
+}
+
+// WithSpanLeakDetection enable the detector of spans which are started but never finished. Spans not finished
+// in conf.TTL are logged with the code site which started them, or passed to conf.OnLeak if set, and are finished
+// with system tag "leaked" if conf.ForceFinish is true. Default is nil, which disables it.
+func WithSpanLeakDetection(conf *SpanLeakDetectionConf) Option {
+	return func(p *options) {
+		p.traceLeakDetection = conf
+	}
+}
+
+// WithModelPricing set the model prices to compute the `cost` tag of spans, from the input_tokens, output_tokens
+// and model_name tags when span is finished. Spans with cost set by SetCost, or without price of model are not
+// computed. Default is nil, which disables it.
+func WithModelPricing(pricing *ModelPricing) Option {
+	return func(p *options) {
+		p.traceModelPricing = pricing
+	}
+}
+
+// WithBaggageConf set the count and size limits of span baggage, and the filter of baggage propagated to
+// downstream services by headers. Default limits are 64 items and 8192 bytes, and all baggage is propagated.
+func WithBaggageConf(conf *BaggageConf) Option {
+	return func(p *options) {
+		p.traceBaggageConf = conf
+	}
+}
+
+// WithBaggageProcessor set the processor called when every span is started, which inspects the baggage inherited,
+// such as "debug=1" or tenant id, to inject tags on the span, drop it, or report it with full fidelity. As baggage
+// is passed to all descendant spans, including those of downstream services, they get the same decision.
+// Default is nil.
+func WithBaggageProcessor(processor BaggageProcessor) Option {
+	return func(p *options) {
+		p.traceBaggageProcessor = processor
+	}
+}
+
+// WithSpanProcessor add span processors invoked in order when spans are finished, after the default processor
+// reporting spans to CozeLoop, such as a processor printing spans to console, or reporting to another backend.
+// They are also flushed and shutdown with client, in the same order.
+func WithSpanProcessor(processors ...SpanProcessor) Option {
+	return func(p *options) {
+		p.traceSpanProcessors = append(p.traceSpanProcessors, processors...)
+	}
+}
+
+// WithTraceDestination add secondary destinations which spans are reported to, in addition to the workspace of client,
+// e.g. the workspace of a team and the one of central observability. Every destination has its own queues and
+// exporter, so a slow or failing destination does not block or drop the spans of the others. Stats and the report of
+// Shutdown only count the spans reported to the workspace of client.
+func WithTraceDestination(destinations ...*TraceDestination) Option {
+	return func(p *options) {
+		p.traceDestinations = append(p.traceDestinations, destinations...)
+	}
+}
+
+// WithSpanConsumer add consumers of the snapshots of finished spans, such as mirroring spans to a data lake. They
+// are invoked in order by the span processors added, see WithSpanProcessor. Consumers are called synchronously in
+// Span.Finish, they should not block, e.g. send the spans to a buffered channel.
+func WithSpanConsumer(consumers ...SpanConsumer) Option {
+	return func(p *options) {
+		for _, consumer := range consumers {
+			if processor := trace.NewSpanConsumerProcessor(consumer); processor != nil {
+				p.traceSpanProcessors = append(p.traceSpanProcessors, processor)
+			}
+		}
+	}
+}
+
+// WithDebugExporter print spans in readable format to conf.Writer, which is stdout by default, for validating
+// instrumentation locally. Spans are only printed unless conf.AlsoReport is true, and workspace id and auth are
+// not required in that case. It can also be enabled by env COZELOOP_DEBUG=1, or COZELOOP_DEBUG=report to
+// report spans as well, and COZELOOP_DEBUG_FILE to print to file. Default is nil, which disables it.
+func WithDebugExporter(conf *DebugConf) Option {
+	return func(p *options) {
+		if conf == nil {
+			conf = &DebugConf{}
+		}
+		p.traceDebug = conf
+	}
+}
+
+// WithResourceAttributes set the attributes of the service, such as service.name, service.version, env and region,
+// which are attached to the system tags of every span, so that spans can be filtered by them. It is merged if set
+// multiple times. It can also be set by env COZELOOP_RESOURCE_ATTRIBUTES in format of "key1=value1,key2=value2".
+// Default is nil.
+func WithResourceAttributes(attributes map[string]string) Option {
+	return func(p *options) {
+		if len(attributes) == 0 {
+			return
+		}
+		if p.traceResourceAttributes == nil {
+			p.traceResourceAttributes = make(map[string]string, len(attributes))
+		}
+		for key, value := range attributes {
+			p.traceResourceAttributes[key] = value
+		}
+	}
+}
+
 // GetWorkspaceID return space id
 func GetWorkspaceID() string {
 	return getDefaultClient().GetWorkspaceID()
@@ -320,11 +1132,32 @@ func Close(ctx context.Context) {
 	getDefaultClient().Close(ctx)
 }
 
+// Shutdown close the client like Close, and returns the report of spans flushed, dropped and pending.
+// Spans not reported before ctx done are pending and lost.
+func Shutdown(ctx context.Context) (*ShutdownReport, error) {
+	return getDefaultClient().Shutdown(ctx)
+}
+
+// Stats return the statistics of prompt cache and span reporting at the moment, for monitoring the SDK.
+func Stats() ClientStats {
+	return getDefaultClient().Stats()
+}
+
+// UpdateConfig updates the options set in update at runtime, see ConfigUpdate.
+func UpdateConfig(update ConfigUpdate) error {
+	return getDefaultClient().UpdateConfig(update)
+}
+
 // GetPrompt get prompt by prompt key and version
 func GetPrompt(ctx context.Context, param GetPromptParam, options ...GetPromptOption) (*entity.Prompt, error) {
 	return getDefaultClient().GetPrompt(ctx, param, options...)
 }
 
+// MGetPrompts get prompts of params in batch, keyed by the params
+func MGetPrompts(ctx context.Context, params []GetPromptParam, options ...GetPromptOption) (map[GetPromptParam]*entity.Prompt, error) {
+	return getDefaultClient().MGetPrompts(ctx, params, options...)
+}
+
 // PromptFormat format prompt with variables
 func PromptFormat(ctx context.Context, prompt *entity.Prompt, variables map[string]any, options ...PromptFormatOption) (
 	messages []*entity.Message, err error,
@@ -332,6 +1165,26 @@ func PromptFormat(ctx context.Context, prompt *entity.Prompt, variables map[stri
 	return getDefaultClient().PromptFormat(ctx, prompt, variables, options...)
 }
 
+// PromptFormatPartial format prompt with the variables which are ready, and return the unresolved variables and
+// placeholders, which are kept in messages so that they can be formatted again later.
+func PromptFormatPartial(ctx context.Context, prompt *entity.Prompt, variables map[string]any, options ...PromptFormatOption) (
+	*entity.PartialFormatResult, error,
+) {
+	return getDefaultClient().PromptFormatPartial(ctx, prompt, variables, options...)
+}
+
+// ComparePromptVersions get the two versions of prompt, format both with the same variables, and return the diff of them
+func ComparePromptVersions(ctx context.Context, promptKey, versionA, versionB string, variables map[string]any,
+	options ...PromptFormatOption,
+) (*entity.PromptVersionDiff, error) {
+	return getDefaultClient().ComparePromptVersions(ctx, promptKey, versionA, versionB, variables, options...)
+}
+
+// ListPromptVersions list all committed versions of prompt with labels, description and commit time, the latest first.
+func ListPromptVersions(ctx context.Context, promptKey string) ([]*entity.PromptVersion, error) {
+	return getDefaultClient().ListPromptVersions(ctx, promptKey)
+}
+
 // StartSpan Generate a span that automatically links to the previous span in the context.
 // The start time of the span starts counting from the call of StartSpan.
 // The generated span will be automatically written into the context.
@@ -340,6 +1193,38 @@ func StartSpan(ctx context.Context, name, spanType string, opts ...StartSpanOpti
 	return getDefaultClient().StartSpan(ctx, name, spanType, opts...)
 }
 
+// WithSpan Start a span named name with spanType, and run fn with the context carrying the span.
+// The error returned by fn is recorded on the span, and the span is always finished after fn returns.
+// If fn panics, the panic is recorded on the span, and re-panicked after the span is finished.
+func WithSpan(ctx context.Context, name, spanType string, fn func(ctx context.Context) error, opts ...StartSpanOption) (err error) {
+	ctx, span := StartSpan(ctx, name, spanType, opts...)
+	defer RecoverSpan(ctx, span)
+	if err = fn(ctx); err != nil {
+		span.SetStatusCode(ctx, util.GetErrorCode(err))
+		span.SetError(ctx, err)
+	}
+	return err
+}
+
+// RecoverSpan Finish the span, and if the goroutine is panicking, record the panic message and stack
+// trace on the span before finishing it, then re-panic. It must be called directly by defer, like:
+//
+//	ctx, span := cozeloop.StartSpan(ctx, "name", "type")
+//	defer cozeloop.RecoverSpan(ctx, span)
+func RecoverSpan(ctx context.Context, span Span) {
+	if r := recover(); r != nil {
+		span.SetTags(ctx, map[string]interface{}{
+			consts.Panic:      fmt.Sprint(r),
+			consts.PanicStack: string(debug.Stack()),
+		})
+		span.SetStatusCode(ctx, consts.StatusCodeErrorDefault)
+		span.SetError(ctx, fmt.Errorf("panic: %v", r))
+		span.Finish(ctx)
+		panic(r)
+	}
+	span.Finish(ctx)
+}
+
 // GetSpanFromContext Get the span from the context.
 func GetSpanFromContext(ctx context.Context) Span {
 	return getDefaultClient().GetSpanFromContext(ctx)
@@ -355,7 +1240,26 @@ func Flush(ctx context.Context) {
 	getDefaultClient().Flush(ctx)
 }
 
+// ImportSpans Report spans of the past with the ids and times provided, see ImportedSpan.
+func ImportSpans(ctx context.Context, spans []*ImportedSpan) error {
+	return getDefaultClient().ImportSpans(ctx, spans)
+}
+
+// ReportFeedback Report end-user feedback, such as thumbs-up/down, rating and comment, of the specified span.
+func ReportFeedback(ctx context.Context, traceID, spanID string, feedback *entity.Feedback) error {
+	return getDefaultClient().ReportFeedback(ctx, traceID, spanID, feedback)
+}
+
+// AnnotateSpan Attach tags, such as human review status and resolution code, to the span which has been reported.
+// The values of tags must be string, bool or number.
+func AnnotateSpan(ctx context.Context, traceID, spanID string, tags map[string]any) error {
+	return getDefaultClient().AnnotateSpan(ctx, traceID, spanID, tags)
+}
+
 func buildOptionsFromEnv(opts *options) {
+	if region := os.Getenv(EnvRegion); region != "" {
+		WithRegion(Region(region))(opts)
+	}
 	if baseURL := os.Getenv(EnvApiBaseURL); baseURL != "" {
 		opts.apiBaseURL = baseURL
 	}
@@ -375,13 +1279,56 @@ func buildOptionsFromEnv(opts *options) {
 	if jwtOAuthPublicKeyID := os.Getenv(EnvJwtOAuthPublicKeyID); jwtOAuthPublicKeyID != "" {
 		opts.jwtOAuthPublicKeyID = jwtOAuthPublicKeyID
 	}
+	if debug := os.Getenv(EnvDebug); debug != "" && debug != "0" && debug != "false" {
+		opts.traceDebug = &DebugConf{AlsoReport: debug == DebugModeReport}
+	}
+	if attributes := os.Getenv(EnvResourceAttributes); attributes != "" {
+		resourceAttributes := make(map[string]string)
+		for _, attribute := range strings.Split(attributes, ",") {
+			if key, value, ok := strings.Cut(attribute, "="); ok && strings.TrimSpace(key) != "" {
+				resourceAttributes[strings.TrimSpace(key)] = strings.TrimSpace(value)
+			}
+		}
+		WithResourceAttributes(resourceAttributes)(opts)
+	}
+	if debugFile := os.Getenv(EnvDebugFile); debugFile != "" {
+		opts.traceDebugFile = debugFile
+		if opts.traceDebug == nil {
+			opts.traceDebug = &DebugConf{}
+		}
+	}
+	if disabled := os.Getenv(EnvDisabled); disabled == "1" || disabled == "true" {
+		opts.disabled = true
+	}
+	if fixtureDir := os.Getenv(EnvPromptFixtureDir); fixtureDir != "" {
+		opts.promptFixtureDir = fixtureDir
+	}
+	if localPromptDir := os.Getenv(EnvLocalPromptDir); localPromptDir != "" {
+		opts.localPromptDir = localPromptDir
+	}
+	if captureContent := os.Getenv(EnvCaptureContent); captureContent == "0" || captureContent == "false" {
+		opts.traceCaptureContent = false
+	}
+	if tagLint := os.Getenv(EnvTagLint); tagLint == "1" || tagLint == "true" {
+		opts.traceTagLint = &TagLintConf{}
+	}
+	if sampleRatio := os.Getenv(EnvTraceSampleRatio); sampleRatio != "" {
+		if ratio, err := strconv.ParseFloat(sampleRatio, 64); err == nil {
+			opts.traceSampleRatio = ratio
+		} else {
+			logger.CtxWarnf(context.Background(), "invalid %s: %q, ignored", EnvTraceSampleRatio, sampleRatio)
+		}
+	}
 }
 
 func checkOptions(opts *options) error {
+	if _, ok := regionBaseURLs[opts.region]; !ok && opts.region != "" && opts.apiBaseURL == "" {
+		return ErrInvalidParam.Wrap(fmt.Errorf("unknown region: %s", opts.region))
+	}
 	if opts.apiBaseURL == "" {
 		return ErrInvalidParam.Wrap(errors.New("apiBaseURL is required"))
 	}
-	if opts.workspaceID == "" {
+	if opts.workspaceID == "" && !opts.debugOnly() {
 		return ErrInvalidParam.Wrap(errors.New("workspaceID is required"))
 	}
 	if opts.httpClient == nil {
@@ -393,10 +1340,24 @@ func checkOptions(opts *options) error {
 	if opts.promptCacheRefreshInterval < 0 {
 		opts.promptCacheRefreshInterval = consts.DefaultPromptCacheRefreshInterval
 	}
+	if !validSampleRatio(opts.traceSampleRatio) {
+		return ErrInvalidParam.Wrap(fmt.Errorf("trace sample ratio should be in [0, 1]: %v", opts.traceSampleRatio))
+	}
+	for i, dest := range opts.traceDestinations {
+		if dest == nil || (dest.WorkspaceID == "" && dest.Exporter == nil) {
+			return ErrInvalidParam.Wrap(fmt.Errorf("trace destination %d requires workspace id or exporter", i))
+		}
+	}
+	if !opts.traceWireFormat.IsValid() {
+		return ErrInvalidParam.Wrap(fmt.Errorf("unknown trace wire format: %s", opts.traceWireFormat))
+	}
 	return nil
 }
 
 func buildAuth(opts options) (httpclient.Auth, error) {
+	if opts.authProvider != nil {
+		return opts.authProvider, nil
+	}
 	if opts.jwtOAuthClientID != "" && opts.jwtOAuthPrivateKey != "" && opts.jwtOAuthPublicKeyID != "" {
 		oauthClient, err := httpclient.NewJWTOAuthClient(httpclient.NewJWTOAuthClientParam{
 			ClientID:      opts.jwtOAuthClientID,
@@ -408,12 +1369,33 @@ func buildAuth(opts options) (httpclient.Auth, error) {
 		}
 		return httpclient.NewJWTAuth(oauthClient, nil), nil
 	}
+	if opts.oauthDeviceClientID != "" {
+		deviceClient, err := httpclient.NewDeviceOAuthClient(opts.oauthDeviceClientID,
+			httpclient.WithAuthBaseURL(opts.apiBaseURL), httpclient.WithAuthHttpClient(opts.httpClient))
+		if err != nil {
+			return nil, err
+		}
+		deviceOptions := &httpclient.DeviceOAuthOptions{
+			OnDeviceCode: opts.oauthDeviceCodeHandler,
+		}
+		if opts.oauthTokenFile != "" {
+			deviceOptions.TokenStore = httpclient.NewFileTokenStore(opts.oauthTokenFile)
+		}
+		return httpclient.NewDeviceOAuth(deviceClient, deviceOptions), nil
+	}
 	if opts.apiToken != "" {
 		return httpclient.NewTokenAuth(opts.apiToken), nil
 	}
 	return nil, ErrAuthInfoRequired
 }
 
+// debugAuth is used in debug mode without auth info, so that client can be created to print spans locally.
+type debugAuth struct{}
+
+func (debugAuth) Token(ctx context.Context) (string, error) {
+	return "", ErrAuthInfoRequired
+}
+
 func createTraceHeaderEnricher() func(ctx context.Context, req *http.Request) {
 	return func(ctx context.Context, req *http.Request) {
 		span := GetSpanFromContext(ctx)
@@ -431,6 +1413,8 @@ func createTraceHeaderEnricher() func(ctx context.Context, req *http.Request) {
 	}
 }
 
+// SetDefaultClient set the client which backs the package-level functions, such as StartSpan and GetPrompt.
+// The previous default client is not closed.
 func SetDefaultClient(client Client) {
 	defaultClientLock.Lock()
 	defer defaultClientLock.Unlock()
@@ -438,37 +1422,25 @@ func SetDefaultClient(client Client) {
 }
 
 func getDefaultClient() Client {
-	if defaultClient != nil {
-		return defaultClient
+	defaultClientLock.RLock()
+	client := defaultClient
+	defaultClientLock.RUnlock()
+	if client != nil {
+		return client
 	}
 	once.Do(func() {
-		var err error
-		client, err := NewClient()
+		// NewClient sets the client as default client if there is no default client
+		_, err := NewClient()
 		if err != nil {
 			defaultClientLock.Lock()
-			defaultClient = &NoopClient{newClientError: err}
+			if defaultClient == nil {
+				defaultClient = &NoopClient{newClientError: err}
+			}
 			defaultClientLock.Unlock()
-		} else {
-			defaultClientLock.Lock()
-			defaultClient = client
-			defaultClientLock.Unlock()
-			sigChan := make(chan os.Signal, 1)
-			signal.Notify(sigChan, syscall.SIGINT, syscall.SIGTERM)
-			go func() {
-				sig := <-sigChan
-				ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
-				defer cancel()
-
-				logger.CtxInfof(ctx, "Received signal: %v, starting graceful shutdown...", sig)
-				defaultClient.Close(ctx)
-				defaultClientLock.Lock()
-				defaultClient = &NoopClient{newClientError: consts.ErrClientClosed}
-				defaultClientLock.Unlock()
-				logger.CtxInfof(ctx, "Graceful shutdown finished.")
-				os.Exit(0)
-			}()
 		}
 	})
+	defaultClientLock.RLock()
+	defer defaultClientLock.RUnlock()
 	return defaultClient
 }
 
@@ -482,10 +1454,32 @@ var (
 type loopClient struct {
 	traceProvider  *trace.Provider
 	promptProvider *prompt.Provider
+	evalProvider   *eval.Provider
 
 	workspaceID string
+	// cacheKey key of the client in clientCache, empty if the client is not cached
+	cacheKey string
+
+	// state lifecycle state of client, see clientRunning, clientClosing and clientClosed
+	state int32
+	// flushLock is held for reading by in-flight Flush calls, and for writing by Shutdown, so that spans are not
+	// flushed to the processor being shut down
+	flushLock sync.RWMutex
+	// shutdownDone is closed when the client is closed
+	shutdownDone chan struct{}
+	// pooledClient the http client created by SDK for WithConnPoolConf, whose idle connections are closed by Shutdown
+	pooledClient *http.Client
+}
 
-	closed bool
+const (
+	clientRunning int32 = iota
+	// clientClosing Shutdown is in progress, new calls are rejected as the client is closed
+	clientClosing
+	clientClosed
+)
+
+func (c *loopClient) isRunning() bool {
+	return atomic.LoadInt32(&c.state) == clientRunning
 }
 
 func (c *loopClient) GetWorkspaceID() string {
@@ -493,15 +1487,71 @@ func (c *loopClient) GetWorkspaceID() string {
 }
 
 func (c *loopClient) Close(ctx context.Context) {
-	if c.closed {
-		return
+	_, _ = c.Shutdown(ctx)
+}
+
+// Shutdown closes the client once, it is safe to be called concurrently. The calls other than the first one wait
+// until the client is closed or ctx is done, and return ErrClientClosed.
+func (c *loopClient) Shutdown(ctx context.Context) (*ShutdownReport, error) {
+	if !atomic.CompareAndSwapInt32(&c.state, clientRunning, clientClosing) {
+		select {
+		case <-c.shutdownDone:
+		case <-ctx.Done():
+		}
+		return nil, consts.ErrClientClosed
 	}
-	c.traceProvider.CloseTrace(ctx)
-	c.closed = true
+	defer func() {
+		atomic.StoreInt32(&c.state, clientClosed)
+		close(c.shutdownDone)
+	}()
+	if c.cacheKey != "" {
+		// a closed client should not be returned by NewClient
+		if cached, ok := clientCache.Load(c.cacheKey); ok && cached == c {
+			clientCache.Delete(c.cacheKey)
+		}
+	}
+	// wait for in-flight Flush calls
+	c.flushLock.Lock()
+	defer c.flushLock.Unlock()
+	report, err := c.traceProvider.CloseTrace(ctx)
+	c.promptProvider.Close()
+	if c.pooledClient != nil {
+		c.pooledClient.CloseIdleConnections()
+	}
+	return report, err
+}
+
+func (c *loopClient) Stats() ClientStats {
+	return ClientStats{
+		Prompt: c.promptProvider.Stats(),
+		Trace:  c.traceProvider.Stats(),
+	}
+}
+
+func (c *loopClient) UpdateConfig(update ConfigUpdate) error {
+	if !c.isRunning() {
+		return consts.ErrClientClosed
+	}
+	if err := update.check(); err != nil {
+		return err
+	}
+	if update.LogLevel != nil {
+		SetLogLevel(*update.LogLevel)
+	}
+	if update.TraceSampleRatio != nil {
+		c.traceProvider.SetSampleRatio(*update.TraceSampleRatio)
+	}
+	if update.CaptureContent != nil {
+		c.traceProvider.SetCaptureContent(*update.CaptureContent)
+	}
+	if update.PromptCacheRefreshInterval != nil {
+		c.promptProvider.SetCacheRefreshInterval(*update.PromptCacheRefreshInterval)
+	}
+	return nil
 }
 
 func (c *loopClient) GetPrompt(ctx context.Context, param GetPromptParam, options ...GetPromptOption) (*entity.Prompt, error) {
-	if c.closed {
+	if !c.isRunning() {
 		return nil, consts.ErrClientClosed
 	}
 	config := prompt.GetPromptOptions{}
@@ -511,8 +1561,19 @@ func (c *loopClient) GetPrompt(ctx context.Context, param GetPromptParam, option
 	return c.promptProvider.GetPrompt(ctx, param, config)
 }
 
+func (c *loopClient) MGetPrompts(ctx context.Context, params []GetPromptParam, options ...GetPromptOption) (map[GetPromptParam]*entity.Prompt, error) {
+	if !c.isRunning() {
+		return nil, consts.ErrClientClosed
+	}
+	config := prompt.GetPromptOptions{}
+	for _, opt := range options {
+		opt(&config)
+	}
+	return c.promptProvider.MGetPrompts(ctx, params, config)
+}
+
 func (c *loopClient) PromptFormat(ctx context.Context, loopPrompt *entity.Prompt, variables map[string]any, options ...PromptFormatOption) (messages []*entity.Message, err error) {
-	if c.closed {
+	if !c.isRunning() {
 		return nil, consts.ErrClientClosed
 	}
 	config := prompt.PromptFormatOptions{}
@@ -522,22 +1583,53 @@ func (c *loopClient) PromptFormat(ctx context.Context, loopPrompt *entity.Prompt
 	return c.promptProvider.PromptFormat(ctx, loopPrompt, variables, config)
 }
 
+func (c *loopClient) PromptFormatPartial(ctx context.Context, loopPrompt *entity.Prompt, variables map[string]any, options ...PromptFormatOption) (*entity.PartialFormatResult, error) {
+	if !c.isRunning() {
+		return nil, consts.ErrClientClosed
+	}
+	config := prompt.PromptFormatOptions{}
+	for _, opt := range options {
+		opt(&config)
+	}
+	return c.promptProvider.PromptFormatPartial(ctx, loopPrompt, variables, config)
+}
+
+func (c *loopClient) ComparePromptVersions(ctx context.Context, promptKey, versionA, versionB string, variables map[string]any,
+	options ...PromptFormatOption,
+) (*entity.PromptVersionDiff, error) {
+	if !c.isRunning() {
+		return nil, consts.ErrClientClosed
+	}
+	config := prompt.PromptFormatOptions{}
+	for _, opt := range options {
+		opt(&config)
+	}
+	return c.promptProvider.ComparePromptVersions(ctx, promptKey, versionA, versionB, variables, config)
+}
+
+func (c *loopClient) ListPromptVersions(ctx context.Context, promptKey string) ([]*entity.PromptVersion, error) {
+	if !c.isRunning() {
+		return nil, consts.ErrClientClosed
+	}
+	return c.promptProvider.ListPromptVersions(ctx, promptKey)
+}
+
 func (c *loopClient) Execute(ctx context.Context, req *entity.ExecuteParam, options ...ExecuteOption) (entity.ExecuteResult, error) {
-	if c.closed {
+	if !c.isRunning() {
 		return entity.ExecuteResult{}, consts.ErrClientClosed
 	}
 	return c.promptProvider.Execute(ctx, req, options...)
 }
 
 func (c *loopClient) ExecuteStreaming(ctx context.Context, req *entity.ExecuteParam, options ...ExecuteStreamingOption) (entity.StreamReader[entity.ExecuteResult], error) {
-	if c.closed {
+	if !c.isRunning() {
 		return nil, consts.ErrClientClosed
 	}
 	return c.promptProvider.ExecuteStreaming(ctx, req, options...)
 }
 
 func (c *loopClient) StartSpan(ctx context.Context, name, spanType string, opts ...StartSpanOption) (context.Context, Span) {
-	if c.closed {
+	if !c.isRunning() {
 		return ctx, DefaultNoopSpan
 	}
 	config := trace.StartSpanOptions{}
@@ -556,7 +1648,7 @@ func (c *loopClient) StartSpan(ctx context.Context, name, spanType string, opts
 }
 
 func (c *loopClient) GetSpanFromContext(ctx context.Context) Span {
-	if c.closed {
+	if !c.isRunning() {
 		return DefaultNoopSpan
 	}
 	span := c.traceProvider.GetSpanFromContext(ctx)
@@ -567,15 +1659,144 @@ func (c *loopClient) GetSpanFromContext(ctx context.Context) Span {
 }
 
 func (c *loopClient) GetSpanFromHeader(ctx context.Context, header map[string]string) SpanContext {
-	if c.closed {
+	if !c.isRunning() {
 		return DefaultNoopSpan
 	}
 	return c.traceProvider.GetSpanFromHeader(ctx, header)
 }
 
 func (c *loopClient) Flush(ctx context.Context) {
-	if c.closed {
+	c.flushLock.RLock()
+	defer c.flushLock.RUnlock()
+	if !c.isRunning() {
 		return
 	}
 	c.traceProvider.Flush(ctx)
 }
+
+func (c *loopClient) ImportSpans(ctx context.Context, spans []*ImportedSpan) error {
+	if !c.isRunning() {
+		return consts.ErrClientClosed
+	}
+	return c.traceProvider.ImportSpans(ctx, spans)
+}
+
+func (c *loopClient) ReportFeedback(ctx context.Context, traceID, spanID string, feedback *entity.Feedback) error {
+	if !c.isRunning() {
+		return consts.ErrClientClosed
+	}
+	return c.traceProvider.ReportFeedback(ctx, traceID, spanID, feedback)
+}
+
+func (c *loopClient) AnnotateSpan(ctx context.Context, traceID, spanID string, tags map[string]any) error {
+	if !c.isRunning() {
+		return consts.ErrClientClosed
+	}
+	return c.traceProvider.AnnotateSpan(ctx, traceID, spanID, tags)
+}
+
+func (c *loopClient) ListSpans(ctx context.Context, param *entity.ListSpansParam) (*entity.ListSpansResult, error) {
+	if !c.isRunning() {
+		return nil, consts.ErrClientClosed
+	}
+	return c.traceProvider.ListSpans(ctx, param)
+}
+
+func (c *loopClient) CreateEvalDataset(ctx context.Context, param *entity.CreateEvalDatasetParam) (*entity.EvalDataset, error) {
+	if !c.isRunning() {
+		return nil, consts.ErrClientClosed
+	}
+	return c.evalProvider.CreateDataset(ctx, param)
+}
+
+func (c *loopClient) UploadEvalItems(ctx context.Context, datasetID string, items []*entity.EvalItem) error {
+	if !c.isRunning() {
+		return consts.ErrClientClosed
+	}
+	return c.evalProvider.UploadItems(ctx, datasetID, items)
+}
+
+func (c *loopClient) RunEvaluation(ctx context.Context, param *entity.RunEvaluationParam) (*entity.EvalRun, error) {
+	if !c.isRunning() {
+		return nil, consts.ErrClientClosed
+	}
+	return c.evalProvider.RunEvaluation(ctx, param)
+}
+
+func (c *loopClient) GetEvalRun(ctx context.Context, runID string) (*entity.EvalRun, error) {
+	if !c.isRunning() {
+		return nil, consts.ErrClientClosed
+	}
+	return c.evalProvider.GetEvalRun(ctx, runID)
+}
+
+func (c *loopClient) WaitEvalRun(ctx context.Context, runID string, options ...WaitEvalRunOption) (*entity.EvalRun, error) {
+	if !c.isRunning() {
+		return nil, consts.ErrClientClosed
+	}
+	config := eval.WaitEvalRunOptions{}
+	for _, opt := range options {
+		opt(&config)
+	}
+	return c.evalProvider.WaitEvalRun(ctx, runID, config)
+}
+
+func (c *loopClient) ListEvalResults(ctx context.Context, param *entity.ListEvalResultsParam) (*entity.ListEvalResultsResult, error) {
+	if !c.isRunning() {
+		return nil, consts.ErrClientClosed
+	}
+	return c.evalProvider.ListEvalResults(ctx, param)
+}
+
+func (c *loopClient) SubmitPromptOptimization(ctx context.Context, param *entity.SubmitPromptOptimizationParam) (*entity.PromptOptimizationRun, error) {
+	if !c.isRunning() {
+		return nil, consts.ErrClientClosed
+	}
+	return c.evalProvider.SubmitPromptOptimization(ctx, param)
+}
+
+func (c *loopClient) GetPromptOptimization(ctx context.Context, runID string) (*entity.PromptOptimizationRun, error) {
+	if !c.isRunning() {
+		return nil, consts.ErrClientClosed
+	}
+	return c.evalProvider.GetPromptOptimization(ctx, runID)
+}
+
+func (c *loopClient) WaitPromptOptimization(ctx context.Context, runID string, options ...WaitEvalRunOption) (*entity.PromptOptimizationRun, error) {
+	if !c.isRunning() {
+		return nil, consts.ErrClientClosed
+	}
+	config := eval.WaitEvalRunOptions{}
+	for _, opt := range options {
+		opt(&config)
+	}
+	return c.evalProvider.WaitPromptOptimization(ctx, runID, config)
+}
+
+func (c *loopClient) CreateDataset(ctx context.Context, param *entity.CreateEvalDatasetParam) (*entity.EvalDataset, error) {
+	if !c.isRunning() {
+		return nil, consts.ErrClientClosed
+	}
+	return c.evalProvider.CreateDataset(ctx, param)
+}
+
+func (c *loopClient) AppendDatasetItems(ctx context.Context, datasetID string, items []*entity.DatasetItem) ([]string, error) {
+	if !c.isRunning() {
+		return nil, consts.ErrClientClosed
+	}
+	return c.evalProvider.AppendItems(ctx, datasetID, items)
+}
+
+func (c *loopClient) ListDatasetItems(ctx context.Context, param *entity.ListDatasetItemsParam) (*entity.ListDatasetItemsResult, error) {
+	if !c.isRunning() {
+		return nil, consts.ErrClientClosed
+	}
+	return c.evalProvider.ListItems(ctx, param)
+}
+
+func (c *loopClient) DeleteDatasetItems(ctx context.Context, datasetID string, itemIDs []string) error {
+	if !c.isRunning() {
+		return consts.ErrClientClosed
+	}
+	return c.evalProvider.DeleteItems(ctx, datasetID, itemIDs)
+}
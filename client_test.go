@@ -4,9 +4,20 @@
 package cozeloop
 
 import (
+	"bytes"
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"sync"
 	"testing"
+	"time"
 
 	. "github.com/smartystreets/goconvey/convey"
+
+	"github.com/coze-dev/cozeloop-go/entity"
+	"github.com/coze-dev/cozeloop-go/internal/consts"
+	"github.com/coze-dev/cozeloop-go/internal/logger"
 )
 
 func TestNewClient(t *testing.T) {
@@ -18,7 +29,392 @@ func TestNewClient(t *testing.T) {
 		client3, err := NewClient(WithWorkspaceID("456"), WithAPIToken("token"))
 		So(err, ShouldBeNil)
 
-		So(client1, ShouldEqual, client2)
-		So(client1, ShouldNotEqual, client3)
+		So(client1 == client2, ShouldBeTrue)
+		So(client1 == client3, ShouldBeFalse)
+	})
+}
+
+func TestNewClientNoCache(t *testing.T) {
+	Convey("new isolated client", t, func() {
+		ctx := context.Background()
+		client1, err := NewClient(WithWorkspaceID("789"), WithAPIToken("token"), WithNoClientCache())
+		So(err, ShouldBeNil)
+		client2, err := NewClient(WithWorkspaceID("789"), WithAPIToken("token"), WithNoClientCache())
+		So(err, ShouldBeNil)
+		So(client1 == client2, ShouldBeFalse)
+		So(getDefaultClient() == client1, ShouldBeFalse)
+
+		defaultClient := getDefaultClient()
+		SetDefaultClient(client1)
+		So(GetWorkspaceID(), ShouldEqual, "789")
+		SetDefaultClient(defaultClient)
+
+		client1.Close(ctx)
+		client2.Close(ctx)
+	})
+
+	Convey("closed client is removed from cache", t, func() {
+		ctx := context.Background()
+		client1, err := NewClient(WithWorkspaceID("1011"), WithAPIToken("token"))
+		So(err, ShouldBeNil)
+		client1.Close(ctx)
+		client2, err := NewClient(WithWorkspaceID("1011"), WithAPIToken("token"))
+		So(err, ShouldBeNil)
+		So(client1 == client2, ShouldBeFalse)
+	})
+
+	Convey("clients with tag lint enabled by env are shared", t, func() {
+		ctx := context.Background()
+		t.Setenv(EnvTagLint, "1")
+		client1, err := NewClient(WithWorkspaceID("1415"), WithAPIToken("token"))
+		So(err, ShouldBeNil)
+		client2, err := NewClient(WithWorkspaceID("1415"), WithAPIToken("token"))
+		So(err, ShouldBeNil)
+		So(client1 == client2, ShouldBeTrue)
+
+		client1.Close(ctx)
+	})
+
+	Convey("clients with equal id normalization confs are shared", t, func() {
+		ctx := context.Background()
+		client1, err := NewClient(WithWorkspaceID("1617"), WithAPIToken("token"),
+			WithTraceIDNormalization(&TraceIDNormalizationConf{VendorPrefixes: []string{"dd"}, PadShortIDs: true}))
+		So(err, ShouldBeNil)
+		client2, err := NewClient(WithWorkspaceID("1617"), WithAPIToken("token"),
+			WithTraceIDNormalization(&TraceIDNormalizationConf{VendorPrefixes: []string{"dd"}, PadShortIDs: true}))
+		So(err, ShouldBeNil)
+		So(client1 == client2, ShouldBeTrue)
+		client3, err := NewClient(WithWorkspaceID("1617"), WithAPIToken("token"),
+			WithTraceIDNormalization(&TraceIDNormalizationConf{VendorPrefixes: []string{"dd"}}))
+		So(err, ShouldBeNil)
+		So(client1 == client3, ShouldBeFalse)
+
+		client1.Close(ctx)
+		client3.Close(ctx)
+	})
+
+	Convey("clients with func options are not shared", t, func() {
+		ctx := context.Background()
+		redactor := func(replacement string) SpanRedactor {
+			return func(span *entity.UploadSpan) {
+				span.Input = replacement
+			}
+		}
+		client1, err := NewClient(WithWorkspaceID("1213"), WithAPIToken("token"), WithSpanRedactor(redactor("a")))
+		So(err, ShouldBeNil)
+		client2, err := NewClient(WithWorkspaceID("1213"), WithAPIToken("token"), WithSpanRedactor(redactor("b")))
+		So(err, ShouldBeNil)
+		So(client1 == client2, ShouldBeFalse)
+
+		client1.Close(ctx)
+		client2.Close(ctx)
+	})
+}
+
+func TestWithSpan(t *testing.T) {
+	Convey("with span returns error of fn", t, func() {
+		ctx := context.Background()
+		var spanInFn Span
+		err := WithSpan(ctx, "with_span", "custom", func(ctx context.Context) error {
+			spanInFn = GetSpanFromContext(ctx)
+			return errors.New("failed")
+		})
+		So(err, ShouldNotBeNil)
+		So(err.Error(), ShouldEqual, "failed")
+		So(spanInFn, ShouldNotBeNil)
+
+		err = WithSpan(ctx, "with_span", "custom", func(ctx context.Context) error {
+			return nil
+		})
+		So(err, ShouldBeNil)
+	})
+}
+
+type recordExporter struct {
+	mu    sync.Mutex
+	spans []*entity.UploadSpan
+}
+
+func (e *recordExporter) ExportSpans(ctx context.Context, spans []*entity.UploadSpan) error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.spans = append(e.spans, spans...)
+	return nil
+}
+
+func (e *recordExporter) ExportFiles(ctx context.Context, files []*entity.UploadFile) error {
+	return nil
+}
+
+func TestRecoverSpan(t *testing.T) {
+	Convey("recover span records panic and re-panics", t, func() {
+		ctx := context.Background()
+		exporter := &recordExporter{}
+		client, err := NewClient(WithWorkspaceID("recover_span"), WithAPIToken("token"), WithExporter(exporter))
+		So(err, ShouldBeNil)
+
+		So(func() {
+			ctx, span := client.StartSpan(ctx, "panic_span", "custom")
+			defer RecoverSpan(ctx, span)
+			panic("boom")
+		}, ShouldPanicWith, "boom")
+
+		client.Flush(ctx)
+		exporter.mu.Lock()
+		defer exporter.mu.Unlock()
+		So(len(exporter.spans), ShouldEqual, 1)
+		So(exporter.spans[0].StatusCode, ShouldEqual, consts.StatusCodeErrorDefault)
+		So(exporter.spans[0].TagsString[consts.Panic], ShouldEqual, "boom")
+		So(exporter.spans[0].TagsString[consts.PanicStack], ShouldNotBeEmpty)
+	})
+}
+
+func TestNewClientDebugMode(t *testing.T) {
+	Convey("Test client in debug mode prints spans without workspace and auth", t, func() {
+		var buf bytes.Buffer
+		client, err := NewClient(WithDebugExporter(&DebugConf{Writer: &buf}), WithNoClientCache())
+		So(err, ShouldBeNil)
+		ctx, span := client.StartSpan(context.Background(), "debug_span", "custom")
+		span.Finish(ctx)
+		client.Close(ctx)
+		So(buf.String(), ShouldContainSubstring, `[cozeloop] span "debug_span"`)
+
+		_, err = client.GetPrompt(ctx, GetPromptParam{PromptKey: "key"})
+		So(err, ShouldNotBeNil)
+	})
+
+	Convey("Test client reporting in debug mode requires workspace", t, func() {
+		_, err := NewClient(WithDebugExporter(&DebugConf{AlsoReport: true}), WithNoClientCache())
+		So(err, ShouldNotBeNil)
+	})
+}
+
+func TestClientStats(t *testing.T) {
+	Convey("Test stats of spans reported by client", t, func() {
+		ctx := context.Background()
+		exporter := &recordExporter{}
+		client, err := NewClient(WithWorkspaceID("123"), WithAPIToken("token"), WithExporter(exporter), WithNoClientCache())
+		So(err, ShouldBeNil)
+		So(client.Stats(), ShouldResemble, ClientStats{})
+
+		spanCtx, span := client.StartSpan(ctx, "span", "custom")
+		span.Finish(spanCtx)
+		client.Flush(ctx)
+		stats := client.Stats()
+		So(stats.Trace.SpansFlushed, ShouldEqual, 1)
+		So(stats.Trace.SpansPending, ShouldEqual, 0)
+		client.Close(ctx)
+	})
+}
+
+func TestClientConcurrentShutdown(t *testing.T) {
+	Convey("Test client is closed once while spans are started and flushed concurrently", t, func() {
+		ctx := context.Background()
+		exporter := &recordExporter{}
+		client, err := NewClient(WithWorkspaceID("123"), WithAPIToken("token"), WithExporter(exporter), WithNoClientCache())
+		So(err, ShouldBeNil)
+
+		var wg sync.WaitGroup
+		for i := 0; i < 10; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				for j := 0; j < 20; j++ {
+					spanCtx, span := client.StartSpan(ctx, "span", "custom")
+					span.Finish(spanCtx)
+					client.Flush(ctx)
+				}
+			}()
+		}
+		reports := make(chan *ShutdownReport, 5)
+		errs := make(chan error, 5)
+		for i := 0; i < 5; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				report, err := client.Shutdown(ctx)
+				reports <- report
+				errs <- err
+			}()
+		}
+		wg.Wait()
+		close(reports)
+		close(errs)
+
+		var closedErrs int
+		for err := range errs {
+			if errors.Is(err, ErrClientClosed) {
+				closedErrs++
+			}
+		}
+		var shutdownReports int
+		for report := range reports {
+			if report != nil {
+				shutdownReports++
+			}
+		}
+		So(closedErrs, ShouldEqual, 4)
+		So(shutdownReports, ShouldEqual, 1)
+
+		_, span := client.StartSpan(ctx, "span", "custom")
+		So(span, ShouldEqual, DefaultNoopSpan)
+		client.Flush(ctx)
+		client.Close(ctx)
+		_, err = client.GetPrompt(ctx, GetPromptParam{PromptKey: "key"})
+		So(errors.Is(err, ErrClientClosed), ShouldBeTrue)
+	})
+}
+
+func TestWithSpanConsumer(t *testing.T) {
+	Convey("Test snapshots of finished spans are consumed", t, func() {
+		ctx := context.Background()
+		var consumed []*ReadOnlySpan
+		client, err := NewClient(WithWorkspaceID("123"), WithAPIToken("token"), WithExporter(&recordExporter{}),
+			WithSpanConsumer(func(ctx context.Context, span *ReadOnlySpan) {
+				consumed = append(consumed, span)
+			}, nil), WithNoClientCache())
+		So(err, ShouldBeNil)
+		defer client.Close(ctx)
+
+		spanCtx, span := client.StartSpan(ctx, "span", "custom")
+		span.SetTags(spanCtx, map[string]interface{}{"key": "value"})
+		span.Finish(spanCtx)
+		So(len(consumed), ShouldEqual, 1)
+		So(consumed[0].SpanID, ShouldEqual, span.GetSpanID())
+		So(consumed[0].TraceID, ShouldEqual, span.GetTraceID())
+		So(consumed[0].WorkspaceID, ShouldEqual, "123")
+		So(consumed[0].SpanName, ShouldEqual, "span")
+		So(consumed[0].Tags["key"], ShouldEqual, "value")
+		So(consumed[0].FinishTime.IsZero(), ShouldBeFalse)
+	})
+}
+
+func TestUpdateConfig(t *testing.T) {
+	Convey("Test options are updated at runtime", t, func() {
+		ctx := context.Background()
+		var consumed []*ReadOnlySpan
+		client, err := NewClient(WithWorkspaceID("123"), WithAPIToken("token"), WithExporter(&recordExporter{}),
+			WithTraceSampleRatio(0), WithSpanConsumer(func(ctx context.Context, span *ReadOnlySpan) {
+				consumed = append(consumed, span)
+			}), WithNoClientCache())
+		So(err, ShouldBeNil)
+		defer client.Close(ctx)
+		startAndFinish := func() {
+			spanCtx, span := client.StartSpan(ctx, "span", "custom")
+			span.SetInput(spanCtx, "hello")
+			span.Finish(spanCtx)
+		}
+
+		startAndFinish()
+		So(consumed, ShouldBeEmpty)
+
+		ratio, captureContent, interval := 1.0, false, time.Minute
+		level := logger.GetLogLevel()
+		defer SetLogLevel(level)
+		So(client.UpdateConfig(ConfigUpdate{
+			LogLevel:                   &[]LogLevel{LogLevelDebug}[0],
+			TraceSampleRatio:           &ratio,
+			PromptCacheRefreshInterval: &interval,
+			CaptureContent:             &captureContent,
+		}), ShouldBeNil)
+		So(logger.GetLogLevel(), ShouldEqual, LogLevelDebug)
+		startAndFinish()
+		So(len(consumed), ShouldEqual, 1)
+		So(consumed[0].Tags["input"], ShouldStartWith, "sha256:")
+
+		invalidRatio := 2.0
+		err = client.UpdateConfig(ConfigUpdate{TraceSampleRatio: &invalidRatio, CaptureContent: &[]bool{true}[0]})
+		So(errors.Is(err, ErrInvalidParam), ShouldBeTrue)
+		startAndFinish()
+		So(consumed[1].Tags["input"], ShouldStartWith, "sha256:")
+
+		_, err = NewClient(WithWorkspaceID("123"), WithAPIToken("token"), WithTraceSampleRatio(-1), WithNoClientCache())
+		So(errors.Is(err, ErrInvalidParam), ShouldBeTrue)
+	})
+}
+
+func TestNewClientDisabled(t *testing.T) {
+	Convey("Test disabled client needs no workspace and auth", t, func() {
+		t.Setenv(EnvDisabled, "1")
+		client, err := NewClient(WithNoClientCache())
+		So(err, ShouldBeNil)
+		ctx, span := client.StartSpan(context.Background(), "span", "custom")
+		So(span, ShouldEqual, DefaultNoopSpan)
+		span.Finish(ctx)
+		client.Flush(ctx)
+
+		_, err = client.GetPrompt(ctx, GetPromptParam{PromptKey: "key"})
+		So(errors.Is(err, ErrClientDisabled), ShouldBeTrue)
+		_, err = client.Execute(ctx, &entity.ExecuteParam{PromptKey: "key"})
+		So(errors.Is(err, ErrClientDisabled), ShouldBeTrue)
+		report, err := client.Shutdown(ctx)
+		So(err, ShouldBeNil)
+		So(report, ShouldNotBeNil)
+	})
+
+	Convey("Test disabled client serves prompts from fixture dir", t, func() {
+		dir := t.TempDir()
+		So(os.WriteFile(filepath.Join(dir, "greeting.json"), []byte(`{"version":"0.0.2","prompt_template":{
+			"template_type":"normal","messages":[{"role":"system","content":"Hello {{name}}"}],
+			"variable_defs":[{"key":"name","type":"string"}]}}`), 0o644), ShouldBeNil)
+		So(os.WriteFile(filepath.Join(dir, "greeting@0.0.1.json"), []byte(`{"version":"0.0.1"}`), 0o644), ShouldBeNil)
+
+		client, err := NewClient(WithDisabled(true), WithPromptFixtureDir(dir), WithWorkspaceID("123"),
+			WithPromptNotFoundError(true), WithNoClientCache())
+		So(err, ShouldBeNil)
+		ctx := context.Background()
+		p, err := client.GetPrompt(ctx, GetPromptParam{PromptKey: "greeting", Label: "beta"})
+		So(err, ShouldBeNil)
+		So(p.PromptKey, ShouldEqual, "greeting")
+		So(p.WorkspaceID, ShouldEqual, "123")
+		So(p.Version, ShouldEqual, "0.0.2")
+		messages, err := client.PromptFormat(ctx, p, map[string]any{"name": "Alice"})
+		So(err, ShouldBeNil)
+		So(len(messages), ShouldEqual, 1)
+		So(*messages[0].Content, ShouldEqual, "Hello Alice")
+
+		p, err = client.GetPrompt(ctx, GetPromptParam{PromptKey: "greeting", Version: "0.0.1"})
+		So(err, ShouldBeNil)
+		So(p.Version, ShouldEqual, "0.0.1")
+
+		_, err = client.GetPrompt(ctx, GetPromptParam{PromptKey: "missing"})
+		So(errors.Is(err, ErrPromptNotFound), ShouldBeTrue)
+	})
+}
+
+func TestNewClientRegion(t *testing.T) {
+	Convey("Test api base url is set by region", t, func() {
+		opts := defaultOptions()
+		opts.workspaceID = "123"
+		So(opts.apiBaseURL, ShouldEqual, CnBaseURL)
+		WithRegion(RegionI18N)(&opts)
+		So(opts.apiBaseURL, ShouldEqual, ComBaseURL)
+		WithAPIBaseURL("https://loop.example.com")(&opts)
+		So(opts.apiBaseURL, ShouldEqual, "https://loop.example.com")
+		So(checkOptions(&opts), ShouldBeNil)
+
+		t.Setenv(EnvRegion, string(RegionI18N))
+		opts = defaultOptions()
+		buildOptionsFromEnv(&opts)
+		So(opts.apiBaseURL, ShouldEqual, ComBaseURL)
+	})
+
+	Convey("Test unknown region is rejected", t, func() {
+		_, err := NewClient(WithWorkspaceID("123"), WithAPIToken("token"), WithRegion("mars"), WithNoClientCache())
+		So(errors.Is(err, ErrInvalidParam), ShouldBeTrue)
+	})
+}
+
+func TestNewClientResourceAttributes(t *testing.T) {
+	Convey("Test resource attributes are merged and read from env", t, func() {
+		t.Setenv(EnvResourceAttributes, "service.name=svc, env = prod,invalid")
+		opts := defaultOptions()
+		buildOptionsFromEnv(&opts)
+		WithResourceAttributes(map[string]string{"service.version": "v1"})(&opts)
+		So(opts.traceResourceAttributes, ShouldResemble, map[string]string{
+			"service.name":    "svc",
+			"env":             "prod",
+			"service.version": "v1",
+		})
 	})
 }
@@ -0,0 +1,68 @@
+// Copyright (c) 2025 Bytedance Ltd. and/or its affiliates
+// SPDX-License-Identifier: MIT
+
+// Command cozeloop-promptgen pulls a prompt and generates Go constants and struct of its variables.
+// The client is configured by environment variables, such as COZELOOP_WORKSPACE_ID and COZELOOP_API_TOKEN.
+//
+// Usage with go:generate:
+//
+//	//go:generate go run github.com/coze-dev/cozeloop-go/cmd/cozeloop-promptgen -key customer_service -version 0.0.1 -out customer_service_prompt.go
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/coze-dev/cozeloop-go"
+	"github.com/coze-dev/cozeloop-go/promptgen"
+)
+
+func main() {
+	var (
+		key         = flag.String("key", "", "key of prompt, required")
+		version     = flag.String("version", "", "version of prompt, default is the latest version")
+		label       = flag.String("label", "", "label of prompt, ignored if version is set")
+		packageName = flag.String("package", os.Getenv("GOPACKAGE"), "package of generated code, default is $GOPACKAGE set by go generate")
+		typeName    = flag.String("type", "", "prefix of generated identifiers, default is the prompt key in CamelCase")
+		out         = flag.String("out", "", "output file, default is stdout")
+	)
+	flag.Parse()
+	if err := run(*key, *version, *label, *out, promptgen.Options{PackageName: *packageName, TypeName: *typeName}); err != nil {
+		fmt.Fprintf(os.Stderr, "cozeloop-promptgen: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+func run(key, version, label, out string, options promptgen.Options) error {
+	if key == "" {
+		return fmt.Errorf("-key is required")
+	}
+	if options.PackageName == "" {
+		return fmt.Errorf("-package is required when not run by go generate")
+	}
+	ctx := context.Background()
+	client, err := cozeloop.NewClient()
+	if err != nil {
+		return fmt.Errorf("new client failed: %w", err)
+	}
+	defer client.Close(ctx)
+
+	prompt, err := client.GetPrompt(ctx, cozeloop.GetPromptParam{PromptKey: key, Version: version, Label: label})
+	if err != nil {
+		return fmt.Errorf("get prompt failed: %w", err)
+	}
+	if prompt == nil {
+		return fmt.Errorf("prompt %s not found", key)
+	}
+	src, err := promptgen.Generate(prompt, options)
+	if err != nil {
+		return err
+	}
+	if out == "" {
+		_, err = os.Stdout.Write(src)
+		return err
+	}
+	return os.WriteFile(out, src, 0o644)
+}
@@ -11,16 +11,52 @@ import (
 const (
 	// environment keys for loop client
 	EnvApiBaseURL          = "COZELOOP_API_BASE_URL"
+	EnvRegion              = "COZELOOP_REGION"
 	EnvWorkspaceID         = "COZELOOP_WORKSPACE_ID"
 	EnvApiToken            = "COZELOOP_API_TOKEN"
 	EnvJwtOAuthClientID    = "COZELOOP_JWT_OAUTH_CLIENT_ID"
 	EnvJwtOAuthPrivateKey  = "COZELOOP_JWT_OAUTH_PRIVATE_KEY"
 	EnvJwtOAuthPublicKeyID = "COZELOOP_JWT_OAUTH_PUBLIC_KEY_ID"
+	// EnvResourceAttributes resource attributes set on every span, in the format of "key1=value1,key2=value2".
+	EnvResourceAttributes = "COZELOOP_RESOURCE_ATTRIBUTES"
+	// EnvDebug enables debug exporter, "1" to print spans only, "report" to print and report spans.
+	EnvDebug = "COZELOOP_DEBUG"
+	// EnvDebugFile file which debug exporter prints spans to, instead of stdout.
+	EnvDebugFile = "COZELOOP_DEBUG_FILE"
+	// EnvDisabled disables the client if it is "1" or "true", see WithDisabled.
+	EnvDisabled = "COZELOOP_DISABLED"
+	// EnvPromptFixtureDir directory of the prompts served by the disabled client, see WithPromptFixtureDir.
+	EnvPromptFixtureDir = "COZELOOP_PROMPT_FIXTURE_DIR"
+	// EnvLocalPromptDir directory of the local prompt files consulted by GetPrompt, see WithLocalPromptDir.
+	EnvLocalPromptDir = "COZELOOP_LOCAL_PROMPT_DIR"
+	// EnvCaptureContent disables capturing the content of input and output if it is "0" or "false", see WithCaptureContent.
+	EnvCaptureContent = "COZELOOP_CAPTURE_CONTENT"
+	// EnvTraceSampleRatio ratio of traces reported, in [0, 1], see WithTraceSampleRatio.
+	EnvTraceSampleRatio = "COZELOOP_TRACE_SAMPLE_RATIO"
+	// EnvTagLint enables the lint of tags with warnings logged if it is "1" or "true", see WithTagLint.
+	EnvTagLint = "COZELOOP_TAG_LINT"
 
-	// ComBaseURL = consts.ComBaseURL
-	CnBaseURL = consts.CnBaseURL
+	DebugModeReport = "report"
+
+	ComBaseURL = consts.ComBaseURL
+	CnBaseURL  = consts.CnBaseURL
 )
 
+// Region region of CozeLoop service, which decides the api base url of prompt and trace requests.
+type Region string
+
+const (
+	// RegionCN CozeLoop in China, whose api base url is CnBaseURL.
+	RegionCN Region = "cn"
+	// RegionI18N CozeLoop overseas, whose api base url is ComBaseURL.
+	RegionI18N Region = "i18n"
+)
+
+var regionBaseURLs = map[Region]string{
+	RegionCN:   CnBaseURL,
+	RegionI18N: ComBaseURL,
+}
+
 // SpanFinishEvent finish inner event
 type SpanFinishEvent consts.SpanFinishEvent
 
@@ -35,9 +71,60 @@ type FinishEventInfo consts.FinishEventInfo
 
 type TagTruncateConf trace.TagTruncateConf
 
+// TruncationPolicy is the policy to deal with the tag value exceeding the size limit.
+type TruncationPolicy = trace.TruncationPolicy
+
+const (
+	TruncationPolicyTruncate   = trace.TruncationPolicyTruncate
+	TruncationPolicyDrop       = trace.TruncationPolicyDrop
+	TruncationPolicyUploadFile = trace.TruncationPolicyUploadFile
+)
+
+// BufferEvictionPolicy decides which spans and files are dropped when the buffered bytes reach the limit,
+// see WithTraceMaxBufferedBytes.
+type BufferEvictionPolicy = trace.BufferEvictionPolicy
+
+const (
+	BufferEvictionPolicyDropNewest = trace.BufferEvictionPolicyDropNewest
+	BufferEvictionPolicyDropOldest = trace.BufferEvictionPolicyDropOldest
+)
+
+// SpanPriority decides which spans survive when the trace queues are full, see WithPriority.
+type SpanPriority = trace.SpanPriority
+
+const (
+	SpanPriorityHigh   = trace.SpanPriorityHigh
+	SpanPriorityNormal = trace.SpanPriorityNormal
+	SpanPriorityLow    = trace.SpanPriorityLow
+)
+
+// TraceWireFormat the encoding of spans reported to CozeLoop, see WithTraceWireFormat.
+type TraceWireFormat = trace.WireFormat
+
+const (
+	TraceWireFormatJSON    = trace.WireFormatJSON
+	TraceWireFormatMsgpack = trace.WireFormatMsgpack
+)
+
 type APIBasePath struct {
 	TraceSpanUploadPath string
 	TraceFileUploadPath string
 }
 
 type TraceQueueConf trace.QueueConf
+
+// TraceDestination a secondary destination which spans are reported to, see WithTraceDestination.
+type TraceDestination struct {
+	// WorkspaceID the workspace the spans are reported to, the workspace of spans is kept if it is empty
+	WorkspaceID string
+	// Exporter exports the spans of destination, the spans are reported to CozeLoop by the client if it is nil
+	Exporter trace.Exporter
+	// QueueConf conf of the queues of destination, the defaults are used if it is nil
+	QueueConf *TraceQueueConf
+}
+
+// ExportDropped the spans or files dropped after the export retries failed, see TraceQueueConf.OnExportDropped.
+type ExportDropped = trace.ExportDropped
+
+// ExportResult the result of exporting a batch of spans or files, see TraceQueueConf.OnExportResult.
+type ExportResult = trace.ExportResult
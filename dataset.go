@@ -0,0 +1,23 @@
+// Copyright (c) 2025 Bytedance Ltd. and/or its affiliates
+// SPDX-License-Identifier: MIT
+
+package cozeloop
+
+import (
+	"context"
+
+	"github.com/coze-dev/cozeloop-go/entity"
+)
+
+// DatasetClient interface of dataset client. Datasets are shared with EvalClient, so that the items appended, such
+// as the samples of production traffic, can be evaluated by RunEvaluation.
+type DatasetClient interface {
+	// CreateDataset create a dataset, which is the same as CreateEvalDataset of EvalClient
+	CreateDataset(ctx context.Context, param *entity.CreateEvalDatasetParam) (*entity.EvalDataset, error)
+	// AppendDatasetItems append items to the dataset and return ids of created items. Items are appended in batches.
+	AppendDatasetItems(ctx context.Context, datasetID string, items []*entity.DatasetItem) ([]string, error)
+	// ListDatasetItems list items of the dataset with pagination
+	ListDatasetItems(ctx context.Context, param *entity.ListDatasetItemsParam) (*entity.ListDatasetItemsResult, error)
+	// DeleteDatasetItems delete items of the dataset by item ids
+	DeleteDatasetItems(ctx context.Context, datasetID string, itemIDs []string) error
+}
@@ -0,0 +1,149 @@
+// Copyright (c) 2025 Bytedance Ltd. and/or its affiliates
+// SPDX-License-Identifier: MIT
+
+package cozeloop
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/coze-dev/cozeloop-go/entity"
+	"github.com/coze-dev/cozeloop-go/internal/consts"
+	"github.com/coze-dev/cozeloop-go/internal/logger"
+	"github.com/coze-dev/cozeloop-go/internal/prompt"
+)
+
+// disabledClient the client created with WithDisabled. It never accesses CozeLoop service, spans are noop and
+// prompts are served from the fixture dir. The APIs not overridden return ErrClientDisabled by NoopClient.
+type disabledClient struct {
+	NoopClient
+	workspaceID         string
+	fixtureDir          string
+	promptNotFoundError bool
+	promptProvider      *prompt.Provider
+}
+
+func newDisabledClient(options options) *disabledClient {
+	fixtureDir := options.promptFixtureDir
+	if fixtureDir == "" {
+		fixtureDir = options.localPromptDir
+	}
+	c := &disabledClient{
+		NoopClient:          NoopClient{newClientError: consts.ErrClientDisabled},
+		workspaceID:         options.workspaceID,
+		fixtureDir:          fixtureDir,
+		promptNotFoundError: options.promptNotFoundError,
+		promptProvider: prompt.NewFormatProvider(prompt.Options{
+			WorkspaceID:         options.workspaceID,
+			PromptCacheMaxCount: options.promptCacheMaxCount,
+			PromptShared:        !options.promptDeepCopy,
+			TemplateFuncs:       options.templateFuncs,
+			Jinja2:              options.promptJinja2Conf,
+			FormatCache:         options.promptFormatCache,
+		}),
+	}
+	logger.CtxInfof(context.Background(), "CozeLoop client is disabled, spans are not reported and prompts are "+
+		"served from fixture dir %q.", c.fixtureDir)
+
+	if !options.noClientCache {
+		defaultClientLock.Lock()
+		if defaultClient == nil {
+			defaultClient = c
+		}
+		defaultClientLock.Unlock()
+	}
+	return c
+}
+
+func (c *disabledClient) GetWorkspaceID() string {
+	return c.workspaceID
+}
+
+func (c *disabledClient) Close(ctx context.Context) {}
+
+func (c *disabledClient) Shutdown(ctx context.Context) (*ShutdownReport, error) {
+	return &ShutdownReport{}, nil
+}
+
+func (c *disabledClient) Stats() ClientStats {
+	return ClientStats{}
+}
+
+// UpdateConfig only updates the log level, as spans are noop and prompts are not cached.
+func (c *disabledClient) UpdateConfig(update ConfigUpdate) error {
+	if err := update.check(); err != nil {
+		return err
+	}
+	if update.LogLevel != nil {
+		SetLogLevel(*update.LogLevel)
+	}
+	return nil
+}
+
+func (c *disabledClient) GetPrompt(ctx context.Context, param GetPromptParam, options ...GetPromptOption) (*entity.Prompt, error) {
+	if c.fixtureDir == "" {
+		return nil, ErrClientDisabled.Wrap(fmt.Errorf("no prompt fixture dir to get prompt %s", param.PromptKey))
+	}
+	p, err := prompt.LoadLocalPrompt(c.fixtureDir, param)
+	if err != nil {
+		return nil, err
+	}
+	if p != nil {
+		if p.WorkspaceID == "" {
+			p.WorkspaceID = c.workspaceID
+		}
+		return p, nil
+	}
+	if c.promptNotFoundError {
+		return nil, ErrPromptNotFound.Wrap(fmt.Errorf("no fixture of prompt %s in %s", param.PromptKey, c.fixtureDir))
+	}
+	return nil, nil
+}
+
+func (c *disabledClient) MGetPrompts(ctx context.Context, params []GetPromptParam, options ...GetPromptOption) (map[GetPromptParam]*entity.Prompt, error) {
+	prompts := make(map[GetPromptParam]*entity.Prompt, len(params))
+	for _, param := range params {
+		p, err := c.GetPrompt(ctx, param, options...)
+		if err != nil {
+			return prompts, err
+		}
+		if p != nil {
+			prompts[param] = p
+		}
+	}
+	return prompts, nil
+}
+
+func (c *disabledClient) PromptFormat(ctx context.Context, loopPrompt *entity.Prompt, variables map[string]any, options ...PromptFormatOption) (messages []*entity.Message, err error) {
+	config := prompt.PromptFormatOptions{}
+	for _, opt := range options {
+		opt(&config)
+	}
+	return c.promptProvider.PromptFormat(ctx, loopPrompt, variables, config)
+}
+
+func (c *disabledClient) PromptFormatPartial(ctx context.Context, loopPrompt *entity.Prompt, variables map[string]any, options ...PromptFormatOption) (*entity.PartialFormatResult, error) {
+	config := prompt.PromptFormatOptions{}
+	for _, opt := range options {
+		opt(&config)
+	}
+	return c.promptProvider.PromptFormatPartial(ctx, loopPrompt, variables, config)
+}
+
+func (c *disabledClient) StartSpan(ctx context.Context, name, spanType string, opts ...StartSpanOption) (context.Context, Span) {
+	return ctx, DefaultNoopSpan
+}
+
+func (c *disabledClient) GetSpanFromContext(ctx context.Context) Span {
+	return DefaultNoopSpan
+}
+
+func (c *disabledClient) GetSpanFromHeader(ctx context.Context, header map[string]string) SpanContext {
+	return DefaultNoopSpan
+}
+
+func (c *disabledClient) Flush(ctx context.Context) {}
+
+func (c *disabledClient) ImportSpans(ctx context.Context, spans []*ImportedSpan) error {
+	return nil
+}
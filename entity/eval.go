@@ -0,0 +1,97 @@
+// Copyright (c) 2025 Bytedance Ltd. and/or its affiliates
+// SPDX-License-Identifier: MIT
+
+package entity
+
+type EvalDataset struct {
+	ID          string `json:"id"`
+	WorkspaceID string `json:"workspace_id"`
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"`
+}
+
+type EvalItem struct {
+	Input           string            `json:"input"`
+	ReferenceOutput string            `json:"reference_output,omitempty"`
+	Extra           map[string]string `json:"extra,omitempty"`
+}
+
+type EvalRunStatus string
+
+const (
+	EvalRunStatusPending EvalRunStatus = "pending"
+	EvalRunStatusRunning EvalRunStatus = "running"
+	EvalRunStatusSuccess EvalRunStatus = "success"
+	EvalRunStatusFailed  EvalRunStatus = "failed"
+)
+
+// IsFinished returns whether the eval run has reached a terminal status.
+func (s EvalRunStatus) IsFinished() bool {
+	return s == EvalRunStatusSuccess || s == EvalRunStatusFailed
+}
+
+type EvalRun struct {
+	ID            string        `json:"id"`
+	WorkspaceID   string        `json:"workspace_id"`
+	DatasetID     string        `json:"dataset_id"`
+	PromptKey     string        `json:"prompt_key"`
+	PromptVersion string        `json:"prompt_version,omitempty"`
+	Status        EvalRunStatus `json:"status"`
+	TotalCount    int           `json:"total_count"`
+	SuccessCount  int           `json:"success_count"`
+	FailCount     int           `json:"fail_count"`
+	ErrMsg        string        `json:"err_msg,omitempty"`
+}
+
+type EvalResult struct {
+	ItemID          string   `json:"item_id"`
+	Input           string   `json:"input"`
+	Output          string   `json:"output"`
+	ReferenceOutput string   `json:"reference_output,omitempty"`
+	Score           *float64 `json:"score,omitempty"`
+	Reason          string   `json:"reason,omitempty"`
+}
+
+type CreateEvalDatasetParam struct {
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"`
+}
+
+type RunEvaluationParam struct {
+	DatasetID  string   `json:"dataset_id"`
+	PromptKey  string   `json:"prompt_key"`
+	Version    string   `json:"version,omitempty"`
+	Label      string   `json:"label,omitempty"`
+	Evaluators []string `json:"evaluators,omitempty"`
+}
+
+type ListEvalResultsParam struct {
+	RunID     string `json:"run_id"`
+	PageToken string `json:"page_token,omitempty"`
+	PageSize  int    `json:"page_size,omitempty"`
+}
+
+type ListEvalResultsResult struct {
+	Results       []*EvalResult `json:"results,omitempty"`
+	NextPageToken string        `json:"next_page_token,omitempty"`
+	HasMore       bool          `json:"has_more"`
+}
+
+type DatasetItem struct {
+	ID             string            `json:"id,omitempty"`
+	Messages       []*Message        `json:"messages,omitempty"`
+	ExpectedOutput *Message          `json:"expected_output,omitempty"`
+	Tags           map[string]string `json:"tags,omitempty"`
+}
+
+type ListDatasetItemsParam struct {
+	DatasetID string `json:"dataset_id"`
+	PageToken string `json:"page_token,omitempty"`
+	PageSize  int    `json:"page_size,omitempty"`
+}
+
+type ListDatasetItemsResult struct {
+	Items         []*DatasetItem `json:"items,omitempty"`
+	NextPageToken string         `json:"next_page_token,omitempty"`
+	HasMore       bool           `json:"has_more"`
+}
@@ -0,0 +1,19 @@
+// Copyright (c) 2025 Bytedance Ltd. and/or its affiliates
+// SPDX-License-Identifier: MIT
+
+package entity
+
+type FeedbackThumbs string
+
+const (
+	FeedbackThumbsUp   FeedbackThumbs = "up"
+	FeedbackThumbsDown FeedbackThumbs = "down"
+)
+
+// Feedback is the end-user feedback of a span. At least one of Thumbs, Score and Comment should be set.
+type Feedback struct {
+	Thumbs  FeedbackThumbs `json:"thumbs,omitempty"`
+	Score   *float64       `json:"score,omitempty"`
+	Comment string         `json:"comment,omitempty"`
+	UserID  string         `json:"user_id,omitempty"`
+}
@@ -0,0 +1,148 @@
+// Copyright (c) 2025 Bytedance Ltd. and/or its affiliates
+// SPDX-License-Identifier: MIT
+
+package entity
+
+import (
+	"unicode/utf8"
+)
+
+const (
+	// estimatedMessageOverheadTokens tokens of the role and separators of a message
+	estimatedMessageOverheadTokens = 4
+	// estimatedImageTokens tokens of an image part, which depends on the size and detail of image in fact
+	estimatedImageTokens = 85
+)
+
+// TrimHistoryOptions options of trimming the chat history before it is passed as placeholder variable.
+type TrimHistoryOptions struct {
+	// MaxTurns max count of turns kept, a turn starts from a user message and includes the replies and tool calls
+	// after it. Default is 0, no limit.
+	MaxTurns int
+	// MaxMessages max count of messages kept, including the system messages. Default is 0, no limit.
+	MaxMessages int
+	// MaxTokens max estimated tokens of messages kept, including the system messages. Default is 0, no limit.
+	MaxTokens int
+	// TokenEstimator estimates the tokens of a message. Default is EstimateMessageTokens.
+	TokenEstimator func(message *Message) int
+	// Summarize returns the message to replace the dropped turns, such as a summary of them, which is inserted
+	// after the system messages. It is only called if some turns are dropped, and nothing is inserted if it returns
+	// nil. The summary is not counted in MaxMessages and MaxTokens, so leave room for it. Default is nil.
+	Summarize func(dropped []*Message) *Message
+}
+
+// TrimHistory trims the chat history to the limits of options, the oldest turns are dropped first. The system
+// messages are always kept, and turns are dropped as a whole, so tool results are never separated from the tool
+// calls. The latest turn is always kept even if it exceeds the limits. The messages passed in are not modified.
+func TrimHistory(messages []*Message, options TrimHistoryOptions) []*Message {
+	if len(messages) == 0 {
+		return messages
+	}
+	estimate := options.TokenEstimator
+	if estimate == nil {
+		estimate = EstimateMessageTokens
+	}
+
+	var system []*Message
+	var turns [][]*Message
+	for _, message := range messages {
+		if message == nil {
+			continue
+		}
+		switch {
+		case message.Role == RoleSystem:
+			system = append(system, message)
+		case message.Role == RoleUser || len(turns) == 0:
+			turns = append(turns, []*Message{message})
+		default:
+			turns[len(turns)-1] = append(turns[len(turns)-1], message)
+		}
+	}
+
+	count, tokens := len(system), 0
+	if options.MaxTokens > 0 {
+		for _, message := range system {
+			tokens += estimate(message)
+		}
+	}
+	kept := 0
+	for i := len(turns) - 1; i >= 0; i-- {
+		turnTokens := 0
+		if options.MaxTokens > 0 {
+			for _, message := range turns[i] {
+				turnTokens += estimate(message)
+			}
+		}
+		if kept > 0 {
+			if (options.MaxTurns > 0 && kept >= options.MaxTurns) ||
+				(options.MaxMessages > 0 && count+len(turns[i]) > options.MaxMessages) ||
+				(options.MaxTokens > 0 && tokens+turnTokens > options.MaxTokens) {
+				break
+			}
+		}
+		kept++
+		count += len(turns[i])
+		tokens += turnTokens
+	}
+
+	result := make([]*Message, 0, count+1)
+	result = append(result, system...)
+	dropped := turns[:len(turns)-kept]
+	if len(dropped) > 0 && options.Summarize != nil {
+		var droppedMessages []*Message
+		for _, turn := range dropped {
+			droppedMessages = append(droppedMessages, turn...)
+		}
+		if summary := options.Summarize(droppedMessages); summary != nil {
+			result = append(result, summary)
+		}
+	}
+	for _, turn := range turns[len(turns)-kept:] {
+		result = append(result, turn...)
+	}
+	return result
+}
+
+// EstimateMessageTokens estimates the tokens of message without a tokenizer. An ASCII character is counted as 1/4
+// token and the others as 1 token, which is close to the tokenizers of most models for English and Chinese text.
+func EstimateMessageTokens(message *Message) int {
+	if message == nil {
+		return 0
+	}
+	tokens := estimatedMessageOverheadTokens + estimateTextTokens(message.ReasoningContent) +
+		estimateTextTokens(message.Content)
+	for _, part := range message.Parts {
+		if part == nil {
+			continue
+		}
+		switch part.Type {
+		case ContentTypeImageURL, ContentTypeBase64Data:
+			tokens += estimatedImageTokens
+		default:
+			tokens += estimateTextTokens(part.Text)
+		}
+	}
+	for _, toolCall := range message.ToolCalls {
+		if toolCall == nil || toolCall.FunctionCall == nil {
+			continue
+		}
+		name := toolCall.FunctionCall.Name
+		tokens += estimateTextTokens(&name) + estimateTextTokens(toolCall.FunctionCall.Arguments)
+	}
+	return tokens
+}
+
+func estimateTextTokens(text *string) int {
+	if text == nil || *text == "" {
+		return 0
+	}
+	ascii, others := 0, 0
+	for _, r := range *text {
+		if r < utf8.RuneSelf {
+			ascii++
+		} else {
+			others++
+		}
+	}
+	return (ascii+3)/4 + others
+}
@@ -0,0 +1,78 @@
+// Copyright (c) 2025 Bytedance Ltd. and/or its affiliates
+// SPDX-License-Identifier: MIT
+
+package entity
+
+import (
+	"strings"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+
+	"github.com/coze-dev/cozeloop-go/internal/util"
+)
+
+func TestTrimHistory(t *testing.T) {
+	newMessage := func(role Role, content string) *Message {
+		return &Message{Role: role, Content: util.Ptr(content)}
+	}
+	contents := func(messages []*Message) []string {
+		var result []string
+		for _, message := range messages {
+			result = append(result, *message.Content)
+		}
+		return result
+	}
+	history := []*Message{
+		newMessage(RoleSystem, "system"),
+		newMessage(RoleUser, "q1"),
+		newMessage(RoleAssistant, "a1"),
+		newMessage(RoleUser, "q2"),
+		{Role: RoleAssistant, Content: util.Ptr("call"), ToolCalls: []*ToolCall{{ID: "1", FunctionCall: &FunctionCall{Name: "search"}}}},
+		{Role: RoleTool, Content: util.Ptr("result"), ToolCallID: util.Ptr("1")},
+		newMessage(RoleAssistant, "a2"),
+		newMessage(RoleUser, "q3"),
+	}
+
+	Convey("Test trim by turns and messages", t, func() {
+		So(contents(TrimHistory(history, TrimHistoryOptions{})), ShouldResemble, contents(history))
+		So(contents(TrimHistory(history, TrimHistoryOptions{MaxTurns: 2})), ShouldResemble,
+			[]string{"system", "q2", "call", "result", "a2", "q3"})
+		// tool results are not separated from the tool calls
+		So(contents(TrimHistory(history, TrimHistoryOptions{MaxMessages: 5})), ShouldResemble, []string{"system", "q3"})
+		// the latest turn is always kept
+		So(contents(TrimHistory(history, TrimHistoryOptions{MaxMessages: 1})), ShouldResemble, []string{"system", "q3"})
+		So(len(history), ShouldEqual, 8)
+		So(TrimHistory(nil, TrimHistoryOptions{MaxTurns: 1}), ShouldBeNil)
+	})
+
+	Convey("Test trim by tokens", t, func() {
+		tenTokens := func(message *Message) int { return 10 }
+		So(contents(TrimHistory(history, TrimHistoryOptions{MaxTokens: 40, TokenEstimator: tenTokens})), ShouldResemble,
+			[]string{"system", "q3"})
+		So(contents(TrimHistory(history, TrimHistoryOptions{MaxTokens: 70, TokenEstimator: tenTokens})), ShouldResemble,
+			[]string{"system", "q2", "call", "result", "a2", "q3"})
+
+		So(EstimateMessageTokens(nil), ShouldEqual, 0)
+		So(EstimateMessageTokens(newMessage(RoleUser, strings.Repeat("a", 8)+"你好")), ShouldEqual, 4+2+2)
+		So(EstimateMessageTokens(&Message{Role: RoleUser, Parts: []*ContentPart{
+			{Type: ContentTypeText, Text: util.Ptr("abcd")},
+			{Type: ContentTypeImageURL, ImageURL: util.Ptr("https://example.com/a.png")},
+		}}), ShouldEqual, 4+1+85)
+	})
+
+	Convey("Test summary of dropped turns", t, func() {
+		var dropped []string
+		summarize := func(messages []*Message) *Message {
+			dropped = contents(messages)
+			return newMessage(RoleAssistant, "summary")
+		}
+		So(contents(TrimHistory(history, TrimHistoryOptions{MaxTurns: 1, Summarize: summarize})), ShouldResemble,
+			[]string{"system", "summary", "q3"})
+		So(dropped, ShouldResemble, []string{"q1", "a1", "q2", "call", "result", "a2"})
+
+		dropped = nil
+		So(len(TrimHistory(history, TrimHistoryOptions{MaxTurns: 3, Summarize: summarize})), ShouldEqual, len(history))
+		So(dropped, ShouldBeNil)
+	})
+}
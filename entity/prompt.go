@@ -4,9 +4,13 @@
 package entity
 
 import (
+	"time"
+
 	"github.com/coze-dev/cozeloop-go/internal/util"
 )
 
+// Prompt a version of prompt. The prompt returned by GetPrompt is shared with the prompt cache of client if
+// WithPromptDeepCopy is disabled, and it should be read only then, use DeepCopy to get a copy which can be modified.
 type Prompt struct {
 	WorkspaceID    string          `json:"workspace_id"`
 	PromptKey      string          `json:"prompt_key"`
@@ -15,6 +19,18 @@ type Prompt struct {
 	Tools          []*Tool         `json:"tools,omitempty"`
 	ToolCallConfig *ToolCallConfig `json:"tool_call_config,omitempty"`
 	LLMConfig      *LLMConfig      `json:"llm_config,omitempty"`
+	// VersionMeta metadata of the version, nil if it is not provided by the API
+	VersionMeta *PromptVersionMeta `json:"version_meta,omitempty"`
+}
+
+// PromptVersionMeta metadata of the version of prompt, which tells which revision of prompt served a request.
+type PromptVersionMeta struct {
+	// Description description of prompt
+	Description string `json:"description,omitempty"`
+	// CommitMessage message of the commit of version
+	CommitMessage string    `json:"commit_message,omitempty"`
+	CommittedBy   string    `json:"committed_by,omitempty"`
+	CreatedAt     time.Time `json:"created_at"`
 }
 
 type PromptTemplate struct {
@@ -143,7 +159,11 @@ type ExecuteParam struct {
 	Version      string         `json:"version,omitempty"`
 	Label        string         `json:"label,omitempty"`
 	VariableVals map[string]any `json:"variable_vals,omitempty"`
-	Messages     []*Message     `json:"messages,omitempty"`
+	// VariableStruct struct whose fields are bound to variables by BindVariables, merged with VariableVals.
+	VariableStruct any        `json:"-"`
+	Messages       []*Message `json:"messages,omitempty"`
+	// LLMConfig overrides the fields set of the llm config of prompt, such as JSONMode, optional.
+	LLMConfig *LLMConfig `json:"llm_config,omitempty"`
 }
 
 type ExecuteResult struct {
@@ -170,7 +190,16 @@ func (p *Prompt) DeepCopy() *Prompt {
 		Tools:          deepCopyTools(p.Tools),
 		ToolCallConfig: p.ToolCallConfig.DeepCopy(),
 		LLMConfig:      p.LLMConfig.DeepCopy(),
+		VersionMeta:    p.VersionMeta.DeepCopy(),
+	}
+}
+
+func (m *PromptVersionMeta) DeepCopy() *PromptVersionMeta {
+	if m == nil {
+		return nil
 	}
+	copied := *m
+	return &copied
 }
 
 func (pt *PromptTemplate) DeepCopy() *PromptTemplate {
@@ -0,0 +1,64 @@
+// Copyright (c) 2025 Bytedance Ltd. and/or its affiliates
+// SPDX-License-Identifier: MIT
+
+package entity
+
+// DiffType type of a diff item.
+type DiffType string
+
+const (
+	DiffTypeEqual    DiffType = "equal"
+	DiffTypeAdded    DiffType = "added"
+	DiffTypeRemoved  DiffType = "removed"
+	DiffTypeModified DiffType = "modified"
+)
+
+// PromptVersionDiff the diff of two versions of a prompt formatted with the same variables.
+type PromptVersionDiff struct {
+	PromptKey string `json:"prompt_key"`
+	VersionA  string `json:"version_a"`
+	VersionB  string `json:"version_b"`
+	// MessagesA, MessagesB the messages formatted from the two versions
+	MessagesA []*Message `json:"messages_a,omitempty"`
+	MessagesB []*Message `json:"messages_b,omitempty"`
+	// MessageDiffs the message level diff from MessagesA to MessagesB
+	MessageDiffs []*MessageDiff `json:"message_diffs,omitempty"`
+	// ToolsChanged whether the tools or tool call config are changed
+	ToolsChanged bool `json:"tools_changed"`
+	// LLMConfigChanged whether the model config is changed
+	LLMConfigChanged bool `json:"llm_config_changed"`
+}
+
+// Equal whether the two versions produce the same messages, with the same tools and model config.
+func (d *PromptVersionDiff) Equal() bool {
+	if d == nil {
+		return true
+	}
+	if d.ToolsChanged || d.LLMConfigChanged {
+		return false
+	}
+	for _, diff := range d.MessageDiffs {
+		if diff.Type != DiffTypeEqual {
+			return false
+		}
+	}
+	return true
+}
+
+// MessageDiff the diff of a message. IndexA and IndexB are the index of message in MessagesA and MessagesB,
+// which is -1 if the message is added or removed.
+type MessageDiff struct {
+	Type   DiffType `json:"type"`
+	IndexA int      `json:"index_a"`
+	IndexB int      `json:"index_b"`
+	A      *Message `json:"a,omitempty"`
+	B      *Message `json:"b,omitempty"`
+	// TextDiffs the line level diff of content, only set if Type is DiffTypeModified
+	TextDiffs []*TextDiff `json:"text_diffs,omitempty"`
+}
+
+// TextDiff a segment of text diff, Type is one of DiffTypeEqual, DiffTypeAdded and DiffTypeRemoved.
+type TextDiff struct {
+	Type DiffType `json:"type"`
+	Text string   `json:"text"`
+}
@@ -0,0 +1,18 @@
+// Copyright (c) 2025 Bytedance Ltd. and/or its affiliates
+// SPDX-License-Identifier: MIT
+
+package entity
+
+// PartialFormatResult the result of formatting prompt with part of the variables.
+type PartialFormatResult struct {
+	// Messages the formatted messages. In normal template, the tags of unresolved variables, such as {{name}}, are
+	// kept as it is, and so are the multi-part variable parts and placeholder messages without value. So Messages can
+	// be formatted again with the same variable defs when the rest of variables are ready.
+	Messages []*Message `json:"messages,omitempty"`
+	// UnresolvedVariables defined variables which are referenced by templates without value, sorted by name.
+	// Jinja2 templates are not parsed, so all defined variables without value are reported, and they are rendered
+	// as empty by Jinja2.
+	UnresolvedVariables []string `json:"unresolved_variables,omitempty"`
+	// UnresolvedPlaceholders variables of placeholder messages without value, in the order of messages
+	UnresolvedPlaceholders []string `json:"unresolved_placeholders,omitempty"`
+}
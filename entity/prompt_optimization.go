@@ -0,0 +1,59 @@
+// Copyright (c) 2025 Bytedance Ltd. and/or its affiliates
+// SPDX-License-Identifier: MIT
+
+package entity
+
+type PromptOptimizationStatus string
+
+const (
+	PromptOptimizationStatusPending PromptOptimizationStatus = "pending"
+	PromptOptimizationStatusRunning PromptOptimizationStatus = "running"
+	PromptOptimizationStatusSuccess PromptOptimizationStatus = "success"
+	PromptOptimizationStatusFailed  PromptOptimizationStatus = "failed"
+)
+
+// IsFinished returns whether the prompt optimization has reached a terminal status.
+func (s PromptOptimizationStatus) IsFinished() bool {
+	return s == PromptOptimizationStatusSuccess || s == PromptOptimizationStatusFailed
+}
+
+// PromptOptimizationSample an output of the prompt with its inputs and score, which the optimizer learns from.
+type PromptOptimizationSample struct {
+	// Variables variables used to format the prompt
+	Variables map[string]any `json:"variables,omitempty"`
+	// Output output of the model
+	Output string `json:"output"`
+	// ExpectedOutput the output expected, optional
+	ExpectedOutput string `json:"expected_output,omitempty"`
+	// Score score of the output, higher is better
+	Score *float64 `json:"score,omitempty"`
+	// Feedback why the output is good or bad, optional
+	Feedback string `json:"feedback,omitempty"`
+}
+
+type SubmitPromptOptimizationParam struct {
+	PromptKey string `json:"prompt_key"`
+	// Version version of the prompt to optimize, the latest version if it is empty
+	Version string `json:"version,omitempty"`
+	// Objective what to improve in natural language, optional
+	Objective string                      `json:"objective,omitempty"`
+	Samples   []*PromptOptimizationSample `json:"samples"`
+}
+
+// PromptOptimizationCandidate a prompt template proposed by the optimizer.
+type PromptOptimizationCandidate struct {
+	PromptTemplate *PromptTemplate `json:"prompt_template,omitempty"`
+	// Score score of the candidate estimated by the optimizer
+	Score  *float64 `json:"score,omitempty"`
+	Reason string   `json:"reason,omitempty"`
+}
+
+type PromptOptimizationRun struct {
+	ID            string                         `json:"id"`
+	WorkspaceID   string                         `json:"workspace_id"`
+	PromptKey     string                         `json:"prompt_key"`
+	PromptVersion string                         `json:"prompt_version,omitempty"`
+	Status        PromptOptimizationStatus       `json:"status"`
+	Candidates    []*PromptOptimizationCandidate `json:"candidates,omitempty"`
+	ErrMsg        string                         `json:"err_msg,omitempty"`
+}
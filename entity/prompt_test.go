@@ -74,6 +74,7 @@ func TestPromptDeepCopy(t *testing.T) {
 					PresencePenalty:  &presPenalty,
 					JSONMode:         &jsonMode,
 				},
+				VersionMeta: &PromptVersionMeta{CommitMessage: "init", CommittedBy: "alice"},
 			}
 
 			copied := p.DeepCopy()
@@ -112,6 +113,10 @@ func TestPromptDeepCopy(t *testing.T) {
 			So(copied.PromptTemplate.VariableDefs[0].Key, ShouldEqual, "var1")
 			So(copied.PromptTemplate.VariableDefs[0].Desc, ShouldEqual, "desc1")
 			So(copied.PromptTemplate.VariableDefs[0].Type, ShouldEqual, VariableTypeString)
+
+			p.VersionMeta.CommitMessage = "modified"
+			So(copied.VersionMeta.CommitMessage, ShouldEqual, "init")
+			So(copied.VersionMeta.CommittedBy, ShouldEqual, "alice")
 		})
 	})
 }
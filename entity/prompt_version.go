@@ -0,0 +1,19 @@
+// Copyright (c) 2025 Bytedance Ltd. and/or its affiliates
+// SPDX-License-Identifier: MIT
+
+package entity
+
+import (
+	"time"
+)
+
+// PromptVersion metadata of a committed version of prompt.
+type PromptVersion struct {
+	PromptKey   string `json:"prompt_key"`
+	Version     string `json:"version"`
+	Description string `json:"description,omitempty"`
+	// Labels labels pointing at the version, such as production
+	Labels      []string  `json:"labels,omitempty"`
+	CommittedBy string    `json:"committed_by,omitempty"`
+	CommittedAt time.Time `json:"committed_at"`
+}
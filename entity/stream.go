@@ -3,6 +3,71 @@
 
 package entity
 
+import (
+	"context"
+	"errors"
+	"io"
+
+	"github.com/coze-dev/cozeloop-go/internal/util"
+)
+
+// StreamReader reads a stream item by item. Recv returns io.EOF when the stream ends.
+// The readers returned by the SDK also implement io.Closer, Close releases the underlying connection,
+// it is safe to be called multiple times, and concurrently with Recv.
 type StreamReader[T any] interface {
 	Recv() (T, error)
 }
+
+// StreamItem is an item of the channel returned by StreamToChan. Err is set if the stream failed.
+type StreamItem[T any] struct {
+	Value T
+	Err   error
+}
+
+// StreamToChan reads the stream in background, and sends the items to the returned channel.
+// The channel is closed when the stream ends, fails, or ctx is done. io.EOF is not sent, and the error
+// of a failed stream is sent as the last item. The reader is closed if it implements io.Closer,
+// so the caller can stop reading by cancelling ctx.
+func StreamToChan[T any](ctx context.Context, reader StreamReader[T]) <-chan StreamItem[T] {
+	ch := make(chan StreamItem[T])
+	util.GoSafe(ctx, func() {
+		defer close(ch)
+		defer CloseStream(reader)
+
+		// close the reader to unblock Recv when ctx is done
+		stop := make(chan struct{})
+		defer close(stop)
+		util.GoSafe(ctx, func() {
+			select {
+			case <-ctx.Done():
+				CloseStream(reader)
+			case <-stop:
+			}
+		})
+
+		for {
+			value, err := reader.Recv()
+			if errors.Is(err, io.EOF) || ctx.Err() != nil {
+				return
+			}
+			item := StreamItem[T]{Value: value, Err: err}
+			select {
+			case ch <- item:
+			case <-ctx.Done():
+				return
+			}
+			if err != nil {
+				return
+			}
+		}
+	})
+	return ch
+}
+
+// CloseStream closes the reader if it implements io.Closer.
+func CloseStream[T any](reader StreamReader[T]) error {
+	if closer, ok := reader.(io.Closer); ok {
+		return closer.Close()
+	}
+	return nil
+}
@@ -0,0 +1,38 @@
+// Copyright (c) 2025 Bytedance Ltd. and/or its affiliates
+// SPDX-License-Identifier: MIT
+
+//go:build go1.23
+
+package entity
+
+import (
+	"errors"
+	"io"
+	"iter"
+)
+
+// StreamSeq returns an iterator over the stream, which can be used as:
+//
+//	for result, err := range entity.StreamSeq(reader) {
+//		if err != nil {
+//			return err
+//		}
+//		...
+//	}
+//
+// The iteration stops at the end of stream, and the error of a failed stream is yielded as the last item.
+// The reader is closed when the iteration stops, including break by caller.
+func StreamSeq[T any](reader StreamReader[T]) iter.Seq2[T, error] {
+	return func(yield func(T, error) bool) {
+		defer CloseStream(reader)
+		for {
+			value, err := reader.Recv()
+			if errors.Is(err, io.EOF) {
+				return
+			}
+			if !yield(value, err) || err != nil {
+				return
+			}
+		}
+	}
+}
@@ -0,0 +1,36 @@
+// Copyright (c) 2025 Bytedance Ltd. and/or its affiliates
+// SPDX-License-Identifier: MIT
+
+//go:build go1.23
+
+package entity
+
+import (
+	"errors"
+	"io"
+	"sync/atomic"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestStreamSeq(t *testing.T) {
+	Convey("Test iterate stream", t, func() {
+		reader := &sliceStreamReader{items: []int{1, 2}, err: io.EOF}
+		var values []int
+		for value, err := range StreamSeq[int](reader) {
+			So(err, ShouldBeNil)
+			values = append(values, value)
+		}
+		So(values, ShouldResemble, []int{1, 2})
+		So(atomic.LoadInt32(&reader.closed), ShouldEqual, 1)
+	})
+
+	Convey("Test break closes stream", t, func() {
+		reader := &sliceStreamReader{items: []int{1, 2}, err: errors.New("failed")}
+		for range StreamSeq[int](reader) {
+			break
+		}
+		So(atomic.LoadInt32(&reader.closed), ShouldEqual, 1)
+	})
+}
@@ -0,0 +1,79 @@
+// Copyright (c) 2025 Bytedance Ltd. and/or its affiliates
+// SPDX-License-Identifier: MIT
+
+package entity
+
+import (
+	"context"
+	"errors"
+	"io"
+	"sync/atomic"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+// sliceStreamReader returns the items one by one, then err. It blocks on block after the items if block is set.
+type sliceStreamReader struct {
+	items  []int
+	err    error
+	block  chan struct{}
+	closed int32
+}
+
+func (r *sliceStreamReader) Recv() (int, error) {
+	if atomic.LoadInt32(&r.closed) == 1 {
+		return 0, errors.New("closed")
+	}
+	if len(r.items) > 0 {
+		item := r.items[0]
+		r.items = r.items[1:]
+		return item, nil
+	}
+	if r.block != nil {
+		<-r.block
+		return 0, errors.New("closed")
+	}
+	return 0, r.err
+}
+
+func (r *sliceStreamReader) Close() error {
+	if atomic.CompareAndSwapInt32(&r.closed, 0, 1) && r.block != nil {
+		close(r.block)
+	}
+	return nil
+}
+
+func TestStreamToChan(t *testing.T) {
+	Convey("Test stream ends with EOF", t, func() {
+		reader := &sliceStreamReader{items: []int{1, 2, 3}, err: io.EOF}
+		var values []int
+		for item := range StreamToChan[int](context.Background(), reader) {
+			So(item.Err, ShouldBeNil)
+			values = append(values, item.Value)
+		}
+		So(values, ShouldResemble, []int{1, 2, 3})
+		So(atomic.LoadInt32(&reader.closed), ShouldEqual, 1)
+	})
+
+	Convey("Test stream error is the last item", t, func() {
+		reader := &sliceStreamReader{items: []int{1}, err: errors.New("failed")}
+		var items []StreamItem[int]
+		for item := range StreamToChan[int](context.Background(), reader) {
+			items = append(items, item)
+		}
+		So(len(items), ShouldEqual, 2)
+		So(items[1].Err.Error(), ShouldEqual, "failed")
+	})
+
+	Convey("Test cancel unblocks Recv", t, func() {
+		ctx, cancel := context.WithCancel(context.Background())
+		reader := &sliceStreamReader{items: []int{1}, block: make(chan struct{})}
+		ch := StreamToChan[int](ctx, reader)
+		So((<-ch).Value, ShouldEqual, 1)
+		cancel()
+		for range ch {
+		}
+		So(atomic.LoadInt32(&reader.closed), ShouldEqual, 1)
+	})
+}
@@ -0,0 +1,519 @@
+// Copyright (c) 2025 Bytedance Ltd. and/or its affiliates
+// SPDX-License-Identifier: MIT
+
+package entity
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// JSONSchemaTag is the struct tag to set the keywords of JSON schema of a field, separated by comma, e.g.
+// `jsonschema:"description=city name,enum=Beijing,enum=Shanghai"`. Supported keywords are description, enum,
+// format, pattern, minimum, maximum, minLength, maxLength, minItems and maxItems, use `jsonschema:"-"` to skip the
+// field. Use JSONSchemaDescriptionTag for the description which contains commas.
+const (
+	JSONSchemaTag            = "jsonschema"
+	JSONSchemaDescriptionTag = "jsonschema_description"
+)
+
+var (
+	timeType       = reflect.TypeOf(time.Time{})
+	rawMessageType = reflect.TypeOf(json.RawMessage{})
+)
+
+// NewFunctionTool creates a function tool whose parameters are the JSON schema of args, see NewFunction.
+func NewFunctionTool(name, description string, args any) (*Tool, error) {
+	function, err := NewFunction(name, description, args)
+	if err != nil {
+		return nil, err
+	}
+	return &Tool{Type: ToolTypeFunction, Function: function}, nil
+}
+
+// NewFunction creates a function whose parameters are the JSON schema of args, which is a struct or pointer to
+// struct, usually the zero value of the arguments type of tool handler, so the schema is always in sync with the
+// handler. The property names follow the `json` tags, and fields without omitempty are required.
+func NewFunction(name, description string, args any) (*Function, error) {
+	if name == "" {
+		return nil, fmt.Errorf("function name is required")
+	}
+	parameters, err := JSONSchemaOf(args)
+	if err != nil {
+		return nil, err
+	}
+	function := &Function{Name: name, Parameters: &parameters}
+	if description != "" {
+		function.Description = &description
+	}
+	return function, nil
+}
+
+// JSONSchemaOf returns the JSON schema of the type of v, which is a struct or pointer to struct. An object without
+// properties is returned if v is nil.
+func JSONSchemaOf(v any) (string, error) {
+	schema := &jsonSchema{Type: "object", Properties: jsonSchemaProperties{}}
+	if v != nil {
+		t := reflect.TypeOf(v)
+		for t.Kind() == reflect.Ptr {
+			t = t.Elem()
+		}
+		if t.Kind() != reflect.Struct {
+			return "", fmt.Errorf("arguments should be struct or pointer to struct, got %T", v)
+		}
+		var err error
+		if schema, err = newJSONSchema(t, map[reflect.Type]bool{}); err != nil {
+			return "", err
+		}
+	}
+	data, err := json.Marshal(schema)
+	if err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
+
+type jsonSchema struct {
+	Type                 string               `json:"type,omitempty"`
+	Description          string               `json:"description,omitempty"`
+	Format               string               `json:"format,omitempty"`
+	Pattern              string               `json:"pattern,omitempty"`
+	Enum                 []any                `json:"enum,omitempty"`
+	Minimum              *float64             `json:"minimum,omitempty"`
+	Maximum              *float64             `json:"maximum,omitempty"`
+	MinLength            *int                 `json:"minLength,omitempty"`
+	MaxLength            *int                 `json:"maxLength,omitempty"`
+	MinItems             *int                 `json:"minItems,omitempty"`
+	MaxItems             *int                 `json:"maxItems,omitempty"`
+	Items                *jsonSchema          `json:"items,omitempty"`
+	Properties           jsonSchemaProperties `json:"properties,omitempty"`
+	Required             []string             `json:"required,omitempty"`
+	AdditionalProperties any                  `json:"additionalProperties,omitempty"`
+}
+
+type jsonSchemaProperty struct {
+	name   string
+	schema *jsonSchema
+}
+
+// jsonSchemaProperties the properties of object in order of fields, as the order helps model to fill arguments.
+type jsonSchemaProperties []jsonSchemaProperty
+
+func (p jsonSchemaProperties) MarshalJSON() ([]byte, error) {
+	var buf bytes.Buffer
+	buf.WriteByte('{')
+	for i, property := range p {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		name, err := json.Marshal(property.name)
+		if err != nil {
+			return nil, err
+		}
+		value, err := json.Marshal(property.schema)
+		if err != nil {
+			return nil, err
+		}
+		buf.Write(name)
+		buf.WriteByte(':')
+		buf.Write(value)
+	}
+	buf.WriteByte('}')
+	return buf.Bytes(), nil
+}
+
+// newJSONSchema returns the schema of type t, visiting is the struct types on the path, to reject recursive types.
+func newJSONSchema(t reflect.Type, visiting map[reflect.Type]bool) (*jsonSchema, error) {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	switch {
+	case t == timeType:
+		return &jsonSchema{Type: "string", Format: "date-time"}, nil
+	case t == rawMessageType:
+		return &jsonSchema{}, nil
+	}
+	switch t.Kind() {
+	case reflect.Bool:
+		return &jsonSchema{Type: "boolean"}, nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return &jsonSchema{Type: "integer"}, nil
+	case reflect.Float32, reflect.Float64:
+		return &jsonSchema{Type: "number"}, nil
+	case reflect.String:
+		return &jsonSchema{Type: "string"}, nil
+	case reflect.Interface:
+		return &jsonSchema{}, nil
+	case reflect.Slice, reflect.Array:
+		if t.Elem().Kind() == reflect.Uint8 {
+			// []byte is encoded as base64 string
+			return &jsonSchema{Type: "string"}, nil
+		}
+		items, err := newJSONSchema(t.Elem(), visiting)
+		if err != nil {
+			return nil, err
+		}
+		return &jsonSchema{Type: "array", Items: items}, nil
+	case reflect.Map:
+		if t.Key().Kind() != reflect.String {
+			return nil, fmt.Errorf("map key of %s should be string", t)
+		}
+		values, err := newJSONSchema(t.Elem(), visiting)
+		if err != nil {
+			return nil, err
+		}
+		return &jsonSchema{Type: "object", AdditionalProperties: values}, nil
+	case reflect.Struct:
+		if visiting[t] {
+			return nil, fmt.Errorf("recursive type %s is not supported", t)
+		}
+		visiting[t] = true
+		defer delete(visiting, t)
+		schema := &jsonSchema{Type: "object", Properties: jsonSchemaProperties{}, AdditionalProperties: false}
+		if err := addJSONSchemaFields(schema, t, visiting); err != nil {
+			return nil, err
+		}
+		return schema, nil
+	default:
+		return nil, fmt.Errorf("type %s is not supported", t)
+	}
+}
+
+func addJSONSchemaFields(schema *jsonSchema, t reflect.Type, visiting map[reflect.Type]bool) error {
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		jsonTag, hasJSONTag := field.Tag.Lookup("json")
+		if jsonTag == "-" || field.Tag.Get(JSONSchemaTag) == "-" {
+			continue
+		}
+		name, opts, _ := strings.Cut(jsonTag, ",")
+		if field.Anonymous && name == "" {
+			embedded := field.Type
+			if embedded.Kind() == reflect.Ptr {
+				embedded = embedded.Elem()
+			}
+			if embedded.Kind() == reflect.Struct {
+				if err := addJSONSchemaFields(schema, embedded, visiting); err != nil {
+					return err
+				}
+				continue
+			}
+		}
+		if !field.IsExported() {
+			continue
+		}
+		if !hasJSONTag || name == "" {
+			name = field.Name
+		}
+
+		property, err := newJSONSchema(field.Type, visiting)
+		if err != nil {
+			return fmt.Errorf("field %s.%s: %w", t, field.Name, err)
+		}
+		if err := setJSONSchemaKeywords(property, field); err != nil {
+			return fmt.Errorf("field %s.%s: %w", t, field.Name, err)
+		}
+		schema.Properties = append(schema.Properties, jsonSchemaProperty{name: name, schema: property})
+		if !strings.Contains(","+opts+",", ",omitempty,") {
+			schema.Required = append(schema.Required, name)
+		}
+	}
+	return nil
+}
+
+func setJSONSchemaKeywords(schema *jsonSchema, field reflect.StructField) error {
+	if tag := field.Tag.Get(JSONSchemaTag); tag != "" {
+		for _, keyword := range strings.Split(tag, ",") {
+			key, value, _ := strings.Cut(keyword, "=")
+			var err error
+			switch strings.TrimSpace(key) {
+			case "description":
+				schema.Description = value
+			case "format":
+				schema.Format = value
+			case "pattern":
+				schema.Pattern = value
+			case "enum":
+				var item any
+				if item, err = parseJSONSchemaValue(schema.Type, value); err == nil {
+					schema.Enum = append(schema.Enum, item)
+				}
+			case "minimum":
+				schema.Minimum, err = parseJSONSchemaFloat(value)
+			case "maximum":
+				schema.Maximum, err = parseJSONSchemaFloat(value)
+			case "minLength":
+				schema.MinLength, err = parseJSONSchemaInt(value)
+			case "maxLength":
+				schema.MaxLength, err = parseJSONSchemaInt(value)
+			case "minItems":
+				schema.MinItems, err = parseJSONSchemaInt(value)
+			case "maxItems":
+				schema.MaxItems, err = parseJSONSchemaInt(value)
+			default:
+				err = fmt.Errorf("unknown keyword")
+			}
+			if err != nil {
+				return fmt.Errorf("invalid %s tag %q: %w", JSONSchemaTag, keyword, err)
+			}
+		}
+	}
+	if description, ok := field.Tag.Lookup(JSONSchemaDescriptionTag); ok {
+		schema.Description = description
+	}
+	return nil
+}
+
+// parseJSONSchemaValue parses the enum value as the type of schema.
+func parseJSONSchemaValue(schemaType, value string) (any, error) {
+	switch schemaType {
+	case "integer":
+		return strconv.ParseInt(value, 10, 64)
+	case "number":
+		return strconv.ParseFloat(value, 64)
+	case "boolean":
+		return strconv.ParseBool(value)
+	default:
+		return value, nil
+	}
+}
+
+func parseJSONSchemaFloat(value string) (*float64, error) {
+	f, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		return nil, err
+	}
+	return &f, nil
+}
+
+func parseJSONSchemaInt(value string) (*int, error) {
+	i, err := strconv.Atoi(value)
+	if err != nil {
+		return nil, err
+	}
+	return &i, nil
+}
+
+func (s *jsonSchema) UnmarshalJSON(data []byte) error {
+	type alias jsonSchema
+	var raw struct {
+		*alias
+		Properties           map[string]*jsonSchema `json:"properties,omitempty"`
+		AdditionalProperties json.RawMessage        `json:"additionalProperties,omitempty"`
+	}
+	raw.alias = (*alias)(s)
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+	names := make([]string, 0, len(raw.Properties))
+	for name := range raw.Properties {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	s.Properties = nil
+	for _, name := range names {
+		s.Properties = append(s.Properties, jsonSchemaProperty{name: name, schema: raw.Properties[name]})
+	}
+	s.AdditionalProperties = nil
+	if len(raw.AdditionalProperties) > 0 {
+		var allowed bool
+		if err := json.Unmarshal(raw.AdditionalProperties, &allowed); err == nil {
+			s.AdditionalProperties = allowed
+		} else {
+			values := &jsonSchema{}
+			if err := json.Unmarshal(raw.AdditionalProperties, values); err != nil {
+				return err
+			}
+			s.AdditionalProperties = values
+		}
+	}
+	return nil
+}
+
+// ValidateJSONSchema validates the JSON data against the schema, such as the output of model in JSON mode.
+// The keywords generated by JSONSchemaOf are supported, and the others are ignored.
+func ValidateJSONSchema(schema string, data []byte) error {
+	s := &jsonSchema{}
+	if err := json.Unmarshal([]byte(schema), s); err != nil {
+		return fmt.Errorf("invalid schema: %w", err)
+	}
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	var value any
+	if err := decoder.Decode(&value); err != nil {
+		return fmt.Errorf("invalid JSON: %w", err)
+	}
+	if decoder.More() {
+		return fmt.Errorf("invalid JSON: unexpected data after top-level value")
+	}
+	return s.validate("$", value)
+}
+
+func (s *jsonSchema) validate(path string, value any) error {
+	if s == nil {
+		return nil
+	}
+	if len(s.Enum) > 0 && !s.inEnum(value) {
+		return fmt.Errorf("%s: value %v is not one of %v", path, value, s.Enum)
+	}
+	switch v := value.(type) {
+	case nil:
+		if s.Type != "" {
+			return fmt.Errorf("%s: expected %s, got null", path, s.Type)
+		}
+	case bool:
+		if s.Type != "" && s.Type != "boolean" {
+			return fmt.Errorf("%s: expected %s, got boolean", path, s.Type)
+		}
+	case json.Number:
+		return s.validateNumber(path, v)
+	case string:
+		if s.Type != "" && s.Type != "string" {
+			return fmt.Errorf("%s: expected %s, got string", path, s.Type)
+		}
+		return s.validateString(path, v)
+	case []any:
+		if s.Type != "" && s.Type != "array" {
+			return fmt.Errorf("%s: expected %s, got array", path, s.Type)
+		}
+		if s.MinItems != nil && len(v) < *s.MinItems {
+			return fmt.Errorf("%s: expected at least %d items, got %d", path, *s.MinItems, len(v))
+		}
+		if s.MaxItems != nil && len(v) > *s.MaxItems {
+			return fmt.Errorf("%s: expected at most %d items, got %d", path, *s.MaxItems, len(v))
+		}
+		for i, item := range v {
+			if err := s.Items.validate(fmt.Sprintf("%s[%d]", path, i), item); err != nil {
+				return err
+			}
+		}
+	case map[string]any:
+		if s.Type != "" && s.Type != "object" {
+			return fmt.Errorf("%s: expected %s, got object", path, s.Type)
+		}
+		return s.validateObject(path, v)
+	}
+	return nil
+}
+
+func (s *jsonSchema) validateNumber(path string, v json.Number) error {
+	f, err := v.Float64()
+	if err != nil {
+		return fmt.Errorf("%s: invalid number %s", path, v)
+	}
+	switch s.Type {
+	case "", "number":
+	case "integer":
+		if _, err := v.Int64(); err != nil && f != float64(int64(f)) {
+			return fmt.Errorf("%s: expected integer, got %s", path, v)
+		}
+	default:
+		return fmt.Errorf("%s: expected %s, got number", path, s.Type)
+	}
+	if s.Minimum != nil && f < *s.Minimum {
+		return fmt.Errorf("%s: %s is less than minimum %v", path, v, *s.Minimum)
+	}
+	if s.Maximum != nil && f > *s.Maximum {
+		return fmt.Errorf("%s: %s is greater than maximum %v", path, v, *s.Maximum)
+	}
+	return nil
+}
+
+func (s *jsonSchema) validateString(path string, v string) error {
+	length := utf8.RuneCountInString(v)
+	if s.MinLength != nil && length < *s.MinLength {
+		return fmt.Errorf("%s: expected at least %d characters, got %d", path, *s.MinLength, length)
+	}
+	if s.MaxLength != nil && length > *s.MaxLength {
+		return fmt.Errorf("%s: expected at most %d characters, got %d", path, *s.MaxLength, length)
+	}
+	if s.Pattern != "" {
+		matched, err := regexp.MatchString(s.Pattern, v)
+		if err != nil {
+			return fmt.Errorf("%s: invalid pattern %q: %w", path, s.Pattern, err)
+		}
+		if !matched {
+			return fmt.Errorf("%s: %q does not match pattern %q", path, v, s.Pattern)
+		}
+	}
+	if s.Format == "date-time" {
+		if _, err := time.Parse(time.RFC3339, v); err != nil {
+			return fmt.Errorf("%s: %q is not a date-time", path, v)
+		}
+	}
+	return nil
+}
+
+func (s *jsonSchema) validateObject(path string, v map[string]any) error {
+	for _, name := range s.Required {
+		if _, ok := v[name]; !ok {
+			return fmt.Errorf("%s: missing required property %q", path, name)
+		}
+	}
+	properties := make(map[string]*jsonSchema, len(s.Properties))
+	for _, property := range s.Properties {
+		properties[property.name] = property.schema
+	}
+	names := make([]string, 0, len(v))
+	for name := range v {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		property, ok := properties[name]
+		if !ok {
+			switch additional := s.AdditionalProperties.(type) {
+			case bool:
+				if !additional {
+					return fmt.Errorf("%s: unexpected property %q", path, name)
+				}
+				continue
+			case *jsonSchema:
+				property = additional
+			default:
+				continue
+			}
+		}
+		if err := property.validate(path+"."+name, v[name]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *jsonSchema) inEnum(value any) bool {
+	for _, item := range s.Enum {
+		if number, ok := value.(json.Number); ok {
+			if f, err := number.Float64(); err == nil {
+				if itemNumber, ok := toFloat64(item); ok && itemNumber == f {
+					return true
+				}
+			}
+			continue
+		}
+		if item == value {
+			return true
+		}
+	}
+	return false
+}
+
+func toFloat64(v any) (float64, bool) {
+	switch n := v.(type) {
+	case int64:
+		return float64(n), true
+	case float64:
+		return n, true
+	default:
+		return 0, false
+	}
+}
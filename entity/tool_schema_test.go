@@ -0,0 +1,107 @@
+// Copyright (c) 2025 Bytedance Ltd. and/or its affiliates
+// SPDX-License-Identifier: MIT
+
+package entity
+
+import (
+	"testing"
+	"time"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+type location struct {
+	City    string `json:"city" jsonschema:"description=city name,enum=Beijing,enum=Shanghai"`
+	Country string `json:"country,omitempty" jsonschema_description:"country code, such as CN"`
+}
+
+type weatherArgs struct {
+	location
+	Days     *int              `json:"days,omitempty" jsonschema:"minimum=1,maximum=7"`
+	Unit     string            `json:"unit" jsonschema:"enum=celsius,enum=fahrenheit"`
+	Levels   []int             `json:"levels,omitempty" jsonschema:"maxItems=3"`
+	Since    time.Time         `json:"since"`
+	Extra    map[string]string `json:"extra,omitempty"`
+	Ignored  string            `json:"-"`
+	Internal string            `jsonschema:"-"`
+	private  string
+}
+
+type treeNode struct {
+	Children []*treeNode `json:"children"`
+}
+
+func TestJSONSchemaOf(t *testing.T) {
+	Convey("Test schema of struct", t, func() {
+		schema, err := JSONSchemaOf(&weatherArgs{})
+		So(err, ShouldBeNil)
+		So(schema, ShouldEqual, `{"type":"object","properties":{`+
+			`"city":{"type":"string","description":"city name","enum":["Beijing","Shanghai"]},`+
+			`"country":{"type":"string","description":"country code, such as CN"},`+
+			`"days":{"type":"integer","minimum":1,"maximum":7},`+
+			`"unit":{"type":"string","enum":["celsius","fahrenheit"]},`+
+			`"levels":{"type":"array","maxItems":3,"items":{"type":"integer"}},`+
+			`"since":{"type":"string","format":"date-time"},`+
+			`"extra":{"type":"object","additionalProperties":{"type":"string"}}},`+
+			`"required":["city","unit","since"],"additionalProperties":false}`)
+
+		schema, err = JSONSchemaOf(nil)
+		So(err, ShouldBeNil)
+		So(schema, ShouldEqual, `{"type":"object"}`)
+	})
+
+	Convey("Test unsupported types", t, func() {
+		_, err := JSONSchemaOf("text")
+		So(err, ShouldNotBeNil)
+		_, err = JSONSchemaOf(treeNode{})
+		So(err, ShouldNotBeNil)
+		_, err = JSONSchemaOf(struct {
+			Count int `json:"count" jsonschema:"minimum=one"`
+		}{})
+		So(err, ShouldNotBeNil)
+		_, err = JSONSchemaOf(struct {
+			Callback func() `json:"callback"`
+		}{})
+		So(err, ShouldNotBeNil)
+	})
+
+	Convey("Test NewFunctionTool", t, func() {
+		tool, err := NewFunctionTool("get_weather", "get weather of city", weatherArgs{})
+		So(err, ShouldBeNil)
+		So(tool.Type, ShouldEqual, ToolTypeFunction)
+		So(tool.Function.Name, ShouldEqual, "get_weather")
+		So(*tool.Function.Description, ShouldEqual, "get weather of city")
+		So(*tool.Function.Parameters, ShouldStartWith, `{"type":"object","properties":{"city"`)
+
+		_, err = NewFunctionTool("", "", weatherArgs{})
+		So(err, ShouldNotBeNil)
+	})
+}
+
+func TestValidateJSONSchema(t *testing.T) {
+	Convey("Test validate JSON against schema", t, func() {
+		schema, err := JSONSchemaOf(weatherArgs{})
+		So(err, ShouldBeNil)
+		valid := `{"city":"Beijing","unit":"celsius","since":"2025-01-01T00:00:00Z","days":3,"levels":[1,2],"extra":{"a":"b"}}`
+		So(ValidateJSONSchema(schema, []byte(valid)), ShouldBeNil)
+
+		for data, expected := range map[string]string{
+			`{"city":"Beijing","unit":"celsius"}`:                                                   `missing required property "since"`,
+			`{"city":"Paris","unit":"celsius","since":"2025-01-01T00:00:00Z"}`:                      "$.city",
+			`{"city":"Beijing","unit":"celsius","since":"yesterday"}`:                               "not a date-time",
+			`{"city":"Beijing","unit":"celsius","since":"2025-01-01T00:00:00Z","days":8}`:           "greater than maximum",
+			`{"city":"Beijing","unit":"celsius","since":"2025-01-01T00:00:00Z","days":1.5}`:         "expected integer",
+			`{"city":"Beijing","unit":"celsius","since":"2025-01-01T00:00:00Z","levels":[1,2,3,4]}`: "at most 3 items",
+			`{"city":"Beijing","unit":"celsius","since":"2025-01-01T00:00:00Z","extra":{"a":1}}`:    "$.extra.a",
+			`{"city":"Beijing","unit":"celsius","since":"2025-01-01T00:00:00Z","other":1}`:          `unexpected property "other"`,
+			`[1]`:      "expected object",
+			`{"city":`: "invalid JSON",
+		} {
+			err := ValidateJSONSchema(schema, []byte(data))
+			So(err, ShouldNotBeNil)
+			So(err.Error(), ShouldContainSubstring, expected)
+		}
+
+		So(ValidateJSONSchema("{", []byte(valid)), ShouldNotBeNil)
+	})
+}
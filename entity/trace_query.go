@@ -0,0 +1,29 @@
+// Copyright (c) 2025 Bytedance Ltd. and/or its affiliates
+// SPDX-License-Identifier: MIT
+
+package entity
+
+import (
+	"time"
+)
+
+type ListSpansParam struct {
+	// TraceID list spans of the trace. Either TraceID or time range is required.
+	TraceID string
+	// StartTime and EndTime filter spans whose start time is in [StartTime, EndTime).
+	StartTime time.Time
+	EndTime   time.Time
+	// SpanTypes filter spans by span type, such as model, prompt, tool.
+	SpanTypes []string
+	// Tags filter spans whose string tags match all the given key-values.
+	Tags map[string]string
+
+	PageToken string
+	PageSize  int
+}
+
+type ListSpansResult struct {
+	Spans         []*UploadSpan `json:"spans,omitempty"`
+	NextPageToken string        `json:"next_page_token,omitempty"`
+	HasMore       bool          `json:"has_more"`
+}
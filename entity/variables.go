@@ -0,0 +1,138 @@
+// Copyright (c) 2025 Bytedance Ltd. and/or its affiliates
+// SPDX-License-Identifier: MIT
+
+package entity
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// VariableTag is the struct tag to bind a field to a prompt variable, e.g. `loop:"var_name"`.
+// Use `loop:"-"` to skip the field, and `loop:"var_name,omitempty"` to skip the field of zero value.
+const VariableTag = "loop"
+
+var (
+	messageType     = reflect.TypeOf(Message{})
+	contentPartType = reflect.TypeOf(ContentPart{})
+)
+
+// BindVariables extracts the variables of prompt from struct v, or pointer to struct. The variable key of field is
+// the name in `loop` tag, or the field name if there is no tag. Unexported fields are skipped, and the fields of
+// embedded struct without tag are extracted as fields of v. Nil fields are skipped, and pointer fields of basic
+// types are dereferenced, so the values have the types expected by variable definitions.
+func BindVariables(v any) (map[string]any, error) {
+	if v == nil {
+		return nil, nil
+	}
+	if variables, ok := v.(map[string]any); ok {
+		return variables, nil
+	}
+	rv := reflect.ValueOf(v)
+	for rv.Kind() == reflect.Ptr {
+		if rv.IsNil() {
+			return nil, nil
+		}
+		rv = rv.Elem()
+	}
+	if rv.Kind() != reflect.Struct {
+		return nil, fmt.Errorf("variables should be struct or pointer to struct, got %T", v)
+	}
+	variables := make(map[string]any)
+	if err := bindStructVariables(rv, variables); err != nil {
+		return nil, err
+	}
+	return variables, nil
+}
+
+func bindStructVariables(rv reflect.Value, variables map[string]any) error {
+	rt := rv.Type()
+	for i := 0; i < rt.NumField(); i++ {
+		field := rt.Field(i)
+		tag, hasTag := field.Tag.Lookup(VariableTag)
+		if tag == "-" {
+			continue
+		}
+		name, opts, _ := strings.Cut(tag, ",")
+		value := rv.Field(i)
+		if field.Anonymous && !hasTag {
+			embedded := value
+			if embedded.Kind() == reflect.Ptr {
+				if embedded.IsNil() {
+					continue
+				}
+				embedded = embedded.Elem()
+			}
+			if embedded.Kind() == reflect.Struct && embedded.Type() != messageType && embedded.Type() != contentPartType {
+				if err := bindStructVariables(embedded, variables); err != nil {
+					return err
+				}
+				continue
+			}
+		}
+		if !field.IsExported() {
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+		if isNilValue(value) || (opts == "omitempty" && value.IsZero()) {
+			continue
+		}
+		if _, ok := variables[name]; ok {
+			return fmt.Errorf("variable '%s' is bound by more than one field of %s", name, rt)
+		}
+		variables[name] = variableValue(value)
+	}
+	return nil
+}
+
+func isNilValue(value reflect.Value) bool {
+	switch value.Kind() {
+	case reflect.Ptr, reflect.Interface, reflect.Map, reflect.Slice:
+		return value.IsNil()
+	default:
+		return false
+	}
+}
+
+// variableValue dereferences the pointer of basic types, and converts the named basic types to the builtin ones,
+// e.g. *string and `type Tone string` to string.
+func variableValue(value reflect.Value) any {
+	if value.Kind() == reflect.Ptr && isBasicKind(value.Elem().Kind()) {
+		value = value.Elem()
+	}
+	if value.Kind() == reflect.Interface {
+		value = value.Elem()
+	}
+	if isBasicKind(value.Kind()) && value.Type().PkgPath() != "" {
+		switch value.Kind() {
+		case reflect.String:
+			return value.String()
+		case reflect.Bool:
+			return value.Bool()
+		case reflect.Int:
+			return int(value.Int())
+		case reflect.Int32:
+			return int32(value.Int())
+		case reflect.Int64:
+			return value.Int()
+		case reflect.Float32:
+			return float32(value.Float())
+		case reflect.Float64:
+			return value.Float()
+		default:
+		}
+	}
+	return value.Interface()
+}
+
+func isBasicKind(kind reflect.Kind) bool {
+	switch kind {
+	case reflect.String, reflect.Bool, reflect.Int, reflect.Int32, reflect.Int64, reflect.Float32, reflect.Float64:
+		return true
+	default:
+		return false
+	}
+}
@@ -0,0 +1,92 @@
+// Copyright (c) 2025 Bytedance Ltd. and/or its affiliates
+// SPDX-License-Identifier: MIT
+
+package entity
+
+import (
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+type Tone string
+
+type commonVariables struct {
+	UserName string `loop:"user_name"`
+}
+
+type testVariables struct {
+	commonVariables
+	Query    string     `loop:"query"`
+	Tone     Tone       `loop:"tone"`
+	TopK     *int       `loop:"top_k"`
+	Extra    string     `loop:"extra,omitempty"`
+	History  []*Message `loop:"history"`
+	Ignored  string     `loop:"-"`
+	Language string
+	internal string
+}
+
+func TestBindVariables(t *testing.T) {
+	Convey("Test BindVariables", t, func() {
+		Convey("When v is struct with tags", func() {
+			topK := 3
+			history := []*Message{{Role: RoleUser}}
+			variables, err := BindVariables(&testVariables{
+				commonVariables: commonVariables{UserName: "Tom"},
+				Query:           "hi",
+				Tone:            "formal",
+				TopK:            &topK,
+				History:         history,
+				Ignored:         "ignored",
+				Language:        "en",
+				internal:        "internal",
+			})
+			So(err, ShouldBeNil)
+			So(variables, ShouldResemble, map[string]any{
+				"user_name": "Tom",
+				"query":     "hi",
+				"tone":      "formal",
+				"top_k":     3,
+				"history":   history,
+				"Language":  "en",
+			})
+		})
+
+		Convey("When nil fields and zero omitempty fields", func() {
+			variables, err := BindVariables(testVariables{})
+			So(err, ShouldBeNil)
+			So(variables, ShouldResemble, map[string]any{
+				"user_name": "",
+				"query":     "",
+				"tone":      "",
+				"Language":  "",
+			})
+		})
+
+		Convey("When v is nil, nil pointer or map", func() {
+			variables, err := BindVariables(nil)
+			So(err, ShouldBeNil)
+			So(variables, ShouldBeNil)
+			variables, err = BindVariables((*testVariables)(nil))
+			So(err, ShouldBeNil)
+			So(variables, ShouldBeNil)
+			variables, err = BindVariables(map[string]any{"a": 1})
+			So(err, ShouldBeNil)
+			So(variables, ShouldResemble, map[string]any{"a": 1})
+		})
+
+		Convey("When v is not struct", func() {
+			_, err := BindVariables("query")
+			So(err, ShouldNotBeNil)
+		})
+
+		Convey("When variable is bound by more than one field", func() {
+			_, err := BindVariables(struct {
+				A string `loop:"query"`
+				B string `loop:"query"`
+			}{})
+			So(err, ShouldNotBeNil)
+		})
+	})
+}
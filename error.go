@@ -5,12 +5,38 @@ package cozeloop
 
 import (
 	"github.com/coze-dev/cozeloop-go/internal/consts"
+	"github.com/coze-dev/cozeloop-go/internal/trace"
 )
 
 var (
 	ErrInvalidParam  = consts.ErrInvalidParam
 	ErrHeaderParent  = consts.ErrHeaderParent
 	ErrRemoteService = consts.ErrRemoteService
+	// ErrPromptNotFound is returned by GetPrompt when the prompt does not exist, if WithPromptNotFoundError is set.
+	ErrPromptNotFound = consts.ErrPromptNotFound
+	// ErrCircuitOpen is returned when the request is rejected by circuit breaker, if WithCircuitBreaker is set.
+	ErrCircuitOpen = consts.ErrCircuitOpen
+	// ErrSpanFinished is returned by SetTagsE when the span is already finished.
+	ErrSpanFinished = consts.ErrSpanFinished
+	// ErrTagInvalidType, ErrTagTooLarge and ErrTagCountExceeded are the reasons of the tags dropped by SetTagsE.
+	ErrTagInvalidType   = consts.ErrTagInvalidType
+	ErrTagTooLarge      = consts.ErrTagTooLarge
+	ErrTagCountExceeded = consts.ErrTagCountExceeded
+	// ErrToolCallLimit is returned by ExecuteWithTools when the model still calls tools after the max rounds.
+	ErrToolCallLimit = consts.ErrToolCallLimit
+	// ErrStructuredOutput is returned by ExecuteStructured when the output of model is still invalid after retries.
+	ErrStructuredOutput = consts.ErrStructuredOutput
+	// ErrClientDisabled is returned by the APIs which need CozeLoop service when the client is disabled, see WithDisabled.
+	ErrClientDisabled = consts.ErrClientDisabled
+	// ErrClientClosed is returned by the APIs of client after it is closed, including Shutdown called more than once.
+	ErrClientClosed = consts.ErrClientClosed
+
+	// ErrAuthExpired, ErrPermissionDenied, ErrRateLimited and ErrWorkspaceNotFound are the failure modes of
+	// CozeLoop service, which are matched by errors.Is, see package looperr.
+	ErrAuthExpired       = consts.ErrAuthExpired
+	ErrPermissionDenied  = consts.ErrPermissionDenied
+	ErrRateLimited       = consts.ErrRateLimited
+	ErrWorkspaceNotFound = consts.ErrWorkspaceNotFound
 
 	ErrAuthInfoRequired = consts.ErrAuthInfoRequired
 	ErrParsePrivateKey  = consts.ErrParsePrivateKey
@@ -19,4 +45,6 @@ var (
 type (
 	AuthError          = consts.AuthError
 	RemoteServiceError = consts.RemoteServiceError
+	// TagErrors the errors of tags dropped by Span.SetTagsE, by tag key.
+	TagErrors = trace.TagErrors
 )
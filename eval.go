@@ -0,0 +1,37 @@
+// Copyright (c) 2025 Bytedance Ltd. and/or its affiliates
+// SPDX-License-Identifier: MIT
+
+package cozeloop
+
+import (
+	"context"
+	"time"
+
+	"github.com/coze-dev/cozeloop-go/entity"
+	"github.com/coze-dev/cozeloop-go/internal/eval"
+)
+
+// EvalClient interface of evaluation client.
+type EvalClient interface {
+	// CreateEvalDataset create a dataset for evaluation
+	CreateEvalDataset(ctx context.Context, param *entity.CreateEvalDatasetParam) (*entity.EvalDataset, error)
+	// UploadEvalItems upload eval items to the dataset. Items are uploaded in batches.
+	UploadEvalItems(ctx context.Context, datasetID string, items []*entity.EvalItem) error
+	// RunEvaluation trigger an evaluation run of the dataset against a prompt key and version
+	RunEvaluation(ctx context.Context, param *entity.RunEvaluationParam) (*entity.EvalRun, error)
+	// GetEvalRun get the status of an evaluation run
+	GetEvalRun(ctx context.Context, runID string) (*entity.EvalRun, error)
+	// WaitEvalRun poll the evaluation run until it is finished or ctx is done
+	WaitEvalRun(ctx context.Context, runID string, options ...WaitEvalRunOption) (*entity.EvalRun, error)
+	// ListEvalResults list the results of an evaluation run with pagination
+	ListEvalResults(ctx context.Context, param *entity.ListEvalResultsParam) (*entity.ListEvalResultsResult, error)
+}
+
+type WaitEvalRunOption func(option *eval.WaitEvalRunOptions)
+
+// WithPollInterval set the interval of polling eval run status. Default is 5s
+func WithPollInterval(interval time.Duration) WaitEvalRunOption {
+	return func(option *eval.WaitEvalRunOptions) {
+		option.PollInterval = interval
+	}
+}
@@ -0,0 +1,167 @@
+// Copyright (c) 2025 Bytedance Ltd. and/or its affiliates
+// SPDX-License-Identifier: MIT
+
+package cozeloop
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/coze-dev/cozeloop-go/entity"
+	"github.com/coze-dev/cozeloop-go/internal/util"
+)
+
+// SpanTypeStructuredOutput type of the span started by ExecuteStructured, the execute spans are its children.
+const SpanTypeStructuredOutput = "structured_output"
+
+// Tags of the span started by ExecuteStructured.
+const (
+	TagStructuredOutputAttempts = "structured_output_attempts"
+	TagStructuredOutputErrors   = "structured_output_errors"
+)
+
+const defaultStructuredOutputRetries = 1
+
+type executeStructuredOptions struct {
+	client         Client
+	retries        int
+	schemaPrompt   bool
+	executeOptions []ExecuteOption
+}
+
+type ExecuteStructuredOption func(o *executeStructuredOptions)
+
+// WithStructuredClient set the client used to execute prompt and start span. Default is the default client.
+func WithStructuredClient(client Client) ExecuteStructuredOption {
+	return func(o *executeStructuredOptions) {
+		o.client = client
+	}
+}
+
+// WithStructuredRetries set how many times to execute again if the output is not valid, the errors are told to
+// model in the retries. Default is 1, no retry if it is negative.
+func WithStructuredRetries(retries int) ExecuteStructuredOption {
+	return func(o *executeStructuredOptions) {
+		o.retries = retries
+	}
+}
+
+// WithStructuredSchemaPrompt set whether to append a system message with the JSON schema of output, enable it if the
+// prompt doesn't describe the output format. Default is false.
+func WithStructuredSchemaPrompt(enable bool) ExecuteStructuredOption {
+	return func(o *executeStructuredOptions) {
+		o.schemaPrompt = enable
+	}
+}
+
+// WithStructuredExecuteOptions set the options passed to every Execute. Default is nil.
+func WithStructuredExecuteOptions(options ...ExecuteOption) ExecuteStructuredOption {
+	return func(o *executeStructuredOptions) {
+		o.executeOptions = append(o.executeOptions, options...)
+	}
+}
+
+// ExecuteStructured executes prompt in JSON mode, validates the output against the JSON schema of T, and unmarshals
+// it to T, which is a struct whose schema is generated by entity.JSONSchemaOf. If the output is invalid, the prompt
+// is executed again with the errors told to model. The executions are traced as children of a span of type
+// SpanTypeStructuredOutput, which records the validation errors. The result is the last execute result, with
+// the token usage of all executions, and ErrStructuredOutput is returned if the output is still invalid.
+func ExecuteStructured[T any](ctx context.Context, param *entity.ExecuteParam, opts ...ExecuteStructuredOption) (output T, result entity.ExecuteResult, err error) {
+	if param == nil {
+		return output, result, ErrInvalidParam.Wrap(fmt.Errorf("param is required"))
+	}
+	schema, err := entity.JSONSchemaOf(&output)
+	if err != nil {
+		return output, result, ErrInvalidParam.Wrap(err)
+	}
+	o := &executeStructuredOptions{retries: defaultStructuredOutputRetries}
+	for _, opt := range opts {
+		opt(o)
+	}
+	if o.client == nil {
+		o.client = getDefaultClient()
+	}
+
+	ctx, span := o.client.StartSpan(ctx, "ExecuteStructured", SpanTypeStructuredOutput)
+	defer span.Finish(ctx)
+
+	round := *param
+	round.Messages = append([]*entity.Message(nil), param.Messages...)
+	if o.schemaPrompt {
+		round.Messages = append(round.Messages, &entity.Message{
+			Role:    entity.RoleSystem,
+			Content: util.Ptr("Respond with a JSON object which conforms to the JSON schema:\n" + schema),
+		})
+	}
+	llmConfig := &entity.LLMConfig{}
+	if param.LLMConfig != nil {
+		llmConfig = param.LLMConfig.DeepCopy()
+	}
+	llmConfig.JSONMode = util.Ptr(true)
+	round.LLMConfig = llmConfig
+
+	usage := &entity.TokenUsage{}
+	var validationErrors []string
+	attempts, maxAttempts := 0, 1
+	if o.retries > 0 {
+		maxAttempts += o.retries
+	}
+	for attempts < maxAttempts {
+		attempts++
+		result, err = o.client.Execute(ctx, &round, o.executeOptions...)
+		if result.Usage != nil {
+			usage.InputTokens += result.Usage.InputTokens
+			usage.OutputTokens += result.Usage.OutputTokens
+			result.Usage = usage
+		}
+		if err != nil {
+			break
+		}
+		var content string
+		if result.Message != nil {
+			content = trimJSONCodeFence(util.PtrValue(result.Message.Content))
+			round.Messages = append(round.Messages, result.Message)
+		}
+		if err = entity.ValidateJSONSchema(schema, []byte(content)); err == nil {
+			var zero T
+			output = zero
+			if err = json.Unmarshal([]byte(content), &output); err == nil {
+				break
+			}
+		}
+		validationErrors = append(validationErrors, err.Error())
+		err = ErrStructuredOutput.Wrap(err)
+		round.Messages = append(round.Messages, &entity.Message{
+			Role: entity.RoleUser,
+			Content: util.Ptr(fmt.Sprintf("The output is invalid: %s. Respond again with only the JSON object "+
+				"which conforms to the schema.", validationErrors[len(validationErrors)-1])),
+		})
+	}
+
+	tags := map[string]any{TagStructuredOutputAttempts: attempts}
+	if len(validationErrors) > 0 {
+		tags[TagStructuredOutputErrors] = util.ToJSON(validationErrors)
+	}
+	span.SetTags(ctx, tags)
+	if err != nil {
+		span.SetStatusCode(ctx, util.GetErrorCode(err))
+		span.SetError(ctx, err)
+		return output, result, err
+	}
+	span.SetOutput(ctx, output)
+	return output, result, nil
+}
+
+// trimJSONCodeFence removes the markdown code fence around JSON, which some models add even in JSON mode.
+func trimJSONCodeFence(content string) string {
+	content = strings.TrimSpace(content)
+	if !strings.HasPrefix(content, "```") {
+		return content
+	}
+	content = strings.TrimPrefix(content, "```")
+	content = strings.TrimPrefix(content, "json")
+	content = strings.TrimSuffix(content, "```")
+	return strings.TrimSpace(content)
+}
@@ -0,0 +1,88 @@
+// Copyright (c) 2025 Bytedance Ltd. and/or its affiliates
+// SPDX-License-Identifier: MIT
+
+package cozeloop
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+
+	"github.com/coze-dev/cozeloop-go/entity"
+	"github.com/coze-dev/cozeloop-go/internal/util"
+)
+
+type sentiment struct {
+	Label      string  `json:"label" jsonschema:"enum=positive,enum=negative"`
+	Confidence float64 `json:"confidence" jsonschema:"minimum=0,maximum=1"`
+}
+
+func textResult(content string) entity.ExecuteResult {
+	return entity.ExecuteResult{
+		Message: &entity.Message{Role: entity.RoleAssistant, Content: util.Ptr(content)},
+		Usage:   &entity.TokenUsage{InputTokens: 10, OutputTokens: 5},
+	}
+}
+
+func TestExecuteStructured(t *testing.T) {
+	ctx := context.Background()
+	param := &entity.ExecuteParam{
+		PromptKey: "sentiment",
+		Messages:  []*entity.Message{{Role: entity.RoleUser, Content: util.Ptr("I like it")}},
+	}
+
+	Convey("Test valid output", t, func() {
+		client := &toolsClient{results: []entity.ExecuteResult{
+			textResult("```json\n{\"label\":\"positive\",\"confidence\":0.9}\n```"),
+		}}
+		output, result, err := ExecuteStructured[sentiment](ctx, param, WithStructuredClient(client), WithStructuredSchemaPrompt(true))
+		So(err, ShouldBeNil)
+		So(output, ShouldResemble, sentiment{Label: "positive", Confidence: 0.9})
+		So(result.Usage.OutputTokens, ShouldEqual, 5)
+		So(len(client.params), ShouldEqual, 1)
+		So(*client.params[0].LLMConfig.JSONMode, ShouldBeTrue)
+		So(len(client.params[0].Messages), ShouldEqual, 2)
+		So(*client.params[0].Messages[1].Content, ShouldContainSubstring, `"enum":["positive","negative"]`)
+		So(param.LLMConfig, ShouldBeNil)
+		So(len(param.Messages), ShouldEqual, 1)
+	})
+
+	Convey("Test invalid output is retried", t, func() {
+		client := &toolsClient{results: []entity.ExecuteResult{
+			textResult(`{"label":"neutral","confidence":0.5}`),
+			textResult(`{"label":"negative","confidence":0.8}`),
+		}}
+		output, result, err := ExecuteStructured[sentiment](ctx, param, WithStructuredClient(client))
+		So(err, ShouldBeNil)
+		So(output.Label, ShouldEqual, "negative")
+		So(*result.Usage, ShouldResemble, entity.TokenUsage{InputTokens: 20, OutputTokens: 10})
+		messages := client.params[1].Messages
+		So(len(messages), ShouldEqual, 3)
+		So(*messages[2].Content, ShouldContainSubstring, "$.label")
+	})
+
+	Convey("Test output is still invalid after retries", t, func() {
+		client := &toolsClient{results: []entity.ExecuteResult{
+			textResult(`not json`),
+			textResult(`{"label":"positive"}`),
+		}}
+		_, _, err := ExecuteStructured[sentiment](ctx, param, WithStructuredClient(client))
+		So(errors.Is(err, ErrStructuredOutput), ShouldBeTrue)
+		So(err.Error(), ShouldContainSubstring, "confidence")
+		So(len(client.params), ShouldEqual, 2)
+
+		client = &toolsClient{results: []entity.ExecuteResult{textResult(`not json`)}}
+		_, _, err = ExecuteStructured[sentiment](ctx, param, WithStructuredClient(client), WithStructuredRetries(-1))
+		So(errors.Is(err, ErrStructuredOutput), ShouldBeTrue)
+		So(len(client.params), ShouldEqual, 1)
+	})
+
+	Convey("Test invalid param", t, func() {
+		_, _, err := ExecuteStructured[sentiment](ctx, nil)
+		So(err, ShouldNotBeNil)
+		_, _, err = ExecuteStructured[string](ctx, param)
+		So(err, ShouldNotBeNil)
+	})
+}
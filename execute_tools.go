@@ -0,0 +1,158 @@
+// Copyright (c) 2025 Bytedance Ltd. and/or its affiliates
+// SPDX-License-Identifier: MIT
+
+package cozeloop
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/coze-dev/cozeloop-go/entity"
+	"github.com/coze-dev/cozeloop-go/internal/util"
+)
+
+const defaultMaxToolCallRounds = 10
+
+// ToolFunc handles a tool call of model, arguments is the JSON arguments generated by model, and the result is
+// passed back to model as the content of tool message. Use NewToolFunc to wrap a typed Go function.
+type ToolFunc func(ctx context.Context, arguments string) (string, error)
+
+// NewToolFunc wraps fn as ToolFunc. The arguments of model are unmarshaled to A, so A should match the JSON schema
+// of tool parameters, which can be generated from A by entity.NewFunctionTool. The result is marshaled to JSON
+// unless it is a string.
+func NewToolFunc[A any, R any](fn func(ctx context.Context, args A) (R, error)) ToolFunc {
+	return func(ctx context.Context, arguments string) (string, error) {
+		var args A
+		if arguments != "" {
+			if err := json.Unmarshal([]byte(arguments), &args); err != nil {
+				return "", fmt.Errorf("invalid arguments: %w", err)
+			}
+		}
+		result, err := fn(ctx, args)
+		if err != nil {
+			return "", err
+		}
+		if s, ok := any(result).(string); ok {
+			return s, nil
+		}
+		data, err := json.Marshal(result)
+		if err != nil {
+			return "", err
+		}
+		return string(data), nil
+	}
+}
+
+type executeWithToolsOptions struct {
+	client         Client
+	maxRounds      int
+	executeOptions []ExecuteOption
+}
+
+type ExecuteWithToolsOption func(o *executeWithToolsOptions)
+
+// WithToolsClient set the client used to execute prompt and start tool spans. Default is the default client.
+func WithToolsClient(client Client) ExecuteWithToolsOption {
+	return func(o *executeWithToolsOptions) {
+		o.client = client
+	}
+}
+
+// WithMaxToolCallRounds set the max rounds of executing prompt and calling tools, ErrToolCallLimit is returned
+// if the model still calls tools in the last round. Default is 10.
+func WithMaxToolCallRounds(maxRounds int) ExecuteWithToolsOption {
+	return func(o *executeWithToolsOptions) {
+		o.maxRounds = maxRounds
+	}
+}
+
+// WithToolsExecuteOptions set the options passed to every Execute. Default is nil.
+func WithToolsExecuteOptions(options ...ExecuteOption) ExecuteWithToolsOption {
+	return func(o *executeWithToolsOptions) {
+		o.executeOptions = append(o.executeOptions, options...)
+	}
+}
+
+// ExecuteWithTools executes prompt, calls the tools requested by model and executes again with the tool messages,
+// until the model replies without tool calls. Each tool call is traced as a tool span. Errors of tools, including
+// unknown tools and invalid arguments, are passed back to model as the tool message, so that it can correct the
+// call. The result is the last reply of model, with the token usage of all rounds. The messages of param are
+// not modified.
+func ExecuteWithTools(ctx context.Context, param *entity.ExecuteParam, tools map[string]ToolFunc, opts ...ExecuteWithToolsOption) (entity.ExecuteResult, error) {
+	if param == nil {
+		return entity.ExecuteResult{}, ErrInvalidParam.Wrap(fmt.Errorf("param is required"))
+	}
+	o := &executeWithToolsOptions{maxRounds: defaultMaxToolCallRounds}
+	for _, opt := range opts {
+		opt(o)
+	}
+	if o.maxRounds <= 0 {
+		o.maxRounds = defaultMaxToolCallRounds
+	}
+	if o.client == nil {
+		o.client = getDefaultClient()
+	}
+
+	round := *param
+	round.Messages = append([]*entity.Message(nil), param.Messages...)
+	usage := &entity.TokenUsage{}
+	for i := 0; i < o.maxRounds; i++ {
+		result, err := o.client.Execute(ctx, &round, o.executeOptions...)
+		if result.Usage != nil {
+			usage.InputTokens += result.Usage.InputTokens
+			usage.OutputTokens += result.Usage.OutputTokens
+			result.Usage = usage
+		}
+		if err != nil || result.Message == nil || len(result.Message.ToolCalls) == 0 {
+			return result, err
+		}
+		if i == o.maxRounds-1 {
+			return result, ErrToolCallLimit.Wrap(fmt.Errorf("model still calls tools after %d rounds", o.maxRounds))
+		}
+
+		round.Messages = append(round.Messages, result.Message)
+		for _, toolCall := range result.Message.ToolCalls {
+			if toolCall == nil {
+				continue
+			}
+			round.Messages = append(round.Messages, &entity.Message{
+				Role:       entity.RoleTool,
+				Content:    util.Ptr(callTool(ctx, o.client, tools, toolCall)),
+				ToolCallID: util.Ptr(toolCall.ID),
+			})
+		}
+	}
+	return entity.ExecuteResult{}, nil
+}
+
+// callTool calls the tool in a tool span, and returns the content of tool message.
+func callTool(ctx context.Context, client Client, tools map[string]ToolFunc, toolCall *entity.ToolCall) string {
+	var name, arguments string
+	if toolCall.FunctionCall != nil {
+		name = toolCall.FunctionCall.Name
+		arguments = util.PtrValue(toolCall.FunctionCall.Arguments)
+	}
+
+	var opts []StartSpanOption
+	if toolCall.ID != "" {
+		opts = append(opts, WithToolCallID(toolCall.ID))
+	}
+	ctx, span := startToolSpan(ctx, client, name, arguments, opts...)
+	defer span.Finish(ctx)
+
+	var result string
+	var err error
+	if tool, ok := tools[name]; ok && tool != nil {
+		result, err = tool(ctx, arguments)
+	} else {
+		err = fmt.Errorf("tool %q not found", name)
+	}
+	if err != nil {
+		span.SetStatusCode(ctx, util.GetErrorCode(err))
+		span.SetError(ctx, err)
+		return fmt.Sprintf("error: %v", err)
+	}
+	span.SetOutput(ctx, result)
+	return result
+}
@@ -0,0 +1,140 @@
+// Copyright (c) 2025 Bytedance Ltd. and/or its affiliates
+// SPDX-License-Identifier: MIT
+
+package cozeloop
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+
+	"github.com/coze-dev/cozeloop-go/entity"
+	"github.com/coze-dev/cozeloop-go/internal/util"
+	"github.com/coze-dev/cozeloop-go/spec/tracespec"
+)
+
+type toolsClient struct {
+	NoopClient
+	results []entity.ExecuteResult
+	params  []entity.ExecuteParam
+	spans   []*startSpanOptions
+}
+
+func (c *toolsClient) StartSpan(ctx context.Context, name, spanType string, opts ...StartSpanOption) (context.Context, Span) {
+	o := &startSpanOptions{}
+	for _, opt := range opts {
+		opt(o)
+	}
+	c.spans = append(c.spans, o)
+	return c.NoopClient.StartSpan(ctx, name, spanType, opts...)
+}
+
+func (c *toolsClient) Execute(ctx context.Context, param *entity.ExecuteParam, options ...ExecuteOption) (entity.ExecuteResult, error) {
+	c.params = append(c.params, entity.ExecuteParam{
+		PromptKey: param.PromptKey,
+		Messages:  append([]*entity.Message(nil), param.Messages...),
+		LLMConfig: param.LLMConfig,
+	})
+	if len(c.params) > len(c.results) {
+		return entity.ExecuteResult{}, errors.New("no more results")
+	}
+	return c.results[len(c.params)-1], nil
+}
+
+func toolCallResult(calls ...*entity.ToolCall) entity.ExecuteResult {
+	return entity.ExecuteResult{
+		Message:      &entity.Message{Role: entity.RoleAssistant, ToolCalls: calls},
+		FinishReason: util.Ptr("tool_calls"),
+		Usage:        &entity.TokenUsage{InputTokens: 10, OutputTokens: 5},
+	}
+}
+
+func newToolCall(id, name, arguments string) *entity.ToolCall {
+	return &entity.ToolCall{ID: id, Type: entity.ToolTypeFunction, FunctionCall: &entity.FunctionCall{Name: name, Arguments: util.Ptr(arguments)}}
+}
+
+type weatherArgs struct {
+	City string `json:"city"`
+}
+
+type weather struct {
+	City        string `json:"city"`
+	Temperature int    `json:"temperature"`
+}
+
+func TestExecuteWithTools(t *testing.T) {
+	ctx := context.Background()
+	tools := map[string]ToolFunc{
+		"get_weather": NewToolFunc(func(ctx context.Context, args weatherArgs) (*weather, error) {
+			if args.City == "" {
+				return nil, fmt.Errorf("city is required")
+			}
+			return &weather{City: args.City, Temperature: 20}, nil
+		}),
+		"now": NewToolFunc(func(ctx context.Context, args struct{}) (string, error) {
+			return "12:00", nil
+		}),
+	}
+	param := &entity.ExecuteParam{
+		PromptKey: "assistant",
+		Messages:  []*entity.Message{{Role: entity.RoleUser, Content: util.Ptr("weather in Beijing?")}},
+	}
+
+	Convey("Test tool calls are answered until model replies", t, func() {
+		client := &toolsClient{results: []entity.ExecuteResult{
+			toolCallResult(newToolCall("1", "get_weather", `{"city":"Beijing"}`), newToolCall("2", "now", "")),
+			toolCallResult(newToolCall("3", "get_weather", `{}`), newToolCall("4", "unknown", `{}`), newToolCall("5", "get_weather", `[`)),
+			{
+				Message:      &entity.Message{Role: entity.RoleAssistant, Content: util.Ptr("sunny, 20°C")},
+				FinishReason: util.Ptr("stop"),
+				Usage:        &entity.TokenUsage{InputTokens: 10, OutputTokens: 5},
+			},
+		}}
+		result, err := ExecuteWithTools(ctx, param, tools, WithToolsClient(client))
+		So(err, ShouldBeNil)
+		So(*result.Message.Content, ShouldEqual, "sunny, 20°C")
+		So(*result.Usage, ShouldResemble, entity.TokenUsage{InputTokens: 30, OutputTokens: 15})
+		So(len(param.Messages), ShouldEqual, 1)
+
+		So(len(client.params), ShouldEqual, 3)
+		messages := client.params[1].Messages
+		So(len(messages), ShouldEqual, 4)
+		So(messages[2].Role, ShouldEqual, entity.RoleTool)
+		So(*messages[2].ToolCallID, ShouldEqual, "1")
+		So(*messages[2].Content, ShouldEqual, `{"city":"Beijing","temperature":20}`)
+		So(*messages[3].Content, ShouldEqual, "12:00")
+
+		// tool spans are linked to the tool calls
+		So(len(client.spans), ShouldEqual, 5)
+		So(client.spans[0].Tags[tracespec.ToolCallID], ShouldEqual, "1")
+
+		// errors are passed back to model
+		messages = client.params[2].Messages
+		So(len(messages), ShouldEqual, 8)
+		So(*messages[5].Content, ShouldEqual, "error: city is required")
+		So(*messages[6].Content, ShouldEqual, `error: tool "unknown" not found`)
+		So(*messages[7].Content, ShouldStartWith, "error: invalid arguments")
+	})
+
+	Convey("Test tool call rounds limit", t, func() {
+		client := &toolsClient{results: []entity.ExecuteResult{
+			toolCallResult(newToolCall("1", "now", "")),
+			toolCallResult(newToolCall("2", "now", "")),
+		}}
+		result, err := ExecuteWithTools(ctx, param, tools, WithToolsClient(client), WithMaxToolCallRounds(2))
+		So(errors.Is(err, ErrToolCallLimit), ShouldBeTrue)
+		So(len(result.Message.ToolCalls), ShouldEqual, 1)
+		So(len(client.params), ShouldEqual, 2)
+	})
+
+	Convey("Test execute error", t, func() {
+		client := &toolsClient{}
+		_, err := ExecuteWithTools(ctx, param, tools, WithToolsClient(client))
+		So(err, ShouldNotBeNil)
+		_, err = ExecuteWithTools(ctx, nil, tools, WithToolsClient(client))
+		So(err, ShouldNotBeNil)
+	})
+}
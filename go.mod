@@ -11,6 +11,7 @@ require (
 	github.com/smartystreets/goconvey v1.8.1
 	github.com/valyala/fasttemplate v1.2.2
 	golang.org/x/sync v0.11.0
+	gopkg.in/yaml.v3 v3.0.1
 )
 
 require (
@@ -0,0 +1,106 @@
+// Copyright (c) 2025 Bytedance Ltd. and/or its affiliates
+// SPDX-License-Identifier: MIT
+
+package cozeloop
+
+import (
+	"fmt"
+	"net/http"
+)
+
+// SpanTypeHTTPClient type of the span started by the transport wrapped by WrapTransport.
+const SpanTypeHTTPClient = "http_client"
+
+// Tags for http client span, the same as the ones of server span in package servertrace.
+const (
+	tagHTTPMethod     = "http.method"
+	tagHTTPURL        = "http.url"
+	tagHTTPStatusCode = "http.status_code"
+)
+
+type transportOptions struct {
+	client   TraceClient
+	spanType string
+}
+
+type TransportOption func(o *transportOptions)
+
+// WithTransportClient set the client used to start spans. Default is the default client.
+func WithTransportClient(client TraceClient) TransportOption {
+	return func(o *transportOptions) {
+		o.client = client
+	}
+}
+
+// WithTransportSpanType set the type of the span of request. Default is SpanTypeHTTPClient.
+func WithTransportSpanType(spanType string) TransportOption {
+	return func(o *transportOptions) {
+		o.spanType = spanType
+	}
+}
+
+// WrapTransport wraps base to start a child span of the span in request context for each request, and inject the
+// trace context and baggage headers of it, the same as Span.ToHeader, so the trace is continued by the downstream
+// service, such as the one using package servertrace, without passing headers manually.
+// Requests without span in context are sent as is, so it is safe to wrap http.DefaultTransport, which may also be
+// used to report spans. http.DefaultTransport is used if base is nil.
+//
+//	httpClient := &http.Client{Transport: cozeloop.WrapTransport(http.DefaultTransport)}
+//	req, _ := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+//	resp, err := httpClient.Do(req)
+func WrapTransport(base http.RoundTripper, opts ...TransportOption) http.RoundTripper {
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	o := &transportOptions{spanType: SpanTypeHTTPClient}
+	for _, opt := range opts {
+		opt(o)
+	}
+	return &tracedTransport{base: base, o: o}
+}
+
+type tracedTransport struct {
+	base http.RoundTripper
+	o    *transportOptions
+}
+
+func (t *tracedTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	client := t.o.client
+	if client == nil {
+		client = getDefaultClient()
+	}
+	ctx := req.Context()
+	if parent := client.GetSpanFromContext(ctx); parent == nil || parent.GetSpanID() == "" {
+		return t.base.RoundTrip(req)
+	}
+
+	ctx, span := client.StartSpan(ctx, req.Method+" "+req.URL.Host, t.o.spanType)
+	// the query is not recorded, as it may contain secrets
+	span.SetTags(ctx, map[string]any{
+		tagHTTPMethod: req.Method,
+		tagHTTPURL:    req.URL.Scheme + "://" + req.URL.Host + req.URL.Path,
+	})
+	// RoundTripper should not modify the request, so the headers are set to a copy of it
+	req = req.Clone(ctx)
+	if header, err := span.ToHeader(); err == nil {
+		for key, value := range header {
+			if value != "" {
+				req.Header.Set(key, value)
+			}
+		}
+	}
+
+	resp, err := t.base.RoundTrip(req)
+	if err != nil {
+		span.SetError(ctx, err)
+		span.Finish(ctx)
+		return resp, err
+	}
+	span.SetTags(ctx, map[string]any{tagHTTPStatusCode: resp.StatusCode})
+	if resp.StatusCode >= http.StatusInternalServerError {
+		span.SetStatusCode(ctx, resp.StatusCode)
+		span.SetError(ctx, fmt.Errorf("%d %s", resp.StatusCode, http.StatusText(resp.StatusCode)))
+	}
+	span.Finish(ctx)
+	return resp, nil
+}
@@ -0,0 +1,81 @@
+// Copyright (c) 2025 Bytedance Ltd. and/or its affiliates
+// SPDX-License-Identifier: MIT
+
+package cozeloop
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+
+	"github.com/coze-dev/cozeloop-go/internal/consts"
+)
+
+func TestWrapTransport(t *testing.T) {
+	var traceparents []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		traceparents = append(traceparents, r.Header.Get(consts.TraceContextHeaderParent))
+		if r.URL.Path == "/error" {
+			w.WriteHeader(http.StatusBadGateway)
+		}
+	}))
+	defer server.Close()
+
+	ctx := context.Background()
+	var spans []*ReadOnlySpan
+	client, err := NewClient(WithWorkspaceID("transport"), WithAPIToken("token"), WithExporter(&recordExporter{}),
+		WithSpanConsumer(func(ctx context.Context, span *ReadOnlySpan) {
+			spans = append(spans, span)
+		}), WithNoClientCache())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer client.Close(ctx)
+	httpClient := &http.Client{Transport: WrapTransport(nil, WithTransportClient(client))}
+
+	Convey("Test requests without span are not traced", t, func() {
+		traceparents, spans = nil, nil
+		req, _ := http.NewRequestWithContext(ctx, http.MethodGet, server.URL+"/ok", nil)
+		resp, err := httpClient.Do(req)
+		So(err, ShouldBeNil)
+		_ = resp.Body.Close()
+		So(traceparents, ShouldResemble, []string{""})
+		So(spans, ShouldBeEmpty)
+	})
+
+	Convey("Test client span is started and its headers are injected", t, func() {
+		traceparents, spans = nil, nil
+		parentCtx, parent := client.StartSpan(ctx, "parent", "custom")
+		req, _ := http.NewRequestWithContext(parentCtx, http.MethodGet, server.URL+"/ok?token=secret", nil)
+		resp, err := httpClient.Do(req)
+		So(err, ShouldBeNil)
+		_ = resp.Body.Close()
+		So(req.Header.Get(consts.TraceContextHeaderParent), ShouldBeEmpty)
+
+		So(len(spans), ShouldEqual, 1)
+		span := spans[0]
+		So(span.SpanType, ShouldEqual, SpanTypeHTTPClient)
+		So(span.TraceID, ShouldEqual, parent.GetTraceID())
+		So(span.ParentID, ShouldEqual, parent.GetSpanID())
+		So(span.Tags[tagHTTPURL], ShouldEqual, server.URL+"/ok")
+		So(span.Tags[tagHTTPStatusCode], ShouldEqual, http.StatusOK)
+		So(traceparents[0], ShouldContainSubstring, span.TraceID+"-"+span.SpanID)
+		parent.Finish(parentCtx)
+	})
+
+	Convey("Test server error is recorded", t, func() {
+		spans = nil
+		parentCtx, parent := client.StartSpan(ctx, "parent", "custom")
+		defer parent.Finish(parentCtx)
+		req, _ := http.NewRequestWithContext(parentCtx, http.MethodPost, server.URL+"/error", nil)
+		resp, err := httpClient.Do(req)
+		So(err, ShouldBeNil)
+		_ = resp.Body.Close()
+		So(len(spans), ShouldEqual, 1)
+		So(spans[0].StatusCode, ShouldEqual, http.StatusBadGateway)
+		So(spans[0].SpanName, ShouldStartWith, http.MethodPost+" ")
+	})
+}
@@ -9,12 +9,13 @@ import (
 
 // default values for loop client
 const (
-	// ComBaseURL = "https://api.coze.com"
+	ComBaseURL                        = "https://api.coze.com"
 	CnBaseURL                         = "https://api.coze.cn"
 	DefaultOAuthRefreshTTL            = 900 * time.Second
 	OAuthRefreshAdvanceTime           = 60 * time.Second
 	DefaultPromptCacheMaxCount        = 100
 	DefaultPromptCacheRefreshInterval = 1 * time.Minute
+	DefaultPromptNotFoundCacheTTL     = 10 * time.Second
 	DefaultTimeout                    = 3 * time.Second
 	DefaultUploadTimeout              = 30 * time.Second
 )
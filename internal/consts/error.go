@@ -4,24 +4,66 @@
 package consts
 
 import (
+	"errors"
 	"fmt"
+	"net/http"
+	"strings"
+	"sync"
 )
 
 var (
-	ErrInvalidParam  = NewError("invalid param")
-	ErrInternal      = NewError("internal error")
-	ErrRemoteService = NewError("remote service error")
-	ErrClientClosed  = NewError("client already closed")
+	ErrInvalidParam   = NewError("invalid param")
+	ErrInternal       = NewError("internal error")
+	ErrRemoteService  = NewError("remote service error")
+	ErrClientClosed   = NewError("client already closed")
+	ErrClientDisabled = NewError("client is disabled")
 
 	ErrAuthInfoRequired = NewError("api token or jwt oauth info is required")
 	ErrParsePrivateKey  = NewError("failed to parse private key")
 	ErrHeaderParent     = NewError("header traceparent is illegal")
 	ErrTemplateRender   = NewError("template render error")
+	ErrPromptNotFound   = NewError("prompt not found")
+	ErrCircuitOpen      = NewError("circuit breaker is open")
+	ErrSpanFinished     = NewError("span already finished")
+	ErrTagInvalidType   = NewError("tag value type is invalid")
+	ErrTagTooLarge      = NewError("tag value is too large")
+	ErrTagCountExceeded = NewError("tag count exceeds limit")
+	ErrToolCallLimit    = NewError("tool call rounds exceed limit")
+	ErrStructuredOutput = NewError("structured output is invalid")
+
+	// ErrAuthExpired, ErrPermissionDenied, ErrRateLimited and ErrWorkspaceNotFound are the failure modes of
+	// remote service, which are matched by RemoteServiceError.Is instead of being returned directly.
+	ErrAuthExpired       = NewError("auth expired or invalid")
+	ErrPermissionDenied  = NewError("permission denied")
+	ErrRateLimited       = NewError("rate limited")
+	ErrWorkspaceNotFound = NewError("workspace not found")
 )
 
+var (
+	errorCodesLock sync.RWMutex
+	// errorCodes sentinel errors of the error codes of remote service
+	errorCodes = map[int]error{}
+)
+
+// RegisterErrorCode maps the error code of remote service to a sentinel error, so that errors.Is reports the
+// RemoteServiceError with the code as the sentinel.
+func RegisterErrorCode(code int, sentinel error) {
+	errorCodesLock.Lock()
+	defer errorCodesLock.Unlock()
+	errorCodes[code] = sentinel
+}
+
+func errorOfCode(code int) error {
+	errorCodesLock.RLock()
+	defer errorCodesLock.RUnlock()
+	return errorCodes[code]
+}
+
 type LoopError struct {
 	Msg   string
 	cause error
+	// sentinel is the error wrapped from, so that errors.Is(err, sentinel) is true for the copy.
+	sentinel *LoopError
 }
 
 func NewError(msg string) *LoopError {
@@ -41,9 +83,19 @@ func (e *LoopError) Unwrap() error {
 	return e.cause
 }
 
+// Is reports whether target is the sentinel which the error is wrapped from.
+func (e *LoopError) Is(target error) bool {
+	return e.sentinel != nil && target == error(e.sentinel)
+}
+
+// Wrap returns a copy of the error with cause, the sentinel itself is never modified, so it is safe to be called
+// concurrently.
 func (e *LoopError) Wrap(err error) *LoopError {
-	e.cause = err
-	return e
+	sentinel := e
+	if e.sentinel != nil {
+		sentinel = e.sentinel
+	}
+	return &LoopError{Msg: e.Msg, cause: err, sentinel: sentinel}
 }
 
 type RemoteServiceError struct {
@@ -76,6 +128,54 @@ func (e *RemoteServiceError) Unwrap() error {
 	return e.cause
 }
 
+// Code returns the error code of remote service, -1 if the response is not valid.
+func (e *RemoteServiceError) Code() int {
+	return e.ErrCode
+}
+
+// HTTPStatus returns the http status code of response.
+func (e *RemoteServiceError) HTTPStatus() int {
+	return e.HttpCode
+}
+
+// RequestID returns the log id of request, which helps the platform team to locate the request.
+func (e *RemoteServiceError) RequestID() string {
+	return e.LogID
+}
+
+// Is reports whether the error is the failure mode of target, by the error code registered by RegisterErrorCode,
+// the http status and the code of oauth error.
+func (e *RemoteServiceError) Is(target error) bool {
+	if sentinel := errorOfCode(e.ErrCode); sentinel != nil && sentinel == target {
+		return true
+	}
+	var authCode AuthErrorCode
+	authErr := &AuthError{}
+	if errors.As(e.cause, &authErr) {
+		authCode = authErr.Code
+	}
+	switch target {
+	case ErrAuthExpired:
+		return e.HttpCode == http.StatusUnauthorized || authCode == ExpiredToken
+	case ErrPermissionDenied:
+		return e.HttpCode == http.StatusForbidden || authCode == AccessDenied
+	case ErrRateLimited:
+		return e.HttpCode == http.StatusTooManyRequests || authCode == SlowDown
+	case ErrWorkspaceNotFound:
+		return e.HttpCode == http.StatusNotFound && strings.Contains(strings.ToLower(e.ErrMsg), "workspace")
+	}
+	return false
+}
+
+// RequestIDOf returns the log id of request of the RemoteServiceError in the chain of err, empty if there is none.
+func RequestIDOf(err error) string {
+	remoteErr := &RemoteServiceError{}
+	if errors.As(err, &remoteErr) {
+		return remoteErr.LogID
+	}
+	return ""
+}
+
 func (e *RemoteServiceError) Wrap(err error) *RemoteServiceError {
 	e.cause = err
 	return e
@@ -5,12 +5,22 @@ package consts
 
 // System reserved tag fields.
 const (
-	UserID             = "user_id"
-	MessageID          = "message_id"
-	ThreadID           = "thread_id"
-	StartTimeFirstResp = "start_time_first_resp"
-	LatencyFirstResp   = "latency_first_resp"
-	DeploymentEnv      = "deployment_env"
+	UserID              = "user_id"
+	MessageID           = "message_id"
+	ThreadID            = "thread_id"
+	StartTimeFirstResp  = "start_time_first_resp"
+	LatencyFirstResp    = "latency_first_resp"
+	DeploymentEnv       = "deployment_env"
+	Panic               = "panic"
+	PanicStack          = "panic_stack"
+	PromptExperiment    = "prompt_experiment"
+	PromptExperimentArm = "prompt_experiment_arm"
+	Leaked              = "leaked"
+	LeakedCreationSite  = "leaked_creation_site"
+	// ExportFailedRequestIDs the comma separated log ids of the ingest requests failed to export the span, which
+	// are attached before the span is exported again.
+	ExportFailedRequestIDs = "export_failed_request_ids"
 
-	CutOff = "cut_off"
+	CutOff      = "cut_off"
+	DroppedTags = "dropped_tags"
 )
@@ -23,6 +23,7 @@ var (
 	typeStr   string
 	typeInt   int
 	typeInt32 int32
+	typeFloat float64
 )
 
 // ReserveFieldTypes Define the allowed types for each reserved field.
@@ -35,4 +36,5 @@ var ReserveFieldTypes = map[string][]reflect.Type{
 	tracespec.Tokens:       {reflect.TypeOf(typeInt64), reflect.TypeOf(typeInt), reflect.TypeOf(typeInt32)},
 	StartTimeFirstResp:     {reflect.TypeOf(typeInt64), reflect.TypeOf(typeInt), reflect.TypeOf(typeInt32)},
 	LatencyFirstResp:       {reflect.TypeOf(typeInt64), reflect.TypeOf(typeInt), reflect.TypeOf(typeInt32)},
+	tracespec.Cost:         {reflect.TypeOf(typeFloat)},
 }
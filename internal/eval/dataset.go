@@ -0,0 +1,76 @@
+// Copyright (c) 2025 Bytedance Ltd. and/or its affiliates
+// SPDX-License-Identifier: MIT
+
+package eval
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/coze-dev/cozeloop-go/entity"
+	"github.com/coze-dev/cozeloop-go/internal/consts"
+)
+
+const (
+	maxListItemsPageSize = 200
+)
+
+// AppendItems appends items to the dataset and returns the ids of created items.
+// If some batch failed, ids of the items which have been created are returned with the error.
+func (p *Provider) AppendItems(ctx context.Context, datasetID string, items []*entity.DatasetItem) ([]string, error) {
+	if datasetID == "" {
+		return nil, consts.ErrInvalidParam.Wrap(fmt.Errorf("dataset id is empty"))
+	}
+	if len(items) == 0 {
+		return nil, nil
+	}
+	for i, item := range items {
+		if item == nil {
+			return nil, consts.ErrInvalidParam.Wrap(fmt.Errorf("dataset item at index %d is nil", i))
+		}
+		if len(item.Messages) == 0 {
+			return nil, consts.ErrInvalidParam.Wrap(fmt.Errorf("messages of dataset item at index %d is empty", i))
+		}
+	}
+	return p.openAPIClient.BatchCreateDatasetItems(ctx, BatchCreateDatasetItemsRequest{
+		WorkspaceID: p.config.WorkspaceID,
+		DatasetID:   datasetID,
+		Items:       items,
+	})
+}
+
+func (p *Provider) ListItems(ctx context.Context, param *entity.ListDatasetItemsParam) (*entity.ListDatasetItemsResult, error) {
+	if param == nil || param.DatasetID == "" {
+		return nil, consts.ErrInvalidParam.Wrap(fmt.Errorf("dataset id is empty"))
+	}
+	if param.PageSize < 0 || param.PageSize > maxListItemsPageSize {
+		return nil, consts.ErrInvalidParam.Wrap(fmt.Errorf("page size should be in [0, %d]", maxListItemsPageSize))
+	}
+	result, err := p.openAPIClient.ListItems(ctx, ListItemsRequest{
+		WorkspaceID: p.config.WorkspaceID,
+		DatasetID:   param.DatasetID,
+		PageToken:   param.PageToken,
+		PageSize:    param.PageSize,
+	})
+	if err != nil {
+		return nil, err
+	}
+	if result == nil {
+		return &entity.ListDatasetItemsResult{}, nil
+	}
+	return result, nil
+}
+
+func (p *Provider) DeleteItems(ctx context.Context, datasetID string, itemIDs []string) error {
+	if datasetID == "" {
+		return consts.ErrInvalidParam.Wrap(fmt.Errorf("dataset id is empty"))
+	}
+	if len(itemIDs) == 0 {
+		return nil
+	}
+	return p.openAPIClient.BatchDeleteItems(ctx, BatchDeleteItemsRequest{
+		WorkspaceID: p.config.WorkspaceID,
+		DatasetID:   datasetID,
+		ItemIDs:     itemIDs,
+	})
+}
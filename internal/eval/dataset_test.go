@@ -0,0 +1,78 @@
+// Copyright (c) 2025 Bytedance Ltd. and/or its affiliates
+// SPDX-License-Identifier: MIT
+
+package eval
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+
+	"github.com/coze-dev/cozeloop-go/entity"
+	"github.com/coze-dev/cozeloop-go/internal/consts"
+	"github.com/coze-dev/cozeloop-go/internal/util"
+)
+
+func TestDataset(t *testing.T) {
+	ctx := context.Background()
+
+	Convey("Test AppendItems", t, func() {
+		var path string
+		p, closeFn := newTestProvider(func(w http.ResponseWriter, r *http.Request) {
+			path = r.URL.Path
+			var req BatchCreateDatasetItemsRequest
+			_ = json.NewDecoder(r.Body).Decode(&req)
+			ids := make([]string, 0, len(req.Items))
+			for range req.Items {
+				ids = append(ids, fmt.Sprintf("item_%d", len(ids)))
+			}
+			writeJSON(w, map[string]any{"code": 0, "data": BatchCreateItemsData{ItemIDs: ids}})
+		})
+		defer closeFn()
+
+		_, err := p.AppendItems(ctx, "d1", []*entity.DatasetItem{{}})
+		So(errors.Is(err, consts.ErrInvalidParam), ShouldBeTrue)
+
+		items := make([]*entity.DatasetItem, 0, 150)
+		for i := 0; i < 150; i++ {
+			items = append(items, &entity.DatasetItem{
+				Messages:       []*entity.Message{{Role: entity.RoleUser, Content: util.Ptr("hi")}},
+				ExpectedOutput: &entity.Message{Role: entity.RoleAssistant, Content: util.Ptr("hello")},
+				Tags:           map[string]string{"source": "production"},
+			})
+		}
+		ids, err := p.AppendItems(ctx, "d1", items)
+		So(err, ShouldBeNil)
+		So(len(ids), ShouldEqual, 150)
+		So(path, ShouldEqual, batchCreateItemsPath)
+	})
+
+	Convey("Test ListItems", t, func() {
+		var req ListItemsRequest
+		p, closeFn := newTestProvider(func(w http.ResponseWriter, r *http.Request) {
+			_ = json.NewDecoder(r.Body).Decode(&req)
+			writeJSON(w, map[string]any{"code": 0, "data": entity.ListDatasetItemsResult{
+				Items:         []*entity.DatasetItem{{ID: "item_1"}},
+				NextPageToken: "next",
+				HasMore:       true,
+			}})
+		})
+		defer closeFn()
+
+		_, err := p.ListItems(ctx, &entity.ListDatasetItemsParam{DatasetID: "d1", PageSize: maxListItemsPageSize + 1})
+		So(errors.Is(err, consts.ErrInvalidParam), ShouldBeTrue)
+
+		result, err := p.ListItems(ctx, &entity.ListDatasetItemsParam{DatasetID: "d1", PageToken: "cur", PageSize: 10})
+		So(err, ShouldBeNil)
+		So(result.HasMore, ShouldBeTrue)
+		So(result.NextPageToken, ShouldEqual, "next")
+		So(len(result.Items), ShouldEqual, 1)
+		So(req.PageToken, ShouldEqual, "cur")
+		So(req.DatasetID, ShouldEqual, "d1")
+	})
+}
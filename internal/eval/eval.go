@@ -0,0 +1,147 @@
+// Copyright (c) 2025 Bytedance Ltd. and/or its affiliates
+// SPDX-License-Identifier: MIT
+
+package eval
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/coze-dev/cozeloop-go/entity"
+	"github.com/coze-dev/cozeloop-go/internal/consts"
+	"github.com/coze-dev/cozeloop-go/internal/httpclient"
+	"github.com/coze-dev/cozeloop-go/internal/logger"
+)
+
+const (
+	defaultPollInterval = 5 * time.Second
+)
+
+type Provider struct {
+	openAPIClient *OpenAPIClient
+	config        Options
+}
+
+type Options struct {
+	WorkspaceID string
+}
+
+type WaitEvalRunOptions struct {
+	PollInterval time.Duration
+}
+
+func NewEvalProvider(httpClient *httpclient.Client, options Options) *Provider {
+	return &Provider{
+		openAPIClient: &OpenAPIClient{httpClient: httpClient},
+		config:        options,
+	}
+}
+
+func (p *Provider) CreateDataset(ctx context.Context, param *entity.CreateEvalDatasetParam) (*entity.EvalDataset, error) {
+	if param == nil || param.Name == "" {
+		return nil, consts.ErrInvalidParam.Wrap(fmt.Errorf("dataset name is empty"))
+	}
+	return p.openAPIClient.CreateDataset(ctx, CreateDatasetRequest{
+		WorkspaceID: p.config.WorkspaceID,
+		Name:        param.Name,
+		Description: param.Description,
+	})
+}
+
+func (p *Provider) UploadItems(ctx context.Context, datasetID string, items []*entity.EvalItem) error {
+	if datasetID == "" {
+		return consts.ErrInvalidParam.Wrap(fmt.Errorf("dataset id is empty"))
+	}
+	if len(items) == 0 {
+		return nil
+	}
+	for i, item := range items {
+		if item == nil {
+			return consts.ErrInvalidParam.Wrap(fmt.Errorf("eval item at index %d is nil", i))
+		}
+	}
+	_, err := p.openAPIClient.BatchCreateItems(ctx, BatchCreateItemsRequest{
+		WorkspaceID: p.config.WorkspaceID,
+		DatasetID:   datasetID,
+		Items:       items,
+	})
+	return err
+}
+
+func (p *Provider) RunEvaluation(ctx context.Context, param *entity.RunEvaluationParam) (*entity.EvalRun, error) {
+	if param == nil {
+		return nil, consts.ErrInvalidParam.Wrap(fmt.Errorf("run evaluation param is nil"))
+	}
+	if param.DatasetID == "" {
+		return nil, consts.ErrInvalidParam.Wrap(fmt.Errorf("dataset id is empty"))
+	}
+	if param.PromptKey == "" {
+		return nil, consts.ErrInvalidParam.Wrap(fmt.Errorf("prompt key is empty"))
+	}
+	return p.openAPIClient.CreateEvalRun(ctx, CreateEvalRunRequest{
+		WorkspaceID: p.config.WorkspaceID,
+		DatasetID:   param.DatasetID,
+		PromptKey:   param.PromptKey,
+		Version:     param.Version,
+		Label:       param.Label,
+		Evaluators:  param.Evaluators,
+	})
+}
+
+func (p *Provider) GetEvalRun(ctx context.Context, runID string) (*entity.EvalRun, error) {
+	if runID == "" {
+		return nil, consts.ErrInvalidParam.Wrap(fmt.Errorf("run id is empty"))
+	}
+	return p.openAPIClient.GetEvalRun(ctx, GetEvalRunRequest{
+		WorkspaceID: p.config.WorkspaceID,
+		RunID:       runID,
+	})
+}
+
+// WaitEvalRun polls the eval run until it is finished or ctx is done.
+func (p *Provider) WaitEvalRun(ctx context.Context, runID string, options WaitEvalRunOptions) (*entity.EvalRun, error) {
+	interval := defaultPollInterval
+	if options.PollInterval > 0 {
+		interval = options.PollInterval
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		run, err := p.GetEvalRun(ctx, runID)
+		if err != nil {
+			return nil, err
+		}
+		if run == nil {
+			return nil, consts.ErrRemoteService.Wrap(fmt.Errorf("eval run %s not found", runID))
+		}
+		if run.Status.IsFinished() {
+			return run, nil
+		}
+		logger.CtxDebugf(ctx, "eval run %s is %s, wait for next poll", runID, run.Status)
+		select {
+		case <-ctx.Done():
+			return run, ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+func (p *Provider) ListEvalResults(ctx context.Context, param *entity.ListEvalResultsParam) (*entity.ListEvalResultsResult, error) {
+	if param == nil || param.RunID == "" {
+		return nil, consts.ErrInvalidParam.Wrap(fmt.Errorf("run id is empty"))
+	}
+	result, err := p.openAPIClient.ListEvalResults(ctx, ListEvalResultsRequest{
+		WorkspaceID: p.config.WorkspaceID,
+		RunID:       param.RunID,
+		PageToken:   param.PageToken,
+		PageSize:    param.PageSize,
+	})
+	if err != nil {
+		return nil, err
+	}
+	if result == nil {
+		return &entity.ListEvalResultsResult{}, nil
+	}
+	return result, nil
+}
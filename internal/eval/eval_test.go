@@ -0,0 +1,106 @@
+// Copyright (c) 2025 Bytedance Ltd. and/or its affiliates
+// SPDX-License-Identifier: MIT
+
+package eval
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	. "github.com/smartystreets/goconvey/convey"
+
+	"github.com/coze-dev/cozeloop-go/entity"
+	"github.com/coze-dev/cozeloop-go/internal/consts"
+	"github.com/coze-dev/cozeloop-go/internal/httpclient"
+)
+
+func newTestProvider(handler http.HandlerFunc) (*Provider, func()) {
+	server := httptest.NewServer(handler)
+	client := httpclient.NewClient(server.URL, http.DefaultClient, httpclient.NewTokenAuth("token"), nil)
+	return NewEvalProvider(client, Options{WorkspaceID: "workspace1"}), server.Close
+}
+
+func writeJSON(w http.ResponseWriter, v any) {
+	_ = json.NewEncoder(w).Encode(v)
+}
+
+func TestProvider(t *testing.T) {
+	ctx := context.Background()
+
+	Convey("Test CreateDataset", t, func() {
+		var path string
+		var req CreateDatasetRequest
+		p, closeFn := newTestProvider(func(w http.ResponseWriter, r *http.Request) {
+			path = r.URL.Path
+			_ = json.NewDecoder(r.Body).Decode(&req)
+			writeJSON(w, map[string]any{"code": 0, "data": entity.EvalDataset{ID: "d1", Name: req.Name}})
+		})
+		defer closeFn()
+
+		_, err := p.CreateDataset(ctx, &entity.CreateEvalDatasetParam{})
+		So(errors.Is(err, consts.ErrInvalidParam), ShouldBeTrue)
+
+		dataset, err := p.CreateDataset(ctx, &entity.CreateEvalDatasetParam{Name: "dataset"})
+		So(err, ShouldBeNil)
+		So(dataset.ID, ShouldEqual, "d1")
+		So(dataset.Name, ShouldEqual, "dataset")
+		So(path, ShouldEqual, createDatasetPath)
+		So(req.WorkspaceID, ShouldEqual, "workspace1")
+	})
+
+	Convey("Test UploadItems in batches", t, func() {
+		var calls, maxBatch int32
+		p, closeFn := newTestProvider(func(w http.ResponseWriter, r *http.Request) {
+			var req BatchCreateItemsRequest
+			_ = json.NewDecoder(r.Body).Decode(&req)
+			if n := int32(len(req.Items)); n > atomic.LoadInt32(&maxBatch) {
+				atomic.StoreInt32(&maxBatch, n)
+			}
+			atomic.AddInt32(&calls, 1)
+			writeJSON(w, map[string]any{"code": 0})
+		})
+		defer closeFn()
+
+		items := make([]*entity.EvalItem, 0, 250)
+		for i := 0; i < 250; i++ {
+			items = append(items, &entity.EvalItem{Input: "input"})
+		}
+		err := p.UploadItems(ctx, "d1", items)
+		So(err, ShouldBeNil)
+		So(atomic.LoadInt32(&calls), ShouldEqual, 3)
+		So(atomic.LoadInt32(&maxBatch), ShouldEqual, maxEvalItemBatchSize)
+	})
+
+	Convey("Test WaitEvalRun", t, func() {
+		var calls int32
+		p, closeFn := newTestProvider(func(w http.ResponseWriter, r *http.Request) {
+			status := entity.EvalRunStatusRunning
+			if atomic.AddInt32(&calls, 1) >= 3 {
+				status = entity.EvalRunStatusSuccess
+			}
+			writeJSON(w, map[string]any{"code": 0, "data": entity.EvalRun{ID: "r1", Status: status}})
+		})
+		defer closeFn()
+
+		run, err := p.WaitEvalRun(ctx, "r1", WaitEvalRunOptions{PollInterval: time.Millisecond})
+		So(err, ShouldBeNil)
+		So(run.Status, ShouldEqual, entity.EvalRunStatusSuccess)
+		So(atomic.LoadInt32(&calls), ShouldEqual, 3)
+	})
+
+	Convey("Test RunEvaluation remote error", t, func() {
+		p, closeFn := newTestProvider(func(w http.ResponseWriter, r *http.Request) {
+			writeJSON(w, map[string]any{"code": 600, "msg": "dataset not found"})
+		})
+		defer closeFn()
+
+		_, err := p.RunEvaluation(ctx, &entity.RunEvaluationParam{DatasetID: "d1", PromptKey: "key"})
+		So(errors.Is(err, consts.ErrRemoteService), ShouldBeTrue)
+	})
+}
@@ -0,0 +1,234 @@
+// Copyright (c) 2025 Bytedance Ltd. and/or its affiliates
+// SPDX-License-Identifier: MIT
+
+package eval
+
+import (
+	"context"
+
+	"github.com/coze-dev/cozeloop-go/entity"
+	"github.com/coze-dev/cozeloop-go/internal/httpclient"
+)
+
+const (
+	createDatasetPath    = "/v1/loop/eval/datasets/create"
+	batchCreateItemsPath = "/v1/loop/eval/datasets/items/batch_create"
+	createEvalRunPath    = "/v1/loop/eval/runs/create"
+	getEvalRunPath       = "/v1/loop/eval/runs/get"
+	listEvalResultsPath  = "/v1/loop/eval/runs/results/list"
+	listItemsPath        = "/v1/loop/eval/datasets/items/list"
+	batchDeleteItemsPath = "/v1/loop/eval/datasets/items/batch_delete"
+
+	createPromptOptimizationPath = "/v1/loop/prompts/optimizations/create"
+	getPromptOptimizationPath    = "/v1/loop/prompts/optimizations/get"
+
+	maxEvalItemBatchSize = 100
+)
+
+type OpenAPIClient struct {
+	httpClient *httpclient.Client
+}
+
+type CreateDatasetRequest struct {
+	WorkspaceID string `json:"workspace_id"`
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"`
+}
+
+type CreateDatasetResponse struct {
+	httpclient.BaseResponse
+	Data *entity.EvalDataset `json:"data"`
+}
+
+type BatchCreateItemsRequest struct {
+	WorkspaceID string             `json:"workspace_id"`
+	DatasetID   string             `json:"dataset_id"`
+	Items       []*entity.EvalItem `json:"items"`
+}
+
+type BatchCreateDatasetItemsRequest struct {
+	WorkspaceID string                `json:"workspace_id"`
+	DatasetID   string                `json:"dataset_id"`
+	Items       []*entity.DatasetItem `json:"items"`
+}
+
+// batchCreateItemsBody body of a batch of BatchCreateItemsRequest or BatchCreateDatasetItemsRequest.
+type batchCreateItemsBody[T any] struct {
+	WorkspaceID string `json:"workspace_id"`
+	DatasetID   string `json:"dataset_id"`
+	Items       []T    `json:"items"`
+}
+
+type BatchCreateItemsResponse struct {
+	httpclient.BaseResponse
+	Data *BatchCreateItemsData `json:"data"`
+}
+
+type BatchCreateItemsData struct {
+	ItemIDs []string `json:"item_ids,omitempty"`
+}
+
+type ListItemsRequest struct {
+	WorkspaceID string `json:"workspace_id"`
+	DatasetID   string `json:"dataset_id"`
+	PageToken   string `json:"page_token,omitempty"`
+	PageSize    int    `json:"page_size,omitempty"`
+}
+
+type ListItemsResponse struct {
+	httpclient.BaseResponse
+	Data *entity.ListDatasetItemsResult `json:"data"`
+}
+
+type BatchDeleteItemsRequest struct {
+	WorkspaceID string   `json:"workspace_id"`
+	DatasetID   string   `json:"dataset_id"`
+	ItemIDs     []string `json:"item_ids"`
+}
+
+type BatchDeleteItemsResponse struct {
+	httpclient.BaseResponse
+}
+
+type CreateEvalRunRequest struct {
+	WorkspaceID string   `json:"workspace_id"`
+	DatasetID   string   `json:"dataset_id"`
+	PromptKey   string   `json:"prompt_key"`
+	Version     string   `json:"version,omitempty"`
+	Label       string   `json:"label,omitempty"`
+	Evaluators  []string `json:"evaluators,omitempty"`
+}
+
+type EvalRunResponse struct {
+	httpclient.BaseResponse
+	Data *entity.EvalRun `json:"data"`
+}
+
+type GetEvalRunRequest struct {
+	WorkspaceID string `json:"workspace_id"`
+	RunID       string `json:"run_id"`
+}
+
+type ListEvalResultsRequest struct {
+	WorkspaceID string `json:"workspace_id"`
+	RunID       string `json:"run_id"`
+	PageToken   string `json:"page_token,omitempty"`
+	PageSize    int    `json:"page_size,omitempty"`
+}
+
+type ListEvalResultsResponse struct {
+	httpclient.BaseResponse
+	Data *entity.ListEvalResultsResult `json:"data"`
+}
+
+type CreatePromptOptimizationRequest struct {
+	WorkspaceID string                             `json:"workspace_id"`
+	PromptKey   string                             `json:"prompt_key"`
+	Version     string                             `json:"version,omitempty"`
+	Objective   string                             `json:"objective,omitempty"`
+	Samples     []*entity.PromptOptimizationSample `json:"samples"`
+}
+
+type GetPromptOptimizationRequest struct {
+	WorkspaceID string `json:"workspace_id"`
+	RunID       string `json:"run_id"`
+}
+
+type PromptOptimizationResponse struct {
+	httpclient.BaseResponse
+	Data *entity.PromptOptimizationRun `json:"data"`
+}
+
+func (o *OpenAPIClient) CreateDataset(ctx context.Context, req CreateDatasetRequest) (*entity.EvalDataset, error) {
+	var resp CreateDatasetResponse
+	if err := o.httpClient.Post(ctx, createDatasetPath, req, &resp); err != nil {
+		return nil, err
+	}
+	return resp.Data, nil
+}
+
+func (o *OpenAPIClient) BatchCreateItems(ctx context.Context, req BatchCreateItemsRequest) ([]string, error) {
+	return batchCreateItems(ctx, o, req.WorkspaceID, req.DatasetID, req.Items)
+}
+
+func (o *OpenAPIClient) BatchCreateDatasetItems(ctx context.Context, req BatchCreateDatasetItemsRequest) ([]string, error) {
+	return batchCreateItems(ctx, o, req.WorkspaceID, req.DatasetID, req.Items)
+}
+
+// batchCreateItems creates the items in batches and returns the ids of created items, including the ones created
+// before some batch failed.
+func batchCreateItems[T any](ctx context.Context, o *OpenAPIClient, workspaceID, datasetID string, items []T) ([]string, error) {
+	var itemIDs []string
+	for i := 0; i < len(items); i += maxEvalItemBatchSize {
+		end := i + maxEvalItemBatchSize
+		if end > len(items) {
+			end = len(items)
+		}
+		batchReq := batchCreateItemsBody[T]{
+			WorkspaceID: workspaceID,
+			DatasetID:   datasetID,
+			Items:       items[i:end],
+		}
+		var resp BatchCreateItemsResponse
+		if err := o.httpClient.Post(ctx, batchCreateItemsPath, batchReq, &resp); err != nil {
+			return itemIDs, err
+		}
+		if resp.Data != nil {
+			itemIDs = append(itemIDs, resp.Data.ItemIDs...)
+		}
+	}
+	return itemIDs, nil
+}
+
+func (o *OpenAPIClient) ListItems(ctx context.Context, req ListItemsRequest) (*entity.ListDatasetItemsResult, error) {
+	var resp ListItemsResponse
+	if err := o.httpClient.Post(ctx, listItemsPath, req, &resp); err != nil {
+		return nil, err
+	}
+	return resp.Data, nil
+}
+
+func (o *OpenAPIClient) BatchDeleteItems(ctx context.Context, req BatchDeleteItemsRequest) error {
+	var resp BatchDeleteItemsResponse
+	return o.httpClient.Post(ctx, batchDeleteItemsPath, req, &resp)
+}
+
+func (o *OpenAPIClient) CreateEvalRun(ctx context.Context, req CreateEvalRunRequest) (*entity.EvalRun, error) {
+	var resp EvalRunResponse
+	if err := o.httpClient.Post(ctx, createEvalRunPath, req, &resp); err != nil {
+		return nil, err
+	}
+	return resp.Data, nil
+}
+
+func (o *OpenAPIClient) GetEvalRun(ctx context.Context, req GetEvalRunRequest) (*entity.EvalRun, error) {
+	var resp EvalRunResponse
+	if err := o.httpClient.Post(ctx, getEvalRunPath, req, &resp); err != nil {
+		return nil, err
+	}
+	return resp.Data, nil
+}
+
+func (o *OpenAPIClient) ListEvalResults(ctx context.Context, req ListEvalResultsRequest) (*entity.ListEvalResultsResult, error) {
+	var resp ListEvalResultsResponse
+	if err := o.httpClient.Post(ctx, listEvalResultsPath, req, &resp); err != nil {
+		return nil, err
+	}
+	return resp.Data, nil
+}
+
+func (o *OpenAPIClient) CreatePromptOptimization(ctx context.Context, req CreatePromptOptimizationRequest) (*entity.PromptOptimizationRun, error) {
+	var resp PromptOptimizationResponse
+	if err := o.httpClient.Post(ctx, createPromptOptimizationPath, req, &resp); err != nil {
+		return nil, err
+	}
+	return resp.Data, nil
+}
+
+func (o *OpenAPIClient) GetPromptOptimization(ctx context.Context, req GetPromptOptimizationRequest) (*entity.PromptOptimizationRun, error) {
+	var resp PromptOptimizationResponse
+	if err := o.httpClient.Post(ctx, getPromptOptimizationPath, req, &resp); err != nil {
+		return nil, err
+	}
+	return resp.Data, nil
+}
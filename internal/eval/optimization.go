@@ -0,0 +1,83 @@
+// Copyright (c) 2025 Bytedance Ltd. and/or its affiliates
+// SPDX-License-Identifier: MIT
+
+package eval
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/coze-dev/cozeloop-go/entity"
+	"github.com/coze-dev/cozeloop-go/internal/consts"
+	"github.com/coze-dev/cozeloop-go/internal/logger"
+)
+
+const (
+	maxPromptOptimizationSamples = 1000
+)
+
+func (p *Provider) SubmitPromptOptimization(ctx context.Context, param *entity.SubmitPromptOptimizationParam) (*entity.PromptOptimizationRun, error) {
+	if param == nil {
+		return nil, consts.ErrInvalidParam.Wrap(fmt.Errorf("submit prompt optimization param is nil"))
+	}
+	if param.PromptKey == "" {
+		return nil, consts.ErrInvalidParam.Wrap(fmt.Errorf("prompt key is empty"))
+	}
+	if len(param.Samples) == 0 {
+		return nil, consts.ErrInvalidParam.Wrap(fmt.Errorf("samples is empty"))
+	}
+	if len(param.Samples) > maxPromptOptimizationSamples {
+		return nil, consts.ErrInvalidParam.Wrap(fmt.Errorf("samples count should be no more than %d", maxPromptOptimizationSamples))
+	}
+	for i, sample := range param.Samples {
+		if sample == nil {
+			return nil, consts.ErrInvalidParam.Wrap(fmt.Errorf("sample at index %d is nil", i))
+		}
+	}
+	return p.openAPIClient.CreatePromptOptimization(ctx, CreatePromptOptimizationRequest{
+		WorkspaceID: p.config.WorkspaceID,
+		PromptKey:   param.PromptKey,
+		Version:     param.Version,
+		Objective:   param.Objective,
+		Samples:     param.Samples,
+	})
+}
+
+func (p *Provider) GetPromptOptimization(ctx context.Context, runID string) (*entity.PromptOptimizationRun, error) {
+	if runID == "" {
+		return nil, consts.ErrInvalidParam.Wrap(fmt.Errorf("run id is empty"))
+	}
+	return p.openAPIClient.GetPromptOptimization(ctx, GetPromptOptimizationRequest{
+		WorkspaceID: p.config.WorkspaceID,
+		RunID:       runID,
+	})
+}
+
+// WaitPromptOptimization polls the prompt optimization until it is finished or ctx is done.
+func (p *Provider) WaitPromptOptimization(ctx context.Context, runID string, options WaitEvalRunOptions) (*entity.PromptOptimizationRun, error) {
+	interval := defaultPollInterval
+	if options.PollInterval > 0 {
+		interval = options.PollInterval
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		run, err := p.GetPromptOptimization(ctx, runID)
+		if err != nil {
+			return nil, err
+		}
+		if run == nil {
+			return nil, consts.ErrRemoteService.Wrap(fmt.Errorf("prompt optimization %s not found", runID))
+		}
+		if run.Status.IsFinished() {
+			return run, nil
+		}
+		logger.CtxDebugf(ctx, "prompt optimization %s is %s, wait for next poll", runID, run.Status)
+		select {
+		case <-ctx.Done():
+			return run, ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
@@ -0,0 +1,100 @@
+// Copyright (c) 2025 Bytedance Ltd. and/or its affiliates
+// SPDX-License-Identifier: MIT
+
+package eval
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	. "github.com/smartystreets/goconvey/convey"
+
+	"github.com/coze-dev/cozeloop-go/entity"
+	"github.com/coze-dev/cozeloop-go/internal/consts"
+	"github.com/coze-dev/cozeloop-go/internal/util"
+)
+
+func TestPromptOptimization(t *testing.T) {
+	ctx := context.Background()
+
+	Convey("Test SubmitPromptOptimization", t, func() {
+		var path string
+		var req CreatePromptOptimizationRequest
+		p, closeFn := newTestProvider(func(w http.ResponseWriter, r *http.Request) {
+			path = r.URL.Path
+			_ = json.NewDecoder(r.Body).Decode(&req)
+			writeJSON(w, map[string]any{"code": 0, "data": entity.PromptOptimizationRun{
+				ID:        "o1",
+				PromptKey: req.PromptKey,
+				Status:    entity.PromptOptimizationStatusPending,
+			}})
+		})
+		defer closeFn()
+
+		_, err := p.SubmitPromptOptimization(ctx, &entity.SubmitPromptOptimizationParam{PromptKey: "key"})
+		So(errors.Is(err, consts.ErrInvalidParam), ShouldBeTrue)
+		_, err = p.SubmitPromptOptimization(ctx, &entity.SubmitPromptOptimizationParam{
+			Samples: []*entity.PromptOptimizationSample{{Output: "output"}},
+		})
+		So(errors.Is(err, consts.ErrInvalidParam), ShouldBeTrue)
+		_, err = p.SubmitPromptOptimization(ctx, &entity.SubmitPromptOptimizationParam{
+			PromptKey: "key",
+			Samples:   []*entity.PromptOptimizationSample{nil},
+		})
+		So(errors.Is(err, consts.ErrInvalidParam), ShouldBeTrue)
+
+		run, err := p.SubmitPromptOptimization(ctx, &entity.SubmitPromptOptimizationParam{
+			PromptKey: "key",
+			Version:   "0.0.1",
+			Objective: "be concise",
+			Samples: []*entity.PromptOptimizationSample{{
+				Variables: map[string]any{"question": "hi"},
+				Output:    "hello",
+				Score:     util.Ptr(0.5),
+				Feedback:  "too short",
+			}},
+		})
+		So(err, ShouldBeNil)
+		So(run.ID, ShouldEqual, "o1")
+		So(run.PromptKey, ShouldEqual, "key")
+		So(path, ShouldEqual, createPromptOptimizationPath)
+		So(req.WorkspaceID, ShouldEqual, "workspace1")
+		So(req.Version, ShouldEqual, "0.0.1")
+		So(req.Objective, ShouldEqual, "be concise")
+		So(len(req.Samples), ShouldEqual, 1)
+		So(*req.Samples[0].Score, ShouldEqual, 0.5)
+		So(req.Samples[0].Variables["question"], ShouldEqual, "hi")
+	})
+
+	Convey("Test WaitPromptOptimization", t, func() {
+		var calls int32
+		p, closeFn := newTestProvider(func(w http.ResponseWriter, r *http.Request) {
+			run := entity.PromptOptimizationRun{ID: "o1", Status: entity.PromptOptimizationStatusRunning}
+			if atomic.AddInt32(&calls, 1) >= 3 {
+				run.Status = entity.PromptOptimizationStatusSuccess
+				run.Candidates = []*entity.PromptOptimizationCandidate{{
+					PromptTemplate: &entity.PromptTemplate{TemplateType: entity.TemplateTypeNormal},
+					Score:          util.Ptr(0.9),
+				}}
+			}
+			writeJSON(w, map[string]any{"code": 0, "data": run})
+		})
+		defer closeFn()
+
+		_, err := p.GetPromptOptimization(ctx, "")
+		So(errors.Is(err, consts.ErrInvalidParam), ShouldBeTrue)
+
+		run, err := p.WaitPromptOptimization(ctx, "o1", WaitEvalRunOptions{PollInterval: time.Millisecond})
+		So(err, ShouldBeNil)
+		So(run.Status, ShouldEqual, entity.PromptOptimizationStatusSuccess)
+		So(len(run.Candidates), ShouldEqual, 1)
+		So(*run.Candidates[0].Score, ShouldEqual, 0.9)
+		So(atomic.LoadInt32(&calls), ShouldEqual, 3)
+	})
+
+}
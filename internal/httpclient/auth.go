@@ -5,6 +5,13 @@ package httpclient
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sync"
 	"time"
 
 	"github.com/coze-dev/cozeloop-go/internal/consts"
@@ -13,13 +20,31 @@ import (
 	"golang.org/x/sync/singleflight"
 )
 
+// Auth provides the access token of requests, which is sent as Bearer token in Authorization header.
+// Token is called for every request, so it should cache the token and refresh it when needed.
 type Auth interface {
 	Token(ctx context.Context) (string, error)
 }
 
+// HeaderAuth is an Auth which sets the auth headers of requests itself, for auth schemes other than Bearer token.
+type HeaderAuth interface {
+	Auth
+	SetAuthHeader(ctx context.Context, request *http.Request) error
+}
+
+// AuthFunc is an adapter to use a func as Auth.
+type AuthFunc func(ctx context.Context) (string, error)
+
+// Token calls f(ctx).
+func (f AuthFunc) Token(ctx context.Context) (string, error) {
+	return f(ctx)
+}
+
 var (
 	_ Auth = &tokenAuthImpl{}
 	_ Auth = &jwtOAuthImpl{}
+	_ Auth = AuthFunc(nil)
+	_ Auth = &deviceOAuthImpl{}
 )
 
 // tokenAuthImpl implements the Auth interface with fixed access token.
@@ -100,3 +125,232 @@ func (r *jwtOAuthImpl) Token(ctx context.Context) (string, error) {
 	}
 	return val.(string), nil
 }
+
+// TokenStore persists the oauth token, so the refresh token can be reused after restart.
+type TokenStore interface {
+	// Load returns nil token if there is no token stored
+	Load(ctx context.Context) (*OAuthToken, error)
+	Save(ctx context.Context, token *OAuthToken) error
+}
+
+type fileTokenStore struct {
+	path string
+}
+
+// NewFileTokenStore creates a TokenStore which stores token as json in the file, readable only by current user.
+func NewFileTokenStore(path string) TokenStore {
+	return &fileTokenStore{path: path}
+}
+
+func (s *fileTokenStore) Load(ctx context.Context) (*OAuthToken, error) {
+	data, err := os.ReadFile(s.path)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	token := &OAuthToken{}
+	if err = json.Unmarshal(data, token); err != nil {
+		return nil, err
+	}
+	return token, nil
+}
+
+func (s *fileTokenStore) Save(ctx context.Context, token *OAuthToken) error {
+	data, err := json.Marshal(token)
+	if err != nil {
+		return err
+	}
+	if err = os.MkdirAll(filepath.Dir(s.path), 0o700); err != nil {
+		return err
+	}
+	return os.WriteFile(s.path, data, 0o600)
+}
+
+// DeviceOAuthOptions options of device code oauth.
+type DeviceOAuthOptions struct {
+	// WorkspaceID request device code of the workspace, optional
+	WorkspaceID string
+	// TokenStore persists token, optional. The user needn't authorize again until the refresh token expires.
+	TokenStore TokenStore
+	// OnDeviceCode is called when the user should open the verification url to authorize.
+	// Default prints the url and user code to stderr.
+	OnDeviceCode func(ctx context.Context, code *DeviceAuthCode)
+}
+
+type deviceOAuthImpl struct {
+	client  *DeviceOAuthClient
+	options DeviceOAuthOptions
+	lock    sync.RWMutex
+	token   *OAuthToken
+	loaded  bool
+	group   singleflight.Group
+	// authorizing the device code flow in progress, nil if there is none, guarded by lock
+	authorizing *deviceAuthorization
+}
+
+// deviceAuthorization a device code flow running in background, which is shared by the requests waiting for it.
+type deviceAuthorization struct {
+	done  chan struct{}
+	token *OAuthToken
+	err   error
+}
+
+// defaultDeviceCodeExpiration how long the device code flow waits for the user if the server does not tell.
+const defaultDeviceCodeExpiration = 10 * time.Minute
+
+// NewDeviceOAuth creates an Auth with device code flow. The token is refreshed by the refresh token when expired,
+// and the device code flow starts when there is no valid token, Token blocks until the user authorizes.
+func NewDeviceOAuth(client *DeviceOAuthClient, options *DeviceOAuthOptions) Auth {
+	impl := &deviceOAuthImpl{client: client}
+	if options != nil {
+		impl.options = *options
+	}
+	if impl.options.OnDeviceCode == nil {
+		impl.options.OnDeviceCode = printDeviceCode
+	}
+	return impl
+}
+
+func printDeviceCode(ctx context.Context, code *DeviceAuthCode) {
+	_, _ = fmt.Fprintf(os.Stderr, "Open %s in browser and enter code %s to authorize.\n", code.VerificationURL, code.UserCode)
+}
+
+func (r *deviceOAuthImpl) validToken() *OAuthToken {
+	r.lock.RLock()
+	defer r.lock.RUnlock()
+	if r.token == nil || time.Now().Add(consts.OAuthRefreshAdvanceTime).Unix() > r.token.ExpiresIn {
+		return nil
+	}
+	return r.token
+}
+
+func (r *deviceOAuthImpl) Token(ctx context.Context) (string, error) {
+	if token := r.validToken(); token != nil {
+		return token.AccessToken, nil
+	}
+	val, err, _ := r.group.Do("device_token", func() (interface{}, error) {
+		token, err := r.refresh(ctx)
+		if err != nil || token == nil {
+			return "", err
+		}
+		r.setToken(ctx, token)
+		return token.AccessToken, nil
+	})
+	if err != nil {
+		return "", err
+	}
+	if accessToken := val.(string); accessToken != "" {
+		return accessToken, nil
+	}
+	return r.authorize(ctx)
+}
+
+func (r *deviceOAuthImpl) setToken(ctx context.Context, token *OAuthToken) {
+	r.lock.Lock()
+	r.token = token
+	r.lock.Unlock()
+	if r.options.TokenStore != nil {
+		if err := r.options.TokenStore.Save(ctx, token); err != nil {
+			logger.CtxWarnf(ctx, "save oauth token failed: %v", err)
+		}
+	}
+}
+
+// authorize waits for the device code flow until the user authorizes or ctx is done. The flow runs in background
+// until the device code expires, so that it is not canceled by the timeout of the request which starts it, and the
+// requests made meanwhile wait for the same device code instead of requesting new ones.
+func (r *deviceOAuthImpl) authorize(ctx context.Context) (string, error) {
+	r.lock.Lock()
+	auth := r.authorizing
+	if auth == nil {
+		auth = &deviceAuthorization{done: make(chan struct{})}
+		r.authorizing = auth
+		util.GoSafe(context.Background(), func() {
+			r.runAuthorization(auth)
+		})
+	}
+	r.lock.Unlock()
+
+	select {
+	case <-auth.done:
+		if auth.err != nil {
+			return "", auth.err
+		}
+		return auth.token.AccessToken, nil
+	case <-ctx.Done():
+		return "", ctx.Err()
+	}
+}
+
+func (r *deviceOAuthImpl) runAuthorization(auth *deviceAuthorization) {
+	defer close(auth.done)
+	ctx := context.Background()
+	auth.token, auth.err = r.deviceCodeFlow(ctx)
+	if auth.err == nil {
+		r.setToken(ctx, auth.token)
+	} else {
+		logger.CtxWarnf(ctx, "device oauth authorization failed: %v", auth.err)
+	}
+	r.lock.Lock()
+	r.authorizing = nil
+	r.lock.Unlock()
+}
+
+// deviceCodeFlow requests a device code, and polls the token until the user authorizes or the code expires.
+func (r *deviceOAuthImpl) deviceCodeFlow(ctx context.Context) (*OAuthToken, error) {
+	code, err := r.client.GetDeviceCode(ctx, r.options.WorkspaceID)
+	if err != nil {
+		return nil, err
+	}
+	r.options.OnDeviceCode(ctx, code)
+	ctx, cancel := context.WithDeadline(ctx, deviceCodeDeadline(code))
+	defer cancel()
+	return r.client.GetAccessToken(ctx, code, true)
+}
+
+// deviceCodeDeadline returns when the device code expires. ExpiresIn is either the seconds the code lasts or the unix
+// timestamp it expires at.
+func deviceCodeDeadline(code *DeviceAuthCode) time.Time {
+	switch {
+	case code.ExpiresIn <= 0:
+		return time.Now().Add(defaultDeviceCodeExpiration)
+	case code.ExpiresIn > time.Now().Unix()/2:
+		return time.Unix(code.ExpiresIn, 0)
+	default:
+		return time.Now().Add(time.Duration(code.ExpiresIn) * time.Second)
+	}
+}
+
+// refresh loads token from store, or refreshes it by refresh token. It returns nil token if the user should authorize
+// by device code flow.
+func (r *deviceOAuthImpl) refresh(ctx context.Context) (*OAuthToken, error) {
+	r.lock.RLock()
+	token, loaded := r.token, r.loaded
+	r.lock.RUnlock()
+	if !loaded && r.options.TokenStore != nil {
+		r.lock.Lock()
+		r.loaded = true
+		r.lock.Unlock()
+		stored, err := r.options.TokenStore.Load(ctx)
+		if err != nil {
+			logger.CtxWarnf(ctx, "load oauth token failed: %v", err)
+		} else if stored != nil {
+			if time.Now().Add(consts.OAuthRefreshAdvanceTime).Unix() <= stored.ExpiresIn {
+				return stored, nil
+			}
+			token = stored
+		}
+	}
+
+	if token != nil && token.RefreshToken != "" {
+		logger.CtxDebugf(ctx, "refresh device oauth token")
+		refreshed, err := r.client.RefreshToken(ctx, token.RefreshToken)
+		if err == nil {
+			return refreshed, nil
+		}
+		logger.CtxWarnf(ctx, "refresh oauth token failed, authorize again: %v", err)
+	}
+	return nil, nil
+}
@@ -10,6 +10,7 @@ import (
 	"crypto/x509"
 	"encoding/base64"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
@@ -113,6 +114,8 @@ const (
 	getAccountTokenPath        = "/api/permission/oauth2/account/%d/token"
 	getDeviceCodePath          = "/api/permission/oauth2/device/code"
 	getWorkspaceDeviceCodePath = "/api/permission/oauth2/workspace_id/%s/device/code"
+
+	defaultDevicePollInterval = 5 * time.Second
 )
 
 type oauthOption struct {
@@ -315,6 +318,104 @@ func (c *OAuthClient) doPost(ctx context.Context, path string, body any, resp Op
 	return parseResponse(ctx, url, response, resp)
 }
 
+// DeviceOAuthClient represents the device code OAuth core, for CLI tools which can not keep a client secret
+// and authenticate users interactively.
+type DeviceOAuthClient struct {
+	*OAuthClient
+}
+
+// NewDeviceOAuthClient creates a new device code OAuth core
+func NewDeviceOAuthClient(clientID string, opts ...OAuthClientOption) (*DeviceOAuthClient, error) {
+	client, err := newOAuthClient(clientID, "", opts...)
+	if err != nil {
+		return nil, err
+	}
+	return &DeviceOAuthClient{OAuthClient: client}, nil
+}
+
+// DeviceAuthCode represents the device code response, the user should open VerificationURL to authorize.
+type DeviceAuthCode struct {
+	BaseResponse
+	DeviceCode      string `json:"device_code"`
+	UserCode        string `json:"user_code"`
+	VerificationURI string `json:"verification_uri"`
+	VerificationURL string `json:"verification_url"`
+	ExpiresIn       int64  `json:"expires_in"`
+	Interval        int    `json:"interval"` // polling interval in seconds
+}
+
+type getDeviceCodeReq struct {
+	ClientID string `json:"client_id"`
+}
+
+// GetDeviceCode gets the device code and user code. Device code of workspace is requested if workspaceID is not empty.
+func (c *DeviceOAuthClient) GetDeviceCode(ctx context.Context, workspaceID string) (*DeviceAuthCode, error) {
+	path := getDeviceCodePath
+	if workspaceID != "" {
+		path = fmt.Sprintf(getWorkspaceDeviceCodePath, workspaceID)
+	}
+	result := &DeviceAuthCode{}
+	header := map[string]string{
+		"Content-Type": "application/json",
+	}
+	if err := c.doPost(ctx, path, &getDeviceCodeReq{ClientID: c.clientID}, result, header); err != nil {
+		logger.CtxErrorf(ctx, "get device code failed: %v", err)
+		return nil, err
+	}
+	if result.VerificationURL == "" {
+		result.VerificationURL = fmt.Sprintf("%s?user_code=%s", result.VerificationURI, result.UserCode)
+	}
+	return result, nil
+}
+
+// GetAccessToken gets the access token of the device code. If poll is true, it polls until the user authorizes,
+// denies or the device code expires, otherwise it returns the authorization_pending error immediately.
+func (c *DeviceOAuthClient) GetAccessToken(ctx context.Context, code *DeviceAuthCode, poll bool) (*OAuthToken, error) {
+	req := getAccessTokenParams{
+		Type: GrantTypeDeviceCode,
+		Request: &getAccessTokenReq{
+			ClientID:   c.clientID,
+			GrantType:  GrantTypeDeviceCode.String(),
+			DeviceCode: code.DeviceCode,
+		},
+	}
+	if !poll {
+		return c.getAccessToken(ctx, req)
+	}
+
+	interval := time.Duration(code.Interval) * time.Second
+	if interval <= 0 {
+		interval = defaultDevicePollInterval
+	}
+	for {
+		token, err := c.getAccessToken(ctx, req)
+		if err == nil {
+			return token, nil
+		}
+		var authError *consts.AuthError
+		if !errors.As(err, &authError) {
+			return nil, err
+		}
+		switch authError.Code {
+		case consts.AuthorizationPending:
+		case consts.SlowDown:
+			interval += defaultDevicePollInterval
+		default:
+			return nil, err
+		}
+		select {
+		case <-time.After(interval):
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+}
+
+// RefreshToken gets a new access token by the refresh token.
+func (c *DeviceOAuthClient) RefreshToken(ctx context.Context, refreshToken string) (*OAuthToken, error) {
+	return c.refreshAccessToken(ctx, refreshToken)
+}
+
 // JWTOAuthClient represents the JWT OAuth core
 type JWTOAuthClient struct {
 	*OAuthClient
@@ -67,6 +67,7 @@ tt0xwhEZBT693So7I5FTP3Wbf64fw4vE0JCA3YqEwVRdatO6Xcm327xY2u0exLnz
 				Body:       io.NopCloser(bytes.NewReader(data)),
 			}, nil
 		}).Build()
+		defer mockHttpClient.UnPatch()
 
 		Convey("should return access token successfully", func() {
 			token, err := client.GetAccessToken(ctx, &GetJWTAccessTokenReq{
@@ -4,7 +4,16 @@
 package httpclient
 
 import (
+	"bytes"
 	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"path/filepath"
+	"strings"
+	"sync"
+	"sync/atomic"
 	"testing"
 	"time"
 
@@ -25,6 +34,45 @@ func TestTokenAuthImpl(t *testing.T) {
 	})
 }
 
+type apiKeyAuth struct {
+	key string
+}
+
+func (a *apiKeyAuth) Token(ctx context.Context) (string, error) {
+	return a.key, nil
+}
+
+func (a *apiKeyAuth) SetAuthHeader(ctx context.Context, request *http.Request) error {
+	request.Header.Set("X-Api-Key", a.key)
+	return nil
+}
+
+func TestCustomAuth(t *testing.T) {
+	ctx := context.Background()
+
+	Convey("Test AuthFunc returns rotating token", t, func() {
+		tokens := []string{"token1", "token2"}
+		i := 0
+		auth := AuthFunc(func(ctx context.Context) (string, error) {
+			token := tokens[i%len(tokens)]
+			i++
+			return token, nil
+		})
+		request, _ := http.NewRequest(http.MethodGet, "http://localhost", nil)
+		So(setAuthorizationHeader(ctx, request, auth), ShouldBeNil)
+		So(request.Header.Get(consts.AuthorizeHeader), ShouldEqual, "Bearer token1")
+		So(setAuthorizationHeader(ctx, request, auth), ShouldBeNil)
+		So(request.Header.Get(consts.AuthorizeHeader), ShouldEqual, "Bearer token2")
+	})
+
+	Convey("Test HeaderAuth sets headers itself", t, func() {
+		request, _ := http.NewRequest(http.MethodGet, "http://localhost", nil)
+		So(setAuthorizationHeader(ctx, request, &apiKeyAuth{key: "key"}), ShouldBeNil)
+		So(request.Header.Get("X-Api-Key"), ShouldEqual, "key")
+		So(request.Header.Get(consts.AuthorizeHeader), ShouldBeEmpty)
+	})
+}
+
 func TestJWTAuthImpl(t *testing.T) {
 	ctx := context.Background()
 	client := &JWTOAuthClient{}
@@ -55,3 +103,120 @@ func TestJWTAuthImpl(t *testing.T) {
 		So(mockClient.Times(), ShouldEqual, 2)
 	})
 }
+
+type deviceFlowHTTPClient struct {
+	mu           sync.Mutex
+	paths        []string
+	pendingTimes int
+}
+
+func (c *deviceFlowHTTPClient) Do(req *http.Request) (*http.Response, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.paths = append(c.paths, req.URL.Path)
+	body := map[string]any{}
+	_ = json.NewDecoder(req.Body).Decode(&body)
+	statusCode := http.StatusOK
+	var resp any
+	switch {
+	case strings.HasSuffix(req.URL.Path, "/device/code"):
+		resp = &DeviceAuthCode{DeviceCode: "device_code", UserCode: "user_code", VerificationURI: "https://www.coze.cn/device", Interval: 1}
+	case body["grant_type"] == GrantTypeDeviceCode.String() && c.pendingTimes > 0:
+		c.pendingTimes--
+		statusCode = http.StatusBadRequest
+		resp = &consts.AuthErrorFormat{ErrorCode: consts.AuthorizationPending.String()}
+	case body["grant_type"] == GrantTypeRefreshToken.String():
+		resp = &OAuthToken{AccessToken: "refreshed_token", RefreshToken: "refresh_token2", ExpiresIn: time.Now().Add(time.Hour).Unix()}
+	default:
+		resp = &OAuthToken{AccessToken: "device_token", RefreshToken: "refresh_token", ExpiresIn: time.Now().Add(time.Hour).Unix()}
+	}
+	data, _ := json.Marshal(resp)
+	return &http.Response{StatusCode: statusCode, Body: io.NopCloser(bytes.NewReader(data))}, nil
+}
+
+func (c *deviceFlowHTTPClient) requestPaths() []string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return append([]string(nil), c.paths...)
+}
+
+func TestDeviceOAuthImpl(t *testing.T) {
+	ctx := context.Background()
+
+	Convey("Test device oauth flow", t, func() {
+		httpClient := &deviceFlowHTTPClient{pendingTimes: 1}
+		client, err := NewDeviceOAuthClient("client_id", WithAuthBaseURL("https://api.coze.cn"), WithAuthHttpClient(httpClient))
+		So(err, ShouldBeNil)
+		store := NewFileTokenStore(filepath.Join(t.TempDir(), "token.json"))
+		var shownCode *DeviceAuthCode
+		auth := NewDeviceOAuth(client, &DeviceOAuthOptions{
+			TokenStore: store,
+			OnDeviceCode: func(ctx context.Context, code *DeviceAuthCode) {
+				shownCode = code
+			},
+		})
+
+		token, err := auth.Token(ctx)
+		So(err, ShouldBeNil)
+		So(token, ShouldEqual, "device_token")
+		So(shownCode, ShouldNotBeNil)
+		So(shownCode.VerificationURL, ShouldEqual, "https://www.coze.cn/device?user_code=user_code")
+		// device code, pending, token
+		So(len(httpClient.requestPaths()), ShouldEqual, 3)
+
+		// cached token
+		token, err = auth.Token(ctx)
+		So(err, ShouldBeNil)
+		So(token, ShouldEqual, "device_token")
+		So(len(httpClient.requestPaths()), ShouldEqual, 3)
+
+		// token is loaded from store after restart
+		auth = NewDeviceOAuth(client, &DeviceOAuthOptions{TokenStore: store})
+		token, err = auth.Token(ctx)
+		So(err, ShouldBeNil)
+		So(token, ShouldEqual, "device_token")
+		So(len(httpClient.requestPaths()), ShouldEqual, 3)
+
+		// expired token is refreshed by refresh token
+		stored, err := store.Load(ctx)
+		So(err, ShouldBeNil)
+		stored.ExpiresIn = time.Now().Add(-time.Minute).Unix()
+		So(store.Save(ctx, stored), ShouldBeNil)
+		auth = NewDeviceOAuth(client, &DeviceOAuthOptions{TokenStore: store})
+		token, err = auth.Token(ctx)
+		So(err, ShouldBeNil)
+		So(token, ShouldEqual, "refreshed_token")
+		stored, err = store.Load(ctx)
+		So(err, ShouldBeNil)
+		So(stored.RefreshToken, ShouldEqual, "refresh_token2")
+	})
+	Convey("Test device code flow outlives the timeout of requests", t, func() {
+		httpClient := &deviceFlowHTTPClient{pendingTimes: 1}
+		client, err := NewDeviceOAuthClient("client_id", WithAuthBaseURL("https://api.coze.cn"), WithAuthHttpClient(httpClient))
+		So(err, ShouldBeNil)
+		var shown int32
+		auth := NewDeviceOAuth(client, &DeviceOAuthOptions{
+			OnDeviceCode: func(ctx context.Context, code *DeviceAuthCode) {
+				atomic.AddInt32(&shown, 1)
+			},
+		})
+
+		timeoutCtx, cancel := context.WithTimeout(ctx, 100*time.Millisecond)
+		defer cancel()
+		_, err = auth.Token(timeoutCtx)
+		So(errors.Is(err, context.DeadlineExceeded), ShouldBeTrue)
+
+		// the pending device code is reused
+		token, err := auth.Token(ctx)
+		So(err, ShouldBeNil)
+		So(token, ShouldEqual, "device_token")
+		So(atomic.LoadInt32(&shown), ShouldEqual, 1)
+		deviceCodes := 0
+		for _, path := range httpClient.requestPaths() {
+			if strings.HasSuffix(path, "/device/code") {
+				deviceCodes++
+			}
+		}
+		So(deviceCodes, ShouldEqual, 1)
+	})
+}
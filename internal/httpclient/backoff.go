@@ -59,6 +59,10 @@ func (b *Backoff) Retry(ctx context.Context, f func() error, retryTimes int) err
 		if err == nil {
 			return err
 		}
+		// request rejected by circuit breaker needn't retry
+		if errors.Is(err, consts.ErrCircuitOpen) {
+			return err
+		}
 		// auth error needn't retry
 		var authError *consts.AuthError
 		if isAuthError := errors.As(err, &authError); isAuthError {
@@ -0,0 +1,271 @@
+// Copyright (c) 2025 Bytedance Ltd. and/or its affiliates
+// SPDX-License-Identifier: MIT
+
+package httpclient
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/coze-dev/cozeloop-go/internal/consts"
+	"github.com/coze-dev/cozeloop-go/internal/logger"
+)
+
+const (
+	defaultFailureRateThreshold  = 0.5
+	defaultSlowCallDuration      = 5 * time.Second
+	defaultSlowCallRateThreshold = 1
+	defaultMinCalls              = 20
+	defaultBreakerWindow         = 10 * time.Second
+	defaultOpenDuration          = 30 * time.Second
+	defaultHalfOpenProbes        = 1
+)
+
+// CircuitState state of circuit breaker.
+type CircuitState int
+
+const (
+	// CircuitClosed requests are sent as usual.
+	CircuitClosed CircuitState = iota
+	// CircuitOpen requests are rejected without being sent.
+	CircuitOpen
+	// CircuitHalfOpen a few probe requests are sent to check whether the server is recovered.
+	CircuitHalfOpen
+)
+
+func (s CircuitState) String() string {
+	switch s {
+	case CircuitClosed:
+		return "closed"
+	case CircuitOpen:
+		return "open"
+	case CircuitHalfOpen:
+		return "half-open"
+	default:
+		return fmt.Sprintf("unknown(%d)", int(s))
+	}
+}
+
+// CircuitBreakerConf conf of the circuit breaker around requests to loop server. Each api path has its own breaker,
+// so an outage of trace ingest does not reject prompt requests.
+type CircuitBreakerConf struct {
+	// FailureRateThreshold the circuit opens when the rate of failed calls in window reaches it. Failed calls are
+	// the ones with network error, timeout, or http status 429 and 5xx. Default is 0.5.
+	FailureRateThreshold float64
+	// SlowCallDuration calls taking longer than it are counted as slow. Default is 5s.
+	SlowCallDuration time.Duration
+	// SlowCallRateThreshold the circuit opens when the rate of slow calls in window reaches it. Default is 1,
+	// that is the circuit opens only if all calls are slow.
+	SlowCallRateThreshold float64
+	// MinCalls min count of calls in window before the rates are evaluated. Default is 20.
+	MinCalls int
+	// Window the rates are evaluated on the calls in the latest window. Default is 10s.
+	Window time.Duration
+	// OpenDuration how long the circuit keeps open before probing the server. Default is 30s.
+	OpenDuration time.Duration
+	// HalfOpenProbes count of probe calls in half-open state, the circuit closes if all of them succeed,
+	// and opens again if any of them fails. Default is 1.
+	HalfOpenProbes int
+	// OnStateChange is called when the circuit of path changes state, it can be used to report metrics or alerts.
+	// It is called in the goroutine of request, so it should not block.
+	OnStateChange func(path string, from, to CircuitState)
+	// OnReject is called when a request to path is rejected by the circuit, it should not block either.
+	OnReject func(path string)
+}
+
+// circuitBreakers the circuit breakers of api paths.
+type circuitBreakers struct {
+	conf     CircuitBreakerConf
+	breakers sync.Map // path -> *circuitBreaker
+}
+
+func newCircuitBreakers(conf *CircuitBreakerConf) *circuitBreakers {
+	if conf == nil {
+		return nil
+	}
+	c := *conf
+	if c.FailureRateThreshold <= 0 {
+		c.FailureRateThreshold = defaultFailureRateThreshold
+	}
+	if c.SlowCallDuration <= 0 {
+		c.SlowCallDuration = defaultSlowCallDuration
+	}
+	if c.SlowCallRateThreshold <= 0 {
+		c.SlowCallRateThreshold = defaultSlowCallRateThreshold
+	}
+	if c.MinCalls <= 0 {
+		c.MinCalls = defaultMinCalls
+	}
+	if c.Window <= 0 {
+		c.Window = defaultBreakerWindow
+	}
+	if c.OpenDuration <= 0 {
+		c.OpenDuration = defaultOpenDuration
+	}
+	if c.HalfOpenProbes <= 0 {
+		c.HalfOpenProbes = defaultHalfOpenProbes
+	}
+	return &circuitBreakers{conf: c}
+}
+
+func (c *circuitBreakers) get(path string) *circuitBreaker {
+	if b, ok := c.breakers.Load(path); ok {
+		return b.(*circuitBreaker)
+	}
+	b, _ := c.breakers.LoadOrStore(path, &circuitBreaker{path: path, conf: &c.conf, windowStart: time.Now()})
+	return b.(*circuitBreaker)
+}
+
+// circuitBreaker breaker of an api path. Calls are counted in a fixed window in closed state, and the circuit
+// opens if the rate of failed or slow calls reaches the threshold.
+type circuitBreaker struct {
+	path string
+	conf *CircuitBreakerConf
+
+	mu          sync.Mutex
+	state       CircuitState
+	generation  uint64 // increased on every state change, results of calls started in another state are ignored
+	windowStart time.Time
+	calls       int
+	failures    int
+	slowCalls   int
+	openedAt    time.Time
+	probes      int // probes in flight in half-open state
+	succeeded   int // succeeded probes in half-open state
+	changes     [][2]CircuitState
+}
+
+// allow returns whether the call is allowed, and the generation which the result of call should be recorded with.
+func (b *circuitBreaker) allow(now time.Time) (uint64, bool) {
+	b.mu.Lock()
+	defer b.unlock()
+	switch b.state {
+	case CircuitOpen:
+		if now.Sub(b.openedAt) < b.conf.OpenDuration {
+			return 0, false
+		}
+		b.setState(CircuitHalfOpen, now)
+		fallthrough
+	case CircuitHalfOpen:
+		if b.probes+b.succeeded >= b.conf.HalfOpenProbes {
+			return 0, false
+		}
+		b.probes++
+	default:
+		if now.Sub(b.windowStart) >= b.conf.Window {
+			b.resetWindow(now)
+		}
+	}
+	return b.generation, true
+}
+
+func (b *circuitBreaker) record(generation uint64, failed bool, elapsed time.Duration, now time.Time) {
+	b.mu.Lock()
+	defer b.unlock()
+	if generation != b.generation {
+		return
+	}
+	switch b.state {
+	case CircuitHalfOpen:
+		b.probes--
+		if failed {
+			b.setState(CircuitOpen, now)
+			return
+		}
+		b.succeeded++
+		if b.succeeded >= b.conf.HalfOpenProbes {
+			b.setState(CircuitClosed, now)
+		}
+	case CircuitClosed:
+		if now.Sub(b.windowStart) >= b.conf.Window {
+			b.resetWindow(now)
+		}
+		b.calls++
+		if failed {
+			b.failures++
+		}
+		if elapsed >= b.conf.SlowCallDuration {
+			b.slowCalls++
+		}
+		if b.calls < b.conf.MinCalls {
+			return
+		}
+		if float64(b.failures)/float64(b.calls) >= b.conf.FailureRateThreshold ||
+			float64(b.slowCalls)/float64(b.calls) >= b.conf.SlowCallRateThreshold {
+			b.setState(CircuitOpen, now)
+		}
+	}
+}
+
+// cancel releases the probe of a call which is not finished by the server, e.g. canceled by caller.
+func (b *circuitBreaker) cancel(generation uint64) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if generation == b.generation && b.state == CircuitHalfOpen {
+		b.probes--
+	}
+}
+
+func (b *circuitBreaker) setState(state CircuitState, now time.Time) {
+	from := b.state
+	b.state = state
+	b.generation++
+	b.probes = 0
+	b.succeeded = 0
+	b.resetWindow(now)
+	if state == CircuitOpen {
+		b.openedAt = now
+	}
+	b.changes = append(b.changes, [2]CircuitState{from, state})
+}
+
+// unlock unlocks the breaker and notifies the state changes, so OnStateChange is called without holding the lock.
+func (b *circuitBreaker) unlock() {
+	changes := b.changes
+	b.changes = nil
+	b.mu.Unlock()
+	for _, change := range changes {
+		logger.CtxWarnf(context.Background(), "circuit breaker of %s changes from %s to %s", b.path, change[0], change[1])
+		if b.conf.OnStateChange != nil {
+			b.conf.OnStateChange(b.path, change[0], change[1])
+		}
+	}
+}
+
+func (b *circuitBreaker) resetWindow(now time.Time) {
+	b.windowStart = now
+	b.calls = 0
+	b.failures = 0
+	b.slowCalls = 0
+}
+
+// do sends the request through the circuit breaker of path, and fails fast with ErrCircuitOpen if the circuit is open.
+// ctx is the context of caller, without the timeout of client which is in the context of request.
+func (c *Client) do(ctx context.Context, path string, request *http.Request) (*http.Response, error) {
+	if c.breakers == nil {
+		return c.httpClient.Do(request)
+	}
+	b := c.breakers.get(path)
+	generation, ok := b.allow(time.Now())
+	if !ok {
+		if c.breakers.conf.OnReject != nil {
+			c.breakers.conf.OnReject(path)
+		}
+		return nil, consts.ErrCircuitOpen.Wrap(fmt.Errorf("request to %s is rejected", path))
+	}
+	start := time.Now()
+	response, err := c.httpClient.Do(request)
+	if err != nil && ctx.Err() != nil {
+		// canceled or timed out by caller, not a failure of server, only the timeout of client is counted
+		b.cancel(generation)
+		return response, err
+	}
+	failed := err != nil || response.StatusCode == http.StatusTooManyRequests ||
+		response.StatusCode >= http.StatusInternalServerError
+	now := time.Now()
+	b.record(generation, failed, now.Sub(start), now)
+	return response, err
+}
@@ -0,0 +1,132 @@
+// Copyright (c) 2025 Bytedance Ltd. and/or its affiliates
+// SPDX-License-Identifier: MIT
+
+package httpclient
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	. "github.com/smartystreets/goconvey/convey"
+
+	"github.com/coze-dev/cozeloop-go/internal/consts"
+)
+
+func TestCircuitBreaker(t *testing.T) {
+	ctx := context.Background()
+	Convey("Test circuit breaker opens on failures, and closes after probes succeed", t, func() {
+		var status, requests int32 = http.StatusInternalServerError, 0
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			atomic.AddInt32(&requests, 1)
+			if code := atomic.LoadInt32(&status); code != http.StatusOK {
+				w.WriteHeader(int(code))
+				_, _ = w.Write([]byte(`{"code":500}`))
+				return
+			}
+			_, _ = w.Write([]byte(`{"code":0}`))
+		}))
+		defer server.Close()
+		var rejected int32
+		changes := make(chan CircuitState, 10)
+		client := NewClient(server.URL, http.DefaultClient, NewTokenAuth("token"), &ClientOptions{
+			CircuitBreaker: &CircuitBreakerConf{
+				MinCalls:     4,
+				OpenDuration: 50 * time.Millisecond,
+				OnStateChange: func(path string, from, to CircuitState) {
+					changes <- to
+				},
+				OnReject: func(path string) {
+					atomic.AddInt32(&rejected, 1)
+				},
+			},
+		})
+
+		for i := 0; i < 4; i++ {
+			So(client.Post(ctx, "/v1/a", nil, &BaseResponse{}), ShouldNotBeNil)
+		}
+		So(<-changes, ShouldEqual, CircuitOpen)
+		err := client.Post(ctx, "/v1/a", nil, &BaseResponse{})
+		So(errors.Is(err, consts.ErrCircuitOpen), ShouldBeTrue)
+		So(errors.Is(err, consts.ErrRemoteService), ShouldBeTrue)
+		So(atomic.LoadInt32(&requests), ShouldEqual, 4)
+		So(atomic.LoadInt32(&rejected), ShouldEqual, 1)
+
+		Convey("Requests rejected are not retried", func() {
+			err := client.PostWithRetry(ctx, "/v1/a", nil, &BaseResponse{}, 3)
+			So(errors.Is(err, consts.ErrCircuitOpen), ShouldBeTrue)
+			So(atomic.LoadInt32(&rejected), ShouldEqual, 2)
+		})
+
+		Convey("Other paths are not affected", func() {
+			So(client.Get(ctx, "/v1/b", nil, &BaseResponse{}), ShouldNotBeNil)
+			So(atomic.LoadInt32(&requests), ShouldEqual, 5)
+		})
+
+		Convey("Circuit opens again if the probe fails", func() {
+			time.Sleep(60 * time.Millisecond)
+			So(errors.Is(client.Post(ctx, "/v1/a", nil, &BaseResponse{}), consts.ErrCircuitOpen), ShouldBeFalse)
+			So(<-changes, ShouldEqual, CircuitHalfOpen)
+			So(<-changes, ShouldEqual, CircuitOpen)
+			So(errors.Is(client.Post(ctx, "/v1/a", nil, &BaseResponse{}), consts.ErrCircuitOpen), ShouldBeTrue)
+		})
+
+		Convey("Circuit closes if the probe succeeds", func() {
+			atomic.StoreInt32(&status, http.StatusOK)
+			time.Sleep(60 * time.Millisecond)
+			So(client.Post(ctx, "/v1/a", nil, &BaseResponse{}), ShouldBeNil)
+			So(<-changes, ShouldEqual, CircuitHalfOpen)
+			So(<-changes, ShouldEqual, CircuitClosed)
+			So(client.Post(ctx, "/v1/a", nil, &BaseResponse{}), ShouldBeNil)
+		})
+	})
+
+	Convey("Test timeout of caller is not counted as failure, and timeout of client is", t, func() {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			select {
+			case <-r.Context().Done():
+			case <-time.After(200 * time.Millisecond):
+			}
+		}))
+		defer server.Close()
+		conf := &CircuitBreakerConf{MinCalls: 2, OpenDuration: time.Minute}
+
+		client := NewClient(server.URL, http.DefaultClient, NewTokenAuth("token"), &ClientOptions{CircuitBreaker: conf})
+		for i := 0; i < 2; i++ {
+			callerCtx, cancel := context.WithTimeout(ctx, 10*time.Millisecond)
+			So(client.Post(callerCtx, "/v1/a", nil, &BaseResponse{}), ShouldNotBeNil)
+			cancel()
+		}
+		So(client.breakers.get("/v1/a").state, ShouldEqual, CircuitClosed)
+		So(client.breakers.get("/v1/a").calls, ShouldEqual, 0)
+
+		client = NewClient(server.URL, http.DefaultClient, NewTokenAuth("token"), &ClientOptions{
+			Timeout:        10 * time.Millisecond,
+			CircuitBreaker: conf,
+		})
+		for i := 0; i < 2; i++ {
+			So(client.Post(ctx, "/v1/a", nil, &BaseResponse{}), ShouldNotBeNil)
+		}
+		So(client.breakers.get("/v1/a").state, ShouldEqual, CircuitOpen)
+	})
+
+	Convey("Test circuit breaker opens on slow calls, and results of stale calls are ignored", t, func() {
+		b := newCircuitBreakers(&CircuitBreakerConf{MinCalls: 2, SlowCallDuration: time.Second, SlowCallRateThreshold: 0.5}).get("/v1/a")
+		now := time.Now()
+		generation, ok := b.allow(now)
+		So(ok, ShouldBeTrue)
+		b.record(generation, false, time.Millisecond, now)
+		So(b.state, ShouldEqual, CircuitClosed)
+		b.record(generation, false, 2*time.Second, now)
+		So(b.state, ShouldEqual, CircuitOpen)
+		// result of the call started before opening is ignored
+		b.record(generation, true, time.Millisecond, now)
+		So(b.failures, ShouldEqual, 0)
+		_, ok = b.allow(now)
+		So(ok, ShouldBeFalse)
+	})
+}
@@ -5,6 +5,7 @@ package httpclient
 
 import (
 	"bytes"
+	"compress/gzip"
 	"context"
 	"encoding/json"
 	"fmt"
@@ -12,10 +13,12 @@ import (
 	"mime/multipart"
 	"net/http"
 	"os"
+	"sync"
 	"time"
 
 	"github.com/coze-dev/cozeloop-go/internal/consts"
 	"github.com/coze-dev/cozeloop-go/internal/logger"
+	"github.com/coze-dev/cozeloop-go/internal/util"
 )
 
 type Client struct {
@@ -25,12 +28,18 @@ type Client struct {
 	timeout        time.Duration
 	uploadTimeout  time.Duration
 	headerEnricher func(ctx context.Context, req *http.Request)
+	gzipRequest    bool
+	breakers       *circuitBreakers
 }
 
 type ClientOptions struct {
 	Timeout        time.Duration
 	UploadTimeout  time.Duration
 	HeaderEnricher func(ctx context.Context, req *http.Request)
+	// GzipRequest compress request body of PostCompressed with gzip
+	GzipRequest bool
+	// CircuitBreaker fail fast the requests to the api path which keeps failing or slow, disabled if nil
+	CircuitBreaker *CircuitBreakerConf
 }
 
 func NewClient(baseURL string, httpClient HTTPClient, auth Auth, options *ClientOptions) *Client {
@@ -43,10 +52,20 @@ func NewClient(baseURL string, httpClient HTTPClient, auth Auth, options *Client
 		c.timeout = options.Timeout
 		c.uploadTimeout = options.UploadTimeout
 		c.headerEnricher = options.HeaderEnricher
+		c.gzipRequest = options.GzipRequest
+		c.breakers = newCircuitBreakers(options.CircuitBreaker)
 	}
 	return c
 }
 
+// Timeout returns the timeout of requests without deadline, 0 if there is no timeout.
+func (c *Client) Timeout() time.Duration {
+	if c == nil {
+		return 0
+	}
+	return c.timeout
+}
+
 func (c *Client) GetWithRetry(ctx context.Context, path string, params map[string]string, resp OpenAPIResponse, retryTimes int) error {
 	return defaultBackoff.Retry(ctx, func() error {
 		return c.Get(ctx, path, params, resp)
@@ -54,6 +73,7 @@ func (c *Client) GetWithRetry(ctx context.Context, path string, params map[strin
 }
 
 func (c *Client) Get(ctx context.Context, path string, params map[string]string, resp OpenAPIResponse) error {
+	callerCtx := ctx
 	var cancel context.CancelFunc
 	if c.timeout > 0 {
 		ctx, cancel = context.WithTimeout(ctx, c.timeout)
@@ -76,7 +96,7 @@ func (c *Client) Get(ctx context.Context, path string, params map[string]string,
 		return err
 	}
 
-	response, err := c.httpClient.Do(request)
+	response, err := c.do(callerCtx, path, request)
 	if err != nil {
 		logger.CtxErrorf(ctx, "http client Get failed, url: %v, err: %v", url, err)
 		return consts.ErrRemoteService.Wrap(err)
@@ -92,32 +112,61 @@ func (c *Client) PostWithRetry(ctx context.Context, path string, body any, resp
 }
 
 func (c *Client) Post(ctx context.Context, path string, body any, resp OpenAPIResponse) error {
+	return c.post(ctx, path, body, resp, false)
+}
+
+// PostCompressed works like Post, except that the request body is compressed with gzip
+// if GzipRequest is enabled. It is used for the requests with large body, such as span ingest.
+func (c *Client) PostCompressed(ctx context.Context, path string, body any, resp OpenAPIResponse) error {
+	return c.post(ctx, path, body, resp, c.gzipRequest)
+}
+
+func (c *Client) post(ctx context.Context, path string, body any, resp OpenAPIResponse, compress bool) error {
+	callerCtx := ctx
 	var cancel context.CancelFunc
 	if _, ok := ctx.Deadline(); !ok && c.timeout > 0 {
 		ctx, cancel = context.WithTimeout(ctx, c.timeout)
 		defer cancel()
 	}
 
-	var bodyReader io.Reader
+	headers := map[string]string{"Content-Type": "application/json"}
+	if encoder, ok := body.(BodyEncoder); ok {
+		headers["Content-Type"] = encoder.ContentType()
+	}
+	var bodyReader *pooledBody
 	if body != nil {
-		data, err := json.Marshal(body)
-		if err != nil {
+		var err error
+		if bodyReader, err = newPooledBody(body, compress); err != nil {
 			return consts.ErrInternal.Wrap(err)
 		}
-		bodyReader = bytes.NewReader(data)
+		if compress {
+			headers["Content-Encoding"] = "gzip"
+		}
 	}
 
 	url := c.baseURL + path
-	request, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bodyReader)
+	var request *http.Request
+	var err error
+	if bodyReader != nil {
+		request, err = http.NewRequestWithContext(ctx, http.MethodPost, url, bodyReader)
+	} else {
+		request, err = http.NewRequestWithContext(ctx, http.MethodPost, url, nil)
+	}
 	if err != nil {
+		if bodyReader != nil {
+			_ = bodyReader.Close()
+		}
 		return consts.ErrInternal.Wrap(err)
 	}
+	if bodyReader != nil {
+		request.ContentLength = int64(bodyReader.Len())
+	}
 
-	if err := c.setHeaders(ctx, request, map[string]string{"Content-Type": "application/json"}); err != nil {
+	if err := c.setHeaders(ctx, request, headers); err != nil {
 		return err
 	}
 
-	response, err := c.httpClient.Do(request)
+	response, err := c.do(callerCtx, path, request)
 	if err != nil {
 		logger.CtxErrorf(ctx, "http client Post failed, url: %v, err: %v", url, err)
 		return consts.ErrRemoteService.Wrap(err)
@@ -126,7 +175,60 @@ func (c *Client) Post(ctx context.Context, path string, body any, resp OpenAPIRe
 	return parseResponse(ctx, url, response, resp)
 }
 
+var gzipWriterPool = sync.Pool{New: func() interface{} {
+	return gzip.NewWriter(nil)
+}}
+
+// BodyEncoder is implemented by the request bodies encoded in other format than JSON, such as msgpack. The body
+// is sent with the content type of the format.
+type BodyEncoder interface {
+	ContentType() string
+	Encode(w io.Writer) error
+}
+
+func encodeBody(w io.Writer, body any) error {
+	if encoder, ok := body.(BodyEncoder); ok {
+		return encoder.Encode(w)
+	}
+	return json.NewEncoder(w).Encode(body)
+}
+
+// pooledBody is the request body encoded into pooled buffer, the buffer is recycled when the body is closed by http client.
+type pooledBody struct {
+	*bytes.Reader
+	buffer *bytes.Buffer
+	once   sync.Once
+}
+
+func newPooledBody(body any, compress bool) (*pooledBody, error) {
+	buffer := util.GetStringBuffer()
+	var err error
+	if compress {
+		gw := gzipWriterPool.Get().(*gzip.Writer)
+		gw.Reset(buffer)
+		if err = encodeBody(gw, body); err == nil {
+			err = gw.Close()
+		}
+		gzipWriterPool.Put(gw)
+	} else {
+		err = encodeBody(buffer, body)
+	}
+	if err != nil {
+		util.RecycleStringBuffer(buffer)
+		return nil, err
+	}
+	return &pooledBody{Reader: bytes.NewReader(buffer.Bytes()), buffer: buffer}, nil
+}
+
+func (b *pooledBody) Close() error {
+	b.once.Do(func() {
+		util.RecycleStringBuffer(b.buffer)
+	})
+	return nil
+}
+
 func (c *Client) PostStream(ctx context.Context, path string, body any) (*http.Response, error) {
+	callerCtx := ctx
 	if _, ok := ctx.Deadline(); !ok && c.timeout > 0 {
 		ctx, _ = context.WithTimeout(ctx, c.timeout)
 	}
@@ -151,7 +253,7 @@ func (c *Client) PostStream(ctx context.Context, path string, body any) (*http.R
 		return nil, err
 	}
 
-	response, err := c.httpClient.Do(request)
+	response, err := c.do(callerCtx, path, request)
 	if err != nil {
 		logger.CtxErrorf(ctx, "http client PostStream failed, url: %v, err: %v", url, err)
 		return nil, consts.ErrRemoteService.Wrap(err)
@@ -166,6 +268,7 @@ func (c *Client) PostStream(ctx context.Context, path string, body any) (*http.R
 }
 
 func (c *Client) UploadFile(ctx context.Context, path string, fileName string, reader io.Reader, form map[string]string, resp OpenAPIResponse) error {
+	callerCtx := ctx
 	var cancel context.CancelFunc
 	if c.uploadTimeout > 0 {
 		ctx, cancel = context.WithTimeout(ctx, c.uploadTimeout)
@@ -204,7 +307,7 @@ func (c *Client) UploadFile(ctx context.Context, path string, fileName string, r
 		return err
 	}
 
-	response, err := c.httpClient.Do(request)
+	response, err := c.do(callerCtx, path, request)
 	logger.CtxDebugf(ctx, "http client upload file, url: %v, content type:%s, response: %#v",
 		url, request.Header.Get("Content-Type"), response)
 	if err != nil {
@@ -240,6 +343,9 @@ func (c *Client) setHeaders(ctx context.Context, request *http.Request, headers
 }
 
 func setAuthorizationHeader(ctx context.Context, request *http.Request, auth Auth) error {
+	if headerAuth, ok := auth.(HeaderAuth); ok {
+		return headerAuth.SetAuthHeader(ctx, request)
+	}
 	token, err := auth.Token(ctx)
 	if err != nil {
 		return err
@@ -262,7 +368,8 @@ func parseResponse(ctx context.Context, url string, response *http.Response, res
 
 	if err := checkOAuthError(logID, respBody, response.StatusCode); err != nil {
 		logger.CtxErrorf(ctx, "OAuth failed, %v", err)
-		return consts.ErrRemoteService.Wrap(err)
+		return consts.ErrRemoteService.Wrap(consts.NewRemoteServiceError(
+			response.StatusCode, -1, err.ErrorMessage, logID).Wrap(err))
 	}
 
 	if err = json.Unmarshal(respBody, resp); err != nil {
@@ -271,6 +378,12 @@ func parseResponse(ctx context.Context, url string, response *http.Response, res
 			response.StatusCode, -1, "", logID))
 	}
 	resp.SetLogID(logID)
+	if resp.GetCode() == 0 && response.StatusCode >= http.StatusBadRequest {
+		err := consts.ErrRemoteService.Wrap(consts.NewRemoteServiceError(
+			response.StatusCode, -1, resp.GetMsg(), logID))
+		logger.CtxErrorf(ctx, "call remote service failed, %v", err)
+		return err
+	}
 	if resp.GetCode() != 0 {
 		err := consts.ErrRemoteService.Wrap(consts.NewRemoteServiceError(
 			response.StatusCode, resp.GetCode(), resp.GetMsg(), logID))
@@ -283,7 +396,7 @@ func parseResponse(ctx context.Context, url string, response *http.Response, res
 	return nil
 }
 
-func checkOAuthError(logID string, resp []byte, statusCode int) error {
+func checkOAuthError(logID string, resp []byte, statusCode int) *consts.AuthError {
 	if statusCode != http.StatusOK {
 		// oauth error has special format
 		errorInfo := consts.AuthErrorFormat{}
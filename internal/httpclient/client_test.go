@@ -5,10 +5,13 @@ package httpclient
 
 import (
 	"bytes"
+	"compress/gzip"
 	"context"
+	"encoding/json"
 	"errors"
 	"io"
 	"net/http"
+	"strings"
 	"testing"
 
 	. "github.com/bytedance/mockey"
@@ -50,6 +53,15 @@ func Test_Get(t *testing.T) {
 		So(remoteServiceErr.ErrCode, ShouldEqual, 4000)
 	})
 
+	PatchConvey("Test return 5xx error without code", t, func() {
+		header := http.Header{}
+		header.Set(consts.LogIDHeader, "log_id")
+		Mock((*mockHttpClient).Do).Return(&http.Response{StatusCode: 502, Header: header, Body: buildBody("{}")}, nil).Build()
+		err := client.Get(ctx, path, params, resp)
+		So(err, ShouldNotBeNil)
+		So(consts.RequestIDOf(err), ShouldEqual, "log_id")
+	})
+
 	PatchConvey("Test Get success", t, func() {
 		Mock((*mockHttpClient).Do).Return(&http.Response{StatusCode: 200, Body: buildBody("{\"code\":0}")}, nil).Build()
 		err := client.Get(ctx, path, params, resp)
@@ -74,6 +86,39 @@ func Test_Post(t *testing.T) {
 	})
 }
 
+func Test_PostCompressed(t *testing.T) {
+	ctx := context.Background()
+	path := "/api/v1/data"
+	body := map[string]string{"input": strings.Repeat("hello ", 100)}
+
+	Convey("Test PostCompressed with gzip", t, func() {
+		recorder := &recordHttpClient{}
+		client := NewClient("http://test", recorder, &mockAuthImpl{}, &ClientOptions{GzipRequest: true})
+		resp := &BaseResponse{}
+		err := client.PostCompressed(ctx, path, body, resp)
+		So(err, ShouldBeNil)
+		So(recorder.req.Header.Get("Content-Encoding"), ShouldEqual, "gzip")
+
+		reader, err := gzip.NewReader(bytes.NewReader(recorder.body))
+		So(err, ShouldBeNil)
+		raw, err := io.ReadAll(reader)
+		So(err, ShouldBeNil)
+		expected, _ := json.Marshal(body)
+		So(strings.TrimSpace(string(raw)), ShouldEqual, string(expected))
+		So(len(recorder.body), ShouldBeLessThan, len(expected))
+	})
+
+	Convey("Test PostCompressed without gzip", t, func() {
+		recorder := &recordHttpClient{}
+		client := NewClient("http://test", recorder, &mockAuthImpl{}, nil)
+		err := client.PostCompressed(ctx, path, body, &BaseResponse{})
+		So(err, ShouldBeNil)
+		So(recorder.req.Header.Get("Content-Encoding"), ShouldBeEmpty)
+		expected, _ := json.Marshal(body)
+		So(strings.TrimSpace(string(recorder.body)), ShouldEqual, string(expected))
+	})
+}
+
 func Test_UploadFile(t *testing.T) {
 	ctx := context.Background()
 	path := "/api/v1/upload"
@@ -99,6 +144,17 @@ func (c *mockHttpClient) Do(req *http.Request) (*http.Response, error) {
 	return nil, nil
 }
 
+type recordHttpClient struct {
+	req  *http.Request
+	body []byte
+}
+
+func (c *recordHttpClient) Do(req *http.Request) (*http.Response, error) {
+	c.req = req
+	c.body, _ = io.ReadAll(req.Body)
+	return &http.Response{StatusCode: 200, Body: buildBody("{\"code\":0}")}, nil
+}
+
 type mockAuthImpl struct{}
 
 func (a *mockAuthImpl) Token(ctx context.Context) (string, error) {
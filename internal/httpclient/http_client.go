@@ -4,10 +4,88 @@
 package httpclient
 
 import (
+	"context"
+	"io"
 	"net/http"
+	"sync"
+	"time"
+
+	"github.com/coze-dev/cozeloop-go/internal/consts"
+	"github.com/coze-dev/cozeloop-go/internal/logger"
+	"github.com/coze-dev/cozeloop-go/internal/util"
 )
 
 // HTTPClient an interface for making HTTP requests
 type HTTPClient interface {
 	Do(*http.Request) (*http.Response, error)
 }
+
+const (
+	defaultMaxIdleConnsPerHost = 16
+	defaultIdleConnTimeout     = 90 * time.Second
+)
+
+// PoolConf tunes the connection pool of the http client created by NewPooledHTTPClient.
+type PoolConf struct {
+	// MaxIdleConnsPerHost max idle connections kept to the server. Default is 16, while the default of http package
+	// is 2, which makes the connections of bursty exports closed and opened again.
+	MaxIdleConnsPerHost int
+	// MaxConnsPerHost max connections to the server, including the ones in use. Default is 0, no limit.
+	MaxConnsPerHost int
+	// IdleConnTimeout how long an idle connection is kept before it is closed. Default is 90s.
+	IdleConnTimeout time.Duration
+	// WarmConns connections opened in background when the client is created, see Client.Warm. Default is 0.
+	WarmConns int
+}
+
+// NewPooledHTTPClient creates the http client with its own transport tuned by conf, the other settings of transport
+// are the same as http.DefaultTransport.
+func NewPooledHTTPClient(conf PoolConf) *http.Client {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.MaxIdleConnsPerHost = defaultMaxIdleConnsPerHost
+	if conf.MaxIdleConnsPerHost > 0 {
+		transport.MaxIdleConnsPerHost = conf.MaxIdleConnsPerHost
+	}
+	if transport.MaxIdleConns > 0 && transport.MaxIdleConns < transport.MaxIdleConnsPerHost {
+		transport.MaxIdleConns = transport.MaxIdleConnsPerHost
+	}
+	transport.MaxConnsPerHost = conf.MaxConnsPerHost
+	transport.IdleConnTimeout = defaultIdleConnTimeout
+	if conf.IdleConnTimeout > 0 {
+		transport.IdleConnTimeout = conf.IdleConnTimeout
+	}
+	return &http.Client{Transport: transport}
+}
+
+// Warm opens n connections to the server concurrently, so that the first requests, such as the first export of
+// spans, do not pay for the handshakes. The connections are kept in the pool of http client, and the responses are
+// discarded. It returns after all the connections are opened or failed.
+func (c *Client) Warm(ctx context.Context, n int) {
+	timeout := c.timeout
+	if timeout <= 0 {
+		timeout = consts.DefaultTimeout
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	var wg sync.WaitGroup
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		util.GoSafe(ctx, func() {
+			defer wg.Done()
+			request, err := http.NewRequestWithContext(ctx, http.MethodHead, c.baseURL, nil)
+			if err != nil {
+				return
+			}
+			setUserAgent(request)
+			response, err := c.httpClient.Do(request)
+			if err != nil {
+				logger.CtxDebugf(ctx, "warm connection to %s failed: %v", c.baseURL, err)
+				return
+			}
+			// the connection is put back to pool only if the body is read to the end
+			_, _ = io.Copy(io.Discard, response.Body)
+			_ = response.Body.Close()
+		})
+	}
+	wg.Wait()
+}
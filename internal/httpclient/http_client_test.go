@@ -0,0 +1,66 @@
+// Copyright (c) 2025 Bytedance Ltd. and/or its affiliates
+// SPDX-License-Identifier: MIT
+
+package httpclient
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func Test_PooledHTTPClient(t *testing.T) {
+	Convey("Test transport is tuned by conf", t, func() {
+		transport := NewPooledHTTPClient(PoolConf{}).Transport.(*http.Transport)
+		So(transport.MaxIdleConnsPerHost, ShouldEqual, defaultMaxIdleConnsPerHost)
+		So(transport.IdleConnTimeout, ShouldEqual, defaultIdleConnTimeout)
+		So(transport.Proxy, ShouldNotBeNil)
+
+		transport = NewPooledHTTPClient(PoolConf{
+			MaxIdleConnsPerHost: 200,
+			MaxConnsPerHost:     300,
+			IdleConnTimeout:     time.Minute,
+		}).Transport.(*http.Transport)
+		So(transport.MaxIdleConnsPerHost, ShouldEqual, 200)
+		So(transport.MaxIdleConns, ShouldEqual, 200)
+		So(transport.MaxConnsPerHost, ShouldEqual, 300)
+		So(transport.IdleConnTimeout, ShouldEqual, time.Minute)
+		So(http.DefaultTransport.(*http.Transport).MaxIdleConnsPerHost, ShouldEqual, 0)
+	})
+
+	Convey("Test warm connections are reused by requests", t, func() {
+		var opened int32
+		server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.Method == http.MethodHead {
+				// hold the warm requests, so that they open connections concurrently
+				time.Sleep(50 * time.Millisecond)
+				return
+			}
+			_, _ = w.Write([]byte(`{"code":0}`))
+		}))
+		server.Config.ConnState = func(conn net.Conn, state http.ConnState) {
+			if state == http.StateNew {
+				atomic.AddInt32(&opened, 1)
+			}
+		}
+		server.Start()
+		defer server.Close()
+
+		httpClient := NewPooledHTTPClient(PoolConf{})
+		defer httpClient.CloseIdleConnections()
+		client := NewClient(server.URL, httpClient, NewTokenAuth("token"), nil)
+		client.Warm(context.Background(), 4)
+		So(atomic.LoadInt32(&opened), ShouldEqual, 4)
+
+		for i := 0; i < 4; i++ {
+			So(client.Post(context.Background(), "/v1/loop/traces/ingest", map[string]string{}, &BaseResponse{}), ShouldBeNil)
+		}
+		So(atomic.LoadInt32(&opened), ShouldEqual, 4)
+	})
+}
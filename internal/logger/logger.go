@@ -8,12 +8,15 @@ import (
 	"fmt"
 	"log"
 	"os"
+	"sync/atomic"
 )
 
 var defaultLogger = func() Logger {
 	return stdLogger{log: log.New(os.Stderr, "", log.LstdFlags|log.Lmicroseconds|log.Lshortfile)}
 }()
-var defaultLogLevel = LogLevelWarn
+
+// defaultLogLevel is read and written atomically, as the level can be updated at runtime by Client.UpdateConfig
+var defaultLogLevel = int32(LogLevelWarn)
 
 // Logger Interface for logging
 type Logger interface {
@@ -63,11 +66,11 @@ func GetLogger() Logger {
 }
 
 func SetLogLevel(level LogLevel) {
-	defaultLogLevel = level
+	atomic.StoreInt32(&defaultLogLevel, int32(level))
 }
 
 func GetLogLevel() LogLevel {
-	return defaultLogLevel
+	return LogLevel(atomic.LoadInt32(&defaultLogLevel))
 }
 
 func CtxDebugf(ctx context.Context, format string, v ...interface{}) {
@@ -6,13 +6,16 @@ package prompt
 import (
 	"context"
 	"fmt"
+	"math/rand"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/bluele/gcache"
 
 	"github.com/coze-dev/cozeloop-go/entity"
+	"github.com/coze-dev/cozeloop-go/internal/logger"
 	"github.com/coze-dev/cozeloop-go/internal/util"
 )
 
@@ -20,21 +23,58 @@ const (
 	defaultCacheSize = 100
 	cacheKeyPrefix   = "prompt_hub"
 	updateInterval   = time.Minute
+	// maxUpdateBackoff max delay of async updates which keep failing, unless the update interval is longer
+	maxUpdateBackoff = 30 * time.Minute
+	// updateJitter the delay of async updates is randomized by ±10%, so that the caches of many instances started
+	// at the same time do not update at the same moment
+	updateJitter = 0.1
 )
 
+var (
+	// jitterRand seeded by time, as the global source of math/rand is not randomly seeded before go1.20
+	jitterRand     = rand.New(rand.NewSource(time.Now().UnixNano()))
+	jitterRandLock sync.Mutex
+)
+
+// jitterFloat64 returns a random float64 in [0.0, 1.0), which is safe to call concurrently.
+func jitterFloat64() float64 {
+	jitterRandLock.Lock()
+	defer jitterRandLock.Unlock()
+	return jitterRand.Float64()
+}
+
 type PromptCache struct {
 	workspaceID string
 	cache       gcache.Cache
 	openAPI     *OpenAPIClient
 	once        sync.Once
+	stopOnce    sync.Once
 	stopChan    chan struct{}
 	option      CacheOption
+	// updateInterval nanoseconds of the update interval, which can be updated by SetUpdateInterval
+	updateInterval int64
+	// intervalChan notifies the async update task to reset its timer
+	intervalChan chan struct{}
+	// updateFailures consecutive failures of async updates, only accessed by the async update task
+	updateFailures int
+}
+
+type cacheItem struct {
+	prompt     *entity.Prompt
+	updateTime time.Time
+	// notFound the prompt does not exist, the item expires after a short time
+	notFound bool
 }
 
 type CacheOption struct {
 	EnableAsyncUpdate bool          // Whether to enable asynchronous updates
 	UpdateInterval    time.Duration // Update interval, if 0, use default value
 	MaxCacheSize      int
+	FieldMask         *FieldMask // Field mask of prompts pulled by the cache
+	// OnUpdate is called when a prompt is set into the cache, e.g. to invalidate the data derived from the prompt
+	OnUpdate func(prompt *entity.Prompt)
+	// stats counts the results of async updates, nil if not counted
+	stats *promptStats
 }
 
 type Option func(*CacheOption)
@@ -55,6 +95,27 @@ func withUpdateInterval(interval time.Duration) Option {
 	}
 }
 
+// withFieldMask set field mask of prompts
+func withFieldMask(mask *FieldMask) Option {
+	return func(opt *CacheOption) {
+		opt.FieldMask = mask
+	}
+}
+
+// withOnUpdate set the callback of prompt updates
+func withOnUpdate(onUpdate func(prompt *entity.Prompt)) Option {
+	return func(opt *CacheOption) {
+		opt.OnUpdate = onUpdate
+	}
+}
+
+// withStats set the stats counting results of async updates
+func withStats(stats *promptStats) Option {
+	return func(opt *CacheOption) {
+		opt.stats = stats
+	}
+}
+
 // withMaxCacheSize set max cache size
 func withMaxCacheSize(size int) Option {
 	return func(opt *CacheOption) {
@@ -83,6 +144,9 @@ func newPromptCache(workspaceID string, openAPI *OpenAPIClient, opts ...Option)
 		openAPI:     openAPI,
 		stopChan:    make(chan struct{}),
 		option:      *option,
+		// buffered so that SetUpdateInterval never blocks
+		intervalChan:   make(chan struct{}, 1),
+		updateInterval: int64(option.UpdateInterval),
 	}
 
 	// If asynchronous updates are enabled, start the update task
@@ -99,39 +163,97 @@ func (c *PromptCache) Start() {
 	})
 }
 
+// Stop stops the async update task, it can be called more than once.
 func (c *PromptCache) Stop() {
-	close(c.stopChan)
+	c.stopOnce.Do(func() {
+		close(c.stopChan)
+	})
 }
 
 func (c *PromptCache) startAsyncUpdate() {
-	ticker := time.NewTicker(c.option.UpdateInterval)
-	defer ticker.Stop()
+	timer := time.NewTimer(c.nextUpdateDelay())
+	defer timer.Stop()
 
 	for {
 		select {
-		case <-ticker.C:
-			c.updateAllPrompts()
+		case <-timer.C:
+			if err := c.updateAllPrompts(); err != nil {
+				c.updateFailures++
+			} else {
+				c.updateFailures = 0
+			}
+			timer.Reset(c.nextUpdateDelay())
+		case <-c.intervalChan:
+			if !timer.Stop() {
+				select {
+				case <-timer.C:
+				default:
+				}
+			}
+			timer.Reset(c.nextUpdateDelay())
 		case <-c.stopChan:
 			return
 		}
 	}
 }
 
-func (c *PromptCache) updateAllPrompts() {
-	ctx := context.Background()
-	queries := c.GetAllPromptQueries()
+// nextUpdateDelay returns the delay of the next async update. It is the update interval doubled by each of the
+// consecutive failures up to maxUpdateBackoff, so that the server is not hammered while it is unavailable, and
+// jittered by updateJitter.
+func (c *PromptCache) nextUpdateDelay() time.Duration {
+	interval := c.getUpdateInterval()
+	maxDelay := maxUpdateBackoff
+	if interval > maxDelay {
+		maxDelay = interval
+	}
+	delay := interval
+	for i := 0; i < c.updateFailures && delay < maxDelay; i++ {
+		delay *= 2
+	}
+	if delay > maxDelay {
+		delay = maxDelay
+	}
+	return delay + time.Duration((jitterFloat64()*2-1)*updateJitter*float64(delay))
+}
 
-	if len(queries) == 0 {
+// SetUpdateInterval updates the interval of async updates, it is ignored if interval is not positive.
+func (c *PromptCache) SetUpdateInterval(interval time.Duration) {
+	if interval <= 0 {
 		return
 	}
+	atomic.StoreInt64(&c.updateInterval, int64(interval))
+	select {
+	case c.intervalChan <- struct{}{}:
+	default:
+	}
+}
+
+func (c *PromptCache) getUpdateInterval() time.Duration {
+	return time.Duration(atomic.LoadInt64(&c.updateInterval))
+}
+
+// updateAllPrompts pulls all cached prompts from server, the error is returned if any of them fails.
+func (c *PromptCache) updateAllPrompts() error {
+	return c.updatePrompts(c.GetAllPromptQueries())
+}
+
+// updatePrompts pulls the prompts of queries from server and sets them into cache.
+func (c *PromptCache) updatePrompts(queries []PromptQuery) error {
+	ctx := context.Background()
+	if len(queries) == 0 {
+		return nil
+	}
 
 	// Batch update
 	promptResults, err := c.openAPI.MPullPrompt(ctx, MPullPromptRequest{
 		WorkSpaceID: c.workspaceID,
 		Queries:     queries,
+		FieldMask:   c.option.FieldMask,
 	})
+	c.option.stats.refreshed(err)
 	if err != nil {
-		return
+		// the prompts of succeeded batches are still updated
+		logger.CtxWarnf(ctx, "update cached prompts failed: %v", err)
 	}
 
 	// Update cache
@@ -140,6 +262,7 @@ func (c *PromptCache) updateAllPrompts() {
 			c.Set(p.Query.PromptKey, p.Query.Version, p.Query.Label, toModelPrompt(p.Prompt))
 		}
 	}
+	return err
 }
 
 func (c *PromptCache) getCacheKey(promptKey, version, label string) string {
@@ -149,19 +272,58 @@ func (c *PromptCache) getCacheKey(promptKey, version, label string) string {
 func (c *PromptCache) Get(promptKey, version, label string) (*entity.Prompt, bool) {
 	key := c.getCacheKey(promptKey, version, label)
 	if value, err := c.cache.Get(key); err == nil {
-		if prompt, ok := value.(*entity.Prompt); ok {
-			return prompt, true
+		if item, ok := value.(*cacheItem); ok && !item.notFound {
+			return item.prompt, true
 		}
 	}
 	return nil, false
 }
 
+// IsNotFound returns whether the prompt is cached as not found.
+func (c *PromptCache) IsNotFound(promptKey, version, label string) bool {
+	key := c.getCacheKey(promptKey, version, label)
+	if value, err := c.cache.Get(key); err == nil {
+		if item, ok := value.(*cacheItem); ok {
+			return item.notFound
+		}
+	}
+	return false
+}
+
+// SetNotFound caches that the prompt does not exist for ttl, so the missing prompt is not queried repeatedly.
+// The item is replaced if the prompt is found by async update.
+func (c *PromptCache) SetNotFound(promptKey, version, label string, ttl time.Duration) {
+	key := c.getCacheKey(promptKey, version, label)
+	_ = c.cache.SetWithExpire(key, &cacheItem{
+		updateTime: time.Now(),
+		notFound:   true,
+	}, ttl)
+}
+
+// IsStale returns whether the cached prompt has not been updated for more than UpdateInterval.
+// A prompt which is not in cache is not stale.
+func (c *PromptCache) IsStale(promptKey, version, label string) bool {
+	key := c.getCacheKey(promptKey, version, label)
+	if value, err := c.cache.Get(key); err == nil {
+		if item, ok := value.(*cacheItem); ok && !item.notFound {
+			return time.Since(item.updateTime) > c.getUpdateInterval()
+		}
+	}
+	return false
+}
+
 func (c *PromptCache) Set(promptKey, version, label string, prompt *entity.Prompt) {
 	if prompt == nil {
 		return
 	}
 	key := c.getCacheKey(promptKey, version, label)
-	c.cache.Set(key, prompt)
+	_ = c.cache.Set(key, &cacheItem{
+		prompt:     prompt,
+		updateTime: time.Now(),
+	})
+	if c.option.OnUpdate != nil {
+		c.option.OnUpdate(prompt)
+	}
 }
 
 // GetAllPromptQueries gets all cached Prompt query conditions
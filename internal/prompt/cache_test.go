@@ -35,6 +35,35 @@ func TestPromptCache(t *testing.T) {
 			So(found, ShouldBeFalse)
 		})
 
+		Convey("Test SetUpdateInterval", func() {
+			cache.Set("key1", "1.0", "", &entity.Prompt{PromptKey: "key1", Version: "1.0"})
+			So(cache.IsStale("key1", "1.0", ""), ShouldBeFalse)
+			cache.SetUpdateInterval(time.Nanosecond)
+			time.Sleep(time.Millisecond)
+			So(cache.getUpdateInterval(), ShouldEqual, time.Nanosecond)
+			So(cache.IsStale("key1", "1.0", ""), ShouldBeTrue)
+			// not positive intervals are ignored
+			cache.SetUpdateInterval(0)
+			So(cache.getUpdateInterval(), ShouldEqual, time.Nanosecond)
+		})
+
+		Convey("Test delay of async updates backs off with failures", func() {
+			within := func(delay, expected time.Duration) {
+				So(delay, ShouldBeBetweenOrEqual, time.Duration(float64(expected)*(1-updateJitter)),
+					time.Duration(float64(expected)*(1+updateJitter)))
+			}
+			within(cache.nextUpdateDelay(), updateInterval)
+			cache.updateFailures = 3
+			within(cache.nextUpdateDelay(), 8*updateInterval)
+			cache.updateFailures = 100
+			within(cache.nextUpdateDelay(), maxUpdateBackoff)
+			// the interval longer than max backoff is not shortened
+			cache.SetUpdateInterval(time.Hour)
+			within(cache.nextUpdateDelay(), time.Hour)
+			cache.updateFailures = 0
+			within(cache.nextUpdateDelay(), time.Hour)
+		})
+
 		Convey("Test Get and Set methods with empty version", func() {
 			prompt := &entity.Prompt{
 				WorkspaceID: "workspace1",
@@ -69,7 +98,7 @@ func TestPromptCache(t *testing.T) {
 
 		Convey("Test Start and Stop methods", func() {
 			// Mock the MPullPrompt method to avoid actual API calls
-			Mock((*OpenAPIClient).MPullPrompt).Return([]*PromptResult{
+			mockMPull := Mock((*OpenAPIClient).MPullPrompt).Return([]*PromptResult{
 				{
 					Query: PromptQuery{
 						PromptKey: "key1",
@@ -82,6 +111,7 @@ func TestPromptCache(t *testing.T) {
 					},
 				},
 			}, nil).Build()
+			defer mockMPull.UnPatch()
 
 			cache := newPromptCache("workspace1", openAPI, withAsyncUpdate(true), withUpdateInterval(time.Second))
 			prompt := &entity.Prompt{
@@ -0,0 +1,218 @@
+// Copyright (c) 2025 Bytedance Ltd. and/or its affiliates
+// SPDX-License-Identifier: MIT
+
+package prompt
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/coze-dev/cozeloop-go/entity"
+	"github.com/coze-dev/cozeloop-go/internal/consts"
+	"github.com/coze-dev/cozeloop-go/internal/util"
+)
+
+// ComparePromptVersions formats the two versions of prompt with the same variables, and returns the diff of them.
+func (p *Provider) ComparePromptVersions(ctx context.Context, promptKey, versionA, versionB string, variables map[string]any,
+	options PromptFormatOptions,
+) (*entity.PromptVersionDiff, error) {
+	if promptKey == "" {
+		return nil, consts.ErrInvalidParam.Wrap(fmt.Errorf("prompt key is empty"))
+	}
+	promptA, messagesA, err := p.getAndFormat(ctx, promptKey, versionA, variables, options)
+	if err != nil {
+		return nil, err
+	}
+	promptB, messagesB, err := p.getAndFormat(ctx, promptKey, versionB, variables, options)
+	if err != nil {
+		return nil, err
+	}
+	return &entity.PromptVersionDiff{
+		PromptKey:        promptKey,
+		VersionA:         promptA.Version,
+		VersionB:         promptB.Version,
+		MessagesA:        messagesA,
+		MessagesB:        messagesB,
+		MessageDiffs:     diffMessages(messagesA, messagesB),
+		ToolsChanged:     util.ToJSON(promptA.Tools) != util.ToJSON(promptB.Tools) || util.ToJSON(promptA.ToolCallConfig) != util.ToJSON(promptB.ToolCallConfig),
+		LLMConfigChanged: util.ToJSON(promptA.LLMConfig) != util.ToJSON(promptB.LLMConfig),
+	}, nil
+}
+
+func (p *Provider) getAndFormat(ctx context.Context, promptKey, version string, variables map[string]any,
+	options PromptFormatOptions,
+) (*entity.Prompt, []*entity.Message, error) {
+	prompt, err := p.GetPrompt(ctx, GetPromptParam{PromptKey: promptKey, Version: version}, GetPromptOptions{})
+	if err != nil {
+		return nil, nil, err
+	}
+	if prompt == nil {
+		return nil, nil, consts.ErrInvalidParam.Wrap(fmt.Errorf("prompt %s of version %q not found", promptKey, version))
+	}
+	messages, err := p.PromptFormat(ctx, prompt, variables, options)
+	if err != nil {
+		return nil, nil, fmt.Errorf("format prompt %s of version %s failed: %w", promptKey, prompt.Version, err)
+	}
+	return prompt, messages, nil
+}
+
+type diffOp struct {
+	typ entity.DiffType
+	a   int // index in a, -1 for added
+	b   int // index in b, -1 for removed
+}
+
+// diffSequence returns the edit script from a of length n to b of length m, by longest common subsequence.
+func diffSequence(n, m int, equal func(i, j int) bool) []diffOp {
+	// lcs[i][j] is the length of lcs of a[i:] and b[j:]
+	lcs := make([][]int, n+1)
+	for i := range lcs {
+		lcs[i] = make([]int, m+1)
+	}
+	for i := n - 1; i >= 0; i-- {
+		for j := m - 1; j >= 0; j-- {
+			if equal(i, j) {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else if lcs[i+1][j] >= lcs[i][j+1] {
+				lcs[i][j] = lcs[i+1][j]
+			} else {
+				lcs[i][j] = lcs[i][j+1]
+			}
+		}
+	}
+	ops := make([]diffOp, 0, n+m)
+	i, j := 0, 0
+	for i < n && j < m {
+		switch {
+		case equal(i, j):
+			ops = append(ops, diffOp{typ: entity.DiffTypeEqual, a: i, b: j})
+			i++
+			j++
+		case lcs[i+1][j] >= lcs[i][j+1]:
+			ops = append(ops, diffOp{typ: entity.DiffTypeRemoved, a: i, b: -1})
+			i++
+		default:
+			ops = append(ops, diffOp{typ: entity.DiffTypeAdded, a: -1, b: j})
+			j++
+		}
+	}
+	for ; i < n; i++ {
+		ops = append(ops, diffOp{typ: entity.DiffTypeRemoved, a: i, b: -1})
+	}
+	for ; j < m; j++ {
+		ops = append(ops, diffOp{typ: entity.DiffTypeAdded, a: -1, b: j})
+	}
+	return ops
+}
+
+// diffMessages returns the message level diff. The removed and added messages of the same role in a row
+// are paired as modified, with the line level diff of content.
+func diffMessages(a, b []*entity.Message) []*entity.MessageDiff {
+	keysA := make([]string, len(a))
+	for i, message := range a {
+		keysA[i] = util.ToJSON(message)
+	}
+	keysB := make([]string, len(b))
+	for i, message := range b {
+		keysB[i] = util.ToJSON(message)
+	}
+	ops := diffSequence(len(a), len(b), func(i, j int) bool {
+		return keysA[i] == keysB[j]
+	})
+
+	var result []*entity.MessageDiff
+	for k := 0; k < len(ops); {
+		if ops[k].typ == entity.DiffTypeEqual {
+			result = append(result, &entity.MessageDiff{Type: entity.DiffTypeEqual, IndexA: ops[k].a, IndexB: ops[k].b, A: a[ops[k].a], B: b[ops[k].b]})
+			k++
+			continue
+		}
+		// collect the changes until next equal message
+		var removed, added []int
+		for ; k < len(ops) && ops[k].typ != entity.DiffTypeEqual; k++ {
+			if ops[k].typ == entity.DiffTypeRemoved {
+				removed = append(removed, ops[k].a)
+			} else {
+				added = append(added, ops[k].b)
+			}
+		}
+		for len(removed) > 0 || len(added) > 0 {
+			switch {
+			case len(removed) > 0 && len(added) > 0 && messageRole(a[removed[0]]) == messageRole(b[added[0]]):
+				messageA, messageB := a[removed[0]], b[added[0]]
+				result = append(result, &entity.MessageDiff{
+					Type:      entity.DiffTypeModified,
+					IndexA:    removed[0],
+					IndexB:    added[0],
+					A:         messageA,
+					B:         messageB,
+					TextDiffs: diffText(messageContent(messageA), messageContent(messageB)),
+				})
+				removed, added = removed[1:], added[1:]
+			case len(removed) > 0:
+				result = append(result, &entity.MessageDiff{Type: entity.DiffTypeRemoved, IndexA: removed[0], IndexB: -1, A: a[removed[0]]})
+				removed = removed[1:]
+			default:
+				result = append(result, &entity.MessageDiff{Type: entity.DiffTypeAdded, IndexA: -1, IndexB: added[0], B: b[added[0]]})
+				added = added[1:]
+			}
+		}
+	}
+	return result
+}
+
+// diffText returns the line level diff of text, the consecutive lines of the same type are merged.
+func diffText(a, b string) []*entity.TextDiff {
+	linesA := strings.SplitAfter(a, "\n")
+	linesB := strings.SplitAfter(b, "\n")
+	ops := diffSequence(len(linesA), len(linesB), func(i, j int) bool {
+		return linesA[i] == linesB[j]
+	})
+	var result []*entity.TextDiff
+	for _, op := range ops {
+		var line string
+		if op.typ == entity.DiffTypeAdded {
+			line = linesB[op.b]
+		} else {
+			line = linesA[op.a]
+		}
+		if line == "" {
+			continue
+		}
+		if len(result) > 0 && result[len(result)-1].Type == op.typ {
+			result[len(result)-1].Text += line
+			continue
+		}
+		result = append(result, &entity.TextDiff{Type: op.typ, Text: line})
+	}
+	return result
+}
+
+func messageRole(message *entity.Message) entity.Role {
+	if message == nil {
+		return ""
+	}
+	return message.Role
+}
+
+// messageContent returns the text of message, including the text parts.
+func messageContent(message *entity.Message) string {
+	if message == nil {
+		return ""
+	}
+	content := util.PtrValue(message.Content)
+	for _, part := range message.Parts {
+		if part == nil {
+			continue
+		}
+		switch part.Type {
+		case entity.ContentTypeText:
+			content += util.PtrValue(part.Text)
+		case entity.ContentTypeImageURL:
+			content += fmt.Sprintf("\n[image: %s]\n", util.PtrValue(part.ImageURL))
+		default:
+		}
+	}
+	return content
+}
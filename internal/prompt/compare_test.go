@@ -0,0 +1,79 @@
+// Copyright (c) 2025 Bytedance Ltd. and/or its affiliates
+// SPDX-License-Identifier: MIT
+
+package prompt
+
+import (
+	"context"
+	"testing"
+
+	. "github.com/bytedance/mockey"
+	. "github.com/smartystreets/goconvey/convey"
+
+	"github.com/coze-dev/cozeloop-go/entity"
+	"github.com/coze-dev/cozeloop-go/internal/httpclient"
+	"github.com/coze-dev/cozeloop-go/internal/util"
+)
+
+func TestDiffText(t *testing.T) {
+	Convey("Test line level diff", t, func() {
+		diffs := diffText("a\nb\nc", "a\nB\nc\nd")
+		So(diffs, ShouldResemble, []*entity.TextDiff{
+			{Type: entity.DiffTypeEqual, Text: "a\n"},
+			{Type: entity.DiffTypeRemoved, Text: "b\nc"},
+			{Type: entity.DiffTypeAdded, Text: "B\nc\nd"},
+		})
+	})
+}
+
+func TestComparePromptVersions(t *testing.T) {
+	ctx := context.Background()
+	provider := NewPromptProvider(&httpclient.Client{}, nil, Options{WorkspaceID: "workspace1"})
+	newPrompt := func(version, system string) *entity.Prompt {
+		return &entity.Prompt{
+			PromptKey: "key1",
+			Version:   version,
+			PromptTemplate: &entity.PromptTemplate{
+				TemplateType: entity.TemplateTypeNormal,
+				Messages: []*entity.Message{
+					{Role: entity.RoleSystem, Content: util.Ptr(system)},
+					{Role: entity.RoleUser, Content: util.Ptr("{{question}}")},
+				},
+				VariableDefs: []*entity.VariableDef{{Key: "question", Type: entity.VariableTypeString}},
+			},
+		}
+	}
+
+	PatchConvey("Test compare two versions", t, func() {
+		Mock((*Provider).GetPrompt).To(func(p *Provider, ctx context.Context, param GetPromptParam, options GetPromptOptions) (*entity.Prompt, error) {
+			if param.Version == "0.0.1" {
+				return newPrompt("0.0.1", "You are a helpful assistant.\nAnswer briefly."), nil
+			}
+			return newPrompt("0.0.2", "You are a helpful assistant.\nAnswer in detail."), nil
+		}).Build()
+
+		diff, err := provider.ComparePromptVersions(ctx, "key1", "0.0.1", "0.0.2", map[string]any{"question": "hi"}, PromptFormatOptions{})
+		So(err, ShouldBeNil)
+		So(diff.VersionA, ShouldEqual, "0.0.1")
+		So(diff.Equal(), ShouldBeFalse)
+		So(len(diff.MessageDiffs), ShouldEqual, 2)
+		So(diff.MessageDiffs[0].Type, ShouldEqual, entity.DiffTypeModified)
+		So(diff.MessageDiffs[0].TextDiffs, ShouldResemble, []*entity.TextDiff{
+			{Type: entity.DiffTypeEqual, Text: "You are a helpful assistant.\n"},
+			{Type: entity.DiffTypeRemoved, Text: "Answer briefly."},
+			{Type: entity.DiffTypeAdded, Text: "Answer in detail."},
+		})
+		So(diff.MessageDiffs[1].Type, ShouldEqual, entity.DiffTypeEqual)
+		So(util.PtrValue(diff.MessagesB[1].Content), ShouldEqual, "hi")
+
+		same, err := provider.ComparePromptVersions(ctx, "key1", "0.0.1", "0.0.1", map[string]any{"question": "hi"}, PromptFormatOptions{})
+		So(err, ShouldBeNil)
+		So(same.Equal(), ShouldBeTrue)
+	})
+
+	PatchConvey("Test prompt not found", t, func() {
+		Mock((*Provider).GetPrompt).Return(nil, nil).Build()
+		_, err := provider.ComparePromptVersions(ctx, "key1", "0.0.1", "0.0.2", nil, PromptFormatOptions{})
+		So(err, ShouldNotBeNil)
+	})
+}
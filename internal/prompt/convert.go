@@ -4,6 +4,8 @@
 package prompt
 
 import (
+	"time"
+
 	"github.com/coze-dev/cozeloop-go/entity"
 	"github.com/coze-dev/cozeloop-go/internal/util"
 	"github.com/coze-dev/cozeloop-go/spec/tracespec"
@@ -23,9 +25,26 @@ func toModelPrompt(p *Prompt) *entity.Prompt {
 		Tools:          toModelTools(p.Tools),
 		ToolCallConfig: toModelToolCallConfig(p.ToolCallConfig),
 		LLMConfig:      toModelLLMConfig(p.LLMConfig),
+		VersionMeta:    toModelPromptVersionMeta(p),
 	}
 }
 
+// toModelPromptVersionMeta returns nil if the API provides no metadata of version.
+func toModelPromptVersionMeta(p *Prompt) *entity.PromptVersionMeta {
+	if p.Description == "" && p.CommitMessage == "" && p.CommittedBy == "" && p.CreatedAtMs <= 0 {
+		return nil
+	}
+	meta := &entity.PromptVersionMeta{
+		Description:   p.Description,
+		CommitMessage: p.CommitMessage,
+		CommittedBy:   p.CommittedBy,
+	}
+	if p.CreatedAtMs > 0 {
+		meta.CreatedAt = time.UnixMilli(p.CreatedAtMs)
+	}
+	return meta
+}
+
 func toModelPromptTemplate(pt *PromptTemplate) *entity.PromptTemplate {
 	if pt == nil {
 		return nil
@@ -41,12 +60,12 @@ func toModelMessages(messages []*Message) []*entity.Message {
 	if messages == nil {
 		return nil
 	}
-	result := make([]*entity.Message, len(messages))
-	for i, msg := range messages {
+	result := make([]*entity.Message, 0, len(messages))
+	for _, msg := range messages {
 		if msg == nil {
 			continue
 		}
-		result[i] = toModelMessage(msg)
+		result = append(result, toModelMessage(msg))
 	}
 	return result
 }
@@ -144,16 +163,16 @@ func toModelVariableDefs(defs []*VariableDef) []*entity.VariableDef {
 	if defs == nil {
 		return nil
 	}
-	result := make([]*entity.VariableDef, len(defs))
-	for i, def := range defs {
+	result := make([]*entity.VariableDef, 0, len(defs))
+	for _, def := range defs {
 		if def == nil {
 			continue
 		}
-		result[i] = &entity.VariableDef{
+		result = append(result, &entity.VariableDef{
 			Key:  def.Key,
 			Desc: def.Desc,
 			Type: toModelVariableType(def.Type),
-		}
+		})
 	}
 	return result
 }
@@ -162,15 +181,15 @@ func toModelTools(tools []*Tool) []*entity.Tool {
 	if tools == nil {
 		return nil
 	}
-	result := make([]*entity.Tool, len(tools))
-	for i, tool := range tools {
+	result := make([]*entity.Tool, 0, len(tools))
+	for _, tool := range tools {
 		if tool == nil {
 			continue
 		}
-		result[i] = &entity.Tool{
+		result = append(result, &entity.Tool{
 			Type:     toModelToolType(tool.Type),
 			Function: toModelFunction(tool.Function),
-		}
+		})
 	}
 	return result
 }
@@ -351,9 +370,45 @@ func toSpanMessage(message *entity.Message) *tracespec.ModelMessage {
 		return nil
 	}
 	return &tracespec.ModelMessage{
-		Role:    string(message.Role),
-		Content: util.PtrValue(message.Content),
-		Parts:   toSpanContentParts(message.Parts),
+		Role:             string(message.Role),
+		Content:          util.PtrValue(message.Content),
+		ReasoningContent: util.PtrValue(message.ReasoningContent),
+		Parts:            toSpanContentParts(message.Parts),
+		ToolCalls:        toSpanToolCalls(message.ToolCalls),
+		ToolCallID:       util.PtrValue(message.ToolCallID),
+	}
+}
+
+func toSpanToolCalls(toolCalls []*entity.ToolCall) []*tracespec.ModelToolCall {
+	var result []*tracespec.ModelToolCall
+	for _, toolCall := range toolCalls {
+		if toolCall == nil {
+			continue
+		}
+		spanToolCall := &tracespec.ModelToolCall{
+			ID:   toolCall.ID,
+			Type: string(toolCall.Type),
+		}
+		if toolCall.FunctionCall != nil {
+			spanToolCall.Function = &tracespec.ModelToolCallFunction{
+				Name:      toolCall.FunctionCall.Name,
+				Arguments: util.PtrValue(toolCall.FunctionCall.Arguments),
+			}
+		}
+		result = append(result, spanToolCall)
+	}
+	return result
+}
+
+func toSpanModelOutput(result entity.ExecuteResult) *tracespec.ModelOutput {
+	return &tracespec.ModelOutput{
+		Choices: []*tracespec.ModelChoice{
+			{
+				FinishReason: util.PtrValue(result.FinishReason),
+				Index:        0,
+				Message:      toSpanMessage(result.Message),
+			},
+		},
 	}
 }
 
@@ -542,3 +597,19 @@ func toOpenAPIFunctionCall(fc *entity.FunctionCall) *FunctionCall {
 		Arguments: fc.Arguments,
 	}
 }
+
+// toOpenAPILLMConfig converts entity.LLMConfig to openapi LLMConfig
+func toOpenAPILLMConfig(config *entity.LLMConfig) *LLMConfig {
+	if config == nil {
+		return nil
+	}
+	return &LLMConfig{
+		Temperature:      config.Temperature,
+		MaxTokens:        config.MaxTokens,
+		TopK:             config.TopK,
+		TopP:             config.TopP,
+		FrequencyPenalty: config.FrequencyPenalty,
+		PresencePenalty:  config.PresencePenalty,
+		JSONMode:         config.JSONMode,
+	}
+}
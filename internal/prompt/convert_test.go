@@ -5,6 +5,7 @@ package prompt
 
 import (
 	"testing"
+	"time"
 
 	"github.com/coze-dev/cozeloop-go/entity"
 	. "github.com/smartystreets/goconvey/convey"
@@ -60,6 +61,10 @@ func TestToModelPrompt(t *testing.T) {
 					Temperature: &temperature,
 					MaxTokens:   &maxTokens,
 				},
+				Description:   "prompt description",
+				CommitMessage: "fix typo",
+				CommittedBy:   "alice",
+				CreatedAtMs:   1700000000000,
 			}
 
 			result := toModelPrompt(input)
@@ -84,6 +89,19 @@ func TestToModelPrompt(t *testing.T) {
 			So(result.ToolCallConfig.ToolChoice, ShouldEqual, entity.ToolChoiceTypeAuto)
 			So(*result.LLMConfig.Temperature, ShouldEqual, temperature)
 			So(*result.LLMConfig.MaxTokens, ShouldEqual, maxTokens)
+
+			// Check VersionMeta
+			So(result.VersionMeta, ShouldResemble, &entity.PromptVersionMeta{
+				Description:   "prompt description",
+				CommitMessage: "fix typo",
+				CommittedBy:   "alice",
+				CreatedAt:     time.UnixMilli(1700000000000),
+			})
+		})
+
+		Convey("When metadata of version is not provided", func() {
+			result := toModelPrompt(&Prompt{PromptKey: "key1", Version: "1.0"})
+			So(result.VersionMeta, ShouldBeNil)
 		})
 	})
 }
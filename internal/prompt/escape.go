@@ -0,0 +1,107 @@
+// Copyright (c) 2025 Bytedance Ltd. and/or its affiliates
+// SPDX-License-Identifier: MIT
+
+package prompt
+
+import (
+	"strings"
+
+	"github.com/coze-dev/cozeloop-go/entity"
+)
+
+// escapeTemplateText escapes the template control sequences in s, by breaking the opening delimiters of normal and
+// jinja2 templates, "{{", "{%" and "{#", with a zero width space. The escaped text is never parsed as a tag when it
+// is formatted again, while it reads the same to models.
+func escapeTemplateText(s string) string {
+	if !strings.Contains(s, "{") {
+		return s
+	}
+	var b strings.Builder
+	b.Grow(len(s) + 8)
+	for i := 0; i < len(s); i++ {
+		b.WriteByte(s[i])
+		if s[i] == '{' && i+1 < len(s) && strings.IndexByte("{%#", s[i+1]) >= 0 {
+			b.WriteString("\u200b")
+		}
+	}
+	return b.String()
+}
+
+// escapeVariables returns a copy of variables with the template control sequences in the values escaped, except the
+// values of raw keys. The strings in slices, maps and placeholder messages are escaped too, the values passed in are
+// not modified.
+func escapeVariables(variables map[string]any, rawKeys []string) map[string]any {
+	raw := make(map[string]bool, len(rawKeys))
+	for _, key := range rawKeys {
+		raw[key] = true
+	}
+	escaped := make(map[string]any, len(variables))
+	for key, value := range variables {
+		if raw[key] {
+			escaped[key] = value
+		} else {
+			escaped[key] = escapeValue(value)
+		}
+	}
+	return escaped
+}
+
+func escapeValue(value any) any {
+	switch v := value.(type) {
+	case string:
+		return escapeTemplateText(v)
+	case []string:
+		escaped := make([]string, len(v))
+		for i, s := range v {
+			escaped[i] = escapeTemplateText(s)
+		}
+		return escaped
+	case []any:
+		escaped := make([]any, len(v))
+		for i, item := range v {
+			escaped[i] = escapeValue(item)
+		}
+		return escaped
+	case map[string]any:
+		escaped := make(map[string]any, len(v))
+		for key, item := range v {
+			escaped[key] = escapeValue(item)
+		}
+		return escaped
+	case []*entity.Message:
+		escaped := make([]*entity.Message, len(v))
+		for i, message := range v {
+			escaped[i] = escapeMessage(message)
+		}
+		return escaped
+	case []entity.Message:
+		escaped := make([]*entity.Message, len(v))
+		for i := range v {
+			escaped[i] = escapeMessage(&v[i])
+		}
+		return escaped
+	case *entity.Message:
+		return escapeMessage(v)
+	case entity.Message:
+		return escapeMessage(&v)
+	default:
+		return value
+	}
+}
+
+// escapeMessage returns a copy of placeholder message with the text of content and parts escaped.
+func escapeMessage(message *entity.Message) *entity.Message {
+	if message == nil {
+		return nil
+	}
+	escaped := copyMessageText(message)
+	if escaped.Content != nil {
+		*escaped.Content = escapeTemplateText(*escaped.Content)
+	}
+	for _, part := range escaped.Parts {
+		if part != nil && part.Text != nil {
+			*part.Text = escapeTemplateText(*part.Text)
+		}
+	}
+	return escaped
+}
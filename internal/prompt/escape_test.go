@@ -0,0 +1,71 @@
+// Copyright (c) 2025 Bytedance Ltd. and/or its affiliates
+// SPDX-License-Identifier: MIT
+
+package prompt
+
+import (
+	"context"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+
+	"github.com/coze-dev/cozeloop-go/entity"
+	"github.com/coze-dev/cozeloop-go/internal/util"
+)
+
+func TestEscapeVariables(t *testing.T) {
+	ctx := context.Background()
+	provider := NewFormatProvider(Options{})
+	newPrompt := func(templateType entity.TemplateType, content string) *entity.Prompt {
+		return &entity.Prompt{
+			PromptKey: "key1",
+			Version:   "1.0",
+			PromptTemplate: &entity.PromptTemplate{
+				TemplateType: templateType,
+				Messages: []*entity.Message{
+					{Role: entity.RoleSystem, Content: util.Ptr(content)},
+					{Role: entity.RolePlaceholder, Content: util.Ptr("history")},
+				},
+				VariableDefs: []*entity.VariableDef{
+					{Key: "name", Type: entity.VariableTypeString},
+					{Key: "question", Type: entity.VariableTypeString},
+					{Key: "snippet", Type: entity.VariableTypeString},
+					{Key: "history", Type: entity.VariableTypePlaceholder},
+				},
+			},
+		}
+	}
+
+	Convey("Test template control sequences are escaped", t, func() {
+		So(escapeTemplateText("no braces"), ShouldEqual, "no braces")
+		So(escapeTemplateText("{a} {{b}} {%c%} {#d#}"), ShouldEqual, "{a} {\u200b{b}} {\u200b%c%} {\u200b#d#}")
+		So(escapeTemplateText("{{{x"), ShouldEqual, "{\u200b{\u200b{x")
+	})
+
+	Convey("Test escaped values are kept when the partial result is formatted again", t, func() {
+		prompt := newPrompt(entity.TemplateTypeNormal, "Hi {{name}}, {{question}}")
+		partial, err := provider.PromptFormatPartial(ctx, prompt, map[string]any{"question": "what is {{name}}?"},
+			PromptFormatOptions{EscapeVariables: true})
+		So(err, ShouldBeNil)
+		So(partial.UnresolvedVariables, ShouldResemble, []string{"name"})
+
+		prompt.PromptTemplate.Messages = partial.Messages
+		messages, err := provider.PromptFormat(ctx, prompt, map[string]any{"name": "Bob"}, PromptFormatOptions{})
+		So(err, ShouldBeNil)
+		So(*messages[0].Content, ShouldEqual, "Hi Bob, what is {\u200b{name}}?")
+	})
+
+	Convey("Test raw variables and placeholder messages", t, func() {
+		prompt := newPrompt(entity.TemplateTypeJinja2, "{{ question }} {{ snippet }}")
+		history := []*entity.Message{{Role: entity.RoleUser, Content: util.Ptr("{% if true %}x{% endif %}")}}
+		messages, err := provider.PromptFormat(ctx, prompt, map[string]any{
+			"question": "{{ 7 * 7 }}",
+			"snippet":  "{{ name }}",
+			"history":  history,
+		}, PromptFormatOptions{EscapeVariables: true, RawVariables: []string{"snippet"}})
+		So(err, ShouldBeNil)
+		So(*messages[0].Content, ShouldEqual, "{\u200b{ 7 * 7 }} {{ name }}")
+		So(*messages[1].Content, ShouldEqual, "{\u200b% if true %}x{\u200b% endif %}")
+		So(*history[0].Content, ShouldEqual, "{% if true %}x{% endif %}")
+	})
+}
@@ -0,0 +1,96 @@
+// Copyright (c) 2025 Bytedance Ltd. and/or its affiliates
+// SPDX-License-Identifier: MIT
+
+package prompt
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"sort"
+	"time"
+
+	"github.com/bluele/gcache"
+
+	"github.com/coze-dev/cozeloop-go/entity"
+)
+
+const (
+	defaultExecuteCacheMaxCount = 1000
+	defaultExecuteCacheTTL      = 10 * time.Minute
+)
+
+// ExecuteCacheConf conf of the cache of Execute results.
+type ExecuteCacheConf struct {
+	// MaxCount max count of cached results, the least recently used ones are evicted. Default is 1000.
+	MaxCount int
+	// TTL how long a result is cached. Default is 10 minutes.
+	TTL time.Duration
+}
+
+// executeCache caches the results of executing a prompt version with the same variables, messages and llm config.
+// Only requests with prompt version are cached, as the prompt of a label may be changed to another version.
+type executeCache struct {
+	cache gcache.Cache
+}
+
+func newExecuteCache(conf *ExecuteCacheConf) *executeCache {
+	if conf == nil {
+		return nil
+	}
+	maxCount := conf.MaxCount
+	if maxCount <= 0 {
+		maxCount = defaultExecuteCacheMaxCount
+	}
+	ttl := conf.TTL
+	if ttl <= 0 {
+		ttl = defaultExecuteCacheTTL
+	}
+	return &executeCache{cache: gcache.New(maxCount).LRU().Expiration(ttl).Build()}
+}
+
+// key returns the hash of execute request as cache key, and false if the result should not be cached.
+func (c *executeCache) key(req ExecuteRequest) (string, bool) {
+	if c == nil || req.PromptIdentifier == nil || req.PromptIdentifier.Version == "" {
+		return "", false
+	}
+	// variables are built from map, sort them so the same variables have the same key
+	variableVals := make([]*VariableVal, len(req.VariableVals))
+	copy(variableVals, req.VariableVals)
+	sort.Slice(variableVals, func(i, j int) bool {
+		return variableVals[i].Key < variableVals[j].Key
+	})
+	req.VariableVals = variableVals
+	data, err := json.Marshal(req)
+	if err != nil {
+		return "", false
+	}
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:]), true
+}
+
+// get returns a copy of the cached result, so the cached one is not modified by callers.
+func (c *executeCache) get(key string) (entity.ExecuteResult, bool) {
+	value, err := c.cache.Get(key)
+	if err != nil {
+		return entity.ExecuteResult{}, false
+	}
+	return copyExecuteResult(value.(entity.ExecuteResult)), true
+}
+
+func (c *executeCache) set(key string, result entity.ExecuteResult) {
+	_ = c.cache.Set(key, copyExecuteResult(result))
+}
+
+func copyExecuteResult(result entity.ExecuteResult) entity.ExecuteResult {
+	copied := entity.ExecuteResult{Message: result.Message.DeepCopy()}
+	if result.FinishReason != nil {
+		finishReason := *result.FinishReason
+		copied.FinishReason = &finishReason
+	}
+	if result.Usage != nil {
+		usage := *result.Usage
+		copied.Usage = &usage
+	}
+	return copied
+}
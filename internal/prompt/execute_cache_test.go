@@ -0,0 +1,79 @@
+// Copyright (c) 2025 Bytedance Ltd. and/or its affiliates
+// SPDX-License-Identifier: MIT
+
+package prompt
+
+import (
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+
+	"github.com/coze-dev/cozeloop-go/entity"
+	"github.com/coze-dev/cozeloop-go/internal/util"
+)
+
+func TestExecuteCache(t *testing.T) {
+	newParam := func(version string, variables map[string]any) *entity.ExecuteParam {
+		return &entity.ExecuteParam{
+			PromptKey:    "key1",
+			Version:      version,
+			VariableVals: variables,
+			Messages:     []*entity.Message{{Role: entity.RoleUser, Content: util.Ptr("hello")}},
+		}
+	}
+	keyOf := func(cache *executeCache, param *entity.ExecuteParam) (string, bool) {
+		req, err := buildExecuteRequest(param, "workspace1")
+		So(err, ShouldBeNil)
+		return cache.key(req)
+	}
+
+	Convey("Test nothing is cached if cache is disabled", t, func() {
+		So(newExecuteCache(nil), ShouldBeNil)
+		_, ok := keyOf(nil, newParam("1.0", nil))
+		So(ok, ShouldBeFalse)
+	})
+
+	Convey("Test key of execute request", t, func() {
+		cache := newExecuteCache(&ExecuteCacheConf{})
+		variables := map[string]any{"a": "1", "b": "2", "c": "3", "d": 4}
+		key1, ok := keyOf(cache, newParam("1.0", variables))
+		So(ok, ShouldBeTrue)
+		for i := 0; i < 10; i++ {
+			key, _ := keyOf(cache, newParam("1.0", variables))
+			So(key, ShouldEqual, key1)
+		}
+		key2, _ := keyOf(cache, newParam("1.1", variables))
+		So(key2, ShouldNotEqual, key1)
+		key3, _ := keyOf(cache, newParam("1.0", map[string]any{"a": "1"}))
+		So(key3, ShouldNotEqual, key1)
+
+		// the prompt of label may be changed
+		param := newParam("", variables)
+		param.Label = "production"
+		_, ok = keyOf(cache, param)
+		So(ok, ShouldBeFalse)
+	})
+
+	Convey("Test cached result is copied", t, func() {
+		cache := newExecuteCache(&ExecuteCacheConf{MaxCount: 10})
+		result := entity.ExecuteResult{
+			Message:      &entity.Message{Role: entity.RoleAssistant, Content: util.Ptr("positive")},
+			FinishReason: util.Ptr("stop"),
+			Usage:        &entity.TokenUsage{InputTokens: 10, OutputTokens: 1},
+		}
+		cache.set("key", result)
+		*result.Message.Content = "negative"
+
+		cached, ok := cache.get("key")
+		So(ok, ShouldBeTrue)
+		So(*cached.Message.Content, ShouldEqual, "positive")
+		So(*cached.FinishReason, ShouldEqual, "stop")
+		So(cached.Usage.InputTokens, ShouldEqual, 10)
+		cached.Usage.InputTokens = 0
+		cached, _ = cache.get("key")
+		So(cached.Usage.InputTokens, ShouldEqual, 10)
+
+		_, ok = cache.get("missing")
+		So(ok, ShouldBeFalse)
+	})
+}
@@ -7,14 +7,20 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
 	"net/http"
 	"strings"
+	"sync"
+	"time"
 
 	"github.com/coze-dev/cozeloop-go/entity"
 	"github.com/coze-dev/cozeloop-go/internal/consts"
 	"github.com/coze-dev/cozeloop-go/internal/httpclient"
 	"github.com/coze-dev/cozeloop-go/internal/stream"
+	"github.com/coze-dev/cozeloop-go/internal/trace"
+	"github.com/coze-dev/cozeloop-go/internal/util"
 )
 
 // ExecuteSSEParser implements SSEParser for ExecuteResult
@@ -93,3 +99,134 @@ func NewExecuteStreamReader(ctx context.Context, resp *http.Response) (*ExecuteS
 		BaseStreamReader: baseReader,
 	}, nil
 }
+
+// tracedExecuteStreamReader aggregates the streamed results into the execute span,
+// and finishes the span when the stream ends, fails or is closed.
+type tracedExecuteStreamReader struct {
+	ctx    context.Context
+	reader *ExecuteStreamReader
+	span   *trace.Span
+
+	lock      sync.Mutex
+	finished  bool
+	firstResp bool
+	result    entity.ExecuteResult
+}
+
+func newTracedExecuteStreamReader(ctx context.Context, reader *ExecuteStreamReader, span *trace.Span) *tracedExecuteStreamReader {
+	return &tracedExecuteStreamReader{
+		ctx:    ctx,
+		reader: reader,
+		span:   span,
+	}
+}
+
+// Recv receives the next item from the stream
+func (r *tracedExecuteStreamReader) Recv() (entity.ExecuteResult, error) {
+	result, err := r.reader.Recv()
+	if err != nil {
+		if errors.Is(err, io.EOF) {
+			r.finish(nil)
+		} else {
+			r.finish(err)
+		}
+		return result, err
+	}
+	r.merge(result)
+	return result, nil
+}
+
+// Close closes the stream reader, the span is finished with the results received so far
+func (r *tracedExecuteStreamReader) Close() error {
+	r.finish(nil)
+	return r.reader.Close()
+}
+
+func (r *tracedExecuteStreamReader) merge(result entity.ExecuteResult) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	if r.finished {
+		return
+	}
+	if !r.firstResp {
+		r.firstResp = true
+		r.span.SetStartTimeFirstResp(r.ctx, time.Now().UnixMicro())
+	}
+	if result.Message != nil {
+		if r.result.Message == nil {
+			r.result.Message = &entity.Message{}
+		}
+		mergeMessage(r.result.Message, result.Message)
+	}
+	if util.PtrValue(result.FinishReason) != "" {
+		r.result.FinishReason = result.FinishReason
+	}
+	if result.Usage != nil {
+		r.result.Usage = result.Usage
+	}
+}
+
+func (r *tracedExecuteStreamReader) finish(err error) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	if r.finished {
+		return
+	}
+	r.finished = true
+	finishExecuteSpan(r.ctx, r.span, r.result, err)
+}
+
+// mergeMessage appends the delta message to the aggregated message
+func mergeMessage(dst, delta *entity.Message) {
+	if dst.Role == "" {
+		dst.Role = delta.Role
+	}
+	if delta.Content != nil {
+		dst.Content = util.Ptr(util.PtrValue(dst.Content) + *delta.Content)
+	}
+	if delta.ReasoningContent != nil {
+		dst.ReasoningContent = util.Ptr(util.PtrValue(dst.ReasoningContent) + *delta.ReasoningContent)
+	}
+	if delta.ToolCallID != nil {
+		dst.ToolCallID = delta.ToolCallID
+	}
+	dst.Parts = append(dst.Parts, delta.Parts...)
+	for _, toolCall := range delta.ToolCalls {
+		if toolCall == nil {
+			continue
+		}
+		var target *entity.ToolCall
+		for _, existing := range dst.ToolCalls {
+			if existing.Index == toolCall.Index {
+				target = existing
+				break
+			}
+		}
+		if target == nil {
+			copied := *toolCall
+			if toolCall.FunctionCall != nil {
+				functionCall := *toolCall.FunctionCall
+				copied.FunctionCall = &functionCall
+			}
+			dst.ToolCalls = append(dst.ToolCalls, &copied)
+			continue
+		}
+		if target.ID == "" {
+			target.ID = toolCall.ID
+		}
+		if target.Type == "" {
+			target.Type = toolCall.Type
+		}
+		if toolCall.FunctionCall != nil {
+			if target.FunctionCall == nil {
+				target.FunctionCall = &entity.FunctionCall{}
+			}
+			if target.FunctionCall.Name == "" {
+				target.FunctionCall.Name = toolCall.FunctionCall.Name
+			}
+			if toolCall.FunctionCall.Arguments != nil {
+				target.FunctionCall.Arguments = util.Ptr(util.PtrValue(target.FunctionCall.Arguments) + *toolCall.FunctionCall.Arguments)
+			}
+		}
+	}
+}
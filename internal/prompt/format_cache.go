@@ -0,0 +1,141 @@
+// Copyright (c) 2025 Bytedance Ltd. and/or its affiliates
+// SPDX-License-Identifier: MIT
+
+package prompt
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/bluele/gcache"
+
+	"github.com/coze-dev/cozeloop-go/entity"
+)
+
+const (
+	defaultFormatCacheMaxCount = 1000
+	defaultFormatCacheTTL      = 10 * time.Minute
+)
+
+// FormatCacheConf conf of the cache of PromptFormat results.
+type FormatCacheConf struct {
+	// MaxCount max count of cached results, the least recently used ones are evicted. Default is 1000.
+	MaxCount int
+	// TTL how long a result is cached. Default is 10 minutes.
+	TTL time.Duration
+}
+
+// formatCache caches the messages formatted from a prompt version with the same template and variables. Only
+// prompts with key and version are cached, local prompts built by users are always formatted. The template is
+// hashed into the key, as the prompts of local prompt dir and the ones modified by users keep their versions.
+type formatCache struct {
+	cache gcache.Cache
+}
+
+func newFormatCache(conf *FormatCacheConf) *formatCache {
+	if conf == nil {
+		return nil
+	}
+	maxCount := conf.MaxCount
+	if maxCount <= 0 {
+		maxCount = defaultFormatCacheMaxCount
+	}
+	ttl := conf.TTL
+	if ttl <= 0 {
+		ttl = defaultFormatCacheTTL
+	}
+	return &formatCache{cache: gcache.New(maxCount).LRU().Expiration(ttl).Build()}
+}
+
+// key returns the cache key of formatting, and false if the result should not be cached, e.g. the variables
+// can not be serialized. Variables are serialized into the key rather than hashed, so different variables never
+// share a result.
+func (c *formatCache) key(prompt *entity.Prompt, variables map[string]any, options PromptFormatOptions) (string, bool) {
+	if c == nil || prompt.PromptKey == "" || prompt.Version == "" || options.partial != nil ||
+		options.PlaceholderPolicy != nil {
+		return "", false
+	}
+	template, err := json.Marshal(prompt.PromptTemplate)
+	if err != nil {
+		return "", false
+	}
+	templateHash := sha256.Sum256(template)
+	keys := make([]string, 0, len(variables))
+	for key := range variables {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	buf := make([]byte, 0, 128)
+	buf = append(buf, prompt.WorkspaceID...)
+	buf = append(buf, '\t')
+	buf = append(buf, prompt.PromptKey...)
+	buf = append(buf, '\t')
+	buf = append(buf, prompt.Version...)
+	buf = append(buf, '\t')
+	buf = append(buf, prompt.PromptTemplate.TemplateType...)
+	buf = append(buf, '\t')
+	buf = append(buf, hex.EncodeToString(templateHash[:])...)
+	buf = strconv.AppendBool(append(buf, '\t'), options.StrictVariables)
+	buf = strconv.AppendBool(append(buf, '\t'), options.EscapeVariables)
+	if options.EscapeVariables {
+		rawKeys := append([]string(nil), options.RawVariables...)
+		sort.Strings(rawKeys)
+		for _, key := range rawKeys {
+			buf = strconv.AppendQuote(append(buf, '\t'), key)
+		}
+	}
+	for _, key := range keys {
+		buf = strconv.AppendQuote(append(buf, '\n'), key)
+		// type is written too, as values of different types may be serialized to the same text, e.g. 1 and 1.0,
+		// but only one of them passes the type validation
+		switch value := variables[key].(type) {
+		case string:
+			buf = strconv.AppendQuote(append(buf, "\tstring\t"...), value)
+		case bool:
+			buf = strconv.AppendBool(append(buf, "\tbool\t"...), value)
+		case int:
+			buf = strconv.AppendInt(append(buf, "\tint\t"...), int64(value), 10)
+		case int64:
+			buf = strconv.AppendInt(append(buf, "\tint64\t"...), value, 10)
+		case float64:
+			buf = strconv.AppendFloat(append(buf, "\tfloat64\t"...), value, 'g', -1, 64)
+		default:
+			data, err := json.Marshal(value)
+			if err != nil {
+				return "", false
+			}
+			buf = append(buf, fmt.Sprintf("\t%T\t", value)...)
+			buf = append(buf, data...)
+		}
+	}
+	return string(buf), true
+}
+
+// get returns a copy of the cached messages, so the cached ones are not modified by callers.
+func (c *formatCache) get(key string) ([]*entity.Message, bool) {
+	value, err := c.cache.Get(key)
+	if err != nil {
+		return nil, false
+	}
+	return copyMessages(value.([]*entity.Message)), true
+}
+
+func (c *formatCache) set(key string, messages []*entity.Message) {
+	_ = c.cache.Set(key, copyMessages(messages))
+}
+
+func copyMessages(messages []*entity.Message) []*entity.Message {
+	if messages == nil {
+		return nil
+	}
+	copied := make([]*entity.Message, len(messages))
+	for i, message := range messages {
+		copied[i] = message.DeepCopy()
+	}
+	return copied
+}
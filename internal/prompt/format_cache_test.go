@@ -0,0 +1,143 @@
+// Copyright (c) 2025 Bytedance Ltd. and/or its affiliates
+// SPDX-License-Identifier: MIT
+
+package prompt
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	. "github.com/smartystreets/goconvey/convey"
+
+	"github.com/coze-dev/cozeloop-go/entity"
+	"github.com/coze-dev/cozeloop-go/internal/httpclient"
+	"github.com/coze-dev/cozeloop-go/internal/util"
+)
+
+func newFormatCacheTestPrompt() *entity.Prompt {
+	return &entity.Prompt{
+		WorkspaceID: "workspace1",
+		PromptKey:   "key1",
+		Version:     "1.0",
+		PromptTemplate: &entity.PromptTemplate{
+			TemplateType: entity.TemplateTypeNormal,
+			Messages: []*entity.Message{
+				{Role: entity.RoleSystem, Content: util.Ptr("You are a helpful assistant of {{product}}, answer in {{language}}.")},
+				{Role: entity.RoleUser, Content: util.Ptr("My level is {{level}}.")},
+				{Role: entity.RolePlaceholder, Content: util.Ptr("history")},
+			},
+			VariableDefs: []*entity.VariableDef{
+				{Key: "product", Type: entity.VariableTypeString},
+				{Key: "language", Type: entity.VariableTypeString},
+				{Key: "level", Type: entity.VariableTypeInteger},
+				{Key: "history", Type: entity.VariableTypePlaceholder},
+			},
+		},
+	}
+}
+
+func TestFormatCache(t *testing.T) {
+	ctx := context.Background()
+	Convey("Test PromptFormat with format cache", t, func() {
+		provider := NewPromptProvider(&httpclient.Client{}, nil, Options{
+			WorkspaceID: "workspace1",
+			FormatCache: &FormatCacheConf{MaxCount: 10, TTL: time.Minute},
+		})
+		prompt := newFormatCacheTestPrompt()
+		variables := map[string]any{"product": "CozeLoop", "language": "English", "level": 1}
+
+		Convey("Same variables hit the cache, and cached messages are not modified by callers", func() {
+			messages, err := provider.PromptFormat(ctx, prompt, variables, PromptFormatOptions{})
+			So(err, ShouldBeNil)
+			So(*messages[0].Content, ShouldEqual, "You are a helpful assistant of CozeLoop, answer in English.")
+			So(provider.formatCache.cache.Len(true), ShouldEqual, 1)
+			*messages[0].Content = "modified"
+
+			messages, err = provider.PromptFormat(ctx, prompt, map[string]any{"level": 1, "language": "English", "product": "CozeLoop"}, PromptFormatOptions{})
+			So(err, ShouldBeNil)
+			So(*messages[0].Content, ShouldEqual, "You are a helpful assistant of CozeLoop, answer in English.")
+			So(provider.formatCache.cache.Len(true), ShouldEqual, 1)
+		})
+
+		Convey("Different variables, versions or options miss the cache", func() {
+			_, err := provider.PromptFormat(ctx, prompt, variables, PromptFormatOptions{})
+			So(err, ShouldBeNil)
+			messages, err := provider.PromptFormat(ctx, prompt, map[string]any{"product": "CozeLoop", "language": "Chinese", "level": 1}, PromptFormatOptions{})
+			So(err, ShouldBeNil)
+			So(*messages[0].Content, ShouldEqual, "You are a helpful assistant of CozeLoop, answer in Chinese.")
+			other := newFormatCacheTestPrompt()
+			other.Version = "2.0"
+			_, err = provider.PromptFormat(ctx, other, variables, PromptFormatOptions{})
+			So(err, ShouldBeNil)
+			_, err = provider.PromptFormat(ctx, prompt, variables, PromptFormatOptions{StrictVariables: true})
+			So(err, ShouldNotBeNil)
+			So(provider.formatCache.cache.Len(true), ShouldEqual, 3)
+		})
+
+		Convey("Prompts of the same version but modified template miss the cache", func() {
+			_, err := provider.PromptFormat(ctx, prompt, variables, PromptFormatOptions{})
+			So(err, ShouldBeNil)
+			// e.g. the file of local prompt dir is edited
+			edited := newFormatCacheTestPrompt()
+			edited.PromptTemplate.Messages[0].Content = util.Ptr("You are an assistant of {{product}}.")
+			messages, err := provider.PromptFormat(ctx, edited, variables, PromptFormatOptions{})
+			So(err, ShouldBeNil)
+			So(*messages[0].Content, ShouldEqual, "You are an assistant of CozeLoop.")
+			So(provider.formatCache.cache.Len(true), ShouldEqual, 2)
+		})
+
+		Convey("Values serialized to the same json but of different types do not share the result", func() {
+			_, err := provider.PromptFormat(ctx, prompt, variables, PromptFormatOptions{})
+			So(err, ShouldBeNil)
+			_, err = provider.PromptFormat(ctx, prompt, map[string]any{"product": "CozeLoop", "language": "English", "level": 1.0}, PromptFormatOptions{})
+			So(err, ShouldNotBeNil)
+		})
+
+		Convey("Local prompts without version and unserializable variables are not cached", func() {
+			local := newFormatCacheTestPrompt()
+			local.Version = ""
+			_, err := provider.PromptFormat(ctx, local, variables, PromptFormatOptions{})
+			So(err, ShouldBeNil)
+			_, err = provider.PromptFormat(ctx, prompt, map[string]any{"product": "CozeLoop", "language": "English", "level": 1, "fn": func() {}}, PromptFormatOptions{})
+			So(err, ShouldBeNil)
+			So(provider.formatCache.cache.Len(true), ShouldEqual, 0)
+		})
+	})
+}
+
+func benchmarkPromptFormat(b *testing.B, templateType entity.TemplateType, conf *FormatCacheConf) {
+	ctx := context.Background()
+	provider := NewPromptProvider(&httpclient.Client{}, nil, Options{WorkspaceID: "workspace1", FormatCache: conf})
+	prompt := newFormatCacheTestPrompt()
+	prompt.PromptTemplate.TemplateType = templateType
+	variables := map[string]any{
+		"product":  "CozeLoop",
+		"language": "English",
+		"level":    1,
+		"history":  []*entity.Message{{Role: entity.RoleUser, Content: util.Ptr("hello")}, {Role: entity.RoleAssistant, Content: util.Ptr("hi")}},
+	}
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := provider.PromptFormat(ctx, prompt, variables, PromptFormatOptions{}); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkPromptFormat(b *testing.B) {
+	benchmarkPromptFormat(b, entity.TemplateTypeNormal, nil)
+}
+
+func BenchmarkPromptFormatCached(b *testing.B) {
+	benchmarkPromptFormat(b, entity.TemplateTypeNormal, &FormatCacheConf{})
+}
+
+func BenchmarkPromptFormatJinja2(b *testing.B) {
+	benchmarkPromptFormat(b, entity.TemplateTypeJinja2, nil)
+}
+
+func BenchmarkPromptFormatJinja2Cached(b *testing.B) {
+	benchmarkPromptFormat(b, entity.TemplateTypeJinja2, &FormatCacheConf{})
+}
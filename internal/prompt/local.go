@@ -0,0 +1,76 @@
+// Copyright (c) 2025 Bytedance Ltd. and/or its affiliates
+// SPDX-License-Identifier: MIT
+
+package prompt
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/coze-dev/cozeloop-go/entity"
+	"github.com/coze-dev/cozeloop-go/internal/consts"
+)
+
+// localPromptExtensions extensions of local prompt files, in the order of lookup.
+var localPromptExtensions = []string{".json", ".yaml", ".yml"}
+
+// LoadLocalPrompt loads the prompt of param from dir. The file "<prompt_key>@<version>", "<prompt_key>@<label>"
+// and "<prompt_key>" is looked up in order, with the extension .json, .yaml or .yml, whose content is
+// entity.Prompt in JSON or YAML with the same field names. It returns nil if no file is found.
+func LoadLocalPrompt(dir string, param GetPromptParam) (*entity.Prompt, error) {
+	if param.PromptKey == "" {
+		return nil, consts.ErrInvalidParam.Wrap(errors.New("prompt key is required"))
+	}
+	names := make([]string, 0, 3)
+	if param.Version != "" {
+		names = append(names, param.PromptKey+"@"+param.Version)
+	}
+	if param.Label != "" {
+		names = append(names, param.PromptKey+"@"+param.Label)
+	}
+	names = append(names, param.PromptKey)
+	for _, name := range names {
+		for _, ext := range localPromptExtensions {
+			data, err := os.ReadFile(filepath.Join(dir, name+ext))
+			if errors.Is(err, os.ErrNotExist) {
+				continue
+			}
+			if err != nil {
+				return nil, consts.ErrInvalidParam.Wrap(fmt.Errorf("read local prompt %s: %w", name+ext, err))
+			}
+			prompt, err := parseLocalPrompt(data, ext)
+			if err != nil {
+				return nil, consts.ErrInvalidParam.Wrap(fmt.Errorf("parse local prompt %s: %w", name+ext, err))
+			}
+			if prompt.PromptKey == "" {
+				prompt.PromptKey = param.PromptKey
+			}
+			return prompt, nil
+		}
+	}
+	return nil, nil
+}
+
+func parseLocalPrompt(data []byte, ext string) (*entity.Prompt, error) {
+	if ext != ".json" {
+		// convert YAML to JSON, so that the json tags of entity.Prompt are used
+		var value any
+		if err := yaml.Unmarshal(data, &value); err != nil {
+			return nil, err
+		}
+		var err error
+		if data, err = json.Marshal(value); err != nil {
+			return nil, err
+		}
+	}
+	prompt := &entity.Prompt{}
+	if err := json.Unmarshal(data, prompt); err != nil {
+		return nil, err
+	}
+	return prompt, nil
+}
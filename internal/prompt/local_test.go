@@ -0,0 +1,114 @@
+// Copyright (c) 2025 Bytedance Ltd. and/or its affiliates
+// SPDX-License-Identifier: MIT
+
+package prompt
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	. "github.com/bytedance/mockey"
+	. "github.com/smartystreets/goconvey/convey"
+
+	"github.com/coze-dev/cozeloop-go/entity"
+	"github.com/coze-dev/cozeloop-go/internal/consts"
+	"github.com/coze-dev/cozeloop-go/internal/httpclient"
+)
+
+const localPromptYAML = `version: "0.0.2"
+prompt_template:
+  template_type: normal
+  messages:
+    - role: system
+      content: "Hello {{name}}"
+  variable_defs:
+    - key: name
+      type: string
+llm_config:
+  temperature: 0.5
+`
+
+func writeLocalPrompt(t *testing.T, dir, name, content string) {
+	if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestLoadLocalPrompt(t *testing.T) {
+	dir := t.TempDir()
+	writeLocalPrompt(t, dir, "greeting.yaml", localPromptYAML)
+	writeLocalPrompt(t, dir, "greeting@beta.json", `{"prompt_key":"greeting","version":"0.0.3"}`)
+	writeLocalPrompt(t, dir, "greeting@0.0.1.yml", `version: "0.0.1"`)
+	writeLocalPrompt(t, dir, "broken.json", `{`)
+
+	Convey("Test prompt is loaded from YAML", t, func() {
+		prompt, err := LoadLocalPrompt(dir, GetPromptParam{PromptKey: "greeting"})
+		So(err, ShouldBeNil)
+		So(prompt.PromptKey, ShouldEqual, "greeting")
+		So(prompt.Version, ShouldEqual, "0.0.2")
+		So(prompt.PromptTemplate.TemplateType, ShouldEqual, entity.TemplateTypeNormal)
+		So(*prompt.PromptTemplate.Messages[0].Content, ShouldEqual, "Hello {{name}}")
+		So(prompt.PromptTemplate.VariableDefs[0].Type, ShouldEqual, entity.VariableTypeString)
+		So(*prompt.LLMConfig.Temperature, ShouldEqual, 0.5)
+	})
+
+	Convey("Test version and label files take precedence", t, func() {
+		prompt, err := LoadLocalPrompt(dir, GetPromptParam{PromptKey: "greeting", Version: "0.0.1", Label: "beta"})
+		So(err, ShouldBeNil)
+		So(prompt.Version, ShouldEqual, "0.0.1")
+
+		prompt, err = LoadLocalPrompt(dir, GetPromptParam{PromptKey: "greeting", Label: "beta"})
+		So(err, ShouldBeNil)
+		So(prompt.Version, ShouldEqual, "0.0.3")
+
+		prompt, err = LoadLocalPrompt(dir, GetPromptParam{PromptKey: "greeting", Label: "prod"})
+		So(err, ShouldBeNil)
+		So(prompt.Version, ShouldEqual, "0.0.2")
+	})
+
+	Convey("Test missing and broken files", t, func() {
+		prompt, err := LoadLocalPrompt(dir, GetPromptParam{PromptKey: "missing"})
+		So(err, ShouldBeNil)
+		So(prompt, ShouldBeNil)
+
+		_, err = LoadLocalPrompt(dir, GetPromptParam{PromptKey: "broken"})
+		So(errors.Is(err, consts.ErrInvalidParam), ShouldBeTrue)
+	})
+}
+
+func TestGetLocalPrompt(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+	writeLocalPrompt(t, dir, "greeting.yaml", localPromptYAML)
+
+	PatchConvey("Test local prompt is returned without fetching from server", t, func() {
+		mockMPull := Mock((*OpenAPIClient).MPullPrompt).Return([]*PromptResult{}, nil).Build()
+		provider := NewPromptProvider(&httpclient.Client{}, nil, Options{WorkspaceID: "workspace1", LocalPromptDir: dir})
+		prompt, err := provider.GetPrompt(ctx, GetPromptParam{PromptKey: "greeting"}, GetPromptOptions{})
+		So(err, ShouldBeNil)
+		So(prompt.Version, ShouldEqual, "0.0.2")
+		So(prompt.WorkspaceID, ShouldEqual, "workspace1")
+		So(mockMPull.Times(), ShouldEqual, 0)
+
+		prompt, err = provider.GetPrompt(ctx, GetPromptParam{PromptKey: "other"}, GetPromptOptions{})
+		So(err, ShouldBeNil)
+		So(prompt, ShouldBeNil)
+		So(mockMPull.Times(), ShouldEqual, 1)
+	})
+
+	PatchConvey("Test local prompt only", t, func() {
+		mockMPull := Mock((*OpenAPIClient).MPullPrompt).Return([]*PromptResult{}, nil).Build()
+		provider := NewPromptProvider(&httpclient.Client{}, nil, Options{
+			WorkspaceID:         "workspace1",
+			LocalPromptDir:      dir,
+			LocalPromptOnly:     true,
+			PromptNotFoundError: true,
+		})
+		_, err := provider.GetPrompt(ctx, GetPromptParam{PromptKey: "other"}, GetPromptOptions{})
+		So(errors.Is(err, consts.ErrPromptNotFound), ShouldBeTrue)
+		So(mockMPull.Times(), ShouldEqual, 0)
+	})
+}
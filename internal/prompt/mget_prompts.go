@@ -0,0 +1,151 @@
+// Copyright (c) 2025 Bytedance Ltd. and/or its affiliates
+// SPDX-License-Identifier: MIT
+
+package prompt
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/coze-dev/cozeloop-go/entity"
+	"github.com/coze-dev/cozeloop-go/internal/consts"
+	"github.com/coze-dev/cozeloop-go/internal/logger"
+	"github.com/coze-dev/cozeloop-go/internal/trace"
+	"github.com/coze-dev/cozeloop-go/internal/util"
+	"github.com/coze-dev/cozeloop-go/spec/tracespec"
+)
+
+// MGetPrompts gets the prompts of params, keyed by the params. The prompts in local prompt dir or cache are
+// returned directly, and the others are pulled from server in one request, sharing the cache with GetPrompt.
+// Prompts which do not exist are absent from the result, and if some of the batches of pull fail, the prompts
+// got are returned with the error.
+func (p *Provider) MGetPrompts(ctx context.Context, params []GetPromptParam, options GetPromptOptions) (
+	prompts map[GetPromptParam]*entity.Prompt, err error,
+) {
+	if p.config.PromptTrace && p.traceProvider != nil {
+		var promptHubSpan *trace.Span
+		var spanErr error
+		ctx, promptHubSpan, spanErr = p.traceProvider.StartSpan(ctx, consts.TracePromptHubSpanName, tracespec.VPromptHubSpanType,
+			trace.StartSpanOptions{Scene: tracespec.VScenePromptHub})
+		if spanErr != nil {
+			logger.CtxWarnf(ctx, "start prompt hub span failed: %v", spanErr)
+		}
+		defer func() {
+			if promptHubSpan != nil {
+				promptHubSpan.SetTags(ctx, map[string]any{tracespec.Input: util.ToJSON(params)})
+				if len(options.SpanTags) > 0 {
+					promptHubSpan.SetTags(ctx, options.SpanTags)
+				}
+				if len(prompts) > 0 {
+					found := make([]*entity.Prompt, 0, len(prompts))
+					for _, param := range params {
+						if prompt, ok := prompts[param]; ok {
+							found = append(found, prompt)
+						}
+					}
+					promptHubSpan.SetTags(ctx, map[string]any{tracespec.Output: util.ToJSON(found)})
+				}
+				if err != nil {
+					promptHubSpan.SetStatusCode(ctx, util.GetErrorCode(err))
+					promptHubSpan.SetError(ctx, err)
+				}
+				promptHubSpan.Finish(ctx)
+			}
+		}()
+	}
+	return p.doMGetPrompts(ctx, params, options)
+}
+
+func (p *Provider) doMGetPrompts(ctx context.Context, params []GetPromptParam, options GetPromptOptions) (
+	prompts map[GetPromptParam]*entity.Prompt, err error,
+) {
+	defer func() {
+		// object cache item should be read only, it is returned without copy only if sharing is allowed
+		if !p.config.PromptShared {
+			for param, prompt := range prompts {
+				prompts[param] = prompt.DeepCopy()
+			}
+		}
+	}()
+	prompts = make(map[GetPromptParam]*entity.Prompt, len(params))
+	cache := p.getCache(options.WorkspaceID, options.FieldMask)
+	var missing []GetPromptParam
+	var queries []PromptQuery
+	seen := make(map[GetPromptParam]bool, len(params))
+	for _, param := range params {
+		if seen[param] {
+			continue
+		}
+		seen[param] = true
+		prompt, done, err := p.getLocalOrCached(cache, param, options)
+		switch {
+		case err != nil && !errors.Is(err, consts.ErrPromptNotFound):
+			return nil, err
+		case prompt != nil:
+			prompts[param] = prompt
+		case done:
+			missing = append(missing, param)
+		default:
+			queries = append(queries, PromptQuery{PromptKey: param.PromptKey, Version: param.Version, Label: param.Label})
+		}
+	}
+	if len(queries) > 0 {
+		callCtx, cancel := withCallTimeout(ctx, options.Timeout)
+		defer cancel()
+		promptResults, pullErr := p.openAPIClient.MPullPrompt(callCtx, MPullPromptRequest{
+			WorkSpaceID: cache.workspaceID,
+			FieldMask:   cache.option.FieldMask,
+			Queries:     queries,
+		})
+		var mpullErr *MPullPromptError
+		if pullErr != nil && !errors.As(pullErr, &mpullErr) {
+			return nil, pullErr
+		}
+		failed := make(map[PromptQuery]bool)
+		if mpullErr != nil {
+			for _, failure := range mpullErr.Failures {
+				for _, query := range failure.Queries {
+					failed[query] = true
+				}
+			}
+		}
+		pulled := make(map[PromptQuery]*entity.Prompt, len(promptResults))
+		for _, promptResult := range promptResults {
+			if promptResult.Prompt == nil {
+				continue
+			}
+			query := promptResult.Query
+			result := toModelPrompt(promptResult.Prompt)
+			cache.Set(query.PromptKey, query.Version, query.Label, result)
+			pulled[query] = result
+		}
+		for _, query := range queries {
+			param := GetPromptParam{PromptKey: query.PromptKey, Version: query.Version, Label: query.Label}
+			if result, ok := pulled[query]; ok {
+				prompts[param] = result
+				continue
+			}
+			if failed[query] {
+				continue
+			}
+			if p.config.PromptNotFoundCacheTTL > 0 {
+				cache.SetNotFound(query.PromptKey, query.Version, query.Label, p.config.PromptNotFoundCacheTTL)
+			}
+			missing = append(missing, param)
+		}
+		if pullErr != nil {
+			return prompts, pullErr
+		}
+	}
+	if len(missing) > 0 && p.config.PromptNotFoundError {
+		details := make([]string, 0, len(missing))
+		for _, param := range missing {
+			details = append(details, fmt.Sprintf("[prompt_key: %s, version: %s, label: %s]", param.PromptKey,
+				param.Version, param.Label))
+		}
+		return prompts, consts.ErrPromptNotFound.Wrap(fmt.Errorf("prompts not found: %s", strings.Join(details, ", ")))
+	}
+	return prompts, nil
+}
@@ -0,0 +1,102 @@
+// Copyright (c) 2025 Bytedance Ltd. and/or its affiliates
+// SPDX-License-Identifier: MIT
+
+package prompt
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	. "github.com/bytedance/mockey"
+	. "github.com/smartystreets/goconvey/convey"
+
+	"github.com/coze-dev/cozeloop-go/internal/consts"
+	"github.com/coze-dev/cozeloop-go/internal/httpclient"
+)
+
+func TestMGetPrompts(t *testing.T) {
+	ctx := context.Background()
+	newProvider := func(options Options) *Provider {
+		options.WorkspaceID = "workspace1"
+		options.PromptCacheRefreshInterval = time.Minute
+		return NewPromptProvider(&httpclient.Client{}, nil, options)
+	}
+	var requests []MPullPromptRequest
+	mockMPull := func() {
+		requests = nil
+		Mock((*OpenAPIClient).MPullPrompt).To(func(ctx context.Context, req MPullPromptRequest) ([]*PromptResult, error) {
+			requests = append(requests, req)
+			var results []*PromptResult
+			for _, query := range req.Queries {
+				result := &PromptResult{Query: query}
+				if query.PromptKey != "missing" {
+					result.Prompt = &Prompt{WorkspaceID: req.WorkSpaceID, PromptKey: query.PromptKey, Version: "1.0"}
+				}
+				results = append(results, result)
+			}
+			return results, nil
+		}).Build()
+	}
+
+	Convey("Test prompts not cached are pulled in one request", t, func() {
+		mockMPull()
+		defer UnPatchAll()
+		provider := newProvider(Options{PromptNotFoundCacheTTL: time.Minute, PromptShared: true})
+		params := []GetPromptParam{
+			{PromptKey: "key1"},
+			{PromptKey: "key2", Label: "production"},
+			{PromptKey: "key1"},
+			{PromptKey: "missing", Version: "1.0"},
+		}
+
+		prompts, err := provider.MGetPrompts(ctx, params, GetPromptOptions{})
+		So(err, ShouldBeNil)
+		So(len(prompts), ShouldEqual, 2)
+		So(prompts[GetPromptParam{PromptKey: "key1"}].PromptKey, ShouldEqual, "key1")
+		So(prompts[GetPromptParam{PromptKey: "key2", Label: "production"}].PromptKey, ShouldEqual, "key2")
+		So(len(requests), ShouldEqual, 1)
+		So(len(requests[0].Queries), ShouldEqual, 3)
+
+		// the cache is shared with GetPrompt
+		prompt, err := provider.GetPrompt(ctx, GetPromptParam{PromptKey: "key2", Label: "production"}, GetPromptOptions{})
+		So(err, ShouldBeNil)
+		So(prompt, ShouldPointTo, prompts[GetPromptParam{PromptKey: "key2", Label: "production"}])
+		prompts, err = provider.MGetPrompts(ctx, append(params, GetPromptParam{PromptKey: "key3"}), GetPromptOptions{})
+		So(err, ShouldBeNil)
+		So(len(prompts), ShouldEqual, 3)
+		So(len(requests), ShouldEqual, 2)
+		So(requests[1].Queries, ShouldResemble, []PromptQuery{{PromptKey: "key3"}})
+	})
+
+	Convey("Test missing prompts are returned as error if required", t, func() {
+		mockMPull()
+		defer UnPatchAll()
+		provider := newProvider(Options{PromptNotFoundError: true})
+		prompts, err := provider.MGetPrompts(ctx, []GetPromptParam{{PromptKey: "key1"}, {PromptKey: "missing"}},
+			GetPromptOptions{WorkspaceID: "workspace2"})
+		So(errors.Is(err, consts.ErrPromptNotFound), ShouldBeTrue)
+		So(err.Error(), ShouldContainSubstring, "prompt_key: missing")
+		So(len(prompts), ShouldEqual, 1)
+		So(prompts[GetPromptParam{PromptKey: "key1"}].WorkspaceID, ShouldEqual, "workspace2")
+		cached, _ := provider.getCache("workspace2", nil).Get("key1", "", "")
+		So(prompts[GetPromptParam{PromptKey: "key1"}], ShouldNotPointTo, cached)
+	})
+
+	Convey("Test prompts got are returned if some batches fail", t, func() {
+		pullErr := &MPullPromptError{BatchCount: 2, Failures: []*MPullPromptFailure{
+			{Queries: []PromptQuery{{PromptKey: "key2"}}, Err: errors.New("timeout")},
+		}}
+		Mock((*OpenAPIClient).MPullPrompt).Return([]*PromptResult{
+			{Query: PromptQuery{PromptKey: "key1"}, Prompt: &Prompt{PromptKey: "key1"}},
+		}, pullErr).Build()
+		defer UnPatchAll()
+		provider := newProvider(Options{PromptNotFoundError: true})
+		prompts, err := provider.MGetPrompts(ctx, []GetPromptParam{{PromptKey: "key1"}, {PromptKey: "key2"}},
+			GetPromptOptions{})
+		So(err, ShouldEqual, pullErr)
+		So(len(prompts), ShouldEqual, 1)
+		So(provider.cache.IsNotFound("key2", "", ""), ShouldBeFalse)
+	})
+}
@@ -6,20 +6,29 @@ package prompt
 import (
 	"context"
 	"encoding/json"
+	"fmt"
 	"net/http"
 	"sort"
+	"strings"
+	"sync"
 	"time"
 
 	"golang.org/x/sync/singleflight"
 
+	"github.com/coze-dev/cozeloop-go/internal/consts"
 	"github.com/coze-dev/cozeloop-go/internal/httpclient"
+	"github.com/coze-dev/cozeloop-go/internal/util"
 )
 
 const (
 	mpullPromptPath            = "/v1/loop/prompts/mget"
 	executePromptPath          = "/v1/loop/prompts/execute"
 	executeStreamingPromptPath = "/v1/loop/prompts/execute_streaming"
+	listPromptVersionsPath     = "/v1/loop/prompts/versions/list"
 	maxPromptQueryBatchSize    = 25
+	// maxConcurrentPromptQueryBatches max count of batches pulled at the same time
+	maxConcurrentPromptQueryBatches = 4
+	listPromptVersionsPageSize      = 100
 
 	defaultExecuteTimeout = 10 * time.Minute
 )
@@ -32,6 +41,11 @@ type Prompt struct {
 	Tools          []*Tool         `json:"tools,omitempty"`
 	ToolCallConfig *ToolCallConfig `json:"tool_call_config,omitempty"`
 	LLMConfig      *LLMConfig      `json:"llm_config,omitempty"`
+	// metadata of version, which is returned by the API of newer versions
+	Description   string `json:"description,omitempty"`
+	CommitMessage string `json:"commit_message,omitempty"`
+	CommittedBy   string `json:"committed_by,omitempty"`
+	CreatedAtMs   int64  `json:"created_at_ms,omitempty"`
 }
 
 type PromptTemplate struct {
@@ -175,6 +189,78 @@ type OpenAPIClient struct {
 type MPullPromptRequest struct {
 	WorkSpaceID string        `json:"workspace_id"`
 	Queries     []PromptQuery `json:"queries"`
+	FieldMask   *FieldMask    `json:"field_mask,omitempty"`
+}
+
+// Fields of prompt which can be masked. Workspace id, prompt key, version and metadata of version are always
+// returned.
+const (
+	PromptFieldPromptTemplate = "prompt_template"
+	PromptFieldTools          = "tools"
+	PromptFieldToolCallConfig = "tool_call_config"
+	PromptFieldLLMConfig      = "llm_config"
+)
+
+// FieldMask selects the fields of prompt to pull, to reduce the payload and cache memory of large prompts.
+type FieldMask struct {
+	// Include only pull these fields if not empty
+	Include []string `json:"include,omitempty"`
+	// Exclude do not pull these fields
+	Exclude []string `json:"exclude,omitempty"`
+}
+
+func (m *FieldMask) isEmpty() bool {
+	return m == nil || (len(m.Include) == 0 && len(m.Exclude) == 0)
+}
+
+// key returns the same key for masks with the same fields in different order.
+func (m *FieldMask) key() string {
+	if m.isEmpty() {
+		return ""
+	}
+	include := append([]string(nil), m.Include...)
+	exclude := append([]string(nil), m.Exclude...)
+	sort.Strings(include)
+	sort.Strings(exclude)
+	return strings.Join(include, ",") + "|" + strings.Join(exclude, ",")
+}
+
+func (m *FieldMask) keep(field string) bool {
+	if m.isEmpty() {
+		return true
+	}
+	if len(m.Include) > 0 && !containsField(m.Include, field) {
+		return false
+	}
+	return !containsField(m.Exclude, field)
+}
+
+func containsField(fields []string, field string) bool {
+	for _, f := range fields {
+		if f == field {
+			return true
+		}
+	}
+	return false
+}
+
+// apply clears the masked fields of prompt.
+func (m *FieldMask) apply(p *Prompt) {
+	if m.isEmpty() || p == nil {
+		return
+	}
+	if !m.keep(PromptFieldPromptTemplate) {
+		p.PromptTemplate = nil
+	}
+	if !m.keep(PromptFieldTools) {
+		p.Tools = nil
+	}
+	if !m.keep(PromptFieldToolCallConfig) {
+		p.ToolCallConfig = nil
+	}
+	if !m.keep(PromptFieldLLMConfig) {
+		p.LLMConfig = nil
+	}
 }
 
 type MPullPromptResponse struct {
@@ -197,6 +283,8 @@ type PromptResult struct {
 	Prompt *Prompt     `json:"prompt,omitempty"`
 }
 
+// MPullPrompt pulls prompts of queries. Queries more than maxPromptQueryBatchSize are pulled in batches concurrently,
+// and if some of the batches fail, the results of the others are returned with *MPullPromptError.
 func (o *OpenAPIClient) MPullPrompt(ctx context.Context, req MPullPromptRequest) ([]*PromptResult, error) {
 	// Sort the entire request's Queries
 	sort.Slice(req.Queries, func(i, j int) bool {
@@ -211,47 +299,142 @@ func (o *OpenAPIClient) MPullPrompt(ctx context.Context, req MPullPromptRequest)
 		return o.singleflightMPullPrompt(ctx, req)
 	}
 
-	// Process the requests in batches
-	var allPrompts []*PromptResult
+	// Process the requests in batches concurrently, the results of failed batches are skipped
+	var batches []MPullPromptRequest
 	for i := 0; i < len(req.Queries); i += maxPromptQueryBatchSize {
 		end := i + maxPromptQueryBatchSize
 		if end > len(req.Queries) {
 			end = len(req.Queries)
 		}
-
-		batchReq := MPullPromptRequest{
+		batches = append(batches, MPullPromptRequest{
 			WorkSpaceID: req.WorkSpaceID,
 			Queries:     req.Queries[i:end],
+			FieldMask:   req.FieldMask,
+		})
+	}
+	batchResults := make([][]*PromptResult, len(batches))
+	batchErrs := make([]error, len(batches))
+	sem := make(chan struct{}, maxConcurrentPromptQueryBatches)
+	var wg sync.WaitGroup
+	for i := range batches {
+		i := i
+		wg.Add(1)
+		sem <- struct{}{}
+		util.GoSafe(ctx, func() {
+			defer func() {
+				<-sem
+				wg.Done()
+			}()
+			batchResults[i], batchErrs[i] = o.singleflightMPullPrompt(ctx, batches[i])
+		})
+	}
+	wg.Wait()
+
+	var allPrompts []*PromptResult
+	var mpullErr *MPullPromptError
+	for i, batch := range batches {
+		if batchErrs[i] != nil {
+			if mpullErr == nil {
+				mpullErr = &MPullPromptError{BatchCount: len(batches)}
+			}
+			mpullErr.Failures = append(mpullErr.Failures, &MPullPromptFailure{Queries: batch.Queries, Err: batchErrs[i]})
+			continue
 		}
+		allPrompts = append(allPrompts, batchResults[i]...)
+	}
+	if mpullErr != nil {
+		return allPrompts, mpullErr
+	}
+	return allPrompts, nil
+}
 
-		prompts, err := o.singleflightMPullPrompt(ctx, batchReq)
-		if err != nil {
-			return nil, err
+// MPullPromptError is returned by MPullPrompt when some of the batches fail, along with the results
+// of the succeeded batches.
+type MPullPromptError struct {
+	BatchCount int
+	Failures   []*MPullPromptFailure
+}
+
+// MPullPromptFailure the queries of a failed batch and the error.
+type MPullPromptFailure struct {
+	Queries []PromptQuery
+	Err     error
+}
+
+func (e *MPullPromptError) Error() string {
+	details := make([]string, 0, len(e.Failures))
+	for _, failure := range e.Failures {
+		keys := make([]string, 0, len(failure.Queries))
+		for _, query := range failure.Queries {
+			keys = append(keys, query.PromptKey)
 		}
-		allPrompts = append(allPrompts, prompts...)
+		details = append(details, fmt.Sprintf("[%s]: %v", strings.Join(keys, ", "), failure.Err))
 	}
+	return fmt.Sprintf("mpull prompt failed in %d of %d batches, %s", len(e.Failures), e.BatchCount, strings.Join(details, "; "))
+}
 
-	return allPrompts, nil
+// Unwrap returns the error of the first failed batch.
+func (e *MPullPromptError) Unwrap() error {
+	if len(e.Failures) == 0 {
+		return nil
+	}
+	return e.Failures[0].Err
+}
+
+// applyFieldMask clears the masked fields of prompts, in case that server returns them.
+func applyFieldMask(mask *FieldMask, results []*PromptResult) {
+	for _, result := range results {
+		if result != nil {
+			mask.apply(result.Prompt)
+		}
+	}
 }
 
+// singleflightMPullPrompt shares the request of the same queries between concurrent callers. The shared request
+// is detached from the ctx of the caller who starts it and has its own timeout, so that the cancellation of any
+// caller does not fail the others, and each caller stops waiting when its own ctx is done. The field mask is applied
+// before the results are shared, which must not be modified by callers.
 func (o *OpenAPIClient) singleflightMPullPrompt(ctx context.Context, req MPullPromptRequest) ([]*PromptResult, error) {
 	// Queries are already sorted in the upper layer, so generate the key directly here
 	b, _ := json.Marshal(req)
 	key := string(b)
 
-	v, err, _ := o.sf.Do(key, func() (interface{}, error) {
-		return o.doMPullPrompt(ctx, req)
+	ch := o.sf.DoChan(key, func() (interface{}, error) {
+		sharedCtx, cancel := context.WithTimeout(util.DetachContext(ctx), o.sharedTimeout())
+		defer cancel()
+		prompts, err := o.doMPullPrompt(sharedCtx, req)
+		if err != nil {
+			return nil, err
+		}
+		applyFieldMask(req.FieldMask, prompts)
+		return prompts, nil
 	})
 
-	if err != nil {
-		return nil, err
+	var result singleflight.Result
+	select {
+	case result = <-ch:
+	case <-ctx.Done():
+		return nil, ctx.Err()
 	}
 
-	if v == nil {
+	if result.Err != nil {
+		return nil, result.Err
+	}
+
+	if result.Val == nil {
 		return nil, nil
 	}
 
-	return v.([]*PromptResult), nil
+	return result.Val.([]*PromptResult), nil
+}
+
+// sharedTimeout returns the timeout of the request shared by singleflight, which is the timeout of client, or
+// consts.DefaultTimeout if the client has no timeout.
+func (o *OpenAPIClient) sharedTimeout() time.Duration {
+	if timeout := o.httpClient.Timeout(); timeout > 0 {
+		return timeout
+	}
+	return consts.DefaultTimeout
 }
 
 func (o *OpenAPIClient) doMPullPrompt(ctx context.Context, req MPullPromptRequest) ([]*PromptResult, error) {
@@ -268,6 +451,7 @@ type ExecuteRequest struct {
 	PromptIdentifier *PromptQuery   `json:"prompt_identifier,omitempty"`
 	VariableVals     []*VariableVal `json:"variable_vals,omitempty"`
 	Messages         []*Message     `json:"messages,omitempty"`
+	LLMConfig        *LLMConfig     `json:"llm_config,omitempty"`
 }
 
 type ExecuteResponse struct {
@@ -292,8 +476,12 @@ type ExecuteStreamingData struct {
 
 // Execute 执行Prompt请求
 func (o *OpenAPIClient) Execute(ctx context.Context, req ExecuteRequest) (*ExecuteData, error) {
-	ctx, cancel := context.WithTimeout(ctx, defaultExecuteTimeout)
-	defer cancel()
+	// the deadline of ctx, such as the one set by the timeout of call, overrides the default timeout
+	if _, ok := ctx.Deadline(); !ok {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, defaultExecuteTimeout)
+		defer cancel()
+	}
 	var response ExecuteResponse
 	err := o.httpClient.Post(ctx, executePromptPath, req, &response)
 	if err != nil {
@@ -307,3 +495,49 @@ func (o *OpenAPIClient) ExecuteStreaming(ctx context.Context, req ExecuteRequest
 	ctx, _ = context.WithTimeout(ctx, defaultExecuteTimeout)
 	return o.httpClient.PostStream(ctx, executeStreamingPromptPath, req)
 }
+
+type ListPromptVersionsRequest struct {
+	WorkspaceID string `json:"workspace_id"`
+	PromptKey   string `json:"prompt_key"`
+	PageToken   string `json:"page_token,omitempty"`
+	PageSize    int    `json:"page_size,omitempty"`
+}
+
+type ListPromptVersionsResponse struct {
+	httpclient.BaseResponse
+	Data *ListPromptVersionsData `json:"data"`
+}
+
+type ListPromptVersionsData struct {
+	Versions      []*PromptVersionInfo `json:"versions,omitempty"`
+	NextPageToken string               `json:"next_page_token,omitempty"`
+	HasMore       bool                 `json:"has_more"`
+}
+
+type PromptVersionInfo struct {
+	Version       string   `json:"version"`
+	Description   string   `json:"description,omitempty"`
+	Labels        []string `json:"labels,omitempty"`
+	CommittedBy   string   `json:"committed_by,omitempty"`
+	CommittedAtMs int64    `json:"committed_at_ms,omitempty"`
+}
+
+// ListPromptVersions pulls all pages of versions of the prompt.
+func (o *OpenAPIClient) ListPromptVersions(ctx context.Context, req ListPromptVersionsRequest) ([]*PromptVersionInfo, error) {
+	req.PageSize = listPromptVersionsPageSize
+	var versions []*PromptVersionInfo
+	for {
+		var resp ListPromptVersionsResponse
+		if err := o.httpClient.Post(ctx, listPromptVersionsPath, req, &resp); err != nil {
+			return nil, err
+		}
+		if resp.Data == nil {
+			return versions, nil
+		}
+		versions = append(versions, resp.Data.Versions...)
+		if !resp.Data.HasMore || resp.Data.NextPageToken == "" {
+			return versions, nil
+		}
+		req.PageToken = resp.Data.NextPageToken
+	}
+}
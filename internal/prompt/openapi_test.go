@@ -7,11 +7,20 @@ import (
 	"context"
 	"encoding/json"
 	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
 	"testing"
+	"time"
 
 	. "github.com/bytedance/mockey"
-	"github.com/coze-dev/cozeloop-go/internal/httpclient"
 	. "github.com/smartystreets/goconvey/convey"
+
+	"github.com/coze-dev/cozeloop-go/internal/consts"
+	"github.com/coze-dev/cozeloop-go/internal/httpclient"
 )
 
 func TestOpenAPIClient_MPullPrompt(t *testing.T) {
@@ -129,11 +138,12 @@ func TestOpenAPIClient_MPullPrompt(t *testing.T) {
 
 			// Capture the sorted queries
 			var capturedReq MPullPromptRequest
-			Mock((*OpenAPIClient).singleflightMPullPrompt).
+			mockCapture := Mock((*OpenAPIClient).singleflightMPullPrompt).
 				To(func(ctx context.Context, req MPullPromptRequest) ([]*PromptResult, error) {
 					capturedReq = req
 					return []*PromptResult{}, nil
 				}).Build()
+			defer mockCapture.UnPatch()
 
 			_, _ = client.MPullPrompt(ctx, req)
 
@@ -344,3 +354,217 @@ func TestHelperFunctions(t *testing.T) {
 		})
 	})
 }
+
+func TestFieldMask(t *testing.T) {
+	Convey("Test FieldMask", t, func() {
+		var nilMask *FieldMask
+		So(nilMask.key(), ShouldEqual, "")
+		So(nilMask.keep(PromptFieldTools), ShouldBeTrue)
+
+		mask1 := &FieldMask{Include: []string{PromptFieldTools, PromptFieldPromptTemplate}}
+		mask2 := &FieldMask{Include: []string{PromptFieldPromptTemplate, PromptFieldTools}}
+		So(mask1.key(), ShouldEqual, mask2.key())
+
+		p := &Prompt{
+			PromptTemplate: &PromptTemplate{},
+			Tools:          []*Tool{{}},
+			ToolCallConfig: &ToolCallConfig{},
+			LLMConfig:      &LLMConfig{},
+		}
+		mask1.apply(p)
+		So(p.PromptTemplate, ShouldNotBeNil)
+		So(p.Tools, ShouldNotBeNil)
+		So(p.ToolCallConfig, ShouldBeNil)
+		So(p.LLMConfig, ShouldBeNil)
+	})
+}
+
+func TestMPullPromptConcurrentBatches(t *testing.T) {
+	ctx := context.Background()
+	var running, maxRunning int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := atomic.AddInt32(&running, 1)
+		defer atomic.AddInt32(&running, -1)
+		for {
+			m := atomic.LoadInt32(&maxRunning)
+			if n <= m || atomic.CompareAndSwapInt32(&maxRunning, m, n) {
+				break
+			}
+		}
+		var req MPullPromptRequest
+		_ = json.NewDecoder(r.Body).Decode(&req)
+		// the batch with key "bad_*" fails
+		if strings.HasPrefix(req.Queries[0].PromptKey, "bad_") {
+			_ = json.NewEncoder(w).Encode(map[string]any{"code": 500, "msg": "internal error"})
+			return
+		}
+		items := make([]*PromptResult, 0, len(req.Queries))
+		for _, query := range req.Queries {
+			items = append(items, &PromptResult{Query: query, Prompt: &Prompt{PromptKey: query.PromptKey, Version: query.Version,
+				Tools: []*Tool{{}}, LLMConfig: &LLMConfig{}}})
+		}
+		_ = json.NewEncoder(w).Encode(map[string]any{"code": 0, "data": PromptResultData{Items: items}})
+	}))
+	defer server.Close()
+	client := &OpenAPIClient{httpClient: httpclient.NewClient(server.URL, http.DefaultClient, httpclient.NewTokenAuth("token"), nil)}
+
+	queries := func(prefix string, n int) []PromptQuery {
+		var queries []PromptQuery
+		for i := 0; i < n; i++ {
+			queries = append(queries, PromptQuery{PromptKey: fmt.Sprintf("%s%03d", prefix, i), Version: "1"})
+		}
+		return queries
+	}
+
+	Convey("Test batches are pulled concurrently", t, func() {
+		results, err := client.MPullPrompt(ctx, MPullPromptRequest{WorkSpaceID: "workspace1", Queries: queries("good_", maxPromptQueryBatchSize*6)})
+		So(err, ShouldBeNil)
+		So(len(results), ShouldEqual, maxPromptQueryBatchSize*6)
+		So(results[0].Query.PromptKey, ShouldEqual, "good_000")
+		So(results[len(results)-1].Query.PromptKey, ShouldEqual, fmt.Sprintf("good_%03d", maxPromptQueryBatchSize*6-1))
+		So(atomic.LoadInt32(&maxRunning), ShouldBeLessThanOrEqualTo, maxConcurrentPromptQueryBatches)
+	})
+
+	Convey("Test results of succeeded batches are returned with the failed queries", t, func() {
+		req := MPullPromptRequest{WorkSpaceID: "workspace1", Queries: append(queries("bad_", maxPromptQueryBatchSize), queries("good_", maxPromptQueryBatchSize)...)}
+		results, err := client.MPullPrompt(ctx, req)
+		So(len(results), ShouldEqual, maxPromptQueryBatchSize)
+		So(results[0].Query.PromptKey, ShouldEqual, "good_000")
+		var mpullErr *MPullPromptError
+		So(errors.As(err, &mpullErr), ShouldBeTrue)
+		So(mpullErr.BatchCount, ShouldEqual, 2)
+		So(len(mpullErr.Failures), ShouldEqual, 1)
+		So(mpullErr.Failures[0].Queries[0].PromptKey, ShouldEqual, "bad_000")
+		So(errors.Is(err, consts.ErrRemoteService), ShouldBeTrue)
+		So(err.Error(), ShouldContainSubstring, "failed in 1 of 2 batches")
+	})
+
+	Convey("Test field mask does not strip the results of callers with other masks", t, func() {
+		var wg sync.WaitGroup
+		results := make([][]*PromptResult, 2)
+		masks := []*FieldMask{{Exclude: []string{PromptFieldTools}}, nil}
+		for i := range masks {
+			i := i
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				results[i], _ = client.MPullPrompt(ctx, MPullPromptRequest{WorkSpaceID: "workspace1", Queries: queries("good_", 1),
+					FieldMask: masks[i]})
+			}()
+		}
+		wg.Wait()
+		So(results[0][0].Prompt.Tools, ShouldBeNil)
+		So(results[0][0].Prompt.LLMConfig, ShouldNotBeNil)
+		So(results[1][0].Prompt.Tools, ShouldNotBeNil)
+	})
+}
+
+func TestSingleflightMPullPromptCancellation(t *testing.T) {
+	var requests int32
+	var release chan struct{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// the body is read first, so that the cancellation of the request is noticed by the server
+		var req MPullPromptRequest
+		_ = json.NewDecoder(r.Body).Decode(&req)
+		// the first request blocks until released or canceled
+		if atomic.AddInt32(&requests, 1) == 1 {
+			select {
+			case <-release:
+			case <-r.Context().Done():
+				return
+			}
+		}
+		items := make([]*PromptResult, 0, len(req.Queries))
+		for _, query := range req.Queries {
+			items = append(items, &PromptResult{Query: query, Prompt: &Prompt{PromptKey: query.PromptKey}})
+		}
+		_ = json.NewEncoder(w).Encode(map[string]any{"code": 0, "data": PromptResultData{Items: items}})
+	}))
+	defer server.Close()
+	newClient := func(options *httpclient.ClientOptions) *OpenAPIClient {
+		atomic.StoreInt32(&requests, 0)
+		release = make(chan struct{})
+		return &OpenAPIClient{httpClient: httpclient.NewClient(server.URL, http.DefaultClient, httpclient.NewTokenAuth("token"), options)}
+	}
+	req := MPullPromptRequest{WorkSpaceID: "workspace1", Queries: []PromptQuery{{PromptKey: "key1"}}}
+	waitRequests := func(n int32) {
+		for atomic.LoadInt32(&requests) < n {
+			time.Sleep(time.Millisecond)
+		}
+	}
+	type callResult struct {
+		results []*PromptResult
+		err     error
+	}
+
+	Convey("Test cancellation of some callers does not fail the callers sharing the request", t, func() {
+		client := newClient(nil)
+		var cancels []context.CancelFunc
+		calls := make([]chan callResult, 6)
+		for i := range calls {
+			ctx, cancel := context.WithCancel(context.Background())
+			if i%2 == 0 {
+				cancels = append(cancels, cancel)
+			} else {
+				defer cancel()
+			}
+			calls[i] = make(chan callResult, 1)
+			go func(call chan callResult) {
+				results, err := client.singleflightMPullPrompt(ctx, req)
+				call <- callResult{results: results, err: err}
+			}(calls[i])
+			waitRequests(1)
+		}
+		time.Sleep(50 * time.Millisecond) // all callers join the shared request
+
+		// the first caller who starts the shared request is canceled too
+		for _, cancel := range cancels {
+			cancel()
+		}
+		for i := 0; i < len(calls); i += 2 {
+			So(errors.Is((<-calls[i]).err, context.Canceled), ShouldBeTrue)
+		}
+		close(release)
+		for i := 1; i < len(calls); i += 2 {
+			call := <-calls[i]
+			So(call.err, ShouldBeNil)
+			So(len(call.results), ShouldEqual, 1)
+			So(call.results[0].Prompt.PromptKey, ShouldEqual, "key1")
+		}
+		So(atomic.LoadInt32(&requests), ShouldEqual, 1)
+	})
+
+	Convey("Test deadline of the first caller does not time out the shared request", t, func() {
+		client := newClient(nil)
+		ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+		defer cancel()
+		first := make(chan error, 1)
+		go func() {
+			_, err := client.singleflightMPullPrompt(ctx, req)
+			first <- err
+		}()
+		waitRequests(1)
+		second := make(chan callResult, 1)
+		go func() {
+			results, err := client.singleflightMPullPrompt(context.Background(), req)
+			second <- callResult{results: results, err: err}
+		}()
+
+		So(errors.Is(<-first, context.DeadlineExceeded), ShouldBeTrue)
+		close(release)
+		call := <-second
+		So(call.err, ShouldBeNil)
+		So(len(call.results), ShouldEqual, 1)
+		So(atomic.LoadInt32(&requests), ShouldEqual, 1)
+	})
+
+	Convey("Test shared request is timed out by the timeout of client", t, func() {
+		client := newClient(&httpclient.ClientOptions{Timeout: 100 * time.Millisecond})
+		defer close(release)
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		_, err := client.singleflightMPullPrompt(ctx, req)
+		So(err, ShouldNotBeNil)
+		So(ctx.Err(), ShouldBeNil)
+	})
+}
@@ -0,0 +1,73 @@
+// Copyright (c) 2025 Bytedance Ltd. and/or its affiliates
+// SPDX-License-Identifier: MIT
+
+package prompt
+
+import (
+	"context"
+	"sort"
+
+	"github.com/coze-dev/cozeloop-go/entity"
+)
+
+// partialFormat records the unresolved variables and placeholders of PromptFormatPartial.
+type partialFormat struct {
+	variables    map[string]bool
+	placeholders []string
+}
+
+func (f *partialFormat) addVariable(key string) {
+	if f.variables == nil {
+		f.variables = make(map[string]bool)
+	}
+	f.variables[key] = true
+}
+
+func (f *partialFormat) addPlaceholder(key string) {
+	for _, placeholder := range f.placeholders {
+		if placeholder == key {
+			return
+		}
+	}
+	f.placeholders = append(f.placeholders, key)
+}
+
+func (f *partialFormat) unresolvedVariables() []string {
+	if len(f.variables) == 0 {
+		return nil
+	}
+	keys := make([]string, 0, len(f.variables))
+	for key := range f.variables {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// PromptFormatPartial formats the prompt with the variables which are ready, and keeps the unresolved ones as it is
+// instead of rendering them as empty, so that the messages can be formatted again later.
+func (p *Provider) PromptFormatPartial(ctx context.Context, prompt *entity.Prompt, variables map[string]any, options PromptFormatOptions) (*entity.PartialFormatResult, error) {
+	partial := &partialFormat{}
+	options.partial = partial
+	messages, err := p.PromptFormat(ctx, prompt, variables, options)
+	if err != nil {
+		return nil, err
+	}
+	return &entity.PartialFormatResult{
+		Messages:               messages,
+		UnresolvedVariables:    partial.unresolvedVariables(),
+		UnresolvedPlaceholders: partial.placeholders,
+	}, nil
+}
+
+// collectJinja2Unresolved records the defined variables without value, as jinja2 templates are not parsed.
+func collectJinja2Unresolved(template *entity.PromptTemplate, variables map[string]any, partial *partialFormat) {
+	for _, variableDef := range template.VariableDefs {
+		if variableDef == nil || variableDef.Type == entity.VariableTypePlaceholder || variableDef.Type == entity.VariableTypeMultiPart {
+			continue
+		}
+		if _, ok := variables[variableDef.Key]; !ok {
+			partial.addVariable(variableDef.Key)
+		}
+	}
+}
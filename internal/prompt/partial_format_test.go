@@ -0,0 +1,100 @@
+// Copyright (c) 2025 Bytedance Ltd. and/or its affiliates
+// SPDX-License-Identifier: MIT
+
+package prompt
+
+import (
+	"context"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+
+	"github.com/coze-dev/cozeloop-go/entity"
+	"github.com/coze-dev/cozeloop-go/internal/httpclient"
+	"github.com/coze-dev/cozeloop-go/internal/util"
+)
+
+func TestPromptFormatPartial(t *testing.T) {
+	ctx := context.Background()
+	provider := NewPromptProvider(&httpclient.Client{}, nil, Options{
+		WorkspaceID:   "workspace1",
+		TemplateFuncs: map[string]any{"upper": func(s string) string { return s }},
+		FormatCache:   &FormatCacheConf{},
+	})
+	newPrompt := func(templateType entity.TemplateType) *entity.Prompt {
+		return &entity.Prompt{
+			PromptKey: "key1",
+			Version:   "0.0.1",
+			PromptTemplate: &entity.PromptTemplate{
+				TemplateType: templateType,
+				Messages: []*entity.Message{
+					{Role: entity.RoleSystem, Content: util.Ptr("You are {{role}}, answer in {{language|upper}}.")},
+					{Role: entity.RolePlaceholder, Content: util.Ptr("history")},
+					{Role: entity.RoleUser, Parts: []*entity.ContentPart{
+						{Type: entity.ContentTypeText, Text: util.Ptr("{{question}}")},
+						{Type: entity.ContentTypeMultiPartVariable, Text: util.Ptr("images")},
+					}},
+				},
+				VariableDefs: []*entity.VariableDef{
+					{Key: "role", Type: entity.VariableTypeString},
+					{Key: "language", Type: entity.VariableTypeString},
+					{Key: "question", Type: entity.VariableTypeString},
+					{Key: "history", Type: entity.VariableTypePlaceholder},
+					{Key: "images", Type: entity.VariableTypeMultiPart},
+				},
+			},
+		}
+	}
+
+	Convey("Test unresolved variables and placeholders are kept", t, func() {
+		prompt := newPrompt(entity.TemplateTypeNormal)
+		result, err := provider.PromptFormatPartial(ctx, prompt, map[string]any{"role": "a translator"}, PromptFormatOptions{})
+		So(err, ShouldBeNil)
+		So(result.UnresolvedVariables, ShouldResemble, []string{"images", "language", "question"})
+		So(result.UnresolvedPlaceholders, ShouldResemble, []string{"history"})
+		So(len(result.Messages), ShouldEqual, 3)
+		So(*result.Messages[0].Content, ShouldEqual, "You are a translator, answer in {{language|upper}}.")
+		So(result.Messages[1].Role, ShouldEqual, entity.RolePlaceholder)
+		So(*result.Messages[2].Parts[0].Text, ShouldEqual, "{{question}}")
+		So(result.Messages[2].Parts[1].Type, ShouldEqual, entity.ContentTypeMultiPartVariable)
+		// the prompt passed in is not modified
+		So(*prompt.PromptTemplate.Messages[0].Content, ShouldEqual, "You are {{role}}, answer in {{language|upper}}.")
+
+		// the messages can be formatted again with the rest of variables
+		next := newPrompt(entity.TemplateTypeNormal)
+		next.Version = ""
+		next.PromptTemplate.Messages = result.Messages
+		messages, err := provider.PromptFormat(ctx, next, map[string]any{
+			"language": "French",
+			"question": "hello",
+			"history":  []*entity.Message{{Role: entity.RoleUser, Content: util.Ptr("hi")}},
+		}, PromptFormatOptions{})
+		So(err, ShouldBeNil)
+		So(len(messages), ShouldEqual, 3)
+		So(*messages[0].Content, ShouldEqual, "You are a translator, answer in French.")
+		So(*messages[1].Content, ShouldEqual, "hi")
+		So(*messages[2].Parts[0].Text, ShouldEqual, "hello")
+		So(len(messages[2].Parts), ShouldEqual, 1)
+	})
+
+	Convey("Test partial result is not served from format cache", t, func() {
+		variables := map[string]any{"role": "a translator"}
+		messages, err := provider.PromptFormat(ctx, newPrompt(entity.TemplateTypeNormal), variables, PromptFormatOptions{})
+		So(err, ShouldBeNil)
+		So(*messages[0].Content, ShouldEqual, "You are a translator, answer in .")
+
+		result, err := provider.PromptFormatPartial(ctx, newPrompt(entity.TemplateTypeNormal), variables, PromptFormatOptions{})
+		So(err, ShouldBeNil)
+		So(*result.Messages[0].Content, ShouldEqual, "You are a translator, answer in {{language|upper}}.")
+	})
+
+	Convey("Test defined variables without value are reported for jinja2 template", t, func() {
+		prompt := newPrompt(entity.TemplateTypeJinja2)
+		prompt.PromptTemplate.Messages[0].Content = util.Ptr("You are {{ role }}.")
+		result, err := provider.PromptFormatPartial(ctx, prompt, map[string]any{"role": "a translator", "question": "hello"}, PromptFormatOptions{})
+		So(err, ShouldBeNil)
+		So(result.UnresolvedVariables, ShouldResemble, []string{"images", "language"})
+		So(result.UnresolvedPlaceholders, ShouldResemble, []string{"history"})
+		So(*result.Messages[0].Content, ShouldEqual, "You are a translator.")
+	})
+}
@@ -0,0 +1,159 @@
+// Copyright (c) 2025 Bytedance Ltd. and/or its affiliates
+// SPDX-License-Identifier: MIT
+
+package prompt
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+
+	"github.com/coze-dev/cozeloop-go/entity"
+	"github.com/coze-dev/cozeloop-go/internal/consts"
+	"github.com/coze-dev/cozeloop-go/internal/logger"
+	"github.com/coze-dev/cozeloop-go/internal/util"
+)
+
+// PlaceholderPolicy guards the messages of placeholder variables, which are usually the conversation history
+// supplied by users, against prompt injection, such as the system messages spliced into the history.
+type PlaceholderPolicy struct {
+	// AllowedRoles the roles allowed in placeholder messages, the format fails if any message is of other roles.
+	// All roles are allowed if it is empty.
+	AllowedRoles []entity.Role
+	// SuspiciousPatterns the content matching any of them is suspicious, DefaultSuspiciousPatterns is used if it is
+	// nil.
+	SuspiciousPatterns []*regexp.Regexp
+	// Sanitize removes the suspicious content from the text of messages. The messages are copied, so the variables
+	// passed in are not modified.
+	Sanitize bool
+	// OnSuspicious is called for every suspicious content found. Default logs a warning.
+	OnSuspicious func(ctx context.Context, content *SuspiciousContent)
+}
+
+// SuspiciousContent the suspicious content found in placeholder messages.
+type SuspiciousContent struct {
+	Placeholder string
+	// Index of the message in the messages of placeholder
+	Index int
+	Role  entity.Role
+	// Match the text matching the pattern
+	Match   string
+	Pattern string
+}
+
+// DefaultSuspiciousPatterns the patterns of common injections, which are the special tokens of chat templates, role
+// headers imitating a new message, and instructions overriding the prompt.
+var DefaultSuspiciousPatterns = []*regexp.Regexp{
+	regexp.MustCompile(`<\|(im_start|im_end|system|user|assistant|endoftext|eot_id|start_header_id|end_header_id)\|>`),
+	regexp.MustCompile(`\[/?INST\]|<</?SYS>>`),
+	regexp.MustCompile(`(?im)^[ \t]*#{0,3}[ \t]*(system|assistant)[ \t]*:`),
+	regexp.MustCompile(`(?i)\b(ignore|disregard|forget)\s+(all\s+)?(the\s+)?(previous|prior|above|earlier)\s+` +
+		`(instructions|prompts?|messages)`),
+}
+
+// guard validates and sanitizes the messages of placeholder by the policy, it returns messages as it is if the
+// policy is nil.
+func (p *PlaceholderPolicy) guard(ctx context.Context, placeholder string, messages []*entity.Message) (
+	[]*entity.Message, error,
+) {
+	if p == nil {
+		return messages, nil
+	}
+	if len(p.AllowedRoles) > 0 {
+		for i, message := range messages {
+			if message != nil && !p.allowRole(message.Role) {
+				return nil, consts.ErrInvalidParam.Wrap(fmt.Errorf(
+					"role %s is not allowed in message %d of placeholder [%s]", message.Role, i, placeholder))
+			}
+		}
+	}
+	patterns := p.SuspiciousPatterns
+	if patterns == nil {
+		patterns = DefaultSuspiciousPatterns
+	}
+	if len(patterns) == 0 {
+		return messages, nil
+	}
+	results := messages
+	if p.Sanitize {
+		results = make([]*entity.Message, 0, len(messages))
+	}
+	for i, message := range messages {
+		if message == nil {
+			if p.Sanitize {
+				results = append(results, nil)
+			}
+			continue
+		}
+		if p.Sanitize {
+			message = copyMessageText(message)
+			results = append(results, message)
+		}
+		check := func(text *string) {
+			if text == nil {
+				return
+			}
+			for _, pattern := range patterns {
+				for _, match := range pattern.FindAllString(*text, -1) {
+					p.report(ctx, &SuspiciousContent{
+						Placeholder: placeholder,
+						Index:       i,
+						Role:        message.Role,
+						Match:       match,
+						Pattern:     pattern.String(),
+					})
+				}
+				if p.Sanitize {
+					*text = pattern.ReplaceAllString(*text, "")
+				}
+			}
+		}
+		check(message.Content)
+		for _, part := range message.Parts {
+			if part != nil {
+				check(part.Text)
+			}
+		}
+	}
+	return results, nil
+}
+
+func (p *PlaceholderPolicy) allowRole(role entity.Role) bool {
+	for _, allowed := range p.AllowedRoles {
+		if role == allowed {
+			return true
+		}
+	}
+	return false
+}
+
+func (p *PlaceholderPolicy) report(ctx context.Context, content *SuspiciousContent) {
+	if p.OnSuspicious != nil {
+		p.OnSuspicious(ctx, content)
+		return
+	}
+	logger.CtxWarnf(ctx, "suspicious content in message %d of placeholder [%s]: %s", content.Index,
+		content.Placeholder, util.TruncateStringByChar(content.Match, 100))
+}
+
+// copyMessageText copies the message with the text of content and parts copied, the other fields are shared.
+func copyMessageText(message *entity.Message) *entity.Message {
+	copied := *message
+	if message.Content != nil {
+		copied.Content = util.Ptr(*message.Content)
+	}
+	if message.Parts != nil {
+		copied.Parts = make([]*entity.ContentPart, 0, len(message.Parts))
+		for _, part := range message.Parts {
+			if part != nil {
+				copiedPart := *part
+				if part.Text != nil {
+					copiedPart.Text = util.Ptr(*part.Text)
+				}
+				part = &copiedPart
+			}
+			copied.Parts = append(copied.Parts, part)
+		}
+	}
+	return &copied
+}
@@ -7,8 +7,13 @@ import (
 	"context"
 	"fmt"
 	"io"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
 	"time"
 
+	"github.com/bluele/gcache"
 	"github.com/valyala/fasttemplate"
 
 	"github.com/coze-dev/cozeloop-go/entity"
@@ -25,13 +30,60 @@ type Provider struct {
 	traceProvider *trace.Provider
 	cache         *PromptCache
 	config        Options
+	formatCache   *formatCache
+	executeCache  *executeCache
+	templateEnv   *templateEnv
+	templateCache *templateCache // compiled templates of prompt versions
+	refreshing    sync.Map       // cache keys of prompts which are being refreshed in background
+	// extraCaches caches of other workspaces or field masks set by GetPromptOptions, which are created on first use.
+	// At most maxExtraCaches of them are kept, the least recently used one is stopped when evicted.
+	extraCaches gcache.Cache
+	// extraCachesLock makes the creation of extra caches atomic
+	extraCachesLock sync.Mutex
+	// subscriptions the subscriptions of prompt changes by workspace, which are shared by the caches of workspace
+	subscriptions     map[string]*promptSubscription
+	subscriptionsLock sync.Mutex
+	stats             *promptStats
+	// refreshInterval nanoseconds of PromptCacheRefreshInterval, which can be updated by SetCacheRefreshInterval
+	refreshInterval int64
 }
 
+// maxExtraCaches the max count of the caches of other workspaces or field masks, each of which has its own async
+// update task.
+const maxExtraCaches = 32
+
 type Options struct {
 	WorkspaceID                string
 	PromptCacheMaxCount        int
 	PromptCacheRefreshInterval time.Duration
 	PromptTrace                bool
+	// PromptStaleWhileRevalidate return stale cached prompt immediately and refresh it in background.
+	PromptStaleWhileRevalidate bool
+	// PromptShared return the shared read only prompt of cache by GetPrompt without copy, otherwise a deep copy of
+	// it is returned.
+	PromptShared bool
+	// PromptNotFoundCacheTTL how long a missing prompt is cached as not found, disabled if it is not positive.
+	PromptNotFoundCacheTTL time.Duration
+	// PromptNotFoundError return consts.ErrPromptNotFound instead of nil prompt when the prompt does not exist.
+	PromptNotFoundError bool
+	// TemplateFuncs custom funcs which can be used in prompt templates
+	TemplateFuncs map[string]any
+	// Jinja2 conf of jinja2 templates, the defaults are used if it is nil
+	Jinja2 *Jinja2Conf
+	// FormatCache cache the results of PromptFormat if it is not nil
+	FormatCache *FormatCacheConf
+	// ExecuteCache cache the results of Execute if it is not nil
+	ExecuteCache *ExecuteCacheConf
+	// TraceInput limits of the variables recorded in prompt template span, the defaults are used if it is nil
+	TraceInput *TraceInputConf
+	// LocalPromptDir GetPrompt loads prompts from the files in this dir before fetching them from server,
+	// see LoadLocalPrompt.
+	LocalPromptDir string
+	// LocalPromptOnly never fetch prompts from server, the prompt not in LocalPromptDir is not found.
+	LocalPromptOnly bool
+	// PromptSubscription subscribe the prompt changes from server, so that the cached prompts are updated in near
+	// real time instead of at the next refresh. Refresh keeps working as fallback.
+	PromptSubscription bool
 }
 
 type GetPromptParam struct {
@@ -40,21 +92,168 @@ type GetPromptParam struct {
 	Label     string
 }
 
-type GetPromptOptions struct{}
+type GetPromptOptions struct {
+	// WorkspaceID get prompt from this workspace instead of the workspace of client
+	WorkspaceID string
+	// FieldMask only pull the selected fields of prompt
+	FieldMask *FieldMask
+	// SpanTags extra tags of prompt hub span
+	SpanTags map[string]any
+	// Timeout of the call, which overrides the timeout of client if it is positive
+	Timeout time.Duration
+}
 
-type PromptFormatOptions struct{}
+type PromptFormatOptions struct {
+	// StrictVariables fail the format when any defined variable is missing, or any variable
+	// referenced by template or passed in is not defined.
+	StrictVariables bool
+	// VariableStruct struct whose fields are bound to variables by entity.BindVariables, merged with the variables
+	// passed in. Every field should be a defined variable of the prompt.
+	VariableStruct any
+	// TraceExcludedVariables variables not recorded in the input of prompt template span, such as sensitive ones
+	TraceExcludedVariables []string
+	// EscapeVariables escape the template control sequences in variable values, such as "{{" and "{%", so that
+	// the values supplied by users are never parsed as tags, e.g. when the result of PromptFormatPartial is
+	// formatted again
+	EscapeVariables bool
+	// RawVariables the variables inserted as they are even if EscapeVariables is set
+	RawVariables []string
+	// PlaceholderPolicy validates and sanitizes the messages of placeholder variables, they are not checked if it is
+	// nil
+	PlaceholderPolicy *PlaceholderPolicy
+
+	// partial records the unresolved variables and placeholders, which is set by PromptFormatPartial
+	partial *partialFormat
+}
 
 func NewPromptProvider(httpClient *httpclient.Client, traceProvider *trace.Provider, options Options) *Provider {
 	openAPI := &OpenAPIClient{httpClient: httpClient}
-	cache := newPromptCache(options.WorkspaceID, openAPI,
-		withAsyncUpdate(true),
-		withUpdateInterval(options.PromptCacheRefreshInterval),
-		withMaxCacheSize(options.PromptCacheMaxCount))
-	return &Provider{
+	templateCache := newTemplateCache(options.PromptCacheMaxCount)
+	stats := &promptStats{}
+	p := &Provider{
 		openAPIClient: openAPI,
 		traceProvider: traceProvider,
-		cache:         cache,
+		cache:         newProviderCache(options.WorkspaceID, openAPI, options, templateCache.invalidate, stats),
+		subscriptions: make(map[string]*promptSubscription),
 		config:        options,
+		formatCache:   newFormatCache(options.FormatCache),
+		executeCache:  newExecuteCache(options.ExecuteCache),
+		templateCache: templateCache,
+		templateEnv:   newTemplateEnv(options.TemplateFuncs, options.Jinja2),
+		stats:         stats,
+		// refreshInterval is read and written atomically
+		refreshInterval: int64(options.PromptCacheRefreshInterval),
+	}
+	p.extraCaches = gcache.New(maxExtraCaches).LRU().EvictedFunc(func(_, cache interface{}) {
+		p.stopCache(cache.(*PromptCache))
+	}).Build()
+	p.subscribe(p.cache)
+	return p
+}
+
+// NewFormatProvider creates a provider which only formats prompts, without the api client and prompt cache.
+// It is used by the disabled client, GetPrompt and Execute must not be called on it.
+func NewFormatProvider(options Options) *Provider {
+	templateCache := newTemplateCache(options.PromptCacheMaxCount)
+	return &Provider{
+		config:        options,
+		formatCache:   newFormatCache(options.FormatCache),
+		templateCache: templateCache,
+		templateEnv:   newTemplateEnv(options.TemplateFuncs, options.Jinja2),
+	}
+}
+
+func newProviderCache(workspaceID string, openAPI *OpenAPIClient, options Options, onUpdate func(*entity.Prompt),
+	stats *promptStats,
+) *PromptCache {
+	return newPromptCache(workspaceID, openAPI,
+		withAsyncUpdate(true),
+		withOnUpdate(onUpdate),
+		withStats(stats),
+		withUpdateInterval(options.PromptCacheRefreshInterval),
+		withMaxCacheSize(options.PromptCacheMaxCount))
+}
+
+// subscribe adds the cache to the subscription of its workspace if PromptSubscription is set, the subscription is
+// started for the first cache of workspace.
+func (p *Provider) subscribe(cache *PromptCache) {
+	if !p.config.PromptSubscription {
+		return
+	}
+	p.subscriptionsLock.Lock()
+	defer p.subscriptionsLock.Unlock()
+	subscription, ok := p.subscriptions[cache.workspaceID]
+	if !ok {
+		subscription = newPromptSubscription(cache.workspaceID, p.openAPIClient)
+		p.subscriptions[cache.workspaceID] = subscription
+		subscription.start()
+	}
+	subscription.add(cache)
+}
+
+// stopCache stops the cache, and the subscription of its workspace if it is the last cache of workspace.
+func (p *Provider) stopCache(cache *PromptCache) {
+	cache.Stop()
+	p.subscriptionsLock.Lock()
+	defer p.subscriptionsLock.Unlock()
+	if subscription, ok := p.subscriptions[cache.workspaceID]; ok && subscription.remove(cache) {
+		subscription.stop()
+		delete(p.subscriptions, cache.workspaceID)
+	}
+}
+
+// getCache returns the prompt cache of the workspace and field mask. The workspace of client is used
+// if workspaceID is empty, and caches of other workspaces or field masks are created on first use.
+func (p *Provider) getCache(workspaceID string, mask *FieldMask) *PromptCache {
+	if workspaceID == "" {
+		workspaceID = p.config.WorkspaceID
+	}
+	if workspaceID == p.config.WorkspaceID && mask.isEmpty() {
+		return p.cache
+	}
+	name := workspaceID + ":" + mask.key()
+	if cache, err := p.extraCaches.Get(name); err == nil {
+		return cache.(*PromptCache)
+	}
+	p.extraCachesLock.Lock()
+	defer p.extraCachesLock.Unlock()
+	if cache, err := p.extraCaches.Get(name); err == nil {
+		return cache.(*PromptCache)
+	}
+	cache := newPromptCache(workspaceID, p.openAPIClient,
+		withUpdateInterval(time.Duration(atomic.LoadInt64(&p.refreshInterval))),
+		withMaxCacheSize(p.config.PromptCacheMaxCount),
+		withFieldMask(mask),
+		withOnUpdate(p.templateCache.invalidate),
+		withStats(p.stats))
+	_ = p.extraCaches.Set(name, cache)
+	cache.Start()
+	p.subscribe(cache)
+	return cache
+}
+
+// Close stops the async updates and subscriptions of all prompt caches. The prompts cached can still be got, but
+// they are not updated any more.
+func (p *Provider) Close() {
+	if p.cache == nil {
+		return
+	}
+	p.stopCache(p.cache)
+	for _, cache := range p.extraCaches.GetALL(false) {
+		p.stopCache(cache.(*PromptCache))
+	}
+}
+
+// SetCacheRefreshInterval updates the refresh interval of all prompt caches, it is ignored if interval is not
+// positive.
+func (p *Provider) SetCacheRefreshInterval(interval time.Duration) {
+	if interval <= 0 || p.cache == nil {
+		return
+	}
+	atomic.StoreInt64(&p.refreshInterval, int64(interval))
+	p.cache.SetUpdateInterval(interval)
+	for _, cache := range p.extraCaches.GetALL(false) {
+		cache.(*PromptCache).SetUpdateInterval(interval)
 	}
 }
 
@@ -77,6 +276,9 @@ func (p *Provider) GetPrompt(ctx context.Context, param GetPromptParam, options
 						tracespec.PromptLabel:   param.Label,
 					}),
 				})
+				if len(options.SpanTags) > 0 {
+					promptHubSpan.SetTags(ctx, options.SpanTags)
+				}
 				if prompt != nil {
 					promptHubSpan.SetTags(ctx, map[string]any{
 						tracespec.PromptVersion: prompt.Version, // actual version
@@ -96,17 +298,22 @@ func (p *Provider) GetPrompt(ctx context.Context, param GetPromptParam, options
 
 func (p *Provider) doGetPrompt(ctx context.Context, param GetPromptParam, options GetPromptOptions) (prompt *entity.Prompt, err error) {
 	defer func() {
-		// object cache item should be read only
-		prompt = prompt.DeepCopy()
+		// object cache item should be read only, it is returned without copy only if sharing is allowed
+		if !p.config.PromptShared {
+			prompt = prompt.DeepCopy()
+		}
 	}()
-	// Get from cache
-	if cached, ok := p.cache.Get(param.PromptKey, param.Version, param.Label); ok {
-		return cached, nil
+	cache := p.getCache(options.WorkspaceID, options.FieldMask)
+	if prompt, done, err := p.getLocalOrCached(cache, param, options); done {
+		return prompt, err
 	}
+	ctx, cancel := withCallTimeout(ctx, options.Timeout)
+	defer cancel()
 
 	// Cache miss, fetch from server
 	promptResults, err := p.openAPIClient.MPullPrompt(ctx, MPullPromptRequest{
-		WorkSpaceID: p.config.WorkspaceID,
+		WorkSpaceID: cache.workspaceID,
+		FieldMask:   cache.option.FieldMask,
 		Queries: []PromptQuery{
 			{
 				PromptKey: param.PromptKey,
@@ -120,20 +327,118 @@ func (p *Provider) doGetPrompt(ctx context.Context, param GetPromptParam, option
 	}
 
 	if len(promptResults) == 0 || promptResults[0].Prompt == nil {
-		return nil, nil
+		if p.config.PromptNotFoundCacheTTL > 0 {
+			cache.SetNotFound(param.PromptKey, param.Version, param.Label, p.config.PromptNotFoundCacheTTL)
+		}
+		return nil, p.notFoundError(param)
 	}
 
 	// Cache the result
 	result := toModelPrompt(promptResults[0].Prompt)
-	p.cache.Set(promptResults[0].Query.PromptKey, promptResults[0].Query.Version, promptResults[0].Query.Label, result)
+	cache.Set(promptResults[0].Query.PromptKey, promptResults[0].Query.Version, promptResults[0].Query.Label, result)
 
 	return result, nil
 }
 
+// withCallTimeout returns ctx with the timeout of call, ctx is returned as it is if timeout is not positive.
+func withCallTimeout(ctx context.Context, timeout time.Duration) (context.Context, context.CancelFunc) {
+	if timeout <= 0 {
+		return ctx, func() {}
+	}
+	return context.WithTimeout(ctx, timeout)
+}
+
+// getLocalOrCached gets the prompt from local prompt dir or cache, done is false if it should be pulled from server.
+func (p *Provider) getLocalOrCached(cache *PromptCache, param GetPromptParam, options GetPromptOptions) (
+	prompt *entity.Prompt, done bool, err error,
+) {
+	if p.config.LocalPromptDir != "" {
+		local, err := LoadLocalPrompt(p.config.LocalPromptDir, param)
+		if err != nil || local != nil {
+			if local != nil && local.WorkspaceID == "" {
+				local.WorkspaceID = options.WorkspaceID
+				if local.WorkspaceID == "" {
+					local.WorkspaceID = p.config.WorkspaceID
+				}
+			}
+			return local, true, err
+		}
+		if p.config.LocalPromptOnly {
+			return nil, true, p.notFoundError(param)
+		}
+	}
+	// Get from cache
+	if cached, ok := cache.Get(param.PromptKey, param.Version, param.Label); ok {
+		p.stats.add(&p.stats.cacheHits)
+		if p.config.PromptStaleWhileRevalidate && cache.IsStale(param.PromptKey, param.Version, param.Label) {
+			p.revalidate(cache, param)
+		}
+		return cached, true, nil
+	}
+	if cache.IsNotFound(param.PromptKey, param.Version, param.Label) {
+		p.stats.add(&p.stats.cacheHits)
+		return nil, true, p.notFoundError(param)
+	}
+	p.stats.add(&p.stats.cacheMisses)
+	return nil, false, nil
+}
+
+// notFoundError returns the error of missing prompt, which is nil unless PromptNotFoundError is set.
+func (p *Provider) notFoundError(param GetPromptParam) error {
+	if !p.config.PromptNotFoundError {
+		return nil
+	}
+	return consts.ErrPromptNotFound.Wrap(fmt.Errorf("prompt_key: %s, version: %s, label: %s", param.PromptKey, param.Version, param.Label))
+}
+
+// revalidate refreshes the cached prompt in background, at most one refresh for the same prompt at the same time.
+func (p *Provider) revalidate(cache *PromptCache, param GetPromptParam) {
+	key := cache.workspaceID + ":" + cache.option.FieldMask.key() + ":" + cache.getCacheKey(param.PromptKey, param.Version, param.Label)
+	if _, loaded := p.refreshing.LoadOrStore(key, struct{}{}); loaded {
+		return
+	}
+	ctx := context.Background()
+	util.GoSafe(ctx, func() {
+		defer p.refreshing.Delete(key)
+		promptResults, err := p.openAPIClient.MPullPrompt(ctx, MPullPromptRequest{
+			WorkSpaceID: cache.workspaceID,
+			FieldMask:   cache.option.FieldMask,
+			Queries: []PromptQuery{
+				{
+					PromptKey: param.PromptKey,
+					Version:   param.Version,
+					Label:     param.Label,
+				},
+			},
+		})
+		p.stats.refreshed(err)
+		if err != nil {
+			logger.CtxWarnf(ctx, "revalidate prompt [%s] failed, keep the stale one: %v", param.PromptKey, err)
+			return
+		}
+		if len(promptResults) == 0 || promptResults[0].Prompt == nil {
+			return
+		}
+		query := promptResults[0].Query
+		cache.Set(query.PromptKey, query.Version, query.Label, toModelPrompt(promptResults[0].Prompt))
+	})
+}
+
+// Stats returns the statistics of getting prompts.
+func (p *Provider) Stats() Stats {
+	return p.stats.snapshot()
+}
+
 func (p *Provider) PromptFormat(ctx context.Context, prompt *entity.Prompt, variables map[string]any, options PromptFormatOptions) (messages []*entity.Message, err error) {
 	if prompt == nil || prompt.PromptTemplate == nil {
 		return nil, nil
 	}
+	if options.VariableStruct != nil {
+		variables, err = mergeVariableStruct(prompt.PromptTemplate.VariableDefs, variables, options.VariableStruct)
+		if err != nil {
+			return nil, err
+		}
+	}
 	if p.config.PromptTrace && p.traceProvider != nil {
 		var promptTemplateSpan *trace.Span
 		var spanErr error
@@ -144,10 +449,12 @@ func (p *Provider) PromptFormat(ctx context.Context, prompt *entity.Prompt, vari
 		}
 		defer func() {
 			if promptTemplateSpan != nil {
+				input := toTracedPromptInput(prompt.PromptTemplate.Messages, variables,
+					p.config.TraceInput.withDefaults(), options.TraceExcludedVariables)
 				promptTemplateSpan.SetTags(ctx, map[string]any{
 					tracespec.PromptKey:     prompt.PromptKey,
 					tracespec.PromptVersion: prompt.Version,
-					tracespec.Input:         util.ToJSON(toSpanPromptInput(prompt.PromptTemplate.Messages, variables)),
+					tracespec.Input:         util.ToJSON(input),
 					tracespec.Output:        util.ToJSON(toSpanMessages(messages)),
 				})
 				if err != nil {
@@ -158,10 +465,34 @@ func (p *Provider) PromptFormat(ctx context.Context, prompt *entity.Prompt, vari
 			}
 		}()
 	}
-	return p.doPromptFormat(ctx, prompt.DeepCopy(), variables)
+	return p.cachedPromptFormat(ctx, prompt, variables, options)
 }
 
-func (p *Provider) doPromptFormat(ctx context.Context, prompt *entity.Prompt, variables map[string]any) (results []*entity.Message, err error) {
+// cachedPromptFormat returns the cached result if the same prompt version is formatted with the same variables,
+// otherwise formats a copy of prompt and caches the result.
+func (p *Provider) cachedPromptFormat(ctx context.Context, prompt *entity.Prompt, variables map[string]any, options PromptFormatOptions) ([]*entity.Message, error) {
+	key, cacheable := p.formatCache.key(prompt, variables, options)
+	if cacheable {
+		if messages, ok := p.formatCache.get(key); ok {
+			return messages, nil
+		}
+	}
+	messages, err := p.doPromptFormat(ctx, copyForFormat(prompt), variables, options)
+	if err == nil && cacheable {
+		p.formatCache.set(key, messages)
+	}
+	return messages, err
+}
+
+// copyForFormat copies the prompt template modified by formatting, other fields such as tools and llm config
+// are shared with prompt, as they are not modified.
+func copyForFormat(prompt *entity.Prompt) *entity.Prompt {
+	copied := *prompt
+	copied.PromptTemplate = prompt.PromptTemplate.DeepCopy()
+	return &copied
+}
+
+func (p *Provider) doPromptFormat(ctx context.Context, prompt *entity.Prompt, variables map[string]any, options PromptFormatOptions) (results []*entity.Message, err error) {
 	if prompt.PromptTemplate == nil || len(prompt.PromptTemplate.Messages) == 0 {
 		return nil, nil
 	}
@@ -170,17 +501,63 @@ func (p *Provider) doPromptFormat(ctx context.Context, prompt *entity.Prompt, va
 	if err != nil {
 		return nil, err
 	}
-	results, err = formatNormalMessages(prompt.PromptTemplate.TemplateType, prompt.PromptTemplate.Messages, prompt.PromptTemplate.VariableDefs, variables)
+	if options.StrictVariables {
+		err = validateStrictVariables(prompt.PromptTemplate, variables, p.config.TemplateFuncs)
+		if err != nil {
+			return nil, err
+		}
+	}
+	if options.EscapeVariables {
+		variables = escapeVariables(variables, options.RawVariables)
+	}
+	env := p.templateEnv
+	if options.partial != nil {
+		env = env.withPartial(options.partial)
+		if prompt.PromptTemplate.TemplateType == entity.TemplateTypeJinja2 {
+			collectJinja2Unresolved(prompt.PromptTemplate, variables, options.partial)
+		}
+	}
+	results, err = formatNormalMessages(prompt.PromptTemplate.TemplateType, prompt.PromptTemplate.Messages, prompt.PromptTemplate.VariableDefs, variables,
+		env, p.templateCache.scope(prompt))
 	if err != nil {
 		return nil, err
 	}
-	results, err = formatPlaceholderMessages(results, variables)
+	results, err = formatPlaceholderMessages(ctx, results, variables, options.partial, options.PlaceholderPolicy)
 	if err != nil {
 		return nil, err
 	}
 	return results, nil
 }
 
+// mergeVariableStruct binds the variables of struct, and merges them with variables. The fields not defined
+// by variableDefs, which are usually typos, are rejected.
+func mergeVariableStruct(variableDefs []*entity.VariableDef, variables map[string]any, variableStruct any) (map[string]any, error) {
+	bound, err := entity.BindVariables(variableStruct)
+	if err != nil {
+		return nil, consts.ErrInvalidParam.Wrap(err)
+	}
+	defined := make(map[string]bool, len(variableDefs))
+	for _, variableDef := range variableDefs {
+		if variableDef != nil {
+			defined[variableDef.Key] = true
+		}
+	}
+	merged := make(map[string]any, len(variables)+len(bound))
+	for key, value := range variables {
+		merged[key] = value
+	}
+	for key, value := range bound {
+		if !defined[key] {
+			return nil, consts.ErrInvalidParam.Wrap(fmt.Errorf("variable '%s' bound by struct is not defined in prompt", key))
+		}
+		if _, ok := merged[key]; ok {
+			return nil, consts.ErrInvalidParam.Wrap(fmt.Errorf("variable '%s' is set by both struct and map", key))
+		}
+		merged[key] = value
+	}
+	return merged, nil
+}
+
 func validateVariableValuesType(variableDefs []*entity.VariableDef, variables map[string]any) error {
 	for _, variableDef := range variableDefs {
 		if variableDef == nil {
@@ -261,10 +638,76 @@ func validateVariableValuesType(variableDefs []*entity.VariableDef, variables ma
 	return nil
 }
 
+// validateStrictVariables checks that every defined variable has a value, and no variable is referenced
+// by normal template or passed in without definition.
+func validateStrictVariables(template *entity.PromptTemplate, variables map[string]any, funcs map[string]any) error {
+	defined := make(map[string]bool)
+	var missing []string
+	for _, variableDef := range template.VariableDefs {
+		if variableDef == nil {
+			continue
+		}
+		defined[variableDef.Key] = true
+		if variables[variableDef.Key] == nil {
+			missing = append(missing, variableDef.Key)
+		}
+	}
+	extraSet := make(map[string]bool)
+	for key := range variables {
+		if !defined[key] {
+			extraSet[key] = true
+		}
+	}
+	// only normal template references can be collected, jinja2 expressions are not parsed
+	if template.TemplateType == entity.TemplateTypeNormal {
+		collect := func(text string) {
+			fasttemplate.ExecuteFuncString(text, consts.PromptNormalTemplateStartTag, consts.PromptNormalTemplateEndTag, func(w io.Writer, tag string) (int, error) {
+				if len(funcs) > 0 {
+					tag, _ = parseNormalTag(tag)
+				}
+				if !defined[tag] {
+					extraSet[tag] = true
+				}
+				return 0, nil
+			})
+		}
+		for _, message := range template.Messages {
+			if message == nil || message.Role == entity.RolePlaceholder {
+				continue
+			}
+			collect(util.PtrValue(message.Content))
+			for _, part := range message.Parts {
+				if part != nil && part.Type == entity.ContentTypeText {
+					collect(util.PtrValue(part.Text))
+				}
+			}
+		}
+	}
+	if len(missing) == 0 && len(extraSet) == 0 {
+		return nil
+	}
+	extra := make([]string, 0, len(extraSet))
+	for key := range extraSet {
+		extra = append(extra, key)
+	}
+	sort.Strings(missing)
+	sort.Strings(extra)
+	var details []string
+	if len(missing) > 0 {
+		details = append(details, fmt.Sprintf("missing variables: [%s]", strings.Join(missing, ", ")))
+	}
+	if len(extra) > 0 {
+		details = append(details, fmt.Sprintf("extra variables: [%s]", strings.Join(extra, ", ")))
+	}
+	return consts.ErrInvalidParam.Wrap(fmt.Errorf("strict variables check failed, %s", strings.Join(details, "; ")))
+}
+
 func formatNormalMessages(templateType entity.TemplateType,
 	messages []*entity.Message,
 	variableDefs []*entity.VariableDef,
 	variableVals map[string]any,
+	env *templateEnv,
+	templates *versionTemplates,
 ) (results []*entity.Message, err error) {
 	variableDefMap := make(map[string]*entity.VariableDef)
 	for _, variableDef := range variableDefs {
@@ -272,7 +715,7 @@ func formatNormalMessages(templateType entity.TemplateType,
 			variableDefMap[variableDef.Key] = variableDef
 		}
 	}
-	for _, message := range messages {
+	for i, message := range messages {
 		if message == nil {
 			continue
 		}
@@ -283,14 +726,17 @@ func formatNormalMessages(templateType entity.TemplateType,
 		}
 		// render content
 		if util.PtrValue(message.Content) != "" {
-			renderedContent, err := renderTextContent(templateType, util.PtrValue(message.Content), variableDefMap, variableVals)
+			renderedContent, err := templates.render(templatePosition{message: i, part: -1}, templateType, util.PtrValue(message.Content), variableDefMap, variableVals, env)
 			if err != nil {
 				return nil, err
 			}
 			message.Content = util.Ptr(renderedContent)
 		}
 		// render parts
-		message.Parts = formatMultiPart(templateType, message.Parts, variableDefMap, variableVals)
+		message.Parts, err = formatMultiPart(templateType, message.Parts, variableDefMap, variableVals, env, templates, i)
+		if err != nil {
+			return nil, err
+		}
 		results = append(results, message)
 	}
 	return results, nil
@@ -300,17 +746,20 @@ func formatMultiPart(templateType entity.TemplateType,
 	parts []*entity.ContentPart,
 	defMap map[string]*entity.VariableDef,
 	valMap map[string]any,
-) []*entity.ContentPart {
+	env *templateEnv,
+	templates *versionTemplates,
+	messageIndex int,
+) ([]*entity.ContentPart, error) {
 	var formatedParts []*entity.ContentPart
 	// render text
-	for _, part := range parts {
+	for i, part := range parts {
 		if part == nil {
 			continue
 		}
 		if part.Type == entity.ContentTypeText && util.PtrValue(part.Text) != "" {
-			renderedText, err := renderTextContent(templateType, util.PtrValue(part.Text), defMap, valMap)
+			renderedText, err := templates.render(templatePosition{message: messageIndex, part: i}, templateType, util.PtrValue(part.Text), defMap, valMap, env)
 			if err != nil {
-				return nil
+				return nil, err
 			}
 			part.Text = util.Ptr(renderedText)
 		}
@@ -323,7 +772,10 @@ func formatMultiPart(templateType entity.TemplateType,
 		if part.Type == entity.ContentTypeMultiPartVariable && util.PtrValue(part.Text) != "" {
 			multiPartVariableKey := util.PtrValue(part.Text)
 			if vardef, ok := defMap[multiPartVariableKey]; ok {
-				if value, ok := valMap[multiPartVariableKey]; ok {
+				if _, ok := valMap[multiPartVariableKey]; !ok && env.getPartial() != nil {
+					env.getPartial().addVariable(multiPartVariableKey)
+					formatedParts = append(formatedParts, part)
+				} else if value, ok := valMap[multiPartVariableKey]; ok {
 					if vardef != nil && value != nil && vardef.Type == entity.VariableTypeMultiPart {
 						if multiPartValues, ok := value.([]*entity.ContentPart); ok {
 							formatedParts = append(formatedParts, multiPartValues...)
@@ -345,10 +797,14 @@ func formatMultiPart(templateType entity.TemplateType,
 			filtered = append(filtered, pt)
 		}
 	}
-	return filtered
+	return filtered, nil
 }
 
-func formatPlaceholderMessages(messages []*entity.Message, variableVals map[string]any) (results []*entity.Message, err error) {
+// formatPlaceholderMessages expands the placeholder messages with variables. The placeholder messages without value
+// are dropped, or kept and recorded in partial if it is not nil. The messages of placeholders are guarded by policy.
+func formatPlaceholderMessages(ctx context.Context, messages []*entity.Message, variableVals map[string]any,
+	partial *partialFormat, policy *PlaceholderPolicy,
+) (results []*entity.Message, err error) {
 	expandedMessages := make([]*entity.Message, 0)
 	for _, message := range messages {
 		if message != nil && message.Role == entity.RolePlaceholder {
@@ -358,7 +814,14 @@ func formatPlaceholderMessages(messages []*entity.Message, variableVals map[stri
 				if err != nil {
 					return nil, err
 				}
+				placeholderMessages, err = policy.guard(ctx, placeholderVariableName, placeholderMessages)
+				if err != nil {
+					return nil, err
+				}
 				expandedMessages = append(expandedMessages, placeholderMessages...)
+			} else if partial != nil {
+				partial.addPlaceholder(placeholderVariableName)
+				expandedMessages = append(expandedMessages, message)
 			}
 		} else {
 			expandedMessages = append(expandedMessages, message)
@@ -371,25 +834,22 @@ func renderTextContent(templateType entity.TemplateType,
 	templateStr string,
 	variableDefMap map[string]*entity.VariableDef,
 	variableVals map[string]any,
+	env *templateEnv,
 ) (string, error) {
-	switch templateType {
-	case entity.TemplateTypeNormal:
-		return fasttemplate.ExecuteFuncString(templateStr, consts.PromptNormalTemplateStartTag, consts.PromptNormalTemplateEndTag, func(w io.Writer, tag string) (int, error) {
-			// If not in variable definition, don't replace and return directly
-			if variableDefMap[tag] == nil {
-				return w.Write([]byte(consts.PromptNormalTemplateStartTag + tag + consts.PromptNormalTemplateEndTag))
-			}
-			// Otherwise replace
-			if val, ok := variableVals[tag]; ok {
-				return w.Write([]byte(fmt.Sprint(val)))
-			}
-			return 0, nil
-		}), nil
-	case entity.TemplateTypeJinja2:
-		return util.InterpolateJinja2(templateStr, variableVals)
-	default:
-		return "", consts.ErrInternal.Wrap(fmt.Errorf("unknown template type: %s", templateType))
+	tpl, err := compileTemplate(templateType, templateStr, env)
+	if err != nil {
+		return "", err
+	}
+	return tpl.render(variableDefMap, variableVals, env)
+}
+
+// parseNormalTag parses tag of normal template like `name|trim|upper` into variable key and func names.
+func parseNormalTag(tag string) (key string, funcNames []string) {
+	parts := strings.Split(tag, "|")
+	for i := range parts {
+		parts[i] = strings.TrimSpace(parts[i])
 	}
+	return parts[0], parts[1:]
 }
 
 func convertMessageLikeObjectToMessages(object any) (messages []*entity.Message, err error) {
@@ -5,8 +5,13 @@ package prompt
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
 	"testing"
 	"time"
 
@@ -14,8 +19,10 @@ import (
 	. "github.com/smartystreets/goconvey/convey"
 
 	"github.com/coze-dev/cozeloop-go/entity"
+	"github.com/coze-dev/cozeloop-go/internal/consts"
 	"github.com/coze-dev/cozeloop-go/internal/httpclient"
 	"github.com/coze-dev/cozeloop-go/internal/trace"
+	"github.com/coze-dev/cozeloop-go/internal/util"
 )
 
 func TestNewPromptProvider(t *testing.T) {
@@ -504,13 +511,13 @@ func TestValidateVariableValuesType(t *testing.T) {
 func TestFormatNormalMessages(t *testing.T) {
 	Convey("Test formatNormalMessages", t, func() {
 		Convey("When messages is empty", func() {
-			results, err := formatNormalMessages(entity.TemplateTypeNormal, []*entity.Message{}, nil, nil)
+			results, err := formatNormalMessages(entity.TemplateTypeNormal, []*entity.Message{}, nil, nil, nil, nil)
 			So(err, ShouldBeNil)
 			So(len(results), ShouldEqual, 0)
 		})
 
 		Convey("When message is nil", func() {
-			results, err := formatNormalMessages(entity.TemplateTypeNormal, []*entity.Message{nil}, nil, nil)
+			results, err := formatNormalMessages(entity.TemplateTypeNormal, []*entity.Message{nil}, nil, nil, nil, nil)
 			So(err, ShouldBeNil)
 			So(len(results), ShouldEqual, 0)
 		})
@@ -523,7 +530,7 @@ func TestFormatNormalMessages(t *testing.T) {
 					Content: &content,
 				},
 			}
-			results, err := formatNormalMessages(entity.TemplateTypeNormal, messages, nil, nil)
+			results, err := formatNormalMessages(entity.TemplateTypeNormal, messages, nil, nil, nil, nil)
 			So(err, ShouldBeNil)
 			So(len(results), ShouldEqual, 1)
 			So(results[0].Role, ShouldEqual, entity.RolePlaceholder)
@@ -545,7 +552,7 @@ func TestFormatNormalMessages(t *testing.T) {
 			}
 			variables := map[string]any{"key1": "world"}
 
-			results, err := formatNormalMessages(entity.TemplateTypeNormal, messages, variableDefs, variables)
+			results, err := formatNormalMessages(entity.TemplateTypeNormal, messages, variableDefs, variables, nil, nil)
 			So(err, ShouldBeNil)
 			So(len(results), ShouldEqual, 1)
 			So(*results[0].Content, ShouldEqual, "Hello world")
@@ -560,7 +567,7 @@ func TestFormatNormalMessages(t *testing.T) {
 				},
 			}
 
-			results, err := formatNormalMessages(entity.TemplateTypeNormal, messages, nil, nil)
+			results, err := formatNormalMessages(entity.TemplateTypeNormal, messages, nil, nil, nil, nil)
 			So(err, ShouldBeNil)
 			So(len(results), ShouldEqual, 1)
 			So(*results[0].Content, ShouldEqual, "")
@@ -574,7 +581,7 @@ func TestFormatNormalMessages(t *testing.T) {
 				},
 			}
 
-			results, err := formatNormalMessages(entity.TemplateTypeNormal, messages, nil, nil)
+			results, err := formatNormalMessages(entity.TemplateTypeNormal, messages, nil, nil, nil, nil)
 			So(err, ShouldBeNil)
 			So(len(results), ShouldEqual, 1)
 			So(results[0].Content, ShouldBeNil)
@@ -589,7 +596,7 @@ func TestFormatNormalMessages(t *testing.T) {
 				},
 			}
 
-			results, err := formatNormalMessages("unknown", messages, nil, nil)
+			results, err := formatNormalMessages("unknown", messages, nil, nil, nil, nil)
 			So(err, ShouldNotBeNil)
 			So(results, ShouldBeNil)
 		})
@@ -607,7 +614,7 @@ func TestFormatPlaceholderMessages(t *testing.T) {
 				},
 			}
 
-			results, err := formatPlaceholderMessages(messages, nil)
+			results, err := formatPlaceholderMessages(context.Background(), messages, nil, nil, nil)
 			So(err, ShouldBeNil)
 			So(results, ShouldNotBeNil)
 			So(len(results), ShouldEqual, 1)
@@ -617,7 +624,7 @@ func TestFormatPlaceholderMessages(t *testing.T) {
 		Convey("When messages contain nil", func() {
 			messages := []*entity.Message{nil}
 
-			results, err := formatPlaceholderMessages(messages, nil)
+			results, err := formatPlaceholderMessages(context.Background(), messages, nil, nil, nil)
 			So(err, ShouldBeNil)
 			So(results, ShouldNotBeNil)
 			So(len(results), ShouldEqual, 1)
@@ -648,7 +655,7 @@ func TestFormatPlaceholderMessages(t *testing.T) {
 				},
 			}
 
-			results, err := formatPlaceholderMessages(messages, variables)
+			results, err := formatPlaceholderMessages(context.Background(), messages, variables, nil, nil)
 			So(err, ShouldBeNil)
 			So(results, ShouldNotBeNil)
 			So(len(results), ShouldEqual, 2)
@@ -669,7 +676,7 @@ func TestFormatPlaceholderMessages(t *testing.T) {
 
 			variables := map[string]any{} // No matching variable
 
-			results, err := formatPlaceholderMessages(messages, variables)
+			results, err := formatPlaceholderMessages(context.Background(), messages, variables, nil, nil)
 			So(err, ShouldBeNil)
 			So(results, ShouldNotBeNil)
 			So(len(results), ShouldEqual, 0)
@@ -686,7 +693,7 @@ func TestFormatPlaceholderMessages(t *testing.T) {
 
 			variables := map[string]any{"placeholder_var": nil}
 
-			results, err := formatPlaceholderMessages(messages, variables)
+			results, err := formatPlaceholderMessages(context.Background(), messages, variables, nil, nil)
 			So(err, ShouldBeNil)
 			So(results, ShouldNotBeNil)
 			So(len(results), ShouldEqual, 0)
@@ -703,7 +710,7 @@ func TestFormatPlaceholderMessages(t *testing.T) {
 
 			variables := map[string]any{"placeholder_var": "not a message"}
 
-			results, err := formatPlaceholderMessages(messages, variables)
+			results, err := formatPlaceholderMessages(context.Background(), messages, variables, nil, nil)
 			So(err, ShouldNotBeNil)
 			So(results, ShouldBeNil)
 		})
@@ -798,7 +805,7 @@ func TestRenderTextContent(t *testing.T) {
 			}
 			variables := map[string]any{"key1": "world"}
 
-			result, err := renderTextContent(entity.TemplateTypeNormal, template, variableDefs, variables)
+			result, err := renderTextContent(entity.TemplateTypeNormal, template, variableDefs, variables, nil)
 			So(err, ShouldBeNil)
 			So(result, ShouldEqual, "Hello world")
 		})
@@ -808,7 +815,7 @@ func TestRenderTextContent(t *testing.T) {
 			variableDefs := map[string]*entity.VariableDef{} // No key1 defined
 			variables := map[string]any{"key1": "world"}
 
-			result, err := renderTextContent(entity.TemplateTypeNormal, template, variableDefs, variables)
+			result, err := renderTextContent(entity.TemplateTypeNormal, template, variableDefs, variables, nil)
 			So(err, ShouldBeNil)
 			So(result, ShouldEqual, "Hello {{key1}}")
 		})
@@ -823,7 +830,7 @@ func TestRenderTextContent(t *testing.T) {
 			}
 			variables := map[string]any{} // No key1 provided
 
-			result, err := renderTextContent(entity.TemplateTypeNormal, template, variableDefs, variables)
+			result, err := renderTextContent(entity.TemplateTypeNormal, template, variableDefs, variables, nil)
 			So(err, ShouldBeNil)
 			So(result, ShouldEqual, "Hello ")
 		})
@@ -838,7 +845,7 @@ func TestRenderTextContent(t *testing.T) {
 			}
 			variables := map[string]any{"key1": "world"}
 
-			result, err := renderTextContent(entity.TemplateTypeNormal, template, variableDefs, variables)
+			result, err := renderTextContent(entity.TemplateTypeNormal, template, variableDefs, variables, nil)
 			So(err, ShouldBeNil)
 			So(result, ShouldEqual, "Hello world")
 		})
@@ -853,7 +860,7 @@ func TestRenderTextContent(t *testing.T) {
 			}
 			variables := map[string]any{"key1": "world"}
 
-			result, err := renderTextContent("unknown", template, variableDefs, variables)
+			result, err := renderTextContent("unknown", template, variableDefs, variables, nil)
 			So(err, ShouldNotBeNil)
 			So(err.Error(), ShouldContainSubstring, "unknown template type")
 			So(result, ShouldEqual, "")
@@ -876,7 +883,7 @@ func TestRenderTextContent(t *testing.T) {
 				"name":     "world",
 			}
 
-			result, err := renderTextContent(entity.TemplateTypeNormal, template, variableDefs, variables)
+			result, err := renderTextContent(entity.TemplateTypeNormal, template, variableDefs, variables, nil)
 			So(err, ShouldBeNil)
 			So(result, ShouldEqual, "Hello world!")
 		})
@@ -893,7 +900,7 @@ func TestRenderTextContent(t *testing.T) {
 				"count": 42,
 			}
 
-			result, err := renderTextContent(entity.TemplateTypeNormal, template, variableDefs, variables)
+			result, err := renderTextContent(entity.TemplateTypeNormal, template, variableDefs, variables, nil)
 			So(err, ShouldBeNil)
 			So(result, ShouldEqual, "Count: 42")
 		})
@@ -1014,6 +1021,143 @@ func TestDoGetPrompt(t *testing.T) {
 			So(err, ShouldBeNil)
 			So(prompt, ShouldBeNil)
 		})
+
+		Convey("When cached prompt is stale with stale-while-revalidate", func() {
+			swrProvider := NewPromptProvider(httpClient, traceProvider, Options{
+				WorkspaceID:                "workspace1",
+				PromptCacheRefreshInterval: time.Hour,
+				PromptStaleWhileRevalidate: true,
+			})
+			key := swrProvider.cache.getCacheKey("key1", "1.0", "")
+			_ = swrProvider.cache.cache.Set(key, &cacheItem{
+				prompt:     &entity.Prompt{WorkspaceID: "workspace1", PromptKey: "key1", Version: "1.0"},
+				updateTime: time.Now().Add(-2 * time.Hour),
+			})
+			So(swrProvider.cache.IsStale("key1", "1.0", ""), ShouldBeTrue)
+
+			release := make(chan struct{})
+			promptResult := &PromptResult{
+				Query: PromptQuery{PromptKey: "key1", Version: "1.0"},
+				Prompt: &Prompt{
+					WorkspaceID: "workspace1",
+					PromptKey:   "key1",
+					Version:     "1.0",
+					LLMConfig:   &LLMConfig{},
+				},
+			}
+			Mock((*OpenAPIClient).MPullPrompt).To(func(ctx context.Context, req MPullPromptRequest) ([]*PromptResult, error) {
+				<-release
+				return []*PromptResult{promptResult}, nil
+			}).Build()
+			defer UnPatchAll()
+
+			// stale prompt is returned without waiting for the refresh
+			prompt, err := swrProvider.doGetPrompt(ctx, GetPromptParam{PromptKey: "key1", Version: "1.0"}, GetPromptOptions{})
+			So(err, ShouldBeNil)
+			So(prompt, ShouldNotBeNil)
+			So(prompt.LLMConfig, ShouldBeNil)
+
+			close(release)
+			So(func() bool {
+				for i := 0; i < 100; i++ {
+					if !swrProvider.cache.IsStale("key1", "1.0", "") {
+						return true
+					}
+					time.Sleep(10 * time.Millisecond)
+				}
+				return false
+			}(), ShouldBeTrue)
+			prompt, _ = swrProvider.cache.Get("key1", "1.0", "")
+			So(prompt.LLMConfig, ShouldNotBeNil)
+		})
+
+		Convey("When workspace is overridden", func() {
+			var requestWorkspaceID string
+			Mock((*OpenAPIClient).MPullPrompt).To(func(ctx context.Context, req MPullPromptRequest) ([]*PromptResult, error) {
+				requestWorkspaceID = req.WorkSpaceID
+				return []*PromptResult{{
+					Query:  req.Queries[0],
+					Prompt: &Prompt{WorkspaceID: req.WorkSpaceID, PromptKey: "key1", Version: "1.0"},
+				}}, nil
+			}).Build()
+			defer UnPatchAll()
+
+			wsProvider := NewPromptProvider(httpClient, traceProvider, options)
+			param := GetPromptParam{PromptKey: "key1", Version: "1.0"}
+			prompt, err := wsProvider.doGetPrompt(ctx, param, GetPromptOptions{WorkspaceID: "workspace2"})
+			So(err, ShouldBeNil)
+			So(requestWorkspaceID, ShouldEqual, "workspace2")
+			So(prompt.WorkspaceID, ShouldEqual, "workspace2")
+
+			// caches of workspaces are isolated
+			_, ok := wsProvider.cache.Get("key1", "1.0", "")
+			So(ok, ShouldBeFalse)
+			prompt, err = wsProvider.doGetPrompt(ctx, param, GetPromptOptions{})
+			So(err, ShouldBeNil)
+			So(requestWorkspaceID, ShouldEqual, "workspace1")
+			So(prompt.WorkspaceID, ShouldEqual, "workspace1")
+		})
+
+		Convey("When caches of too many workspaces are created", func() {
+			lruProvider := NewPromptProvider(httpClient, traceProvider, options)
+			first := lruProvider.getCache("tenant0", nil)
+			So(lruProvider.getCache("tenant0", nil), ShouldPointTo, first)
+			for i := 1; i <= maxExtraCaches; i++ {
+				lruProvider.getCache(fmt.Sprintf("tenant%d", i), nil)
+			}
+			So(lruProvider.extraCaches.Len(false), ShouldEqual, maxExtraCaches)
+			// the least recently used cache is evicted and stopped
+			_, ok := <-first.stopChan
+			So(ok, ShouldBeFalse)
+			So(lruProvider.getCache("tenant0", nil), ShouldNotPointTo, first)
+		})
+
+		Convey("When provider is closed", func() {
+			closedProvider := NewPromptProvider(httpClient, traceProvider, options)
+			extra := closedProvider.getCache("workspace2", nil)
+			closedProvider.Close()
+			for _, cache := range []*PromptCache{closedProvider.cache, extra} {
+				_, ok := <-cache.stopChan
+				So(ok, ShouldBeFalse)
+			}
+		})
+
+		Convey("When field mask is set", func() {
+			var requestMask *FieldMask
+			Mock((*OpenAPIClient).doMPullPrompt).To(func(ctx context.Context, req MPullPromptRequest) ([]*PromptResult, error) {
+				requestMask = req.FieldMask
+				// server ignores the mask
+				return []*PromptResult{{
+					Query: req.Queries[0],
+					Prompt: &Prompt{
+						WorkspaceID:    "workspace1",
+						PromptKey:      "key1",
+						Version:        "1.0",
+						PromptTemplate: &PromptTemplate{TemplateType: TemplateTypeNormal},
+						Tools:          []*Tool{nil, {Type: ToolTypeFunction}},
+						LLMConfig:      &LLMConfig{},
+					},
+				}}, nil
+			}).Build()
+			defer UnPatchAll()
+
+			maskProvider := NewPromptProvider(httpClient, traceProvider, options)
+			param := GetPromptParam{PromptKey: "key1", Version: "1.0"}
+			mask := &FieldMask{Exclude: []string{PromptFieldTools, PromptFieldLLMConfig}}
+			prompt, err := maskProvider.doGetPrompt(ctx, param, GetPromptOptions{FieldMask: mask})
+			So(err, ShouldBeNil)
+			So(requestMask, ShouldEqual, mask)
+			So(prompt.PromptTemplate, ShouldNotBeNil)
+			So(prompt.Tools, ShouldBeNil)
+			So(prompt.LLMConfig, ShouldBeNil)
+
+			// masked prompt is not returned to the request without mask
+			prompt, err = maskProvider.doGetPrompt(ctx, param, GetPromptOptions{})
+			So(err, ShouldBeNil)
+			So(requestMask, ShouldBeNil)
+			So(len(prompt.Tools), ShouldEqual, 1)
+			So(prompt.LLMConfig, ShouldNotBeNil)
+		})
 	})
 }
 
@@ -1038,7 +1182,7 @@ func TestDoPromptFormat(t *testing.T) {
 			}
 			variables := map[string]any{}
 
-			messages, err := provider.doPromptFormat(ctx, prompt, variables)
+			messages, err := provider.doPromptFormat(ctx, prompt, variables, PromptFormatOptions{})
 			So(err, ShouldBeNil)
 			So(messages, ShouldBeNil)
 		})
@@ -1054,7 +1198,7 @@ func TestDoPromptFormat(t *testing.T) {
 			}
 			variables := map[string]any{}
 
-			messages, err := provider.doPromptFormat(ctx, prompt, variables)
+			messages, err := provider.doPromptFormat(ctx, prompt, variables, PromptFormatOptions{})
 			So(err, ShouldBeNil)
 			So(messages, ShouldBeNil)
 		})
@@ -1083,7 +1227,7 @@ func TestDoPromptFormat(t *testing.T) {
 			}
 			variables := map[string]any{"key1": 123} // Not a string
 
-			messages, err := provider.doPromptFormat(ctx, prompt, variables)
+			messages, err := provider.doPromptFormat(ctx, prompt, variables, PromptFormatOptions{})
 			So(err, ShouldNotBeNil)
 			So(messages, ShouldBeNil)
 			So(err.Error(), ShouldContainSubstring, "type of variable 'key1' should be string")
@@ -1113,7 +1257,7 @@ func TestDoPromptFormat(t *testing.T) {
 			}
 			variables := map[string]any{"key1": "world"}
 
-			messages, err := provider.doPromptFormat(ctx, prompt, variables)
+			messages, err := provider.doPromptFormat(ctx, prompt, variables, PromptFormatOptions{})
 			So(err, ShouldNotBeNil)
 			So(messages, ShouldBeNil)
 			So(err.Error(), ShouldContainSubstring, "unknown template type")
@@ -1150,7 +1294,7 @@ func TestDoPromptFormat(t *testing.T) {
 				"placeholder_var": "not a message", // Invalid type for placeholder
 			}
 
-			messages, err := provider.doPromptFormat(ctx, prompt, variables)
+			messages, err := provider.doPromptFormat(ctx, prompt, variables, PromptFormatOptions{})
 			So(err, ShouldNotBeNil)
 			So(messages, ShouldBeNil)
 			So(err.Error(), ShouldContainSubstring, "type of variable 'placeholder_var' should be Message like object")
@@ -1180,7 +1324,7 @@ func TestDoPromptFormat(t *testing.T) {
 			}
 			variables := map[string]any{"key1": "world"}
 
-			messages, err := provider.doPromptFormat(ctx, prompt, variables)
+			messages, err := provider.doPromptFormat(ctx, prompt, variables, PromptFormatOptions{})
 			So(err, ShouldBeNil)
 			So(messages, ShouldNotBeNil)
 			So(len(messages), ShouldEqual, 1)
@@ -1226,7 +1370,7 @@ func TestDoPromptFormat(t *testing.T) {
 				},
 			}
 
-			messages, err := provider.doPromptFormat(ctx, prompt, variables)
+			messages, err := provider.doPromptFormat(ctx, prompt, variables, PromptFormatOptions{})
 			So(err, ShouldBeNil)
 			So(messages, ShouldNotBeNil)
 			So(len(messages), ShouldEqual, 2)
@@ -1238,6 +1382,61 @@ func TestDoPromptFormat(t *testing.T) {
 	})
 }
 
+func TestValidateStrictVariables(t *testing.T) {
+	Convey("Test validateStrictVariables", t, func() {
+		template := &entity.PromptTemplate{
+			TemplateType: entity.TemplateTypeNormal,
+			Messages: []*entity.Message{
+				{
+					Role:    entity.RoleSystem,
+					Content: util.Ptr("Hello {{name}}, today is {{date}}"),
+				},
+				{
+					Role: entity.RoleUser,
+					Parts: []*entity.ContentPart{
+						{Type: entity.ContentTypeText, Text: util.Ptr("{{question}}")},
+					},
+				},
+			},
+			VariableDefs: []*entity.VariableDef{
+				{Key: "name", Type: entity.VariableTypeString},
+				{Key: "question", Type: entity.VariableTypeString},
+			},
+		}
+
+		Convey("When all variables are matched", func() {
+			template := &entity.PromptTemplate{
+				TemplateType: template.TemplateType,
+				Messages:     template.Messages[:1],
+				VariableDefs: []*entity.VariableDef{
+					{Key: "name", Type: entity.VariableTypeString},
+					{Key: "date", Type: entity.VariableTypeString},
+				},
+			}
+			err := validateStrictVariables(template, map[string]any{"name": "loop", "date": "today"}, nil)
+			So(err, ShouldBeNil)
+		})
+
+		Convey("When variables are missing or extra", func() {
+			err := validateStrictVariables(template, map[string]any{"name": "loop", "foo": "bar"}, nil)
+			So(errors.Is(err, consts.ErrInvalidParam), ShouldBeTrue)
+			So(err.Error(), ShouldContainSubstring, "missing variables: [question]")
+			So(err.Error(), ShouldContainSubstring, "extra variables: [date, foo]")
+		})
+
+		Convey("When format with strict variables", func() {
+			provider := NewPromptProvider(&httpclient.Client{}, nil, Options{})
+			prompt := &entity.Prompt{PromptTemplate: template}
+			_, err := provider.doPromptFormat(context.Background(), prompt, map[string]any{"name": "loop"}, PromptFormatOptions{StrictVariables: true})
+			So(err, ShouldNotBeNil)
+
+			messages, err := provider.doPromptFormat(context.Background(), prompt.DeepCopy(), map[string]any{"name": "loop"}, PromptFormatOptions{})
+			So(err, ShouldBeNil)
+			So(*messages[0].Content, ShouldEqual, "Hello loop, today is {{date}}")
+		})
+	})
+}
+
 func TestValidateVariableValuesType_ExtendedTypes(t *testing.T) {
 	Convey("Test validateVariableValuesType for extended types", t, func() {
 		Convey("When variable type is boolean", func() {
@@ -1517,6 +1716,36 @@ func TestValidateVariableValuesType_ExtendedTypes(t *testing.T) {
 	})
 }
 
+func TestRenderTextContent_TemplateFuncs(t *testing.T) {
+	Convey("Test renderTextContent with template funcs", t, func() {
+		funcs := map[string]any{
+			"upper": strings.ToUpper,
+			"trim":  strings.TrimSpace,
+		}
+		variableDefs := map[string]*entity.VariableDef{
+			"name": {Key: "name", Type: entity.VariableTypeString},
+		}
+		variables := map[string]any{"name": "  loop  "}
+
+		Convey("Normal template applies funcs in pipeline", func() {
+			result, err := renderTextContent(entity.TemplateTypeNormal, "Hello {{name|trim|upper}}, {{other|upper}}", variableDefs, variables, newTemplateEnv(funcs, nil))
+			So(err, ShouldBeNil)
+			So(result, ShouldEqual, "Hello LOOP, {{other|upper}}")
+		})
+
+		Convey("Normal template with unregistered func", func() {
+			_, err := renderTextContent(entity.TemplateTypeNormal, "Hello {{name|lower}}", variableDefs, variables, newTemplateEnv(funcs, nil))
+			So(errors.Is(err, consts.ErrTemplateRender), ShouldBeTrue)
+		})
+
+		Convey("Jinja2 template uses funcs as filter", func() {
+			result, err := renderTextContent(entity.TemplateTypeJinja2, "Hello {{ name | trim | upper }}", variableDefs, variables, newTemplateEnv(funcs, nil))
+			So(err, ShouldBeNil)
+			So(result, ShouldEqual, "Hello LOOP")
+		})
+	})
+}
+
 func TestRenderTextContent_Jinja2(t *testing.T) {
 	Convey("Test renderTextContent function with Jinja2 template type", t, func() {
 		Convey("When template type is Jinja2", func() {
@@ -1530,7 +1759,7 @@ func TestRenderTextContent_Jinja2(t *testing.T) {
 				}
 				variables := map[string]any{"name": "world"}
 
-				result, err := renderTextContent(entity.TemplateTypeJinja2, template, variableDefs, variables)
+				result, err := renderTextContent(entity.TemplateTypeJinja2, template, variableDefs, variables, nil)
 				So(err, ShouldBeNil)
 				So(result, ShouldEqual, "Hello world")
 			})
@@ -1545,7 +1774,7 @@ func TestRenderTextContent_Jinja2(t *testing.T) {
 				}
 				variables := map[string]any{"active": true}
 
-				result, err := renderTextContent(entity.TemplateTypeJinja2, template, variableDefs, variables)
+				result, err := renderTextContent(entity.TemplateTypeJinja2, template, variableDefs, variables, nil)
 				So(err, ShouldBeNil)
 				So(result, ShouldEqual, "User is active")
 			})
@@ -1560,7 +1789,7 @@ func TestRenderTextContent_Jinja2(t *testing.T) {
 				}
 				variables := map[string]any{"items": []string{"apple", "banana", "cherry"}}
 
-				result, err := renderTextContent(entity.TemplateTypeJinja2, template, variableDefs, variables)
+				result, err := renderTextContent(entity.TemplateTypeJinja2, template, variableDefs, variables, nil)
 				So(err, ShouldBeNil)
 				So(result, ShouldEqual, "Items: apple, banana, cherry")
 			})
@@ -1575,7 +1804,7 @@ func TestRenderTextContent_Jinja2(t *testing.T) {
 				}
 				variables := map[string]any{"name": "world"}
 
-				result, err := renderTextContent(entity.TemplateTypeJinja2, template, variableDefs, variables)
+				result, err := renderTextContent(entity.TemplateTypeJinja2, template, variableDefs, variables, nil)
 				So(err, ShouldNotBeNil)
 				So(result, ShouldEqual, "")
 				So(err.Error(), ShouldContainSubstring, "template render error")
@@ -1593,7 +1822,7 @@ func TestRenderTextContent_Jinja2(t *testing.T) {
 				}
 				variables := map[string]any{"name": "world"}
 
-				result, err := renderTextContent(entity.TemplateTypeNormal, template, variableDefs, variables)
+				result, err := renderTextContent(entity.TemplateTypeNormal, template, variableDefs, variables, nil)
 				So(err, ShouldBeNil)
 				So(result, ShouldEqual, "Hello world")
 			})
@@ -1608,7 +1837,7 @@ func TestRenderTextContent_Jinja2(t *testing.T) {
 				}
 				variables := map[string]any{"name": "world"}
 
-				result, err := renderTextContent(entity.TemplateTypeJinja2, template, variableDefs, variables)
+				result, err := renderTextContent(entity.TemplateTypeJinja2, template, variableDefs, variables, nil)
 				So(err, ShouldBeNil)
 				So(result, ShouldEqual, "Hello world")
 			})
@@ -1625,12 +1854,12 @@ func TestRenderTextContent_Jinja2(t *testing.T) {
 				variables := map[string]any{"active": true}
 
 				// Normal template treats this as literal text
-				normalResult, err := renderTextContent(entity.TemplateTypeNormal, template, variableDefs, variables)
+				normalResult, err := renderTextContent(entity.TemplateTypeNormal, template, variableDefs, variables, nil)
 				So(err, ShouldBeNil)
 				So(normalResult, ShouldEqual, template) // Should remain unchanged
 
 				// Jinja2 template processes the conditional
-				jinja2Result, err := renderTextContent(entity.TemplateTypeJinja2, template, variableDefs, variables)
+				jinja2Result, err := renderTextContent(entity.TemplateTypeJinja2, template, variableDefs, variables, nil)
 				So(err, ShouldBeNil)
 				So(jinja2Result, ShouldEqual, "Active")
 			})
@@ -2010,12 +2239,14 @@ Access denied.
 func TestFormatMultiPart(t *testing.T) {
 	Convey("Test formatMultiPart", t, func() {
 		Convey("When parts is nil", func() {
-			result := formatMultiPart(entity.TemplateTypeNormal, nil, nil, nil)
+			result, err := formatMultiPart(entity.TemplateTypeNormal, nil, nil, nil, nil, nil, 0)
+			So(err, ShouldBeNil)
 			So(result, ShouldBeNil)
 		})
 
 		Convey("When parts is empty", func() {
-			result := formatMultiPart(entity.TemplateTypeNormal, []*entity.ContentPart{}, nil, nil)
+			result, err := formatMultiPart(entity.TemplateTypeNormal, []*entity.ContentPart{}, nil, nil, nil, nil, 0)
+			So(err, ShouldBeNil)
 			So(result, ShouldBeNil)
 		})
 
@@ -2029,7 +2260,8 @@ func TestFormatMultiPart(t *testing.T) {
 				},
 				nil,
 			}
-			result := formatMultiPart(entity.TemplateTypeNormal, parts, nil, nil)
+			result, err := formatMultiPart(entity.TemplateTypeNormal, parts, nil, nil, nil, nil, 0)
+			So(err, ShouldBeNil)
 			So(result, ShouldNotBeNil)
 			So(len(result), ShouldEqual, 1)
 			So(result[0].Type, ShouldEqual, entity.ContentTypeText)
@@ -2053,7 +2285,8 @@ func TestFormatMultiPart(t *testing.T) {
 			valMap := map[string]any{
 				"name": "World",
 			}
-			result := formatMultiPart(entity.TemplateTypeNormal, parts, defMap, valMap)
+			result, err := formatMultiPart(entity.TemplateTypeNormal, parts, defMap, valMap, nil, nil, 0)
+			So(err, ShouldBeNil)
 			So(result, ShouldNotBeNil)
 			So(len(result), ShouldEqual, 1)
 			So(result[0].Type, ShouldEqual, entity.ContentTypeText)
@@ -2089,7 +2322,8 @@ func TestFormatMultiPart(t *testing.T) {
 			valMap := map[string]any{
 				"multipart_var": multiPartValues,
 			}
-			result := formatMultiPart(entity.TemplateTypeNormal, parts, defMap, valMap)
+			result, err := formatMultiPart(entity.TemplateTypeNormal, parts, defMap, valMap, nil, nil, 0)
+			So(err, ShouldBeNil)
 			So(result, ShouldNotBeNil)
 			So(len(result), ShouldEqual, 2)
 			So(result[0].Type, ShouldEqual, entity.ContentTypeText)
@@ -2119,7 +2353,8 @@ func TestFormatMultiPart(t *testing.T) {
 				valMap := map[string]any{
 					"name": "World",
 				}
-				result := formatMultiPart(entity.TemplateTypeNormal, parts, defMap, valMap)
+				result, err := formatMultiPart(entity.TemplateTypeNormal, parts, defMap, valMap, nil, nil, 0)
+				So(err, ShouldNotBeNil)
 				So(result, ShouldBeNil)
 			})
 		})
@@ -2134,7 +2369,8 @@ func TestFormatMultiPart(t *testing.T) {
 			}
 			defMap := map[string]*entity.VariableDef{}
 			valMap := map[string]any{}
-			result := formatMultiPart(entity.TemplateTypeNormal, parts, defMap, valMap)
+			result, err := formatMultiPart(entity.TemplateTypeNormal, parts, defMap, valMap, nil, nil, 0)
+			So(err, ShouldBeNil)
 			So(result, ShouldBeNil)
 		})
 
@@ -2155,7 +2391,8 @@ func TestFormatMultiPart(t *testing.T) {
 			valMap := map[string]any{
 				"invalid_var": "string value",
 			}
-			result := formatMultiPart(entity.TemplateTypeNormal, parts, defMap, valMap)
+			result, err := formatMultiPart(entity.TemplateTypeNormal, parts, defMap, valMap, nil, nil, 0)
+			So(err, ShouldBeNil)
 			So(result, ShouldBeNil)
 		})
 
@@ -2188,7 +2425,8 @@ func TestFormatMultiPart(t *testing.T) {
 			valMap := map[string]any{
 				"multipart_var": multiPartValues,
 			}
-			result := formatMultiPart(entity.TemplateTypeNormal, parts, defMap, valMap)
+			result, err := formatMultiPart(entity.TemplateTypeNormal, parts, defMap, valMap, nil, nil, 0)
+			So(err, ShouldBeNil)
 			So(result, ShouldBeNil) // All parts filtered out
 		})
 
@@ -2226,7 +2464,8 @@ func TestFormatMultiPart(t *testing.T) {
 				"name":          "World",
 				"multipart_var": multiPartValues,
 			}
-			result := formatMultiPart(entity.TemplateTypeNormal, parts, defMap, valMap)
+			result, err := formatMultiPart(entity.TemplateTypeNormal, parts, defMap, valMap, nil, nil, 0)
+			So(err, ShouldBeNil)
 			So(result, ShouldNotBeNil)
 			So(len(result), ShouldEqual, 2)
 			So(result[0].Type, ShouldEqual, entity.ContentTypeText)
@@ -2236,3 +2475,215 @@ func TestFormatMultiPart(t *testing.T) {
 		})
 	})
 }
+
+func TestPromptFormatVariableStruct(t *testing.T) {
+	type variables struct {
+		Name  string            `loop:"name"`
+		Count int               `loop:"count"`
+		Chat  []*entity.Message `loop:"chat"`
+	}
+	provider := &Provider{}
+	prompt := &entity.Prompt{
+		PromptKey: "test_prompt",
+		PromptTemplate: &entity.PromptTemplate{
+			TemplateType: entity.TemplateTypeNormal,
+			Messages: []*entity.Message{
+				{Role: entity.RoleSystem, Content: util.Ptr("Hello {{name}}, {{greeting}}")},
+				{Role: entity.RolePlaceholder, Content: util.Ptr("chat")},
+			},
+			VariableDefs: []*entity.VariableDef{
+				{Key: "name", Type: entity.VariableTypeString},
+				{Key: "greeting", Type: entity.VariableTypeString},
+				{Key: "count", Type: entity.VariableTypeInteger},
+				{Key: "chat", Type: entity.VariableTypePlaceholder},
+			},
+		},
+	}
+
+	Convey("Test PromptFormat with VariableStruct", t, func() {
+		Convey("When struct is merged with variables", func() {
+			messages, err := provider.PromptFormat(context.Background(), prompt, map[string]any{"greeting": "good morning"}, PromptFormatOptions{
+				VariableStruct: variables{Name: "Tom", Chat: []*entity.Message{{Role: entity.RoleUser, Content: util.Ptr("hi")}}},
+			})
+			So(err, ShouldBeNil)
+			So(len(messages), ShouldEqual, 2)
+			So(*messages[0].Content, ShouldEqual, "Hello Tom, good morning")
+			So(*messages[1].Content, ShouldEqual, "hi")
+		})
+
+		Convey("When field is not defined in prompt", func() {
+			_, err := provider.PromptFormat(context.Background(), prompt, nil, PromptFormatOptions{
+				VariableStruct: struct {
+					Nmae string `loop:"nmae"`
+				}{Nmae: "Tom"},
+			})
+			So(err, ShouldNotBeNil)
+			So(err.Error(), ShouldContainSubstring, "nmae")
+		})
+
+		Convey("When field type mismatches variable definition", func() {
+			_, err := provider.PromptFormat(context.Background(), prompt, nil, PromptFormatOptions{
+				VariableStruct: struct {
+					Name int `loop:"name"`
+				}{Name: 1},
+			})
+			So(err, ShouldNotBeNil)
+		})
+
+		Convey("When variable is set by both struct and map", func() {
+			_, err := provider.PromptFormat(context.Background(), prompt, map[string]any{"name": "Jerry"}, PromptFormatOptions{
+				VariableStruct: variables{Name: "Tom"},
+			})
+			So(err, ShouldNotBeNil)
+		})
+	})
+}
+
+func TestBuildExecuteRequestVariableStruct(t *testing.T) {
+	Convey("Test buildExecuteRequest with VariableStruct", t, func() {
+		req, err := buildExecuteRequest(&entity.ExecuteParam{
+			PromptKey:    "test_prompt",
+			VariableVals: map[string]any{"greeting": "hello"},
+			VariableStruct: struct {
+				Name string `loop:"name"`
+			}{Name: "Tom"},
+		}, "123")
+		So(err, ShouldBeNil)
+		values := make(map[string]string)
+		for _, val := range req.VariableVals {
+			values[val.Key] = util.PtrValue(val.Value)
+		}
+		So(values, ShouldResemble, map[string]string{"greeting": "hello", "name": "Tom"})
+
+		_, err = buildExecuteRequest(&entity.ExecuteParam{
+			PromptKey:    "test_prompt",
+			VariableVals: map[string]any{"name": "Jerry"},
+			VariableStruct: struct {
+				Name string `loop:"name"`
+			}{Name: "Tom"},
+		}, "123")
+		So(err, ShouldNotBeNil)
+	})
+
+	Convey("Test buildExecuteRequest with LLMConfig", t, func() {
+		req, err := buildExecuteRequest(&entity.ExecuteParam{
+			PromptKey: "test_prompt",
+			LLMConfig: &entity.LLMConfig{JSONMode: util.Ptr(true)},
+		}, "123")
+		So(err, ShouldBeNil)
+		So(req.LLMConfig, ShouldResemble, &LLMConfig{JSONMode: util.Ptr(true)})
+	})
+}
+
+func TestGetPromptDeepCopy(t *testing.T) {
+	ctx := context.Background()
+	param := GetPromptParam{PromptKey: "key1", Version: "1.0"}
+	Convey("Test GetPrompt returns a copy by default", t, func() {
+		provider := NewPromptProvider(&httpclient.Client{}, nil, Options{WorkspaceID: "workspace1"})
+		cached := newFormatCacheTestPrompt()
+		provider.cache.Set(param.PromptKey, param.Version, param.Label, cached)
+
+		prompt, err := provider.GetPrompt(ctx, param, GetPromptOptions{})
+		So(err, ShouldBeNil)
+		So(prompt, ShouldNotPointTo, cached)
+		So(prompt, ShouldResemble, cached)
+
+		// the copy can be modified without changing the cache
+		prompt.PromptTemplate.Messages = append(prompt.PromptTemplate.Messages, &entity.Message{Role: entity.RoleUser})
+		So(len(cached.PromptTemplate.Messages), ShouldEqual, 3)
+	})
+
+	Convey("Test GetPrompt returns the shared prompt with PromptShared", t, func() {
+		provider := NewPromptProvider(&httpclient.Client{}, nil, Options{WorkspaceID: "workspace1", PromptShared: true})
+		cached := newFormatCacheTestPrompt()
+		provider.cache.Set(param.PromptKey, param.Version, param.Label, cached)
+
+		prompt, err := provider.GetPrompt(ctx, param, GetPromptOptions{})
+		So(err, ShouldBeNil)
+		So(prompt, ShouldPointTo, cached)
+
+		// format does not modify the shared prompt
+		_, err = provider.PromptFormat(ctx, prompt, map[string]any{"product": "CozeLoop"}, PromptFormatOptions{})
+		So(err, ShouldBeNil)
+		So(*cached.PromptTemplate.Messages[0].Content, ShouldEqual, "You are a helpful assistant of {{product}}, answer in {{language}}.")
+	})
+}
+
+func BenchmarkGetPrompt(b *testing.B) {
+	for _, shared := range []bool{false, true} {
+		b.Run(fmt.Sprintf("shared_%v", shared), func(b *testing.B) {
+			ctx := context.Background()
+			provider := NewPromptProvider(&httpclient.Client{}, nil, Options{WorkspaceID: "workspace1", PromptShared: shared})
+			prompt := newFormatCacheTestPrompt()
+			for i := 0; i < 100; i++ {
+				prompt.PromptTemplate.Messages = append(prompt.PromptTemplate.Messages,
+					&entity.Message{Role: entity.RoleUser, Content: util.Ptr(strings.Repeat("example ", 100))})
+			}
+			provider.cache.Set(prompt.PromptKey, prompt.Version, "", prompt)
+			param := GetPromptParam{PromptKey: prompt.PromptKey, Version: prompt.Version}
+			b.ReportAllocs()
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				if _, err := provider.GetPrompt(ctx, param, GetPromptOptions{}); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
+
+func TestGetPromptNotFoundCache(t *testing.T) {
+	ctx := context.Background()
+	var requests int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&requests, 1)
+		_ = json.NewEncoder(w).Encode(map[string]any{"code": 0, "data": PromptResultData{}})
+	}))
+	defer server.Close()
+	httpClient := httpclient.NewClient(server.URL, http.DefaultClient, httpclient.NewTokenAuth("token"), nil)
+	param := GetPromptParam{PromptKey: "missing", Version: "1.0"}
+
+	Convey("Test missing prompt is cached as not found", t, func() {
+		atomic.StoreInt32(&requests, 0)
+		provider := NewPromptProvider(httpClient, nil, Options{WorkspaceID: "workspace1", PromptNotFoundCacheTTL: 100 * time.Millisecond})
+		for i := 0; i < 3; i++ {
+			prompt, err := provider.GetPrompt(ctx, param, GetPromptOptions{})
+			So(err, ShouldBeNil)
+			So(prompt, ShouldBeNil)
+		}
+		So(atomic.LoadInt32(&requests), ShouldEqual, 1)
+
+		time.Sleep(150 * time.Millisecond)
+		_, _ = provider.GetPrompt(ctx, param, GetPromptOptions{})
+		So(atomic.LoadInt32(&requests), ShouldEqual, 2)
+
+		// found prompt replaces the not found item
+		provider.cache.Set(param.PromptKey, param.Version, param.Label, &entity.Prompt{PromptKey: "missing", Version: "1.0"})
+		prompt, err := provider.GetPrompt(ctx, param, GetPromptOptions{})
+		So(err, ShouldBeNil)
+		So(prompt.PromptKey, ShouldEqual, "missing")
+	})
+
+	Convey("Test missing prompt is queried every time when not found cache is disabled", t, func() {
+		atomic.StoreInt32(&requests, 0)
+		provider := NewPromptProvider(httpClient, nil, Options{WorkspaceID: "workspace1"})
+		for i := 0; i < 3; i++ {
+			_, _ = provider.GetPrompt(ctx, param, GetPromptOptions{})
+		}
+		So(atomic.LoadInt32(&requests), ShouldEqual, 3)
+	})
+
+	Convey("Test ErrPromptNotFound is returned with PromptNotFoundError", t, func() {
+		provider := NewPromptProvider(httpClient, nil, Options{
+			WorkspaceID:            "workspace1",
+			PromptNotFoundCacheTTL: time.Minute,
+			PromptNotFoundError:    true,
+		})
+		for i := 0; i < 2; i++ {
+			prompt, err := provider.GetPrompt(ctx, param, GetPromptOptions{})
+			So(prompt, ShouldBeNil)
+			So(errors.Is(err, consts.ErrPromptNotFound), ShouldBeTrue)
+			So(err.Error(), ShouldContainSubstring, "prompt_key: missing")
+		}
+	})
+}
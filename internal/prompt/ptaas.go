@@ -7,13 +7,21 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"time"
 
 	"github.com/coze-dev/cozeloop-go/entity"
 	"github.com/coze-dev/cozeloop-go/internal/consts"
+	"github.com/coze-dev/cozeloop-go/internal/logger"
+	"github.com/coze-dev/cozeloop-go/internal/trace"
+	"github.com/coze-dev/cozeloop-go/internal/util"
+	"github.com/coze-dev/cozeloop-go/spec/tracespec"
 )
 
 // ExecuteOptions Execute选项
-type ExecuteOptions struct{}
+type ExecuteOptions struct {
+	// Timeout 本次调用的超时时间, 大于0时覆盖默认超时时间
+	Timeout time.Duration
+}
 
 // ExecuteStreamingOptions ExecuteStreaming选项
 type ExecuteStreamingOptions struct{}
@@ -25,22 +33,48 @@ type ExecuteOption func(option *ExecuteOptions)
 type ExecuteStreamingOption func(option *ExecuteStreamingOptions)
 
 // Execute 执行Prompt并返回结果
-func (p *Provider) Execute(ctx context.Context, req *entity.ExecuteParam, options ...ExecuteOption) (entity.ExecuteResult, error) {
-	result := entity.ExecuteResult{}
+func (p *Provider) Execute(ctx context.Context, req *entity.ExecuteParam, options ...ExecuteOption) (result entity.ExecuteResult, err error) {
 	// 处理选项
 	opts := &ExecuteOptions{}
 	for _, option := range options {
 		option(opts)
 	}
 
+	var cacheHit bool
+	if p.config.PromptTrace && p.traceProvider != nil {
+		var executeSpan *trace.Span
+		ctx, executeSpan = p.startExecuteSpan(ctx, req, false)
+		defer func() {
+			spanResult := result
+			if p.executeCache != nil && executeSpan != nil {
+				executeSpan.SetTags(ctx, map[string]any{tracespec.ExecuteCacheHit: cacheHit})
+				// 命中缓存时没有调用模型, 不记录token用量
+				if cacheHit {
+					spanResult.Usage = nil
+				}
+			}
+			finishExecuteSpan(ctx, executeSpan, spanResult, err)
+		}()
+	}
+
 	// 构建请求体
 	executeReq, err := buildExecuteRequest(req, p.config.WorkspaceID)
 	if err != nil {
 		return entity.ExecuteResult{}, err
 	}
 
+	// 开启缓存时, 相同版本、变量和消息的请求直接返回缓存结果
+	cacheKey, cacheable := p.executeCache.key(executeReq)
+	if cacheable {
+		if result, cacheHit = p.executeCache.get(cacheKey); cacheHit {
+			return result, nil
+		}
+	}
+
 	// 通过OpenAPIClient发送HTTP请求
-	data, err := p.openAPIClient.Execute(ctx, executeReq)
+	callCtx, cancel := withCallTimeout(ctx, opts.Timeout)
+	defer cancel()
+	data, err := p.openAPIClient.Execute(callCtx, executeReq)
 	if err != nil {
 		return result, err
 	}
@@ -50,6 +84,9 @@ func (p *Provider) Execute(ctx context.Context, req *entity.ExecuteParam, option
 		result.FinishReason = data.FinishReason
 		result.Usage = toModelTokenUsage(data.Usage)
 	}
+	if cacheable {
+		p.executeCache.set(cacheKey, result)
+	}
 	// 转换响应
 	return result, nil
 }
@@ -62,27 +99,113 @@ func (p *Provider) ExecuteStreaming(ctx context.Context, req *entity.ExecutePara
 		option(opts)
 	}
 
+	var executeSpan *trace.Span
+	if p.config.PromptTrace && p.traceProvider != nil {
+		ctx, executeSpan = p.startExecuteSpan(ctx, req, true)
+	}
+
 	// 构建请求体
 	executeReq, err := buildExecuteRequest(req, p.config.WorkspaceID)
 	if err != nil {
+		finishExecuteSpan(ctx, executeSpan, entity.ExecuteResult{}, err)
 		return nil, err
 	}
 
 	// 通过OpenAPIClient发送流式HTTP请求
 	resp, err := p.openAPIClient.ExecuteStreaming(ctx, executeReq)
 	if err != nil {
+		finishExecuteSpan(ctx, executeSpan, entity.ExecuteResult{}, err)
 		return nil, err
 	}
 
 	// 创建新的流式读取器
 	streamReader, err := NewExecuteStreamReader(ctx, resp)
 	if err != nil {
+		finishExecuteSpan(ctx, executeSpan, entity.ExecuteResult{}, err)
 		return nil, err
 	}
 
+	// 开启trace时, 由读取器汇总流式输出, 并在流结束时结束span
+	if executeSpan != nil {
+		return newTracedExecuteStreamReader(ctx, streamReader, executeSpan), nil
+	}
 	return streamReader, nil
 }
 
+// startExecuteSpan 创建Execute的span, 并记录输入
+func (p *Provider) startExecuteSpan(ctx context.Context, req *entity.ExecuteParam, stream bool) (context.Context, *trace.Span) {
+	spanName, spanType, scene := consts.TracePromptExecuteSpanName, tracespec.VPromptExecuteSpanType, tracespec.VScenePromptExecute
+	if stream {
+		spanName, spanType, scene = consts.TracePromptExecuteStreamingSpanName, tracespec.VPromptExecuteStreamingSpanType, tracespec.VScenePromptExecuteStreaming
+	}
+	ctx, executeSpan, err := p.traceProvider.StartSpan(ctx, spanName, spanType, trace.StartSpanOptions{Scene: scene})
+	if err != nil {
+		logger.CtxWarnf(ctx, "start prompt execute span failed: %v", err)
+		return ctx, nil
+	}
+	if executeSpan == nil || req == nil {
+		return ctx, executeSpan
+	}
+	spanVariables, _ := executeVariables(req)
+	executeSpan.SetTags(ctx, map[string]any{
+		tracespec.PromptKey:     req.PromptKey,
+		tracespec.PromptVersion: req.Version,
+		tracespec.PromptLabel:   req.Label,
+		tracespec.Stream:        stream,
+		tracespec.Input: util.ToJSON(map[string]any{
+			tracespec.PromptKey:     req.PromptKey,
+			tracespec.PromptVersion: req.Version,
+			tracespec.PromptLabel:   req.Label,
+			"messages":              toSpanMessages(req.Messages),
+			"arguments":             toSpanArguments(spanVariables),
+		}),
+	})
+	return ctx, executeSpan
+}
+
+// finishExecuteSpan 记录Execute的输出、token用量和错误, 并结束span
+func finishExecuteSpan(ctx context.Context, executeSpan *trace.Span, result entity.ExecuteResult, err error) {
+	if executeSpan == nil {
+		return
+	}
+	if result.Message != nil || result.FinishReason != nil {
+		executeSpan.SetTags(ctx, map[string]any{
+			tracespec.Output: util.ToJSON(toSpanModelOutput(result)),
+		})
+	}
+	if result.Usage != nil {
+		executeSpan.SetInputTokens(ctx, result.Usage.InputTokens)
+		executeSpan.SetOutputTokens(ctx, result.Usage.OutputTokens)
+	}
+	if err != nil {
+		executeSpan.SetStatusCode(ctx, util.GetErrorCode(err))
+		executeSpan.SetError(ctx, err)
+	}
+	executeSpan.Finish(ctx)
+}
+
+// executeVariables 合并VariableVals和VariableStruct绑定的变量, 同一变量不能重复设置
+func executeVariables(param *entity.ExecuteParam) (map[string]any, error) {
+	if param.VariableStruct == nil {
+		return param.VariableVals, nil
+	}
+	bound, err := entity.BindVariables(param.VariableStruct)
+	if err != nil {
+		return nil, consts.ErrInvalidParam.Wrap(err)
+	}
+	variables := make(map[string]any, len(param.VariableVals)+len(bound))
+	for key, value := range param.VariableVals {
+		variables[key] = value
+	}
+	for key, value := range bound {
+		if _, ok := variables[key]; ok {
+			return nil, consts.ErrInvalidParam.Wrap(fmt.Errorf("variable: %s is set by both VariableVals and VariableStruct", key))
+		}
+		variables[key] = value
+	}
+	return variables, nil
+}
+
 // buildExecuteRequest 构建Execute请求体
 func buildExecuteRequest(param *entity.ExecuteParam, workspaceID string) (ExecuteRequest, error) {
 	if param == nil {
@@ -99,12 +222,18 @@ func buildExecuteRequest(param *entity.ExecuteParam, workspaceID string) (Execut
 			Version:   param.Version,
 			Label:     param.Label,
 		},
-		Messages: toOpenAPIMessages(param.Messages),
+		Messages:  toOpenAPIMessages(param.Messages),
+		LLMConfig: toOpenAPILLMConfig(param.LLMConfig),
+	}
+
+	variables, err := executeVariables(param)
+	if err != nil {
+		return ExecuteRequest{}, err
 	}
 
 	// 添加变量值
 	var variableVals []*VariableVal
-	for key, value := range param.VariableVals {
+	for key, value := range variables {
 		if value == nil {
 			return ExecuteRequest{}, consts.ErrInvalidParam.Wrap(fmt.Errorf("variable: %s val is nil", key))
 		}
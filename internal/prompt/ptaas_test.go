@@ -0,0 +1,113 @@
+// Copyright (c) 2025 Bytedance Ltd. and/or its affiliates
+// SPDX-License-Identifier: MIT
+
+package prompt
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+
+	. "github.com/bytedance/mockey"
+	. "github.com/smartystreets/goconvey/convey"
+
+	"github.com/coze-dev/cozeloop-go/entity"
+	"github.com/coze-dev/cozeloop-go/internal/httpclient"
+	"github.com/coze-dev/cozeloop-go/internal/trace"
+	"github.com/coze-dev/cozeloop-go/internal/util"
+	"github.com/coze-dev/cozeloop-go/spec/tracespec"
+)
+
+func TestExecuteTrace(t *testing.T) {
+	ctx := context.Background()
+	provider := NewPromptProvider(&httpclient.Client{}, &trace.Provider{}, Options{
+		WorkspaceID: "workspace1",
+		PromptTrace: true,
+	})
+	req := &entity.ExecuteParam{
+		PromptKey:    "key1",
+		Version:      "0.0.1",
+		VariableVals: map[string]any{"name": "loop"},
+		Messages:     []*entity.Message{{Role: entity.RoleUser, Content: util.Ptr("hi")}},
+	}
+
+	PatchConvey("Test Execute with trace", t, func() {
+		var spanName string
+		tags := map[string]any{}
+		finished := 0
+		Mock((*trace.Provider).StartSpan).To(func(p *trace.Provider, ctx context.Context, name, spanType string, opts trace.StartSpanOptions) (context.Context, *trace.Span, error) {
+			spanName = name
+			return ctx, &trace.Span{}, nil
+		}).Build()
+		Mock((*trace.Span).SetTags).To(func(s *trace.Span, ctx context.Context, tagKVs map[string]any) {
+			for k, v := range tagKVs {
+				tags[k] = v
+			}
+		}).Build()
+		Mock((*trace.Span).Finish).To(func(s *trace.Span, ctx context.Context) {
+			finished++
+		}).Build()
+
+		Convey("non-streaming result is tagged", func() {
+			Mock((*OpenAPIClient).Execute).Return(&ExecuteData{
+				Message:      &Message{Role: RoleAssistant, Content: util.Ptr("hello")},
+				FinishReason: util.Ptr("stop"),
+				Usage:        &TokenUsage{InputTokens: 3, OutputTokens: 5},
+			}, nil).Build()
+
+			result, err := provider.Execute(ctx, req)
+			So(err, ShouldBeNil)
+			So(util.PtrValue(result.Message.Content), ShouldEqual, "hello")
+			So(spanName, ShouldEqual, "PromptExecute")
+			So(finished, ShouldEqual, 1)
+			So(tags[tracespec.PromptKey], ShouldEqual, "key1")
+			So(tags[tracespec.Stream], ShouldEqual, false)
+			So(tags[tracespec.Input], ShouldContainSubstring, `"content":"hi"`)
+			So(tags[tracespec.Input], ShouldContainSubstring, `"key":"name"`)
+			So(tags[tracespec.Output], ShouldContainSubstring, `"finish_reason":"stop"`)
+			So(tags[tracespec.InputTokens], ShouldEqual, 3)
+			So(tags[tracespec.OutputTokens], ShouldEqual, 5)
+		})
+
+		Convey("error is tagged", func() {
+			Mock((*OpenAPIClient).Execute).Return(nil, errors.New("execute failed")).Build()
+
+			_, err := provider.Execute(ctx, req)
+			So(err, ShouldNotBeNil)
+			So(finished, ShouldEqual, 1)
+			So(tags[tracespec.Error], ShouldEqual, "execute failed")
+		})
+
+		Convey("streamed output is aggregated", func() {
+			body := "data: {\"message\":{\"role\":\"assistant\",\"content\":\"hel\"}}\n\n" +
+				"data: {\"message\":{\"role\":\"assistant\",\"content\":\"lo\"},\"finish_reason\":\"stop\",\"usage\":{\"input_tokens\":3,\"output_tokens\":2}}\n\n"
+			Mock((*OpenAPIClient).ExecuteStreaming).Return(&http.Response{
+				Header: http.Header{},
+				Body:   io.NopCloser(strings.NewReader(body)),
+			}, nil).Build()
+
+			reader, err := provider.ExecuteStreaming(ctx, req)
+			So(err, ShouldBeNil)
+			for {
+				_, err = reader.Recv()
+				if err != nil {
+					break
+				}
+			}
+			So(err, ShouldEqual, io.EOF)
+			So(spanName, ShouldEqual, "PromptExecuteStreaming")
+			So(finished, ShouldEqual, 1)
+			So(tags[tracespec.Stream], ShouldEqual, true)
+			So(tags[tracespec.Output], ShouldContainSubstring, `"content":"hello"`)
+			So(tags[tracespec.Output], ShouldContainSubstring, `"finish_reason":"stop"`)
+			So(tags[tracespec.OutputTokens], ShouldEqual, 2)
+
+			// span is finished only once
+			So(reader.(io.Closer).Close(), ShouldBeNil)
+			So(finished, ShouldEqual, 1)
+		})
+	})
+}
@@ -0,0 +1,66 @@
+// Copyright (c) 2025 Bytedance Ltd. and/or its affiliates
+// SPDX-License-Identifier: MIT
+
+package prompt
+
+import (
+	"sync/atomic"
+)
+
+// Stats statistics of getting prompts, counts are accumulated since the provider created.
+type Stats struct {
+	// CacheHits prompts returned from cache, including the ones cached as not found
+	CacheHits int64
+	// CacheMisses prompts fetched from server because they are not in cache
+	CacheMisses int64
+	// RefreshSucceeded background refreshes of cached prompts which succeeded
+	RefreshSucceeded int64
+	// RefreshFailed background refreshes of cached prompts which failed, the stale prompts are kept
+	RefreshFailed int64
+	// RefreshConsecutiveFailures background refreshes failed since the last one succeeded. The periodic refresh of
+	// cache backs off exponentially while it keeps failing, so a growing value means the prompts are getting stale.
+	RefreshConsecutiveFailures int64
+}
+
+// promptStats counts the events of getting prompts, updated atomically.
+type promptStats struct {
+	cacheHits        int64
+	cacheMisses      int64
+	refreshSucceeded int64
+	refreshFailed    int64
+	// consecutiveFailures is a gauge, which is reset by a successful refresh
+	consecutiveFailures int64
+}
+
+func (s *promptStats) add(addr *int64) {
+	if s != nil {
+		atomic.AddInt64(addr, 1)
+	}
+}
+
+// refreshed counts a background refresh by its error.
+func (s *promptStats) refreshed(err error) {
+	if s == nil {
+		return
+	}
+	if err != nil {
+		s.add(&s.refreshFailed)
+		s.add(&s.consecutiveFailures)
+	} else {
+		s.add(&s.refreshSucceeded)
+		atomic.StoreInt64(&s.consecutiveFailures, 0)
+	}
+}
+
+func (s *promptStats) snapshot() Stats {
+	if s == nil {
+		return Stats{}
+	}
+	return Stats{
+		CacheHits:                  atomic.LoadInt64(&s.cacheHits),
+		CacheMisses:                atomic.LoadInt64(&s.cacheMisses),
+		RefreshSucceeded:           atomic.LoadInt64(&s.refreshSucceeded),
+		RefreshFailed:              atomic.LoadInt64(&s.refreshFailed),
+		RefreshConsecutiveFailures: atomic.LoadInt64(&s.consecutiveFailures),
+	}
+}
@@ -0,0 +1,54 @@
+// Copyright (c) 2025 Bytedance Ltd. and/or its affiliates
+// SPDX-License-Identifier: MIT
+
+package prompt
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	. "github.com/bytedance/mockey"
+	. "github.com/smartystreets/goconvey/convey"
+
+	"github.com/coze-dev/cozeloop-go/internal/httpclient"
+)
+
+func TestProviderStats(t *testing.T) {
+	ctx := context.Background()
+	PatchConvey("Test stats of cache hits, misses and refreshes", t, func() {
+		result := &PromptResult{
+			Query:  PromptQuery{PromptKey: "key1"},
+			Prompt: &Prompt{WorkspaceID: "workspace1", PromptKey: "key1", Version: "1.0"},
+		}
+		var mpullErr error
+		mockMPull := Mock((*OpenAPIClient).MPullPrompt).To(func(_ *OpenAPIClient, ctx context.Context, req MPullPromptRequest) ([]*PromptResult, error) {
+			return []*PromptResult{result}, mpullErr
+		}).Build()
+		provider := NewPromptProvider(&httpclient.Client{}, nil, Options{WorkspaceID: "workspace1"})
+		So(provider.Stats(), ShouldResemble, Stats{})
+
+		for i := 0; i < 3; i++ {
+			prompt, err := provider.GetPrompt(ctx, GetPromptParam{PromptKey: "key1"}, GetPromptOptions{})
+			So(err, ShouldBeNil)
+			So(prompt.Version, ShouldEqual, "1.0")
+		}
+		So(mockMPull.Times(), ShouldEqual, 1)
+
+		provider.cache.updateAllPrompts()
+		mpullErr = errors.New("unavailable")
+		provider.cache.updateAllPrompts()
+		So(provider.Stats(), ShouldResemble, Stats{
+			CacheHits:                  2,
+			CacheMisses:                1,
+			RefreshSucceeded:           1,
+			RefreshFailed:              1,
+			RefreshConsecutiveFailures: 1,
+		})
+		provider.cache.updateAllPrompts()
+		So(provider.Stats().RefreshConsecutiveFailures, ShouldEqual, 2)
+		mpullErr = nil
+		provider.cache.updateAllPrompts()
+		So(provider.Stats().RefreshConsecutiveFailures, ShouldEqual, 0)
+	})
+}
@@ -0,0 +1,220 @@
+// Copyright (c) 2025 Bytedance Ltd. and/or its affiliates
+// SPDX-License-Identifier: MIT
+
+package prompt
+
+import (
+	"context"
+	"errors"
+	"io"
+	"math/rand"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/coze-dev/cozeloop-go/internal/consts"
+	"github.com/coze-dev/cozeloop-go/internal/logger"
+	"github.com/coze-dev/cozeloop-go/internal/util"
+	"github.com/coze-dev/cozeloop-go/sse"
+)
+
+const (
+	subscribePromptPath = "/v1/loop/prompts/subscribe"
+	// promptUpdatedEvent the event of subscription stream sent when a prompt is published or its label is moved,
+	// the other events, such as keep-alive, are ignored
+	promptUpdatedEvent = "prompt_updated"
+	// subscribeStreamDuration how long a subscription stream is kept before it is reconnected, so that the streams
+	// broken silently by proxies are not kept forever
+	subscribeStreamDuration = 5 * time.Minute
+	minSubscribeBackoff     = time.Second
+	maxSubscribeBackoff     = time.Minute
+)
+
+type SubscribePromptRequest struct {
+	WorkspaceID string `json:"workspace_id"`
+	// LastEventID the id of the last event received, so the events sent during reconnection are replayed
+	LastEventID string `json:"last_event_id,omitempty"`
+}
+
+// PromptUpdatedEvent the data of prompt_updated event. All cached prompts are updated if PromptKey is empty.
+type PromptUpdatedEvent struct {
+	PromptKey string `json:"prompt_key"`
+	Version   string `json:"version,omitempty"`
+	Label     string `json:"label,omitempty"`
+}
+
+// SubscribePrompt opens the stream of prompt change notifications of the workspace, the events are Server-Sent
+// Events. The stream is closed when ctx is done.
+func (o *OpenAPIClient) SubscribePrompt(ctx context.Context, req SubscribePromptRequest) (*http.Response, error) {
+	return o.httpClient.PostStream(ctx, subscribePromptPath, req)
+}
+
+// isSubscriptionUnsupported returns whether err means the server does not provide the subscription endpoint.
+func isSubscriptionUnsupported(err error) bool {
+	remoteErr := &consts.RemoteServiceError{}
+	if !errors.As(err, &remoteErr) {
+		return false
+	}
+	switch remoteErr.HttpCode {
+	case http.StatusNotFound, http.StatusMethodNotAllowed, http.StatusNotImplemented:
+		return true
+	}
+	return false
+}
+
+// promptSubscription subscribes the prompt changes of a workspace, and notifies all the caches of the workspace, so
+// that there is only one stream per workspace however many field masks are cached.
+type promptSubscription struct {
+	workspaceID string
+	openAPI     *OpenAPIClient
+	lock        sync.Mutex
+	caches      map[*PromptCache]struct{}
+	stopOnce    sync.Once
+	stopChan    chan struct{}
+}
+
+func newPromptSubscription(workspaceID string, openAPI *OpenAPIClient) *promptSubscription {
+	return &promptSubscription{
+		workspaceID: workspaceID,
+		openAPI:     openAPI,
+		caches:      make(map[*PromptCache]struct{}),
+		stopChan:    make(chan struct{}),
+	}
+}
+
+// start starts the subscription in background.
+func (s *promptSubscription) start() {
+	util.GoSafe(context.Background(), s.run)
+}
+
+// stop closes the stream, it can be called more than once.
+func (s *promptSubscription) stop() {
+	s.stopOnce.Do(func() {
+		close(s.stopChan)
+	})
+}
+
+func (s *promptSubscription) add(cache *PromptCache) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.caches[cache] = struct{}{}
+}
+
+// remove removes the cache, and returns whether there is no cache left.
+func (s *promptSubscription) remove(cache *PromptCache) bool {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	delete(s.caches, cache)
+	return len(s.caches) == 0
+}
+
+// notify pulls the prompts changed in all the caches of workspace.
+func (s *promptSubscription) notify(promptKey string) {
+	s.lock.Lock()
+	caches := make([]*PromptCache, 0, len(s.caches))
+	for cache := range s.caches {
+		caches = append(caches, cache)
+	}
+	s.lock.Unlock()
+	for _, cache := range caches {
+		cache.updateChangedPrompts(promptKey)
+	}
+}
+
+// run keeps subscribing the prompt changes of the workspace until stopped, and pulls the cached prompts changed as
+// soon as they are notified, instead of waiting for the next async update. The broken streams are reconnected with
+// backoff. It stops if the server does not support subscription, and async updates keep the caches updated anyway.
+func (s *promptSubscription) run() {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	util.GoSafe(ctx, func() {
+		select {
+		case <-s.stopChan:
+			cancel()
+		case <-ctx.Done():
+		}
+	})
+
+	var lastEventID string
+	backoff := minSubscribeBackoff
+	for {
+		received, err := s.subscribe(ctx, &lastEventID)
+		if ctx.Err() != nil {
+			return
+		}
+		if isSubscriptionUnsupported(err) {
+			logger.CtxInfof(ctx, "prompt subscription is not supported by server, prompts are updated by polling")
+			return
+		}
+		if received {
+			backoff = minSubscribeBackoff
+		}
+		if err == nil {
+			// the stream ends normally, reconnect at once
+			continue
+		}
+		logger.CtxWarnf(ctx, "prompt subscription broken, reconnect in %v: %v", backoff, err)
+		delay := backoff + time.Duration(rand.Int63n(int64(backoff)/2+1))
+		select {
+		case <-time.After(delay):
+		case <-ctx.Done():
+			return
+		}
+		if backoff *= 2; backoff > maxSubscribeBackoff {
+			backoff = maxSubscribeBackoff
+		}
+	}
+}
+
+// subscribe reads one subscription stream until it ends, lastEventID is updated by the events received. It returns
+// whether any event is received, and nil error if the stream ends normally.
+func (s *promptSubscription) subscribe(ctx context.Context, lastEventID *string) (received bool, err error) {
+	streamCtx, cancel := context.WithTimeout(ctx, subscribeStreamDuration)
+	defer cancel()
+	resp, err := s.openAPI.SubscribePrompt(streamCtx, SubscribePromptRequest{
+		WorkspaceID: s.workspaceID,
+		LastEventID: *lastEventID,
+	})
+	if err != nil {
+		return false, err
+	}
+	defer resp.Body.Close()
+
+	for result := range sse.NewDecoder(resp.Body).Decode(streamCtx) {
+		if result.Err != nil {
+			if errors.Is(result.Err, io.EOF) || streamCtx.Err() != nil {
+				return received, nil
+			}
+			return received, result.Err
+		}
+		received = true
+		*lastEventID = result.Event.ID
+		if result.Event.Event != promptUpdatedEvent {
+			continue
+		}
+		var event PromptUpdatedEvent
+		if err := result.Event.JSON(&event); err != nil {
+			logger.CtxWarnf(ctx, "invalid prompt updated event: %v", err)
+			continue
+		}
+		s.notify(event.PromptKey)
+	}
+	return received, nil
+}
+
+// updateChangedPrompts pulls the cached prompts of promptKey, or all cached prompts if promptKey is empty. All the
+// versions and labels of the prompt are pulled, as the prompt without version is changed by a new version, and the
+// prompts of labels are changed when labels are moved.
+func (c *PromptCache) updateChangedPrompts(promptKey string) {
+	queries := c.GetAllPromptQueries()
+	if promptKey != "" {
+		changed := queries[:0]
+		for _, query := range queries {
+			if query.PromptKey == promptKey {
+				changed = append(changed, query)
+			}
+		}
+		queries = changed
+	}
+	_ = c.updatePrompts(queries)
+}
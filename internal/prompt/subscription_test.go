@@ -0,0 +1,169 @@
+// Copyright (c) 2025 Bytedance Ltd. and/or its affiliates
+// SPDX-License-Identifier: MIT
+
+package prompt
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	. "github.com/bytedance/mockey"
+	. "github.com/smartystreets/goconvey/convey"
+
+	"github.com/coze-dev/cozeloop-go/entity"
+	"github.com/coze-dev/cozeloop-go/internal/httpclient"
+)
+
+func TestPromptSubscription(t *testing.T) {
+	newOpenAPI := func(url string) *OpenAPIClient {
+		return &OpenAPIClient{httpClient: httpclient.NewClient(url, http.DefaultClient, httpclient.NewTokenAuth("token"), nil)}
+	}
+	waitFor := func(cond func() bool) bool {
+		deadline := time.Now().Add(3 * time.Second)
+		for time.Now().Before(deadline) {
+			if cond() {
+				return true
+			}
+			time.Sleep(10 * time.Millisecond)
+		}
+		return false
+	}
+
+	Convey("Test cached prompts are updated when they are notified", t, func() {
+		var mu sync.Mutex
+		var pulled [][]PromptQuery
+		Mock((*OpenAPIClient).MPullPrompt).To(func(ctx context.Context, req MPullPromptRequest) ([]*PromptResult, error) {
+			mu.Lock()
+			pulled = append(pulled, req.Queries)
+			mu.Unlock()
+			results := make([]*PromptResult, 0, len(req.Queries))
+			for _, query := range req.Queries {
+				results = append(results, &PromptResult{Query: query, Prompt: &Prompt{PromptKey: query.PromptKey, Version: "2.0"}})
+			}
+			return results, nil
+		}).Build()
+		defer UnPatchAll()
+
+		notify := make(chan string, 1)
+		var subscribeReq SubscribePromptRequest
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			_ = json.NewDecoder(r.Body).Decode(&subscribeReq)
+			w.Header().Set("Content-Type", "text/event-stream")
+			_, _ = fmt.Fprint(w, ": keep-alive\n\n")
+			w.(http.Flusher).Flush()
+			for {
+				select {
+				case key := <-notify:
+					_, _ = fmt.Fprintf(w, "id: 1\nevent: prompt_updated\ndata: {\"prompt_key\":%q}\n\n", key)
+					w.(http.Flusher).Flush()
+				case <-r.Context().Done():
+					return
+				}
+			}
+		}))
+		defer server.Close()
+
+		cache := newPromptCache("workspace1", newOpenAPI(server.URL), withUpdateInterval(time.Hour))
+		cache.Set("key1", "", "", &entity.Prompt{PromptKey: "key1", Version: "1.0"})
+		cache.Set("key1", "", "production", &entity.Prompt{PromptKey: "key1", Version: "1.0"})
+		cache.Set("key2", "", "", &entity.Prompt{PromptKey: "key2", Version: "1.0"})
+		subscription := newPromptSubscription("workspace1", newOpenAPI(server.URL))
+		subscription.add(cache)
+		subscription.start()
+		defer subscription.stop()
+
+		notify <- "key1"
+		So(waitFor(func() bool {
+			prompt, _ := cache.Get("key1", "", "production")
+			return prompt.Version == "2.0"
+		}), ShouldBeTrue)
+		prompt, _ := cache.Get("key1", "", "")
+		So(prompt.Version, ShouldEqual, "2.0")
+		prompt, _ = cache.Get("key2", "", "")
+		So(prompt.Version, ShouldEqual, "1.0")
+		mu.Lock()
+		defer mu.Unlock()
+		So(len(pulled), ShouldEqual, 1)
+		So(len(pulled[0]), ShouldEqual, 2)
+		So(subscribeReq.WorkspaceID, ShouldEqual, "workspace1")
+	})
+
+	Convey("Test broken streams are reconnected with the last event id", t, func() {
+		Mock((*OpenAPIClient).MPullPrompt).Return(nil, nil).Build()
+		defer UnPatchAll()
+
+		var mu sync.Mutex
+		var lastEventIDs []string
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			var req SubscribePromptRequest
+			_ = json.NewDecoder(r.Body).Decode(&req)
+			mu.Lock()
+			lastEventIDs = append(lastEventIDs, req.LastEventID)
+			mu.Unlock()
+			_, _ = fmt.Fprint(w, "id: 7\nevent: prompt_updated\ndata: {\"prompt_key\":\"key1\"}\n\n")
+		}))
+		defer server.Close()
+
+		subscription := newPromptSubscription("workspace1", newOpenAPI(server.URL))
+		subscription.start()
+		defer subscription.stop()
+		So(waitFor(func() bool {
+			mu.Lock()
+			defer mu.Unlock()
+			return len(lastEventIDs) >= 2
+		}), ShouldBeTrue)
+		mu.Lock()
+		defer mu.Unlock()
+		So(lastEventIDs[:2], ShouldResemble, []string{"", "7"})
+	})
+
+	Convey("Test subscription stops if it is not supported", t, func() {
+		var requests int32
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			atomic.AddInt32(&requests, 1)
+			w.WriteHeader(http.StatusNotFound)
+		}))
+		defer server.Close()
+
+		subscription := newPromptSubscription("workspace1", newOpenAPI(server.URL))
+		subscription.start()
+		defer subscription.stop()
+		So(waitFor(func() bool { return atomic.LoadInt32(&requests) > 0 }), ShouldBeTrue)
+		time.Sleep(100 * time.Millisecond)
+		So(atomic.LoadInt32(&requests), ShouldEqual, 1)
+	})
+
+	Convey("Test caches of a workspace share one subscription", t, func() {
+		var streams int32
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			atomic.AddInt32(&streams, 1)
+			w.Header().Set("Content-Type", "text/event-stream")
+			w.(http.Flusher).Flush()
+			<-r.Context().Done()
+			atomic.AddInt32(&streams, -1)
+		}))
+		defer server.Close()
+
+		provider := NewPromptProvider(httpclient.NewClient(server.URL, http.DefaultClient, httpclient.NewTokenAuth("token"), nil),
+			nil, Options{WorkspaceID: "workspace1", PromptSubscription: true})
+		defer provider.stopCache(provider.cache)
+		masked := provider.getCache("", &FieldMask{Include: []string{PromptFieldPromptTemplate}})
+		other := provider.getCache("workspace2", nil)
+		So(waitFor(func() bool { return atomic.LoadInt32(&streams) == 2 }), ShouldBeTrue)
+		So(len(provider.subscriptions), ShouldEqual, 2)
+		So(len(provider.subscriptions["workspace1"].caches), ShouldEqual, 2)
+
+		// the subscription is stopped with the last cache of workspace
+		provider.stopCache(masked)
+		provider.stopCache(other)
+		So(waitFor(func() bool { return atomic.LoadInt32(&streams) == 1 }), ShouldBeTrue)
+		So(len(provider.subscriptions), ShouldEqual, 1)
+	})
+}
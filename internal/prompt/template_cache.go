@@ -0,0 +1,216 @@
+// Copyright (c) 2025 Bytedance Ltd. and/or its affiliates
+// SPDX-License-Identifier: MIT
+
+package prompt
+
+import (
+	"fmt"
+	"io"
+	"sync"
+
+	"github.com/bluele/gcache"
+	"github.com/nikolalohinski/gonja/v2/exec"
+	"github.com/valyala/fasttemplate"
+
+	"github.com/coze-dev/cozeloop-go/entity"
+	"github.com/coze-dev/cozeloop-go/internal/consts"
+	"github.com/coze-dev/cozeloop-go/internal/util"
+)
+
+const defaultTemplateCacheSize = 1000
+
+// Jinja2Conf conf of parsing and rendering jinja2 templates, such as whitespace control and limits of render.
+type Jinja2Conf = util.Jinja2Options
+
+// templateEnv the custom funcs and jinja2 environment used to compile and render templates.
+type templateEnv struct {
+	funcs  map[string]any
+	jinja2 *util.Jinja2Env
+	// partial keeps the tags of defined variables without value and records them, only set by PromptFormatPartial
+	partial *partialFormat
+}
+
+func newTemplateEnv(funcs map[string]any, jinja2 *Jinja2Conf) *templateEnv {
+	return &templateEnv{funcs: funcs, jinja2: util.NewJinja2Env(funcs, jinja2)}
+}
+
+func (e *templateEnv) getFuncs() map[string]any {
+	if e == nil {
+		return nil
+	}
+	return e.funcs
+}
+
+// withPartial returns a copy of env which renders templates partially.
+func (e *templateEnv) withPartial(partial *partialFormat) *templateEnv {
+	copied := &templateEnv{}
+	if e != nil {
+		*copied = *e
+	}
+	copied.partial = partial
+	return copied
+}
+
+func (e *templateEnv) getPartial() *partialFormat {
+	if e == nil {
+		return nil
+	}
+	return e.partial
+}
+
+func (e *templateEnv) getJinja2() *util.Jinja2Env {
+	if e == nil || e.jinja2 == nil {
+		return defaultTemplateEnv.jinja2
+	}
+	return e.jinja2
+}
+
+// defaultTemplateEnv the env without custom funcs and with default jinja2 conf.
+var defaultTemplateEnv = newTemplateEnv(nil, nil)
+
+// compiledTemplate a parsed template of message content or text part, which can be rendered concurrently.
+type compiledTemplate struct {
+	templateType entity.TemplateType
+	source       string
+	// normal is nil if the template has unclosed tag, which is rendered as it is
+	normal *fasttemplate.Template
+	jinja2 *exec.Template
+}
+
+func compileTemplate(templateType entity.TemplateType, templateStr string, env *templateEnv) (*compiledTemplate, error) {
+	switch templateType {
+	case entity.TemplateTypeNormal:
+		tpl, _ := fasttemplate.NewTemplate(templateStr, consts.PromptNormalTemplateStartTag, consts.PromptNormalTemplateEndTag)
+		return &compiledTemplate{templateType: templateType, source: templateStr, normal: tpl}, nil
+	case entity.TemplateTypeJinja2:
+		tpl, err := env.getJinja2().Parse(templateStr)
+		if err != nil {
+			return nil, err
+		}
+		return &compiledTemplate{templateType: templateType, source: templateStr, jinja2: tpl}, nil
+	default:
+		return nil, consts.ErrInternal.Wrap(fmt.Errorf("unknown template type: %s", templateType))
+	}
+}
+
+func (t *compiledTemplate) render(variableDefMap map[string]*entity.VariableDef, variableVals map[string]any, env *templateEnv) (string, error) {
+	if t.jinja2 != nil {
+		return env.getJinja2().Execute(t.jinja2, variableVals)
+	}
+	funcs := env.getFuncs()
+	var renderErr error
+	tagFunc := func(w io.Writer, tag string) (int, error) {
+		key, funcNames := tag, []string(nil)
+		if len(funcs) > 0 {
+			key, funcNames = parseNormalTag(tag)
+		}
+		// If not in variable definition, don't replace and return directly
+		if variableDefMap[key] == nil {
+			return w.Write([]byte(consts.PromptNormalTemplateStartTag + tag + consts.PromptNormalTemplateEndTag))
+		}
+		// Otherwise replace
+		val, ok := variableVals[key]
+		if !ok {
+			if partial := env.getPartial(); partial != nil {
+				partial.addVariable(key)
+				return w.Write([]byte(consts.PromptNormalTemplateStartTag + tag + consts.PromptNormalTemplateEndTag))
+			}
+			return 0, nil
+		}
+		// Apply the custom funcs in pipeline, such as {{name|trim|upper}}
+		for _, funcName := range funcNames {
+			fn, ok := funcs[funcName]
+			if !ok {
+				renderErr = consts.ErrTemplateRender.Wrap(fmt.Errorf("template func '%s' is not registered", funcName))
+				return 0, nil
+			}
+			var err error
+			if val, err = util.CallTemplateFunc(funcName, fn, val); err != nil {
+				renderErr = err
+				return 0, nil
+			}
+		}
+		return w.Write([]byte(fmt.Sprint(val)))
+	}
+	var result string
+	if t.normal != nil {
+		result = t.normal.ExecuteFuncString(tagFunc)
+	} else {
+		result = fasttemplate.ExecuteFuncString(t.source, consts.PromptNormalTemplateStartTag, consts.PromptNormalTemplateEndTag, tagFunc)
+	}
+	if renderErr != nil {
+		return "", renderErr
+	}
+	return result, nil
+}
+
+// templateCache caches the compiled templates of prompt versions, keyed by prompt key, version and the position
+// of template in messages. The templates of a version are invalidated when the version is refreshed by prompt cache.
+type templateCache struct {
+	cache gcache.Cache // workspace id + prompt key + version -> *versionTemplates
+}
+
+// versionTemplates the compiled templates of a prompt version.
+type versionTemplates struct {
+	templates sync.Map // templatePosition -> *compiledTemplate
+}
+
+// templatePosition position of template in messages, part is -1 for message content.
+type templatePosition struct {
+	message int
+	part    int
+}
+
+func newTemplateCache(size int) *templateCache {
+	if size <= 0 {
+		size = defaultTemplateCacheSize
+	}
+	return &templateCache{cache: gcache.New(size).LRU().Build()}
+}
+
+func templateVersionKey(workspaceID, promptKey, version string) string {
+	return workspaceID + "\t" + promptKey + "\t" + version
+}
+
+// scope returns the compiled templates of prompt, or nil if the prompt is not a version pulled from server.
+func (c *templateCache) scope(prompt *entity.Prompt) *versionTemplates {
+	if c == nil || prompt == nil || prompt.PromptKey == "" || prompt.Version == "" {
+		return nil
+	}
+	key := templateVersionKey(prompt.WorkspaceID, prompt.PromptKey, prompt.Version)
+	if value, err := c.cache.Get(key); err == nil {
+		return value.(*versionTemplates)
+	}
+	templates := &versionTemplates{}
+	_ = c.cache.Set(key, templates)
+	return templates
+}
+
+// invalidate drops the compiled templates of the prompt version.
+func (c *templateCache) invalidate(prompt *entity.Prompt) {
+	if c == nil || prompt == nil {
+		return
+	}
+	c.cache.Remove(templateVersionKey(prompt.WorkspaceID, prompt.PromptKey, prompt.Version))
+}
+
+// render renders the template at position with the compiled one, the template is compiled and cached on first use,
+// or recompiled if it is different from the cached one, e.g. the prompt is modified by user.
+func (v *versionTemplates) render(position templatePosition, templateType entity.TemplateType, templateStr string,
+	variableDefMap map[string]*entity.VariableDef, variableVals map[string]any, env *templateEnv,
+) (string, error) {
+	if v == nil {
+		return renderTextContent(templateType, templateStr, variableDefMap, variableVals, env)
+	}
+	if value, ok := v.templates.Load(position); ok {
+		if tpl := value.(*compiledTemplate); tpl.source == templateStr && tpl.templateType == templateType {
+			return tpl.render(variableDefMap, variableVals, env)
+		}
+	}
+	tpl, err := compileTemplate(templateType, templateStr, env)
+	if err != nil {
+		return "", err
+	}
+	v.templates.Store(position, tpl)
+	return tpl.render(variableDefMap, variableVals, env)
+}
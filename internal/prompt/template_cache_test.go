@@ -0,0 +1,108 @@
+// Copyright (c) 2025 Bytedance Ltd. and/or its affiliates
+// SPDX-License-Identifier: MIT
+
+package prompt
+
+import (
+	"context"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+
+	"github.com/coze-dev/cozeloop-go/entity"
+	"github.com/coze-dev/cozeloop-go/internal/httpclient"
+	"github.com/coze-dev/cozeloop-go/internal/util"
+)
+
+func TestTemplateCache(t *testing.T) {
+	ctx := context.Background()
+	Convey("Test compiled templates are cached per prompt version", t, func() {
+		provider := NewPromptProvider(&httpclient.Client{}, nil, Options{WorkspaceID: "workspace1"})
+		prompt := newFormatCacheTestPrompt()
+		prompt.PromptTemplate.TemplateType = entity.TemplateTypeJinja2
+		prompt.PromptTemplate.Messages[1].Parts = []*entity.ContentPart{
+			{Type: entity.ContentTypeText, Text: util.Ptr("Level {{ level }}")},
+		}
+		variables := map[string]any{"product": "CozeLoop", "language": "English", "level": 1}
+		compiled := func(position templatePosition) *compiledTemplate {
+			value, ok := provider.templateCache.scope(prompt).templates.Load(position)
+			if !ok {
+				return nil
+			}
+			return value.(*compiledTemplate)
+		}
+
+		messages, err := provider.PromptFormat(ctx, prompt, variables, PromptFormatOptions{})
+		So(err, ShouldBeNil)
+		So(*messages[0].Content, ShouldEqual, "You are a helpful assistant of CozeLoop, answer in English.")
+		So(*messages[1].Parts[0].Text, ShouldEqual, "Level 1")
+		content := compiled(templatePosition{message: 0, part: -1})
+		So(content, ShouldNotBeNil)
+		So(compiled(templatePosition{message: 1, part: 0}), ShouldNotBeNil)
+
+		Convey("The compiled template is reused by the next format", func() {
+			messages, err = provider.PromptFormat(ctx, prompt, map[string]any{"product": "CozeLoop", "language": "Chinese", "level": 2}, PromptFormatOptions{})
+			So(err, ShouldBeNil)
+			So(*messages[0].Content, ShouldEqual, "You are a helpful assistant of CozeLoop, answer in Chinese.")
+			So(*messages[1].Parts[0].Text, ShouldEqual, "Level 2")
+			So(compiled(templatePosition{message: 0, part: -1}), ShouldEqual, content)
+		})
+
+		Convey("The template is recompiled if it is modified", func() {
+			prompt.PromptTemplate.Messages[0].Content = util.Ptr("Hi {{ product }}")
+			messages, err = provider.PromptFormat(ctx, prompt, variables, PromptFormatOptions{})
+			So(err, ShouldBeNil)
+			So(*messages[0].Content, ShouldEqual, "Hi CozeLoop")
+			So(compiled(templatePosition{message: 0, part: -1}), ShouldNotEqual, content)
+		})
+
+		Convey("The templates are invalidated when the prompt is refreshed", func() {
+			provider.cache.Set(prompt.PromptKey, prompt.Version, "", prompt)
+			So(compiled(templatePosition{message: 0, part: -1}), ShouldBeNil)
+		})
+
+		Convey("Local prompts without version are not cached", func() {
+			local := newFormatCacheTestPrompt()
+			local.Version = ""
+			So(provider.templateCache.scope(local), ShouldBeNil)
+			messages, err = provider.PromptFormat(ctx, local, variables, PromptFormatOptions{})
+			So(err, ShouldBeNil)
+			So(*messages[0].Content, ShouldEqual, "You are a helpful assistant of CozeLoop, answer in English.")
+		})
+	})
+
+	Convey("Test normal template with unclosed tag is rendered as it is", t, func() {
+		templates := &versionTemplates{}
+		variableDefMap := map[string]*entity.VariableDef{"name": {Key: "name", Type: entity.VariableTypeString}}
+		result, err := templates.render(templatePosition{}, entity.TemplateTypeNormal, "Hello {{name}}, {{name", variableDefMap, map[string]any{"name": "Tom"}, nil)
+		So(err, ShouldBeNil)
+		So(result, ShouldEqual, "Hello Tom, {{name")
+	})
+}
+
+func TestJinja2Conf(t *testing.T) {
+	ctx := context.Background()
+	Convey("Test jinja2 conf of provider is used to render templates", t, func() {
+		provider := NewPromptProvider(&httpclient.Client{}, nil, Options{
+			WorkspaceID: "workspace1",
+			Jinja2:      &Jinja2Conf{TrimBlocks: true, MaxLoopIterations: 5},
+		})
+		prompt := &entity.Prompt{
+			PromptKey: "key1",
+			Version:   "0.0.1",
+			PromptTemplate: &entity.PromptTemplate{
+				TemplateType: entity.TemplateTypeJinja2,
+				Messages: []*entity.Message{
+					{Role: entity.RoleSystem, Content: util.Ptr("{% for i in range(n) %}\n{{ i }}{% endfor %}")},
+				},
+				VariableDefs: []*entity.VariableDef{{Key: "n", Type: entity.VariableTypeInteger}},
+			},
+		}
+		messages, err := provider.PromptFormat(ctx, prompt, map[string]any{"n": 3}, PromptFormatOptions{})
+		So(err, ShouldBeNil)
+		So(*messages[0].Content, ShouldEqual, "012")
+
+		_, err = provider.PromptFormat(ctx, prompt, map[string]any{"n": 6}, PromptFormatOptions{})
+		So(err, ShouldNotBeNil)
+	})
+}
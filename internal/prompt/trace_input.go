@@ -0,0 +1,104 @@
+// Copyright (c) 2025 Bytedance Ltd. and/or its affiliates
+// SPDX-License-Identifier: MIT
+
+package prompt
+
+import (
+	"unicode/utf8"
+
+	"github.com/coze-dev/cozeloop-go/entity"
+	"github.com/coze-dev/cozeloop-go/spec/tracespec"
+)
+
+const (
+	defaultTraceMaxArgumentSize        = 16 * 1024
+	defaultTraceMaxPlaceholderMessages = 50
+
+	truncatedSuffix = "...[truncated]"
+)
+
+// TraceInputConf conf of the variables recorded in the input of prompt template span, so that big variables, such
+// as long chat histories, don't make the input exceed the tag size limit and be cut in the middle.
+type TraceInputConf struct {
+	// MaxArgumentSize max bytes of a text variable, or the text of a message or part in variable, the longer ones
+	// are truncated. Default is 16KB, no limit if it is negative.
+	MaxArgumentSize int
+	// MaxPlaceholderMessages max count of messages of a placeholder variable, only the latest ones are recorded.
+	// Default is 50, no limit if it is negative.
+	MaxPlaceholderMessages int
+}
+
+func (c *TraceInputConf) withDefaults() TraceInputConf {
+	var conf TraceInputConf
+	if c != nil {
+		conf = *c
+	}
+	if conf.MaxArgumentSize == 0 {
+		conf.MaxArgumentSize = defaultTraceMaxArgumentSize
+	}
+	if conf.MaxPlaceholderMessages == 0 {
+		conf.MaxPlaceholderMessages = defaultTraceMaxPlaceholderMessages
+	}
+	return conf
+}
+
+// toTracedPromptInput converts the input of prompt template span, the excluded variables are not recorded and
+// the others are truncated by conf.
+func toTracedPromptInput(messages []*entity.Message, variables map[string]any, conf TraceInputConf, excluded []string) *tracespec.PromptInput {
+	if len(excluded) > 0 {
+		traced := make(map[string]any, len(variables))
+		for key, value := range variables {
+			traced[key] = value
+		}
+		for _, key := range excluded {
+			delete(traced, key)
+		}
+		variables = traced
+	}
+	input := toSpanPromptInput(messages, variables)
+	for _, argument := range input.Arguments {
+		truncateSpanArgument(argument, conf)
+	}
+	return input
+}
+
+func truncateSpanArgument(argument *tracespec.PromptArgument, conf TraceInputConf) {
+	switch value := argument.Value.(type) {
+	case string:
+		argument.Value = truncateText(value, conf.MaxArgumentSize)
+	case []*tracespec.ModelMessagePart:
+		truncateSpanParts(value, conf.MaxArgumentSize)
+	case []*tracespec.ModelMessage:
+		if conf.MaxPlaceholderMessages > 0 && len(value) > conf.MaxPlaceholderMessages {
+			value = value[len(value)-conf.MaxPlaceholderMessages:]
+		}
+		for _, message := range value {
+			if message == nil {
+				continue
+			}
+			message.Content = truncateText(message.Content, conf.MaxArgumentSize)
+			message.ReasoningContent = truncateText(message.ReasoningContent, conf.MaxArgumentSize)
+			truncateSpanParts(message.Parts, conf.MaxArgumentSize)
+		}
+		argument.Value = value
+	}
+}
+
+func truncateSpanParts(parts []*tracespec.ModelMessagePart, size int) {
+	for _, part := range parts {
+		if part != nil {
+			part.Text = truncateText(part.Text, size)
+		}
+	}
+}
+
+// truncateText truncates text to size bytes without breaking utf-8 characters.
+func truncateText(text string, size int) string {
+	if size < 0 || len(text) <= size {
+		return text
+	}
+	for size > 0 && !utf8.RuneStart(text[size]) {
+		size--
+	}
+	return text[:size] + truncatedSuffix
+}
@@ -0,0 +1,60 @@
+// Copyright (c) 2025 Bytedance Ltd. and/or its affiliates
+// SPDX-License-Identifier: MIT
+
+package prompt
+
+import (
+	"strings"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+
+	"github.com/coze-dev/cozeloop-go/entity"
+	"github.com/coze-dev/cozeloop-go/internal/util"
+	"github.com/coze-dev/cozeloop-go/spec/tracespec"
+)
+
+func TestToTracedPromptInput(t *testing.T) {
+	Convey("Test variables are truncated and excluded in prompt template span input", t, func() {
+		var history []*entity.Message
+		for i := 0; i < 5; i++ {
+			history = append(history, &entity.Message{Role: entity.RoleUser, Content: util.Ptr(strings.Repeat("a", 20))})
+		}
+		history[4].Content = util.Ptr("latest")
+		variables := map[string]any{
+			"text":     "你好世界",
+			"history":  history,
+			"parts":    []*entity.ContentPart{{Type: entity.ContentTypeText, Text: util.Ptr(strings.Repeat("b", 20))}},
+			"password": "secret",
+		}
+		conf := (&TraceInputConf{MaxArgumentSize: 10, MaxPlaceholderMessages: 2}).withDefaults()
+		input := toTracedPromptInput(nil, variables, conf, []string{"password"})
+		So(len(input.Arguments), ShouldEqual, 3)
+		arguments := make(map[string]*tracespec.PromptArgument)
+		for _, argument := range input.Arguments {
+			arguments[argument.Key] = argument
+		}
+		// utf-8 characters are not broken
+		So(arguments["text"].Value, ShouldEqual, "你好世"+truncatedSuffix)
+		messages := arguments["history"].Value.([]*tracespec.ModelMessage)
+		So(len(messages), ShouldEqual, 2)
+		So(messages[0].Content, ShouldEqual, strings.Repeat("a", 10)+truncatedSuffix)
+		So(messages[1].Content, ShouldEqual, "latest")
+		So(arguments["parts"].Value.([]*tracespec.ModelMessagePart)[0].Text, ShouldEqual, strings.Repeat("b", 10)+truncatedSuffix)
+		So(arguments["password"], ShouldBeNil)
+		// variables passed in are not modified
+		So(len(variables), ShouldEqual, 4)
+		So(*history[0].Content, ShouldEqual, strings.Repeat("a", 20))
+	})
+
+	Convey("Test no limit if conf is negative", t, func() {
+		conf := (&TraceInputConf{MaxArgumentSize: -1, MaxPlaceholderMessages: -1}).withDefaults()
+		text := strings.Repeat("a", defaultTraceMaxArgumentSize+1)
+		input := toTracedPromptInput(nil, map[string]any{"text": text}, conf, nil)
+		So(input.Arguments[0].Value, ShouldEqual, text)
+
+		conf = (*TraceInputConf)(nil).withDefaults()
+		So(conf.MaxArgumentSize, ShouldEqual, defaultTraceMaxArgumentSize)
+		So(conf.MaxPlaceholderMessages, ShouldEqual, defaultTraceMaxPlaceholderMessages)
+	})
+}
@@ -0,0 +1,49 @@
+// Copyright (c) 2025 Bytedance Ltd. and/or its affiliates
+// SPDX-License-Identifier: MIT
+
+package prompt
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/coze-dev/cozeloop-go/entity"
+	"github.com/coze-dev/cozeloop-go/internal/consts"
+)
+
+// ListPromptVersions returns all committed versions of the prompt, the latest first.
+func (p *Provider) ListPromptVersions(ctx context.Context, promptKey string) ([]*entity.PromptVersion, error) {
+	if promptKey == "" {
+		return nil, consts.ErrInvalidParam.Wrap(fmt.Errorf("prompt key is empty"))
+	}
+	versions, err := p.openAPIClient.ListPromptVersions(ctx, ListPromptVersionsRequest{
+		WorkspaceID: p.config.WorkspaceID,
+		PromptKey:   promptKey,
+	})
+	if err != nil {
+		return nil, err
+	}
+	result := make([]*entity.PromptVersion, 0, len(versions))
+	for _, version := range versions {
+		if version == nil {
+			continue
+		}
+		result = append(result, toModelPromptVersion(promptKey, version))
+	}
+	return result, nil
+}
+
+func toModelPromptVersion(promptKey string, v *PromptVersionInfo) *entity.PromptVersion {
+	version := &entity.PromptVersion{
+		PromptKey:   promptKey,
+		Version:     v.Version,
+		Description: v.Description,
+		Labels:      v.Labels,
+		CommittedBy: v.CommittedBy,
+	}
+	if v.CommittedAtMs > 0 {
+		version.CommittedAt = time.UnixMilli(v.CommittedAtMs)
+	}
+	return version
+}
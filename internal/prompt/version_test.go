@@ -0,0 +1,68 @@
+// Copyright (c) 2025 Bytedance Ltd. and/or its affiliates
+// SPDX-License-Identifier: MIT
+
+package prompt
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	. "github.com/smartystreets/goconvey/convey"
+
+	"github.com/coze-dev/cozeloop-go/internal/consts"
+	"github.com/coze-dev/cozeloop-go/internal/httpclient"
+)
+
+func TestListPromptVersions(t *testing.T) {
+	ctx := context.Background()
+
+	Convey("Test list all pages of versions", t, func() {
+		var requests []ListPromptVersionsRequest
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			var req ListPromptVersionsRequest
+			_ = json.NewDecoder(r.Body).Decode(&req)
+			requests = append(requests, req)
+			data := ListPromptVersionsData{
+				Versions: []*PromptVersionInfo{{
+					Version:       "0.0.2",
+					Description:   "shorter answer",
+					Labels:        []string{"production"},
+					CommittedBy:   "alice",
+					CommittedAtMs: 1700000000000,
+				}},
+				NextPageToken: "page2",
+				HasMore:       true,
+			}
+			if req.PageToken == "page2" {
+				data = ListPromptVersionsData{Versions: []*PromptVersionInfo{{Version: "0.0.1"}}}
+			}
+			_ = json.NewEncoder(w).Encode(map[string]any{"code": 0, "data": data})
+		}))
+		defer server.Close()
+		client := httpclient.NewClient(server.URL, http.DefaultClient, httpclient.NewTokenAuth("token"), nil)
+		provider := NewPromptProvider(client, nil, Options{WorkspaceID: "workspace1"})
+
+		_, err := provider.ListPromptVersions(ctx, "")
+		So(errors.Is(err, consts.ErrInvalidParam), ShouldBeTrue)
+
+		versions, err := provider.ListPromptVersions(ctx, "key1")
+		So(err, ShouldBeNil)
+		So(len(requests), ShouldEqual, 2)
+		So(requests[0].WorkspaceID, ShouldEqual, "workspace1")
+		So(requests[0].PromptKey, ShouldEqual, "key1")
+		So(requests[0].PageSize, ShouldEqual, listPromptVersionsPageSize)
+		So(len(versions), ShouldEqual, 2)
+		So(versions[0].PromptKey, ShouldEqual, "key1")
+		So(versions[0].Version, ShouldEqual, "0.0.2")
+		So(versions[0].Labels, ShouldResemble, []string{"production"})
+		So(versions[0].CommittedBy, ShouldEqual, "alice")
+		So(versions[0].CommittedAt.Equal(time.UnixMilli(1700000000000)), ShouldBeTrue)
+		So(versions[1].Version, ShouldEqual, "0.0.1")
+		So(versions[1].CommittedAt.IsZero(), ShouldBeTrue)
+	})
+}
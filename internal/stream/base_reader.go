@@ -7,35 +7,47 @@ import (
 	"context"
 	"fmt"
 	"net/http"
+	"sync"
+	"sync/atomic"
+
+	"github.com/coze-dev/cozeloop-go/sse"
 )
 
+var errStreamClosed = fmt.Errorf("stream reader is closed")
+
 // SSEParser defines the interface for parsing SSE events into specific types
 type SSEParser[T any] interface {
 	Parse(sse *ServerSentEvent) (T, error)
 	HandleError(sse *ServerSentEvent) error
 }
 
-// BaseStreamReader provides generic SSE stream reading capabilities
+// BaseStreamReader provides generic SSE stream reading capabilities.
+// Close is safe to be called multiple times, and concurrently with Recv.
 type BaseStreamReader[T any] struct {
-	ctx      context.Context
-	response *http.Response
-	decoder  *SSEDecoder
-	parser   SSEParser[T]
-	closed   bool
-	events   <-chan SSEEvent
+	ctx       context.Context
+	cancel    context.CancelFunc
+	response  *http.Response
+	decoder   *sse.Decoder
+	parser    SSEParser[T]
+	closed    int32
+	closeOnce sync.Once
+	closeErr  error
+	events    <-chan sse.Result
 }
 
 // NewBaseStreamReader creates a new base stream reader
 func NewBaseStreamReader[T any](ctx context.Context, resp *http.Response, parser SSEParser[T]) *BaseStreamReader[T] {
-	decoder := NewSSEDecoder(resp.Body)
+	// the decoding goroutine exits when the reader is closed
+	ctx, cancel := context.WithCancel(ctx)
+	decoder := sse.NewDecoder(resp.Body)
 	events := decoder.Decode(ctx)
 
 	return &BaseStreamReader[T]{
 		ctx:      ctx,
+		cancel:   cancel,
 		response: resp,
 		decoder:  decoder,
 		parser:   parser,
-		closed:   false,
 		events:   events,
 	}
 }
@@ -44,13 +56,16 @@ func NewBaseStreamReader[T any](ctx context.Context, resp *http.Response, parser
 func (r *BaseStreamReader[T]) Recv() (T, error) {
 	var zero T
 
-	if r.closed {
-		return zero, fmt.Errorf("stream reader is closed")
+	if r.isClosed() {
+		return zero, errStreamClosed
 	}
 
 	for {
 		select {
 		case <-r.ctx.Done():
+			if r.isClosed() {
+				return zero, errStreamClosed
+			}
 			r.Close()
 			return zero, r.ctx.Err()
 
@@ -61,9 +76,9 @@ func (r *BaseStreamReader[T]) Recv() (T, error) {
 				return zero, fmt.Errorf("stream ended")
 			}
 
-			if sseEvent.Error != nil {
+			if sseEvent.Err != nil {
 				r.Close()
-				return zero, sseEvent.Error
+				return zero, sseEvent.Err
 			}
 
 			if sseEvent.Event == nil {
@@ -90,14 +105,16 @@ func (r *BaseStreamReader[T]) Recv() (T, error) {
 
 // Close closes the stream reader and releases resources
 func (r *BaseStreamReader[T]) Close() error {
-	if r.closed {
-		return nil
-	}
-
-	r.closed = true
-	if r.response != nil && r.response.Body != nil {
-		return r.response.Body.Close()
-	}
+	r.closeOnce.Do(func() {
+		atomic.StoreInt32(&r.closed, 1)
+		r.cancel()
+		if r.response != nil && r.response.Body != nil {
+			r.closeErr = r.response.Body.Close()
+		}
+	})
+	return r.closeErr
+}
 
-	return nil
+func (r *BaseStreamReader[T]) isClosed() bool {
+	return atomic.LoadInt32(&r.closed) == 1
 }
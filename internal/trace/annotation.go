@@ -0,0 +1,52 @@
+// Copyright (c) 2025 Bytedance Ltd. and/or its affiliates
+// SPDX-License-Identifier: MIT
+
+package trace
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/coze-dev/cozeloop-go/internal/consts"
+	"github.com/coze-dev/cozeloop-go/internal/httpclient"
+)
+
+const (
+	pathAnnotateSpan = "/v1/loop/traces/annotations/create"
+)
+
+type AnnotateSpanRequest struct {
+	WorkspaceID string         `json:"workspace_id"`
+	TraceID     string         `json:"trace_id"`
+	SpanID      string         `json:"span_id"`
+	Tags        map[string]any `json:"tags"`
+}
+
+// AnnotateSpan attaches tags to the span which has been reported, such as human review status.
+// The values of tags must be string, bool or number.
+func (t *Provider) AnnotateSpan(ctx context.Context, traceID, spanID string, tags map[string]any) error {
+	if traceID == "" || spanID == "" {
+		return consts.ErrInvalidParam.Wrap(fmt.Errorf("trace id and span id are required"))
+	}
+	if len(tags) == 0 {
+		return consts.ErrInvalidParam.Wrap(fmt.Errorf("tags is empty"))
+	}
+	for key, value := range tags {
+		if key == "" {
+			return consts.ErrInvalidParam.Wrap(fmt.Errorf("tag key is empty"))
+		}
+		switch value.(type) {
+		case string, bool, int, int8, int16, int32, int64, uint, uint8, uint16, uint32, uint64, float32, float64:
+		default:
+			return consts.ErrInvalidParam.Wrap(fmt.Errorf("invalid type %T of tag %s, should be string, bool or number", value, key))
+		}
+	}
+
+	resp := httpclient.BaseResponse{}
+	return t.httpClient.Post(ctx, pathAnnotateSpan, AnnotateSpanRequest{
+		WorkspaceID: t.opt.WorkspaceID,
+		TraceID:     traceID,
+		SpanID:      spanID,
+		Tags:        tags,
+	}, &resp)
+}
@@ -0,0 +1,61 @@
+// Copyright (c) 2025 Bytedance Ltd. and/or its affiliates
+// SPDX-License-Identifier: MIT
+
+package trace
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+
+	"github.com/coze-dev/cozeloop-go/internal/consts"
+	"github.com/coze-dev/cozeloop-go/internal/httpclient"
+)
+
+func Test_AnnotateSpan(t *testing.T) {
+	ctx := context.Background()
+	var path string
+	var req AnnotateSpanRequest
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		path = r.URL.Path
+		_ = json.NewDecoder(r.Body).Decode(&req)
+		_, _ = w.Write([]byte(`{"code":0}`))
+	}))
+	defer server.Close()
+	p := &Provider{
+		httpClient: httpclient.NewClient(server.URL, http.DefaultClient, httpclient.NewTokenAuth("token"), nil),
+		opt:        &Options{WorkspaceID: "workspace1"},
+	}
+
+	Convey("Test AnnotateSpan with invalid param", t, func() {
+		err := p.AnnotateSpan(ctx, "trace", "", map[string]any{"review_status": "approved"})
+		So(errors.Is(err, consts.ErrInvalidParam), ShouldBeTrue)
+		err = p.AnnotateSpan(ctx, "trace", "span", nil)
+		So(errors.Is(err, consts.ErrInvalidParam), ShouldBeTrue)
+		err = p.AnnotateSpan(ctx, "trace", "span", map[string]any{"": "approved"})
+		So(errors.Is(err, consts.ErrInvalidParam), ShouldBeTrue)
+		err = p.AnnotateSpan(ctx, "trace", "span", map[string]any{"labels": []string{"a"}})
+		So(errors.Is(err, consts.ErrInvalidParam), ShouldBeTrue)
+	})
+
+	Convey("Test AnnotateSpan success", t, func() {
+		err := p.AnnotateSpan(ctx, "trace", "span", map[string]any{
+			"review_status":   "approved",
+			"resolution_code": 3,
+			"escalated":       false,
+		})
+		So(err, ShouldBeNil)
+		So(path, ShouldEqual, pathAnnotateSpan)
+		So(req.WorkspaceID, ShouldEqual, "workspace1")
+		So(req.TraceID, ShouldEqual, "trace")
+		So(req.SpanID, ShouldEqual, "span")
+		So(req.Tags["review_status"], ShouldEqual, "approved")
+		So(req.Tags["resolution_code"], ShouldEqual, 3)
+		So(req.Tags["escalated"], ShouldEqual, false)
+	})
+}
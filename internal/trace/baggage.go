@@ -0,0 +1,174 @@
+// Copyright (c) 2025 Bytedance Ltd. and/or its affiliates
+// SPDX-License-Identifier: MIT
+
+package trace
+
+import (
+	"context"
+	"sort"
+	"strings"
+
+	"github.com/coze-dev/cozeloop-go/internal/consts"
+	"github.com/coze-dev/cozeloop-go/internal/logger"
+)
+
+const (
+	// defaults are the minimum limits of baggage that W3C requires platforms to propagate,
+	// see https://www.w3.org/TR/baggage/#limits
+	defaultBaggageMaxCount      = 64
+	defaultBaggageMaxTotalBytes = 8192
+)
+
+// BaggageConf limits the baggage of span, and the baggage propagated to downstream by ToHeader.
+type BaggageConf struct {
+	// MaxCount max count of baggage items of a span, items beyond it are rejected. Default is 64.
+	MaxCount int
+	// MaxTotalBytes max bytes of encoded baggage of a span, the size of baggage header.
+	// Items making it exceeded are rejected. Default is 8192.
+	MaxTotalBytes int
+	// PropagateFilter baggage items of the keys which it returns false for are kept in span and passed to
+	// child spans in process, but not propagated by ToHeader. Default is nil, which propagates all.
+	PropagateFilter func(key string) bool
+}
+
+// SamplingDecision decides whether a span is reported, see BaggageDecision.
+type SamplingDecision int
+
+const (
+	// SamplingDefault reports the span as usual.
+	SamplingDefault SamplingDecision = iota
+	// SamplingDrop doesn't report the span, and the sampled flag of traceparent header is unset.
+	SamplingDrop
+	// SamplingRecordAll reports the span with full fidelity, large input and output are uploaded as files
+	// instead of being truncated, just like UltraLargeReport is enabled for the span.
+	SamplingRecordAll
+)
+
+// BaggageDecision the result of BaggageProcessor for a span.
+type BaggageDecision struct {
+	// Tags are set on the span when it is started
+	Tags map[string]interface{}
+	// Sampling decides whether the span is reported
+	Sampling SamplingDecision
+}
+
+// BaggageProcessor is called when every span is started, with the baggage inherited from the parent span or
+// upstream service. As baggage is passed to all descendant spans, the same decision is made for them, so that
+// requests of specific users or tenants, or marked by baggage like "debug=1", can be traced differently.
+// It is called synchronously and must be fast.
+type BaggageProcessor func(ctx context.Context, baggage map[string]string) BaggageDecision
+
+// sampledFlag the sampled flag of traceparent header.
+const sampledFlag byte = 1
+
+func (s *Span) applyBaggageDecision(ctx context.Context, decision BaggageDecision) {
+	switch decision.Sampling {
+	case SamplingDrop:
+		s.flags &^= sampledFlag
+	case SamplingRecordAll:
+		s.ultraLargeReport = true
+	}
+	if len(decision.Tags) > 0 {
+		s.SetTags(ctx, decision.Tags)
+	}
+}
+
+func (c *BaggageConf) maxCount() int {
+	if c != nil && c.MaxCount > 0 {
+		return c.MaxCount
+	}
+	return defaultBaggageMaxCount
+}
+
+func (c *BaggageConf) maxTotalBytes() int {
+	if c != nil && c.MaxTotalBytes > 0 {
+		return c.MaxTotalBytes
+	}
+	return defaultBaggageMaxTotalBytes
+}
+
+func (c *BaggageConf) propagate(key string) bool {
+	return c == nil || c.PropagateFilter == nil || c.PropagateFilter(key)
+}
+
+// addBaggageItem adds the baggage item if the count and size limits of baggage are not exceeded.
+func (s *Span) addBaggageItem(ctx context.Context, key, value string) bool {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	if s.Baggage == nil {
+		s.Baggage = make(map[string]string)
+	}
+	old, exist := s.Baggage[key]
+	if !exist && len(s.Baggage) >= s.baggageConf.maxCount() {
+		logger.CtxWarnf(ctx, "count of baggage exceeds limit %d, key:%s is dropped", s.baggageConf.maxCount(), key)
+		return false
+	}
+	size := baggageBytesSize(s.Baggage) + baggageItemBytesSize(key, value)
+	if exist {
+		size -= baggageItemBytesSize(key, old)
+	}
+	if size > s.baggageConf.maxTotalBytes() {
+		logger.CtxWarnf(ctx, "size of baggage exceeds limit %d, key:%s is dropped", s.baggageConf.maxTotalBytes(), key)
+		return false
+	}
+	s.Baggage[key] = value
+	return true
+}
+
+// baggageBytesSize returns the size of encoded baggage, including the separators.
+func baggageBytesSize(baggage map[string]string) int {
+	size := 0
+	for k, v := range baggage {
+		size += baggageItemBytesSize(k, v)
+	}
+	return size
+}
+
+// baggageItemBytesSize returns the size of encoded baggage item, with the separator "=" and ",".
+func baggageItemBytesSize(key, value string) int {
+	return len(escapeBaggage(key)) + len(escapeBaggage(value)) + 2
+}
+
+// encodeBaggage encodes the baggage as W3C baggage header, in order of keys. The items out of propagate filter,
+// and the items exceeding the size limit are dropped.
+func encodeBaggage(baggage map[string]string, conf *BaggageConf) string {
+	keys := make([]string, 0, len(baggage))
+	for k, v := range baggage {
+		// empty key or value is invalid
+		if k != "" && v != "" && conf.propagate(k) {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+	var b strings.Builder
+	for _, k := range keys {
+		item := escapeBaggage(k) + consts.Equal + escapeBaggage(baggage[k])
+		if b.Len()+len(item)+1 > conf.maxTotalBytes() {
+			continue
+		}
+		if b.Len() > 0 {
+			b.WriteString(consts.Comma)
+		}
+		b.WriteString(item)
+	}
+	return b.String()
+}
+
+// escapeBaggage percent-encodes all bytes except the unreserved characters of RFC 3986, which is valid for both
+// keys and values of W3C baggage. Space is encoded as "%20" rather than "+", and is decoded by url.QueryUnescape
+// as well as by W3C implementations.
+func escapeBaggage(s string) string {
+	const hex = "0123456789ABCDEF"
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if 'a' <= c && c <= 'z' || 'A' <= c && c <= 'Z' || '0' <= c && c <= '9' || c == '-' || c == '.' || c == '_' || c == '~' {
+			b.WriteByte(c)
+			continue
+		}
+		b.WriteByte('%')
+		b.WriteByte(hex[c>>4])
+		b.WriteByte(hex[c&0x0f])
+	}
+	return b.String()
+}
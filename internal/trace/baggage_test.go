@@ -0,0 +1,137 @@
+// Copyright (c) 2025 Bytedance Ltd. and/or its affiliates
+// SPDX-License-Identifier: MIT
+
+package trace
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+
+	"github.com/coze-dev/cozeloop-go/internal/consts"
+)
+
+func TestBaggageLimits(t *testing.T) {
+	ctx := context.Background()
+	Convey("Test baggage count and size limits", t, func() {
+		provider := NewTraceProvider(nil, Options{
+			Exporter:    &replayExporter{},
+			BaggageConf: &BaggageConf{MaxCount: 3, MaxTotalBytes: 40},
+		})
+		defer func() {
+			_, _ = provider.CloseTrace(ctx)
+		}()
+		_, span, err := provider.StartSpan(ctx, "span", "custom", StartSpanOptions{})
+		So(err, ShouldBeNil)
+
+		span.SetBaggage(ctx, map[string]string{"k1": "v1"})
+		span.SetBaggage(ctx, map[string]string{"k2": "v2"})
+		span.SetBaggage(ctx, map[string]string{"k3": "v3"})
+		span.SetBaggage(ctx, map[string]string{"k4": "v4"})
+		So(span.GetBaggage(), ShouldResemble, map[string]string{"k1": "v1", "k2": "v2", "k3": "v3"})
+		So(span.GetTagMap(), ShouldNotContainKey, "k4")
+
+		// replacing existing item is allowed if size is not exceeded
+		span.SetBaggage(ctx, map[string]string{"k3": "v33"})
+		So(span.GetBaggage()["k3"], ShouldEqual, "v33")
+		span.SetBaggage(ctx, map[string]string{"k3": strings.Repeat("v", 30)})
+		So(span.GetBaggage()["k3"], ShouldEqual, "v33")
+	})
+
+	Convey("Test default count limit", t, func() {
+		provider := NewTraceProvider(nil, Options{Exporter: &replayExporter{}})
+		defer func() {
+			_, _ = provider.CloseTrace(ctx)
+		}()
+		_, span, err := provider.StartSpan(ctx, "span", "custom", StartSpanOptions{})
+		So(err, ShouldBeNil)
+		for i := 0; i < 100; i++ {
+			span.SetBaggage(ctx, map[string]string{fmt.Sprintf("k%d", i): "v"})
+		}
+		So(len(span.GetBaggage()), ShouldEqual, defaultBaggageMaxCount)
+	})
+}
+
+func TestBaggageHeader(t *testing.T) {
+	ctx := context.Background()
+	Convey("Test baggage is encoded and filtered in header", t, func() {
+		provider := NewTraceProvider(nil, Options{
+			Exporter: &replayExporter{},
+			BaggageConf: &BaggageConf{PropagateFilter: func(key string) bool {
+				return key != "secret"
+			}},
+		})
+		defer func() {
+			_, _ = provider.CloseTrace(ctx)
+		}()
+		_, span, err := provider.StartSpan(ctx, "span", "custom", StartSpanOptions{})
+		So(err, ShouldBeNil)
+		span.SetBaggage(ctx, map[string]string{"user": "a b+c/é", "secret": "token", "a": "1"})
+
+		header, err := span.ToHeader()
+		So(err, ShouldBeNil)
+		So(header[consts.TraceContextHeaderBaggage], ShouldEqual, "a=1,user=a%20b%2Bc%2F%C3%A9")
+		So(span.GetBaggage()["secret"], ShouldEqual, "token")
+
+		// decoded by FromHeader
+		spanContext := FromHeader(ctx, header)
+		So(spanContext.Baggage, ShouldResemble, map[string]string{"a": "1", "user": "a b+c/é"})
+	})
+
+	Convey("Test encodeBaggage drops items exceeding size limit", t, func() {
+		encoded := encodeBaggage(map[string]string{"a": "1", "b": strings.Repeat("2", 20), "c": "3"}, &BaggageConf{MaxTotalBytes: 10})
+		So(encoded, ShouldEqual, "a=1,c=3")
+	})
+}
+
+func TestBaggageProcessor(t *testing.T) {
+	ctx := context.Background()
+	Convey("Test tags and sampling are decided by baggage for descendant spans", t, func() {
+		var calls []string
+		provider := NewTraceProvider(nil, Options{
+			Exporter:       &replayExporter{},
+			SpanProcessors: []SpanProcessor{&recordProcessor{name: "p", calls: &calls}},
+			BaggageProcessor: func(ctx context.Context, baggage map[string]string) BaggageDecision {
+				switch {
+				case baggage["debug"] == "1":
+					return BaggageDecision{Tags: map[string]interface{}{"debug": true}, Sampling: SamplingRecordAll}
+				case baggage["tenant"] == "noisy":
+					return BaggageDecision{Sampling: SamplingDrop}
+				}
+				return BaggageDecision{}
+			},
+		})
+		defer func() {
+			_, _ = provider.CloseTrace(ctx)
+		}()
+
+		debugCtx, root, err := provider.StartSpan(ctx, "root", "custom", StartSpanOptions{Baggage: map[string]string{"debug": "1"}})
+		So(err, ShouldBeNil)
+		So(root.GetTagMap()["debug"], ShouldEqual, true)
+		_, child, err := provider.StartSpan(debugCtx, "child", "custom", StartSpanOptions{})
+		So(err, ShouldBeNil)
+		So(child.GetTagMap()["debug"], ShouldEqual, true)
+		So(child.ultraLargeReport, ShouldBeTrue)
+		child.Finish(debugCtx)
+		root.Finish(debugCtx)
+
+		noisyCtx, noisy, err := provider.StartSpan(ctx, "noisy", "custom", StartSpanOptions{Baggage: map[string]string{"tenant": "noisy"}})
+		So(err, ShouldBeNil)
+		header, err := noisy.ToHeader()
+		So(err, ShouldBeNil)
+		So(header[consts.TraceContextHeaderParent], ShouldEndWith, "-00")
+		_, noisyChild, err := provider.StartSpan(noisyCtx, "noisy_child", "custom", StartSpanOptions{})
+		So(err, ShouldBeNil)
+		noisyChild.Finish(noisyCtx)
+		noisy.Finish(noisyCtx)
+
+		_, normal, err := provider.StartSpan(ctx, "normal", "custom", StartSpanOptions{})
+		So(err, ShouldBeNil)
+		So(normal.ultraLargeReport, ShouldBeFalse)
+		normal.Finish(ctx)
+		So(calls, ShouldResemble, []string{"p:end:child", "p:end:root", "p:end:normal"})
+	})
+}
@@ -0,0 +1,64 @@
+// Copyright (c) 2025 Bytedance Ltd. and/or its affiliates
+// SPDX-License-Identifier: MIT
+
+package trace
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/coze-dev/cozeloop-go/entity"
+	"github.com/coze-dev/cozeloop-go/internal/httpclient"
+)
+
+// BlobStore stores the files of spans, such as large input and output, and multi-modality attachments, instead of
+// uploading them through CozeLoop. Files are referred by file.TosKey in spans, so the storage should be the object
+// storage configured for the workspace.
+type BlobStore interface {
+	Put(ctx context.Context, file *entity.UploadFile) error
+}
+
+// PresignFunc returns the pre-signed url to upload file by http PUT, such as the url pre-signed by S3 or TOS.
+type PresignFunc func(ctx context.Context, file *entity.UploadFile) (string, error)
+
+type presignedURLBlobStore struct {
+	presign    PresignFunc
+	httpClient httpclient.HTTPClient
+}
+
+// NewPresignedURLBlobStore returns a BlobStore uploading files directly to object storage by the urls pre-signed
+// by presign. http.DefaultClient is used if httpClient is nil.
+func NewPresignedURLBlobStore(presign PresignFunc, httpClient httpclient.HTTPClient) BlobStore {
+	if httpClient == nil {
+		httpClient = http.DefaultClient
+	}
+	return &presignedURLBlobStore{
+		presign:    presign,
+		httpClient: httpClient,
+	}
+}
+
+func (s *presignedURLBlobStore) Put(ctx context.Context, file *entity.UploadFile) error {
+	url, err := s.presign(ctx, file)
+	if err != nil {
+		return fmt.Errorf("presign file[%s] fail: %w", file.TosKey, err)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPut, url, strings.NewReader(file.Data))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", http.DetectContentType([]byte(file.Data)))
+	resp, err := s.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, resp.Body)
+	if resp.StatusCode < http.StatusOK || resp.StatusCode >= http.StatusMultipleChoices {
+		return fmt.Errorf("put file[%s] to pre-signed url fail, status code: %d", file.TosKey, resp.StatusCode)
+	}
+	return nil
+}
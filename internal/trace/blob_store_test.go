@@ -0,0 +1,55 @@
+// Copyright (c) 2025 Bytedance Ltd. and/or its affiliates
+// SPDX-License-Identifier: MIT
+
+package trace
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+
+	"github.com/coze-dev/cozeloop-go/entity"
+)
+
+func TestPresignedURLBlobStore(t *testing.T) {
+	ctx := context.Background()
+	Convey("Test files are put to the pre-signed urls instead of CozeLoop", t, func() {
+		var mu sync.Mutex
+		objects := make(map[string]string)
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.Method != http.MethodPut || r.URL.Query().Get("signature") != "ok" {
+				w.WriteHeader(http.StatusForbidden)
+				return
+			}
+			body, _ := io.ReadAll(r.Body)
+			mu.Lock()
+			objects[r.URL.Path] = string(body)
+			mu.Unlock()
+		}))
+		defer server.Close()
+		store := NewPresignedURLBlobStore(func(ctx context.Context, file *entity.UploadFile) (string, error) {
+			if file.TosKey == "unsigned" {
+				return server.URL + "/" + file.TosKey, nil
+			}
+			if file.TosKey == "error" {
+				return "", errors.New("presign error")
+			}
+			return server.URL + "/" + file.TosKey + "?signature=ok", nil
+		}, nil)
+		exporter := newSpanExporter(nil, nil)
+		exporter.blobStore = store
+
+		err := exporter.ExportFiles(ctx, []*entity.UploadFile{{TosKey: "k1", Data: "large text"}, nil, {TosKey: "k2", Data: "image"}})
+		So(err, ShouldBeNil)
+		So(objects, ShouldResemble, map[string]string{"/k1": "large text", "/k2": "image"})
+
+		So(exporter.ExportFiles(ctx, []*entity.UploadFile{{TosKey: "unsigned"}}), ShouldNotBeNil)
+		So(exporter.ExportFiles(ctx, []*entity.UploadFile{{TosKey: "error"}}), ShouldNotBeNil)
+	})
+}
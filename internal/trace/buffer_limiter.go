@@ -0,0 +1,78 @@
+// Copyright (c) 2025 Bytedance Ltd. and/or its affiliates
+// SPDX-License-Identifier: MIT
+
+package trace
+
+import (
+	"sync/atomic"
+)
+
+// BufferEvictionPolicy decides which items are dropped when the bytes buffered in trace queues reach the limit.
+type BufferEvictionPolicy string
+
+const (
+	// BufferEvictionPolicyDropNewest drop the item being enqueued, it is the default policy.
+	BufferEvictionPolicyDropNewest BufferEvictionPolicy = "drop_newest"
+	// BufferEvictionPolicyDropOldest drop the oldest items waiting in the same queue to make room for the new one,
+	// the new one is dropped if there is still no room.
+	BufferEvictionPolicyDropOldest BufferEvictionPolicy = "drop_oldest"
+)
+
+// bufferLimiter caps the bytes of spans and files buffered across the queues, shared by the queues of processor.
+// A nil limiter has no limit.
+type bufferLimiter struct {
+	maxBytes int64
+	policy   BufferEvictionPolicy
+	used     int64
+}
+
+func newBufferLimiter(conf *QueueConf) *bufferLimiter {
+	if conf == nil || conf.MaxBufferedBytes <= 0 {
+		return nil
+	}
+	policy := conf.BufferEvictionPolicy
+	if policy == "" {
+		policy = BufferEvictionPolicyDropNewest
+	}
+	return &bufferLimiter{
+		maxBytes: conf.MaxBufferedBytes,
+		policy:   policy,
+	}
+}
+
+// acquire reserves size bytes, it fails if the limit would be exceeded.
+func (l *bufferLimiter) acquire(size int64) bool {
+	if l == nil {
+		return true
+	}
+	for {
+		used := atomic.LoadInt64(&l.used)
+		if used+size > l.maxBytes {
+			return false
+		}
+		if atomic.CompareAndSwapInt64(&l.used, used, used+size) {
+			return true
+		}
+	}
+}
+
+// release returns the bytes reserved by acquire.
+func (l *bufferLimiter) release(size int64) {
+	if l == nil || size == 0 {
+		return
+	}
+	atomic.AddInt64(&l.used, -size)
+}
+
+// dropOldest whether to evict the oldest items when the limit is reached.
+func (l *bufferLimiter) dropOldest() bool {
+	return l != nil && l.policy == BufferEvictionPolicyDropOldest
+}
+
+// bufferedBytes returns the bytes buffered now.
+func (l *bufferLimiter) bufferedBytes() int64 {
+	if l == nil {
+		return 0
+	}
+	return atomic.LoadInt64(&l.used)
+}
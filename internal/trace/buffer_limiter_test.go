@@ -0,0 +1,83 @@
+// Copyright (c) 2025 Bytedance Ltd. and/or its affiliates
+// SPDX-License-Identifier: MIT
+
+package trace
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+// newIdleQueueManager creates a queue manager without the goroutine consuming queue, so items stay in queue.
+func newIdleQueueManager(limiter *bufferLimiter) *BatchQueueManager {
+	return &BatchQueueManager{
+		o:     batchQueueManagerOptions{queueName: queueNameSpan, limiter: limiter},
+		queue: make(chan interface{}, 10),
+	}
+}
+
+func TestBufferLimiter(t *testing.T) {
+	ctx := context.Background()
+	Convey("Test no limit if max bytes is not set", t, func() {
+		So(newBufferLimiter(nil), ShouldBeNil)
+		So(newBufferLimiter(&QueueConf{}), ShouldBeNil)
+		qm := newIdleQueueManager(nil)
+		qm.Enqueue(ctx, "a", 100)
+		So(qm.Pending(), ShouldEqual, 1)
+		So(<-qm.queue, ShouldEqual, "a")
+	})
+
+	Convey("Test newest items are dropped by default", t, func() {
+		limiter := newBufferLimiter(&QueueConf{MaxBufferedBytes: 10})
+		So(limiter.policy, ShouldEqual, BufferEvictionPolicyDropNewest)
+		qm := newIdleQueueManager(limiter)
+		qm.Enqueue(ctx, "a", 4)
+		qm.Enqueue(ctx, "b", 4)
+		qm.Enqueue(ctx, "c", 4)
+		So(qm.Pending(), ShouldEqual, 2)
+		So(qm.Dropped(), ShouldEqual, 1)
+		So(limiter.bufferedBytes(), ShouldEqual, 8)
+		So((<-qm.queue).(bufferedItem).item, ShouldEqual, "a")
+	})
+
+	Convey("Test oldest items are dropped to make room", t, func() {
+		limiter := newBufferLimiter(&QueueConf{MaxBufferedBytes: 10, BufferEvictionPolicy: BufferEvictionPolicyDropOldest})
+		qm := newIdleQueueManager(limiter)
+		qm.Enqueue(ctx, "a", 4)
+		qm.Enqueue(ctx, "b", 4)
+		qm.Enqueue(ctx, "c", 6)
+		So(qm.Pending(), ShouldEqual, 2)
+		So(qm.Dropped(), ShouldEqual, 1)
+		So(limiter.bufferedBytes(), ShouldEqual, 10)
+		So(qm.batchByteSize, ShouldEqual, 10)
+
+		// all items are evicted but there is still no room
+		qm.Enqueue(ctx, "d", 20)
+		So(qm.Pending(), ShouldEqual, 0)
+		So(qm.Dropped(), ShouldEqual, 4)
+		So(limiter.bufferedBytes(), ShouldEqual, 0)
+		So(qm.batchByteSize, ShouldEqual, 0)
+	})
+
+	Convey("Test bytes are released after export", t, func() {
+		processor := NewBatchSpanProcessor(&replayExporter{}, nil, nil, nil,
+			&QueueConf{MaxBufferedBytes: 1000}, nil, "").(*BatchSpanProcessor)
+		processor.OnSpanEnd(ctx, &Span{bytesSize: 600})
+		processor.OnSpanEnd(ctx, &Span{bytesSize: 600})
+		So(processor.ForceFlush(ctx), ShouldBeNil)
+		stats := processor.Stats()
+		So(stats.SpansFlushed, ShouldEqual, 1)
+		So(stats.SpansDropped, ShouldEqual, 1)
+		So(stats.BufferedBytes, ShouldEqual, 0)
+
+		processor.OnSpanEnd(ctx, &Span{bytesSize: 600})
+		shutdownCtx, cancel := context.WithTimeout(ctx, time.Second)
+		defer cancel()
+		report, err := processor.Shutdown(shutdownCtx)
+		So(err, ShouldBeNil)
+		So(report.SpansFlushed, ShouldEqual, 2)
+	})
+}
@@ -0,0 +1,96 @@
+// Copyright (c) 2025 Bytedance Ltd. and/or its affiliates
+// SPDX-License-Identifier: MIT
+
+package trace
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"unicode/utf8"
+
+	"github.com/coze-dev/cozeloop-go/internal/util"
+	"github.com/coze-dev/cozeloop-go/spec/tracespec"
+)
+
+// structureKeys the keys whose values are kept when content is not captured, as they describe the structure of
+// messages instead of the content of users.
+var structureKeys = map[string]bool{
+	"role":          true,
+	"type":          true,
+	"finish_reason": true,
+}
+
+// summarizeContent replaces the strings in input or output with their hashes and lengths, so the content is not
+// exported but the same content can still be matched. The structure of JSON, such as the messages and parts, and
+// numbers and bools are kept.
+func summarizeContent(value interface{}) string {
+	text := util.ToJSON(value)
+	var parsed interface{}
+	if err := json.Unmarshal([]byte(text), &parsed); err != nil {
+		return summarizeText(text)
+	}
+	switch parsed.(type) {
+	case map[string]interface{}, []interface{}:
+		return util.ToJSON(summarizeJSON(parsed))
+	default:
+		return summarizeText(text)
+	}
+}
+
+// summarizeContentTags returns the tags with input and output summarized if content is not captured, tagKVs is
+// not modified.
+func (s *Span) summarizeContentTags(tagKVs map[string]interface{}) map[string]interface{} {
+	if !s.noCaptureContent {
+		return tagKVs
+	}
+	var summarized map[string]interface{}
+	for _, key := range []string{tracespec.Input, tracespec.Output} {
+		value, ok := tagKVs[key]
+		if !ok || value == nil {
+			continue
+		}
+		if summarized == nil {
+			summarized = make(map[string]interface{}, len(tagKVs))
+			for k, v := range tagKVs {
+				summarized[k] = v
+			}
+		}
+		summarized[key] = summarizeContent(value)
+	}
+	if summarized == nil {
+		return tagKVs
+	}
+	return summarized
+}
+
+func summarizeJSON(value interface{}) interface{} {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		for key, item := range v {
+			if _, ok := item.(string); ok && structureKeys[key] {
+				continue
+			}
+			v[key] = summarizeJSON(item)
+		}
+		return v
+	case []interface{}:
+		for i, item := range v {
+			v[i] = summarizeJSON(item)
+		}
+		return v
+	case string:
+		return summarizeText(v)
+	default:
+		return v
+	}
+}
+
+func summarizeText(text string) string {
+	if text == "" {
+		return ""
+	}
+	sum := sha256.Sum256([]byte(text))
+	return fmt.Sprintf("sha256:%s len:%d", hex.EncodeToString(sum[:8]), utf8.RuneCountInString(text))
+}
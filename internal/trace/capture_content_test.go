@@ -0,0 +1,69 @@
+// Copyright (c) 2025 Bytedance Ltd. and/or its affiliates
+// SPDX-License-Identifier: MIT
+
+package trace
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+
+	"github.com/coze-dev/cozeloop-go/spec/tracespec"
+)
+
+func TestCaptureContent(t *testing.T) {
+	ctx := context.Background()
+	startSpan := func(noCaptureContent bool) *Span {
+		provider := NewTraceProvider(nil, Options{Exporter: &replayExporter{}, NoCaptureContent: noCaptureContent})
+		_, span, err := provider.StartSpan(ctx, "model", tracespec.VModelSpanType, StartSpanOptions{})
+		So(err, ShouldBeNil)
+		return span
+	}
+
+	Convey("Test content is captured by default", t, func() {
+		span := startSpan(false)
+		span.SetInput(ctx, "hello")
+		So(span.GetTagMap()[tracespec.Input], ShouldEqual, "hello")
+	})
+
+	Convey("Test text is replaced with hash and length", t, func() {
+		span := startSpan(true)
+		span.SetInput(ctx, "hello")
+		span.SetTags(ctx, map[string]interface{}{tracespec.Output: "hello", "key": "value"})
+		tags := span.GetTagMap()
+		So(tags[tracespec.Input], ShouldEqual, "sha256:2cf24dba5fb0a30e len:5")
+		So(tags[tracespec.Output], ShouldEqual, tags[tracespec.Input])
+		So(tags["key"], ShouldEqual, "value")
+	})
+
+	Convey("Test structure of messages is kept", t, func() {
+		span := startSpan(true)
+		span.SetInput(ctx, tracespec.ModelInput{Messages: []*tracespec.ModelMessage{
+			{Role: tracespec.VRoleUser, Content: "你好"},
+			{Role: tracespec.VRoleUser, Parts: []*tracespec.ModelMessagePart{
+				{Type: tracespec.ModelMessagePartTypeImage, ImageURL: &tracespec.ModelImageURL{URL: "https://example.com/a.png"}},
+			}},
+		}})
+		span.SetInputTokens(ctx, 10)
+
+		input := &tracespec.ModelInput{}
+		So(json.Unmarshal([]byte(span.GetTagMap()[tracespec.Input].(string)), input), ShouldBeNil)
+		So(len(input.Messages), ShouldEqual, 2)
+		So(input.Messages[0].Role, ShouldEqual, tracespec.VRoleUser)
+		So(input.Messages[0].Content, ShouldStartWith, "sha256:")
+		So(input.Messages[0].Content, ShouldEndWith, " len:2")
+		So(input.Messages[1].Parts[0].Type, ShouldEqual, tracespec.ModelMessagePartTypeImage)
+		So(input.Messages[1].Parts[0].ImageURL.URL, ShouldStartWith, "sha256:")
+		So(span.multiModalityKeyMap, ShouldBeEmpty)
+		So(span.GetTagMap()[tracespec.InputTokens], ShouldEqual, 10)
+	})
+
+	Convey("Test tags passed in are not modified", t, func() {
+		span := startSpan(true)
+		tags := map[string]interface{}{tracespec.Input: "hello"}
+		span.SetTags(ctx, tags)
+		So(tags[tracespec.Input], ShouldEqual, "hello")
+	})
+}
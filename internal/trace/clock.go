@@ -0,0 +1,59 @@
+// Copyright (c) 2025 Bytedance Ltd. and/or its affiliates
+// SPDX-License-Identifier: MIT
+
+package trace
+
+import (
+	"sync"
+	"time"
+)
+
+// Clock returns the current time, which is the default start time and finish time of spans.
+type Clock interface {
+	Now() time.Time
+}
+
+var _ Clock = systemClock{}
+var _ Clock = (*ManualClock)(nil)
+
+type systemClock struct{}
+
+func (c systemClock) Now() time.Time {
+	return time.Now()
+}
+
+// NewSystemClock returns the clock used by default, which is time.Now.
+func NewSystemClock() Clock {
+	return systemClock{}
+}
+
+// ManualClock is a clock which only moves by Set and Add, so the time and duration of spans are deterministic.
+type ManualClock struct {
+	lock sync.RWMutex
+	now  time.Time
+}
+
+// NewManualClock returns a ManualClock starting at now.
+func NewManualClock(now time.Time) *ManualClock {
+	return &ManualClock{now: now}
+}
+
+func (c *ManualClock) Now() time.Time {
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+	return c.now
+}
+
+// Set sets the current time of clock.
+func (c *ManualClock) Set(now time.Time) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	c.now = now
+}
+
+// Add moves the clock forward by d.
+func (c *ManualClock) Add(d time.Duration) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	c.now = c.now.Add(d)
+}
@@ -0,0 +1,47 @@
+// Copyright (c) 2025 Bytedance Ltd. and/or its affiliates
+// SPDX-License-Identifier: MIT
+
+package trace
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestClock(t *testing.T) {
+	ctx := context.Background()
+	Convey("Test span time follows the clock of provider", t, func() {
+		start := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+		clock := NewManualClock(start)
+		provider := NewTraceProvider(nil, Options{Exporter: &replayExporter{}, Clock: clock})
+		defer func() {
+			_, _ = provider.CloseTrace(ctx)
+		}()
+
+		_, span, err := provider.StartSpan(ctx, "span", "custom", StartSpanOptions{})
+		So(err, ShouldBeNil)
+		So(span.GetStartTime(), ShouldEqual, start)
+		clock.Add(1500 * time.Millisecond)
+		span.Finish(ctx)
+		So(span.GetDuration(), ShouldEqual, int64(1500*1000))
+
+		// start and finish time set explicitly take precedence
+		_, span, err = provider.StartSpan(ctx, "span", "custom", StartSpanOptions{StartTime: start.Add(-time.Hour)})
+		So(err, ShouldBeNil)
+		span.SetFinishTime(start.Add(-time.Hour + time.Second))
+		span.Finish(ctx)
+		So(span.GetStartTime(), ShouldEqual, start.Add(-time.Hour))
+		So(span.GetDuration(), ShouldEqual, int64(1000*1000))
+	})
+
+	Convey("Test system clock", t, func() {
+		So(NewSystemClock().Now(), ShouldHappenWithin, time.Second, time.Now())
+		clock := NewManualClock(time.Time{})
+		now := time.Now()
+		clock.Set(now)
+		So(clock.Now(), ShouldEqual, now)
+	})
+}
@@ -0,0 +1,110 @@
+// Copyright (c) 2025 Bytedance Ltd. and/or its affiliates
+// SPDX-License-Identifier: MIT
+
+package trace
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/coze-dev/cozeloop-go/entity"
+)
+
+// DebugConf configures the debug exporter, which prints spans in readable format for validating
+// instrumentation locally.
+type DebugConf struct {
+	// Writer where spans are printed. Default is os.Stdout.
+	Writer io.Writer
+	// AlsoReport report spans to CozeLoop in addition to printing them. If it is false, spans are only printed,
+	// and workspace id and auth are not required by client.
+	AlsoReport bool
+}
+
+var _ Exporter = (*DebugExporter)(nil)
+
+// DebugExporter prints every span as a summary line followed by the span in indented JSON.
+// Files of ultra large report and multi modality are printed as a summary line only.
+type DebugExporter struct {
+	lock sync.Mutex
+	w    io.Writer
+}
+
+func NewDebugExporter(w io.Writer) *DebugExporter {
+	if w == nil {
+		w = os.Stdout
+	}
+	return &DebugExporter{w: w}
+}
+
+func (e *DebugExporter) ExportSpans(ctx context.Context, spans []*entity.UploadSpan) error {
+	e.lock.Lock()
+	defer e.lock.Unlock()
+	for _, span := range spans {
+		if span == nil {
+			continue
+		}
+		data, err := json.MarshalIndent(span, "", "  ")
+		if err != nil {
+			return err
+		}
+		if _, err = fmt.Fprintf(e.w, "[cozeloop] span %q type=%s trace_id=%s span_id=%s parent_id=%s duration=%v status_code=%d\n%s\n",
+			span.SpanName, span.SpanType, span.TraceID, span.SpanID, span.ParentID,
+			time.Duration(span.DurationMicros)*time.Microsecond, span.StatusCode, data); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (e *DebugExporter) ExportFiles(ctx context.Context, files []*entity.UploadFile) error {
+	e.lock.Lock()
+	defer e.lock.Unlock()
+	for _, file := range files {
+		if file == nil {
+			continue
+		}
+		if _, err := fmt.Fprintf(e.w, "[cozeloop] file key=%s tag=%s type=%s size=%d\n",
+			file.TosKey, file.TagKey, file.FileType, len(file.Data)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+var _ Exporter = (*teeExporter)(nil)
+
+// teeExporter exports to all exporters in order, and returns the error of the last one.
+// It is used to report spans while printing them, the reporting exporter should be the last one,
+// so the retry of reporting works as before.
+type teeExporter []Exporter
+
+func (t teeExporter) ExportSpans(ctx context.Context, spans []*entity.UploadSpan) error {
+	var err error
+	for _, e := range t {
+		err = e.ExportSpans(ctx, spans)
+	}
+	return err
+}
+
+func (t teeExporter) ExportFiles(ctx context.Context, files []*entity.UploadFile) error {
+	var err error
+	for _, e := range t {
+		err = e.ExportFiles(ctx, files)
+	}
+	return err
+}
+
+// debugExporter returns the exporter of debug mode, which prints spans, and reports them by exporter
+// if conf.AlsoReport is true.
+func debugExporter(conf *DebugConf, exporter Exporter) Exporter {
+	debug := NewDebugExporter(conf.Writer)
+	if !conf.AlsoReport {
+		return debug
+	}
+	return teeExporter{debug, exporter}
+}
@@ -0,0 +1,67 @@
+// Copyright (c) 2025 Bytedance Ltd. and/or its affiliates
+// SPDX-License-Identifier: MIT
+
+package trace
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+
+	"github.com/coze-dev/cozeloop-go/entity"
+)
+
+type errExporter struct {
+	spans []*entity.UploadSpan
+}
+
+func (e *errExporter) ExportSpans(ctx context.Context, spans []*entity.UploadSpan) error {
+	e.spans = append(e.spans, spans...)
+	return errors.New("export failed")
+}
+
+func (e *errExporter) ExportFiles(ctx context.Context, files []*entity.UploadFile) error {
+	return nil
+}
+
+func TestDebugExporter(t *testing.T) {
+	ctx := context.Background()
+	Convey("Test debug exporter prints spans and files", t, func() {
+		var buf bytes.Buffer
+		e := NewDebugExporter(&buf)
+		err := e.ExportSpans(ctx, []*entity.UploadSpan{{SpanName: "llm_call", SpanType: "model", TraceID: "t1", SpanID: "s1", DurationMicros: 1500}})
+		So(err, ShouldBeNil)
+		err = e.ExportFiles(ctx, []*entity.UploadFile{{TosKey: "key", TagKey: "input", FileType: fileTypeText, Data: "abc"}})
+		So(err, ShouldBeNil)
+		So(buf.String(), ShouldContainSubstring, `[cozeloop] span "llm_call" type=model trace_id=t1 span_id=s1 parent_id= duration=1.5ms`)
+		So(buf.String(), ShouldContainSubstring, `"span_name": "llm_call"`)
+		So(buf.String(), ShouldContainSubstring, "[cozeloop] file key=key tag=input type=text size=3")
+	})
+
+	Convey("Test debug exporter reports spans as well", t, func() {
+		var buf bytes.Buffer
+		reporter := &errExporter{}
+		e := debugExporter(&DebugConf{Writer: &buf, AlsoReport: true}, reporter)
+		err := e.ExportSpans(ctx, []*entity.UploadSpan{{SpanName: "span"}})
+		// error of reporting is returned for retry
+		So(err, ShouldNotBeNil)
+		So(len(reporter.spans), ShouldEqual, 1)
+		So(buf.String(), ShouldContainSubstring, `"span"`)
+
+		So(debugExporter(&DebugConf{Writer: &buf}, reporter), ShouldHaveSameTypeAs, &DebugExporter{})
+	})
+
+	Convey("Test debug mode of provider", t, func() {
+		var buf bytes.Buffer
+		provider := NewTraceProvider(nil, Options{Debug: &DebugConf{Writer: &buf}})
+		_, span, err := provider.StartSpan(ctx, "debug_span", "custom", StartSpanOptions{})
+		So(err, ShouldBeNil)
+		span.Finish(ctx)
+		_, err = provider.CloseTrace(ctx)
+		So(err, ShouldBeNil)
+		So(buf.String(), ShouldContainSubstring, `[cozeloop] span "debug_span"`)
+	})
+}
@@ -0,0 +1,73 @@
+// Copyright (c) 2025 Bytedance Ltd. and/or its affiliates
+// SPDX-License-Identifier: MIT
+
+package trace
+
+import (
+	"context"
+
+	"github.com/coze-dev/cozeloop-go/entity"
+	"github.com/coze-dev/cozeloop-go/internal/httpclient"
+)
+
+// Destination a secondary destination which spans are reported to, in addition to the workspace of client, such as
+// the workspace of a central observability team. Every destination has its own queues and exporter, so that a slow
+// or failing destination never blocks or drops the spans of the others.
+type Destination struct {
+	// WorkspaceID the workspace the spans are reported to, the workspace of spans is kept if it is empty
+	WorkspaceID string
+	// Exporter exports the spans of destination, the spans are reported to CozeLoop by the client if it is nil
+	Exporter Exporter
+	// QueueConf conf of the queues of destination, the defaults are used if it is nil
+	QueueConf *QueueConf
+}
+
+// newDestinationProcessor creates the batch processor reporting spans to the destination.
+func newDestinationProcessor(httpClient *httpclient.Client, uploadPath *UploadPath, dest *Destination,
+	options Options,
+) SpanProcessor {
+	var exporter Exporter
+	if dest.Exporter != nil {
+		exporter = dest.Exporter
+	} else {
+		spanExporter := newSpanExporter(httpClient, uploadPath)
+		spanExporter.wireFormat = options.WireFormat
+		exporter = spanExporter
+	}
+	if dest.WorkspaceID != "" {
+		exporter = &workspaceExporter{Exporter: exporter, workspaceID: dest.WorkspaceID}
+	}
+	return NewBatchSpanProcessor(exporter, httpClient, uploadPath, nil, dest.QueueConf, options.SpanRedactor, "")
+}
+
+// workspaceExporter exports spans and files to workspaceID instead of their own workspaces.
+type workspaceExporter struct {
+	Exporter
+	workspaceID string
+}
+
+func (e *workspaceExporter) ExportSpans(ctx context.Context, spans []*entity.UploadSpan) error {
+	copied := make([]*entity.UploadSpan, 0, len(spans))
+	for _, span := range spans {
+		if span == nil {
+			continue
+		}
+		s := *span
+		s.WorkspaceID = e.workspaceID
+		copied = append(copied, &s)
+	}
+	return e.Exporter.ExportSpans(ctx, copied)
+}
+
+func (e *workspaceExporter) ExportFiles(ctx context.Context, files []*entity.UploadFile) error {
+	copied := make([]*entity.UploadFile, 0, len(files))
+	for _, file := range files {
+		if file == nil {
+			continue
+		}
+		f := *file
+		f.SpaceID = e.workspaceID
+		copied = append(copied, &f)
+	}
+	return e.Exporter.ExportFiles(ctx, copied)
+}
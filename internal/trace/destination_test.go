@@ -0,0 +1,60 @@
+// Copyright (c) 2025 Bytedance Ltd. and/or its affiliates
+// SPDX-License-Identifier: MIT
+
+package trace
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestDestinations(t *testing.T) {
+	ctx := context.Background()
+	Convey("Test spans are reported to every destination independently", t, func() {
+		primary := &replayExporter{}
+		blocked := &blockingExporter{release: make(chan struct{})}
+		failing := &replayExporter{err: errors.New("unavailable")}
+		central := &replayExporter{}
+		provider := NewTraceProvider(nil, Options{
+			WorkspaceID: "123",
+			Exporter:    primary,
+			Destinations: []*Destination{
+				{Exporter: blocked},
+				{Exporter: failing, QueueConf: &QueueConf{MaxRetries: -1}},
+				{WorkspaceID: "central", Exporter: central},
+				nil,
+			},
+		})
+		defer func() {
+			_, _ = provider.CloseTrace(ctx)
+		}()
+
+		spanCtx, root, err := provider.StartSpan(ctx, "root", "custom", StartSpanOptions{})
+		So(err, ShouldBeNil)
+		_, child, err := provider.StartSpan(spanCtx, "child", "custom", StartSpanOptions{})
+		So(err, ShouldBeNil)
+		child.Finish(spanCtx)
+		root.Finish(ctx)
+
+		// the blocked destination does not delay the others
+		So(provider.batchProcessor.ForceFlush(ctx), ShouldBeNil)
+		So(primary.spanCount(), ShouldEqual, 2)
+		So(primary.spans[0].WorkspaceID, ShouldEqual, "123")
+		for _, processor := range provider.spanProcessor.(multiSpanProcessor)[3:] {
+			So(processor.ForceFlush(ctx), ShouldBeNil)
+		}
+		So(central.spanCount(), ShouldEqual, 2)
+		So(central.spans[0].WorkspaceID, ShouldEqual, "central")
+		So(central.spans[1].WorkspaceID, ShouldEqual, "central")
+		So(blocked.spanCount(), ShouldEqual, 0)
+
+		close(blocked.release)
+		provider.Flush(ctx)
+		So(blocked.spanCount(), ShouldEqual, 2)
+		So(primary.spanCount(), ShouldEqual, 2)
+		So(provider.Stats().SpansFlushed, ShouldEqual, 2)
+	})
+}
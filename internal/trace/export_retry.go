@@ -0,0 +1,170 @@
+// Copyright (c) 2025 Bytedance Ltd. and/or its affiliates
+// SPDX-License-Identifier: MIT
+
+package trace
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/coze-dev/cozeloop-go/entity"
+	"github.com/coze-dev/cozeloop-go/internal/consts"
+)
+
+const (
+	defaultExportMaxRetries = 1
+	maxExportRetryBackoff   = 30 * time.Second
+)
+
+// ExportDropped the spans or files dropped after the export retries failed, see QueueConf.OnExportDropped.
+type ExportDropped struct {
+	Spans []*entity.UploadSpan
+	Files []*entity.UploadFile
+	// Err the error of the last export
+	Err error
+}
+
+// ExportResult the result of exporting a batch of spans or files, see QueueConf.OnExportResult.
+type ExportResult struct {
+	// Spans the spans in batch, nil for the batch of files
+	Spans []*entity.UploadSpan
+	// Files the files in batch, nil for the batch of spans
+	Files []*entity.UploadFile
+	// Retry whether the batch is exported by retry queue
+	Retry bool
+	// Err the error of export, nil if the batch is delivered
+	Err error
+	// StatusCode the http status code of the failed request, 0 if it is unknown, such as network error or
+	// the error of custom exporter
+	StatusCode int
+	// LogID the log id of the failed request, which helps to troubleshoot with the platform
+	LogID string
+	// Latency time taken by the export, including the retries
+	Latency time.Duration
+}
+
+// TraceIDs returns the distinct trace ids of the spans in batch.
+func (r *ExportResult) TraceIDs() []string {
+	var traceIDs []string
+	seen := make(map[string]struct{}, len(r.Spans))
+	for _, span := range r.Spans {
+		if span == nil {
+			continue
+		}
+		if _, ok := seen[span.TraceID]; ok {
+			continue
+		}
+		seen[span.TraceID] = struct{}{}
+		traceIDs = append(traceIDs, span.TraceID)
+	}
+	return traceIDs
+}
+
+// exportRetrier retries the export in retry queues with backoff, and reports the items dropped.
+type exportRetrier struct {
+	maxRetries int
+	backoff    time.Duration
+	onDropped  func(ctx context.Context, dropped *ExportDropped)
+	onResult   func(ctx context.Context, result *ExportResult)
+
+	stopCh   chan struct{}
+	stopOnce sync.Once
+}
+
+func newExportRetrier(conf *QueueConf) *exportRetrier {
+	r := &exportRetrier{
+		maxRetries: defaultExportMaxRetries,
+		stopCh:     make(chan struct{}),
+	}
+	if conf != nil {
+		if conf.MaxRetries != 0 {
+			r.maxRetries = conf.MaxRetries
+		}
+		r.backoff = conf.RetryBackoff
+		r.onDropped = conf.OnExportDropped
+		r.onResult = conf.OnExportResult
+	}
+	return r
+}
+
+// enabled returns whether the items failed in the first export are sent to retry queue.
+func (r *exportRetrier) enabled() bool {
+	return r == nil || r.maxRetries > 0
+}
+
+// retry calls export until it succeeds or maxRetries is reached, the delay between calls is doubled from backoff.
+// It stops waiting and returns the last error once the retrier is stopped.
+func (r *exportRetrier) retry(ctx context.Context, export func() error) error {
+	if r == nil {
+		return export()
+	}
+	var err error
+	backoff := r.backoff
+	for i := 0; i < r.maxRetries; i++ {
+		if i > 0 {
+			if !r.wait(ctx, backoff) {
+				return err
+			}
+			backoff *= 2
+			if backoff > maxExportRetryBackoff {
+				backoff = maxExportRetryBackoff
+			}
+		}
+		if err = export(); err == nil {
+			return nil
+		}
+	}
+	return err
+}
+
+func (r *exportRetrier) wait(ctx context.Context, d time.Duration) bool {
+	select {
+	case <-r.stopCh:
+		return false
+	case <-ctx.Done():
+		return false
+	default:
+	}
+	if d <= 0 {
+		return true
+	}
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return true
+	case <-r.stopCh:
+		return false
+	case <-ctx.Done():
+		return false
+	}
+}
+
+func (r *exportRetrier) dropped(ctx context.Context, dropped *ExportDropped) {
+	if r != nil && r.onDropped != nil {
+		r.onDropped(ctx, dropped)
+	}
+}
+
+func (r *exportRetrier) result(ctx context.Context, result *ExportResult) {
+	if r == nil || r.onResult == nil {
+		return
+	}
+	var remoteErr *consts.RemoteServiceError
+	if errors.As(result.Err, &remoteErr) {
+		result.StatusCode = remoteErr.HttpCode
+		result.LogID = remoteErr.LogID
+	}
+	r.onResult(ctx, result)
+}
+
+// stop stops waiting for backoff, so that shutdown is not blocked by retries.
+func (r *exportRetrier) stop() {
+	if r != nil {
+		r.stopOnce.Do(func() {
+			close(r.stopCh)
+		})
+	}
+}
@@ -0,0 +1,126 @@
+// Copyright (c) 2025 Bytedance Ltd. and/or its affiliates
+// SPDX-License-Identifier: MIT
+
+package trace
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	. "github.com/smartystreets/goconvey/convey"
+
+	"github.com/coze-dev/cozeloop-go/entity"
+	"github.com/coze-dev/cozeloop-go/internal/consts"
+)
+
+// flakyExporter fails the first failures exports of spans with err, or a default error if err is nil.
+type flakyExporter struct {
+	mu       sync.Mutex
+	failures int
+	calls    int
+	err      error
+}
+
+func (e *flakyExporter) ExportSpans(ctx context.Context, spans []*entity.UploadSpan) error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.calls++
+	if e.calls <= e.failures {
+		if e.err != nil {
+			return e.err
+		}
+		return errors.New("unavailable")
+	}
+	return nil
+}
+
+func (e *flakyExporter) ExportFiles(ctx context.Context, files []*entity.UploadFile) error {
+	return nil
+}
+
+func TestExportRetry(t *testing.T) {
+	ctx := context.Background()
+	Convey("Test spans are exported again until max retries", t, func() {
+		var dropped []*ExportDropped
+		newProcessor := func(exporter Exporter, maxRetries int) SpanProcessor {
+			return NewBatchSpanProcessor(exporter, nil, nil, nil, &QueueConf{
+				MaxRetries:   maxRetries,
+				RetryBackoff: time.Millisecond,
+				OnExportDropped: func(ctx context.Context, d *ExportDropped) {
+					dropped = append(dropped, d)
+				},
+			}, nil, "")
+		}
+
+		Convey("spans succeed in retries are flushed", func() {
+			exporter := &flakyExporter{failures: 3}
+			processor := newProcessor(exporter, 3)
+			processor.OnSpanEnd(ctx, &Span{})
+			So(processor.ForceFlush(ctx), ShouldBeNil)
+			report, err := processor.Shutdown(ctx)
+			So(err, ShouldBeNil)
+			So(exporter.calls, ShouldEqual, 4)
+			So(report.SpansFlushed, ShouldEqual, 1)
+			So(len(dropped), ShouldEqual, 0)
+		})
+
+		Convey("spans failed after retries are reported as dropped", func() {
+			exporter := &flakyExporter{failures: 10}
+			processor := newProcessor(exporter, 2)
+			processor.OnSpanEnd(ctx, &Span{SpanContext: SpanContext{SpanID: "span-id"}})
+			So(processor.ForceFlush(ctx), ShouldBeNil)
+			report, err := processor.Shutdown(ctx)
+			So(err, ShouldBeNil)
+			So(exporter.calls, ShouldEqual, 3)
+			So(report.SpansDropped, ShouldEqual, 1)
+			So(len(dropped), ShouldEqual, 1)
+			So(dropped[0].Spans[0].SpanID, ShouldEqual, "span-id")
+			So(dropped[0].Err, ShouldNotBeNil)
+		})
+
+		Convey("spans are dropped without retry if max retries is negative", func() {
+			exporter := &flakyExporter{failures: 10}
+			processor := newProcessor(exporter, -1)
+			processor.OnSpanEnd(ctx, &Span{})
+			So(processor.ForceFlush(ctx), ShouldBeNil)
+			_, err := processor.Shutdown(ctx)
+			So(err, ShouldBeNil)
+			So(exporter.calls, ShouldEqual, 1)
+			So(len(dropped), ShouldEqual, 1)
+		})
+	})
+}
+
+func TestExportResult(t *testing.T) {
+	ctx := context.Background()
+	Convey("Test results of first export and retry are reported", t, func() {
+		var results []*ExportResult
+		exporter := &flakyExporter{
+			failures: 1,
+			err:      consts.NewError("export spans fail").Wrap(consts.NewRemoteServiceError(503, -1, "", "log-id")),
+		}
+		processor := NewBatchSpanProcessor(exporter, nil, nil, nil, &QueueConf{
+			OnExportResult: func(ctx context.Context, result *ExportResult) {
+				results = append(results, result)
+			},
+		}, nil, "")
+		processor.OnSpanEnd(ctx, &Span{SpanContext: SpanContext{TraceID: "trace-id", SpanID: "span-1"}})
+		processor.OnSpanEnd(ctx, &Span{SpanContext: SpanContext{TraceID: "trace-id", SpanID: "span-2"}})
+		So(processor.ForceFlush(ctx), ShouldBeNil)
+		_, err := processor.Shutdown(ctx)
+		So(err, ShouldBeNil)
+
+		So(len(results), ShouldEqual, 2)
+		So(results[0].Retry, ShouldBeFalse)
+		So(results[0].Err, ShouldNotBeNil)
+		So(results[0].StatusCode, ShouldEqual, 503)
+		So(results[0].LogID, ShouldEqual, "log-id")
+		So(results[1].Retry, ShouldBeTrue)
+		So(results[1].Err, ShouldBeNil)
+		So(len(results[1].Spans), ShouldEqual, 2)
+		So(results[1].TraceIDs(), ShouldResemble, []string{"trace-id"})
+	})
+}
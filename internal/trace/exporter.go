@@ -9,6 +9,7 @@ import (
 	"encoding/base64"
 	"encoding/json"
 	"fmt"
+	"sync/atomic"
 
 	"github.com/coze-dev/cozeloop-go/entity"
 	"github.com/coze-dev/cozeloop-go/internal/consts"
@@ -31,6 +32,8 @@ const (
 	fileTypeText  = "text"
 	fileTypeImage = "image"
 	fileTypeFile  = "file"
+	fileTypeAudio = "audio"
+	fileTypeVideo = "video"
 
 	pathIngestTrace = "/v1/loop/traces/ingest"
 	pathUploadFile  = "/v1/loop/files/upload"
@@ -41,6 +44,10 @@ var _ Exporter = (*SpanExporter)(nil)
 type SpanExporter struct {
 	client     *httpclient.Client
 	uploadPath UploadPath
+	blobStore  BlobStore // files are put to it instead of uploading to CozeLoop if it is not nil
+	wireFormat WireFormat
+	// msgpackRejected is set once the ingest endpoint rejects msgpack, spans are exported as JSON after that
+	msgpackRejected int32
 }
 
 type UploadPath struct {
@@ -48,6 +55,27 @@ type UploadPath struct {
 	fileUploadPath string
 }
 
+// newSpanExporter creates the exporter reporting to CozeLoop, the default paths are used if uploadPath is nil.
+func newSpanExporter(client *httpclient.Client, uploadPath *UploadPath) *SpanExporter {
+	spanPath := pathIngestTrace
+	filePath := pathUploadFile
+	if uploadPath != nil {
+		if uploadPath.spanUploadPath != "" {
+			spanPath = uploadPath.spanUploadPath
+		}
+		if uploadPath.fileUploadPath != "" {
+			filePath = uploadPath.fileUploadPath
+		}
+	}
+	return &SpanExporter{
+		client: client,
+		uploadPath: UploadPath{
+			spanUploadPath: spanPath,
+			fileUploadPath: filePath,
+		},
+	}
+}
+
 func (e *SpanExporter) ExportFiles(ctx context.Context, files []*entity.UploadFile) error {
 	uploadFiles := files
 	for _, file := range uploadFiles {
@@ -55,15 +83,23 @@ func (e *SpanExporter) ExportFiles(ctx context.Context, files []*entity.UploadFi
 			continue
 		}
 		logger.CtxDebugf(ctx, "uploadFile start, file name: %s", file.Name)
+		if e.blobStore != nil {
+			if err := e.blobStore.Put(ctx, file); err != nil {
+				return consts.NewError(fmt.Sprintf("export files[%s] to blob store fail", file.TosKey)).Wrap(err)
+			}
+			logger.CtxDebugf(ctx, "uploadFile end, file name: %s", file.Name)
+			continue
+		}
 		resp := httpclient.BaseResponse{}
 		err := e.client.UploadFile(ctx, e.uploadPath.fileUploadPath, file.TosKey, bytes.NewReader([]byte(file.Data)), map[string]string{"workspace_id": file.SpaceID}, &resp)
 		if err != nil {
+			logger.CtxDebugf(ctx, "uploadFile fail, file name: %s, logID: %s", file.Name, consts.RequestIDOf(err))
 			return consts.NewError(fmt.Sprintf("export files[%s] fail", file.TosKey)).Wrap(err)
 		}
 		if resp.GetCode() != 0 { // todo: some err code do not need retry
 			return consts.NewError(fmt.Sprintf("export files[%s] fail, code:[%v], msg:[%v] retry later", file.TosKey, resp.GetCode(), resp.GetMsg()))
 		}
-		logger.CtxDebugf(ctx, "uploadFile end, file name: %s", file.Name)
+		logger.CtxDebugf(ctx, "uploadFile end, file name: %s, logID: %s", file.Name, resp.GetLogID())
 	}
 
 	return nil
@@ -74,13 +110,25 @@ func (e *SpanExporter) ExportSpans(ctx context.Context, ss []*entity.UploadSpan)
 		return
 	}
 	resp := httpclient.BaseResponse{}
-	err = e.client.Post(ctx, e.uploadPath.spanUploadPath, UploadSpanData{ss}, &resp)
+	if e.wireFormat == WireFormatMsgpack && atomic.LoadInt32(&e.msgpackRejected) == 0 {
+		err = e.client.PostCompressed(ctx, e.uploadPath.spanUploadPath, msgpackSpanData{ss}, &resp)
+		if isUnsupportedMediaType(err) {
+			atomic.StoreInt32(&e.msgpackRejected, 1)
+			logger.CtxWarnf(ctx, "msgpack is not supported by ingest endpoint, spans are exported as JSON")
+			resp = httpclient.BaseResponse{}
+			err = e.client.PostCompressed(ctx, e.uploadPath.spanUploadPath, UploadSpanData{ss}, &resp)
+		}
+	} else {
+		err = e.client.PostCompressed(ctx, e.uploadPath.spanUploadPath, UploadSpanData{ss}, &resp)
+	}
 	if err != nil {
+		logger.CtxDebugf(ctx, "export spans fail, span count: %d, logID: %s", len(ss), consts.RequestIDOf(err))
 		return consts.NewError(fmt.Sprintf("export spans fail, span count: [%d]", len(ss))).Wrap(err)
 	}
 	if resp.GetCode() != 0 { // todo: some err code do not need retry
 		return consts.NewError(fmt.Sprintf("export spans fail, span count: [%d], code:[%v], msg:[%v]", len(ss), resp.GetCode(), resp.GetMsg()))
 	}
+	logger.CtxDebugf(ctx, "export spans success, span count: %d, logID: %s", len(ss), resp.GetLogID())
 
 	return
 }
@@ -229,12 +277,11 @@ func convertInput(ctx context.Context, spanKey string, span *Span) (valueRes str
 				uploadFile = append(uploadFile, fs...)
 			}
 		}
-		tempV, err := json.Marshal(modelInput)
+		valueRes, err = marshalMultiModality(value, modelInput, len(uploadFile) > 0)
 		if err != nil {
 			logger.CtxErrorf(ctx, "marshal multiModalityContent failed, err: %v", err)
 			return valueRes, nil, err
 		}
-		valueRes = string(tempV)
 
 		// If the content is still too long, truncate it, and
 		// decide whether to report the oversized content based on the UltraLargeReport option.
@@ -280,12 +327,11 @@ func convertOutput(ctx context.Context, spanKey string, span *Span) (valueRes st
 				uploadFile = append(uploadFile, files...)
 			}
 		}
-		tempV, err := json.Marshal(modelOutput)
+		valueRes, err = marshalMultiModality(value, modelOutput, len(uploadFile) > 0)
 		if err != nil {
 			logger.CtxErrorf(ctx, "marshal multiModalityContent failed, err: %v", err)
 			return valueRes, nil, err
 		}
-		valueRes = string(tempV)
 
 		// If the content is still too long, truncate it, and
 		// decide whether to report the oversized content based on the UltraLargeReport option.
@@ -301,6 +347,15 @@ func convertOutput(ctx context.Context, spanKey string, span *Span) (valueRes st
 	return
 }
 
+// marshalMultiModality returns the original tag value if no part has been replaced by uploaded file,
+// so that the multi-modality content is not marshaled again.
+func marshalMultiModality(original interface{}, content interface{}, replaced bool) (string, error) {
+	if originalStr, ok := original.(string); ok && !replaced {
+		return originalStr, nil
+	}
+	return util.ToJSONE(content)
+}
+
 func parseInputOutput(ctx context.Context, span *Span) (spanUploadFiles []*entity.UploadFile, putContentMap map[string]string, err error) {
 	if span == nil {
 		return
@@ -374,6 +429,18 @@ func transferMessagePart(src *tracespec.ModelMessagePart, span *Span, tagKey str
 		if f := transferFile(src.FileURL, span, tagKey); f != nil {
 			uploadFiles = append(uploadFiles, f)
 		}
+	case tracespec.ModelMessagePartTypeAudio:
+		if src.AudioURL != nil {
+			if f := transferMedia(&src.AudioURL.URL, src.AudioURL.Name, fileTypeAudio, span, tagKey); f != nil {
+				uploadFiles = append(uploadFiles, f)
+			}
+		}
+	case tracespec.ModelMessagePartTypeVideo:
+		if src.VideoURL != nil {
+			if f := transferMedia(&src.VideoURL.URL, src.VideoURL.Name, fileTypeVideo, span, tagKey); f != nil {
+				uploadFiles = append(uploadFiles, f)
+			}
+		}
 	case tracespec.ModelMessagePartTypeText:
 		return
 	default:
@@ -392,7 +459,7 @@ func transferText(src string, span *Span, tagKey string) (string, *entity.Upload
 		return src, nil
 	}
 
-	if len(src) > consts.MaxBytesOfOneTagValueOfInputOutput {
+	if len(src) > span.getTagValueSizeLimit(tagKey) {
 		// key := "traceid/spanid/tagkey/filetype/large_text"
 		key := fmt.Sprintf(KeyTemplateLargeText, span.GetTraceID(), span.GetSpanID(), tagKey, fileTypeText)
 		return util.TruncateStringByChar(src, consts.TextTruncateCharLength), &entity.UploadFile{
@@ -454,6 +521,30 @@ func transferFile(src *tracespec.ModelFileURL, span *Span, tagKey string) *entit
 	}
 }
 
+// transferMedia replaces the base64 data of audio or video part in url by the key of file uploaded.
+func transferMedia(url *string, name, fileType string, span *Span, tagKey string) *entity.UploadFile {
+	if url == nil || *url == "" || span == nil {
+		return nil
+	}
+	if isValidURL := util.IsValidURL(*url); isValidURL {
+		return nil
+	}
+
+	// key := "traceid_spanid_tagkey_filetype_randomid"
+	key := fmt.Sprintf(KeyTemplateMultiModality, span.GetTraceID(), span.GetSpanID(), tagKey, fileType, util.Gen16CharID())
+	bin, _ := base64.StdEncoding.DecodeString(*url)
+	*url = key
+	return &entity.UploadFile{
+		TosKey:     key,
+		Data:       string(bin),
+		UploadType: entity.UploadTypeMultiModality,
+		TagKey:     tagKey,
+		Name:       name,
+		FileType:   fileType,
+		SpaceID:    span.GetSpaceID(),
+	}
+}
+
 type UploadSpanData struct {
 	Spans []*entity.UploadSpan `json:"spans"`
 }
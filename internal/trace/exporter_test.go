@@ -5,20 +5,152 @@ package trace
 
 import (
 	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
 	"testing"
 
 	. "github.com/bytedance/mockey"
+	"github.com/coze-dev/cozeloop-go/entity"
 	"github.com/coze-dev/cozeloop-go/internal/httpclient"
+	"github.com/coze-dev/cozeloop-go/spec/tracespec"
 	. "github.com/smartystreets/goconvey/convey"
 )
 
 func Test_ExportSpans(t *testing.T) {
 	ctx := context.Background()
-	spans := []*UploadSpan{{}, {}}
+	spans := []*entity.UploadSpan{{}, {}}
 
 	PatchConvey("Test transferToUploadSpanAndFile failed", t, func() {
-		Mock((*httpclient.Client).Post).Return(nil).Build()
+		Mock((*httpclient.Client).PostCompressed).Return(nil).Build()
 		err := (&SpanExporter{}).ExportSpans(ctx, spans)
 		So(err, ShouldBeNil)
 	})
 }
+
+type countingHTTPClient struct {
+	bytes int64
+}
+
+func (c *countingHTTPClient) Do(req *http.Request) (*http.Response, error) {
+	n, _ := io.Copy(io.Discard, req.Body)
+	_ = req.Body.Close()
+	c.bytes += n
+	return &http.Response{StatusCode: http.StatusOK, Body: io.NopCloser(strings.NewReader(`{"code":0}`))}, nil
+}
+
+func benchmarkExportSpans(b *testing.B, gzipRequest bool) {
+	ctx := context.Background()
+	httpClient := &countingHTTPClient{}
+	exporter := &SpanExporter{
+		client: httpclient.NewClient("http://test", httpClient, httpclient.NewTokenAuth("token"),
+			&httpclient.ClientOptions{GzipRequest: gzipRequest}),
+		uploadPath: UploadPath{spanUploadPath: pathIngestTrace},
+	}
+	spans := make([]*entity.UploadSpan, 0, 100)
+	for i := 0; i < 100; i++ {
+		spans = append(spans, &entity.UploadSpan{
+			TraceID:  "0123456789abcdef0123456789abcdef",
+			SpanID:   fmt.Sprintf("%016x", i),
+			SpanName: "llm_call",
+			SpanType: tracespec.VModelSpanType,
+			Input:    strings.Repeat(`{"role":"user","content":"What is the weather like today?"}`, 50),
+			Output:   strings.Repeat(`{"role":"assistant","content":"It is sunny today."}`, 50),
+			TagsString: map[string]string{
+				tracespec.ModelName: "gpt-4o",
+			},
+		})
+	}
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if err := exporter.ExportSpans(ctx, spans); err != nil {
+			b.Fatal(err)
+		}
+	}
+	b.ReportMetric(float64(httpClient.bytes)/float64(b.N), "req_bytes/op")
+}
+
+func BenchmarkExportSpans(b *testing.B) {
+	b.Run("raw", func(b *testing.B) {
+		benchmarkExportSpans(b, false)
+	})
+	b.Run("gzip", func(b *testing.B) {
+		benchmarkExportSpans(b, true)
+	})
+}
+
+func BenchmarkTransferToUploadSpanAndFile(b *testing.B) {
+	ctx := context.Background()
+	spans := make([]*Span, 0, 100)
+	for i := 0; i < 100; i++ {
+		s := newMockSpan()
+		s.SystemTagMap = make(map[string]interface{})
+		s.SetInput(ctx, &tracespec.ModelInput{
+			Messages: []*tracespec.ModelMessage{
+				{
+					Role: tracespec.VRoleUser,
+					Parts: []*tracespec.ModelMessagePart{
+						{Type: tracespec.ModelMessagePartTypeText, Text: strings.Repeat("describe the image ", 20)},
+						{Type: tracespec.ModelMessagePartTypeImage, ImageURL: &tracespec.ModelImageURL{URL: "https://example.com/image.png"}},
+					},
+				},
+			},
+		})
+		s.SetOutput(ctx, strings.Repeat("It is a cat. ", 50))
+		s.SetTags(ctx, map[string]interface{}{"tag_str": "value", "tag_int": 1, "tag_float": 1.5, "tag_obj": map[string]string{"k": "v"}})
+		spans = append(spans, s)
+	}
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		transferToUploadSpanAndFile(ctx, spans)
+	}
+}
+
+func Test_TransferAudioVideoParts(t *testing.T) {
+	ctx := context.Background()
+	Convey("Test base64 audio and video parts are uploaded as attachments", t, func() {
+		s := newMockSpan()
+		s.SystemTagMap = make(map[string]interface{})
+		s.SetInput(ctx, &tracespec.ModelInput{
+			Messages: []*tracespec.ModelMessage{{
+				Role: tracespec.VRoleUser,
+				Parts: []*tracespec.ModelMessagePart{
+					{Type: tracespec.ModelMessagePartTypeAudio, AudioURL: &tracespec.ModelAudioURL{Name: "question.wav", URL: "data:audio/wav;base64,YXVkaW8="}},
+					{Type: tracespec.ModelMessagePartTypeVideo, VideoURL: &tracespec.ModelVideoURL{URL: "https://example.com/video.mp4"}},
+				},
+			}},
+		})
+		s.SetOutput(ctx, &tracespec.ModelOutput{
+			Choices: []*tracespec.ModelChoice{{
+				Message: &tracespec.ModelMessage{
+					Role: tracespec.VRoleAssistant,
+					Parts: []*tracespec.ModelMessagePart{
+						{Type: tracespec.ModelMessagePartTypeVideo, VideoURL: &tracespec.ModelVideoURL{URL: "data:video/mp4;base64,dmlkZW8="}},
+					},
+				},
+			}},
+		})
+
+		spans, files := transferToUploadSpanAndFile(ctx, []*Span{s})
+		So(len(spans), ShouldEqual, 1)
+		So(len(files), ShouldEqual, 2)
+		fileMap := make(map[string]*entity.UploadFile)
+		for _, f := range files {
+			fileMap[f.FileType] = f
+		}
+		So(fileMap[fileTypeAudio].Data, ShouldEqual, "audio")
+		So(fileMap[fileTypeAudio].Name, ShouldEqual, "question.wav")
+		So(fileMap[fileTypeAudio].TagKey, ShouldEqual, tracespec.Input)
+		So(fileMap[fileTypeVideo].Data, ShouldEqual, "video")
+		So(fileMap[fileTypeVideo].TagKey, ShouldEqual, tracespec.Output)
+		So(spans[0].Input, ShouldContainSubstring, fileMap[fileTypeAudio].TosKey)
+		So(spans[0].Input, ShouldContainSubstring, "https://example.com/video.mp4")
+		So(spans[0].Output, ShouldContainSubstring, fileMap[fileTypeVideo].TosKey)
+		So(spans[0].ObjectStorage, ShouldContainSubstring, `"type":"audio"`)
+	})
+}
@@ -0,0 +1,47 @@
+// Copyright (c) 2025 Bytedance Ltd. and/or its affiliates
+// SPDX-License-Identifier: MIT
+
+package trace
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/coze-dev/cozeloop-go/entity"
+	"github.com/coze-dev/cozeloop-go/internal/consts"
+	"github.com/coze-dev/cozeloop-go/internal/httpclient"
+)
+
+const (
+	pathReportFeedback = "/v1/loop/traces/feedback/report"
+)
+
+type ReportFeedbackRequest struct {
+	WorkspaceID string           `json:"workspace_id"`
+	TraceID     string           `json:"trace_id"`
+	SpanID      string           `json:"span_id"`
+	Feedback    *entity.Feedback `json:"feedback"`
+}
+
+// ReportFeedback reports end-user feedback of the span to the platform.
+func (t *Provider) ReportFeedback(ctx context.Context, traceID, spanID string, feedback *entity.Feedback) error {
+	if traceID == "" || spanID == "" {
+		return consts.ErrInvalidParam.Wrap(fmt.Errorf("trace id and span id are required"))
+	}
+	if feedback == nil || (feedback.Thumbs == "" && feedback.Score == nil && feedback.Comment == "") {
+		return consts.ErrInvalidParam.Wrap(fmt.Errorf("feedback is empty"))
+	}
+	switch feedback.Thumbs {
+	case "", entity.FeedbackThumbsUp, entity.FeedbackThumbsDown:
+	default:
+		return consts.ErrInvalidParam.Wrap(fmt.Errorf("invalid feedback thumbs: %s", feedback.Thumbs))
+	}
+
+	resp := httpclient.BaseResponse{}
+	return t.httpClient.Post(ctx, pathReportFeedback, ReportFeedbackRequest{
+		WorkspaceID: t.opt.WorkspaceID,
+		TraceID:     traceID,
+		SpanID:      spanID,
+		Feedback:    feedback,
+	}, &resp)
+}
@@ -0,0 +1,57 @@
+// Copyright (c) 2025 Bytedance Ltd. and/or its affiliates
+// SPDX-License-Identifier: MIT
+
+package trace
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+
+	"github.com/coze-dev/cozeloop-go/entity"
+	"github.com/coze-dev/cozeloop-go/internal/consts"
+	"github.com/coze-dev/cozeloop-go/internal/httpclient"
+	"github.com/coze-dev/cozeloop-go/internal/util"
+)
+
+func Test_ReportFeedback(t *testing.T) {
+	ctx := context.Background()
+	var req ReportFeedbackRequest
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewDecoder(r.Body).Decode(&req)
+		_, _ = w.Write([]byte(`{"code":0}`))
+	}))
+	defer server.Close()
+	p := &Provider{
+		httpClient: httpclient.NewClient(server.URL, http.DefaultClient, httpclient.NewTokenAuth("token"), nil),
+		opt:        &Options{WorkspaceID: "workspace1"},
+	}
+
+	Convey("Test ReportFeedback with invalid param", t, func() {
+		err := p.ReportFeedback(ctx, "", "span", &entity.Feedback{Comment: "good"})
+		So(errors.Is(err, consts.ErrInvalidParam), ShouldBeTrue)
+		err = p.ReportFeedback(ctx, "trace", "span", &entity.Feedback{})
+		So(errors.Is(err, consts.ErrInvalidParam), ShouldBeTrue)
+		err = p.ReportFeedback(ctx, "trace", "span", &entity.Feedback{Thumbs: "left"})
+		So(errors.Is(err, consts.ErrInvalidParam), ShouldBeTrue)
+	})
+
+	Convey("Test ReportFeedback success", t, func() {
+		err := p.ReportFeedback(ctx, "trace", "span", &entity.Feedback{
+			Thumbs:  entity.FeedbackThumbsUp,
+			Score:   util.Ptr(4.5),
+			Comment: "good",
+		})
+		So(err, ShouldBeNil)
+		So(req.WorkspaceID, ShouldEqual, "workspace1")
+		So(req.TraceID, ShouldEqual, "trace")
+		So(req.SpanID, ShouldEqual, "span")
+		So(req.Feedback.Thumbs, ShouldEqual, entity.FeedbackThumbsUp)
+		So(*req.Feedback.Score, ShouldEqual, 4.5)
+	})
+}
@@ -0,0 +1,73 @@
+// Copyright (c) 2025 Bytedance Ltd. and/or its affiliates
+// SPDX-License-Identifier: MIT
+
+package trace
+
+import (
+	"fmt"
+	"math/rand"
+	"sync"
+
+	"github.com/coze-dev/cozeloop-go/internal/util"
+)
+
+// IDGenerator generates trace id and span id for new spans.
+// TraceID must be 32 hex chars and SpanID must be 16 hex chars, neither of them can be all zero. Invalid ids are
+// replaced by random ones with a warning.
+type IDGenerator interface {
+	NewTraceID() string
+	NewSpanID() string
+}
+
+var _ IDGenerator = (*defaultIDGenerator)(nil)
+var _ IDGenerator = (*deterministicIDGenerator)(nil)
+
+// defaultIDGenerator generates random ids, seeded by crypto/rand.
+type defaultIDGenerator struct{}
+
+func (g *defaultIDGenerator) NewTraceID() string {
+	return util.Gen32CharID()
+}
+
+func (g *defaultIDGenerator) NewSpanID() string {
+	return util.Gen16CharID()
+}
+
+// NewDefaultIDGenerator returns the id generator used by default.
+func NewDefaultIDGenerator() IDGenerator {
+	return &defaultIDGenerator{}
+}
+
+// deterministicIDGenerator generates the same id sequence for the same seed,
+// which is useful for test fixtures and replay.
+type deterministicIDGenerator struct {
+	lock sync.Mutex
+	rand *rand.Rand
+}
+
+// NewDeterministicIDGenerator returns an id generator which always generates the same id sequence for the same seed.
+func NewDeterministicIDGenerator(seed int64) IDGenerator {
+	return &deterministicIDGenerator{
+		rand: rand.New(rand.NewSource(seed)),
+	}
+}
+
+func (g *deterministicIDGenerator) NewTraceID() string {
+	g.lock.Lock()
+	defer g.lock.Unlock()
+	return fmt.Sprintf("%016x%016x", g.nextNonZero(), g.nextNonZero())
+}
+
+func (g *deterministicIDGenerator) NewSpanID() string {
+	g.lock.Lock()
+	defer g.lock.Unlock()
+	return fmt.Sprintf("%016x", g.nextNonZero())
+}
+
+func (g *deterministicIDGenerator) nextNonZero() uint64 {
+	for {
+		if id := g.rand.Uint64(); id != 0 {
+			return id
+		}
+	}
+}
@@ -0,0 +1,85 @@
+// Copyright (c) 2025 Bytedance Ltd. and/or its affiliates
+// SPDX-License-Identifier: MIT
+
+package trace
+
+import (
+	"context"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+
+	"github.com/coze-dev/cozeloop-go/internal/util"
+)
+
+func Test_IDGenerator(t *testing.T) {
+	Convey("deterministic id generator generates same sequence for same seed", t, func() {
+		g1 := NewDeterministicIDGenerator(42)
+		g2 := NewDeterministicIDGenerator(42)
+		for i := 0; i < 10; i++ {
+			traceID, spanID := g1.NewTraceID(), g1.NewSpanID()
+			So(traceID, ShouldEqual, g2.NewTraceID())
+			So(spanID, ShouldEqual, g2.NewSpanID())
+			So(len(traceID), ShouldEqual, 32)
+			So(len(spanID), ShouldEqual, 16)
+			So(util.IsValidHexStr(traceID), ShouldBeTrue)
+			So(util.IsValidHexStr(spanID), ShouldBeTrue)
+		}
+	})
+
+	Convey("deterministic id generator uses all bits of ids", t, func() {
+		g := NewDeterministicIDGenerator(42)
+		highBitSet := false
+		for i := 0; i < 64 && !highBitSet; i++ {
+			highBitSet = g.NewSpanID()[0] >= '8'
+		}
+		So(highBitSet, ShouldBeTrue)
+	})
+
+	Convey("provider uses configured id generator", t, func() {
+		p := &Provider{opt: &Options{IDGenerator: NewDeterministicIDGenerator(1)}}
+		expected := NewDeterministicIDGenerator(1)
+		expectedSpanID := expected.NewSpanID()
+		expectedTraceID := expected.NewTraceID()
+
+		_, span, err := p.StartSpan(context.Background(), "name", "type", StartSpanOptions{})
+		So(err, ShouldBeNil)
+		So(span.GetSpanID(), ShouldEqual, expectedSpanID)
+		So(span.GetTraceID(), ShouldEqual, expectedTraceID)
+	})
+
+	Convey("provider falls back to default id generator", t, func() {
+		p := &Provider{opt: &Options{}}
+		_, span, err := p.StartSpan(context.Background(), "name", "type", StartSpanOptions{})
+		So(err, ShouldBeNil)
+		So(len(span.GetSpanID()), ShouldEqual, 16)
+		So(len(span.GetTraceID()), ShouldEqual, 32)
+	})
+
+	Convey("provider falls back to default id generator if ids are invalid", t, func() {
+		for _, g := range []IDGenerator{
+			&fixedIDGenerator{traceID: "00000000000000000000000000000000", spanID: "0000000000000000"},
+			&fixedIDGenerator{traceID: "trace-1", spanID: "span-1"},
+			&fixedIDGenerator{traceID: "0123456789abcdef0123456789abcdeg", spanID: "0123456789abcdef01"},
+		} {
+			p := &Provider{opt: &Options{IDGenerator: g}}
+			_, span, err := p.StartSpan(context.Background(), "name", "type", StartSpanOptions{})
+			So(err, ShouldBeNil)
+			So(isValidSpanID(span.GetSpanID()), ShouldBeTrue)
+			So(isValidTraceID(span.GetTraceID()), ShouldBeTrue)
+		}
+	})
+}
+
+type fixedIDGenerator struct {
+	traceID string
+	spanID  string
+}
+
+func (g *fixedIDGenerator) NewTraceID() string {
+	return g.traceID
+}
+
+func (g *fixedIDGenerator) NewSpanID() string {
+	return g.spanID
+}
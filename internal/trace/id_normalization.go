@@ -0,0 +1,59 @@
+// Copyright (c) 2025 Bytedance Ltd. and/or its affiliates
+// SPDX-License-Identifier: MIT
+
+package trace
+
+import (
+	"strings"
+
+	"github.com/coze-dev/cozeloop-go/internal/util"
+)
+
+const (
+	traceIDHexLen = 32
+	spanIDHexLen  = 16
+)
+
+// IDNormalizationConf accepts the trace ids and span ids of header parent sent by upstreams in other formats, and
+// normalizes them to 32 and 16 lowercase hex chars, instead of rejecting them. It makes FromHeader tolerant of
+// heterogeneous tracing systems, such as the ones sending uppercase ids, 64-bit trace ids or ids with leading
+// zeros dropped.
+type IDNormalizationConf struct {
+	// VendorPrefixes the prefixes stripped from ids case-insensitively, in addition to "0x". The prefixes must not
+	// contain "-", which separates the fields of header parent.
+	VendorPrefixes []string
+	// PadShortIDs left pads the ids shorter than 32 and 16 hex chars with zeros, such as 64-bit trace ids and the ids
+	// whose leading zeros are dropped.
+	PadShortIDs bool
+}
+
+// normalizeTraceID returns id in 32 lowercase hex chars, or id itself if it can not be normalized.
+func (c *IDNormalizationConf) normalizeTraceID(id string) string {
+	return c.normalize(id, traceIDHexLen)
+}
+
+// normalizeSpanID returns id in 16 lowercase hex chars, or id itself if it can not be normalized.
+func (c *IDNormalizationConf) normalizeSpanID(id string) string {
+	return c.normalize(id, spanIDHexLen)
+}
+
+func (c *IDNormalizationConf) normalize(id string, size int) string {
+	if c == nil {
+		return id
+	}
+	normalized := strings.ToLower(strings.TrimSpace(id))
+	normalized = strings.TrimPrefix(normalized, "0x")
+	for _, prefix := range c.VendorPrefixes {
+		if prefix != "" && strings.HasPrefix(normalized, strings.ToLower(prefix)) {
+			normalized = normalized[len(prefix):]
+			break
+		}
+	}
+	if normalized == "" || !util.IsValidHexStr(normalized) {
+		return id
+	}
+	if len(normalized) < size && c.PadShortIDs {
+		normalized = strings.Repeat("0", size-len(normalized)) + normalized
+	}
+	return normalized
+}
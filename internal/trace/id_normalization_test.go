@@ -0,0 +1,46 @@
+// Copyright (c) 2025 Bytedance Ltd. and/or its affiliates
+// SPDX-License-Identifier: MIT
+
+package trace
+
+import (
+	"context"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestIDNormalization(t *testing.T) {
+	ctx := context.Background()
+	header := func(parent string) map[string]string {
+		return map[string]string{"X-Cozeloop-Traceparent": parent}
+	}
+
+	Convey("Test ids in other formats are rejected by default", t, func() {
+		provider := NewTraceProvider(nil, Options{})
+		So(provider.GetSpanFromHeader(ctx, header("00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")).TraceID,
+			ShouldEqual, "4bf92f3577b34da6a3ce929d0e0e4736")
+		So(provider.GetSpanFromHeader(ctx, header("00-0x4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")).TraceID,
+			ShouldBeEmpty)
+		So(provider.GetSpanFromHeader(ctx, header("00-a3ce929d0e0e4736-f067aa0ba902b7-01")).TraceID, ShouldBeEmpty)
+	})
+
+	Convey("Test ids are accepted and normalized by conf", t, func() {
+		provider := NewTraceProvider(nil, Options{IDNormalization: &IDNormalizationConf{
+			VendorPrefixes: []string{"dd"},
+			PadShortIDs:    true,
+		}})
+		spanCtx := provider.GetSpanFromHeader(ctx, header(" 00-0X4BF92F3577B34DA6A3CE929D0E0E4736-DD00F067AA0BA902B7-01 "))
+		So(spanCtx.TraceID, ShouldEqual, "4bf92f3577b34da6a3ce929d0e0e4736")
+		So(spanCtx.SpanID, ShouldEqual, "00f067aa0ba902b7")
+
+		spanCtx = provider.GetSpanFromHeader(ctx, header("00-a3ce929d0e0e4736-f067aa0ba902b7-01"))
+		So(spanCtx.TraceID, ShouldEqual, "0000000000000000a3ce929d0e0e4736")
+		So(spanCtx.SpanID, ShouldEqual, "00f067aa0ba902b7")
+
+		// ids which are not hex, too long or all zero are still rejected
+		So(provider.GetSpanFromHeader(ctx, header("00-xyz-00f067aa0ba902b7-01")).TraceID, ShouldBeEmpty)
+		So(provider.GetSpanFromHeader(ctx, header("00-a3ce929d0e0e4736-00f067aa0ba902b7ff-01")).TraceID, ShouldBeEmpty)
+		So(provider.GetSpanFromHeader(ctx, header("00-0-00f067aa0ba902b7-01")).TraceID, ShouldBeEmpty)
+	})
+}
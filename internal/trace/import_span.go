@@ -0,0 +1,94 @@
+// Copyright (c) 2025 Bytedance Ltd. and/or its affiliates
+// SPDX-License-Identifier: MIT
+
+package trace
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/coze-dev/cozeloop-go/internal/consts"
+)
+
+// ImportedSpan a span of the past, such as the spans rebuilt from historical logs, which is reported with the ids
+// and times provided instead of the ones generated when the span is started.
+type ImportedSpan struct {
+	// TraceID 32 hex chars, required
+	TraceID string
+	// SpanID 16 hex chars, required
+	SpanID string
+	// ParentSpanID 16 hex chars of the parent span, empty if the span is the root span of trace
+	ParentSpanID string
+	Name         string
+	SpanType     string
+	// WorkspaceID the workspace of client is used if it is empty
+	WorkspaceID string
+	// StartTime and FinishTime are required, FinishTime must not be before StartTime
+	StartTime  time.Time
+	FinishTime time.Time
+	Input      interface{}
+	Output     interface{}
+	Tags       map[string]interface{}
+	Baggage    map[string]string
+	StatusCode int
+	Error      error
+}
+
+func (s *ImportedSpan) validate() error {
+	switch {
+	case s == nil:
+		return errors.New("span is nil")
+	case !isValidTraceID(s.TraceID):
+		return fmt.Errorf("invalid trace id: %s", s.TraceID)
+	case !isValidSpanID(s.SpanID):
+		return fmt.Errorf("invalid span id: %s", s.SpanID)
+	case s.ParentSpanID != "" && !isValidSpanID(s.ParentSpanID):
+		return fmt.Errorf("invalid parent span id: %s", s.ParentSpanID)
+	case s.StartTime.IsZero() || s.FinishTime.IsZero():
+		return fmt.Errorf("start time and finish time of span %s are required", s.SpanID)
+	case s.FinishTime.Before(s.StartTime):
+		return fmt.Errorf("finish time of span %s is before start time", s.SpanID)
+	}
+	return nil
+}
+
+// ImportSpans reports spans of the past by the same processors and exporter as the spans started by StartSpan.
+// All spans are validated before any of them is reported, and the span in ctx is not used as parent. Imported
+// spans are reported regardless of Options.SampleRatio.
+func (t *Provider) ImportSpans(ctx context.Context, spans []*ImportedSpan) error {
+	for i, span := range spans {
+		if err := span.validate(); err != nil {
+			return consts.ErrInvalidParam.Wrap(fmt.Errorf("span %d: %w", i, err))
+		}
+	}
+	for _, span := range spans {
+		spanCtx, s, err := t.StartSpan(ctx, span.Name, span.SpanType, StartSpanOptions{
+			StartTime:     span.StartTime,
+			ParentSpanID:  span.ParentSpanID,
+			SpanID:        span.SpanID,
+			TraceID:       span.TraceID,
+			Baggage:       span.Baggage,
+			StartNewTrace: true,
+			WorkspaceID:   span.WorkspaceID,
+			Tags:          span.Tags,
+			imported:      true,
+		})
+		if err != nil {
+			return err
+		}
+		if span.Input != nil {
+			s.SetInput(spanCtx, span.Input)
+		}
+		if span.Output != nil {
+			s.SetOutput(spanCtx, span.Output)
+		}
+		s.FinishWithOptions(spanCtx, FinishOptions{
+			Error:      span.Error,
+			StatusCode: span.StatusCode,
+			FinishTime: span.FinishTime,
+		})
+	}
+	return nil
+}
@@ -0,0 +1,112 @@
+// Copyright (c) 2025 Bytedance Ltd. and/or its affiliates
+// SPDX-License-Identifier: MIT
+
+package trace
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	. "github.com/smartystreets/goconvey/convey"
+
+	"github.com/coze-dev/cozeloop-go/internal/consts"
+	"github.com/coze-dev/cozeloop-go/spec/tracespec"
+)
+
+func TestImportSpans(t *testing.T) {
+	ctx := context.Background()
+	start := time.Date(2024, 6, 1, 8, 0, 0, 0, time.UTC)
+	traceID := "0af7651916cd43dd8448eb211c80319c"
+
+	Convey("Test spans are reported with the ids and times provided", t, func() {
+		var consumed []*ReadOnlySpan
+		ratio := 0.0
+		provider := NewTraceProvider(nil, Options{
+			WorkspaceID: "123",
+			Exporter:    &replayExporter{},
+			SampleRatio: &ratio,
+			SpanProcessors: []SpanProcessor{NewSpanConsumerProcessor(func(ctx context.Context, span *ReadOnlySpan) {
+				consumed = append(consumed, span)
+			})},
+		})
+		defer func() {
+			_, _ = provider.CloseTrace(ctx)
+		}()
+		// the span in ctx is not the parent
+		parentCtx, _, err := provider.StartSpan(ctx, "live", "custom", StartSpanOptions{})
+		So(err, ShouldBeNil)
+
+		err = provider.ImportSpans(parentCtx, []*ImportedSpan{
+			{
+				TraceID:    traceID,
+				SpanID:     "b7ad6b7169203331",
+				Name:       "root",
+				SpanType:   "custom",
+				StartTime:  start,
+				FinishTime: start.Add(3 * time.Second),
+				Input:      "question",
+				Output:     "answer",
+				Tags:       map[string]interface{}{"source": "log"},
+			},
+			{
+				TraceID:      traceID,
+				SpanID:       "00f067aa0ba902b7",
+				ParentSpanID: "b7ad6b7169203331",
+				Name:         "model",
+				SpanType:     tracespec.VModelSpanType,
+				WorkspaceID:  "456",
+				StartTime:    start.Add(time.Second),
+				FinishTime:   start.Add(2 * time.Second),
+				Error:        errors.New("timeout"),
+				StatusCode:   500,
+			},
+		})
+		So(err, ShouldBeNil)
+		So(len(consumed), ShouldEqual, 2)
+
+		root, child := consumed[0], consumed[1]
+		So(root.TraceID, ShouldEqual, traceID)
+		So(root.SpanID, ShouldEqual, "b7ad6b7169203331")
+		So(root.ParentID, ShouldEqual, "0")
+		So(root.WorkspaceID, ShouldEqual, "123")
+		So(root.StartTime, ShouldEqual, start)
+		So(root.FinishTime, ShouldEqual, start.Add(3*time.Second))
+		So(root.DurationMicros, ShouldEqual, int64(3000000))
+		So(root.Tags[tracespec.Input], ShouldEqual, "question")
+		So(root.Tags[tracespec.Output], ShouldEqual, "answer")
+		So(root.Tags["source"], ShouldEqual, "log")
+		So(child.ParentID, ShouldEqual, "b7ad6b7169203331")
+		So(child.WorkspaceID, ShouldEqual, "456")
+		So(child.StatusCode, ShouldEqual, 500)
+		So(child.Tags[tracespec.Error], ShouldEqual, "timeout")
+	})
+
+	Convey("Test no span is reported if any span is invalid", t, func() {
+		var consumed []*ReadOnlySpan
+		provider := NewTraceProvider(nil, Options{
+			Exporter: &replayExporter{},
+			SpanProcessors: []SpanProcessor{NewSpanConsumerProcessor(func(ctx context.Context, span *ReadOnlySpan) {
+				consumed = append(consumed, span)
+			})},
+		})
+		defer func() {
+			_, _ = provider.CloseTrace(ctx)
+		}()
+		valid := &ImportedSpan{TraceID: traceID, SpanID: "b7ad6b7169203331", StartTime: start, FinishTime: start}
+		for _, invalid := range []*ImportedSpan{
+			nil,
+			{TraceID: "abc", SpanID: "b7ad6b7169203331", StartTime: start, FinishTime: start},
+			{TraceID: traceID, SpanID: "0000000000000000", StartTime: start, FinishTime: start},
+			{TraceID: traceID, SpanID: "b7ad6b7169203331", ParentSpanID: "xyz", StartTime: start, FinishTime: start},
+			{TraceID: traceID, SpanID: "b7ad6b7169203331", StartTime: start},
+			{TraceID: traceID, SpanID: "b7ad6b7169203331", StartTime: start, FinishTime: start.Add(-time.Second)},
+		} {
+			err := provider.ImportSpans(ctx, []*ImportedSpan{valid, invalid})
+			So(errors.Is(err, consts.ErrInvalidParam), ShouldBeTrue)
+			So(err.Error(), ShouldContainSubstring, "span 1")
+		}
+		So(consumed, ShouldBeEmpty)
+	})
+}
@@ -0,0 +1,185 @@
+// Copyright (c) 2025 Bytedance Ltd. and/or its affiliates
+// SPDX-License-Identifier: MIT
+
+package trace
+
+import (
+	"context"
+	"fmt"
+	"runtime"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/coze-dev/cozeloop-go/internal/consts"
+	"github.com/coze-dev/cozeloop-go/internal/logger"
+	"github.com/coze-dev/cozeloop-go/internal/util"
+)
+
+const (
+	defaultLeakCheckInterval = time.Minute
+	maxCreationSiteDepth     = 32
+	sdkPackagePrefix         = "github.com/coze-dev/cozeloop-go."
+	sdkInternalPackagePrefix = "github.com/coze-dev/cozeloop-go/internal/"
+)
+
+// LeakDetectionConf configures the detector of spans which are started but never finished.
+type LeakDetectionConf struct {
+	// TTL spans not finished in TTL after started are reported as leaked. Leak detection is disabled if TTL <= 0.
+	TTL time.Duration
+	// CheckInterval interval to check leaked spans. Default is TTL, and at most 1 minute.
+	CheckInterval time.Duration
+	// ForceFinish finish the leaked spans with system tag "leaked", so they are still reported.
+	ForceFinish bool
+	// OnLeak called for every leaked span. Default logs a warning with the creation site of span.
+	OnLeak func(ctx context.Context, info *LeakedSpanInfo)
+}
+
+// LeakedSpanInfo the span not finished in TTL.
+type LeakedSpanInfo struct {
+	TraceID   string
+	SpanID    string
+	SpanName  string
+	SpanType  string
+	StartTime time.Time
+	// CreationSite the code which started the span, in format of "function file:line"
+	CreationSite string
+}
+
+// leakDetector tracks the live spans. A span is dropped from the registry once it is finished or reported
+// as leaked, so the registry never holds a leaked span longer than TTL plus CheckInterval.
+type leakDetector struct {
+	conf  LeakDetectionConf
+	lock  sync.Mutex
+	spans map[*Span][]uintptr // span -> call stack of creation
+	stopC chan struct{}
+	once  sync.Once
+}
+
+func newLeakDetector(conf *LeakDetectionConf) *leakDetector {
+	if conf == nil || conf.TTL <= 0 {
+		return nil
+	}
+	d := &leakDetector{
+		conf:  *conf,
+		spans: make(map[*Span][]uintptr),
+		stopC: make(chan struct{}),
+	}
+	if d.conf.CheckInterval <= 0 {
+		d.conf.CheckInterval = d.conf.TTL
+		if d.conf.CheckInterval > defaultLeakCheckInterval {
+			d.conf.CheckInterval = defaultLeakCheckInterval
+		}
+	}
+	util.GoSafe(context.Background(), d.run)
+	return d
+}
+
+func (d *leakDetector) track(s *Span) {
+	if d == nil || s == nil {
+		return
+	}
+	pcs := make([]uintptr, maxCreationSiteDepth)
+	pcs = pcs[:runtime.Callers(3, pcs)]
+	d.lock.Lock()
+	defer d.lock.Unlock()
+	d.spans[s] = pcs
+}
+
+func (d *leakDetector) untrack(s *Span) {
+	if d == nil || s == nil {
+		return
+	}
+	d.lock.Lock()
+	defer d.lock.Unlock()
+	delete(d.spans, s)
+}
+
+func (d *leakDetector) run() {
+	ticker := time.NewTicker(d.conf.CheckInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			d.check(context.Background(), time.Now())
+		case <-d.stopC:
+			return
+		}
+	}
+}
+
+// check reports the spans started before now - TTL and not finished yet.
+func (d *leakDetector) check(ctx context.Context, now time.Time) {
+	leaked := make(map[*Span][]uintptr)
+	d.lock.Lock()
+	for s, pcs := range d.spans {
+		if now.Sub(s.GetStartTime()) >= d.conf.TTL {
+			leaked[s] = pcs
+			delete(d.spans, s)
+		}
+	}
+	d.lock.Unlock()
+
+	for s, pcs := range leaked {
+		info := &LeakedSpanInfo{
+			TraceID:      s.GetTraceID(),
+			SpanID:       s.GetSpanID(),
+			SpanName:     s.GetSpanName(),
+			SpanType:     s.GetSpanType(),
+			StartTime:    s.GetStartTime(),
+			CreationSite: creationSite(pcs),
+		}
+		if d.conf.OnLeak != nil {
+			d.conf.OnLeak(ctx, info)
+		} else {
+			logger.CtxWarnf(ctx, "span is not finished in %v, trace_id: %s, span_id: %s, name: %s, created at: %s",
+				d.conf.TTL, info.TraceID, info.SpanID, info.SpanName, info.CreationSite)
+		}
+		if d.conf.ForceFinish {
+			s.setLeaked(info.CreationSite)
+			s.Finish(ctx)
+		}
+	}
+}
+
+func (d *leakDetector) stop() {
+	if d == nil {
+		return
+	}
+	d.once.Do(func() {
+		close(d.stopC)
+	})
+}
+
+// creationSite returns the first frame outside the SDK, test code of SDK is regarded as outside.
+func creationSite(pcs []uintptr) string {
+	if len(pcs) == 0 {
+		return ""
+	}
+	frames := runtime.CallersFrames(pcs)
+	var first string
+	for {
+		frame, more := frames.Next()
+		site := fmt.Sprintf("%s %s:%d", frame.Function, frame.File, frame.Line)
+		if first == "" {
+			first = site
+		}
+		isSDK := strings.HasPrefix(frame.Function, sdkPackagePrefix) || strings.HasPrefix(frame.Function, sdkInternalPackagePrefix)
+		if !isSDK || strings.HasSuffix(frame.File, "_test.go") {
+			return site
+		}
+		if !more {
+			return first
+		}
+	}
+}
+
+func (s *Span) setLeaked(creationSite string) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	if s.SystemTagMap == nil {
+		s.SystemTagMap = make(map[string]interface{})
+	}
+	s.SystemTagMap[consts.Leaked] = true
+	s.SystemTagMap[consts.LeakedCreationSite] = creationSite
+}
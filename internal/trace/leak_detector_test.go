@@ -0,0 +1,65 @@
+// Copyright (c) 2025 Bytedance Ltd. and/or its affiliates
+// SPDX-License-Identifier: MIT
+
+package trace
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	. "github.com/smartystreets/goconvey/convey"
+
+	"github.com/coze-dev/cozeloop-go/internal/consts"
+)
+
+func TestLeakDetector(t *testing.T) {
+	ctx := context.Background()
+	Convey("Test unfinished spans are reported", t, func() {
+		var leaked []*LeakedSpanInfo
+		provider := NewTraceProvider(nil, Options{
+			Exporter: &replayExporter{},
+			LeakDetection: &LeakDetectionConf{
+				TTL:         time.Hour,
+				ForceFinish: true,
+				OnLeak: func(ctx context.Context, info *LeakedSpanInfo) {
+					leaked = append(leaked, info)
+				},
+			},
+		})
+		defer func() {
+			_, _ = provider.CloseTrace(ctx)
+		}()
+
+		_, finishedSpan, err := provider.StartSpan(ctx, "finished", "custom", StartSpanOptions{})
+		So(err, ShouldBeNil)
+		finishedSpan.Finish(ctx)
+		_, leakedSpan, err := provider.StartSpan(ctx, "leaked", "custom", StartSpanOptions{})
+		So(err, ShouldBeNil)
+
+		// not leaked before TTL
+		provider.leakDetector.check(ctx, time.Now())
+		So(leaked, ShouldBeEmpty)
+
+		provider.leakDetector.check(ctx, time.Now().Add(2*time.Hour))
+		So(len(leaked), ShouldEqual, 1)
+		So(leaked[0].SpanName, ShouldEqual, "leaked")
+		So(leaked[0].CreationSite, ShouldContainSubstring, "leak_detector_test.go")
+		So(leakedSpan.isSpanFinished(), ShouldBeTrue)
+		So(leakedSpan.SystemTagMap[consts.Leaked], ShouldEqual, true)
+
+		// reported only once
+		provider.leakDetector.check(ctx, time.Now().Add(4*time.Hour))
+		So(len(leaked), ShouldEqual, 1)
+	})
+
+	Convey("Test leak detection is disabled by default", t, func() {
+		provider := NewTraceProvider(nil, Options{Exporter: &replayExporter{}})
+		defer func() {
+			_, _ = provider.CloseTrace(ctx)
+		}()
+		So(provider.leakDetector, ShouldBeNil)
+		_, span, _ := provider.StartSpan(ctx, "span", "custom", StartSpanOptions{})
+		span.Finish(ctx)
+	})
+}
@@ -11,6 +11,6 @@ type ObjectStorage struct {
 type Attachment struct {
 	Field  string `json:"field,omitempty"`
 	Name   string `json:"name,omitempty"`
-	Type   string `json:"type,omitempty"` // text, image, file
+	Type   string `json:"type,omitempty"` // text, image, file, audio, video
 	TosKey string `json:"tos_key,omitempty"`
 }
@@ -0,0 +1,76 @@
+// Copyright (c) 2025 Bytedance Ltd. and/or its affiliates
+// SPDX-License-Identifier: MIT
+
+package trace
+
+import (
+	"context"
+	"fmt"
+	"strings"
+)
+
+var _ SpanProcessor = (multiSpanProcessor)(nil)
+
+// multiSpanProcessor invokes the processors in order. The first one is the batch span processor reporting
+// to CozeLoop, whose ShutdownReport is returned by Shutdown.
+type multiSpanProcessor []SpanProcessor
+
+func newMultiSpanProcessor(main SpanProcessor, others []SpanProcessor) SpanProcessor {
+	processors := multiSpanProcessor{main}
+	for _, p := range others {
+		if p != nil {
+			processors = append(processors, p)
+		}
+	}
+	if len(processors) == 1 {
+		return main
+	}
+	return processors
+}
+
+func (m multiSpanProcessor) OnSpanEnd(ctx context.Context, s *Span) {
+	for _, p := range m {
+		p.OnSpanEnd(ctx, s)
+	}
+}
+
+func (m multiSpanProcessor) Shutdown(ctx context.Context) (*ShutdownReport, error) {
+	var report *ShutdownReport
+	var errs []error
+	for i, p := range m {
+		r, err := p.Shutdown(ctx)
+		if i == 0 {
+			report = r
+		}
+		if err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return report, joinErrors(errs)
+}
+
+func (m multiSpanProcessor) ForceFlush(ctx context.Context) error {
+	var errs []error
+	for _, p := range m {
+		if err := p.ForceFlush(ctx); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return joinErrors(errs)
+}
+
+// joinErrors returns the only error, or an error with messages of all errors.
+func joinErrors(errs []error) error {
+	switch len(errs) {
+	case 0:
+		return nil
+	case 1:
+		return errs[0]
+	default:
+		msgs := make([]string, 0, len(errs))
+		for _, err := range errs {
+			msgs = append(msgs, err.Error())
+		}
+		return fmt.Errorf("%d span processors failed: %s", len(errs), strings.Join(msgs, "; "))
+	}
+}
@@ -0,0 +1,77 @@
+// Copyright (c) 2025 Bytedance Ltd. and/or its affiliates
+// SPDX-License-Identifier: MIT
+
+package trace
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+// recordProcessor records the calls into calls shared by processors, to check the order of calls.
+type recordProcessor struct {
+	name  string
+	calls *[]string
+	err   error
+}
+
+func (p *recordProcessor) OnSpanEnd(ctx context.Context, s *Span) {
+	*p.calls = append(*p.calls, p.name+":end:"+s.GetSpanName())
+}
+
+func (p *recordProcessor) Shutdown(ctx context.Context) (*ShutdownReport, error) {
+	*p.calls = append(*p.calls, p.name+":shutdown")
+	return &ShutdownReport{SpansFlushed: 1}, p.err
+}
+
+func (p *recordProcessor) ForceFlush(ctx context.Context) error {
+	*p.calls = append(*p.calls, p.name+":flush")
+	return p.err
+}
+
+func TestMultiSpanProcessor(t *testing.T) {
+	ctx := context.Background()
+	Convey("Test span processors are invoked in order", t, func() {
+		var calls []string
+		provider := NewTraceProvider(nil, Options{
+			Exporter: &replayExporter{},
+			SpanProcessors: []SpanProcessor{
+				&recordProcessor{name: "a", calls: &calls},
+				nil,
+				&recordProcessor{name: "b", calls: &calls},
+			},
+		})
+
+		_, span, err := provider.StartSpan(ctx, "span", "custom", StartSpanOptions{})
+		So(err, ShouldBeNil)
+		span.Finish(ctx)
+		provider.Flush(ctx)
+		report, err := provider.CloseTrace(ctx)
+		So(err, ShouldBeNil)
+		So(report, ShouldNotBeNil)
+		So(calls, ShouldResemble, []string{"a:end:span", "b:end:span", "a:flush", "b:flush", "a:shutdown", "b:shutdown"})
+	})
+
+	Convey("Test errors of processors are joined", t, func() {
+		var calls []string
+		m := newMultiSpanProcessor(&recordProcessor{name: "main", calls: &calls}, []SpanProcessor{
+			&recordProcessor{name: "a", calls: &calls, err: errors.New("a failed")},
+			&recordProcessor{name: "b", calls: &calls, err: errors.New("b failed")},
+		})
+		err := m.ForceFlush(ctx)
+		So(err, ShouldNotBeNil)
+		So(err.Error(), ShouldContainSubstring, "a failed")
+		So(err.Error(), ShouldContainSubstring, "b failed")
+		report, err := m.Shutdown(ctx)
+		So(err, ShouldNotBeNil)
+		So(report.SpansFlushed, ShouldEqual, 1)
+	})
+
+	Convey("Test single processor is not wrapped", t, func() {
+		main := &recordProcessor{name: "main"}
+		So(newMultiSpanProcessor(main, nil), ShouldEqual, main)
+	})
+}
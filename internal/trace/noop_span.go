@@ -16,36 +16,48 @@ var DefaultNoopSpan = &noopSpan{}
 type noopSpan struct{}
 
 // implement of commonSpanSetter
-func (n noopSpan) SetInput(ctx context.Context, input interface{})                       {}
-func (n noopSpan) SetOutput(ctx context.Context, output interface{})                     {}
-func (n noopSpan) SetError(ctx context.Context, err error)                               {}
-func (n noopSpan) SetStatusCode(ctx context.Context, code int)                           {}
-func (n noopSpan) SetUserID(ctx context.Context, userID string)                          {}
-func (n noopSpan) SetUserIDBaggage(ctx context.Context, userID string)                   {}
-func (n noopSpan) SetMessageID(ctx context.Context, messageID string)                    {}
-func (n noopSpan) SetMessageIDBaggage(ctx context.Context, messageID string)             {}
-func (n noopSpan) SetThreadID(ctx context.Context, threadID string)                      {}
-func (n noopSpan) SetThreadIDBaggage(ctx context.Context, threadID string)               {}
-func (n noopSpan) SetPrompt(ctx context.Context, prompt entity.Prompt)                   {}
-func (n noopSpan) SetModelProvider(ctx context.Context, modelProvider string)            {}
-func (n noopSpan) SetModelName(ctx context.Context, modelName string)                    {}
-func (n noopSpan) SetModelCallOptions(ctx context.Context, modelCallOptions interface{}) {}
-func (n noopSpan) SetInputTokens(ctx context.Context, inputTokens int)                   {}
-func (n noopSpan) SetOutputTokens(ctx context.Context, outputTokens int)                 {}
-func (n noopSpan) SetStartTimeFirstResp(ctx context.Context, startTimeFirstResp int64)   {}
-func (n noopSpan) SetRuntime(ctx context.Context, runtime tracespec.Runtime)             {}
-func (n noopSpan) SetServiceName(ctx context.Context, serviceName string)                {}
-func (n noopSpan) SetLogID(ctx context.Context, logID string)                            {}
-func (n noopSpan) SetFinishTime(finishTime time.Time)                                    {}
-func (n noopSpan) SetSystemTags(ctx context.Context, systemTags map[string]interface{})  {}
-func (n noopSpan) SetDeploymentEnv(ctx context.Context, deploymentEnv string)            {}
+func (n noopSpan) SetInput(ctx context.Context, input interface{})                              {}
+func (n noopSpan) SetOutput(ctx context.Context, output interface{})                            {}
+func (n noopSpan) SetError(ctx context.Context, err error)                                      {}
+func (n noopSpan) SetStatusCode(ctx context.Context, code int)                                  {}
+func (n noopSpan) SetUserID(ctx context.Context, userID string)                                 {}
+func (n noopSpan) SetUserIDBaggage(ctx context.Context, userID string)                          {}
+func (n noopSpan) SetMessageID(ctx context.Context, messageID string)                           {}
+func (n noopSpan) SetMessageIDBaggage(ctx context.Context, messageID string)                    {}
+func (n noopSpan) SetThreadID(ctx context.Context, threadID string)                             {}
+func (n noopSpan) SetThreadIDBaggage(ctx context.Context, threadID string)                      {}
+func (n noopSpan) SetPrompt(ctx context.Context, prompt entity.Prompt)                          {}
+func (n noopSpan) SetModelProvider(ctx context.Context, modelProvider string)                   {}
+func (n noopSpan) SetModelName(ctx context.Context, modelName string)                           {}
+func (n noopSpan) SetModelCallOptions(ctx context.Context, modelCallOptions interface{})        {}
+func (n noopSpan) SetStream(ctx context.Context, stream bool)                                   {}
+func (n noopSpan) SetReasoningTokens(ctx context.Context, reasoningTokens int)                  {}
+func (n noopSpan) SetReasoningDuration(ctx context.Context, duration time.Duration)             {}
+func (n noopSpan) SetToolCallID(ctx context.Context, toolCallID string)                         {}
+func (n noopSpan) SetRetrieverProvider(ctx context.Context, provider string)                    {}
+func (n noopSpan) SetRetrieverCallOptions(ctx context.Context, _ tracespec.RetrieverCallOption) {}
+func (n noopSpan) SetRetrieverDocuments(ctx context.Context, _ []*tracespec.RetrieverDocument)  {}
+func (n noopSpan) SetInputTokens(ctx context.Context, inputTokens int)                          {}
+func (n noopSpan) SetOutputTokens(ctx context.Context, outputTokens int)                        {}
+func (n noopSpan) SetCost(ctx context.Context, cost float64)                                    {}
+func (n noopSpan) SetStartTimeFirstResp(ctx context.Context, startTimeFirstResp int64)          {}
+func (n noopSpan) AddStreamChunk(ctx context.Context, tokenCount int)                           {}
+func (n noopSpan) SetRuntime(ctx context.Context, runtime tracespec.Runtime)                    {}
+func (n noopSpan) SetServiceName(ctx context.Context, serviceName string)                       {}
+func (n noopSpan) SetLogID(ctx context.Context, logID string)                                   {}
+func (n noopSpan) SetFinishTime(finishTime time.Time)                                           {}
+func (n noopSpan) SetSystemTags(ctx context.Context, systemTags map[string]interface{})         {}
+func (n noopSpan) SetDeploymentEnv(ctx context.Context, deploymentEnv string)                   {}
 
 // implement of Span
-func (n noopSpan) SetTags(ctx context.Context, tagKVs map[string]interface{})     {}
-func (n noopSpan) SetBaggage(ctx context.Context, baggageItems map[string]string) {}
-func (n noopSpan) GetBaggage() map[string]string                                  { return nil }
-func (n noopSpan) Finish(ctx context.Context)                                     {}
-func (n noopSpan) GetTraceID() string                                             { return "" }
-func (n noopSpan) GetSpanID() string                                              { return "" }
-func (n noopSpan) GetStartTime() time.Time                                        { return time.Time{} }
-func (n noopSpan) ToHeader() (map[string]string, error)                           { return nil, nil }
+func (n noopSpan) SetTags(ctx context.Context, tagKVs map[string]interface{})        {}
+func (n noopSpan) SetTagsE(ctx context.Context, tagKVs map[string]interface{}) error { return nil }
+func (n noopSpan) SetBaggage(ctx context.Context, baggageItems map[string]string)    {}
+func (n noopSpan) GetBaggage() map[string]string                                     { return nil }
+func (n noopSpan) SetUltraLargeReport(enable bool)                                   {}
+func (n noopSpan) Finish(ctx context.Context)                                        {}
+func (n noopSpan) FinishWithOptions(ctx context.Context, opts FinishOptions)         {}
+func (n noopSpan) GetTraceID() string                                                { return "" }
+func (n noopSpan) GetSpanID() string                                                 { return "" }
+func (n noopSpan) GetStartTime() time.Time                                           { return time.Time{} }
+func (n noopSpan) ToHeader() (map[string]string, error)                              { return nil, nil }
@@ -0,0 +1,458 @@
+// Copyright (c) 2025 Bytedance Ltd. and/or its affiliates
+// SPDX-License-Identifier: MIT
+
+package trace
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/coze-dev/cozeloop-go/entity"
+	"github.com/coze-dev/cozeloop-go/internal/logger"
+	"github.com/coze-dev/cozeloop-go/internal/util"
+)
+
+const (
+	persistentQueueFileName = "cozeloop_spans.wal"
+	// the spans added are dropped instead of persisted when the file reaches this size after compaction
+	persistentQueueMaxFileBytes = 256 * 1024 * 1024
+	// compact the file when it is larger than this size, and at least half of it is acked
+	persistentQueueCompactMinBytes = 4 * 1024 * 1024
+	// the spans waiting for writer are dropped instead of persisted when there are too many
+	persistentQueueMaxBufferedSpans = 4096
+	// the records replayed more times than this are dropped, such as the spans rejected by server permanently
+	persistentQueueMaxReplays = 3
+
+	persistentOpAdd = "add"
+	persistentOpAck = "ack"
+)
+
+// persistentRecord is one line of the queue file. The spans added are not removed from file until acked,
+// so the spans which have not been exported can be replayed after restart.
+type persistentRecord struct {
+	Op    string               `json:"op"`
+	Key   string               `json:"key,omitempty"`
+	Span  *entity.UploadSpan   `json:"span,omitempty"`
+	Files []*entity.UploadFile `json:"files,omitempty"`
+	Keys  []string             `json:"keys,omitempty"`
+	// Replays the count of times the span has been replayed after restart
+	Replays int `json:"replays,omitempty"`
+}
+
+// persistentOp the span to add or the keys to ack, which are written to file by the writer goroutine in order.
+type persistentOp struct {
+	span    *Span
+	ackKeys []string
+}
+
+// persistentQueue is an append-only file which persists the finished spans until they are exported, or dropped.
+// The spans are converted and written by a writer goroutine, so that finishing spans never waits for disk.
+// The file is written without fsync, which survives process crash but not machine crash.
+type persistentQueue struct {
+	path     string
+	redactor SpanRedactor
+
+	// lock guards ops and bufferedSpans, the ops are taken by writer in batch
+	lock          sync.Mutex
+	ops           []persistentOp
+	bufferedSpans int
+	closed        bool
+	notify        chan struct{}
+	stopCh        chan struct{}
+	stopWait      sync.WaitGroup
+
+	// the states below are only accessed by writer, or before writer started
+	file   *os.File
+	writer *bufio.Writer
+	// pending the keys of spans not acked, with the bytes of their records
+	pending   map[string]int64
+	size      int64
+	liveBytes int64
+}
+
+func persistentSpanKey(span *entity.UploadSpan) string {
+	return span.TraceID + "/" + span.SpanID
+}
+
+// openPersistentQueue opens the queue file in dir, and returns the records which have not been acked. The replay
+// count of records are increased, and the records replayed too many times are dropped. The spans added are redacted
+// by redactor before persisted.
+func openPersistentQueue(dir string, redactor SpanRedactor) (*persistentQueue, []*persistentRecord, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, nil, err
+	}
+	q := &persistentQueue{
+		path:     filepath.Join(dir, persistentQueueFileName),
+		redactor: redactor,
+		notify:   make(chan struct{}, 1),
+		stopCh:   make(chan struct{}),
+		pending:  make(map[string]int64),
+	}
+	loaded, err := q.load()
+	if err != nil {
+		return nil, nil, err
+	}
+	records := make([]*persistentRecord, 0, len(loaded))
+	for _, record := range loaded {
+		record.Replays++
+		if record.Replays > persistentQueueMaxReplays {
+			logger.CtxWarnf(context.Background(), "span %s of persistent queue is replayed %d times, dropped",
+				record.Key, persistentQueueMaxReplays)
+			continue
+		}
+		records = append(records, record)
+	}
+	if err = q.rewrite(records); err != nil {
+		return nil, nil, err
+	}
+	q.stopWait.Add(1)
+	util.GoSafe(context.Background(), func() {
+		defer q.stopWait.Done()
+		q.run()
+	})
+	return q, records, nil
+}
+
+// load reads all records which have not been acked from the queue file.
+func (q *persistentQueue) load() ([]*persistentRecord, error) {
+	f, err := os.Open(q.path)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	defer f.Close()
+
+	var added []*persistentRecord
+	acked := make(map[string]struct{})
+	reader := bufio.NewReader(f)
+	for {
+		line, err := reader.ReadBytes('\n')
+		if len(bytes.TrimSpace(line)) > 0 {
+			record := &persistentRecord{}
+			if jsonErr := json.Unmarshal(line, record); jsonErr != nil {
+				// the last line may be broken when process crashed
+				logger.CtxWarnf(context.Background(), "skip broken record of persistent queue: %v", jsonErr)
+			} else {
+				switch record.Op {
+				case persistentOpAdd:
+					added = append(added, record)
+				case persistentOpAck:
+					for _, key := range record.Keys {
+						acked[key] = struct{}{}
+					}
+				}
+			}
+		}
+		if err != nil {
+			if err == io.EOF {
+				break
+			}
+			return nil, err
+		}
+	}
+
+	records := make([]*persistentRecord, 0, len(added))
+	for _, record := range added {
+		if _, ok := acked[record.Key]; !ok && record.Span != nil {
+			records = append(records, record)
+		}
+	}
+	return records, nil
+}
+
+// rewrite replaces the queue file with the records, and opens it for appending.
+func (q *persistentQueue) rewrite(records []*persistentRecord) error {
+	tmpPath := q.path + ".tmp"
+	tmp, err := os.OpenFile(tmpPath, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0o644)
+	if err != nil {
+		return err
+	}
+	writer := bufio.NewWriter(tmp)
+	pending := make(map[string]int64, len(records))
+	var size int64
+	for _, record := range records {
+		data, err := json.Marshal(record)
+		if err != nil {
+			_ = tmp.Close()
+			return err
+		}
+		if _, err = writer.Write(append(data, '\n')); err != nil {
+			_ = tmp.Close()
+			return err
+		}
+		pending[record.Key] = int64(len(data) + 1)
+		size += int64(len(data) + 1)
+	}
+	if err = writer.Flush(); err != nil {
+		_ = tmp.Close()
+		return err
+	}
+	if err = tmp.Close(); err != nil {
+		return err
+	}
+	if q.file != nil {
+		_ = q.file.Close()
+		q.file = nil
+	}
+	if err = os.Rename(tmpPath, q.path); err != nil {
+		return err
+	}
+	q.file, err = os.OpenFile(q.path, os.O_APPEND|os.O_WRONLY, 0o644)
+	if err != nil {
+		return err
+	}
+	q.writer = bufio.NewWriter(q.file)
+	q.pending = pending
+	q.size = size
+	q.liveBytes = size
+	return nil
+}
+
+// Add persists the finished span and its files asynchronously. A snapshot of the span is persisted, so that the
+// writer does not race with the export of span. The span is dropped instead of persisted if the writer falls behind
+// too much.
+func (q *persistentQueue) Add(ctx context.Context, s *Span) {
+	if q == nil || s == nil {
+		return
+	}
+	q.lock.Lock()
+	if q.closed || q.bufferedSpans >= persistentQueueMaxBufferedSpans {
+		q.lock.Unlock()
+		return
+	}
+	q.ops = append(q.ops, persistentOp{span: s.snapshot()})
+	q.bufferedSpans++
+	q.lock.Unlock()
+	q.signal()
+}
+
+// Ack marks the spans as exported, so they will not be replayed. The spans failed to export are not acked, so that
+// they are replayed after restart.
+func (q *persistentQueue) Ack(ctx context.Context, spans []*entity.UploadSpan) {
+	if q == nil || len(spans) == 0 {
+		return
+	}
+	keys := make([]string, 0, len(spans))
+	for _, span := range spans {
+		if span != nil {
+			keys = append(keys, persistentSpanKey(span))
+		}
+	}
+	q.ack(keys)
+}
+
+// AckDropped marks the span dropped by queues as done, so that it will not be replayed.
+func (q *persistentQueue) AckDropped(ctx context.Context, s *Span) {
+	if q == nil || s == nil {
+		return
+	}
+	q.ack([]string{s.GetTraceID() + "/" + s.GetSpanID()})
+}
+
+// snapshot copies the finished span with the fields read by transferToUploadSpanAndFile. The tag maps are copied, so
+// that the tags set by export later, such as the failed request ids, are not persisted.
+func (s *Span) snapshot() *Span {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+	return &Span{
+		SpanContext: SpanContext{
+			SpanID:  s.SpanID,
+			TraceID: s.TraceID,
+			Baggage: copyMap(s.Baggage),
+		},
+		SpanType:               s.SpanType,
+		Name:                   s.Name,
+		ServiceName:            s.ServiceName,
+		LogID:                  s.LogID,
+		WorkspaceID:            s.WorkspaceID,
+		ParentSpanID:           s.ParentSpanID,
+		StartTime:              s.StartTime,
+		FinishTime:             s.FinishTime,
+		Duration:               s.Duration,
+		TagMap:                 copyMap(s.TagMap),
+		SystemTagMap:           copyMap(s.SystemTagMap),
+		StatusCode:             s.StatusCode,
+		multiModalityKeyMap:    copyMap(s.multiModalityKeyMap),
+		ultraLargeReportKeyMap: copyMap(s.ultraLargeReportKeyMap),
+		ultraLargeReport:       s.ultraLargeReport,
+		flags:                  s.flags,
+		bytesSize:              s.bytesSize,
+		tagTruncateConf:        s.tagTruncateConf,
+		noCaptureContent:       s.noCaptureContent,
+		priority:               s.priority,
+	}
+}
+
+func (q *persistentQueue) ack(keys []string) {
+	if len(keys) == 0 {
+		return
+	}
+	q.lock.Lock()
+	if q.closed {
+		q.lock.Unlock()
+		return
+	}
+	q.ops = append(q.ops, persistentOp{ackKeys: keys})
+	q.lock.Unlock()
+	q.signal()
+}
+
+func (q *persistentQueue) signal() {
+	select {
+	case q.notify <- struct{}{}:
+	default:
+	}
+}
+
+// run writes the ops in order until closed, and the ops left are written before it returns.
+func (q *persistentQueue) run() {
+	ctx := context.Background()
+	for {
+		select {
+		case <-q.notify:
+			q.write(ctx)
+		case <-q.stopCh:
+			q.write(ctx)
+			return
+		}
+	}
+}
+
+func (q *persistentQueue) takeOps() []persistentOp {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+	ops := q.ops
+	q.ops = nil
+	q.bufferedSpans = 0
+	return ops
+}
+
+// write writes the ops taken to file, and compacts the file if most of it is acked.
+func (q *persistentQueue) write(ctx context.Context) {
+	ops := q.takeOps()
+	if len(ops) == 0 || q.file == nil {
+		return
+	}
+	for _, op := range ops {
+		if op.span != nil {
+			q.writeSpan(ctx, op.span)
+		} else {
+			q.writeAck(ctx, op.ackKeys)
+		}
+	}
+	if err := q.writer.Flush(); err != nil {
+		logger.CtxErrorf(ctx, "write persistent queue failed: %v", err)
+	}
+	if q.size >= persistentQueueCompactMinBytes && (q.size-q.liveBytes)*2 >= q.size {
+		q.compact(ctx)
+	}
+}
+
+func (q *persistentQueue) writeSpan(ctx context.Context, s *Span) {
+	spans, files := transferToUploadSpanAndFile(ctx, []*Span{s})
+	redactSpans(q.redactor, spans, files)
+	for _, span := range spans {
+		if q.file == nil {
+			return
+		}
+		if span == nil {
+			continue
+		}
+		key := persistentSpanKey(span)
+		data, err := json.Marshal(&persistentRecord{Op: persistentOpAdd, Key: key, Span: span, Files: files})
+		if err != nil {
+			logger.CtxErrorf(ctx, "add span to persistent queue failed: %v", err)
+			continue
+		}
+		size := int64(len(data) + 1)
+		if q.size+size > persistentQueueMaxFileBytes && q.size > q.liveBytes {
+			q.compact(ctx)
+		}
+		if q.size+size > persistentQueueMaxFileBytes {
+			logger.CtxWarnf(ctx, "persistent queue reaches %d bytes, span %s is not persisted", persistentQueueMaxFileBytes, key)
+			continue
+		}
+		if _, err = q.writer.Write(append(data, '\n')); err != nil {
+			logger.CtxErrorf(ctx, "add span to persistent queue failed: %v", err)
+			continue
+		}
+		q.pending[key] = size
+		q.size += size
+		q.liveBytes += size
+	}
+}
+
+func (q *persistentQueue) writeAck(ctx context.Context, keys []string) {
+	acked := make([]string, 0, len(keys))
+	for _, key := range keys {
+		if _, ok := q.pending[key]; ok {
+			acked = append(acked, key)
+		}
+	}
+	if len(acked) == 0 {
+		return
+	}
+	data, err := json.Marshal(&persistentRecord{Op: persistentOpAck, Keys: acked})
+	if err == nil {
+		_, err = q.writer.Write(append(data, '\n'))
+	}
+	if err != nil {
+		logger.CtxErrorf(ctx, "ack spans of persistent queue failed: %v", err)
+		return
+	}
+	q.size += int64(len(data) + 1)
+	for _, key := range acked {
+		q.liveBytes -= q.pending[key]
+		delete(q.pending, key)
+	}
+}
+
+// compact drops the acked records from the queue file. It is only called by writer.
+func (q *persistentQueue) compact(ctx context.Context) {
+	err := q.writer.Flush()
+	var records []*persistentRecord
+	if err == nil {
+		records, err = q.load()
+	}
+	if err == nil {
+		err = q.rewrite(records)
+	}
+	if err != nil {
+		logger.CtxErrorf(ctx, "compact persistent queue failed: %v", err)
+	}
+}
+
+// Close writes the spans and acks left, and closes the file.
+func (q *persistentQueue) Close() error {
+	if q == nil {
+		return nil
+	}
+	q.lock.Lock()
+	if q.closed {
+		q.lock.Unlock()
+		return nil
+	}
+	q.closed = true
+	q.lock.Unlock()
+	close(q.stopCh)
+	q.stopWait.Wait()
+
+	if q.file == nil {
+		return nil
+	}
+	err := q.writer.Flush()
+	if closeErr := q.file.Close(); closeErr != nil && err == nil {
+		err = closeErr
+	}
+	q.file = nil
+	return err
+}
@@ -0,0 +1,163 @@
+// Copyright (c) 2025 Bytedance Ltd. and/or its affiliates
+// SPDX-License-Identifier: MIT
+
+package trace
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	. "github.com/smartystreets/goconvey/convey"
+
+	"github.com/coze-dev/cozeloop-go/entity"
+	"github.com/coze-dev/cozeloop-go/internal/consts"
+)
+
+type replayExporter struct {
+	mu    sync.Mutex
+	spans []*entity.UploadSpan
+	files []*entity.UploadFile
+	err   error
+}
+
+func (e *replayExporter) ExportSpans(ctx context.Context, spans []*entity.UploadSpan) error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if e.err != nil {
+		return e.err
+	}
+	e.spans = append(e.spans, spans...)
+	return nil
+}
+
+func (e *replayExporter) ExportFiles(ctx context.Context, files []*entity.UploadFile) error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.files = append(e.files, files...)
+	return nil
+}
+
+func (e *replayExporter) spanCount() int {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return len(e.spans)
+}
+
+func TestPersistentQueue(t *testing.T) {
+	ctx := context.Background()
+	Convey("Test persistent queue", t, func() {
+		dir := t.TempDir()
+		q, records, err := openPersistentQueue(dir, nil)
+		So(err, ShouldBeNil)
+		So(records, ShouldBeEmpty)
+
+		span1 := &Span{SpanContext: SpanContext{TraceID: "t1", SpanID: "s1"}, Name: "a"}
+		span2 := &Span{SpanContext: SpanContext{TraceID: "t1", SpanID: "s2"}, Name: "b"}
+		q.Add(ctx, span1)
+		q.Add(ctx, span2)
+		// the tags set after added, such as by export, are not persisted
+		span2.addExportFailedRequestID("log_1")
+		q.Ack(ctx, []*entity.UploadSpan{{TraceID: "t1", SpanID: "s1"}})
+		So(q.Close(), ShouldBeNil)
+
+		Convey("pending spans are loaded after reopen", func() {
+			q, records, err := openPersistentQueue(dir, nil)
+			So(err, ShouldBeNil)
+			defer q.Close()
+			So(len(records), ShouldEqual, 1)
+			So(records[0].Span.SpanName, ShouldEqual, "b")
+			So(records[0].Span.SystemTagsString[consts.ExportFailedRequestIDs], ShouldBeEmpty)
+			So(records[0].Replays, ShouldEqual, 1)
+		})
+
+		Convey("broken record is skipped", func() {
+			f, err := os.OpenFile(filepath.Join(dir, persistentQueueFileName), os.O_APPEND|os.O_WRONLY, 0o644)
+			So(err, ShouldBeNil)
+			_, _ = f.WriteString(`{"op":"add","key":"t1/s3","span":{"trace_`)
+			_ = f.Close()
+			q, records, err := openPersistentQueue(dir, nil)
+			So(err, ShouldBeNil)
+			defer q.Close()
+			So(len(records), ShouldEqual, 1)
+		})
+
+		Convey("acked spans are dropped from file", func() {
+			q, _, err := openPersistentQueue(dir, nil)
+			So(err, ShouldBeNil)
+			q.AckDropped(ctx, span2)
+			So(q.Close(), ShouldBeNil)
+			q, records, err := openPersistentQueue(dir, nil)
+			So(err, ShouldBeNil)
+			So(q.Close(), ShouldBeNil)
+			So(records, ShouldBeEmpty)
+			data, err := os.ReadFile(filepath.Join(dir, persistentQueueFileName))
+			So(err, ShouldBeNil)
+			So(len(data), ShouldEqual, 0)
+		})
+
+		Convey("spans are dropped after replayed too many times", func() {
+			for i := 1; i <= persistentQueueMaxReplays; i++ {
+				q, records, err := openPersistentQueue(dir, nil)
+				So(err, ShouldBeNil)
+				So(q.Close(), ShouldBeNil)
+				So(len(records), ShouldEqual, 1)
+				So(records[0].Replays, ShouldEqual, i)
+			}
+			q, records, err := openPersistentQueue(dir, nil)
+			So(err, ShouldBeNil)
+			defer q.Close()
+			So(records, ShouldBeEmpty)
+		})
+	})
+}
+
+func TestBatchSpanProcessor_Replay(t *testing.T) {
+	ctx := context.Background()
+	Convey("Test spans in persistent queue are replayed on start", t, func() {
+		dir := t.TempDir()
+		q, _, err := openPersistentQueue(dir, nil)
+		So(err, ShouldBeNil)
+		q.Add(ctx, &Span{SpanContext: SpanContext{TraceID: "t1", SpanID: "s1"}})
+		So(q.Close(), ShouldBeNil)
+
+		exporter := &replayExporter{}
+		processor := NewBatchSpanProcessor(exporter, nil, nil, nil, nil, nil, dir)
+		deadline := time.Now().Add(3 * time.Second)
+		for exporter.spanCount() == 0 && time.Now().Before(deadline) {
+			time.Sleep(10 * time.Millisecond)
+		}
+		So(exporter.spanCount(), ShouldEqual, 1)
+		_, err = processor.Shutdown(ctx)
+		So(err, ShouldBeNil)
+
+		// replayed spans are acked, and not replayed again
+		q, records, err := openPersistentQueue(dir, nil)
+		So(err, ShouldBeNil)
+		defer q.Close()
+		So(records, ShouldBeEmpty)
+	})
+
+	Convey("Test spans failed to export are replayed, but the ones dropped by queues are not", t, func() {
+		dir := t.TempDir()
+		exporter := &replayExporter{err: errors.New("rejected")}
+		processor := NewBatchSpanProcessor(exporter, nil, nil, nil,
+			&QueueConf{MaxBufferedBytes: 1000, MaxRetries: -1}, nil, dir).(*BatchSpanProcessor)
+		// the first span is dropped after export failed, the second is dropped by the buffer limit
+		processor.OnSpanEnd(ctx, &Span{SpanContext: SpanContext{TraceID: "t1", SpanID: "s1"}, bytesSize: 600})
+		processor.OnSpanEnd(ctx, &Span{SpanContext: SpanContext{TraceID: "t1", SpanID: "s2"}, bytesSize: 600})
+		_, err := processor.Shutdown(ctx)
+		So(err, ShouldBeNil)
+		So(processor.Stats().SpansDropped, ShouldEqual, 2)
+
+		q, records, err := openPersistentQueue(dir, nil)
+		So(err, ShouldBeNil)
+		defer q.Close()
+		So(len(records), ShouldEqual, 1)
+		So(records[0].Key, ShouldEqual, "t1/s1")
+	})
+}
@@ -0,0 +1,80 @@
+// Copyright (c) 2025 Bytedance Ltd. and/or its affiliates
+// SPDX-License-Identifier: MIT
+
+package trace
+
+import (
+	"strings"
+	"sync"
+)
+
+// ModelPrice the price of model per 1k tokens, in any currency, as long as it is the same for all models.
+type ModelPrice struct {
+	InputPer1K  float64
+	OutputPer1K float64
+	// InputCachedPer1K price of input tokens hit by cache. Cached tokens are charged as InputPer1K if it is 0.
+	InputCachedPer1K float64
+}
+
+// ModelPricing the registry of model prices, keyed by model name. It is safe for concurrent use,
+// prices can be updated by Set at any time.
+type ModelPricing struct {
+	lock   sync.RWMutex
+	prices map[string]ModelPrice
+}
+
+func NewModelPricing(prices map[string]ModelPrice) *ModelPricing {
+	p := &ModelPricing{prices: make(map[string]ModelPrice, len(prices))}
+	for name, price := range prices {
+		p.prices[name] = price
+	}
+	return p
+}
+
+// Set sets the price of model.
+func (p *ModelPricing) Set(modelName string, price ModelPrice) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	p.prices[modelName] = price
+}
+
+// Get returns the price of model. If there is no price of the exact model name, the price of the longest
+// model name which is prefix of it is returned, e.g. price of "gpt-4o" is used for "gpt-4o-2024-08-06".
+func (p *ModelPricing) Get(modelName string) (ModelPrice, bool) {
+	if p == nil || modelName == "" {
+		return ModelPrice{}, false
+	}
+	p.lock.RLock()
+	defer p.lock.RUnlock()
+	if price, ok := p.prices[modelName]; ok {
+		return price, true
+	}
+	var matched string
+	for name := range p.prices {
+		if len(name) > len(matched) && strings.HasPrefix(modelName, name) {
+			matched = name
+		}
+	}
+	if matched == "" {
+		return ModelPrice{}, false
+	}
+	return p.prices[matched], true
+}
+
+// Cost returns the cost of model call. cachedTokens is part of inputTokens.
+func (p *ModelPricing) Cost(modelName string, inputTokens, cachedTokens, outputTokens int64) (float64, bool) {
+	price, ok := p.Get(modelName)
+	if !ok {
+		return 0, false
+	}
+	if cachedTokens > inputTokens {
+		cachedTokens = inputTokens
+	}
+	cachedPrice := price.InputCachedPer1K
+	if cachedPrice == 0 {
+		cachedPrice = price.InputPer1K
+	}
+	cost := (float64(inputTokens-cachedTokens)*price.InputPer1K + float64(cachedTokens)*cachedPrice +
+		float64(outputTokens)*price.OutputPer1K) / 1000
+	return cost, true
+}
@@ -0,0 +1,85 @@
+// Copyright (c) 2025 Bytedance Ltd. and/or its affiliates
+// SPDX-License-Identifier: MIT
+
+package trace
+
+import (
+	"context"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+
+	"github.com/coze-dev/cozeloop-go/spec/tracespec"
+)
+
+func TestModelPricing(t *testing.T) {
+	Convey("Test ModelPricing", t, func() {
+		pricing := NewModelPricing(map[string]ModelPrice{
+			"gpt-4o":      {InputPer1K: 0.0025, OutputPer1K: 0.01, InputCachedPer1K: 0.00125},
+			"gpt-4o-mini": {InputPer1K: 0.00015, OutputPer1K: 0.0006},
+		})
+
+		Convey("When model name matches exactly or by prefix", func() {
+			price, ok := pricing.Get("gpt-4o-mini-2024-07-18")
+			So(ok, ShouldBeTrue)
+			So(price.InputPer1K, ShouldEqual, 0.00015)
+			price, ok = pricing.Get("gpt-4o-2024-08-06")
+			So(ok, ShouldBeTrue)
+			So(price.InputPer1K, ShouldEqual, 0.0025)
+			_, ok = pricing.Get("doubao-pro")
+			So(ok, ShouldBeFalse)
+		})
+
+		Convey("When cost is computed with cached tokens", func() {
+			cost, ok := pricing.Cost("gpt-4o", 2000, 1000, 1000)
+			So(ok, ShouldBeTrue)
+			So(cost, ShouldAlmostEqual, 0.0025+0.00125+0.01)
+			cost, ok = pricing.Cost("gpt-4o-mini", 2000, 1000, 0)
+			So(ok, ShouldBeTrue)
+			So(cost, ShouldAlmostEqual, 0.0003)
+		})
+
+		Convey("When price is updated", func() {
+			pricing.Set("doubao-pro", ModelPrice{InputPer1K: 1})
+			cost, ok := pricing.Cost("doubao-pro-32k", 1000, 0, 1000)
+			So(ok, ShouldBeTrue)
+			So(cost, ShouldAlmostEqual, 1)
+		})
+	})
+}
+
+func TestSpanCost(t *testing.T) {
+	ctx := context.Background()
+	Convey("Test cost of span is computed when finished", t, func() {
+		provider := NewTraceProvider(nil, Options{
+			Exporter:     &replayExporter{},
+			ModelPricing: NewModelPricing(map[string]ModelPrice{"gpt-4o": {InputPer1K: 0.002, OutputPer1K: 0.01}}),
+		})
+		defer func() {
+			_, _ = provider.CloseTrace(ctx)
+		}()
+
+		_, span, err := provider.StartSpan(ctx, "model", tracespec.VModelSpanType, StartSpanOptions{})
+		So(err, ShouldBeNil)
+		span.SetModelName(ctx, "gpt-4o")
+		span.SetInputTokens(ctx, 1000)
+		span.SetOutputTokens(ctx, 500)
+		span.Finish(ctx)
+		So(span.GetTagMap()[tracespec.Cost], ShouldAlmostEqual, 0.007)
+
+		_, span, err = provider.StartSpan(ctx, "model", tracespec.VModelSpanType, StartSpanOptions{})
+		So(err, ShouldBeNil)
+		span.SetModelName(ctx, "gpt-4o")
+		span.SetInputTokens(ctx, 1000)
+		span.SetCost(ctx, 1.5)
+		span.Finish(ctx)
+		So(span.GetTagMap()[tracespec.Cost], ShouldEqual, 1.5)
+
+		_, span, err = provider.StartSpan(ctx, "model", tracespec.VModelSpanType, StartSpanOptions{})
+		So(err, ShouldBeNil)
+		span.SetModelName(ctx, "unknown")
+		span.SetInputTokens(ctx, 1000)
+		span.Finish(ctx)
+		So(span.GetTagMap(), ShouldNotContainKey, tracespec.Cost)
+	})
+}
@@ -0,0 +1,33 @@
+// Copyright (c) 2025 Bytedance Ltd. and/or its affiliates
+// SPDX-License-Identifier: MIT
+
+package trace
+
+// SpanPriority decides which spans survive queue pressure. Spans of each priority are queued in their own lane, so
+// verbose spans of low priority filling their lane do not drop the others. The lanes are sized by
+// QueueConf.SpanQueueLength, HighPrioritySpanQueueLength and LowPrioritySpanQueueLength. Priorities are ignored
+// if QueueConf.GroupByTrace is enabled, as the spans of a trace are queued in one lane.
+type SpanPriority string
+
+const (
+	// SpanPriorityHigh spans queued in a separate lane, which is not filled by the normal and low priority spans.
+	SpanPriorityHigh SpanPriority = "high"
+	// SpanPriorityNormal spans queued in the span queue, the same as the spans without priority before.
+	SpanPriorityNormal SpanPriority = "normal"
+	// SpanPriorityLow spans queued in a lane of a quarter of the length of normal one by default, so they are dropped
+	// first under pressure.
+	SpanPriorityLow SpanPriority = "low"
+)
+
+// exportPriority returns the priority of the span when it is finished. The root spans and error spans are of high
+// priority if the priority is not set when the span is started, and the others are of normal priority.
+func (s *Span) exportPriority() SpanPriority {
+	switch s.priority {
+	case SpanPriorityHigh, SpanPriorityNormal, SpanPriorityLow:
+		return s.priority
+	}
+	if s.IsRootSpan() || s.GetStatusCode() != 0 {
+		return SpanPriorityHigh
+	}
+	return SpanPriorityNormal
+}
@@ -0,0 +1,74 @@
+// Copyright (c) 2025 Bytedance Ltd. and/or its affiliates
+// SPDX-License-Identifier: MIT
+
+package trace
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/coze-dev/cozeloop-go/entity"
+	"github.com/coze-dev/cozeloop-go/internal/consts"
+	"github.com/coze-dev/cozeloop-go/internal/httpclient"
+)
+
+const (
+	pathListSpans = "/v1/loop/traces/spans/list"
+
+	maxListSpansPageSize = 1000
+)
+
+type ListSpansRequest struct {
+	WorkspaceID string            `json:"workspace_id"`
+	TraceID     string            `json:"trace_id,omitempty"`
+	StartTimeMs int64             `json:"start_time_ms,omitempty"`
+	EndTimeMs   int64             `json:"end_time_ms,omitempty"`
+	SpanTypes   []string          `json:"span_types,omitempty"`
+	Tags        map[string]string `json:"tags,omitempty"`
+	PageToken   string            `json:"page_token,omitempty"`
+	PageSize    int               `json:"page_size,omitempty"`
+}
+
+type ListSpansResponse struct {
+	httpclient.BaseResponse
+	Data *entity.ListSpansResult `json:"data"`
+}
+
+// ListSpans reads spans back from the platform.
+func (t *Provider) ListSpans(ctx context.Context, param *entity.ListSpansParam) (*entity.ListSpansResult, error) {
+	if param == nil {
+		return nil, consts.ErrInvalidParam.Wrap(fmt.Errorf("list spans param is nil"))
+	}
+	if param.TraceID == "" && (param.StartTime.IsZero() || param.EndTime.IsZero()) {
+		return nil, consts.ErrInvalidParam.Wrap(fmt.Errorf("either trace id or time range is required"))
+	}
+	if !param.StartTime.IsZero() && !param.EndTime.IsZero() && !param.EndTime.After(param.StartTime) {
+		return nil, consts.ErrInvalidParam.Wrap(fmt.Errorf("end time should be after start time"))
+	}
+	if param.PageSize < 0 || param.PageSize > maxListSpansPageSize {
+		return nil, consts.ErrInvalidParam.Wrap(fmt.Errorf("page size should be in [0, %d]", maxListSpansPageSize))
+	}
+
+	req := ListSpansRequest{
+		WorkspaceID: t.opt.WorkspaceID,
+		TraceID:     param.TraceID,
+		SpanTypes:   param.SpanTypes,
+		Tags:        param.Tags,
+		PageToken:   param.PageToken,
+		PageSize:    param.PageSize,
+	}
+	if !param.StartTime.IsZero() {
+		req.StartTimeMs = param.StartTime.UnixMilli()
+	}
+	if !param.EndTime.IsZero() {
+		req.EndTimeMs = param.EndTime.UnixMilli()
+	}
+	resp := ListSpansResponse{}
+	if err := t.httpClient.Post(ctx, pathListSpans, req, &resp); err != nil {
+		return nil, err
+	}
+	if resp.Data == nil {
+		return &entity.ListSpansResult{}, nil
+	}
+	return resp.Data, nil
+}
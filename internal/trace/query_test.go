@@ -0,0 +1,63 @@
+// Copyright (c) 2025 Bytedance Ltd. and/or its affiliates
+// SPDX-License-Identifier: MIT
+
+package trace
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	. "github.com/smartystreets/goconvey/convey"
+
+	"github.com/coze-dev/cozeloop-go/entity"
+	"github.com/coze-dev/cozeloop-go/internal/consts"
+	"github.com/coze-dev/cozeloop-go/internal/httpclient"
+)
+
+func Test_ListSpans(t *testing.T) {
+	ctx := context.Background()
+	var req ListSpansRequest
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewDecoder(r.Body).Decode(&req)
+		_ = json.NewEncoder(w).Encode(map[string]any{"code": 0, "data": entity.ListSpansResult{
+			Spans:   []*entity.UploadSpan{{TraceID: req.TraceID, SpanID: "span"}},
+			HasMore: false,
+		}})
+	}))
+	defer server.Close()
+	p := &Provider{
+		httpClient: httpclient.NewClient(server.URL, http.DefaultClient, httpclient.NewTokenAuth("token"), nil),
+		opt:        &Options{WorkspaceID: "workspace1"},
+	}
+
+	Convey("Test ListSpans with invalid param", t, func() {
+		_, err := p.ListSpans(ctx, &entity.ListSpansParam{})
+		So(errors.Is(err, consts.ErrInvalidParam), ShouldBeTrue)
+		now := time.Now()
+		_, err = p.ListSpans(ctx, &entity.ListSpansParam{StartTime: now, EndTime: now.Add(-time.Hour)})
+		So(errors.Is(err, consts.ErrInvalidParam), ShouldBeTrue)
+	})
+
+	Convey("Test ListSpans success", t, func() {
+		start := time.UnixMilli(1700000000000)
+		result, err := p.ListSpans(ctx, &entity.ListSpansParam{
+			TraceID:   "trace",
+			StartTime: start,
+			EndTime:   start.Add(time.Hour),
+			SpanTypes: []string{"model"},
+			Tags:      map[string]string{"user_id": "u1"},
+		})
+		So(err, ShouldBeNil)
+		So(len(result.Spans), ShouldEqual, 1)
+		So(result.Spans[0].TraceID, ShouldEqual, "trace")
+		So(req.WorkspaceID, ShouldEqual, "workspace1")
+		So(req.StartTimeMs, ShouldEqual, 1700000000000)
+		So(req.EndTimeMs, ShouldEqual, 1700003600000)
+		So(req.Tags["user_id"], ShouldEqual, "u1")
+	})
+}
@@ -25,6 +25,8 @@ import (
 
 const (
 	queueNameSpan      = "span"
+	queueNameSpanHigh  = "span_high"
+	queueNameSpanLow   = "span_low"
 	queueNameSpanRetry = "span_retry"
 	queueNameFile      = "file"
 	queueNameFileRetry = "file_retry"
@@ -37,6 +39,10 @@ type QueueManager interface {
 	Enqueue(ctx context.Context, s interface{}, byteSize int64)
 	Shutdown(ctx context.Context) error
 	ForceFlush(ctx context.Context) error
+	// Dropped returns the count of items dropped because queue is full
+	Dropped() int64
+	// Pending returns the count of items in queue and batch which are not exported
+	Pending() int64
 }
 
 type batchQueueManagerOptions struct {
@@ -48,6 +54,16 @@ type batchQueueManagerOptions struct {
 
 	exportFunc           exportFunc
 	finishEventProcessor func(ctx context.Context, info *consts.FinishEventInfo)
+	// limiter caps the bytes buffered across the queues of processor, nil if not limited
+	limiter *bufferLimiter
+	// onDropped is called with the item dropped because queue is full or buffered bytes exceed limit, nil if not set
+	onDropped func(ctx context.Context, item interface{})
+}
+
+// bufferedItem the item in queue with its bytes reserved from limiter, which are released when it leaves the batch.
+type bufferedItem struct {
+	item interface{}
+	size int64
 }
 
 func newBatchQueueManager(o batchQueueManagerOptions) *BatchQueueManager {
@@ -64,27 +80,36 @@ func newBatchQueueManager(o batchQueueManagerOptions) *BatchQueueManager {
 		stopOnce:   sync.Once{},
 		stopCh:     make(chan struct{}),
 		stopped:    0,
+		drainCtx:   context.Background(),
 	}
 
 	bsp.stopWait.Add(1)
 	util.GoSafe(context.Background(), func() {
 		defer bsp.stopWait.Done()
 		bsp.processQueue()
-		bsp.drainQueue(context.Background())
+		// drainCtx is set before stopCh closed
+		bsp.drainQueue(bsp.drainCtx)
 	})
 
 	return bsp
 }
 
-// BatchQueueManager four queue: span, span retry, file, file retry
+// BatchQueueManager queue of spans or files: span lanes by priority, span retry, file, file retry
 type BatchQueueManager struct {
 	o batchQueueManagerOptions
 
 	queue   chan interface{}
 	dropped uint32
+	// queued items enqueued but not appended to batch yet, including the ones being dequeued, so that they are
+	// always counted by Pending
+	queued int64
 
-	batch         []interface{}
+	batch []interface{}
+	// batchLen length of batch, which is read by Pending without batchMutex held across the export
+	batchLen      int64
 	batchByteSize int64
+	// batchBuffered bytes of the items in batch reserved from limiter, guarded by batchMutex
+	batchBuffered int64
 	batchMutex    sync.Mutex
 	sizeMutex     sync.RWMutex
 	timer         *time.Timer
@@ -95,6 +120,8 @@ type BatchQueueManager struct {
 	stopOnce sync.Once
 	stopCh   chan struct{}
 	stopped  int32
+	// drainCtx is the ctx of Shutdown, which bounds the final drain of queue
+	drainCtx context.Context
 }
 
 func (b *BatchQueueManager) processQueue() {
@@ -117,9 +144,10 @@ func (b *BatchQueueManager) processQueue() {
 				continue
 			}
 			b.batchMutex.Lock()
-			b.batch = append(b.batch, sd)
+			b.appendBatch(sd)
 			shouldExport := b.isShouldExport()
 			b.batchMutex.Unlock()
+			atomic.AddInt64(&b.queued, -1)
 			if shouldExport {
 				if !b.timer.Stop() { // timer reset, need stop first
 					select {
@@ -153,15 +181,20 @@ func (b *BatchQueueManager) drainQueue(ctx context.Context) {
 	ctx, cancel := context.WithCancel(ctx)
 	defer cancel()
 	for {
+		// check ctx first, select picks randomly when both queue and ctx are ready
+		if ctx.Err() != nil {
+			return
+		}
 		select {
 		case sd := <-b.queue:
 			if _, ok := sd.(forceFlushSpan); ok {
 				continue
 			}
 			b.batchMutex.Lock()
-			b.batch = append(b.batch, sd)
+			b.appendBatch(sd)
 			shouldExport := len(b.batch) == b.o.maxExportBatchLength
 			b.batchMutex.Unlock()
+			atomic.AddInt64(&b.queued, -1)
 
 			if shouldExport {
 				b.doExport(ctx)
@@ -176,17 +209,31 @@ func (b *BatchQueueManager) drainQueue(ctx context.Context) {
 	}
 }
 
+// appendBatch appends the item dequeued to batch, batchMutex should be held.
+func (b *BatchQueueManager) appendBatch(sd interface{}) {
+	if item, ok := sd.(bufferedItem); ok {
+		b.batchBuffered += item.size
+		sd = item.item
+	}
+	b.batch = append(b.batch, sd)
+	atomic.AddInt64(&b.batchLen, 1)
+}
+
 func (b *BatchQueueManager) doExport(ctx context.Context) {
 	b.timer.Reset(b.o.batchTimeout)
 	b.batchMutex.Lock()
 	defer b.batchMutex.Unlock()
 
 	if len(b.batch) > 0 {
+		// release before export, so that the items to retry can be enqueued
+		b.o.limiter.release(b.batchBuffered)
+		b.batchBuffered = 0
 		if b.exportFunc != nil {
 			b.exportFunc(ctx, b.batch)
 		}
 		// delete the batch
 		b.batch = b.batch[:0]
+		atomic.StoreInt64(&b.batchLen, 0)
 		b.sizeMutex.Lock()
 		b.batchByteSize = 0
 		b.sizeMutex.Unlock()
@@ -202,20 +249,35 @@ func (b *BatchQueueManager) Enqueue(ctx context.Context, sd interface{}, byteSiz
 	eventType := consts.SpanFinishEventFileQueueEntryRate
 	var detailMsg string
 	var isFail bool
-	select {
-	case b.queue <- sd:
-		b.sizeMutex.Lock()
-		b.batchByteSize += byteSize
-		b.sizeMutex.Unlock()
-		detailMsg = fmt.Sprintf("%s enqueue, queue length: %d", b.o.queueName, len(b.queue))
-	default: // queue is full, not block, drop
-		detailMsg = fmt.Sprintf("%s queue is full, dropped item", b.o.queueName)
+	if !b.acquireBuffer(ctx, byteSize) {
+		detailMsg = fmt.Sprintf("%s buffered bytes exceed limit, dropped item", b.o.queueName)
 		isFail = true
 		atomic.AddUint32(&b.dropped, 1)
+		b.itemDropped(ctx, sd)
+	} else {
+		item := sd
+		if b.o.limiter != nil {
+			item = bufferedItem{item: sd, size: byteSize}
+		}
+		atomic.AddInt64(&b.queued, 1)
+		select {
+		case b.queue <- item:
+			b.sizeMutex.Lock()
+			b.batchByteSize += byteSize
+			b.sizeMutex.Unlock()
+			detailMsg = fmt.Sprintf("%s enqueue, queue length: %d", b.o.queueName, len(b.queue))
+		default: // queue is full, not block, drop
+			atomic.AddInt64(&b.queued, -1)
+			b.o.limiter.release(byteSize)
+			detailMsg = fmt.Sprintf("%s queue is full, dropped item", b.o.queueName)
+			isFail = true
+			atomic.AddUint32(&b.dropped, 1)
+			b.itemDropped(ctx, sd)
+		}
 	}
 
 	switch b.o.queueName {
-	case queueNameSpan, queueNameSpanRetry:
+	case queueNameSpan, queueNameSpanHigh, queueNameSpanLow, queueNameSpanRetry:
 		eventType = consts.SpanFinishEventSpanQueueEntryRate
 		span, ok := sd.(*Span)
 		if ok {
@@ -237,6 +299,46 @@ func (b *BatchQueueManager) Enqueue(ctx context.Context, sd interface{}, byteSiz
 	return
 }
 
+// acquireBuffer reserves the bytes of item from limiter. If the limit is reached and the policy is drop oldest, the
+// oldest items in queue are dropped until there is room.
+func (b *BatchQueueManager) acquireBuffer(ctx context.Context, byteSize int64) bool {
+	if b.o.limiter.acquire(byteSize) {
+		return true
+	}
+	if !b.o.limiter.dropOldest() {
+		return false
+	}
+	for {
+		select {
+		case sd := <-b.queue:
+			switch item := sd.(type) {
+			case forceFlushSpan:
+				// the items before it are all dequeued, the flush can go on
+				close(item.flushed)
+			case bufferedItem:
+				atomic.AddInt64(&b.queued, -1)
+				b.o.limiter.release(item.size)
+				b.sizeMutex.Lock()
+				b.batchByteSize -= item.size
+				b.sizeMutex.Unlock()
+				atomic.AddUint32(&b.dropped, 1)
+				b.itemDropped(ctx, item.item)
+			}
+			if b.o.limiter.acquire(byteSize) {
+				return true
+			}
+		default:
+			return false
+		}
+	}
+}
+
+func (b *BatchQueueManager) itemDropped(ctx context.Context, item interface{}) {
+	if b.o.onDropped != nil {
+		b.o.onDropped(ctx, item)
+	}
+}
+
 func (b *BatchQueueManager) enqueueBlockOnQueueFull(ctx context.Context, sd interface{}, byteSize int64) {
 	// Do not enqueue spans after Shutdown.
 	if atomic.LoadInt32(&b.stopped) != 0 {
@@ -255,6 +357,7 @@ func (b *BatchQueueManager) Shutdown(ctx context.Context) error {
 	var err error
 	b.stopOnce.Do(func() {
 		atomic.StoreInt32(&b.stopped, 1)
+		b.drainCtx = ctx
 		wait := make(chan struct{})
 		go func() {
 			close(b.stopCh)
@@ -271,6 +374,14 @@ func (b *BatchQueueManager) Shutdown(ctx context.Context) error {
 	return err
 }
 
+func (b *BatchQueueManager) Dropped() int64 {
+	return int64(atomic.LoadUint32(&b.dropped))
+}
+
+func (b *BatchQueueManager) Pending() int64 {
+	return atomic.LoadInt64(&b.batchLen) + atomic.LoadInt64(&b.queued)
+}
+
 type forceFlushSpan struct {
 	flushed chan struct{}
 }
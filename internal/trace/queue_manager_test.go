@@ -5,9 +5,14 @@ package trace
 
 import (
 	"context"
+	"errors"
+	"sync/atomic"
 	"testing"
+	"time"
 
 	. "github.com/bytedance/mockey"
+	"github.com/coze-dev/cozeloop-go/entity"
+	"github.com/coze-dev/cozeloop-go/internal/consts"
 	"github.com/coze-dev/cozeloop-go/internal/httpclient"
 	. "github.com/smartystreets/goconvey/convey"
 )
@@ -15,7 +20,7 @@ import (
 func Test_GetBatchSpanProcessor(t *testing.T) {
 	ctx := context.Background()
 	httpClient := &httpclient.Client{}
-	spanQM := NewBatchSpanProcessor(nil, httpClient, nil, nil)
+	spanQM := NewBatchSpanProcessor(nil, httpClient, nil, nil, nil, nil, "")
 
 	PatchConvey("Test GetBatchSpanProcessor", t, func() {
 		PatchConvey("Test with valid inputs", func() {
@@ -25,3 +30,205 @@ func Test_GetBatchSpanProcessor(t *testing.T) {
 		})
 	})
 }
+
+func Test_ShutdownReport(t *testing.T) {
+	ctx := context.Background()
+	Convey("Test shutdown report", t, func() {
+		Convey("spans exported are flushed", func() {
+			processor := NewBatchSpanProcessor(&replayExporter{}, nil, nil, nil, nil, nil, "")
+			processor.OnSpanEnd(ctx, &Span{})
+			processor.OnSpanEnd(ctx, &Span{})
+			report, err := processor.Shutdown(ctx)
+			So(err, ShouldBeNil)
+			So(report.SpansFlushed, ShouldEqual, 2)
+			So(report.SpansDropped, ShouldEqual, 0)
+			So(report.SpansPending, ShouldEqual, 0)
+		})
+
+		Convey("spans failed after retry are dropped", func() {
+			processor := NewBatchSpanProcessor(&replayExporter{err: errors.New("unavailable")}, nil, nil, nil, nil, nil, "")
+			processor.OnSpanEnd(ctx, &Span{})
+			report, err := processor.Shutdown(ctx)
+			So(err, ShouldBeNil)
+			So(report.SpansFlushed, ShouldEqual, 0)
+			So(report.SpansDropped, ShouldEqual, 1)
+		})
+
+		Convey("spans are pending when ctx is done", func() {
+			processor := NewBatchSpanProcessor(&replayExporter{}, nil, nil, nil, nil, nil, "")
+			processor.OnSpanEnd(ctx, &Span{})
+			cancelCtx, cancel := context.WithCancel(ctx)
+			cancel()
+			// err may be nil if the queues stopped before ctx checked
+			report, _ := processor.Shutdown(cancelCtx)
+			So(report.SpansFlushed+report.SpansPending, ShouldEqual, 1)
+		})
+	})
+}
+
+func Test_ProcessorStats(t *testing.T) {
+	ctx := context.Background()
+	Convey("Test stats of span processor before shutdown", t, func() {
+		processor := NewBatchSpanProcessor(&replayExporter{}, nil, nil, nil, nil, nil, "").(*BatchSpanProcessor)
+		processor.OnSpanEnd(ctx, &Span{})
+		processor.OnSpanEnd(ctx, &Span{})
+		stats := processor.Stats()
+		So(stats.SpansFlushed+stats.SpansPending, ShouldEqual, 2)
+
+		So(processor.ForceFlush(ctx), ShouldBeNil)
+		stats = processor.Stats()
+		So(stats.SpansFlushed, ShouldEqual, 2)
+		So(stats.SpansPending, ShouldEqual, 0)
+		So(stats.SpansDropped, ShouldEqual, 0)
+
+		provider := &Provider{batchProcessor: processor}
+		So(provider.Stats(), ShouldResemble, stats)
+		So((&Provider{}).Stats(), ShouldResemble, Stats{})
+	})
+
+	Convey("Test stats do not wait for the export in progress", t, func() {
+		exporter := &blockingExporter{release: make(chan struct{})}
+		processor := NewBatchSpanProcessor(exporter, nil, nil, nil, &QueueConf{SpanMaxExportBatchLength: 1},
+			nil, "").(*BatchSpanProcessor)
+		processor.OnSpanEnd(ctx, &Span{})
+		for atomic.LoadInt64(&processor.spanHighQM.(*BatchQueueManager).queued) > 0 {
+			time.Sleep(time.Millisecond)
+		}
+		statsCh := make(chan Stats, 1)
+		go func() {
+			statsCh <- processor.Stats()
+		}()
+		select {
+		case stats := <-statsCh:
+			So(stats.SpansPending, ShouldEqual, 1)
+		case <-time.After(time.Second):
+			So("stats blocked by export", ShouldBeEmpty)
+		}
+
+		close(exporter.release)
+		_, err := processor.Shutdown(ctx)
+		So(err, ShouldBeNil)
+	})
+}
+
+func Test_ExportFailedRequestID(t *testing.T) {
+	ctx := context.Background()
+	Convey("Test request id of failed export is recorded in stats and span", t, func() {
+		exporter := &replayExporter{err: consts.ErrRemoteService.Wrap(consts.NewRemoteServiceError(500, 1000, "msg", "log_1"))}
+		processor := NewBatchSpanProcessor(exporter, nil, nil, nil, nil, nil, "").(*BatchSpanProcessor)
+		span := &Span{}
+		processor.OnSpanEnd(ctx, span)
+		So(processor.spanLane(span).ForceFlush(ctx), ShouldBeNil)
+		stats := processor.Stats()
+		So(stats.ExportRequestsFailed, ShouldEqual, 1)
+		So(stats.LastFailedRequestID, ShouldEqual, "log_1")
+		So(stats.SpansPending, ShouldEqual, 1)
+
+		exporter.mu.Lock()
+		exporter.err = nil
+		exporter.mu.Unlock()
+		So(processor.ForceFlush(ctx), ShouldBeNil)
+		So(exporter.spanCount(), ShouldEqual, 1)
+		So(exporter.spans[0].SystemTagsString[consts.ExportFailedRequestIDs], ShouldEqual, "log_1")
+	})
+}
+
+func Test_GroupByTrace(t *testing.T) {
+	ctx := context.Background()
+	newSpan := func(traceID, spanID string) *Span {
+		return &Span{SpanContext: SpanContext{TraceID: traceID, SpanID: spanID}}
+	}
+	exportedSpanIDs := func(conf *QueueConf) []string {
+		exporter := &replayExporter{}
+		processor := NewBatchSpanProcessor(exporter, nil, nil, nil, conf, nil, "")
+		for _, span := range []*Span{newSpan("t1", "a"), newSpan("t2", "b"), newSpan("t1", "c"), newSpan("t3", "d"), newSpan("t2", "e")} {
+			processor.OnSpanEnd(ctx, span)
+		}
+		_, _ = processor.Shutdown(ctx)
+		ids := make([]string, 0, len(exporter.spans))
+		for _, span := range exporter.spans {
+			ids = append(ids, span.SpanID)
+		}
+		return ids
+	}
+
+	Convey("Test spans are exported in the order finished by default", t, func() {
+		So(exportedSpanIDs(nil), ShouldResemble, []string{"a", "b", "c", "d", "e"})
+	})
+
+	Convey("Test spans of the same trace are exported together", t, func() {
+		So(exportedSpanIDs(&QueueConf{GroupByTrace: true}), ShouldResemble, []string{"a", "c", "b", "e", "d"})
+	})
+}
+
+// blockingExporter blocks the export of spans until released.
+type blockingExporter struct {
+	replayExporter
+	release chan struct{}
+}
+
+func (e *blockingExporter) ExportSpans(ctx context.Context, spans []*entity.UploadSpan) error {
+	<-e.release
+	return e.replayExporter.ExportSpans(ctx, spans)
+}
+
+func Test_SpanPriority(t *testing.T) {
+	ctx := context.Background()
+	Convey("Test priority of spans", t, func() {
+		So((&Span{}).exportPriority(), ShouldEqual, SpanPriorityHigh)
+		So((&Span{ParentSpanID: "b7ad6b7169203331"}).exportPriority(), ShouldEqual, SpanPriorityNormal)
+		So((&Span{ParentSpanID: "b7ad6b7169203331", StatusCode: 500}).exportPriority(), ShouldEqual, SpanPriorityHigh)
+		So((&Span{priority: SpanPriorityLow}).exportPriority(), ShouldEqual, SpanPriorityLow)
+		So((&Span{ParentSpanID: "b7ad6b7169203331", priority: SpanPriorityHigh}).exportPriority(), ShouldEqual, SpanPriorityHigh)
+	})
+
+	Convey("Test lanes of priority are sized apart from the span queue length", t, func() {
+		processor := NewBatchSpanProcessor(&replayExporter{}, nil, nil, nil, nil, nil, "").(*BatchSpanProcessor)
+		defer processor.Shutdown(ctx)
+		So(cap(processor.spanQM.(*BatchQueueManager).queue), ShouldEqual, DefaultMaxQueueLength)
+		So(cap(processor.spanHighQM.(*BatchQueueManager).queue), ShouldEqual, DefaultMaxQueueLength/4)
+		So(cap(processor.spanLowQM.(*BatchQueueManager).queue), ShouldEqual, DefaultMaxQueueLength/4)
+
+		high, low := priorityQueueLengths(nil, 1)
+		So([]int{high, low}, ShouldResemble, []int{1, 1})
+		high, low = priorityQueueLengths(&QueueConf{HighPrioritySpanQueueLength: 100, LowPrioritySpanQueueLength: 10}, 1024)
+		So([]int{high, low}, ShouldResemble, []int{100, 10})
+	})
+
+	Convey("Test spans of a trace are queued in one lane with GroupByTrace", t, func() {
+		exporter := &replayExporter{}
+		processor := NewBatchSpanProcessor(exporter, nil, nil, nil, &QueueConf{GroupByTrace: true}, nil, "").(*BatchSpanProcessor)
+		root := &Span{SpanContext: SpanContext{TraceID: "t1", SpanID: "root"}}
+		child := &Span{SpanContext: SpanContext{TraceID: "t1", SpanID: "child"}, ParentSpanID: "root", priority: SpanPriorityLow}
+		So(processor.spanLane(root) == processor.spanQM, ShouldBeTrue)
+		So(processor.spanLane(child) == processor.spanQM, ShouldBeTrue)
+		_, err := processor.Shutdown(ctx)
+		So(err, ShouldBeNil)
+	})
+
+	Convey("Test spans of low priority filling the queue do not drop the root spans", t, func() {
+		exporter := &blockingExporter{release: make(chan struct{})}
+		processor := NewBatchSpanProcessor(exporter, nil, nil, nil, &QueueConf{SpanQueueLength: 16, SpanMaxExportBatchLength: 1},
+			nil, "").(*BatchSpanProcessor)
+		for i := 0; i < 10; i++ {
+			processor.OnSpanEnd(ctx, &Span{SpanContext: SpanContext{SpanID: "low"}, ParentSpanID: "b7ad6b7169203331", priority: SpanPriorityLow})
+		}
+		for i := 0; i < 4; i++ {
+			processor.OnSpanEnd(ctx, &Span{SpanContext: SpanContext{SpanID: "root"}})
+		}
+		// at most one span is taken out of the low lane of 4 to export
+		So(processor.Stats().SpansDropped, ShouldBeBetweenOrEqual, 5, 6)
+		So(processor.spanHighQM.Dropped(), ShouldEqual, 0)
+
+		close(exporter.release)
+		_, err := processor.Shutdown(ctx)
+		So(err, ShouldBeNil)
+		roots := 0
+		for _, span := range exporter.spans {
+			if span.SpanID == "root" {
+				roots++
+			}
+		}
+		So(roots, ShouldEqual, 4)
+	})
+}
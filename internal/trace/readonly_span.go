@@ -0,0 +1,108 @@
+// Copyright (c) 2025 Bytedance Ltd. and/or its affiliates
+// SPDX-License-Identifier: MIT
+
+package trace
+
+import (
+	"context"
+	"time"
+)
+
+// ReadOnlySpan the snapshot of a finished span, which can be serialized by encoding/json. Maps are copied from
+// the span, but the tag values are shared, they should not be modified.
+type ReadOnlySpan struct {
+	TraceID     string `json:"trace_id"`
+	SpanID      string `json:"span_id"`
+	ParentID    string `json:"parent_id"`
+	LogID       string `json:"log_id,omitempty"`
+	WorkspaceID string `json:"workspace_id"`
+	ServiceName string `json:"service_name,omitempty"`
+	SpanName    string `json:"span_name"`
+	SpanType    string `json:"span_type"`
+	StatusCode  int32  `json:"status_code"`
+
+	StartTime      time.Time `json:"start_time"`
+	FinishTime     time.Time `json:"finish_time"`
+	DurationMicros int64     `json:"duration_micros"`
+
+	Tags       map[string]interface{} `json:"tags,omitempty"`
+	SystemTags map[string]interface{} `json:"system_tags,omitempty"`
+	Baggage    map[string]string      `json:"baggage,omitempty"`
+}
+
+// ReadOnly returns the snapshot of span, nil if span is nil.
+func (s *Span) ReadOnly() *ReadOnlySpan {
+	if s == nil {
+		return nil
+	}
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+	// Duration of span is in microseconds, and finish time is only kept if it is set by user
+	durationMicros := int64(s.Duration)
+	finishTime := s.FinishTime
+	if finishTime.IsZero() && !s.StartTime.IsZero() {
+		finishTime = s.StartTime.Add(time.Duration(durationMicros) * time.Microsecond)
+	}
+	return &ReadOnlySpan{
+		TraceID:        s.TraceID,
+		SpanID:         s.SpanID,
+		ParentID:       s.ParentSpanID,
+		LogID:          s.LogID,
+		WorkspaceID:    s.WorkspaceID,
+		ServiceName:    s.ServiceName,
+		SpanName:       s.Name,
+		SpanType:       s.SpanType,
+		StatusCode:     s.StatusCode,
+		StartTime:      s.StartTime,
+		FinishTime:     finishTime,
+		DurationMicros: durationMicros,
+		Tags:           copyMap(s.TagMap),
+		SystemTags:     copyMap(s.SystemTagMap),
+		Baggage:        copyMap(s.Baggage),
+	}
+}
+
+func copyMap[V any](m map[string]V) map[string]V {
+	if m == nil {
+		return nil
+	}
+	copied := make(map[string]V, len(m))
+	for k, v := range m {
+		copied[k] = v
+	}
+	return copied
+}
+
+// SpanConsumer consumes the snapshots of finished spans.
+type SpanConsumer func(ctx context.Context, span *ReadOnlySpan)
+
+var _ SpanProcessor = (*spanConsumerProcessor)(nil)
+
+// spanConsumerProcessor passes the snapshots of finished spans to consumer, it holds nothing to flush.
+type spanConsumerProcessor struct {
+	consumer SpanConsumer
+}
+
+// NewSpanConsumerProcessor returns the processor passing the snapshots of finished spans to consumer, nil if
+// consumer is nil.
+func NewSpanConsumerProcessor(consumer SpanConsumer) SpanProcessor {
+	if consumer == nil {
+		return nil
+	}
+	return &spanConsumerProcessor{consumer: consumer}
+}
+
+func (p *spanConsumerProcessor) OnSpanEnd(ctx context.Context, s *Span) {
+	if s == nil {
+		return
+	}
+	p.consumer(ctx, s.ReadOnly())
+}
+
+func (p *spanConsumerProcessor) Shutdown(ctx context.Context) (*ShutdownReport, error) {
+	return &ShutdownReport{}, nil
+}
+
+func (p *spanConsumerProcessor) ForceFlush(ctx context.Context) error {
+	return nil
+}
@@ -0,0 +1,67 @@
+// Copyright (c) 2025 Bytedance Ltd. and/or its affiliates
+// SPDX-License-Identifier: MIT
+
+package trace
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestReadOnlySpan(t *testing.T) {
+	ctx := context.Background()
+	startTime := time.Unix(1700000000, 0)
+	newSpan := func() *Span {
+		return &Span{
+			SpanContext:  SpanContext{TraceID: "trace", SpanID: "span", Baggage: map[string]string{"user": "u1"}},
+			Name:         "name",
+			SpanType:     "model",
+			ParentSpanID: "parent",
+			StartTime:    startTime,
+			Duration:     time.Duration(1500000), // microseconds
+			TagMap:       map[string]interface{}{"key": "value"},
+			SystemTagMap: map[string]interface{}{"runtime": "go"},
+		}
+	}
+
+	Convey("Test snapshot of span", t, func() {
+		So((*Span)(nil).ReadOnly(), ShouldBeNil)
+		span := newSpan()
+		snapshot := span.ReadOnly()
+		So(snapshot.TraceID, ShouldEqual, "trace")
+		So(snapshot.ParentID, ShouldEqual, "parent")
+		So(snapshot.DurationMicros, ShouldEqual, 1500000)
+		So(snapshot.FinishTime, ShouldEqual, startTime.Add(1500*time.Millisecond))
+		So(snapshot.Baggage["user"], ShouldEqual, "u1")
+
+		span.TagMap["key"] = "changed"
+		So(snapshot.Tags["key"], ShouldEqual, "value")
+
+		data, err := json.Marshal(snapshot)
+		So(err, ShouldBeNil)
+		decoded := &ReadOnlySpan{}
+		So(json.Unmarshal(data, decoded), ShouldBeNil)
+		So(decoded.SystemTags["runtime"], ShouldEqual, "go")
+		So(decoded.StartTime.Equal(startTime), ShouldBeTrue)
+	})
+
+	Convey("Test consumer processor", t, func() {
+		So(NewSpanConsumerProcessor(nil), ShouldBeNil)
+		var consumed []*ReadOnlySpan
+		processor := NewSpanConsumerProcessor(func(ctx context.Context, span *ReadOnlySpan) {
+			consumed = append(consumed, span)
+		})
+		processor.OnSpanEnd(ctx, newSpan())
+		processor.OnSpanEnd(ctx, nil)
+		So(len(consumed), ShouldEqual, 1)
+		So(consumed[0].SpanName, ShouldEqual, "name")
+		So(processor.ForceFlush(ctx), ShouldBeNil)
+		report, err := processor.Shutdown(ctx)
+		So(err, ShouldBeNil)
+		So(report, ShouldNotBeNil)
+	})
+}
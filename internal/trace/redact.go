@@ -0,0 +1,78 @@
+// Copyright (c) 2025 Bytedance Ltd. and/or its affiliates
+// SPDX-License-Identifier: MIT
+
+package trace
+
+import (
+	"regexp"
+
+	"github.com/coze-dev/cozeloop-go/entity"
+	"github.com/coze-dev/cozeloop-go/spec/tracespec"
+)
+
+// SpanRedactor is called for every span before export, and can modify input, output and tags of the span in place,
+// such as masking PII. Baggage is reported as tags, so it is redacted as well.
+// Large input and output uploaded as file are passed to SpanRedactor as the Input or Output of a span copy.
+type SpanRedactor func(span *entity.UploadSpan)
+
+const redactedMask = "***"
+
+var (
+	emailPattern      = regexp.MustCompile(`[a-zA-Z0-9._%+\-]+@[a-zA-Z0-9.\-]+\.[a-zA-Z]{2,}`)
+	creditCardPattern = regexp.MustCompile(`\b(?:\d[ \-]?){12,18}\d\b`)
+	// phone patterns match phone numbers of known formats only, so that ids and timestamps are not masked:
+	// international numbers with a country code, such as +1 415 555 1234, numbers with separators or area code,
+	// such as 415-555-1234 and (010) 1234-5678, and mobile numbers of China, such as 13812345678.
+	intlPhonePattern = regexp.MustCompile(`\+\d{1,3}(?:[ \-]?\d{2,4}){2,4}\b`)
+	phonePattern     = regexp.MustCompile(`(?:\(\d{2,4}\)[ \-]?|\b\d{2,4}[ \-])\d{3,4}[ \-]\d{4}\b|\b1[3-9]\d{9}\b`)
+)
+
+// RedactPII masks email addresses, credit card numbers and phone numbers in text.
+func RedactPII(text string) string {
+	text = emailPattern.ReplaceAllString(text, redactedMask)
+	// international phone goes before credit card, because credit card pattern matches the digits after the '+',
+	// and credit card goes before the other phone, because phone pattern matches the tail of card numbers
+	text = intlPhonePattern.ReplaceAllString(text, redactedMask)
+	text = creditCardPattern.ReplaceAllString(text, redactedMask)
+	text = phonePattern.ReplaceAllString(text, redactedMask)
+	return text
+}
+
+// NewTextRedactor returns a SpanRedactor which applies redact to input, output and string tags of span.
+func NewTextRedactor(redact func(text string) string) SpanRedactor {
+	return func(span *entity.UploadSpan) {
+		span.Input = redact(span.Input)
+		span.Output = redact(span.Output)
+		for key, value := range span.TagsString {
+			span.TagsString[key] = redact(value)
+		}
+	}
+}
+
+func redactSpans(redactor SpanRedactor, spans []*entity.UploadSpan, files []*entity.UploadFile) {
+	if redactor == nil {
+		return
+	}
+	for _, span := range spans {
+		if span != nil {
+			redactor(span)
+		}
+	}
+	for _, file := range files {
+		if file == nil || file.UploadType != entity.UploadTypeLong {
+			continue
+		}
+		// large text of input or output, redact it as the input or output of span
+		span := &entity.UploadSpan{}
+		switch file.TagKey {
+		case tracespec.Input:
+			span.Input = file.Data
+			redactor(span)
+			file.Data = span.Input
+		case tracespec.Output:
+			span.Output = file.Data
+			redactor(span)
+			file.Data = span.Output
+		}
+	}
+}
@@ -0,0 +1,52 @@
+// Copyright (c) 2025 Bytedance Ltd. and/or its affiliates
+// SPDX-License-Identifier: MIT
+
+package trace
+
+import (
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+
+	"github.com/coze-dev/cozeloop-go/entity"
+	"github.com/coze-dev/cozeloop-go/spec/tracespec"
+)
+
+func TestRedactPII(t *testing.T) {
+	Convey("Test RedactPII", t, func() {
+		So(RedactPII("mail me at foo.bar@example.com please"), ShouldEqual, "mail me at *** please")
+		So(RedactPII("card 4111 1111 1111 1111 expired"), ShouldEqual, "card *** expired")
+		So(RedactPII("call +1 415 555 1234 or 13812345678"), ShouldEqual, "call *** or ***")
+		So(RedactPII("order 42 costs 100 dollars"), ShouldEqual, "order 42 costs 100 dollars")
+		So(RedactPII("call 415-555-1234, (010) 1234-5678 or +86 138 1234 5678"), ShouldEqual, "call ***, *** or ***")
+		So(RedactPII("created at 1700000000"), ShouldEqual, "created at 1700000000")
+		So(RedactPII("user 987654321 of order 20240101123"), ShouldEqual, "user 987654321 of order 20240101123")
+		So(RedactPII("released on 2024-01-15"), ShouldEqual, "released on 2024-01-15")
+	})
+}
+
+func TestRedactSpans(t *testing.T) {
+	Convey("Test redactSpans", t, func() {
+		spans := []*entity.UploadSpan{
+			{
+				Input:      "my email is foo@example.com",
+				Output:     "ok",
+				TagsString: map[string]string{"user": "bar@example.com"},
+			},
+		}
+		files := []*entity.UploadFile{
+			{TagKey: tracespec.Output, UploadType: entity.UploadTypeLong, Data: "phone 13812345678"},
+			{TagKey: tracespec.Input, UploadType: entity.UploadTypeMultiModality, Data: "foo@example.com"},
+		}
+
+		redactSpans(NewTextRedactor(RedactPII), spans, files)
+		So(spans[0].Input, ShouldEqual, "my email is ***")
+		So(spans[0].Output, ShouldEqual, "ok")
+		So(spans[0].TagsString["user"], ShouldEqual, "***")
+		So(files[0].Data, ShouldEqual, "phone ***")
+		So(files[1].Data, ShouldEqual, "foo@example.com")
+
+		// nil redactor does nothing
+		redactSpans(nil, spans, files)
+	})
+}
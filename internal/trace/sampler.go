@@ -0,0 +1,80 @@
+// Copyright (c) 2025 Bytedance Ltd. and/or its affiliates
+// SPDX-License-Identifier: MIT
+
+package trace
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"math"
+	"sync/atomic"
+)
+
+// runtimeConf the options of Provider which can be updated while spans are being started, so they are read and
+// written atomically. The defaults are used if it is nil.
+type runtimeConf struct {
+	sampleRatio      uint64 // bits of float64
+	noCaptureContent int32
+}
+
+func newRuntimeConf(options Options) *runtimeConf {
+	c := &runtimeConf{}
+	ratio := 1.0
+	if options.SampleRatio != nil {
+		ratio = *options.SampleRatio
+	}
+	c.setSampleRatio(ratio)
+	c.setCaptureContent(!options.NoCaptureContent)
+	return c
+}
+
+func (c *runtimeConf) setSampleRatio(ratio float64) {
+	atomic.StoreUint64(&c.sampleRatio, math.Float64bits(ratio))
+}
+
+func (c *runtimeConf) getSampleRatio() float64 {
+	if c == nil {
+		return 1
+	}
+	return math.Float64frombits(atomic.LoadUint64(&c.sampleRatio))
+}
+
+func (c *runtimeConf) setCaptureContent(enable bool) {
+	var noCapture int32
+	if !enable {
+		noCapture = 1
+	}
+	atomic.StoreInt32(&c.noCaptureContent, noCapture)
+}
+
+func (c *runtimeConf) captureContent() bool {
+	if c == nil {
+		return true
+	}
+	return atomic.LoadInt32(&c.noCaptureContent) == 0
+}
+
+// sampledByRatio decides whether a trace is reported by the hash of its trace id, so all spans of the trace,
+// including the ones in downstream services with the same ratio, make the same decision.
+func sampledByRatio(traceID string, ratio float64) bool {
+	if ratio >= 1 {
+		return true
+	}
+	if ratio <= 0 {
+		return false
+	}
+	sum := sha256.Sum256([]byte(traceID))
+	// the top 53 bits are used as float64 in [0, 1)
+	return float64(binary.BigEndian.Uint64(sum[:8])>>11)/(1<<53) < ratio
+}
+
+// SetSampleRatio updates the ratio of traces reported, it takes effect on the spans started later.
+func (t *Provider) SetSampleRatio(ratio float64) {
+	t.runtime.setSampleRatio(ratio)
+}
+
+// SetCaptureContent updates whether the input and output of spans are reported as they are, see
+// Options.NoCaptureContent. It takes effect on the spans started later.
+func (t *Provider) SetCaptureContent(enable bool) {
+	t.runtime.setCaptureContent(enable)
+}
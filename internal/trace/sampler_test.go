@@ -0,0 +1,69 @@
+// Copyright (c) 2025 Bytedance Ltd. and/or its affiliates
+// SPDX-License-Identifier: MIT
+
+package trace
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+
+	"github.com/coze-dev/cozeloop-go/spec/tracespec"
+)
+
+func TestSampleRatio(t *testing.T) {
+	Convey("Test traces are sampled by the hash of trace id", t, func() {
+		sampled, inconsistent := 0, 0
+		for i := 0; i < 10000; i++ {
+			traceID := fmt.Sprintf("%032x", i)
+			if sampledByRatio(traceID, 0.3) {
+				sampled++
+				// traces sampled by a lower ratio are also sampled by a higher one
+				if !sampledByRatio(traceID, 0.3) || !sampledByRatio(traceID, 0.6) {
+					inconsistent++
+				}
+			}
+		}
+		So(sampled, ShouldBeBetween, 2700, 3300)
+		So(inconsistent, ShouldEqual, 0)
+		So(sampledByRatio("trace", 0), ShouldBeFalse)
+		So(sampledByRatio("trace", 1), ShouldBeTrue)
+	})
+
+	Convey("Test sample ratio and capture content are updated at runtime", t, func() {
+		ctx := context.Background()
+		var consumed []*ReadOnlySpan
+		ratio := 0.0
+		provider := NewTraceProvider(nil, Options{
+			Exporter:    &replayExporter{},
+			SampleRatio: &ratio,
+			SpanProcessors: []SpanProcessor{NewSpanConsumerProcessor(func(ctx context.Context, span *ReadOnlySpan) {
+				consumed = append(consumed, span)
+			})},
+		})
+		defer func() {
+			_, _ = provider.CloseTrace(ctx)
+		}()
+		startAndFinish := func() {
+			spanCtx, span, err := provider.StartSpan(ctx, "span", tracespec.VModelSpanType, StartSpanOptions{})
+			So(err, ShouldBeNil)
+			span.SetInput(spanCtx, "hello")
+			span.Finish(spanCtx)
+		}
+
+		startAndFinish()
+		So(consumed, ShouldBeEmpty)
+
+		provider.SetSampleRatio(1)
+		startAndFinish()
+		So(len(consumed), ShouldEqual, 1)
+		So(consumed[0].Tags[tracespec.Input], ShouldEqual, "hello")
+
+		provider.SetCaptureContent(false)
+		startAndFinish()
+		So(len(consumed), ShouldEqual, 2)
+		So(consumed[1].Tags[tracespec.Input], ShouldStartWith, "sha256:")
+	})
+}
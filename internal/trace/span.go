@@ -10,6 +10,7 @@ import (
 	"net/textproto"
 	"net/url"
 	"reflect"
+	"sort"
 	"strings"
 	"sync"
 	"sync/atomic"
@@ -71,18 +72,42 @@ type Span struct {
 	ultraLargeReportKeyMap map[string]struct{}
 	ultraLargeReport       bool
 	spanProcessor          SpanProcessor
-	flags                  byte  // for W3C, useless now
+	flags                  byte  // for W3C, spans without sampled flag are not reported
 	isFinished             int32 // avoid executing finish repeatedly.
 	lock                   sync.RWMutex
 	bytesSize              int64            // bytes size of span, note: it is an estimated value, may not be accurate.
 	tagTruncateConf        *TagTruncateConf // tag truncate byte conf
+	leakDetector           *leakDetector    // nil if leak detection is disabled
+	modelPricing           *ModelPricing    // nil if cost is not computed
+	baggageConf            *BaggageConf     // nil for default limits
+	clock                  Clock            // nil for time.Now
+	stream                 *streamTimeline  // nil if no stream chunk is recorded
+	noCaptureContent       bool             // input and output are replaced with hashes and lengths
+	priority               SpanPriority     // empty for the priority by whether it is root or error span
+	tagLint                *TagLintConf     // nil if tags are not linted
 }
 
 type TagTruncateConf struct {
 	NormalFieldMaxByte      int
 	InputOutputFieldMaxByte int
+	// MaxTagCount max count of custom tags in one span, tags beyond it are discarded.
+	MaxTagCount int
+	// TruncationPolicy how to deal with the tag value exceeding the size limit.
+	TruncationPolicy TruncationPolicy
 }
 
+// TruncationPolicy is the policy to deal with the tag value exceeding the size limit.
+type TruncationPolicy string
+
+const (
+	// TruncationPolicyTruncate truncate the value to the size limit, it is the default policy.
+	TruncationPolicyTruncate TruncationPolicy = "truncate"
+	// TruncationPolicyDrop drop the whole tag.
+	TruncationPolicyDrop TruncationPolicy = "drop"
+	// TruncationPolicyUploadFile upload the whole value of input and output as file, the same as UltraLargeReport.
+	TruncationPolicyUploadFile TruncationPolicy = "upload_file"
+)
+
 func (s *Span) GetBaggage() map[string]string {
 	var bg map[string]string
 	s.lock.RLock()
@@ -106,13 +131,61 @@ func (s *Span) setCutOffTag(cutOffKeys []string) {
 	s.SystemTagMap[consts.CutOff] = util.RmDupStrSlice(cutOffKeys)
 }
 
-func (s *Span) setTagItem(ctx context.Context, key string, value interface{}) {
-	if int64(len(s.TagMap)) < consts.MaxTagKvCountInOneSpan {
-		s.setTagUnlock(key, value)
-	} else {
-		logger.CtxErrorf(ctx, "tag count exceed limit:%d", consts.MaxTagKvCountInOneSpan)
+// setDroppedTag records the keys of tags dropped by the validation of type, size and count in system tag.
+func (s *Span) setDroppedTag(droppedKeys []string) {
+	if s.SystemTagMap == nil {
+		s.SystemTagMap = make(map[string]interface{})
 	}
-	return
+	if droppedTags, ok := s.SystemTagMap[consts.DroppedTags]; ok {
+		if value, ok := droppedTags.([]string); ok {
+			droppedKeys = append(droppedKeys, value...)
+		}
+	}
+	s.SystemTagMap[consts.DroppedTags] = util.RmDupStrSlice(droppedKeys)
+}
+
+// addExportFailedRequestID records the log id of the ingest request failed to export the span in system tag, so that
+// the failure can be located by the platform team after the span is exported by retry.
+func (s *Span) addExportFailedRequestID(requestID string) {
+	if requestID == "" {
+		return
+	}
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	if s.SystemTagMap == nil {
+		s.SystemTagMap = make(map[string]interface{})
+	}
+	if value, ok := s.SystemTagMap[consts.ExportFailedRequestIDs].(string); ok && value != "" {
+		requestID = value + "," + requestID
+	}
+	s.SystemTagMap[consts.ExportFailedRequestIDs] = requestID
+}
+
+func (s *Span) setTagItem(ctx context.Context, key string, value interface{}) bool {
+	limit := s.getTagCountLimit()
+	if len(s.TagMap) >= limit {
+		logger.CtxErrorf(ctx, "tag count exceed limit:%d", limit)
+		return false
+	}
+	s.setTagUnlock(key, value)
+	return true
+}
+
+func (s *Span) getTagCountLimit() int {
+	if s.tagTruncateConf != nil && s.tagTruncateConf.MaxTagCount > 0 {
+		return s.tagTruncateConf.MaxTagCount
+	}
+	return consts.MaxTagKvCountInOneSpan
+}
+
+func (s *Span) getTruncationPolicy() TruncationPolicy {
+	if s.tagTruncateConf != nil && s.tagTruncateConf.TruncationPolicy != "" {
+		return s.tagTruncateConf.TruncationPolicy
+	}
+	if s.ultraLargeReport {
+		return TruncationPolicyUploadFile
+	}
+	return TruncationPolicyTruncate
 }
 
 func (s *Span) setTagUnlock(key string, value interface{}) {
@@ -181,11 +254,20 @@ func (s *Span) GetStatusCode() int32 {
 	return s.StatusCode
 }
 
+func (s *Span) SetUltraLargeReport(enable bool) {
+	if s == nil || s.isSpanFinished() {
+		return
+	}
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.ultraLargeReport = enable
+}
+
 func (s *Span) UltraLargeReport() bool {
 	if s == nil {
 		return false
 	}
-	return s.ultraLargeReport
+	return s.getTruncationPolicy() == TruncationPolicyUploadFile
 }
 
 func oneTag(k string, v interface{}) map[string]interface{} {
@@ -197,6 +279,11 @@ func oneBaggage(k string, v string) map[string]string {
 }
 
 func FromHeader(ctx context.Context, h map[string]string) *SpanContext {
+	return fromHeader(ctx, h, nil)
+}
+
+// fromHeader parses the span context from header, the ids of header parent are normalized by idConf if it is not nil.
+func fromHeader(ctx context.Context, h map[string]string, idConf *IDNormalizationConf) *SpanContext {
 	header := make(map[string]string)
 	for key, value := range h {
 		newKey := textproto.CanonicalMIMEHeaderKey(key)
@@ -206,7 +293,7 @@ func FromHeader(ctx context.Context, h map[string]string) *SpanContext {
 	s := &SpanContext{}
 	// W3C: https://www.w3.org/TR/trace-context/#tracestate-header
 	if headerParent, ok := header[consts.TraceContextHeaderParent]; ok {
-		traceID, spanID, err := fromHeaderParent(headerParent)
+		traceID, spanID, err := fromHeaderParent(headerParent, idConf)
 		if err != nil {
 			// return null span context if failed to parse header parent
 			logger.CtxWarnf(ctx, "failed to parse header parent: %v", err)
@@ -260,35 +347,46 @@ func parseCommaSeparatedMap(src string, cover bool) (baggage map[string]string)
 	return baggage
 }
 
-func fromHeaderParent(h string) (traceID, spanID string, err error) {
+func fromHeaderParent(h string, idConf *IDNormalizationConf) (traceID, spanID string, err error) {
+	if idConf != nil {
+		h = strings.TrimSpace(h)
+	}
 	splits := strings.Split(h, "-")
 	if len(splits) != 4 {
 		return "", "", consts.ErrHeaderParent
 	}
 
-	traceIDTemp := splits[1]
-	if len(traceIDTemp) != 32 || traceIDTemp == "00000000000000000000000000000000" {
-		return "", "", consts.ErrHeaderParent.Wrap(fmt.Errorf("invalid trace id: %s", traceIDTemp))
-	}
-	if !util.IsValidHexStr(traceIDTemp) {
+	traceIDTemp := idConf.normalizeTraceID(splits[1])
+	if !isValidTraceID(traceIDTemp) {
 		return "", "", consts.ErrHeaderParent.Wrap(fmt.Errorf("invalid trace id: %s", traceIDTemp))
 	}
 
-	spanIDTemp := splits[2]
-	if len(spanIDTemp) != 16 || spanIDTemp == "0000000000000000" {
-		return "", "", consts.ErrHeaderParent.Wrap(fmt.Errorf("invalid span id: %s", spanIDTemp))
-	}
-	if !util.IsValidHexStr(spanIDTemp) {
+	spanIDTemp := idConf.normalizeSpanID(splits[2])
+	if !isValidSpanID(spanIDTemp) {
 		return "", "", consts.ErrHeaderParent.Wrap(fmt.Errorf("invalid span id: %s", spanIDTemp))
 	}
 
 	return traceIDTemp, spanIDTemp, nil
 }
 
+// isValidTraceID returns whether id is 32 hex chars and not all zero.
+func isValidTraceID(id string) bool {
+	return len(id) == 32 && id != "00000000000000000000000000000000" && util.IsValidHexStr(id)
+}
+
+// isValidSpanID returns whether id is 16 hex chars and not all zero.
+func isValidSpanID(id string) bool {
+	return len(id) == 16 && id != "0000000000000000" && util.IsValidHexStr(id)
+}
+
 func (s *Span) SetInput(ctx context.Context, input interface{}) {
 	if s == nil || s.isSpanFinished() {
 		return
 	}
+	if s.noCaptureContent { // no attachments are uploaded
+		s.SetTags(ctx, oneTag(tracespec.Input, input))
+		return
+	}
 
 	messageParts := make([]*tracespec.ModelMessagePart, 0)
 	mContent := tracespec.ModelInput{}
@@ -347,6 +445,18 @@ func deepCopyMessageOfModelInput(src tracespec.ModelInput) tracespec.ModelInput
 					Suffix: part.FileURL.Suffix,
 				}
 			}
+			if part.AudioURL != nil {
+				tempPart.AudioURL = &tracespec.ModelAudioURL{
+					Name: part.AudioURL.Name,
+					URL:  part.AudioURL.URL,
+				}
+			}
+			if part.VideoURL != nil {
+				tempPart.VideoURL = &tracespec.ModelVideoURL{
+					Name: part.VideoURL.Name,
+					URL:  part.VideoURL.URL,
+				}
+			}
 			result.Messages[i].Parts[j] = tempPart
 		}
 	}
@@ -375,6 +485,14 @@ func getModelInputBytesSize(mContent tracespec.ModelInput) int64 {
 				if part.FileURL != nil && part.FileURL.URL != "" {
 					part.FileURL.URL = ""
 				}
+			case tracespec.ModelMessagePartTypeAudio:
+				if part.AudioURL != nil && part.AudioURL.URL != "" {
+					part.AudioURL.URL = ""
+				}
+			case tracespec.ModelMessagePartTypeVideo:
+				if part.VideoURL != nil && part.VideoURL.URL != "" {
+					part.VideoURL.URL = ""
+				}
 			}
 		}
 	}
@@ -409,6 +527,26 @@ func parseModelMessageParts(mContents []*tracespec.ModelMessagePart) (isMultiMod
 					isMultiModality = true
 				}
 			}
+		case tracespec.ModelMessagePartTypeAudio:
+			if content.AudioURL != nil && content.AudioURL.URL != "" {
+				if base64Data, isBase64 := util.ParseValidMDNBase64(content.AudioURL.URL); isBase64 {
+					content.AudioURL.URL = base64Data
+					isMultiModality = true
+				}
+				if isValidURL := util.IsValidURL(content.AudioURL.URL); isValidURL {
+					isMultiModality = true
+				}
+			}
+		case tracespec.ModelMessagePartTypeVideo:
+			if content.VideoURL != nil && content.VideoURL.URL != "" {
+				if base64Data, isBase64 := util.ParseValidMDNBase64(content.VideoURL.URL); isBase64 {
+					content.VideoURL.URL = base64Data
+					isMultiModality = true
+				}
+				if isValidURL := util.IsValidURL(content.VideoURL.URL); isValidURL {
+					isMultiModality = true
+				}
+			}
 		}
 	}
 
@@ -419,6 +557,10 @@ func (s *Span) SetOutput(ctx context.Context, output interface{}) {
 	if s == nil || s.isSpanFinished() {
 		return
 	}
+	if s.noCaptureContent { // no attachments are uploaded
+		s.SetTags(ctx, oneTag(tracespec.Output, output))
+		return
+	}
 	mContent := tracespec.ModelOutput{}
 	messageParts := make([]*tracespec.ModelMessagePart, 0)
 	if mContents, ok := output.(tracespec.ModelOutput); ok {
@@ -479,6 +621,18 @@ func deepCopyMessageOfModelOutput(src tracespec.ModelOutput) tracespec.ModelOutp
 					Suffix: part.FileURL.Suffix,
 				}
 			}
+			if part.AudioURL != nil {
+				tempPart.AudioURL = &tracespec.ModelAudioURL{
+					Name: part.AudioURL.Name,
+					URL:  part.AudioURL.URL,
+				}
+			}
+			if part.VideoURL != nil {
+				tempPart.VideoURL = &tracespec.ModelVideoURL{
+					Name: part.VideoURL.Name,
+					URL:  part.VideoURL.URL,
+				}
+			}
 			result.Choices[i].Message.Parts[j] = tempPart
 		}
 	}
@@ -504,6 +658,14 @@ func getModelOutputBytesSize(mContent tracespec.ModelOutput) int64 {
 				if part.FileURL != nil && part.FileURL.URL != "" {
 					part.FileURL.URL = ""
 				}
+			case tracespec.ModelMessagePartTypeAudio:
+				if part.AudioURL != nil && part.AudioURL.URL != "" {
+					part.AudioURL.URL = ""
+				}
+			case tracespec.ModelMessagePartTypeVideo:
+				if part.VideoURL != nil && part.VideoURL.URL != "" {
+					part.VideoURL.URL = ""
+				}
 			}
 		}
 	}
@@ -607,6 +769,55 @@ func (s *Span) SetModelCallOptions(ctx context.Context, callOptions interface{})
 	s.SetTags(ctx, oneTag(tracespec.CallOptions, callOptions))
 }
 
+func (s *Span) SetStream(ctx context.Context, stream bool) {
+	if s == nil || s.isSpanFinished() {
+		return
+	}
+	s.SetTags(ctx, oneTag(tracespec.Stream, stream))
+}
+
+func (s *Span) SetReasoningTokens(ctx context.Context, reasoningTokens int) {
+	if s == nil || s.isSpanFinished() {
+		return
+	}
+	s.SetTags(ctx, oneTag(tracespec.ReasoningTokens, reasoningTokens))
+}
+
+func (s *Span) SetReasoningDuration(ctx context.Context, duration time.Duration) {
+	if s == nil || s.isSpanFinished() {
+		return
+	}
+	s.SetTags(ctx, oneTag(tracespec.ReasoningDuration, duration.Microseconds()))
+}
+
+func (s *Span) SetToolCallID(ctx context.Context, toolCallID string) {
+	if s == nil || s.isSpanFinished() {
+		return
+	}
+	s.SetTags(ctx, oneTag(tracespec.ToolCallID, toolCallID))
+}
+
+func (s *Span) SetRetrieverProvider(ctx context.Context, provider string) {
+	if s == nil || s.isSpanFinished() {
+		return
+	}
+	s.SetTags(ctx, oneTag(tracespec.RetrieverProvider, provider))
+}
+
+func (s *Span) SetRetrieverCallOptions(ctx context.Context, callOptions tracespec.RetrieverCallOption) {
+	if s == nil || s.isSpanFinished() {
+		return
+	}
+	s.SetTags(ctx, oneTag(tracespec.CallOptions, callOptions))
+}
+
+func (s *Span) SetRetrieverDocuments(ctx context.Context, documents []*tracespec.RetrieverDocument) {
+	if s == nil || s.isSpanFinished() {
+		return
+	}
+	s.SetOutput(ctx, tracespec.RetrieverOutput{Documents: documents})
+}
+
 func (s *Span) SetInputTokens(ctx context.Context, inputTokens int) {
 	if s == nil || s.isSpanFinished() {
 		return
@@ -621,6 +832,13 @@ func (s *Span) SetOutputTokens(ctx context.Context, outputTokens int) {
 	s.SetTags(ctx, oneTag(tracespec.OutputTokens, outputTokens))
 }
 
+func (s *Span) SetCost(ctx context.Context, cost float64) {
+	if s == nil || s.isSpanFinished() {
+		return
+	}
+	s.SetTags(ctx, oneTag(tracespec.Cost, cost))
+}
+
 func (s *Span) SetStartTimeFirstResp(ctx context.Context, startTimeFirstResp int64) {
 	if s == nil || s.isSpanFinished() {
 		return
@@ -632,19 +850,79 @@ func (s *Span) SetTags(ctx context.Context, tagKVs map[string]interface{}) {
 	if s == nil || len(tagKVs) == 0 || s.isSpanFinished() {
 		return
 	}
+	s.lintTags(ctx, tagKVs)
+	s.setTags(ctx, tagKVs)
+}
+
+// TagErrors the errors of tags dropped by SetTagsE, by tag key. Each error wraps one of consts.ErrTagInvalidType,
+// consts.ErrTagTooLarge and consts.ErrTagCountExceeded.
+type TagErrors map[string]error
+
+func (e TagErrors) Error() string {
+	keys := e.keys()
+	sort.Strings(keys)
+	msgs := make([]string, 0, len(keys))
+	for _, key := range keys {
+		msgs = append(msgs, fmt.Sprintf("%s: %v", key, e[key]))
+	}
+	return "tags dropped, " + strings.Join(msgs, "; ")
+}
+
+func (e TagErrors) keys() []string {
+	keys := make([]string, 0, len(e))
+	for key := range e {
+		keys = append(keys, key)
+	}
+	return keys
+}
+
+func (e *TagErrors) add(key string, err error) {
+	if e == nil {
+		return
+	}
+	if *e == nil {
+		*e = make(TagErrors)
+	}
+	(*e)[key] = err
+}
+
+// SetTagsE works like SetTags, and returns TagErrors of the tags dropped, such as reserved tags of wrong type.
+func (s *Span) SetTagsE(ctx context.Context, tagKVs map[string]interface{}) error {
+	if s == nil || len(tagKVs) == 0 {
+		return nil
+	}
+	if s.isSpanFinished() {
+		return consts.ErrSpanFinished
+	}
+	s.lintTags(ctx, tagKVs)
+	if errs := s.setTags(ctx, tagKVs); len(errs) > 0 {
+		return errs
+	}
+	return nil
+}
 
+// setTags sets tags without checking whether span is finished, for the tags computed in Finish.
+func (s *Span) setTags(ctx context.Context, tagKVs map[string]interface{}) TagErrors {
+	tagKVs = s.summarizeContentTags(tagKVs)
 	s.lock.Lock()
 	defer s.lock.Unlock()
 
 	s.addDefaultTag(ctx, tagKVs)
-	rectifiedMap, cutOffKeys, byteSize := s.GetRectifiedMap(ctx, tagKVs)
+	var errs TagErrors
+	rectifiedMap, cutOffKeys, byteSize := s.rectifyTags(ctx, tagKVs, &errs)
 	s.bytesSize += byteSize
 	if len(cutOffKeys) > 0 {
 		s.setCutOffTag(cutOffKeys)
 	}
 	for key, value := range rectifiedMap {
-		s.setTagItem(ctx, key, value)
+		if !s.setTagItem(ctx, key, value) {
+			errs.add(key, consts.ErrTagCountExceeded)
+		}
+	}
+	if len(errs) > 0 {
+		s.setDroppedTag(errs.keys())
 	}
+	return errs
 }
 
 func (s *Span) addDefaultTag(ctx context.Context, tagKVs map[string]interface{}) {
@@ -661,6 +939,11 @@ func (s *Span) addDefaultTag(ctx context.Context, tagKVs map[string]interface{})
 
 // GetRectifiedMap get rectified tag map and cut off keys
 func (s *Span) GetRectifiedMap(ctx context.Context, inputMap map[string]interface{}) (map[string]interface{}, []string, int64) {
+	return s.rectifyTags(ctx, inputMap, nil)
+}
+
+// rectifyTags works like GetRectifiedMap, and records the errors of dropped tags in errs if it is not nil.
+func (s *Span) rectifyTags(ctx context.Context, inputMap map[string]interface{}, errs *TagErrors) (map[string]interface{}, []string, int64) {
 	validateMap := make(map[string]interface{})
 	var cutOffKeys []string
 	var bytesSize int64
@@ -669,6 +952,7 @@ func (s *Span) GetRectifiedMap(ctx context.Context, inputMap map[string]interfac
 		if expectedType, exists := consts.ReserveFieldTypes[key]; exists {
 			if !isTagValidDataType(key, value) {
 				logger.CtxErrorf(ctx, "The value for field [%s] is not in the correct format, type:%s, expectedType:%s", key, reflect.TypeOf(value), expectedType)
+				errs.add(key, fmt.Errorf("%w: type %s, expected %s", consts.ErrTagInvalidType, reflect.TypeOf(value), expectedType))
 				continue
 			}
 		}
@@ -681,11 +965,16 @@ func (s *Span) GetRectifiedMap(ctx context.Context, inputMap map[string]interfac
 		tagValueLengthLimit := s.getTagValueSizeLimit(key)
 		isUltraLargeReport := false
 		v, isTruncate := util.TruncateStringByByte(valueStr, tagValueLengthLimit)
-		if isTruncate {
-			if _, ok := s.multiModalityKeyMap[key]; !ok && s.UltraLargeReport() { // not multi-modality, enable ultra-large-report option, do ultra-large-report
+		if _, ok := s.multiModalityKeyMap[key]; !ok && isTruncate { // multi-modality, skip check value
+			switch s.getTruncationPolicy() {
+			case TruncationPolicyUploadFile: // do ultra-large-report
 				isUltraLargeReport = true
-			}
-			if _, ok := s.multiModalityKeyMap[key]; !ok && !s.UltraLargeReport() { // multi-modality or ultra large report, skip check value
+			case TruncationPolicyDrop:
+				cutOffKeys = append(cutOffKeys, key)
+				logger.CtxWarnf(ctx, "field value [%s] is too long, and truncation policy is drop, so the field has been dropped", key)
+				errs.add(key, consts.ErrTagTooLarge)
+				continue
+			default:
 				value = v
 				cutOffKeys = append(cutOffKeys, key)
 				logger.CtxWarnf(ctx, "field value [%s] is too long, and opt.EnableLongReport is false, so value has been truncated to %d size", key, tagValueLengthLimit)
@@ -781,11 +1070,8 @@ func (s *Span) setBaggage(ctx context.Context, baggageItems map[string]string) {
 	for key, value := range baggageItems {
 		if !isValidBaggageItem(ctx, key, value) {
 			logger.CtxErrorf(ctx, "invalid baggageItems:%s:%s", key, value)
-		} else {
+		} else if s.addBaggageItem(ctx, key, value) {
 			s.SetTags(ctx, map[string]interface{}{key: value})
-			newKey := key
-			newValue := value
-			s.SetBaggageItem(newKey, newValue)
 		}
 	}
 }
@@ -825,18 +1111,71 @@ func (s *Span) SetBaggageItem(restrictedKey, value string) {
 	return
 }
 
+// FinishOptions the final fields of span set by FinishWithOptions, the zero fields are not set.
+type FinishOptions struct {
+	// Error the error of span, which also sets StatusCode to the code of error if StatusCode is zero
+	Error error
+	// StatusCode the status code of span
+	StatusCode int
+	// Tags the custom tags of span
+	Tags map[string]interface{}
+	// FinishTime the finish time of span, the time of clock is used if it is zero
+	FinishTime time.Time
+	// Duration the duration of span, which is used as FinishTime - StartTime if FinishTime is zero
+	Duration time.Duration
+}
+
 func (s *Span) Finish(ctx context.Context) {
+	s.FinishWithOptions(ctx, FinishOptions{})
+}
+
+// FinishWithOptions sets the final fields of span and finishes it. The fields are set after the span is marked
+// finished, so they are not mixed with the fields set by other goroutines at the same time.
+func (s *Span) FinishWithOptions(ctx context.Context, opts FinishOptions) {
 	if s == nil {
 		return
 	}
 	if !s.isDoFinish() {
 		return
 	}
+	s.applyFinishOptions(ctx, opts)
+	s.leakDetector.untrack(s)
 	s.setSystemTag(ctx)
 	s.setStatInfo(ctx)
+	if s.flags&sampledFlag == 0 {
+		return
+	}
 	s.spanProcessor.OnSpanEnd(ctx, s)
 }
 
+func (s *Span) applyFinishOptions(ctx context.Context, opts FinishOptions) {
+	tags := make(map[string]interface{}, len(opts.Tags)+1)
+	for key, value := range opts.Tags {
+		tags[key] = value
+	}
+	statusCode := opts.StatusCode
+	if opts.Error != nil {
+		tags[tracespec.Error] = opts.Error.Error()
+		if statusCode == 0 {
+			statusCode = util.GetErrorCode(opts.Error)
+		}
+	}
+	if len(tags) > 0 {
+		s.setTags(ctx, tags)
+	}
+
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	if statusCode != 0 {
+		s.StatusCode = int32(statusCode)
+	}
+	if !opts.FinishTime.IsZero() {
+		s.FinishTime = opts.FinishTime
+	} else if opts.Duration > 0 {
+		s.FinishTime = s.StartTime.Add(opts.Duration)
+	}
+}
+
 func (s *Span) isDoFinish() bool {
 	return atomic.CompareAndSwapInt32(&s.isFinished, spanUnFinished, spanFinished)
 }
@@ -871,21 +1210,35 @@ func (s *Span) setSystemTag(ctx context.Context) {
 
 // SetStatInfo sets statistical data.
 func (s *Span) setStatInfo(ctx context.Context) {
+	if streamTags := s.streamTags(); streamTags != nil {
+		s.setTags(ctx, streamTags)
+	}
+
 	tagMap := s.GetTagMap()
 	if tempV, ok := tagMap[consts.StartTimeFirstResp]; ok {
 		// latency_first_resp = start_time_first_resp - start_time
-		s.SetTags(ctx, map[string]interface{}{consts.LatencyFirstResp: util.GetValueOfInt(tempV) - s.GetStartTime().UnixMicro()})
+		s.setTags(ctx, map[string]interface{}{consts.LatencyFirstResp: util.GetValueOfInt(tempV) - s.GetStartTime().UnixMicro()})
 	}
 
 	inputTokens, inputTokensExist := tagMap[tracespec.InputTokens]
 	outputTokens, outputTokensExist := tagMap[tracespec.OutputTokens]
 	if inputTokensExist || outputTokensExist {
 		// tokens = input_tokens+output_tokens
-		s.SetTags(ctx, map[string]interface{}{tracespec.Tokens: util.GetValueOfInt(inputTokens) + util.GetValueOfInt(outputTokens)})
+		s.setTags(ctx, map[string]interface{}{tracespec.Tokens: util.GetValueOfInt(inputTokens) + util.GetValueOfInt(outputTokens)})
+
+		// cost is computed by model pricing, unless it is set by user
+		if _, costExist := tagMap[tracespec.Cost]; !costExist && s.modelPricing != nil {
+			modelName, _ := tagMap[tracespec.ModelName].(string)
+			cost, ok := s.modelPricing.Cost(modelName, util.GetValueOfInt(inputTokens),
+				util.GetValueOfInt(tagMap[tracespec.InputCachedTokens]), util.GetValueOfInt(outputTokens))
+			if ok {
+				s.setTags(ctx, map[string]interface{}{tracespec.Cost: cost})
+			}
+		}
 	}
 
 	// Duration = finish_time - start_time, unit: microseconds
-	finishTime := time.Now()
+	finishTime := s.now()
 	if !s.GetFinishTime().IsZero() {
 		finishTime = s.GetFinishTime()
 	}
@@ -895,6 +1248,13 @@ func (s *Span) setStatInfo(ctx context.Context) {
 	s.lock.Unlock()
 }
 
+func (s *Span) now() time.Time {
+	if s.clock == nil {
+		return time.Now()
+	}
+	return s.clock.Now()
+}
+
 func (s *Span) GetStartTime() time.Time {
 	if s == nil {
 		return time.Time{}
@@ -944,19 +1304,12 @@ func (s *Span) ToHeader() (map[string]string, error) {
 }
 
 func (s *Span) toHeaderBaggage() (string, error) {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
 	if len(s.Baggage) == 0 {
 		return "", nil
 	}
-	m := make(map[string]string)
-	for k, v := range s.Baggage {
-		tempK := k
-		tempV := v
-		// empty key or value is invalid
-		if tempK != "" && tempV != "" {
-			m[url.QueryEscape(tempK)] = url.QueryEscape(tempV)
-		}
-	}
-	return util.MapToStringString(m), nil
+	return encodeBaggage(s.Baggage, s.baggageConf), nil
 }
 
 func (s *Span) toHeaderParent() string {
@@ -21,6 +21,8 @@ import (
 	"github.com/coze-dev/cozeloop-go/entity"
 	"github.com/coze-dev/cozeloop-go/internal/consts"
 	"github.com/coze-dev/cozeloop-go/internal/httpclient"
+	"github.com/coze-dev/cozeloop-go/internal/logger"
+	"github.com/coze-dev/cozeloop-go/internal/util"
 )
 
 // Defaults for batchQueueManagerOptions.
@@ -39,43 +41,136 @@ const (
 )
 
 type QueueConf struct {
-	SpanQueueLength          int
-	SpanMaxExportBatchLength int
+	// SpanQueueLength max spans of normal priority buffered, which are all the spans if priority is not set except
+	// root spans and error spans, see SpanPriority. Default is 1024.
+	SpanQueueLength int
+	// HighPrioritySpanQueueLength max spans of high priority buffered in their own lane. Default is a quarter of
+	// SpanQueueLength.
+	HighPrioritySpanQueueLength int
+	// LowPrioritySpanQueueLength max spans of low priority buffered in their own lane. Default is a quarter of
+	// SpanQueueLength.
+	LowPrioritySpanQueueLength int
+	SpanMaxExportBatchLength   int
+	// MaxRetries max times to export spans and files again in retry queues after the first export failed, they are
+	// dropped after that. Default is 1, no retry if it is negative.
+	MaxRetries int
+	// RetryBackoff delay between the retries, which is doubled for every retry and capped at 30s. Default is 0.
+	// Flush waits for the retries in progress, so keep it small.
+	RetryBackoff time.Duration
+	// OnExportDropped is called with the spans and files dropped after retries failed, so that they can be persisted
+	// or alerted. It is called in the export goroutine and should not block.
+	OnExportDropped func(ctx context.Context, dropped *ExportDropped)
+	// OnExportResult is called with the result of every batch exported, including the retries, which can be used to
+	// keep an audit log of the spans delivered. It is called in the export goroutine and should not block.
+	OnExportResult func(ctx context.Context, result *ExportResult)
+	// MaxBufferedBytes max bytes of spans and files buffered across the queues, which protects the memory when
+	// export is unavailable for a while. Items are dropped by BufferEvictionPolicy if it is reached. Default is 0,
+	// no limit other than the queue lengths.
+	MaxBufferedBytes int64
+	// BufferEvictionPolicy which items are dropped when MaxBufferedBytes is reached. Default is
+	// BufferEvictionPolicyDropNewest.
+	BufferEvictionPolicy BufferEvictionPolicy
+	// GroupByTrace export the spans of the same trace together in an ingest request, in the order of the first
+	// span of each trace finished, instead of the order of spans finished. It only regroups the spans finished
+	// within the same batch, which is exported every scheduling window or when it is full. The spans are all
+	// queued in the lane of normal priority, so that the spans of a trace are not split by priority. Default is
+	// false.
+	GroupByTrace bool
 }
 
 var _ SpanProcessor = (*BatchSpanProcessor)(nil)
 
 type SpanProcessor interface {
 	OnSpanEnd(ctx context.Context, s *Span)
-	Shutdown(ctx context.Context) error
+	Shutdown(ctx context.Context) (*ShutdownReport, error)
 	ForceFlush(ctx context.Context) error
 }
 
+// ShutdownReport statistics of span processor when shutdown. Counts are accumulated since the processor created.
+type ShutdownReport struct {
+	// SpansFlushed spans exported successfully
+	SpansFlushed int64
+	// SpansDropped spans dropped because queue is full or export failed after retry
+	SpansDropped int64
+	// SpansPending spans left in queues, which are not exported before ctx done
+	SpansPending int64
+	// FilesFlushed files uploaded successfully
+	FilesFlushed int64
+	// FilesDropped files dropped because queue is full or upload failed after retry
+	FilesDropped int64
+	// FilesPending files left in queues, which are not uploaded before ctx done
+	FilesPending int64
+	// Duration time taken by shutdown
+	Duration time.Duration
+}
+
+// Stats statistics of span processor at the moment. Counts of flushed and dropped are accumulated since the
+// processor created, and pending are the items in queues now.
+type Stats struct {
+	// SpansFlushed spans exported successfully
+	SpansFlushed int64
+	// SpansDropped spans dropped because queue is full or export failed after retry
+	SpansDropped int64
+	// SpansPending spans in queues waiting to be exported, including the ones to retry
+	SpansPending int64
+	// FilesFlushed files uploaded successfully
+	FilesFlushed int64
+	// FilesDropped files dropped because queue is full or upload failed after retry
+	FilesDropped int64
+	// FilesPending files in queues waiting to be uploaded, including the ones to retry
+	FilesPending int64
+	// BufferedBytes bytes of spans and files buffered in queues, only counted if QueueConf.MaxBufferedBytes is set
+	BufferedBytes int64
+	// ExportRequestsFailed export requests of spans and files failed, including the ones retried later
+	ExportRequestsFailed int64
+	// LastFailedRequestID log id of the last failed export request, attach it when reporting issues to the platform team
+	LastFailedRequestID string
+}
+
+// exportStats counts exported and dropped items of export funcs, updated atomically.
+type exportStats struct {
+	spansFlushed int64
+	spansDropped int64
+	filesFlushed int64
+	filesDropped int64
+
+	requestsFailed      int64
+	lastFailedRequestID atomic.Value // string
+}
+
+func (s *exportStats) add(addr *int64, delta int) {
+	if s != nil {
+		atomic.AddInt64(addr, int64(delta))
+	}
+}
+
+// failed counts the failed export request, and returns its log id if the request reached CozeLoop.
+func (s *exportStats) failed(err error) string {
+	requestID := consts.RequestIDOf(err)
+	if s != nil {
+		atomic.AddInt64(&s.requestsFailed, 1)
+		if requestID != "" {
+			s.lastFailedRequestID.Store(requestID)
+		}
+	}
+	return requestID
+}
+
+func (s *exportStats) lastRequestID() string {
+	requestID, _ := s.lastFailedRequestID.Load().(string)
+	return requestID
+}
+
 func NewBatchSpanProcessor(
 	ex Exporter,
 	client *httpclient.Client,
 	uploadPath *UploadPath,
 	finishEventProcessor func(ctx context.Context, info *consts.FinishEventInfo),
 	queueConf *QueueConf,
+	redactor SpanRedactor,
+	persistentQueueDir string,
 ) SpanProcessor {
-	var exporter Exporter
-	spanPath := pathIngestTrace
-	filePath := pathUploadFile
-	if uploadPath != nil {
-		if uploadPath.spanUploadPath != "" {
-			spanPath = uploadPath.spanUploadPath
-		}
-		if uploadPath.fileUploadPath != "" {
-			filePath = uploadPath.fileUploadPath
-		}
-	}
-	exporter = &SpanExporter{
-		client: client,
-		uploadPath: UploadPath{
-			spanUploadPath: spanPath,
-			fileUploadPath: filePath,
-		},
-	}
+	var exporter Exporter = newSpanExporter(client, uploadPath)
 	if ex != nil {
 		exporter = ex
 	}
@@ -90,6 +185,28 @@ func NewBatchSpanProcessor(
 		}
 	}
 
+	stats := &exportStats{}
+	retrier := newExportRetrier(queueConf)
+	groupByTrace := queueConf != nil && queueConf.GroupByTrace
+	limiter := newBufferLimiter(queueConf)
+	var pq *persistentQueue
+	var replayRecords []*persistentRecord
+	if persistentQueueDir != "" {
+		var err error
+		pq, replayRecords, err = openPersistentQueue(persistentQueueDir, redactor)
+		if err != nil {
+			logger.CtxErrorf(context.Background(), "open persistent queue in %s failed, spans will not be persisted: %v", persistentQueueDir, err)
+			pq = nil
+		}
+	}
+
+	// the spans dropped by queues are acked, so that they are not replayed after restart
+	onSpanDropped := func(ctx context.Context, item interface{}) {
+		if span, ok := item.(*Span); ok {
+			pq.AckDropped(ctx, span)
+		}
+	}
+
 	fileRetryQM := newBatchQueueManager(
 		batchQueueManagerOptions{
 			queueName:              queueNameFileRetry,
@@ -97,8 +214,9 @@ func NewBatchSpanProcessor(
 			maxQueueLength:         MaxFileQueueLength,
 			maxExportBatchLength:   MaxFileExportBatchLength,
 			maxExportBatchByteSize: MaxFileExportBatchByteSize,
-			exportFunc:             newExportFilesFunc(exporter, nil, finishEventProcessor),
+			exportFunc:             newExportFilesFunc(exporter, nil, finishEventProcessor, stats, retrier),
 			finishEventProcessor:   finishEventProcessor,
+			limiter:                limiter,
 		})
 	fileQM := newBatchQueueManager(
 		batchQueueManagerOptions{
@@ -107,8 +225,9 @@ func NewBatchSpanProcessor(
 			maxQueueLength:         MaxFileQueueLength,
 			maxExportBatchLength:   MaxFileExportBatchLength,
 			maxExportBatchByteSize: MaxFileExportBatchByteSize,
-			exportFunc:             newExportFilesFunc(exporter, fileRetryQM, finishEventProcessor),
+			exportFunc:             newExportFilesFunc(exporter, fileRetryQM, finishEventProcessor, stats, retrier),
 			finishEventProcessor:   finishEventProcessor,
+			limiter:                limiter,
 		})
 
 	spanRetryQM := newBatchQueueManager(
@@ -118,36 +237,70 @@ func NewBatchSpanProcessor(
 			maxQueueLength:         DefaultMaxRetryQueueLength,
 			maxExportBatchLength:   MaxRetryExportBatchLength,
 			maxExportBatchByteSize: DefaultMaxExportBatchByteSize,
-			exportFunc:             newExportSpansFunc(exporter, nil, fileQM, finishEventProcessor),
+			exportFunc:             newExportSpansFunc(exporter, nil, fileQM, finishEventProcessor, redactor, pq, stats, retrier, groupByTrace),
 			finishEventProcessor:   finishEventProcessor,
+			limiter:                limiter,
+			onDropped:              onSpanDropped,
 		})
 
-	spanQM := newBatchQueueManager(
-		batchQueueManagerOptions{
-			queueName:              queueNameSpan,
-			batchTimeout:           time.Duration(DefaultScheduleDelay) * time.Millisecond,
-			maxQueueLength:         spanQueueLength,
-			maxExportBatchLength:   spanMaxExportBatchLength,
-			maxExportBatchByteSize: DefaultMaxExportBatchByteSize,
-			exportFunc:             newExportSpansFunc(exporter, spanRetryQM, fileQM, finishEventProcessor),
-			finishEventProcessor:   finishEventProcessor,
-		})
+	// spans of each priority are queued in their own lane, see SpanPriority
+	newSpanQM := func(queueName string, queueLength int) *BatchQueueManager {
+		return newBatchQueueManager(
+			batchQueueManagerOptions{
+				queueName:              queueName,
+				batchTimeout:           time.Duration(DefaultScheduleDelay) * time.Millisecond,
+				maxQueueLength:         queueLength,
+				maxExportBatchLength:   spanMaxExportBatchLength,
+				maxExportBatchByteSize: DefaultMaxExportBatchByteSize,
+				exportFunc:             newExportSpansFunc(exporter, spanRetryQM, fileQM, finishEventProcessor, redactor, pq, stats, retrier, groupByTrace),
+				finishEventProcessor:   finishEventProcessor,
+				limiter:                limiter,
+				onDropped:              onSpanDropped,
+			})
+	}
+	highQueueLength, lowQueueLength := priorityQueueLengths(queueConf, spanQueueLength)
 
-	return &BatchSpanProcessor{
-		spanQM:      spanQM,
-		spanRetryQM: spanRetryQM,
-		fileQM:      fileQM,
-		fileRetryQM: fileRetryQM,
+	b := &BatchSpanProcessor{
+		spanHighQM:      newSpanQM(queueNameSpanHigh, highQueueLength),
+		spanQM:          newSpanQM(queueNameSpan, spanQueueLength),
+		spanLowQM:       newSpanQM(queueNameSpanLow, lowQueueLength),
+		spanRetryQM:     spanRetryQM,
+		fileQM:          fileQM,
+		fileRetryQM:     fileRetryQM,
+		persistentQueue: pq,
+		redactor:        redactor,
+		stats:           stats,
+		retrier:         retrier,
+		limiter:         limiter,
+		groupByTrace:    groupByTrace,
 	}
+	if len(replayRecords) > 0 {
+		util.GoSafe(context.Background(), func() {
+			b.replay(context.Background(), exporter, replayRecords, spanMaxExportBatchLength)
+		})
+	}
+	return b
 }
 
 // BatchSpanProcessor implements SpanProcessor
 type BatchSpanProcessor struct {
+	// spanHighQM, spanQM and spanLowQM the lanes of spans by SpanPriority
+	spanHighQM  QueueManager
 	spanQM      QueueManager
+	spanLowQM   QueueManager
 	spanRetryQM QueueManager
 	fileQM      QueueManager
 	fileRetryQM QueueManager
 
+	// groupByTrace spans are all queued in spanQM, so that the spans of a trace are exported together
+	groupByTrace bool
+	// persistentQueue persists finished spans until exported, nil if disabled.
+	persistentQueue *persistentQueue
+	redactor        SpanRedactor
+	stats           *exportStats
+	retrier         *exportRetrier
+	limiter         *bufferLimiter
+
 	exporter SpanExporter
 
 	stopped int32
@@ -158,49 +311,173 @@ func (b *BatchSpanProcessor) OnSpanEnd(ctx context.Context, s *Span) {
 		return
 	}
 
-	b.spanQM.Enqueue(ctx, s, s.bytesSize)
+	b.persistentQueue.Add(ctx, s)
+	b.spanLane(s).Enqueue(ctx, s, s.bytesSize)
 }
 
-func (b *BatchSpanProcessor) Shutdown(ctx context.Context) error {
-	if err := b.spanQM.Shutdown(ctx); err != nil {
-		return err
+// priorityQueueLengths returns the lengths of the lanes of high and low priority, which are a quarter of the span
+// queue length by default, at least 1.
+func priorityQueueLengths(queueConf *QueueConf, spanQueueLength int) (high, low int) {
+	high = spanQueueLength / 4
+	if high == 0 {
+		high = 1
+	}
+	low = high
+	if queueConf != nil && queueConf.HighPrioritySpanQueueLength > 0 {
+		high = queueConf.HighPrioritySpanQueueLength
+	}
+	if queueConf != nil && queueConf.LowPrioritySpanQueueLength > 0 {
+		low = queueConf.LowPrioritySpanQueueLength
 	}
-	if err := b.spanRetryQM.Shutdown(ctx); err != nil {
-		return err
+	return high, low
+}
+
+// spanLane returns the queue of span by its priority, or the queue of normal priority if spans are grouped by trace.
+func (b *BatchSpanProcessor) spanLane(s *Span) QueueManager {
+	if b.groupByTrace {
+		return b.spanQM
 	}
-	if err := b.fileQM.Shutdown(ctx); err != nil {
-		return err
+	switch s.exportPriority() {
+	case SpanPriorityHigh:
+		return b.spanHighQM
+	case SpanPriorityLow:
+		return b.spanLowQM
+	default:
+		return b.spanQM
 	}
-	if err := b.fileRetryQM.Shutdown(ctx); err != nil {
-		return err
+}
+
+// spanQMs returns the queues of spans to export, in the order of priority.
+func (b *BatchSpanProcessor) spanQMs() []QueueManager {
+	return []QueueManager{b.spanHighQM, b.spanQM, b.spanLowQM}
+}
+
+// replay exports the spans left in persistent queue by last process, and acks them when exported successfully.
+// The spans failed to export are kept in persistent queue, and will be replayed after next restart, until they are
+// replayed persistentQueueMaxReplays times.
+func (b *BatchSpanProcessor) replay(ctx context.Context, exporter Exporter, records []*persistentRecord, batchLength int) {
+	logger.CtxInfof(ctx, "replay %d spans from persistent queue", len(records))
+	for start := 0; start < len(records); start += batchLength {
+		end := start + batchLength
+		if end > len(records) {
+			end = len(records)
+		}
+		spans := make([]*entity.UploadSpan, 0, end-start)
+		for _, record := range records[start:end] {
+			spans = append(spans, record.Span)
+		}
+		if err := exporter.ExportSpans(ctx, spans); err != nil {
+			logger.CtxErrorf(ctx, "replay spans from persistent queue failed: %v", err)
+			continue
+		}
+		b.persistentQueue.Ack(ctx, spans)
+		for _, record := range records[start:end] {
+			for _, file := range record.Files {
+				if file != nil {
+					b.fileQM.Enqueue(ctx, file, int64(len(file.Data)))
+				}
+			}
+		}
 	}
+}
 
+// Shutdown shuts down the queues in order, spans exported are sent to file queue before it is shut down.
+// All queues share the deadline of ctx, the items not exported before ctx done are reported as pending.
+func (b *BatchSpanProcessor) Shutdown(ctx context.Context) (*ShutdownReport, error) {
+	start := time.Now()
 	atomic.StoreInt32(&b.stopped, 1)
-	return nil
-}
+	b.retrier.stop()
 
-func (b *BatchSpanProcessor) ForceFlush(ctx context.Context) error {
-	if err := b.spanQM.ForceFlush(ctx); err != nil {
-		return err
+	var err error
+	for _, qm := range append(b.spanQMs(), b.spanRetryQM, b.fileQM, b.fileRetryQM) {
+		if qmErr := qm.Shutdown(ctx); qmErr != nil && err == nil {
+			err = qmErr
+		}
 	}
-	if err := b.spanRetryQM.ForceFlush(ctx); err != nil {
-		return err
+	if pqErr := b.persistentQueue.Close(); pqErr != nil && err == nil {
+		err = pqErr
 	}
-	if err := b.fileQM.ForceFlush(ctx); err != nil {
-		return err
+
+	stats := b.Stats()
+	report := &ShutdownReport{
+		SpansFlushed: stats.SpansFlushed,
+		SpansDropped: stats.SpansDropped,
+		SpansPending: stats.SpansPending,
+		FilesFlushed: stats.FilesFlushed,
+		FilesDropped: stats.FilesDropped,
+		FilesPending: stats.FilesPending,
+		Duration:     time.Since(start),
 	}
-	if err := b.fileRetryQM.ForceFlush(ctx); err != nil {
-		return err
+	return report, err
+}
+
+// Stats returns the statistics of spans and files at the moment, it can be called at any time.
+func (b *BatchSpanProcessor) Stats() Stats {
+	spansDropped := atomic.LoadInt64(&b.stats.spansDropped) + b.spanRetryQM.Dropped()
+	spansPending := b.spanRetryQM.Pending()
+	for _, qm := range b.spanQMs() {
+		spansDropped += qm.Dropped()
+		spansPending += qm.Pending()
+	}
+	return Stats{
+		SpansFlushed:  atomic.LoadInt64(&b.stats.spansFlushed),
+		SpansDropped:  spansDropped,
+		SpansPending:  spansPending,
+		FilesFlushed:  atomic.LoadInt64(&b.stats.filesFlushed),
+		FilesDropped:  atomic.LoadInt64(&b.stats.filesDropped) + b.fileQM.Dropped() + b.fileRetryQM.Dropped(),
+		FilesPending:  b.fileQM.Pending() + b.fileRetryQM.Pending(),
+		BufferedBytes: b.limiter.bufferedBytes(),
+
+		ExportRequestsFailed: atomic.LoadInt64(&b.stats.requestsFailed),
+		LastFailedRequestID:  b.stats.lastRequestID(),
+	}
+}
+
+func (b *BatchSpanProcessor) ForceFlush(ctx context.Context) error {
+	for _, qm := range append(b.spanQMs(), b.spanRetryQM, b.fileQM, b.fileRetryQM) {
+		if err := qm.ForceFlush(ctx); err != nil {
+			return err
+		}
 	}
 
 	return nil
 }
 
+// groupSpansByTrace reorders spans so that the spans of the same trace are adjacent. Traces are in the order of
+// their first span, and spans of a trace keep their order.
+func groupSpansByTrace(spans []*Span) []*Span {
+	if len(spans) <= 1 {
+		return spans
+	}
+	traceIDs := make([]string, 0)
+	groups := make(map[string][]*Span)
+	for _, span := range spans {
+		traceID := span.GetTraceID()
+		if _, ok := groups[traceID]; !ok {
+			traceIDs = append(traceIDs, traceID)
+		}
+		groups[traceID] = append(groups[traceID], span)
+	}
+	if len(traceIDs) == 1 {
+		return spans
+	}
+	grouped := make([]*Span, 0, len(spans))
+	for _, traceID := range traceIDs {
+		grouped = append(grouped, groups[traceID]...)
+	}
+	return grouped
+}
+
 func newExportSpansFunc(
 	exporter Exporter,
 	spanRetryQueue QueueManager,
 	fileQueue QueueManager,
 	finishEventProcessor func(ctx context.Context, info *consts.FinishEventInfo),
+	redactor SpanRedactor,
+	pq *persistentQueue,
+	stats *exportStats,
+	retrier *exportRetrier,
+	groupByTrace bool,
 ) exportFunc {
 	return func(ctx context.Context, l []interface{}) {
 		spans := make([]*Span, 0, len(l))
@@ -209,23 +486,44 @@ func newExportSpansFunc(
 				spans = append(spans, span)
 			}
 		}
+		if groupByTrace {
+			spans = groupSpansByTrace(spans)
+		}
 		var errMsg string
 		var isFail bool
 		uploadSpans, uploadFiles := transferToUploadSpanAndFile(ctx, spans)
+		redactSpans(redactor, uploadSpans, uploadFiles)
 		before := time.Now()
-		err := exporter.ExportSpans(ctx, uploadSpans)
-		tsMs := time.Now().Sub(before).Milliseconds()
+		export := func() error {
+			return exporter.ExportSpans(ctx, uploadSpans)
+		}
+		var err error
+		if spanRetryQueue != nil {
+			err = export()
+		} else {
+			err = retrier.retry(ctx, export)
+		}
+		latency := time.Since(before)
+		tsMs := latency.Milliseconds()
+		retrier.result(ctx, &ExportResult{Spans: uploadSpans, Retry: spanRetryQueue == nil, Err: err, Latency: latency})
 		if err != nil { // fail, send to retry queue.
-			if spanRetryQueue != nil {
+			requestID := stats.failed(err)
+			if spanRetryQueue != nil && retrier.enabled() {
 				for _, span := range spans {
+					span.addExportFailedRequestID(requestID)
 					spanRetryQueue.Enqueue(ctx, span, span.bytesSize)
 				}
 				errMsg = fmt.Sprintf("%v, retry later", err.Error())
 			} else {
-				errMsg = fmt.Sprintf("%v, retry second time failed", err.Error())
+				errMsg = fmt.Sprintf("%v, retry failed, dropped", err.Error())
+				// the spans are kept in persistent queue, which are replayed after restart
+				stats.add(&stats.spansDropped, len(uploadSpans))
+				retrier.dropped(ctx, &ExportDropped{Spans: uploadSpans, Err: err})
 			}
 			isFail = true
 		} else { // success, send to file queue.
+			stats.add(&stats.spansFlushed, len(uploadSpans))
+			pq.Ack(ctx, uploadSpans)
 			for _, file := range uploadFiles {
 				if file == nil {
 					continue
@@ -253,6 +551,8 @@ func newExportFilesFunc(
 	exporter Exporter,
 	fileRetryQueue QueueManager,
 	finishEventProcessor func(ctx context.Context, info *consts.FinishEventInfo),
+	stats *exportStats,
+	retrier *exportRetrier,
 ) exportFunc {
 	return func(ctx context.Context, l []interface{}) {
 		files := make([]*entity.UploadFile, 0, len(l))
@@ -264,18 +564,33 @@ func newExportFilesFunc(
 		var errMsg string
 		var isFail bool
 		before := time.Now()
-		err := exporter.ExportFiles(ctx, files)
-		tsMs := time.Now().Sub(before).Milliseconds()
+		export := func() error {
+			return exporter.ExportFiles(ctx, files)
+		}
+		var err error
+		if fileRetryQueue != nil {
+			err = export()
+		} else {
+			err = retrier.retry(ctx, export)
+		}
+		latency := time.Since(before)
+		tsMs := latency.Milliseconds()
+		retrier.result(ctx, &ExportResult{Files: files, Retry: fileRetryQueue == nil, Err: err, Latency: latency})
 		if err != nil {
-			if fileRetryQueue != nil {
+			stats.failed(err)
+			if fileRetryQueue != nil && retrier.enabled() {
 				for _, bat := range files {
 					fileRetryQueue.Enqueue(ctx, bat, int64(len(bat.Data)))
 				}
 				errMsg = fmt.Sprintf("%v, retry later", err.Error())
 			} else {
-				errMsg = fmt.Sprintf("%v, retry second time failed", err.Error())
+				errMsg = fmt.Sprintf("%v, retry failed, dropped", err.Error())
+				stats.add(&stats.filesDropped, len(files))
+				retrier.dropped(ctx, &ExportDropped{Files: files, Err: err})
 			}
 			isFail = true
+		} else {
+			stats.add(&stats.filesFlushed, len(files))
 		}
 		if finishEventProcessor != nil {
 			finishEventProcessor(ctx, &consts.FinishEventInfo{
@@ -9,6 +9,7 @@ import (
 	"fmt"
 	"io/ioutil"
 	"net/http"
+	"strings"
 	"sync"
 	"testing"
 	"time"
@@ -89,6 +90,48 @@ func Test_SetTag(t *testing.T) {
 	})
 }
 
+func Test_TagTruncateConf(t *testing.T) {
+	ctx := context.Background()
+	longValue := strings.Repeat("a", 20)
+
+	Convey("Test truncate policy by default", t, func() {
+		s := newMockSpan()
+		s.SystemTagMap = make(map[string]interface{})
+		s.tagTruncateConf = &TagTruncateConf{NormalFieldMaxByte: 10}
+		s.SetTags(ctx, map[string]interface{}{"key": longValue})
+		So(s.GetTagMap()["key"], ShouldEqual, longValue[:10])
+		So(s.SystemTagMap[consts.CutOff], ShouldResemble, []string{"key"})
+	})
+
+	Convey("Test drop policy", t, func() {
+		s := newMockSpan()
+		s.SystemTagMap = make(map[string]interface{})
+		s.tagTruncateConf = &TagTruncateConf{NormalFieldMaxByte: 10, TruncationPolicy: TruncationPolicyDrop}
+		s.SetTags(ctx, map[string]interface{}{"key": longValue, "short": "b"})
+		So(s.GetTagMap(), ShouldResemble, map[string]interface{}{"short": "b"})
+		So(s.SystemTagMap[consts.CutOff], ShouldResemble, []string{"key"})
+	})
+
+	Convey("Test upload file policy", t, func() {
+		s := newMockSpan()
+		s.tagTruncateConf = &TagTruncateConf{InputOutputFieldMaxByte: 10, TruncationPolicy: TruncationPolicyUploadFile}
+		So(s.UltraLargeReport(), ShouldBeTrue)
+		s.SetTags(ctx, map[string]interface{}{tracespec.Input: longValue})
+		So(s.GetTagMap()[tracespec.Input], ShouldEqual, longValue)
+
+		_, f := transferText(longValue, s, tracespec.Input)
+		So(f, ShouldNotBeNil)
+		So(f.Data, ShouldEqual, longValue)
+	})
+
+	Convey("Test max tag count", t, func() {
+		s := newMockSpan()
+		s.tagTruncateConf = &TagTruncateConf{MaxTagCount: 2}
+		s.SetTags(ctx, map[string]interface{}{"key1": "1", "key2": "2", "key3": "3"})
+		So(len(s.GetTagMap()), ShouldEqual, 2)
+	})
+}
+
 func Test_SetBaggage(t *testing.T) {
 	ctx := context.Background()
 	PatchConvey("Test SetBaggage with nil Span", t, func() {
@@ -129,7 +172,7 @@ func Test_Finish(t *testing.T) {
 	httpClient := httpclient.NewClient("", nil, nil, nil)
 	s := &Span{
 		isFinished:    0,
-		spanProcessor: NewBatchSpanProcessor(nil, httpClient, nil, nil),
+		spanProcessor: NewBatchSpanProcessor(nil, httpClient, nil, nil, nil, nil, ""),
 		lock:          sync.RWMutex{},
 		TagMap:        make(map[string]interface{}),
 	}
@@ -303,3 +346,89 @@ func getImageBytes(url string) (string, error) {
 
 	return string(imageData), nil
 }
+
+func Test_SpanTypedSetters(t *testing.T) {
+	Convey("Test typed setters of tracespec tags", t, func() {
+		ctx := context.Background()
+		span := newMockSpan()
+		span.SetStream(ctx, true)
+		span.SetReasoningTokens(ctx, 10)
+		span.SetReasoningDuration(ctx, 2*time.Millisecond)
+		span.SetToolCallID(ctx, "call_1")
+		span.SetRetrieverProvider(ctx, "VikingDB")
+		span.SetRetrieverCallOptions(ctx, tracespec.RetrieverCallOption{TopK: 3})
+		span.SetRetrieverDocuments(ctx, []*tracespec.RetrieverDocument{{ID: "doc1", Content: "content", Score: 0.9}})
+
+		So(span.TagMap[tracespec.Stream], ShouldEqual, true)
+		So(span.TagMap[tracespec.ReasoningTokens], ShouldEqual, 10)
+		So(span.TagMap[tracespec.ReasoningDuration], ShouldEqual, int64(2000))
+		So(span.TagMap[tracespec.ToolCallID], ShouldEqual, "call_1")
+		So(span.TagMap[tracespec.RetrieverProvider], ShouldEqual, "VikingDB")
+		So(span.TagMap[tracespec.CallOptions], ShouldContainSubstring, `"top_k":3`)
+		So(span.TagMap[tracespec.Output], ShouldContainSubstring, `"documents":[{"id":"doc1"`)
+	})
+}
+
+func Test_SetTagsE(t *testing.T) {
+	Convey("Test SetTagsE returns the errors of dropped tags", t, func() {
+		ctx := context.Background()
+		span := newMockSpan()
+		span.SystemTagMap = make(map[string]interface{})
+		span.tagTruncateConf = &TagTruncateConf{MaxTagCount: 2, NormalFieldMaxByte: 5, TruncationPolicy: TruncationPolicyDrop}
+
+		So(span.SetTagsE(ctx, map[string]interface{}{"a": 1}), ShouldBeNil)
+		err := span.SetTagsE(ctx, map[string]interface{}{
+			consts.UserID: 123,
+			"long":        "too long value",
+		})
+		var tagErrs TagErrors
+		So(errors.As(err, &tagErrs), ShouldBeTrue)
+		So(len(tagErrs), ShouldEqual, 2)
+		So(errors.Is(tagErrs[consts.UserID], consts.ErrTagInvalidType), ShouldBeTrue)
+		So(errors.Is(tagErrs["long"], consts.ErrTagTooLarge), ShouldBeTrue)
+		So(err.Error(), ShouldStartWith, "tags dropped, long: ")
+
+		So(span.SetTagsE(ctx, map[string]interface{}{"b": 2}), ShouldBeNil)
+		err = span.SetTagsE(ctx, map[string]interface{}{"c": 3})
+		So(errors.Is(err.(TagErrors)["c"], consts.ErrTagCountExceeded), ShouldBeTrue)
+		So(span.SystemTagMap[consts.DroppedTags], ShouldHaveLength, 3)
+
+		span.isFinished = 1
+		So(span.SetTagsE(ctx, map[string]interface{}{"d": 4}), ShouldEqual, consts.ErrSpanFinished)
+	})
+}
+
+func TestFinishWithOptions(t *testing.T) {
+	ctx := context.Background()
+	Convey("Test final fields are set when span is finished", t, func() {
+		exporter := &replayExporter{}
+		provider := NewTraceProvider(nil, Options{Exporter: exporter})
+		defer func() {
+			_, _ = provider.CloseTrace(ctx)
+		}()
+
+		_, span, err := provider.StartSpan(ctx, "span", "custom", StartSpanOptions{})
+		So(err, ShouldBeNil)
+		span.FinishWithOptions(ctx, FinishOptions{
+			Error:    errors.New("failed"),
+			Tags:     map[string]interface{}{"result": "partial"},
+			Duration: 2 * time.Second,
+		})
+		So(span.GetTagMap()["result"], ShouldEqual, "partial")
+		So(span.GetTagMap()[tracespec.Error], ShouldEqual, "failed")
+		So(span.GetStatusCode(), ShouldEqual, int32(consts.StatusCodeErrorDefault))
+		So(span.GetDuration(), ShouldEqual, int64(2*time.Second/time.Microsecond))
+
+		// the span is finished only once
+		span.FinishWithOptions(ctx, FinishOptions{StatusCode: 1, Tags: map[string]interface{}{"result": "ok"}})
+		span.SetTags(ctx, map[string]interface{}{"result": "ok"})
+		So(span.GetTagMap()["result"], ShouldEqual, "partial")
+		So(span.GetStatusCode(), ShouldEqual, int32(consts.StatusCodeErrorDefault))
+
+		_, span, err = provider.StartSpan(ctx, "span", "custom", StartSpanOptions{})
+		So(err, ShouldBeNil)
+		span.FinishWithOptions(ctx, FinishOptions{StatusCode: 404, FinishTime: span.GetStartTime().Add(time.Second), Duration: time.Hour})
+		So(span.GetStatusCode(), ShouldEqual, int32(404))
+		So(span.GetDuration(), ShouldEqual, int64(time.Second/time.Microsecond))
+	})
+}
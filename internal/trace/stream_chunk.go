@@ -0,0 +1,99 @@
+// Copyright (c) 2025 Bytedance Ltd. and/or its affiliates
+// SPDX-License-Identifier: MIT
+
+package trace
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"github.com/coze-dev/cozeloop-go/internal/consts"
+	"github.com/coze-dev/cozeloop-go/spec/tracespec"
+)
+
+// maxStreamChunkGaps max count of the latencies between chunks kept for percentiles, the later ones are only
+// counted in duration and tokens.
+const maxStreamChunkGaps = 10000
+
+// streamTimeline the arrival of chunks of streaming output, which is summarized into tags when span finished.
+type streamTimeline struct {
+	first, last time.Time
+	chunks      int
+	tokens      int // tokens of the chunks after the first one
+	gaps        []time.Duration
+}
+
+func (t *streamTimeline) add(now time.Time, tokenCount int) {
+	if t.chunks > 0 {
+		t.tokens += tokenCount
+		if len(t.gaps) < maxStreamChunkGaps {
+			t.gaps = append(t.gaps, now.Sub(t.last))
+		}
+	} else {
+		t.first = now
+	}
+	t.last = now
+	t.chunks++
+}
+
+// tags returns the stats of stream. Duration is from the first chunk to the last one, and tokens per second is
+// the speed of output after the first chunk, so the latency of first chunk, which is latency_first_resp, is
+// excluded.
+func (t *streamTimeline) tags() map[string]interface{} {
+	duration := t.last.Sub(t.first)
+	tags := map[string]interface{}{
+		tracespec.StreamChunks:   t.chunks,
+		tracespec.StreamDuration: duration.Microseconds(),
+	}
+	if duration > 0 {
+		tags[tracespec.StreamTokensPerSecond] = float64(t.tokens) / duration.Seconds()
+	}
+	if len(t.gaps) > 0 {
+		gaps := make([]time.Duration, len(t.gaps))
+		copy(gaps, t.gaps)
+		sort.Slice(gaps, func(i, j int) bool { return gaps[i] < gaps[j] })
+		tags[tracespec.StreamChunkLatencyP50] = percentile(gaps, 50).Microseconds()
+		tags[tracespec.StreamChunkLatencyP95] = percentile(gaps, 95).Microseconds()
+	}
+	return tags
+}
+
+// percentile returns the p-th percentile of sorted values by the nearest rank.
+func percentile(sorted []time.Duration, p int) time.Duration {
+	rank := (len(sorted)*p + 99) / 100
+	if rank < 1 {
+		rank = 1
+	}
+	return sorted[rank-1]
+}
+
+// AddStreamChunk records a chunk of streaming output received now with the tokens in it. The first chunk sets
+// start_time_first_resp if it is not set.
+func (s *Span) AddStreamChunk(ctx context.Context, tokenCount int) {
+	if s == nil || s.isSpanFinished() {
+		return
+	}
+	now := s.now()
+	s.lock.Lock()
+	if s.stream == nil {
+		s.stream = &streamTimeline{}
+	}
+	first := s.stream.chunks == 0
+	s.stream.add(now, tokenCount)
+	_, firstRespSet := s.TagMap[consts.StartTimeFirstResp]
+	s.lock.Unlock()
+	if first && !firstRespSet {
+		s.SetStartTimeFirstResp(ctx, now.UnixMicro())
+	}
+}
+
+// streamTags returns the stats of streaming output, nil if no chunk is recorded.
+func (s *Span) streamTags() map[string]interface{} {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+	if s.stream == nil {
+		return nil
+	}
+	return s.stream.tags()
+}
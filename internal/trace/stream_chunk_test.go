@@ -0,0 +1,66 @@
+// Copyright (c) 2025 Bytedance Ltd. and/or its affiliates
+// SPDX-License-Identifier: MIT
+
+package trace
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	. "github.com/smartystreets/goconvey/convey"
+
+	"github.com/coze-dev/cozeloop-go/internal/consts"
+	"github.com/coze-dev/cozeloop-go/spec/tracespec"
+)
+
+func TestAddStreamChunk(t *testing.T) {
+	ctx := context.Background()
+	start := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	Convey("Test stats of stream are tagged when span finished", t, func() {
+		clock := NewManualClock(start)
+		provider := NewTraceProvider(nil, Options{Exporter: &replayExporter{}, Clock: clock})
+		defer func() {
+			_, _ = provider.CloseTrace(ctx)
+		}()
+		_, span, err := provider.StartSpan(ctx, "model", tracespec.VModelSpanType, StartSpanOptions{})
+		So(err, ShouldBeNil)
+
+		clock.Add(200 * time.Millisecond)
+		span.AddStreamChunk(ctx, 5)
+		// gaps between chunks: 10ms * 18, 100ms, 500ms
+		for i := 0; i < 18; i++ {
+			clock.Add(10 * time.Millisecond)
+			span.AddStreamChunk(ctx, 5)
+		}
+		clock.Add(100 * time.Millisecond)
+		span.AddStreamChunk(ctx, 5)
+		clock.Add(500 * time.Millisecond)
+		span.AddStreamChunk(ctx, 5)
+		span.Finish(ctx)
+
+		tags := span.GetTagMap()
+		So(tags[tracespec.StreamChunks], ShouldEqual, 21)
+		So(tags[tracespec.StreamDuration], ShouldEqual, int64(780*1000))
+		So(tags[tracespec.StreamTokensPerSecond], ShouldAlmostEqual, 100/0.78, 0.001)
+		So(tags[tracespec.StreamChunkLatencyP50], ShouldEqual, int64(10*1000))
+		So(tags[tracespec.StreamChunkLatencyP95], ShouldEqual, int64(100*1000))
+		So(tags[consts.StartTimeFirstResp], ShouldEqual, start.Add(200*time.Millisecond).UnixMicro())
+		So(tags[consts.LatencyFirstResp], ShouldEqual, int64(200*1000))
+
+		// chunks after finish are ignored
+		span.AddStreamChunk(ctx, 5)
+		So(span.GetTagMap()[tracespec.StreamChunks], ShouldEqual, 21)
+	})
+
+	Convey("Test start time of first resp set by user is kept", t, func() {
+		span := &Span{TagMap: map[string]interface{}{}, StartTime: start}
+		span.SetStartTimeFirstResp(ctx, 1)
+		span.AddStreamChunk(ctx, 1)
+		So(span.GetTagMap()[consts.StartTimeFirstResp], ShouldEqual, 1)
+		So(span.streamTags()[tracespec.StreamChunks], ShouldEqual, 1)
+		So(span.streamTags()[tracespec.StreamChunkLatencyP50], ShouldBeNil)
+		So((&Span{}).streamTags(), ShouldBeNil)
+	})
+}
@@ -0,0 +1,221 @@
+// Copyright (c) 2025 Bytedance Ltd. and/or its affiliates
+// SPDX-License-Identifier: MIT
+
+package trace
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+	"unicode"
+
+	"github.com/coze-dev/cozeloop-go/internal/consts"
+	"github.com/coze-dev/cozeloop-go/internal/logger"
+	"github.com/coze-dev/cozeloop-go/spec/tracespec"
+)
+
+// TagLintConf configures the lint of tags set by SetTags, which catches broken instrumentation, such as the
+// misspelled keys of tracespec which are reported as custom tags and never shown as model stats.
+type TagLintConf struct {
+	// OnWarning is called for every suspicious tag, in the goroutine setting the tag. Default logs a warning.
+	OnWarning func(ctx context.Context, warning *TagLintWarning)
+}
+
+// TagLintWarning the suspicious tag found by lint, the tag is still set as it is.
+type TagLintWarning struct {
+	SpanName string
+	SpanType string
+	Key      string
+	// Suggestion the key of tracespec which Key is probably meant to be, empty if the value is of wrong type
+	Suggestion string
+	Reason     string
+}
+
+// tagLintKinds the kinds of values expected by the keys of tracespec, the keys of other values are omitted.
+var tagLintKinds = map[string]reflect.Kind{
+	tracespec.Stream:            reflect.Bool,
+	tracespec.ReasoningTokens:   reflect.Int,
+	tracespec.ReasoningDuration: reflect.Int,
+	tracespec.ToolCallID:        reflect.String,
+	tracespec.RetrieverProvider: reflect.String,
+	tracespec.PromptProvider:    reflect.String,
+	tracespec.PromptKey:         reflect.String,
+	tracespec.PromptVersion:     reflect.String,
+	tracespec.PromptLabel:       reflect.String,
+	tracespec.ExecuteCacheHit:   reflect.Bool,
+	tracespec.ModelProvider:     reflect.String,
+	tracespec.ModelName:         reflect.String,
+	tracespec.InputTokens:       reflect.Int,
+	tracespec.InputCachedTokens: reflect.Int,
+	tracespec.OutputTokens:      reflect.Int,
+	tracespec.Tokens:            reflect.Int,
+	tracespec.Cost:              reflect.Float64,
+	tracespec.LatencyFirstResp:  reflect.Int,
+	tracespec.CallType:          reflect.String,
+}
+
+// tagLintKeys the keys of tracespec and reserved fields, which the keys set are compared with.
+var tagLintKeys = func() map[string]bool {
+	keys := map[string]bool{
+		tracespec.CallOptions:           true,
+		tracespec.StreamChunks:          true,
+		tracespec.StreamDuration:        true,
+		tracespec.StreamTokensPerSecond: true,
+		tracespec.StreamChunkLatencyP50: true,
+		tracespec.StreamChunkLatencyP95: true,
+		tracespec.VikingDBName:          true,
+		tracespec.VikingDBRegion:        true,
+		tracespec.ESName:                true,
+		tracespec.ESIndex:               true,
+		tracespec.ESCluster:             true,
+		tracespec.SpanType:              true,
+		tracespec.Input:                 true,
+		tracespec.Output:                true,
+		tracespec.Error:                 true,
+		tracespec.Runtime_:              true,
+		tracespec.ModelPlatform:         true,
+		tracespec.ModelIdentification:   true,
+		tracespec.TokenUsageBackup:      true,
+		tracespec.LogID:                 true,
+		tracespec.TraceID:               true,
+	}
+	for key := range tagLintKinds {
+		keys[key] = true
+	}
+	for key := range consts.ReserveFieldTypes {
+		keys[key] = true
+	}
+	return keys
+}()
+
+// sortedTagLintKeys the keys of tagLintKeys in order, so that the suggestion is stable.
+var sortedTagLintKeys = func() []string {
+	keys := make([]string, 0, len(tagLintKeys))
+	for key := range tagLintKeys {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}()
+
+// tagLintAliases the keys of other conventions which are commonly used by mistake.
+var tagLintAliases = map[string]string{
+	"prompt_tokens":     tracespec.InputTokens,
+	"completion_tokens": tracespec.OutputTokens,
+	"total_tokens":      tracespec.Tokens,
+	"model":             tracespec.ModelName,
+}
+
+// lintTags reports the suspicious tags of tagKVs, it does nothing if lint is disabled.
+func (s *Span) lintTags(ctx context.Context, tagKVs map[string]interface{}) {
+	if s.tagLint == nil {
+		return
+	}
+	for key, value := range tagKVs {
+		warning := lintTag(key, value)
+		if warning == nil {
+			continue
+		}
+		warning.SpanName, warning.SpanType = s.GetSpanName(), s.GetSpanType()
+		if s.tagLint.OnWarning != nil {
+			s.tagLint.OnWarning(ctx, warning)
+		} else {
+			logger.CtxWarnf(ctx, "tag lint of span [%s]: %s", warning.SpanName, warning.Reason)
+		}
+	}
+}
+
+func lintTag(key string, value interface{}) *TagLintWarning {
+	if tagLintKeys[key] {
+		kind, ok := tagLintKinds[key]
+		if !ok || value == nil || kindMatches(kind, reflect.TypeOf(value).Kind()) {
+			return nil
+		}
+		return &TagLintWarning{
+			Key:    key,
+			Reason: fmt.Sprintf("value of tag [%s] is %T, expected %s", key, value, kind),
+		}
+	}
+	suggestion := suggestTagKey(key)
+	if suggestion == "" {
+		return nil
+	}
+	return &TagLintWarning{
+		Key:        key,
+		Suggestion: suggestion,
+		Reason:     fmt.Sprintf("tag [%s] is reported as custom tag, did you mean [%s]", key, suggestion),
+	}
+}
+
+func kindMatches(expected, actual reflect.Kind) bool {
+	switch expected {
+	case reflect.Int:
+		return actual >= reflect.Int && actual <= reflect.Uint64
+	case reflect.Float64:
+		return actual == reflect.Float32 || actual == reflect.Float64
+	default:
+		return expected == actual
+	}
+}
+
+// suggestTagKey returns the key of tracespec which key is probably meant to be, such as "input_tokens" for
+// "input_token" or "InputTokens", empty if there is none.
+func suggestTagKey(key string) string {
+	normalized := normalizeTagKey(key)
+	if suggestion, ok := tagLintAliases[normalized]; ok {
+		return suggestion
+	}
+	if tagLintKeys[normalized] {
+		return normalized
+	}
+	// keys of one edit away, the short keys are skipped as they are probably custom ones
+	if len(normalized) < 6 {
+		return ""
+	}
+	for _, specKey := range sortedTagLintKeys {
+		if len(specKey) >= 6 && withinOneEdit(normalized, specKey) {
+			return specKey
+		}
+	}
+	return ""
+}
+
+// normalizeTagKey converts key of camel case, kebab case or dot case to snake case.
+func normalizeTagKey(key string) string {
+	var b strings.Builder
+	runes := []rune(strings.TrimSpace(key))
+	for i, r := range runes {
+		switch {
+		case r == '-' || r == '.' || r == ' ':
+			b.WriteRune('_')
+		case unicode.IsUpper(r):
+			if i > 0 && (unicode.IsLower(runes[i-1]) || unicode.IsDigit(runes[i-1])) {
+				b.WriteRune('_')
+			}
+			b.WriteRune(unicode.ToLower(r))
+		default:
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
+
+// withinOneEdit returns whether a can be changed to b by inserting, deleting or replacing one byte.
+func withinOneEdit(a, b string) bool {
+	if len(a) > len(b) {
+		a, b = b, a
+	}
+	if len(b)-len(a) > 1 {
+		return false
+	}
+	i := 0
+	for i < len(a) && a[i] == b[i] {
+		i++
+	}
+	if len(a) == len(b) {
+		return i == len(a) || a[i+1:] == b[i+1:]
+	}
+	return a[i:] == b[i+1:]
+}
@@ -0,0 +1,63 @@
+// Copyright (c) 2025 Bytedance Ltd. and/or its affiliates
+// SPDX-License-Identifier: MIT
+
+package trace
+
+import (
+	"context"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+
+	"github.com/coze-dev/cozeloop-go/spec/tracespec"
+)
+
+func TestTagLint(t *testing.T) {
+	ctx := context.Background()
+	Convey("Test suggestions of misspelled keys", t, func() {
+		So(suggestTagKey("input_token"), ShouldEqual, tracespec.InputTokens)
+		So(suggestTagKey("InputTokens"), ShouldEqual, tracespec.InputTokens)
+		So(suggestTagKey("model-name"), ShouldEqual, tracespec.ModelName)
+		So(suggestTagKey("completion_tokens"), ShouldEqual, tracespec.OutputTokens)
+		So(suggestTagKey("ouput_tokens"), ShouldEqual, tracespec.OutputTokens)
+		So(suggestTagKey("user_name"), ShouldBeEmpty)
+		So(suggestTagKey("inputs"), ShouldBeEmpty)
+		So(suggestTagKey("biz"), ShouldBeEmpty)
+	})
+
+	Convey("Test suspicious tags are reported and still set", t, func() {
+		var warnings []*TagLintWarning
+		provider := NewTraceProvider(nil, Options{
+			Exporter: &replayExporter{},
+			TagLint: &TagLintConf{OnWarning: func(ctx context.Context, warning *TagLintWarning) {
+				warnings = append(warnings, warning)
+			}},
+		})
+		defer func() {
+			_, _ = provider.CloseTrace(ctx)
+		}()
+		_, span, err := provider.StartSpan(ctx, "llm", tracespec.VModelSpanType, StartSpanOptions{})
+		So(err, ShouldBeNil)
+
+		span.SetTags(ctx, map[string]interface{}{"input_token": 10})
+		So(len(warnings), ShouldEqual, 1)
+		So(warnings[0].SpanName, ShouldEqual, "llm")
+		So(warnings[0].Key, ShouldEqual, "input_token")
+		So(warnings[0].Suggestion, ShouldEqual, tracespec.InputTokens)
+		So(span.GetTagMap()["input_token"], ShouldEqual, 10)
+
+		So(span.SetTagsE(ctx, map[string]interface{}{tracespec.Stream: "true"}), ShouldBeNil)
+		So(len(warnings), ShouldEqual, 2)
+		So(warnings[1].Suggestion, ShouldBeEmpty)
+		So(warnings[1].Reason, ShouldContainSubstring, "expected bool")
+
+		span.SetTags(ctx, map[string]interface{}{tracespec.InputTokens: int64(10), tracespec.ModelName: "gpt", "biz": "x"})
+		span.SetInputTokens(ctx, 10)
+		So(len(warnings), ShouldEqual, 2)
+	})
+
+	Convey("Test tags are not linted if lint is disabled", t, func() {
+		span := &Span{}
+		So(func() { span.lintTags(ctx, map[string]interface{}{"input_token": 10}) }, ShouldNotPanic)
+	})
+}
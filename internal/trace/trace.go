@@ -11,7 +11,6 @@ import (
 	"github.com/coze-dev/cozeloop-go/internal/consts"
 	"github.com/coze-dev/cozeloop-go/internal/httpclient"
 	"github.com/coze-dev/cozeloop-go/internal/logger"
-	"github.com/coze-dev/cozeloop-go/internal/util"
 	"github.com/coze-dev/cozeloop-go/spec/tracespec"
 )
 
@@ -19,6 +18,10 @@ type Provider struct {
 	httpClient    *httpclient.Client
 	opt           *Options
 	spanProcessor SpanProcessor
+	// batchProcessor the processor reporting to CozeLoop, which is the first one of spanProcessor
+	batchProcessor *BatchSpanProcessor
+	leakDetector   *leakDetector
+	runtime        *runtimeConf
 }
 
 type Options struct {
@@ -30,6 +33,38 @@ type Options struct {
 	SpanUploadPath       string
 	FileUploadPath       string
 	QueueConf            *QueueConf
+	IDGenerator          IDGenerator
+	SpanRedactor         SpanRedactor
+	PersistentQueueDir   string
+	LeakDetection        *LeakDetectionConf
+	ModelPricing         *ModelPricing
+	BaggageConf          *BaggageConf
+	// SpanProcessors are invoked in order after the processors reporting to CozeLoop and Destinations.
+	SpanProcessors []SpanProcessor
+	// Debug prints spans by debug exporter, instead of or in addition to the Exporter.
+	Debug *DebugConf
+	// BaggageProcessor decides the tags and sampling of spans by their baggage.
+	BaggageProcessor BaggageProcessor
+	// BlobStore stores files of spans instead of uploading them to CozeLoop, it is ignored if Exporter is set.
+	BlobStore BlobStore
+	// ResourceAttributes are set as system tags of every span, such as service name and version.
+	ResourceAttributes map[string]string
+	// Clock is the default start time and finish time of spans.
+	Clock Clock
+	// NoCaptureContent replaces the input and output of spans with the hashes and lengths of the strings in them.
+	NoCaptureContent bool
+	// SampleRatio the ratio of traces reported in [0, 1], decided by the hash of trace id. All traces are
+	// reported if it is nil.
+	SampleRatio *float64
+	// TagLint reports the suspicious tags set by SetTags, such as misspelled keys of tracespec. Disabled if nil.
+	TagLint *TagLintConf
+	// Destinations the secondary destinations which spans are reported to, each with its own queues.
+	Destinations []*Destination
+	// WireFormat the encoding of spans reported to CozeLoop, JSON if it is empty. It is ignored if Exporter is set.
+	WireFormat WireFormat
+	// IDNormalization accepts and normalizes the ids of header parent in other formats by GetSpanFromHeader, only
+	// the ids of exactly 32 and 16 lowercase hex chars are accepted if it is nil.
+	IDNormalization *IDNormalizationConf
 }
 
 type StartSpanOptions struct {
@@ -41,6 +76,15 @@ type StartSpanOptions struct {
 	StartNewTrace bool
 	Scene         string
 	WorkspaceID   string
+	// Tags set on the span when it is started
+	Tags map[string]interface{}
+	// UltraLargeReport overrides Options.UltraLargeReport for the span if it is not nil
+	UltraLargeReport *bool
+	// Priority the queue lane of the span, see SpanPriority. Root spans and error spans are of high priority and the
+	// others are of normal priority if it is empty.
+	Priority SpanPriority
+	// imported the span is imported by ImportSpans, which is neither sampled nor tracked for leaks
+	imported bool
 }
 
 type loopSpanKey struct{}
@@ -53,16 +97,39 @@ func NewTraceProvider(httpClient *httpclient.Client, options Options) *Provider
 			fileUploadPath: options.FileUploadPath,
 		}
 	}
+	exporter := options.Exporter
+	if exporter == nil && (options.BlobStore != nil || options.WireFormat != "" || options.Debug != nil) {
+		spanExporter := newSpanExporter(httpClient, uploadPath)
+		spanExporter.blobStore = options.BlobStore
+		spanExporter.wireFormat = options.WireFormat
+		exporter = spanExporter
+	}
+	if options.Debug != nil {
+		exporter = debugExporter(options.Debug, exporter)
+	}
+	batchProcessor := NewBatchSpanProcessor(
+		exporter,
+		httpClient,
+		uploadPath,
+		options.FinishEventProcessor,
+		options.QueueConf,
+		options.SpanRedactor,
+		options.PersistentQueueDir,
+	).(*BatchSpanProcessor)
+	processors := make([]SpanProcessor, 0, len(options.Destinations)+len(options.SpanProcessors))
+	for _, dest := range options.Destinations {
+		if dest != nil {
+			processors = append(processors, newDestinationProcessor(httpClient, uploadPath, dest, options))
+		}
+	}
+	processors = append(processors, options.SpanProcessors...)
 	c := &Provider{
-		httpClient: httpClient,
-		opt:        &options,
-		spanProcessor: NewBatchSpanProcessor(
-			options.Exporter,
-			httpClient,
-			uploadPath,
-			options.FinishEventProcessor,
-			options.QueueConf,
-		),
+		httpClient:     httpClient,
+		opt:            &options,
+		spanProcessor:  newMultiSpanProcessor(batchProcessor, processors),
+		batchProcessor: batchProcessor,
+		leakDetector:   newLeakDetector(options.LeakDetection),
+		runtime:        newRuntimeConf(options),
 	}
 	return c
 }
@@ -101,10 +168,16 @@ func (t *Provider) StartSpan(ctx context.Context, name, spanType string, opts St
 		if opts.Baggage == nil {
 			opts.Baggage = parentSpan.GetBaggage()
 		}
+		if opts.WorkspaceID == "" {
+			opts.WorkspaceID = parentSpan.WorkspaceID
+		}
 	}
 
 	// 2. internal start span
 	loopSpan := t.startSpan(ctx, name, spanType, opts)
+	if len(opts.Tags) > 0 {
+		loopSpan.SetTags(ctx, opts.Tags)
+	}
 
 	// 3. inject ctx
 	ctx = context.WithValue(ctx, loopSpanKey{}, loopSpan)
@@ -122,7 +195,7 @@ func (t *Provider) GetSpanFromContext(ctx context.Context) *Span {
 }
 
 func (t *Provider) GetSpanFromHeader(ctx context.Context, header map[string]string) *SpanContext {
-	return FromHeader(ctx, header)
+	return fromHeader(ctx, header, t.opt.IDNormalization)
 }
 
 func (t *Provider) startSpan(ctx context.Context, spanName string, spanType string, options StartSpanOptions) *Span {
@@ -136,28 +209,37 @@ func (t *Provider) startSpan(ctx context.Context, spanName string, spanType stri
 
 	spanID := options.SpanID
 	if len(spanID) == 0 {
-		spanID = util.Gen16CharID()
+		spanID = t.newSpanID()
 	}
 
 	traceID := ""
 	if options.TraceID != "" {
 		traceID = options.TraceID
 	} else {
-		traceID = util.Gen32CharID()
+		traceID = t.newTraceID()
 	}
 
-	startTime := time.Now()
+	clock := t.clock()
+	startTime := clock.Now()
 	if !options.StartTime.IsZero() {
 		startTime = options.StartTime
 	}
 
-	systemTagMap := make(map[string]interface{})
+	systemTagMap := make(map[string]interface{}, len(t.opt.ResourceAttributes))
+	for key, value := range t.opt.ResourceAttributes {
+		systemTagMap[key] = value
+	}
 	if options.Scene != "" {
 		systemTagMap[tracespec.Runtime_] = tracespec.Runtime{
 			Scene: options.Scene,
 		}
 	}
 
+	ultraLargeReport := t.opt.UltraLargeReport
+	if options.UltraLargeReport != nil {
+		ultraLargeReport = *options.UltraLargeReport
+	}
+
 	workSpaceID := t.opt.WorkspaceID
 	if options.WorkspaceID != "" {
 		workSpaceID = options.WorkspaceID
@@ -179,28 +261,89 @@ func (t *Provider) startSpan(ctx context.Context, spanName string, spanType stri
 		TagMap:              make(map[string]interface{}),
 		SystemTagMap:        systemTagMap,
 		StatusCode:          0,
-		ultraLargeReport:    t.opt.UltraLargeReport,
+		ultraLargeReport:    ultraLargeReport,
 		multiModalityKeyMap: make(map[string]struct{}),
 		spanProcessor:       t.spanProcessor,
-		flags:               1, // for W3C, sampled by default
+		flags:               sampledFlag, // for W3C, sampled by default
 		isFinished:          0,
 		lock:                sync.RWMutex{},
 		bytesSize:           0, // The initial value is 0. Default fields do not count towards the size.
 		tagTruncateConf:     t.opt.TagTruncateConf,
+		leakDetector:        t.leakDetector,
+		modelPricing:        t.opt.ModelPricing,
+		baggageConf:         t.opt.BaggageConf,
+		clock:               clock,
+		noCaptureContent:    !t.runtime.captureContent(),
+		priority:            options.Priority,
+		tagLint:             t.opt.TagLint,
+	}
+	if !options.imported && !sampledByRatio(traceID, t.runtime.getSampleRatio()) {
+		s.flags &^= sampledFlag
 	}
 
 	// 3. set Baggage from parent span
 	s.setBaggage(ctx, options.Baggage)
+	if t.opt.BaggageProcessor != nil {
+		s.applyBaggageDecision(ctx, t.opt.BaggageProcessor(ctx, s.GetBaggage()))
+	}
+
+	// 4. track the span until it is finished
+	if !options.imported {
+		t.leakDetector.track(s)
+	}
 
 	return s
 }
 
+func (t *Provider) idGenerator() IDGenerator {
+	if t.opt.IDGenerator == nil {
+		return NewDefaultIDGenerator()
+	}
+	return t.opt.IDGenerator
+}
+
+// newSpanID returns the span id of IDGenerator, or a random one if it is invalid.
+func (t *Provider) newSpanID() string {
+	id := t.idGenerator().NewSpanID()
+	if !isValidSpanID(id) {
+		logger.CtxWarnf(context.Background(), "Invalid span id %q of IDGenerator, a random one is used instead", id)
+		return NewDefaultIDGenerator().NewSpanID()
+	}
+	return id
+}
+
+// newTraceID returns the trace id of IDGenerator, or a random one if it is invalid.
+func (t *Provider) newTraceID() string {
+	id := t.idGenerator().NewTraceID()
+	if !isValidTraceID(id) {
+		logger.CtxWarnf(context.Background(), "Invalid trace id %q of IDGenerator, a random one is used instead", id)
+		return NewDefaultIDGenerator().NewTraceID()
+	}
+	return id
+}
+
+func (t *Provider) clock() Clock {
+	if t.opt.Clock == nil {
+		return NewSystemClock()
+	}
+	return t.opt.Clock
+}
+
 func (t *Provider) Flush(ctx context.Context) {
 	_ = t.spanProcessor.ForceFlush(ctx)
 }
 
-func (t *Provider) CloseTrace(ctx context.Context) {
-	_ = t.spanProcessor.Shutdown(ctx)
+// Stats returns the statistics of spans and files reported to CozeLoop.
+func (t *Provider) Stats() Stats {
+	if t.batchProcessor == nil {
+		return Stats{}
+	}
+	return t.batchProcessor.Stats()
+}
+
+func (t *Provider) CloseTrace(ctx context.Context) (*ShutdownReport, error) {
+	t.leakDetector.stop()
+	return t.spanProcessor.Shutdown(ctx)
 }
 
 func DefaultFinishEventProcessor(ctx context.Context, info *consts.FinishEventInfo) {
@@ -38,6 +38,68 @@ func Test_StartSpan(t *testing.T) {
 	})
 }
 
+func Test_StartSpanWorkspaceID(t *testing.T) {
+	ctx := context.Background()
+	Convey("Test child span inherits workspace of parent span", t, func() {
+		p := &Provider{
+			httpClient: &httpclient.Client{},
+			opt:        &Options{WorkspaceID: "workspace-id"},
+		}
+		ctx, root, err := p.StartSpan(ctx, "root", "custom", StartSpanOptions{WorkspaceID: "tenant-workspace-id"})
+		So(err, ShouldBeNil)
+		So(root.WorkspaceID, ShouldEqual, "tenant-workspace-id")
+
+		_, child, err := p.StartSpan(ctx, "child", "custom", StartSpanOptions{})
+		So(err, ShouldBeNil)
+		So(child.WorkspaceID, ShouldEqual, "tenant-workspace-id")
+
+		_, other, err := p.StartSpan(context.Background(), "other", "custom", StartSpanOptions{})
+		So(err, ShouldBeNil)
+		So(other.WorkspaceID, ShouldEqual, "workspace-id")
+	})
+}
+
+func Test_StartSpanResourceAttributes(t *testing.T) {
+	ctx := context.Background()
+	Convey("Test resource attributes are set as system tags of every span", t, func() {
+		p := &Provider{
+			httpClient: &httpclient.Client{},
+			opt: &Options{
+				WorkspaceID:        "workspace-id",
+				ResourceAttributes: map[string]string{"service.name": "svc", "env": "prod"},
+			},
+		}
+		ctx, root, err := p.StartSpan(ctx, "root", "custom", StartSpanOptions{})
+		So(err, ShouldBeNil)
+		So(root.SystemTagMap["service.name"], ShouldEqual, "svc")
+		_, child, err := p.StartSpan(ctx, "child", "custom", StartSpanOptions{})
+		So(err, ShouldBeNil)
+		So(child.SystemTagMap["env"], ShouldEqual, "prod")
+	})
+}
+
+func Test_StartSpanUltraLargeReport(t *testing.T) {
+	ctx := context.Background()
+	Convey("Test ultra large report is overridden per span", t, func() {
+		p := &Provider{
+			httpClient: &httpclient.Client{},
+			opt:        &Options{WorkspaceID: "workspace-id"},
+		}
+		enable := true
+		_, large, err := p.StartSpan(ctx, "large", "custom", StartSpanOptions{UltraLargeReport: &enable})
+		So(err, ShouldBeNil)
+		So(large.UltraLargeReport(), ShouldBeTrue)
+		large.SetUltraLargeReport(false)
+		So(large.UltraLargeReport(), ShouldBeFalse)
+
+		_, normal, err := p.StartSpan(ctx, "normal", "custom", StartSpanOptions{})
+		So(err, ShouldBeNil)
+		So(normal.UltraLargeReport(), ShouldBeFalse)
+		normal.SetUltraLargeReport(true)
+		So(normal.UltraLargeReport(), ShouldBeTrue)
+	})
+}
+
 func Test_GetSpanFromHeader(t *testing.T) {
 	ctx := context.Background()
 	name, spanType := "test-span", "test-type"
@@ -0,0 +1,301 @@
+// Copyright (c) 2025 Bytedance Ltd. and/or its affiliates
+// SPDX-License-Identifier: MIT
+
+package trace
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"io"
+	"math"
+	"net/http"
+	"sync"
+
+	"github.com/coze-dev/cozeloop-go/entity"
+	"github.com/coze-dev/cozeloop-go/internal/consts"
+)
+
+// WireFormat the encoding of the spans reported to ingest endpoint.
+type WireFormat string
+
+const (
+	// WireFormatJSON encodes spans as JSON, it is the default format.
+	WireFormatJSON WireFormat = "json"
+	// WireFormatMsgpack encodes spans as msgpack with the same keys as JSON, which is smaller and cheaper to encode.
+	// Spans are reported as JSON instead if the ingest endpoint rejects the content type.
+	WireFormatMsgpack WireFormat = "msgpack"
+
+	contentTypeMsgpack = "application/msgpack"
+)
+
+// IsValid returns whether the format is supported, empty is the default format.
+func (f WireFormat) IsValid() bool {
+	return f == "" || f == WireFormatJSON || f == WireFormatMsgpack
+}
+
+// isUnsupportedMediaType returns whether err is the rejection of the content type by remote service.
+func isUnsupportedMediaType(err error) bool {
+	remoteErr := &consts.RemoteServiceError{}
+	return errors.As(err, &remoteErr) && remoteErr.HttpCode == http.StatusUnsupportedMediaType
+}
+
+// msgpackSpanData UploadSpanData encoded as msgpack, in the same layout as JSON.
+type msgpackSpanData UploadSpanData
+
+func (d msgpackSpanData) ContentType() string {
+	return contentTypeMsgpack
+}
+
+func (d msgpackSpanData) Encode(w io.Writer) error {
+	// the small writes are buffered, unless w is already a buffer, as they are expensive for writers like gzip
+	if _, ok := w.(*bytes.Buffer); !ok {
+		bw := msgpackWriterPool.Get().(*bufio.Writer)
+		bw.Reset(w)
+		defer func() {
+			bw.Reset(nil)
+			msgpackWriterPool.Put(bw)
+		}()
+		if err := d.encode(bw); err != nil {
+			return err
+		}
+		return bw.Flush()
+	}
+	return d.encode(w)
+}
+
+func (d msgpackSpanData) encode(w io.Writer) error {
+	e := &msgpackEncoder{w: w}
+	e.writeMapHeader(1)
+	e.writeString("spans")
+	e.writeArrayHeader(len(d.Spans))
+	for _, span := range d.Spans {
+		e.writeSpan(span)
+	}
+	return e.err
+}
+
+var msgpackWriterPool = sync.Pool{New: func() interface{} {
+	return bufio.NewWriterSize(nil, 4096)
+}}
+
+// msgpackEncoder writes msgpack values of the smallest representation to w, the first error is kept and the
+// writes after it are skipped.
+type msgpackEncoder struct {
+	w       io.Writer
+	scratch [9]byte
+	err     error
+}
+
+func (e *msgpackEncoder) write(b []byte) {
+	if e.err == nil {
+		_, e.err = e.w.Write(b)
+	}
+}
+
+func (e *msgpackEncoder) writeString(s string) {
+	n := len(s)
+	switch {
+	case n < 32:
+		e.scratch[0] = 0xa0 | byte(n)
+		e.write(e.scratch[:1])
+	case n <= math.MaxUint8:
+		e.scratch[0], e.scratch[1] = 0xd9, byte(n)
+		e.write(e.scratch[:2])
+	case n <= math.MaxUint16:
+		e.scratch[0] = 0xda
+		binary.BigEndian.PutUint16(e.scratch[1:], uint16(n))
+		e.write(e.scratch[:3])
+	default:
+		e.scratch[0] = 0xdb
+		binary.BigEndian.PutUint32(e.scratch[1:], uint32(n))
+		e.write(e.scratch[:5])
+	}
+	if e.err == nil && n > 0 {
+		_, e.err = io.WriteString(e.w, s)
+	}
+}
+
+func (e *msgpackEncoder) writeInt(v int64) {
+	switch {
+	case v >= 0 && v < 128:
+		e.scratch[0] = byte(v)
+		e.write(e.scratch[:1])
+	case v < 0 && v >= -32:
+		e.scratch[0] = byte(int8(v))
+		e.write(e.scratch[:1])
+	case v >= 0 && v <= math.MaxUint8:
+		e.scratch[0], e.scratch[1] = 0xcc, byte(v)
+		e.write(e.scratch[:2])
+	case v >= 0 && v <= math.MaxUint16:
+		e.scratch[0] = 0xcd
+		binary.BigEndian.PutUint16(e.scratch[1:], uint16(v))
+		e.write(e.scratch[:3])
+	case v >= 0 && v <= math.MaxUint32:
+		e.scratch[0] = 0xce
+		binary.BigEndian.PutUint32(e.scratch[1:], uint32(v))
+		e.write(e.scratch[:5])
+	case v >= 0:
+		e.scratch[0] = 0xcf
+		binary.BigEndian.PutUint64(e.scratch[1:], uint64(v))
+		e.write(e.scratch[:9])
+	case v >= math.MinInt8:
+		e.scratch[0], e.scratch[1] = 0xd0, byte(int8(v))
+		e.write(e.scratch[:2])
+	case v >= math.MinInt16:
+		e.scratch[0] = 0xd1
+		binary.BigEndian.PutUint16(e.scratch[1:], uint16(int16(v)))
+		e.write(e.scratch[:3])
+	case v >= math.MinInt32:
+		e.scratch[0] = 0xd2
+		binary.BigEndian.PutUint32(e.scratch[1:], uint32(int32(v)))
+		e.write(e.scratch[:5])
+	default:
+		e.scratch[0] = 0xd3
+		binary.BigEndian.PutUint64(e.scratch[1:], uint64(v))
+		e.write(e.scratch[:9])
+	}
+}
+
+func (e *msgpackEncoder) writeFloat(v float64) {
+	e.scratch[0] = 0xcb
+	binary.BigEndian.PutUint64(e.scratch[1:], math.Float64bits(v))
+	e.write(e.scratch[:9])
+}
+
+func (e *msgpackEncoder) writeBool(v bool) {
+	e.scratch[0] = 0xc2
+	if v {
+		e.scratch[0] = 0xc3
+	}
+	e.write(e.scratch[:1])
+}
+
+func (e *msgpackEncoder) writeNil() {
+	e.scratch[0] = 0xc0
+	e.write(e.scratch[:1])
+}
+
+func (e *msgpackEncoder) writeMapHeader(n int) {
+	e.writeHeader(n, 0x80, 0xde, 0xdf)
+}
+
+func (e *msgpackEncoder) writeArrayHeader(n int) {
+	e.writeHeader(n, 0x90, 0xdc, 0xdd)
+}
+
+func (e *msgpackEncoder) writeHeader(n int, fix, code16, code32 byte) {
+	switch {
+	case n < 16:
+		e.scratch[0] = fix | byte(n)
+		e.write(e.scratch[:1])
+	case n <= math.MaxUint16:
+		e.scratch[0] = code16
+		binary.BigEndian.PutUint16(e.scratch[1:], uint16(n))
+		e.write(e.scratch[:3])
+	default:
+		e.scratch[0] = code32
+		binary.BigEndian.PutUint32(e.scratch[1:], uint32(n))
+		e.write(e.scratch[:5])
+	}
+}
+
+// writeSpan writes span as the map of its JSON fields, nil span and nil tags are written as nil like JSON.
+func (e *msgpackEncoder) writeSpan(span *entity.UploadSpan) {
+	if span == nil {
+		e.writeNil()
+		return
+	}
+	e.writeMapHeader(21)
+	e.writeString("started_at_micros")
+	e.writeInt(span.StartedATMicros)
+	e.writeString("log_id")
+	e.writeString(span.LogID)
+	e.writeString("span_id")
+	e.writeString(span.SpanID)
+	e.writeString("parent_id")
+	e.writeString(span.ParentID)
+	e.writeString("trace_id")
+	e.writeString(span.TraceID)
+	e.writeString("duration_micros")
+	e.writeInt(span.DurationMicros)
+	e.writeString("service_name")
+	e.writeString(span.ServiceName)
+	e.writeString("workspace_id")
+	e.writeString(span.WorkspaceID)
+	e.writeString("span_name")
+	e.writeString(span.SpanName)
+	e.writeString("span_type")
+	e.writeString(span.SpanType)
+	e.writeString("status_code")
+	e.writeInt(int64(span.StatusCode))
+	e.writeString("input")
+	e.writeString(span.Input)
+	e.writeString("output")
+	e.writeString(span.Output)
+	e.writeString("object_storage")
+	e.writeString(span.ObjectStorage)
+	e.writeString("system_tags_string")
+	e.writeStringMap(span.SystemTagsString)
+	e.writeString("system_tags_long")
+	e.writeLongMap(span.SystemTagsLong)
+	e.writeString("system_tags_double")
+	e.writeDoubleMap(span.SystemTagsDouble)
+	e.writeString("tags_string")
+	e.writeStringMap(span.TagsString)
+	e.writeString("tags_long")
+	e.writeLongMap(span.TagsLong)
+	e.writeString("tags_double")
+	e.writeDoubleMap(span.TagsDouble)
+	e.writeString("tags_bool")
+	e.writeBoolMap(span.TagsBool)
+}
+
+func (e *msgpackEncoder) writeStringMap(m map[string]string) {
+	if m == nil {
+		e.writeNil()
+		return
+	}
+	e.writeMapHeader(len(m))
+	for k, v := range m {
+		e.writeString(k)
+		e.writeString(v)
+	}
+}
+
+func (e *msgpackEncoder) writeLongMap(m map[string]int64) {
+	if m == nil {
+		e.writeNil()
+		return
+	}
+	e.writeMapHeader(len(m))
+	for k, v := range m {
+		e.writeString(k)
+		e.writeInt(v)
+	}
+}
+
+func (e *msgpackEncoder) writeDoubleMap(m map[string]float64) {
+	if m == nil {
+		e.writeNil()
+		return
+	}
+	e.writeMapHeader(len(m))
+	for k, v := range m {
+		e.writeString(k)
+		e.writeFloat(v)
+	}
+}
+
+func (e *msgpackEncoder) writeBoolMap(m map[string]bool) {
+	if m == nil {
+		e.writeNil()
+		return
+	}
+	e.writeMapHeader(len(m))
+	for k, v := range m {
+		e.writeString(k)
+		e.writeBool(v)
+	}
+}
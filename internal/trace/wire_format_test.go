@@ -0,0 +1,328 @@
+// Copyright (c) 2025 Bytedance Ltd. and/or its affiliates
+// SPDX-License-Identifier: MIT
+
+package trace
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+
+	"github.com/coze-dev/cozeloop-go/entity"
+	"github.com/coze-dev/cozeloop-go/internal/httpclient"
+)
+
+// decodeMsgpack decodes the msgpack values written by msgpackEncoder, numbers are decoded as float64 like JSON.
+func decodeMsgpack(b []byte) (interface{}, []byte, error) {
+	if len(b) == 0 {
+		return nil, nil, io.ErrUnexpectedEOF
+	}
+	c, b := b[0], b[1:]
+	readN := func(n int) ([]byte, error) {
+		if len(b) < n {
+			return nil, io.ErrUnexpectedEOF
+		}
+		v := b[:n]
+		b = b[n:]
+		return v, nil
+	}
+	decodeMap := func(n int) (interface{}, []byte, error) {
+		m := make(map[string]interface{}, n)
+		for i := 0; i < n; i++ {
+			k, rest, err := decodeMsgpack(b)
+			if err != nil {
+				return nil, nil, err
+			}
+			v, rest, err := decodeMsgpack(rest)
+			if err != nil {
+				return nil, nil, err
+			}
+			m[k.(string)], b = v, rest
+		}
+		return m, b, nil
+	}
+	decodeArray := func(n int) (interface{}, []byte, error) {
+		a := make([]interface{}, 0, n)
+		for i := 0; i < n; i++ {
+			v, rest, err := decodeMsgpack(b)
+			if err != nil {
+				return nil, nil, err
+			}
+			a, b = append(a, v), rest
+		}
+		return a, b, nil
+	}
+	decodeString := func(n int) (interface{}, []byte, error) {
+		v, err := readN(n)
+		return string(v), b, err
+	}
+	length := func(n int) (int, error) {
+		v, err := readN(n)
+		if err != nil {
+			return 0, err
+		}
+		var l uint64
+		for _, x := range v {
+			l = l<<8 | uint64(x)
+		}
+		return int(l), nil
+	}
+	switch {
+	case c < 0x80:
+		return float64(c), b, nil
+	case c >= 0xe0:
+		return float64(int8(c)), b, nil
+	case c&0xf0 == 0x80:
+		return decodeMap(int(c & 0x0f))
+	case c&0xf0 == 0x90:
+		return decodeArray(int(c & 0x0f))
+	case c&0xe0 == 0xa0:
+		return decodeString(int(c & 0x1f))
+	}
+	switch c {
+	case 0xc0:
+		return nil, b, nil
+	case 0xc2, 0xc3:
+		return c == 0xc3, b, nil
+	case 0xcb:
+		v, err := readN(8)
+		if err != nil {
+			return nil, nil, err
+		}
+		return math.Float64frombits(binary.BigEndian.Uint64(v)), b, nil
+	case 0xcc, 0xcd, 0xce, 0xcf:
+		v, err := length(1 << (c - 0xcc))
+		return float64(v), b, err
+	case 0xd0, 0xd1, 0xd2, 0xd3:
+		v, err := readN(1 << (c - 0xd0))
+		if err != nil {
+			return nil, nil, err
+		}
+		padded := bytes.Repeat([]byte{0xff}, 8-len(v))
+		return float64(int64(binary.BigEndian.Uint64(append(padded, v...)))), b, nil
+	case 0xd9, 0xda, 0xdb:
+		n, err := length(1 << (c - 0xd9))
+		if err != nil {
+			return nil, nil, err
+		}
+		return decodeString(n)
+	case 0xdc, 0xdd:
+		n, err := length(2 << (c - 0xdc))
+		if err != nil {
+			return nil, nil, err
+		}
+		return decodeArray(n)
+	case 0xde, 0xdf:
+		n, err := length(2 << (c - 0xde))
+		if err != nil {
+			return nil, nil, err
+		}
+		return decodeMap(n)
+	}
+	return nil, nil, fmt.Errorf("unsupported msgpack code: %x", c)
+}
+
+func testUploadSpans(n int) []*entity.UploadSpan {
+	spans := make([]*entity.UploadSpan, 0, n)
+	for i := 0; i < n; i++ {
+		spans = append(spans, &entity.UploadSpan{
+			StartedATMicros:  1717228800000000 + int64(i),
+			SpanID:           fmt.Sprintf("%016x", i+1),
+			ParentID:         "0",
+			TraceID:          "0af7651916cd43dd8448eb211c80319c",
+			DurationMicros:   int64(i * 1000),
+			WorkspaceID:      "7380000000000000000",
+			SpanName:         "chat",
+			SpanType:         "model",
+			StatusCode:       int32(-i),
+			Input:            strings.Repeat("question ", 20),
+			Output:           strings.Repeat("answer ", 40),
+			SystemTagsString: map[string]string{"runtime": `{"language":"go","library":"cozeloop"}`},
+			SystemTagsLong:   map[string]int64{"thread_id": 42},
+			TagsString:       map[string]string{"model_name": "gpt-4o", "model_provider": "openai"},
+			TagsLong:         map[string]int64{"input_tokens": 1200, "output_tokens": 300, "tokens": 1500},
+			TagsDouble:       map[string]float64{"cost": 0.0125},
+			TagsBool:         map[string]bool{"stream": i%2 == 0},
+		})
+	}
+	return spans
+}
+
+func TestMsgpackSpanData(t *testing.T) {
+	Convey("Test values are encoded in the smallest representation", t, func() {
+		encode := func(write func(e *msgpackEncoder)) []byte {
+			var buffer bytes.Buffer
+			e := &msgpackEncoder{w: &buffer}
+			write(e)
+			So(e.err, ShouldBeNil)
+			return buffer.Bytes()
+		}
+		for v, expected := range map[int64][]byte{
+			0:              {0x00},
+			127:            {0x7f},
+			-32:            {0xe0},
+			128:            {0xcc, 0x80},
+			-33:            {0xd0, 0xdf},
+			65535:          {0xcd, 0xff, 0xff},
+			-32769:         {0xd2, 0xff, 0xff, 0x7f, 0xff},
+			1 << 32:        {0xcf, 0, 0, 0, 1, 0, 0, 0, 0},
+			math.MinInt64:  {0xd3, 0x80, 0, 0, 0, 0, 0, 0, 0},
+			math.MaxUint32: {0xce, 0xff, 0xff, 0xff, 0xff},
+		} {
+			v := v
+			So(encode(func(e *msgpackEncoder) { e.writeInt(v) }), ShouldResemble, expected)
+		}
+		So(encode(func(e *msgpackEncoder) { e.writeString("") }), ShouldResemble, []byte{0xa0})
+		So(encode(func(e *msgpackEncoder) { e.writeString(strings.Repeat("a", 32)) })[:2], ShouldResemble, []byte{0xd9, 32})
+		So(encode(func(e *msgpackEncoder) { e.writeString(strings.Repeat("a", 256)) })[:3], ShouldResemble,
+			[]byte{0xda, 0x01, 0x00})
+		So(encode(func(e *msgpackEncoder) { e.writeFloat(1.5) }), ShouldResemble, []byte{0xcb, 0x3f, 0xf8, 0, 0, 0, 0, 0, 0})
+		So(encode(func(e *msgpackEncoder) { e.writeMapHeader(16) }), ShouldResemble, []byte{0xde, 0x00, 0x10})
+		So(encode(func(e *msgpackEncoder) { e.writeArrayHeader(3) }), ShouldResemble, []byte{0x93})
+	})
+
+	Convey("Test spans are decoded to the same values as JSON", t, func() {
+		data := UploadSpanData{Spans: append(testUploadSpans(20), nil, &entity.UploadSpan{})}
+		var buffer bytes.Buffer
+		So(msgpackSpanData(data).Encode(&buffer), ShouldBeNil)
+		decoded, rest, err := decodeMsgpack(buffer.Bytes())
+		So(err, ShouldBeNil)
+		So(rest, ShouldBeEmpty)
+
+		bs, err := json.Marshal(data)
+		So(err, ShouldBeNil)
+		var expected interface{}
+		So(json.Unmarshal(bs, &expected), ShouldBeNil)
+		So(decoded, ShouldResemble, expected)
+	})
+}
+
+func TestSpanExporterWireFormat(t *testing.T) {
+	ctx := context.Background()
+	newServer := func(acceptMsgpack bool) (*httptest.Server, func() []string) {
+		var mu sync.Mutex
+		var contentTypes []string
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			contentType := r.Header.Get("Content-Type")
+			mu.Lock()
+			contentTypes = append(contentTypes, contentType)
+			mu.Unlock()
+			var body io.Reader = r.Body
+			if r.Header.Get("Content-Encoding") == "gzip" {
+				gr, err := gzip.NewReader(r.Body)
+				if err != nil {
+					w.WriteHeader(http.StatusBadRequest)
+					return
+				}
+				body = gr
+			}
+			bs, _ := io.ReadAll(body)
+			var decodeErr error
+			if contentType == contentTypeMsgpack {
+				if !acceptMsgpack {
+					w.WriteHeader(http.StatusUnsupportedMediaType)
+					return
+				}
+				_, _, decodeErr = decodeMsgpack(bs)
+			} else {
+				decodeErr = json.Unmarshal(bs, &UploadSpanData{})
+			}
+			if decodeErr != nil {
+				w.WriteHeader(http.StatusBadRequest)
+				return
+			}
+			_, _ = w.Write([]byte(`{"code":0,"msg":"ok"}`))
+		}))
+		return server, func() []string {
+			mu.Lock()
+			defer mu.Unlock()
+			return append([]string(nil), contentTypes...)
+		}
+	}
+	newExporter := func(url string, format WireFormat) *SpanExporter {
+		exporter := newSpanExporter(httpclient.NewClient(url, http.DefaultClient, httpclient.NewTokenAuth("token"),
+			&httpclient.ClientOptions{GzipRequest: true}), nil)
+		exporter.wireFormat = format
+		return exporter
+	}
+
+	Convey("Test spans are exported as msgpack if it is accepted", t, func() {
+		server, contentTypes := newServer(true)
+		defer server.Close()
+		exporter := newExporter(server.URL, WireFormatMsgpack)
+		So(exporter.ExportSpans(ctx, testUploadSpans(3)), ShouldBeNil)
+		So(exporter.ExportSpans(ctx, testUploadSpans(3)), ShouldBeNil)
+		So(contentTypes(), ShouldResemble, []string{contentTypeMsgpack, contentTypeMsgpack})
+	})
+
+	Convey("Test spans are exported as JSON once msgpack is rejected", t, func() {
+		server, contentTypes := newServer(false)
+		defer server.Close()
+		exporter := newExporter(server.URL, WireFormatMsgpack)
+		So(exporter.ExportSpans(ctx, testUploadSpans(3)), ShouldBeNil)
+		So(exporter.ExportSpans(ctx, testUploadSpans(3)), ShouldBeNil)
+		So(contentTypes(), ShouldResemble, []string{contentTypeMsgpack, "application/json", "application/json"})
+	})
+
+	Convey("Test spans are exported as JSON by default", t, func() {
+		server, contentTypes := newServer(false)
+		defer server.Close()
+		So(newExporter(server.URL, "").ExportSpans(ctx, testUploadSpans(3)), ShouldBeNil)
+		So(contentTypes(), ShouldResemble, []string{"application/json"})
+	})
+}
+
+func BenchmarkUploadSpanDataEncoding(b *testing.B) {
+	data := UploadSpanData{Spans: testUploadSpans(100)}
+	for _, format := range []WireFormat{WireFormatJSON, WireFormatMsgpack} {
+		var body interface{} = data
+		if format == WireFormatMsgpack {
+			body = msgpackSpanData(data)
+		}
+		for _, compress := range []bool{false, true} {
+			name := string(format)
+			if compress {
+				name += "_gzip"
+			}
+			b.Run(name, func(b *testing.B) {
+				var buffer bytes.Buffer
+				gw := gzip.NewWriter(nil)
+				b.ReportAllocs()
+				b.ResetTimer()
+				for i := 0; i < b.N; i++ {
+					buffer.Reset()
+					var w io.Writer = &buffer
+					if compress {
+						gw.Reset(&buffer)
+						w = gw
+					}
+					var err error
+					if encoder, ok := body.(httpclient.BodyEncoder); ok {
+						err = encoder.Encode(w)
+					} else {
+						err = json.NewEncoder(w).Encode(body)
+					}
+					if err == nil && compress {
+						err = gw.Close()
+					}
+					if err != nil {
+						b.Fatal(err)
+					}
+				}
+				b.ReportMetric(float64(buffer.Len()), "bytes/payload")
+			})
+		}
+	}
+}
@@ -0,0 +1,39 @@
+// Copyright (c) 2025 Bytedance Ltd. and/or its affiliates
+// SPDX-License-Identifier: MIT
+
+package util
+
+import (
+	"context"
+	"time"
+)
+
+// detachedContext keeps the values of parent, such as the span, but is never canceled.
+type detachedContext struct {
+	parent context.Context
+}
+
+func (c detachedContext) Deadline() (deadline time.Time, ok bool) {
+	return time.Time{}, false
+}
+
+func (c detachedContext) Done() <-chan struct{} {
+	return nil
+}
+
+func (c detachedContext) Err() error {
+	return nil
+}
+
+func (c detachedContext) Value(key any) any {
+	return c.parent.Value(key)
+}
+
+// DetachContext returns a context which carries the values of ctx, but is not canceled when ctx is canceled and
+// has no deadline.
+func DetachContext(ctx context.Context) context.Context {
+	if ctx == nil {
+		return context.Background()
+	}
+	return detachedContext{parent: ctx}
+}
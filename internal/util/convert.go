@@ -38,7 +38,13 @@ func GetStringBuffer() *bytes.Buffer {
 	return bufferPool.Get().(*bytes.Buffer)
 }
 
+// maxPooledBufferSize avoid holding the memory of very large buffers in pool.
+const maxPooledBufferSize = 4 * 1024 * 1024
+
 func RecycleStringBuffer(buffer *bytes.Buffer) {
+	if buffer.Cap() > maxPooledBufferSize {
+		return
+	}
 	buffer.Reset()
 	bufferPool.Put(buffer)
 }
@@ -146,17 +152,25 @@ func TruncateStringByByte(valueStr string, limit int) (string, bool) {
 }
 
 func ToJSON(param interface{}) string {
+	res, _ := ToJSONE(param)
+	return res
+}
+
+// ToJSONE works like ToJSON and returns the marshal error. It encodes with pooled buffer to reduce allocations.
+func ToJSONE(param interface{}) (string, error) {
 	if param == nil {
-		return ""
+		return "", nil
 	}
 	if paramStr, ok := param.(string); ok {
-		return paramStr
+		return paramStr, nil
 	}
-	byteRes, err := json.Marshal(param)
-	if err != nil {
-		return ""
+	buffer := GetStringBuffer()
+	defer RecycleStringBuffer(buffer)
+	if err := json.NewEncoder(buffer).Encode(param); err != nil {
+		return "", err
 	}
-	return string(byteRes)
+	// trim the trailing newline added by json.Encoder
+	return string(bytes.TrimSuffix(buffer.Bytes(), []byte("\n"))), nil
 }
 
 func Stringify(value interface{}) string {
@@ -4,15 +4,18 @@
 package util
 
 import (
-	"bytes"
 	"fmt"
+	"sync"
 
 	"github.com/nikolalohinski/gonja/v2"
 	"github.com/nikolalohinski/gonja/v2/exec"
 	"github.com/nikolalohinski/gonja/v2/nodes"
 	"github.com/nikolalohinski/gonja/v2/parser"
+)
 
-	"github.com/coze-dev/cozeloop-go/internal/consts"
+var (
+	defaultJinja2Env     *Jinja2Env
+	defaultJinja2EnvOnce sync.Once
 )
 
 func init() {
@@ -27,21 +30,44 @@ func init() {
 }
 
 func InterpolateJinja2(templateStr string, valMap map[string]any) (string, error) {
-	// 解析模板
-	tpl, err := gonja.FromString(templateStr)
+	return InterpolateJinja2WithFuncs(templateStr, valMap, nil)
+}
+
+// InterpolateJinja2WithFuncs render jinja2 template with custom funcs, which can be used as
+// filter `{{ name | upper }}` or function `{{ upper(name) }}`.
+func InterpolateJinja2WithFuncs(templateStr string, valMap map[string]any, funcs map[string]any) (string, error) {
+	tpl, err := NewJinja2Template(templateStr, funcs)
 	if err != nil {
-		return "", consts.ErrTemplateRender.Wrap(fmt.Errorf("template render error err: %v", err.Error()))
+		return "", err
 	}
+	return ExecuteJinja2Template(tpl, valMap, funcs)
+}
+
+// NewJinja2Template parses jinja2 template with custom funcs, the parsed template can be executed
+// concurrently by ExecuteJinja2Template with the same funcs.
+func NewJinja2Template(templateStr string, funcs map[string]any) (*exec.Template, error) {
+	return getJinja2Env(funcs).Parse(templateStr)
+}
 
-	// 创建执行上下文
-	data := exec.NewContext(valMap)
-	var out bytes.Buffer
+// ExecuteJinja2Template render the parsed jinja2 template with variables.
+func ExecuteJinja2Template(tpl *exec.Template, valMap map[string]any, funcs map[string]any) (string, error) {
+	return getJinja2Env(funcs).Execute(tpl, valMap)
+}
 
-	// 执行模板渲染
-	err = tpl.Execute(&out, data)
-	if err != nil {
-		return "", consts.ErrTemplateRender.Wrap(fmt.Errorf("template render error err: %v", err.Error()))
+func getJinja2Env(funcs map[string]any) *Jinja2Env {
+	if len(funcs) > 0 {
+		return NewJinja2Env(funcs, nil)
 	}
+	defaultJinja2EnvOnce.Do(func() {
+		defaultJinja2Env = NewJinja2Env(nil, nil)
+	})
+	return defaultJinja2Env
+}
 
-	return out.String(), nil
+func copyFuncs(funcs map[string]any) map[string]any {
+	copied := make(map[string]any, len(funcs))
+	for name, fn := range funcs {
+		copied[name] = fn
+	}
+	return copied
 }
@@ -0,0 +1,307 @@
+// Copyright (c) 2025 Bytedance Ltd. and/or its affiliates
+// SPDX-License-Identifier: MIT
+
+package util
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"time"
+
+	"github.com/nikolalohinski/gonja/v2"
+	"github.com/nikolalohinski/gonja/v2/builtins"
+	controlStructures "github.com/nikolalohinski/gonja/v2/builtins/control_structures"
+	"github.com/nikolalohinski/gonja/v2/config"
+	"github.com/nikolalohinski/gonja/v2/exec"
+	"github.com/nikolalohinski/gonja/v2/loaders"
+	"github.com/nikolalohinski/gonja/v2/nodes"
+	"github.com/nikolalohinski/gonja/v2/parser"
+
+	"github.com/coze-dev/cozeloop-go/internal/consts"
+)
+
+const (
+	defaultJinja2MaxLoopIterations = 10000
+	defaultJinja2MaxRecursionDepth = 100
+
+	// jinja2RenderStateKey key of the render state in context, which can't be used as variable name in templates
+	jinja2RenderStateKey = "__cozeloop_render_state__"
+)
+
+// Jinja2Options options of parsing and rendering jinja2 templates. Templates pulled from prompt hub may be written by
+// others, the limits keep them from hanging or exhausting the memory of service.
+type Jinja2Options struct {
+	// TrimBlocks remove the first newline after a block tag, such as {% if %}. Default is false.
+	TrimBlocks bool
+	// LstripBlocks strip the spaces and tabs from the start of a line to a block tag. Default is false.
+	LstripBlocks bool
+	// AllowedFilters the built-in filters which can be used, such as upper and join. Default is nil, and all
+	// built-in filters can be used. Custom template funcs can always be used.
+	AllowedFilters []string
+	// MaxLoopIterations max count of items generated by range() in one render, nested loops are counted together.
+	// Default is 10000, no limit if it is negative.
+	MaxLoopIterations int
+	// MaxRecursionDepth max depth of nested macro calls. Default is 100, no limit if it is negative.
+	MaxRecursionDepth int
+	// RenderTimeout max duration of one render. Default is 0, no timeout.
+	RenderTimeout time.Duration
+}
+
+func (o *Jinja2Options) withDefaults() Jinja2Options {
+	var options Jinja2Options
+	if o != nil {
+		options = *o
+	}
+	if options.MaxLoopIterations == 0 {
+		options.MaxLoopIterations = defaultJinja2MaxLoopIterations
+	}
+	if options.MaxRecursionDepth == 0 {
+		options.MaxRecursionDepth = defaultJinja2MaxRecursionDepth
+	}
+	return options
+}
+
+// Jinja2Env the environment to parse and render jinja2 templates with custom funcs and options.
+// It can be used concurrently.
+type Jinja2Env struct {
+	funcs   map[string]any
+	options Jinja2Options
+	config  *config.Config
+	env     *exec.Environment
+}
+
+// NewJinja2Env creates the environment of jinja2 templates. The funcs can be used as filter `{{ name | upper }}`
+// or function `{{ upper(name) }}`, the defaults are used if options is nil.
+func NewJinja2Env(funcs map[string]any, options *Jinja2Options) *Jinja2Env {
+	e := &Jinja2Env{
+		funcs:   funcs,
+		options: options.withDefaults(),
+	}
+	e.config = gonja.DefaultConfig.Inherit()
+	e.config.TrimBlocks = e.options.TrimBlocks
+	e.config.LeftStripBlocks = e.options.LstripBlocks
+
+	filters := exec.NewFilterSet(map[string]exec.FilterFunction{})
+	if e.options.AllowedFilters == nil {
+		filters.Update(gonja.DefaultEnvironment.Filters)
+	} else {
+		for _, name := range e.options.AllowedFilters {
+			if filter, ok := gonja.DefaultEnvironment.Filters.Get(name); ok {
+				_ = filters.Register(name, filter)
+			}
+		}
+	}
+	filters.Update(exec.NewFilterSet(newFuncFilters(funcs)))
+
+	// dangerous control structures have been disabled in the default environment
+	structures := exec.NewControlStructureSet(map[string]parser.ControlStructureParser{}).
+		Update(gonja.DefaultEnvironment.ControlStructures)
+	if macroParser, ok := structures.Get("macro"); ok {
+		_ = structures.Replace("macro", limitMacroParser(macroParser))
+	}
+
+	globals := gonja.DefaultContext.Inherit()
+	globals.Set("range", rangeFunction)
+	e.env = &exec.Environment{
+		Context:           globals,
+		Filters:           filters,
+		Tests:             builtins.Tests,
+		ControlStructures: structures,
+		Methods:           builtins.Methods,
+	}
+	return e
+}
+
+// Parse parses the jinja2 template, the parsed template can be executed concurrently by Execute.
+func (e *Jinja2Env) Parse(templateStr string) (*exec.Template, error) {
+	source := []byte(templateStr)
+	sum := sha256.Sum256(source)
+	rootID := fmt.Sprintf("root-%s", hex.EncodeToString(sum[:]))
+	tpl, err := func() (*exec.Template, error) {
+		loader, err := loaders.NewFileSystemLoader("")
+		if err != nil {
+			return nil, err
+		}
+		shiftedLoader, err := loaders.NewShiftedLoader(rootID, bytes.NewReader(source), loader)
+		if err != nil {
+			return nil, err
+		}
+		return exec.NewTemplate(rootID, e.config, shiftedLoader, e.env)
+	}()
+	if err != nil {
+		return nil, consts.ErrTemplateRender.Wrap(fmt.Errorf("template render error err: %v", err.Error()))
+	}
+	return tpl, nil
+}
+
+// Execute renders the parsed template with variables.
+func (e *Jinja2Env) Execute(tpl *exec.Template, valMap map[string]any) (string, error) {
+	state := &jinja2RenderState{options: &e.options}
+	if e.options.RenderTimeout > 0 {
+		state.deadline = time.Now().Add(e.options.RenderTimeout)
+	}
+	// variables are copied to the context of render, so that they are not modified
+	data := exec.NewContext(copyFuncs(e.funcs)).Update(exec.NewContext(valMap))
+	data.Set(jinja2RenderStateKey, state)
+	out := &jinja2Writer{state: state}
+
+	err := tpl.Execute(out, data)
+	if err == nil {
+		err = state.check()
+	}
+	if err != nil {
+		return "", consts.ErrTemplateRender.Wrap(fmt.Errorf("template render error err: %v", err.Error()))
+	}
+	return out.buf.String(), nil
+}
+
+// newFuncFilters registers funcs as filters, the value to filter is passed as the first argument.
+func newFuncFilters(funcs map[string]any) map[string]exec.FilterFunction {
+	filters := make(map[string]exec.FilterFunction, len(funcs))
+	for name, fn := range funcs {
+		name, fn := name, fn
+		filters[name] = func(e *exec.Evaluator, in *exec.Value, params *exec.VarArgs) *exec.Value {
+			args := []any{in.Interface()}
+			for _, arg := range params.Args {
+				args = append(args, arg.Interface())
+			}
+			result, err := CallTemplateFunc(name, fn, args...)
+			if err != nil {
+				return exec.AsValue(err)
+			}
+			return exec.AsValue(result)
+		}
+	}
+	return filters
+}
+
+// jinja2RenderState the limits of one render.
+type jinja2RenderState struct {
+	options        *Jinja2Options
+	deadline       time.Time
+	loopIterations int
+	depth          int
+}
+
+func (s *jinja2RenderState) check() error {
+	if !s.deadline.IsZero() && time.Now().After(s.deadline) {
+		return fmt.Errorf("render timeout exceeds %v", s.options.RenderTimeout)
+	}
+	return nil
+}
+
+func (s *jinja2RenderState) addLoopIterations(n int) error {
+	s.loopIterations += n
+	if s.options.MaxLoopIterations > 0 && s.loopIterations > s.options.MaxLoopIterations {
+		return fmt.Errorf("loop iterations exceed the limit %d", s.options.MaxLoopIterations)
+	}
+	return s.check()
+}
+
+func (s *jinja2RenderState) enter() error {
+	s.depth++
+	if s.options.MaxRecursionDepth > 0 && s.depth > s.options.MaxRecursionDepth {
+		return fmt.Errorf("recursion depth exceeds the limit %d", s.options.MaxRecursionDepth)
+	}
+	return s.check()
+}
+
+func (s *jinja2RenderState) exit() {
+	s.depth--
+}
+
+func getJinja2RenderState(ctx *exec.Context) *jinja2RenderState {
+	if value, ok := ctx.Get(jinja2RenderStateKey); ok {
+		state, _ := value.(*jinja2RenderState)
+		return state
+	}
+	return nil
+}
+
+// jinja2Writer the output of render, which stops the render on timeout.
+type jinja2Writer struct {
+	buf   bytes.Buffer
+	state *jinja2RenderState
+}
+
+func (w *jinja2Writer) Write(p []byte) (int, error) {
+	if err := w.state.check(); err != nil {
+		return 0, err
+	}
+	return w.buf.Write(p)
+}
+
+// rangeFunction works like the built-in range, except that the items generated are counted by the render state.
+func rangeFunction(e *exec.Evaluator, params *exec.VarArgs) ([]int, error) {
+	start, stop, step := 0, 0, 1
+	switch n := len(params.Args); {
+	case n == 1 && params.Args[0].IsInteger():
+		stop = params.Args[0].Integer()
+	case n == 2 && params.Args[0].IsInteger() && params.Args[1].IsInteger():
+		start, stop = params.Args[0].Integer(), params.Args[1].Integer()
+	case n == 3 && params.Args[0].IsInteger() && params.Args[1].IsInteger() && params.Args[2].IsInteger():
+		start, stop, step = params.Args[0].Integer(), params.Args[1].Integer(), params.Args[2].Integer()
+	default:
+		return nil, exec.ErrInvalidCall(fmt.Errorf("expected signature is [start, ]stop[, step] where all arguments are integers"))
+	}
+	if step == 0 {
+		return nil, exec.ErrInvalidCall(fmt.Errorf("step must not be zero"))
+	}
+	count := 0
+	if step > 0 && stop > start {
+		count = (stop - start + step - 1) / step
+	} else if step < 0 && stop < start {
+		count = (start - stop - step - 1) / -step
+	}
+	if state := getJinja2RenderState(e.Environment.Context); state != nil {
+		if err := state.addLoopIterations(count); err != nil {
+			return nil, err
+		}
+	}
+	items := make([]int, 0, count)
+	for i := 0; i < count; i++ {
+		items = append(items, start+i*step)
+	}
+	return items, nil
+}
+
+// limitMacroParser wraps the parser of macro, so that the depth of nested macro calls is limited.
+func limitMacroParser(macroParser parser.ControlStructureParser) parser.ControlStructureParser {
+	return func(p *parser.Parser, args *parser.Parser) (nodes.ControlStructure, error) {
+		structure, err := macroParser(p, args)
+		if err != nil {
+			return nil, err
+		}
+		macro, ok := structure.(*controlStructures.MacroControlStructure)
+		if !ok {
+			return structure, nil
+		}
+		return &limitedMacro{MacroControlStructure: macro}, nil
+	}
+}
+
+type limitedMacro struct {
+	*controlStructures.MacroControlStructure
+}
+
+func (m *limitedMacro) Execute(r *exec.Renderer, tag *nodes.ControlStructureBlock) error {
+	if err := m.MacroControlStructure.Execute(r, tag); err != nil {
+		return err
+	}
+	state := getJinja2RenderState(r.Environment.Context)
+	value, _ := r.Environment.Context.Get(m.Name)
+	macro, ok := value.(exec.Macro)
+	if state == nil || !ok {
+		return nil
+	}
+	r.Environment.Context.Set(m.Name, exec.Macro(func(params *exec.VarArgs) *exec.Value {
+		defer state.exit()
+		if err := state.enter(); err != nil {
+			return exec.AsValue(err)
+		}
+		return macro(params)
+	}))
+	return nil
+}
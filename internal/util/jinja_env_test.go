@@ -0,0 +1,99 @@
+// Copyright (c) 2025 Bytedance Ltd. and/or its affiliates
+// SPDX-License-Identifier: MIT
+
+package util
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	. "github.com/smartystreets/goconvey/convey"
+
+	"github.com/coze-dev/cozeloop-go/internal/consts"
+)
+
+func renderJinja2(env *Jinja2Env, templateStr string, valMap map[string]any) (string, error) {
+	tpl, err := env.Parse(templateStr)
+	if err != nil {
+		return "", err
+	}
+	return env.Execute(tpl, valMap)
+}
+
+func TestJinja2Env(t *testing.T) {
+	Convey("Test whitespace control", t, func() {
+		template := "<ul>\n  {% for item in items %}\n  <li>{{ item }}</li>\n  {% endfor %}\n</ul>"
+		items := map[string]any{"items": []string{"a", "b"}}
+		result, err := renderJinja2(NewJinja2Env(nil, nil), template, items)
+		So(err, ShouldBeNil)
+		So(result, ShouldEqual, "<ul>\n  \n  <li>a</li>\n  \n  <li>b</li>\n  \n</ul>")
+
+		result, err = renderJinja2(NewJinja2Env(nil, &Jinja2Options{TrimBlocks: true, LstripBlocks: true}), template, items)
+		So(err, ShouldBeNil)
+		So(result, ShouldEqual, "<ul>\n  <li>a</li>\n  <li>b</li>\n\n</ul>")
+	})
+
+	Convey("Test allowed filters", t, func() {
+		env := NewJinja2Env(map[string]any{"shout": strings.ToUpper}, &Jinja2Options{AllowedFilters: []string{"lower"}})
+		result, err := renderJinja2(env, "{{ name | lower }} {{ name | shout }}", map[string]any{"name": "Loop"})
+		So(err, ShouldBeNil)
+		So(result, ShouldEqual, "loop LOOP")
+
+		_, err = renderJinja2(env, "{{ name | upper }}", map[string]any{"name": "Loop"})
+		So(errors.Is(err, consts.ErrTemplateRender), ShouldBeTrue)
+	})
+
+	Convey("Test loop iterations limit", t, func() {
+		env := NewJinja2Env(nil, &Jinja2Options{MaxLoopIterations: 10})
+		result, err := renderJinja2(env, "{% for i in range(1, 10, 3) %}{{ i }}{% endfor %}{% for i in range(5, 0, -2) %}{{ i }}{% endfor %}", nil)
+		So(err, ShouldBeNil)
+		So(result, ShouldEqual, "147531")
+
+		// nested loops are counted together
+		_, err = renderJinja2(env, "{% for i in range(3) %}{% for j in range(3) %}{{ j }}{% endfor %}{% endfor %}", nil)
+		So(err, ShouldNotBeNil)
+		So(err.Error(), ShouldContainSubstring, "loop iterations exceed the limit 10")
+
+		_, err = renderJinja2(env, "{% for i in range(0, 10, 0) %}{% endfor %}", nil)
+		So(err, ShouldNotBeNil)
+
+		_, err = renderJinja2(NewJinja2Env(nil, nil), "{% for i in range(1000000000) %}{% endfor %}", nil)
+		So(err, ShouldNotBeNil)
+		result, err = renderJinja2(NewJinja2Env(nil, &Jinja2Options{MaxLoopIterations: -1}), "{{ range(20000) | length }}", nil)
+		So(err, ShouldBeNil)
+		So(result, ShouldEqual, "20000")
+	})
+
+	Convey("Test recursion depth limit", t, func() {
+		template := "{% macro count(n) %}{% if n > 0 %}{{ count(n - 1) }}{% endif %}{{ n }}{% endmacro %}{{ count(depth) }}"
+		env := NewJinja2Env(nil, &Jinja2Options{MaxRecursionDepth: 5})
+		result, err := renderJinja2(env, template, map[string]any{"depth": 3})
+		So(err, ShouldBeNil)
+		So(result, ShouldEqual, "0123")
+
+		_, err = renderJinja2(env, template, map[string]any{"depth": 5})
+		So(err, ShouldNotBeNil)
+		So(err.Error(), ShouldContainSubstring, "recursion depth exceeds the limit 5")
+
+		// infinite recursion is stopped by default
+		_, err = renderJinja2(NewJinja2Env(nil, nil), "{% macro loop() %}{{ loop() }}{% endmacro %}{{ loop() }}", nil)
+		So(err, ShouldNotBeNil)
+	})
+
+	Convey("Test render timeout", t, func() {
+		slow := func(s string) string {
+			time.Sleep(20 * time.Millisecond)
+			return s
+		}
+		env := NewJinja2Env(map[string]any{"slow": slow}, &Jinja2Options{RenderTimeout: 50 * time.Millisecond})
+		result, err := renderJinja2(env, "{{ name | slow }}", map[string]any{"name": "a"})
+		So(err, ShouldBeNil)
+		So(result, ShouldEqual, "a")
+
+		_, err = renderJinja2(env, "{% for i in range(100) %}{{ i | string | slow }}{% endfor %}", nil)
+		So(err, ShouldNotBeNil)
+		So(err.Error(), ShouldContainSubstring, "render timeout")
+	})
+}
@@ -4,6 +4,8 @@
 package util
 
 import (
+	"errors"
+	"strings"
 	"testing"
 
 	. "github.com/smartystreets/goconvey/convey"
@@ -191,3 +193,41 @@ func TestInterpolateJinja2(t *testing.T) {
 		})
 	})
 }
+
+func TestInterpolateJinja2WithFuncs(t *testing.T) {
+	Convey("Test InterpolateJinja2WithFuncs function", t, func() {
+		funcs := map[string]any{
+			"shout": func(s string) string { return strings.ToUpper(s) + "!" },
+			"cut": func(s string, n int) string {
+				if len(s) > n {
+					return s[:n]
+				}
+				return s
+			},
+			"fail": func(s string) (string, error) { return "", errors.New("boom") },
+		}
+
+		Convey("Custom func as filter", func() {
+			result, err := InterpolateJinja2WithFuncs("{{ name | shout }} {{ name | cut(2) }}", map[string]any{"name": "world"}, funcs)
+			So(err, ShouldBeNil)
+			So(result, ShouldEqual, "WORLD! wo")
+		})
+
+		Convey("Custom func as function", func() {
+			result, err := InterpolateJinja2WithFuncs("{{ shout(name) }}", map[string]any{"name": "world"}, funcs)
+			So(err, ShouldBeNil)
+			So(result, ShouldEqual, "WORLD!")
+		})
+
+		Convey("Custom func returns error", func() {
+			_, err := InterpolateJinja2WithFuncs("{{ name | fail }}", map[string]any{"name": "world"}, funcs)
+			So(err, ShouldNotBeNil)
+			So(err.Error(), ShouldContainSubstring, "boom")
+		})
+
+		Convey("Custom filter is not registered globally", func() {
+			_, err := InterpolateJinja2("{{ name | shout }}", map[string]any{"name": "world"})
+			So(err, ShouldNotBeNil)
+		})
+	})
+}
@@ -0,0 +1,56 @@
+// Copyright (c) 2025 Bytedance Ltd. and/or its affiliates
+// SPDX-License-Identifier: MIT
+
+package util
+
+import (
+	"fmt"
+	"reflect"
+
+	"github.com/coze-dev/cozeloop-go/internal/consts"
+)
+
+var errorType = reflect.TypeOf((*error)(nil)).Elem()
+
+// CallTemplateFunc calls a custom template func with args by reflection.
+// The func must return one value, or one value and an error.
+func CallTemplateFunc(name string, fn any, args ...any) (any, error) {
+	fnVal := reflect.ValueOf(fn)
+	fnType := fnVal.Type()
+	if fnVal.Kind() != reflect.Func {
+		return nil, consts.ErrInvalidParam.Wrap(fmt.Errorf("template func '%s' is not a function", name))
+	}
+	if fnType.NumOut() == 0 || fnType.NumOut() > 2 ||
+		(fnType.NumOut() == 2 && !fnType.Out(1).Implements(errorType)) {
+		return nil, consts.ErrInvalidParam.Wrap(fmt.Errorf("template func '%s' should return one value, or one value and an error", name))
+	}
+	numIn := fnType.NumIn()
+	if (!fnType.IsVariadic() && len(args) != numIn) || (fnType.IsVariadic() && len(args) < numIn-1) {
+		return nil, consts.ErrTemplateRender.Wrap(fmt.Errorf("template func '%s' expects %d arguments, got %d", name, numIn, len(args)))
+	}
+	in := make([]reflect.Value, 0, len(args))
+	for i, arg := range args {
+		var argType reflect.Type
+		if fnType.IsVariadic() && i >= numIn-1 {
+			argType = fnType.In(numIn - 1).Elem()
+		} else {
+			argType = fnType.In(i)
+		}
+		argVal := reflect.ValueOf(arg)
+		switch {
+		case !argVal.IsValid():
+			argVal = reflect.Zero(argType)
+		case argVal.Type().AssignableTo(argType):
+		case argVal.Type().ConvertibleTo(argType):
+			argVal = argVal.Convert(argType)
+		default:
+			return nil, consts.ErrTemplateRender.Wrap(fmt.Errorf("argument %d of template func '%s' should be %s, got %T", i, name, argType, arg))
+		}
+		in = append(in, argVal)
+	}
+	out := fnVal.Call(in)
+	if len(out) == 2 && !out[1].IsNil() {
+		return nil, consts.ErrTemplateRender.Wrap(fmt.Errorf("template func '%s' failed: %v", name, out[1].Interface()))
+	}
+	return out[0].Interface(), nil
+}
@@ -28,7 +28,7 @@ func SetLogger(l Logger) {
 }
 
 // SetLogLevel set log level. By default, the log level is set to Info.
-// Note that this method is not thread-safe. Should be called before any other method.
+// It is thread-safe, and can also be updated by Client.UpdateConfig.
 func SetLogLevel(level LogLevel) {
 	logger.SetLogLevel(level)
 }
@@ -0,0 +1,83 @@
+// Copyright (c) 2025 Bytedance Ltd. and/or its affiliates
+// SPDX-License-Identifier: MIT
+
+// Package looperr provides the errors returned by cozeloop, so that callers can branch on the failure modes
+// by errors.Is and errors.As instead of matching error messages:
+//
+//	_, err := client.GetPrompt(ctx, param)
+//	switch {
+//	case errors.Is(err, looperr.ErrRateLimited):
+//		// back off and retry
+//	case errors.Is(err, looperr.ErrAuthExpired):
+//		// refresh credentials
+//	}
+//	var loopErr looperr.LoopError
+//	if errors.As(err, &loopErr) {
+//		log.Printf("code: %d, http status: %d, request id: %s", loopErr.Code(), loopErr.HTTPStatus(), loopErr.RequestID())
+//	}
+package looperr
+
+import (
+	"github.com/coze-dev/cozeloop-go/internal/consts"
+)
+
+// Errors of the failure modes of CozeLoop service, which are reported by errors.Is for the errors of API
+// responses. They are matched by http status and oauth error code, and by the error codes registered by
+// RegisterErrorCode.
+var (
+	// ErrAuthExpired the api token or oauth token is expired or invalid, http status 401.
+	ErrAuthExpired = consts.ErrAuthExpired
+	// ErrPermissionDenied the token has no permission of the workspace or resource, http status 403.
+	ErrPermissionDenied = consts.ErrPermissionDenied
+	// ErrRateLimited the requests are too frequent, http status 429.
+	ErrRateLimited = consts.ErrRateLimited
+	// ErrWorkspaceNotFound the workspace does not exist, http status 404 with workspace in message.
+	ErrWorkspaceNotFound = consts.ErrWorkspaceNotFound
+	// ErrPromptNotFound the prompt does not exist, returned by GetPrompt if WithPromptNotFoundError is set.
+	ErrPromptNotFound = consts.ErrPromptNotFound
+)
+
+// Errors returned by the SDK directly.
+var (
+	ErrInvalidParam     = consts.ErrInvalidParam
+	ErrInternal         = consts.ErrInternal
+	ErrRemoteService    = consts.ErrRemoteService
+	ErrClientClosed     = consts.ErrClientClosed
+	ErrClientDisabled   = consts.ErrClientDisabled
+	ErrAuthInfoRequired = consts.ErrAuthInfoRequired
+	ErrCircuitOpen      = consts.ErrCircuitOpen
+	ErrTemplateRender   = consts.ErrTemplateRender
+)
+
+// LoopError the error of CozeLoop API response, which can be got by errors.As.
+type LoopError interface {
+	error
+	// Code returns the error code of response, -1 if the response is not valid.
+	Code() int
+	// HTTPStatus returns the http status code of response.
+	HTTPStatus() int
+	// RequestID returns the log id of request, attach it when reporting issues to the platform team.
+	RequestID() string
+}
+
+var _ LoopError = (*RemoteServiceError)(nil)
+
+type (
+	// RemoteServiceError the implementation of LoopError.
+	RemoteServiceError = consts.RemoteServiceError
+	// AuthError the error of oauth, which is wrapped by RemoteServiceError.
+	AuthError = consts.AuthError
+)
+
+// RequestID returns the request id of the LoopError in the chain of err, empty if err is not the error of
+// CozeLoop API response.
+func RequestID(err error) string {
+	return consts.RequestIDOf(err)
+}
+
+// RegisterErrorCode maps the error code of CozeLoop service to sentinel, so that errors.Is(err, sentinel) is true
+// for the errors with the code, e.g. RegisterErrorCode(code, ErrWorkspaceNotFound). It should be called before
+// the client is used.
+func RegisterErrorCode(code int, sentinel error) {
+	consts.RegisterErrorCode(code, sentinel)
+}
@@ -0,0 +1,73 @@
+// Copyright (c) 2025 Bytedance Ltd. and/or its affiliates
+// SPDX-License-Identifier: MIT
+
+package looperr
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+
+	"github.com/coze-dev/cozeloop-go/internal/consts"
+)
+
+func TestLoopError(t *testing.T) {
+	Convey("Test sentinels are matched by http status", t, func() {
+		cases := map[int]error{
+			http.StatusUnauthorized:    ErrAuthExpired,
+			http.StatusForbidden:       ErrPermissionDenied,
+			http.StatusTooManyRequests: ErrRateLimited,
+		}
+		for status, sentinel := range cases {
+			err := ErrRemoteService.Wrap(consts.NewRemoteServiceError(status, 1000, "msg", "log_id"))
+			So(errors.Is(err, sentinel), ShouldBeTrue)
+			So(errors.Is(err, ErrRemoteService), ShouldBeTrue)
+			So(errors.Is(err, ErrWorkspaceNotFound), ShouldBeFalse)
+		}
+		err := ErrRemoteService.Wrap(consts.NewRemoteServiceError(http.StatusNotFound, 1000, "Workspace not exist", ""))
+		So(errors.Is(err, ErrWorkspaceNotFound), ShouldBeTrue)
+	})
+
+	Convey("Test details are got by errors.As", t, func() {
+		err := fmt.Errorf("get prompt: %w",
+			ErrRemoteService.Wrap(consts.NewRemoteServiceError(http.StatusBadRequest, 1001, "msg", "log_id")))
+		var loopErr LoopError
+		So(errors.As(err, &loopErr), ShouldBeTrue)
+		So(loopErr.Code(), ShouldEqual, 1001)
+		So(loopErr.HTTPStatus(), ShouldEqual, http.StatusBadRequest)
+		So(loopErr.RequestID(), ShouldEqual, "log_id")
+		So(RequestID(err), ShouldEqual, "log_id")
+		So(RequestID(ErrInvalidParam), ShouldEqual, "")
+		So(errors.Is(err, ErrAuthExpired), ShouldBeFalse)
+	})
+
+	Convey("Test oauth error codes", t, func() {
+		authErr := consts.NewAuthError(&consts.AuthErrorFormat{ErrorCode: string(consts.ExpiredToken)},
+			http.StatusBadRequest, "log_id")
+		err := ErrRemoteService.Wrap(consts.NewRemoteServiceError(http.StatusBadRequest, -1, "", "log_id").Wrap(authErr))
+		So(errors.Is(err, ErrAuthExpired), ShouldBeTrue)
+		var target *AuthError
+		So(errors.As(err, &target), ShouldBeTrue)
+		So(target.Code, ShouldEqual, consts.ExpiredToken)
+	})
+
+	Convey("Test registered error codes", t, func() {
+		RegisterErrorCode(600500, ErrWorkspaceNotFound)
+		err := ErrRemoteService.Wrap(consts.NewRemoteServiceError(http.StatusOK, 600500, "msg", ""))
+		So(errors.Is(err, ErrWorkspaceNotFound), ShouldBeTrue)
+		So(errors.Is(err, ErrRateLimited), ShouldBeFalse)
+	})
+
+	Convey("Test wrap does not modify sentinel", t, func() {
+		err1 := ErrInvalidParam.Wrap(errors.New("a"))
+		err2 := ErrInvalidParam.Wrap(errors.New("b"))
+		So(err1.Error(), ShouldEqual, "invalid param: a")
+		So(err2.Error(), ShouldEqual, "invalid param: b")
+		So(ErrInvalidParam.Error(), ShouldEqual, "invalid param")
+		So(errors.Is(err1, ErrInvalidParam), ShouldBeTrue)
+		So(errors.Is(err1, ErrInternal), ShouldBeFalse)
+	})
+}
@@ -0,0 +1,399 @@
+// Copyright (c) 2025 Bytedance Ltd. and/or its affiliates
+// SPDX-License-Identifier: MIT
+
+// Package loopfake provides a fake CozeLoop server for end-to-end tests of code using cozeloop. The client
+// created with Server.ClientOptions talks to the fake server by HTTP as it does to CozeLoop, so the whole SDK,
+// including cache, batching and serialization, is tested without accessing the real API.
+//
+// Prompts and execute results are served from the fixtures set in tests, and requests are captured to be asserted.
+package loopfake
+
+import (
+	"bufio"
+	"compress/gzip"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+
+	"github.com/coze-dev/cozeloop-go"
+	"github.com/coze-dev/cozeloop-go/entity"
+	"github.com/coze-dev/cozeloop-go/internal/consts"
+	"github.com/coze-dev/cozeloop-go/internal/prompt"
+)
+
+// Paths of the endpoints served by Server.
+const (
+	PathMGetPrompts      = "/v1/loop/prompts/mget"
+	PathExecute          = "/v1/loop/prompts/execute"
+	PathExecuteStreaming = "/v1/loop/prompts/execute_streaming"
+	PathIngestTraces     = "/v1/loop/traces/ingest"
+	PathUploadFile       = "/v1/loop/files/upload"
+)
+
+const (
+	// WorkspaceID is the workspace id used by ClientOptions.
+	WorkspaceID = "loopfake_workspace"
+	// APIToken is the api token used by ClientOptions.
+	APIToken = "loopfake_token"
+
+	fakeLogID = "loopfake"
+)
+
+type (
+	PromptQuery    = prompt.PromptQuery
+	MGetRequest    = prompt.MPullPromptRequest
+	ExecuteRequest = prompt.ExecuteRequest
+)
+
+// Request a captured http request.
+type Request struct {
+	Method string
+	Path   string
+	Header http.Header
+	// Body is the decompressed body of request.
+	Body []byte
+}
+
+// UploadedFile a captured file uploaded by trace reporting, e.g. large input and output of spans.
+type UploadedFile struct {
+	Name        string
+	WorkspaceID string
+	Data        []byte
+}
+
+// Server is a fake CozeLoop server based on httptest.Server. It should be closed after test.
+type Server struct {
+	*httptest.Server
+
+	lock             sync.Mutex
+	requests         []*Request
+	mgetRequests     []*MGetRequest
+	executeRequests  []*ExecuteRequest
+	spans            []*entity.UploadSpan
+	files            []*UploadedFile
+	prompts          map[string][]*entity.Prompt       // prompt key -> versions in order of added
+	labels           map[string]*entity.Prompt         // prompt key + label -> prompt
+	executeResults   map[string]entity.ExecuteResult   // prompt key -> result of execute
+	streamingResults map[string][]entity.ExecuteResult // prompt key -> chunks of execute streaming
+	errors           map[string]*errorResponse         // path -> error response
+}
+
+type errorResponse struct {
+	statusCode int
+	code       int
+	msg        string
+}
+
+type response struct {
+	Code int    `json:"code"`
+	Msg  string `json:"msg"`
+	Data any    `json:"data,omitempty"`
+}
+
+// NewServer starts a fake server. Use ClientOptions to create a client accessing it.
+func NewServer() *Server {
+	s := &Server{
+		prompts:          make(map[string][]*entity.Prompt),
+		labels:           make(map[string]*entity.Prompt),
+		executeResults:   make(map[string]entity.ExecuteResult),
+		streamingResults: make(map[string][]entity.ExecuteResult),
+		errors:           make(map[string]*errorResponse),
+	}
+	mux := http.NewServeMux()
+	mux.HandleFunc(PathMGetPrompts, s.handleMGetPrompts)
+	mux.HandleFunc(PathExecute, s.handleExecute)
+	mux.HandleFunc(PathExecuteStreaming, s.handleExecuteStreaming)
+	mux.HandleFunc(PathIngestTraces, s.handleIngestTraces)
+	mux.HandleFunc(PathUploadFile, s.handleUploadFile)
+	s.Server = httptest.NewServer(mux)
+	return s
+}
+
+// ClientOptions returns the options to create a client accessing the server, with WorkspaceID and APIToken.
+// Client cache is disabled, so each client gets the fixtures set before it is created.
+func (s *Server) ClientOptions() []cozeloop.Option {
+	return []cozeloop.Option{
+		cozeloop.WithAPIBaseURL(s.URL),
+		cozeloop.WithWorkspaceID(WorkspaceID),
+		cozeloop.WithAPIToken(APIToken),
+		cozeloop.WithNoClientCache(),
+	}
+}
+
+// AddPrompt adds a version of prompt served by mget, with the labels pointing to it. Query without version
+// and label gets the last added version of the prompt key.
+func (s *Server) AddPrompt(p *entity.Prompt, labels ...string) {
+	if p == nil {
+		return
+	}
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.prompts[p.PromptKey] = append(s.prompts[p.PromptKey], p)
+	for _, label := range labels {
+		s.labels[labelKey(p.PromptKey, label)] = p
+	}
+}
+
+// SetExecuteResult sets the result of execute of the prompt key. Execute streaming returns the result as
+// the only chunk, unless the chunks are set by SetExecuteStreamingResults.
+func (s *Server) SetExecuteResult(promptKey string, result entity.ExecuteResult) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.executeResults[promptKey] = result
+}
+
+// SetExecuteStreamingResults sets the chunks of execute streaming of the prompt key, sent in order.
+func (s *Server) SetExecuteStreamingResults(promptKey string, chunks ...entity.ExecuteResult) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.streamingResults[promptKey] = chunks
+}
+
+// SetError makes the endpoint of path respond with the error code and msg, e.g. to test the retry and fallback.
+// Error is cleared if code is 0.
+func (s *Server) SetError(path string, statusCode, code int, msg string) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	if code == 0 {
+		delete(s.errors, path)
+		return
+	}
+	s.errors[path] = &errorResponse{statusCode: statusCode, code: code, msg: msg}
+}
+
+// Requests returns the captured requests of all endpoints in order of received.
+func (s *Server) Requests() []*Request {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	return append([]*Request(nil), s.requests...)
+}
+
+// MGetRequests returns the captured requests of mget prompts.
+func (s *Server) MGetRequests() []*MGetRequest {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	return append([]*MGetRequest(nil), s.mgetRequests...)
+}
+
+// ExecuteRequests returns the captured requests of execute and execute streaming.
+func (s *Server) ExecuteRequests() []*ExecuteRequest {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	return append([]*ExecuteRequest(nil), s.executeRequests...)
+}
+
+// Spans returns the reported spans in order of received. Spans are reported in batch asynchronously,
+// close or flush the client before asserting them.
+func (s *Server) Spans() []*entity.UploadSpan {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	return append([]*entity.UploadSpan(nil), s.spans...)
+}
+
+// SpansByName returns the reported spans of the name.
+func (s *Server) SpansByName(name string) []*entity.UploadSpan {
+	var spans []*entity.UploadSpan
+	for _, span := range s.Spans() {
+		if span.SpanName == name {
+			spans = append(spans, span)
+		}
+	}
+	return spans
+}
+
+// UploadedFiles returns the uploaded files in order of received.
+func (s *Server) UploadedFiles() []*UploadedFile {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	return append([]*UploadedFile(nil), s.files...)
+}
+
+// Reset clears the captured requests, spans and files, the fixtures are kept.
+func (s *Server) Reset() {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.requests = nil
+	s.mgetRequests = nil
+	s.executeRequests = nil
+	s.spans = nil
+	s.files = nil
+}
+
+func (s *Server) handleMGetPrompts(w http.ResponseWriter, r *http.Request) {
+	var req MGetRequest
+	if !s.capture(w, r, &req) {
+		return
+	}
+	s.lock.Lock()
+	s.mgetRequests = append(s.mgetRequests, &req)
+	items := make([]*result, 0, len(req.Queries))
+	for _, query := range req.Queries {
+		items = append(items, &result{Query: query, Prompt: s.getPrompt(query)})
+	}
+	s.lock.Unlock()
+	writeJSON(w, http.StatusOK, &response{Data: map[string]any{"items": items}})
+}
+
+// result is the item of mget response, prompt is serialized by the same json fields as the api.
+type result struct {
+	Query  PromptQuery    `json:"query"`
+	Prompt *entity.Prompt `json:"prompt,omitempty"`
+}
+
+func (s *Server) getPrompt(query PromptQuery) *entity.Prompt {
+	versions := s.prompts[query.PromptKey]
+	switch {
+	case query.Version != "":
+		for i := len(versions) - 1; i >= 0; i-- {
+			if versions[i].Version == query.Version {
+				return versions[i]
+			}
+		}
+		return nil
+	case query.Label != "":
+		return s.labels[labelKey(query.PromptKey, query.Label)]
+	case len(versions) > 0:
+		return versions[len(versions)-1]
+	default:
+		return nil
+	}
+}
+
+func (s *Server) handleExecute(w http.ResponseWriter, r *http.Request) {
+	var req ExecuteRequest
+	if !s.capture(w, r, &req) {
+		return
+	}
+	results, ok := s.executeResultsOf(&req, false)
+	if !ok {
+		writeJSON(w, http.StatusOK, &response{Code: -1, Msg: "execute result is not set"})
+		return
+	}
+	writeJSON(w, http.StatusOK, &response{Data: results[0]})
+}
+
+func (s *Server) handleExecuteStreaming(w http.ResponseWriter, r *http.Request) {
+	var req ExecuteRequest
+	if !s.capture(w, r, &req) {
+		return
+	}
+	results, ok := s.executeResultsOf(&req, true)
+	if !ok {
+		writeJSON(w, http.StatusOK, &response{Code: -1, Msg: "execute result is not set"})
+		return
+	}
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set(consts.LogIDHeader, fakeLogID)
+	w.WriteHeader(http.StatusOK)
+	writer := bufio.NewWriter(w)
+	for _, chunk := range results {
+		data, _ := json.Marshal(chunk)
+		_, _ = fmt.Fprintf(writer, "data: %s\n\n", data)
+	}
+	_ = writer.Flush()
+}
+
+func (s *Server) executeResultsOf(req *ExecuteRequest, streaming bool) ([]entity.ExecuteResult, bool) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.executeRequests = append(s.executeRequests, req)
+	if req.PromptIdentifier == nil {
+		return nil, false
+	}
+	if chunks, ok := s.streamingResults[req.PromptIdentifier.PromptKey]; ok && streaming {
+		return chunks, true
+	}
+	result, ok := s.executeResults[req.PromptIdentifier.PromptKey]
+	return []entity.ExecuteResult{result}, ok
+}
+
+func (s *Server) handleIngestTraces(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		Spans []*entity.UploadSpan `json:"spans"`
+	}
+	if !s.capture(w, r, &req) {
+		return
+	}
+	s.lock.Lock()
+	s.spans = append(s.spans, req.Spans...)
+	s.lock.Unlock()
+	writeJSON(w, http.StatusOK, &response{})
+}
+
+func (s *Server) handleUploadFile(w http.ResponseWriter, r *http.Request) {
+	if !s.capture(w, r, nil) {
+		return
+	}
+	file, header, err := r.FormFile("file")
+	if err != nil {
+		writeJSON(w, http.StatusBadRequest, &response{Code: -1, Msg: fmt.Sprintf("read file failed: %v", err)})
+		return
+	}
+	defer file.Close()
+	data, err := io.ReadAll(file)
+	if err != nil {
+		writeJSON(w, http.StatusBadRequest, &response{Code: -1, Msg: fmt.Sprintf("read file failed: %v", err)})
+		return
+	}
+	s.lock.Lock()
+	s.files = append(s.files, &UploadedFile{Name: header.Filename, WorkspaceID: r.FormValue("workspace_id"), Data: data})
+	s.lock.Unlock()
+	writeJSON(w, http.StatusOK, &response{})
+}
+
+// capture records the request and decodes the json body into req if it is not nil. It responds the error set by
+// SetError and returns false if the request should not be served.
+func (s *Server) capture(w http.ResponseWriter, r *http.Request, req any) bool {
+	captured := &Request{Method: r.Method, Path: r.URL.Path, Header: r.Header.Clone()}
+	if req != nil {
+		body, err := readBody(r)
+		if err != nil {
+			writeJSON(w, http.StatusBadRequest, &response{Code: -1, Msg: fmt.Sprintf("read body failed: %v", err)})
+			return false
+		}
+		captured.Body = body
+	}
+	s.lock.Lock()
+	s.requests = append(s.requests, captured)
+	errResp := s.errors[r.URL.Path]
+	s.lock.Unlock()
+
+	if errResp != nil {
+		writeJSON(w, errResp.statusCode, &response{Code: errResp.code, Msg: errResp.msg})
+		return false
+	}
+	if req != nil {
+		if err := json.Unmarshal(captured.Body, req); err != nil {
+			writeJSON(w, http.StatusBadRequest, &response{Code: -1, Msg: fmt.Sprintf("invalid body: %v", err)})
+			return false
+		}
+	}
+	return true
+}
+
+func readBody(r *http.Request) ([]byte, error) {
+	var reader io.Reader = r.Body
+	if r.Header.Get("Content-Encoding") == "gzip" {
+		gz, err := gzip.NewReader(r.Body)
+		if err != nil {
+			return nil, err
+		}
+		defer gz.Close()
+		reader = gz
+	}
+	return io.ReadAll(reader)
+}
+
+func writeJSON(w http.ResponseWriter, statusCode int, resp *response) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set(consts.LogIDHeader, fakeLogID)
+	w.WriteHeader(statusCode)
+	_ = json.NewEncoder(w).Encode(resp)
+}
+
+func labelKey(promptKey, label string) string {
+	return promptKey + "@" + label
+}
@@ -0,0 +1,138 @@
+// Copyright (c) 2025 Bytedance Ltd. and/or its affiliates
+// SPDX-License-Identifier: MIT
+
+package loopfake
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+
+	"github.com/coze-dev/cozeloop-go"
+	"github.com/coze-dev/cozeloop-go/entity"
+	"github.com/coze-dev/cozeloop-go/internal/util"
+)
+
+func TestServerPrompts(t *testing.T) {
+	ctx := context.Background()
+	Convey("Test prompts are served by fixtures and requests are captured", t, func() {
+		s := NewServer()
+		defer s.Close()
+		s.AddPrompt(&entity.Prompt{PromptKey: "demo", Version: "1", PromptTemplate: &entity.PromptTemplate{
+			TemplateType: entity.TemplateTypeNormal,
+			Messages:     []*entity.Message{{Role: entity.RoleSystem, Content: util.Ptr("hello {{name}}")}},
+			VariableDefs: []*entity.VariableDef{{Key: "name", Type: entity.VariableTypeString}},
+		}}, "production")
+		s.AddPrompt(&entity.Prompt{PromptKey: "demo", Version: "2"})
+
+		client, err := cozeloop.NewClient(s.ClientOptions()...)
+		So(err, ShouldBeNil)
+		defer client.Close(ctx)
+
+		prompt, err := client.GetPrompt(ctx, cozeloop.GetPromptParam{PromptKey: "demo", Label: "production"})
+		So(err, ShouldBeNil)
+		So(prompt.Version, ShouldEqual, "1")
+		messages, err := client.PromptFormat(ctx, prompt, map[string]any{"name": "Tom"})
+		So(err, ShouldBeNil)
+		So(*messages[0].Content, ShouldEqual, "hello Tom")
+
+		prompt, err = client.GetPrompt(ctx, cozeloop.GetPromptParam{PromptKey: "demo", Version: "2"})
+		So(err, ShouldBeNil)
+		So(prompt.Version, ShouldEqual, "2")
+		prompt, err = client.GetPrompt(ctx, cozeloop.GetPromptParam{PromptKey: "unknown", Version: "1"})
+		So(err, ShouldBeNil)
+		So(prompt, ShouldBeNil)
+
+		requests := s.MGetRequests()
+		So(len(requests), ShouldEqual, 3)
+		So(requests[0].WorkSpaceID, ShouldEqual, WorkspaceID)
+		So(requests[0].Queries[0].Label, ShouldEqual, "production")
+		So(s.Requests()[0].Header.Get("Authorization"), ShouldEqual, "Bearer "+APIToken)
+
+		s.SetError(PathMGetPrompts, http.StatusOK, 500, "internal error")
+		_, err = client.GetPrompt(ctx, cozeloop.GetPromptParam{PromptKey: "demo", Version: "3"})
+		So(err, ShouldNotBeNil)
+
+		s.Reset()
+		So(s.Requests(), ShouldBeEmpty)
+	})
+}
+
+func TestServerExecute(t *testing.T) {
+	ctx := context.Background()
+	Convey("Test execute and execute streaming are served by fixtures", t, func() {
+		s := NewServer()
+		defer s.Close()
+		s.SetExecuteResult("demo", entity.ExecuteResult{
+			Message:      &entity.Message{Role: entity.RoleAssistant, Content: util.Ptr("hi")},
+			FinishReason: util.Ptr("stop"),
+		})
+
+		client, err := cozeloop.NewClient(s.ClientOptions()...)
+		So(err, ShouldBeNil)
+		defer client.Close(ctx)
+
+		result, err := client.Execute(ctx, &entity.ExecuteParam{
+			PromptKey: "demo",
+			VariableVals: map[string]any{
+				"name": "Tom",
+			},
+		})
+		So(err, ShouldBeNil)
+		So(*result.Message.Content, ShouldEqual, "hi")
+		So(*result.FinishReason, ShouldEqual, "stop")
+		requests := s.ExecuteRequests()
+		So(len(requests), ShouldEqual, 1)
+		So(requests[0].PromptIdentifier.PromptKey, ShouldEqual, "demo")
+		So(requests[0].VariableVals[0].Key, ShouldEqual, "name")
+
+		s.SetExecuteStreamingResults("demo",
+			entity.ExecuteResult{Message: &entity.Message{Role: entity.RoleAssistant, Content: util.Ptr("h")}},
+			entity.ExecuteResult{Message: &entity.Message{Role: entity.RoleAssistant, Content: util.Ptr("i")}, FinishReason: util.Ptr("stop")},
+		)
+		reader, err := client.ExecuteStreaming(ctx, &entity.ExecuteParam{PromptKey: "demo"})
+		So(err, ShouldBeNil)
+		var content string
+		for {
+			chunk, err := reader.Recv()
+			if err == io.EOF {
+				break
+			}
+			So(err, ShouldBeNil)
+			if chunk.Message != nil {
+				content += util.PtrValue(chunk.Message.Content)
+			}
+		}
+		So(content, ShouldEqual, "hi")
+
+		_, err = client.Execute(ctx, &entity.ExecuteParam{PromptKey: "unknown"})
+		So(err, ShouldNotBeNil)
+	})
+}
+
+func TestServerTraces(t *testing.T) {
+	ctx := context.Background()
+	Convey("Test spans are captured after client is closed", t, func() {
+		s := NewServer()
+		defer s.Close()
+		client, err := cozeloop.NewClient(append(s.ClientOptions(), cozeloop.WithGzipTraceReport(true))...)
+		So(err, ShouldBeNil)
+
+		ctx, root := client.StartSpan(ctx, "root", "custom")
+		_, child := client.StartSpan(ctx, "child", "model")
+		child.SetInput(ctx, "question")
+		child.Finish(ctx)
+		root.Finish(ctx)
+		client.Close(ctx)
+
+		So(len(s.Spans()), ShouldEqual, 2)
+		spans := s.SpansByName("child")
+		So(len(spans), ShouldEqual, 1)
+		So(spans[0].WorkspaceID, ShouldEqual, WorkspaceID)
+		So(spans[0].ParentID, ShouldEqual, root.GetSpanID())
+		So(spans[0].Input, ShouldContainSubstring, "question")
+	})
+}
@@ -0,0 +1,303 @@
+// Copyright (c) 2025 Bytedance Ltd. and/or its affiliates
+// SPDX-License-Identifier: MIT
+
+// Package looptest provides an in-memory client for unit tests of code using cozeloop, without mocking
+// the internals of SDK or accessing CozeLoop. Spans are recorded when they are finished, and prompt calls
+// are recorded and served by the fake prompts and execute results set in tests.
+package looptest
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"sync"
+	"time"
+
+	"github.com/coze-dev/cozeloop-go"
+	"github.com/coze-dev/cozeloop-go/entity"
+)
+
+const (
+	MethodGetPrompt        = "GetPrompt"
+	MethodPromptFormat     = "PromptFormat"
+	MethodExecute          = "Execute"
+	MethodExecuteStreaming = "ExecuteStreaming"
+)
+
+// RecordedSpan the snapshot of a finished span.
+type RecordedSpan struct {
+	Name       string
+	Type       string
+	TraceID    string
+	SpanID     string
+	ParentID   string
+	StatusCode int32
+	StartTime  time.Time
+	Duration   time.Duration
+	Tags       map[string]interface{}
+	Baggage    map[string]string
+}
+
+// PromptCall a call of prompt methods of client.
+type PromptCall struct {
+	// Method one of MethodGetPrompt, MethodPromptFormat, MethodExecute and MethodExecuteStreaming
+	Method string
+	// Param is set for GetPrompt
+	Param cozeloop.GetPromptParam
+	// Prompt and Variables are set for PromptFormat
+	Prompt    *entity.Prompt
+	Variables map[string]any
+	// ExecuteParam is set for Execute and ExecuteStreaming
+	ExecuteParam *entity.ExecuteParam
+	// Err the error returned
+	Err error
+}
+
+type executeResult struct {
+	result entity.ExecuteResult
+	err    error
+}
+
+// Recorder is a Client for tests. Trace methods and PromptFormat work as the real client, but spans are recorded
+// in memory instead of being reported. GetPrompt, Execute and ExecuteStreaming are served by the fakes set by
+// AddPrompt and SetExecuteResult. Other methods fail, as there is no CozeLoop to access.
+// Use cozeloop.SetDefaultClient to record the calls of package-level functions.
+type Recorder struct {
+	cozeloop.Client
+
+	lock           sync.Mutex
+	spans          []*RecordedSpan
+	promptCalls    []*PromptCall
+	prompts        map[string][]*entity.Prompt // prompt key -> versions in order of added
+	labels         map[string]*entity.Prompt   // prompt key + label -> prompt
+	promptErrs     map[string]error            // prompt key -> error of GetPrompt
+	executeResults map[string]executeResult    // prompt key -> result of Execute
+}
+
+// NewRecorder creates a Recorder, the options are applied to the underlying client. It should be closed
+// after test. It panics if the client can not be created, e.g. the options are invalid.
+func NewRecorder(opts ...cozeloop.Option) *Recorder {
+	r := &Recorder{
+		prompts:        make(map[string][]*entity.Prompt),
+		labels:         make(map[string]*entity.Prompt),
+		promptErrs:     make(map[string]error),
+		executeResults: make(map[string]executeResult),
+	}
+	options := append([]cozeloop.Option{
+		cozeloop.WithDebugExporter(&cozeloop.DebugConf{Writer: io.Discard}),
+		cozeloop.WithSpanProcessor(&spanRecorder{r: r}),
+		cozeloop.WithNoClientCache(),
+	}, opts...)
+	client, err := cozeloop.NewClient(options...)
+	if err != nil {
+		panic(fmt.Sprintf("looptest: new client failed: %v", err))
+	}
+	r.Client = client
+	return r
+}
+
+// RecordedSpans returns the finished spans in order of finished.
+func (r *Recorder) RecordedSpans() []*RecordedSpan {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	return append([]*RecordedSpan(nil), r.spans...)
+}
+
+// SpansByName returns the finished spans of the name in order of finished.
+func (r *Recorder) SpansByName(name string) []*RecordedSpan {
+	var spans []*RecordedSpan
+	for _, span := range r.RecordedSpans() {
+		if span.Name == name {
+			spans = append(spans, span)
+		}
+	}
+	return spans
+}
+
+// RecordedPromptCalls returns the prompt calls in order of called.
+func (r *Recorder) RecordedPromptCalls() []*PromptCall {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	return append([]*PromptCall(nil), r.promptCalls...)
+}
+
+// Reset clears the recorded spans and prompt calls, the fakes are kept.
+func (r *Recorder) Reset() {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	r.spans = nil
+	r.promptCalls = nil
+}
+
+// AddPrompt adds a version of prompt returned by GetPrompt, with the labels pointing to it. GetPrompt without
+// version and label returns the last added version of the prompt key.
+func (r *Recorder) AddPrompt(prompt *entity.Prompt, labels ...string) {
+	if prompt == nil {
+		return
+	}
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	r.prompts[prompt.PromptKey] = append(r.prompts[prompt.PromptKey], prompt)
+	for _, label := range labels {
+		r.labels[labelKey(prompt.PromptKey, label)] = prompt
+	}
+}
+
+// SetPromptError makes GetPrompt of the prompt key fail with err. Error is cleared if err is nil.
+func (r *Recorder) SetPromptError(promptKey string, err error) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	if err == nil {
+		delete(r.promptErrs, promptKey)
+		return
+	}
+	r.promptErrs[promptKey] = err
+}
+
+// SetExecuteResult sets the result of Execute of the prompt key. ExecuteStreaming returns the result
+// as the only item of stream.
+func (r *Recorder) SetExecuteResult(promptKey string, result entity.ExecuteResult, err error) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	r.executeResults[promptKey] = executeResult{result: result, err: err}
+}
+
+// GetPrompt returns the fake prompt added by AddPrompt, or nil if there is no such prompt.
+func (r *Recorder) GetPrompt(ctx context.Context, param cozeloop.GetPromptParam, options ...cozeloop.GetPromptOption) (*entity.Prompt, error) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	prompt, err := r.getPrompt(param)
+	r.promptCalls = append(r.promptCalls, &PromptCall{Method: MethodGetPrompt, Param: param, Err: err})
+	if prompt == nil {
+		return nil, err
+	}
+	return prompt.DeepCopy(), err
+}
+
+func (r *Recorder) getPrompt(param cozeloop.GetPromptParam) (*entity.Prompt, error) {
+	if err := r.promptErrs[param.PromptKey]; err != nil {
+		return nil, err
+	}
+	versions := r.prompts[param.PromptKey]
+	switch {
+	case param.Version != "":
+		for i := len(versions) - 1; i >= 0; i-- {
+			if versions[i].Version == param.Version {
+				return versions[i], nil
+			}
+		}
+		return nil, nil
+	case param.Label != "":
+		return r.labels[labelKey(param.PromptKey, param.Label)], nil
+	case len(versions) > 0:
+		return versions[len(versions)-1], nil
+	default:
+		return nil, nil
+	}
+}
+
+// PromptFormat formats prompt as the real client, and records the call.
+func (r *Recorder) PromptFormat(ctx context.Context, prompt *entity.Prompt, variables map[string]any,
+	options ...cozeloop.PromptFormatOption,
+) ([]*entity.Message, error) {
+	messages, err := r.Client.PromptFormat(ctx, prompt, variables, options...)
+	r.recordPromptCall(&PromptCall{Method: MethodPromptFormat, Prompt: prompt, Variables: variables, Err: err})
+	return messages, err
+}
+
+// Execute returns the result set by SetExecuteResult.
+func (r *Recorder) Execute(ctx context.Context, param *entity.ExecuteParam, options ...cozeloop.ExecuteOption) (entity.ExecuteResult, error) {
+	result, err := r.execute(param)
+	r.recordPromptCall(&PromptCall{Method: MethodExecute, ExecuteParam: param, Err: err})
+	return result, err
+}
+
+// ExecuteStreaming returns a stream of the result set by SetExecuteResult.
+func (r *Recorder) ExecuteStreaming(ctx context.Context, param *entity.ExecuteParam,
+	options ...cozeloop.ExecuteStreamingOption,
+) (entity.StreamReader[entity.ExecuteResult], error) {
+	result, err := r.execute(param)
+	r.recordPromptCall(&PromptCall{Method: MethodExecuteStreaming, ExecuteParam: param, Err: err})
+	if err != nil {
+		return nil, err
+	}
+	return &resultStreamReader{results: []entity.ExecuteResult{result}}, nil
+}
+
+func (r *Recorder) execute(param *entity.ExecuteParam) (entity.ExecuteResult, error) {
+	if param == nil {
+		return entity.ExecuteResult{}, fmt.Errorf("execute param is nil")
+	}
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	result, ok := r.executeResults[param.PromptKey]
+	if !ok {
+		return entity.ExecuteResult{}, fmt.Errorf("looptest: execute result of prompt %s is not set", param.PromptKey)
+	}
+	return result.result, result.err
+}
+
+func (r *Recorder) recordPromptCall(call *PromptCall) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	r.promptCalls = append(r.promptCalls, call)
+}
+
+func labelKey(promptKey, label string) string {
+	return promptKey + "@" + label
+}
+
+// spanRecorder records the finished spans into Recorder.
+type spanRecorder struct {
+	r *Recorder
+}
+
+func (s *spanRecorder) OnSpanEnd(ctx context.Context, span *cozeloop.FinishedSpan) {
+	recorded := &RecordedSpan{
+		Name:       span.GetSpanName(),
+		Type:       span.GetSpanType(),
+		TraceID:    span.GetTraceID(),
+		SpanID:     span.GetSpanID(),
+		ParentID:   span.GetParentID(),
+		StatusCode: span.GetStatusCode(),
+		StartTime:  span.GetStartTime(),
+		Duration:   time.Duration(span.GetDuration()) * time.Microsecond,
+		Tags:       span.GetTagMap(),
+		Baggage:    span.GetBaggage(),
+	}
+	s.r.lock.Lock()
+	defer s.r.lock.Unlock()
+	s.r.spans = append(s.r.spans, recorded)
+}
+
+func (s *spanRecorder) Shutdown(ctx context.Context) (*cozeloop.ShutdownReport, error) {
+	return &cozeloop.ShutdownReport{}, nil
+}
+
+func (s *spanRecorder) ForceFlush(ctx context.Context) error {
+	return nil
+}
+
+// resultStreamReader returns the results one by one, then io.EOF.
+type resultStreamReader struct {
+	lock    sync.Mutex
+	results []entity.ExecuteResult
+}
+
+func (s *resultStreamReader) Recv() (entity.ExecuteResult, error) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	if len(s.results) == 0 {
+		return entity.ExecuteResult{}, io.EOF
+	}
+	result := s.results[0]
+	s.results = s.results[1:]
+	return result, nil
+}
+
+func (s *resultStreamReader) Close() error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.results = nil
+	return nil
+}
@@ -0,0 +1,109 @@
+// Copyright (c) 2025 Bytedance Ltd. and/or its affiliates
+// SPDX-License-Identifier: MIT
+
+package looptest
+
+import (
+	"context"
+	"errors"
+	"io"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+
+	"github.com/coze-dev/cozeloop-go"
+	"github.com/coze-dev/cozeloop-go/entity"
+	"github.com/coze-dev/cozeloop-go/internal/util"
+)
+
+func TestRecorderSpans(t *testing.T) {
+	ctx := context.Background()
+	Convey("Test spans are recorded when finished", t, func() {
+		r := NewRecorder()
+		defer r.Close(ctx)
+
+		ctx, root := r.StartSpan(ctx, "root", "custom")
+		_, child := r.StartSpan(ctx, "child", "model")
+		child.SetInputTokens(ctx, 10)
+		child.SetTags(ctx, map[string]interface{}{"k": "v"})
+		child.Finish(ctx)
+		root.Finish(ctx)
+
+		spans := r.RecordedSpans()
+		So(len(spans), ShouldEqual, 2)
+		So(spans[0].Name, ShouldEqual, "child")
+		So(spans[0].Type, ShouldEqual, "model")
+		So(spans[0].ParentID, ShouldEqual, root.GetSpanID())
+		So(spans[0].TraceID, ShouldEqual, root.GetTraceID())
+		So(spans[0].Tags["k"], ShouldEqual, "v")
+		So(spans[0].Tags["input_tokens"], ShouldEqual, 10)
+		So(len(r.SpansByName("root")), ShouldEqual, 1)
+
+		r.Reset()
+		So(r.RecordedSpans(), ShouldBeEmpty)
+	})
+}
+
+func TestRecorderPrompts(t *testing.T) {
+	ctx := context.Background()
+	Convey("Test prompts are served by fakes and calls are recorded", t, func() {
+		r := NewRecorder()
+		defer r.Close(ctx)
+		v1 := &entity.Prompt{PromptKey: "demo", Version: "1", PromptTemplate: &entity.PromptTemplate{
+			TemplateType: entity.TemplateTypeNormal,
+			Messages:     []*entity.Message{{Role: entity.RoleSystem, Content: util.Ptr("hello {{name}}")}},
+			VariableDefs: []*entity.VariableDef{{Key: "name", Type: entity.VariableTypeString}},
+		}}
+		v2 := &entity.Prompt{PromptKey: "demo", Version: "2"}
+		r.AddPrompt(v1, "production")
+		r.AddPrompt(v2)
+
+		prompt, err := r.GetPrompt(ctx, cozeloop.GetPromptParam{PromptKey: "demo"})
+		So(err, ShouldBeNil)
+		So(prompt.Version, ShouldEqual, "2")
+		prompt, err = r.GetPrompt(ctx, cozeloop.GetPromptParam{PromptKey: "demo", Label: "production"})
+		So(err, ShouldBeNil)
+		So(prompt.Version, ShouldEqual, "1")
+		prompt, err = r.GetPrompt(ctx, cozeloop.GetPromptParam{PromptKey: "demo", Version: "3"})
+		So(err, ShouldBeNil)
+		So(prompt, ShouldBeNil)
+
+		messages, err := r.PromptFormat(ctx, v1, map[string]any{"name": "Tom"})
+		So(err, ShouldBeNil)
+		So(*messages[0].Content, ShouldEqual, "hello Tom")
+
+		r.SetPromptError("demo", errors.New("boom"))
+		_, err = r.GetPrompt(ctx, cozeloop.GetPromptParam{PromptKey: "demo"})
+		So(err, ShouldNotBeNil)
+
+		calls := r.RecordedPromptCalls()
+		So(len(calls), ShouldEqual, 5)
+		So(calls[0].Method, ShouldEqual, MethodGetPrompt)
+		So(calls[1].Param.Label, ShouldEqual, "production")
+		So(calls[3].Method, ShouldEqual, MethodPromptFormat)
+		So(calls[3].Variables["name"], ShouldEqual, "Tom")
+		So(calls[4].Err, ShouldNotBeNil)
+	})
+
+	Convey("Test execute results are served by fakes", t, func() {
+		r := NewRecorder()
+		defer r.Close(ctx)
+		r.SetExecuteResult("demo", entity.ExecuteResult{Message: &entity.Message{Role: entity.RoleAssistant, Content: util.Ptr("hi")}}, nil)
+
+		result, err := r.Execute(ctx, &entity.ExecuteParam{PromptKey: "demo"})
+		So(err, ShouldBeNil)
+		So(*result.Message.Content, ShouldEqual, "hi")
+
+		reader, err := r.ExecuteStreaming(ctx, &entity.ExecuteParam{PromptKey: "demo"})
+		So(err, ShouldBeNil)
+		result, err = reader.Recv()
+		So(err, ShouldBeNil)
+		So(*result.Message.Content, ShouldEqual, "hi")
+		_, err = reader.Recv()
+		So(err, ShouldEqual, io.EOF)
+
+		_, err = r.Execute(ctx, &entity.ExecuteParam{PromptKey: "unknown"})
+		So(err, ShouldNotBeNil)
+		So(len(r.RecordedPromptCalls()), ShouldEqual, 3)
+	})
+}
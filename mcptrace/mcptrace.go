@@ -0,0 +1,102 @@
+// Copyright (c) 2025 Bytedance Ltd. and/or its affiliates
+// SPDX-License-Identifier: MIT
+
+// Package mcptrace provides helpers to trace MCP (Model Context Protocol) tool invocations as tool spans.
+// It does not depend on any MCP SDK, wrap the tool call of whichever SDK you use with TraceToolCall.
+package mcptrace
+
+import (
+	"context"
+	"errors"
+
+	"github.com/coze-dev/cozeloop-go"
+	"github.com/coze-dev/cozeloop-go/internal/util"
+	"github.com/coze-dev/cozeloop-go/spec/tracespec"
+)
+
+// Tags for mcp tool span.
+const (
+	TagMCPServerName = "mcp_server_name"
+	TagMCPToolName   = "mcp_tool_name"
+)
+
+// ToolCall describes an MCP tool invocation.
+type ToolCall struct {
+	// ServerName name of the MCP server which provides the tool, optional
+	ServerName string
+	// ToolName name of the tool, also used as the span name
+	ToolName string
+	// Arguments arguments of the tool call, set as span input
+	Arguments any
+	// CallID id of the tool call issued by model, optional
+	CallID string
+}
+
+// ErrToolResult is returned by TraceToolCall when the tool reports an error result.
+var ErrToolResult = errors.New("mcp tool returned error result")
+
+type options struct {
+	client        cozeloop.TraceClient
+	isErrorResult func(result any) bool
+}
+
+type Option func(o *options)
+
+// WithClient set the client used to start spans. Default is the default client of cozeloop.
+func WithClient(client cozeloop.TraceClient) Option {
+	return func(o *options) {
+		o.client = client
+	}
+}
+
+// WithErrorResult set the func to check whether the tool result is an error result, such as
+// CallToolResult.IsError in MCP. An error result marks the span as failed even if no error is returned.
+func WithErrorResult(isErrorResult func(result any) bool) Option {
+	return func(o *options) {
+		o.isErrorResult = isErrorResult
+	}
+}
+
+// TraceToolCall calls fn in a tool span, which records the tool name, arguments, result and error.
+// The latency of the tool call is the duration of the span.
+func TraceToolCall[T any](ctx context.Context, call ToolCall, fn func(ctx context.Context) (T, error), opts ...Option) (result T, err error) {
+	o := &options{}
+	for _, opt := range opts {
+		opt(o)
+	}
+
+	var span cozeloop.Span
+	if o.client != nil {
+		ctx, span = o.client.StartSpan(ctx, call.ToolName, tracespec.VToolSpanType)
+	} else {
+		ctx, span = cozeloop.StartSpan(ctx, call.ToolName, tracespec.VToolSpanType)
+	}
+	defer span.Finish(ctx)
+
+	tags := map[string]any{
+		TagMCPToolName: call.ToolName,
+	}
+	if call.ServerName != "" {
+		tags[TagMCPServerName] = call.ServerName
+	}
+	if call.CallID != "" {
+		tags[tracespec.ToolCallID] = call.CallID
+	}
+	span.SetTags(ctx, tags)
+	if call.Arguments != nil {
+		span.SetInput(ctx, call.Arguments)
+	}
+
+	result, err = fn(ctx)
+	if err != nil {
+		span.SetStatusCode(ctx, util.GetErrorCode(err))
+		span.SetError(ctx, err)
+		return result, err
+	}
+	span.SetOutput(ctx, result)
+	if o.isErrorResult != nil && o.isErrorResult(result) {
+		span.SetStatusCode(ctx, util.GetErrorCode(ErrToolResult))
+		span.SetError(ctx, ErrToolResult)
+	}
+	return result, nil
+}
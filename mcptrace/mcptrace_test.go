@@ -0,0 +1,111 @@
+// Copyright (c) 2025 Bytedance Ltd. and/or its affiliates
+// SPDX-License-Identifier: MIT
+
+package mcptrace
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+
+	"github.com/coze-dev/cozeloop-go"
+	"github.com/coze-dev/cozeloop-go/entity"
+	"github.com/coze-dev/cozeloop-go/spec/tracespec"
+)
+
+type spanRecorder struct {
+	mu    sync.Mutex
+	spans []*entity.UploadSpan
+}
+
+func (r *spanRecorder) ExportSpans(ctx context.Context, spans []*entity.UploadSpan) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.spans = append(r.spans, spans...)
+	return nil
+}
+
+func (r *spanRecorder) ExportFiles(ctx context.Context, files []*entity.UploadFile) error {
+	return nil
+}
+
+func (r *spanRecorder) last() *entity.UploadSpan {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if len(r.spans) == 0 {
+		return nil
+	}
+	return r.spans[len(r.spans)-1]
+}
+
+type toolResult struct {
+	Text    string `json:"text"`
+	IsError bool   `json:"is_error"`
+}
+
+func TestTraceToolCall(t *testing.T) {
+	ctx := context.Background()
+	recorder := &spanRecorder{}
+	client, err := cozeloop.NewClient(
+		cozeloop.WithWorkspaceID("mcptrace"),
+		cozeloop.WithAPIToken("token"),
+		cozeloop.WithExporter(recorder),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	call := ToolCall{
+		ServerName: "weather",
+		ToolName:   "get_weather",
+		Arguments:  map[string]any{"city": "Beijing"},
+		CallID:     "call_1",
+	}
+
+	Convey("Test TraceToolCall with result", t, func() {
+		result, err := TraceToolCall(ctx, call, func(ctx context.Context) (*toolResult, error) {
+			return &toolResult{Text: "sunny"}, nil
+		}, WithClient(client))
+		So(err, ShouldBeNil)
+		So(result.Text, ShouldEqual, "sunny")
+
+		client.Flush(ctx)
+		span := recorder.last()
+		So(span, ShouldNotBeNil)
+		So(span.SpanName, ShouldEqual, "get_weather")
+		So(span.SpanType, ShouldEqual, tracespec.VToolSpanType)
+		So(span.StatusCode, ShouldEqual, 0)
+		So(span.Input, ShouldContainSubstring, "Beijing")
+		So(span.Output, ShouldContainSubstring, "sunny")
+		So(span.TagsString[TagMCPServerName], ShouldEqual, "weather")
+		So(span.TagsString[tracespec.ToolCallID], ShouldEqual, "call_1")
+	})
+
+	Convey("Test TraceToolCall with error", t, func() {
+		_, err := TraceToolCall(ctx, call, func(ctx context.Context) (*toolResult, error) {
+			return nil, errors.New("connection refused")
+		}, WithClient(client))
+		So(err, ShouldNotBeNil)
+
+		client.Flush(ctx)
+		span := recorder.last()
+		So(span.StatusCode, ShouldNotEqual, 0)
+		So(span.TagsString[tracespec.Error], ShouldContainSubstring, "connection refused")
+	})
+
+	Convey("Test TraceToolCall with error result", t, func() {
+		_, err := TraceToolCall(ctx, call, func(ctx context.Context) (*toolResult, error) {
+			return &toolResult{Text: "city not found", IsError: true}, nil
+		}, WithClient(client), WithErrorResult(func(result any) bool {
+			return result.(*toolResult).IsError
+		}))
+		So(err, ShouldBeNil)
+
+		client.Flush(ctx)
+		span := recorder.last()
+		So(span.StatusCode, ShouldNotEqual, 0)
+		So(span.Output, ShouldContainSubstring, "city not found")
+	})
+}
@@ -27,16 +27,53 @@ func (c *NoopClient) Close(ctx context.Context) {
 	logger.CtxWarnf(context.Background(), "Noop client not supported. %v", c.newClientError)
 }
 
+func (c *NoopClient) Shutdown(ctx context.Context) (*ShutdownReport, error) {
+	logger.CtxWarnf(context.Background(), "Noop client not supported. %v", c.newClientError)
+	return nil, c.newClientError
+}
+
+func (c *NoopClient) Stats() ClientStats {
+	logger.CtxWarnf(context.Background(), "Noop client not supported. %v", c.newClientError)
+	return ClientStats{}
+}
+
+func (c *NoopClient) UpdateConfig(update ConfigUpdate) error {
+	logger.CtxWarnf(context.Background(), "Noop client not supported. %v", c.newClientError)
+	return c.newClientError
+}
+
 func (c *NoopClient) GetPrompt(ctx context.Context, param GetPromptParam, options ...GetPromptOption) (*entity.Prompt, error) {
 	logger.CtxWarnf(context.Background(), "Noop client not supported. %v", c.newClientError)
 	return nil, c.newClientError
 }
 
+func (c *NoopClient) MGetPrompts(ctx context.Context, params []GetPromptParam, options ...GetPromptOption) (map[GetPromptParam]*entity.Prompt, error) {
+	logger.CtxWarnf(context.Background(), "Noop client not supported. %v", c.newClientError)
+	return nil, c.newClientError
+}
+
 func (c *NoopClient) PromptFormat(ctx context.Context, prompt *entity.Prompt, variables map[string]any, options ...PromptFormatOption) (messages []*entity.Message, err error) {
 	logger.CtxWarnf(context.Background(), "Noop client not supported. %v", c.newClientError)
 	return nil, c.newClientError
 }
 
+func (c *NoopClient) PromptFormatPartial(ctx context.Context, prompt *entity.Prompt, variables map[string]any, options ...PromptFormatOption) (*entity.PartialFormatResult, error) {
+	logger.CtxWarnf(context.Background(), "Noop client not supported. %v", c.newClientError)
+	return nil, c.newClientError
+}
+
+func (c *NoopClient) ComparePromptVersions(ctx context.Context, promptKey, versionA, versionB string, variables map[string]any,
+	options ...PromptFormatOption,
+) (*entity.PromptVersionDiff, error) {
+	logger.CtxWarnf(context.Background(), "Noop client not supported. %v", c.newClientError)
+	return nil, c.newClientError
+}
+
+func (c *NoopClient) ListPromptVersions(ctx context.Context, promptKey string) ([]*entity.PromptVersion, error) {
+	logger.CtxWarnf(context.Background(), "Noop client not supported. %v", c.newClientError)
+	return nil, c.newClientError
+}
+
 func (c *NoopClient) Execute(ctx context.Context, req *entity.ExecuteParam, options ...ExecuteOption) (entity.ExecuteResult, error) {
 	logger.CtxWarnf(context.Background(), "Noop client not supported. %v", c.newClientError)
 	return entity.ExecuteResult{}, c.newClientError
@@ -65,3 +102,88 @@ func (c *NoopClient) GetSpanFromHeader(ctx context.Context, header map[string]st
 func (c *NoopClient) Flush(ctx context.Context) {
 	logger.CtxWarnf(context.Background(), "Noop client not supported. %v", c.newClientError)
 }
+
+func (c *NoopClient) ImportSpans(ctx context.Context, spans []*ImportedSpan) error {
+	logger.CtxWarnf(context.Background(), "Noop client not supported. %v", c.newClientError)
+	return c.newClientError
+}
+
+func (c *NoopClient) ReportFeedback(ctx context.Context, traceID, spanID string, feedback *entity.Feedback) error {
+	logger.CtxWarnf(context.Background(), "Noop client not supported. %v", c.newClientError)
+	return c.newClientError
+}
+
+func (c *NoopClient) AnnotateSpan(ctx context.Context, traceID, spanID string, tags map[string]any) error {
+	logger.CtxWarnf(context.Background(), "Noop client not supported. %v", c.newClientError)
+	return c.newClientError
+}
+
+func (c *NoopClient) ListSpans(ctx context.Context, param *entity.ListSpansParam) (*entity.ListSpansResult, error) {
+	logger.CtxWarnf(context.Background(), "Noop client not supported. %v", c.newClientError)
+	return nil, c.newClientError
+}
+
+func (c *NoopClient) CreateEvalDataset(ctx context.Context, param *entity.CreateEvalDatasetParam) (*entity.EvalDataset, error) {
+	logger.CtxWarnf(context.Background(), "Noop client not supported. %v", c.newClientError)
+	return nil, c.newClientError
+}
+
+func (c *NoopClient) UploadEvalItems(ctx context.Context, datasetID string, items []*entity.EvalItem) error {
+	logger.CtxWarnf(context.Background(), "Noop client not supported. %v", c.newClientError)
+	return c.newClientError
+}
+
+func (c *NoopClient) RunEvaluation(ctx context.Context, param *entity.RunEvaluationParam) (*entity.EvalRun, error) {
+	logger.CtxWarnf(context.Background(), "Noop client not supported. %v", c.newClientError)
+	return nil, c.newClientError
+}
+
+func (c *NoopClient) GetEvalRun(ctx context.Context, runID string) (*entity.EvalRun, error) {
+	logger.CtxWarnf(context.Background(), "Noop client not supported. %v", c.newClientError)
+	return nil, c.newClientError
+}
+
+func (c *NoopClient) WaitEvalRun(ctx context.Context, runID string, options ...WaitEvalRunOption) (*entity.EvalRun, error) {
+	logger.CtxWarnf(context.Background(), "Noop client not supported. %v", c.newClientError)
+	return nil, c.newClientError
+}
+
+func (c *NoopClient) ListEvalResults(ctx context.Context, param *entity.ListEvalResultsParam) (*entity.ListEvalResultsResult, error) {
+	logger.CtxWarnf(context.Background(), "Noop client not supported. %v", c.newClientError)
+	return nil, c.newClientError
+}
+
+func (c *NoopClient) SubmitPromptOptimization(ctx context.Context, param *entity.SubmitPromptOptimizationParam) (*entity.PromptOptimizationRun, error) {
+	logger.CtxWarnf(context.Background(), "Noop client not supported. %v", c.newClientError)
+	return nil, c.newClientError
+}
+
+func (c *NoopClient) GetPromptOptimization(ctx context.Context, runID string) (*entity.PromptOptimizationRun, error) {
+	logger.CtxWarnf(context.Background(), "Noop client not supported. %v", c.newClientError)
+	return nil, c.newClientError
+}
+
+func (c *NoopClient) WaitPromptOptimization(ctx context.Context, runID string, options ...WaitEvalRunOption) (*entity.PromptOptimizationRun, error) {
+	logger.CtxWarnf(context.Background(), "Noop client not supported. %v", c.newClientError)
+	return nil, c.newClientError
+}
+
+func (c *NoopClient) CreateDataset(ctx context.Context, param *entity.CreateEvalDatasetParam) (*entity.EvalDataset, error) {
+	logger.CtxWarnf(context.Background(), "Noop client not supported. %v", c.newClientError)
+	return nil, c.newClientError
+}
+
+func (c *NoopClient) AppendDatasetItems(ctx context.Context, datasetID string, items []*entity.DatasetItem) ([]string, error) {
+	logger.CtxWarnf(context.Background(), "Noop client not supported. %v", c.newClientError)
+	return nil, c.newClientError
+}
+
+func (c *NoopClient) ListDatasetItems(ctx context.Context, param *entity.ListDatasetItemsParam) (*entity.ListDatasetItemsResult, error) {
+	logger.CtxWarnf(context.Background(), "Noop client not supported. %v", c.newClientError)
+	return nil, c.newClientError
+}
+
+func (c *NoopClient) DeleteDatasetItems(ctx context.Context, datasetID string, itemIDs []string) error {
+	logger.CtxWarnf(context.Background(), "Noop client not supported. %v", c.newClientError)
+	return c.newClientError
+}
@@ -0,0 +1,347 @@
+// Copyright (c) 2025 Bytedance Ltd. and/or its affiliates
+// SPDX-License-Identifier: MIT
+
+// Package openaiconv converts prompts of cozeloop to OpenAI chat completion requests, and chat completion
+// responses back to cozeloop messages. It does not depend on any OpenAI SDK, the types are the same as
+// the OpenAI chat completion API in JSON, and have the same field names as github.com/sashabaranov/go-openai,
+// so they can be sent by any HTTP client, or copied to the types of SDK field by field.
+// The package also works for OpenAI compatible APIs, such as Volc ARK and the compatible mode of DashScope.
+package openaiconv
+
+import (
+	"encoding/json"
+	"strings"
+
+	"github.com/coze-dev/cozeloop-go/entity"
+	"github.com/coze-dev/cozeloop-go/internal/util"
+)
+
+const (
+	ChatMessagePartTypeText     = "text"
+	ChatMessagePartTypeImageURL = "image_url"
+
+	ResponseFormatTypeJSONObject = "json_object"
+
+	ToolTypeFunction = "function"
+
+	dataURLPrefix = "data:"
+)
+
+// ChatCompletionRequest is the request of OpenAI chat completion API.
+type ChatCompletionRequest struct {
+	Model            string                        `json:"model"`
+	Messages         []ChatCompletionMessage       `json:"messages"`
+	MaxTokens        int                           `json:"max_tokens,omitempty"`
+	Temperature      float32                       `json:"temperature,omitempty"`
+	TopP             float32                       `json:"top_p,omitempty"`
+	PresencePenalty  float32                       `json:"presence_penalty,omitempty"`
+	FrequencyPenalty float32                       `json:"frequency_penalty,omitempty"`
+	ResponseFormat   *ChatCompletionResponseFormat `json:"response_format,omitempty"`
+	Tools            []Tool                        `json:"tools,omitempty"`
+	// ToolChoice can be either a string or a ToolChoice object.
+	ToolChoice any `json:"tool_choice,omitempty"`
+}
+
+type ChatCompletionResponseFormat struct {
+	Type string `json:"type,omitempty"`
+}
+
+// ChatCompletionMessage is a message of chat completion. Content and MultiContent are both marshaled to
+// the content field, only one of them can be set.
+type ChatCompletionMessage struct {
+	Role             string            `json:"role"`
+	Content          string            `json:"content,omitempty"`
+	MultiContent     []ChatMessagePart `json:"-"`
+	ReasoningContent string            `json:"reasoning_content,omitempty"`
+	Name             string            `json:"name,omitempty"`
+	ToolCalls        []ToolCall        `json:"tool_calls,omitempty"`
+	ToolCallID       string            `json:"tool_call_id,omitempty"`
+}
+
+type chatCompletionMessage ChatCompletionMessage
+
+func (m ChatCompletionMessage) MarshalJSON() ([]byte, error) {
+	if len(m.MultiContent) == 0 {
+		return json.Marshal(chatCompletionMessage(m))
+	}
+	return json.Marshal(struct {
+		chatCompletionMessage
+		MultiContent []ChatMessagePart `json:"content"`
+	}{
+		chatCompletionMessage: chatCompletionMessage(m),
+		MultiContent:          m.MultiContent,
+	})
+}
+
+func (m *ChatCompletionMessage) UnmarshalJSON(data []byte) error {
+	var msg struct {
+		chatCompletionMessage
+		Content json.RawMessage `json:"content,omitempty"`
+	}
+	if err := json.Unmarshal(data, &msg); err != nil {
+		return err
+	}
+	*m = ChatCompletionMessage(msg.chatCompletionMessage)
+	if len(msg.Content) == 0 || string(msg.Content) == "null" {
+		return nil
+	}
+	if msg.Content[0] == '[' {
+		return json.Unmarshal(msg.Content, &m.MultiContent)
+	}
+	return json.Unmarshal(msg.Content, &m.Content)
+}
+
+type ChatMessagePart struct {
+	Type     string               `json:"type,omitempty"`
+	Text     string               `json:"text,omitempty"`
+	ImageURL *ChatMessageImageURL `json:"image_url,omitempty"`
+}
+
+type ChatMessageImageURL struct {
+	URL    string `json:"url,omitempty"`
+	Detail string `json:"detail,omitempty"`
+}
+
+type Tool struct {
+	Type     string              `json:"type"`
+	Function *FunctionDefinition `json:"function,omitempty"`
+}
+
+type FunctionDefinition struct {
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"`
+	// Parameters the JSON schema of parameters.
+	Parameters json.RawMessage `json:"parameters,omitempty"`
+}
+
+type ToolCall struct {
+	// Index is only set in streaming response.
+	Index    *int         `json:"index,omitempty"`
+	ID       string       `json:"id,omitempty"`
+	Type     string       `json:"type"`
+	Function FunctionCall `json:"function"`
+}
+
+type FunctionCall struct {
+	Name      string `json:"name,omitempty"`
+	Arguments string `json:"arguments,omitempty"`
+}
+
+// ChatCompletionResponse is the response of OpenAI chat completion API.
+type ChatCompletionResponse struct {
+	ID      string                 `json:"id"`
+	Model   string                 `json:"model"`
+	Choices []ChatCompletionChoice `json:"choices"`
+	Usage   Usage                  `json:"usage"`
+}
+
+type ChatCompletionChoice struct {
+	Index        int                   `json:"index"`
+	Message      ChatCompletionMessage `json:"message"`
+	FinishReason string                `json:"finish_reason"`
+}
+
+type Usage struct {
+	PromptTokens     int `json:"prompt_tokens"`
+	CompletionTokens int `json:"completion_tokens"`
+	TotalTokens      int `json:"total_tokens"`
+}
+
+// ToChatCompletionRequest converts the messages formatted from prompt by PromptFormat, with the tools,
+// tool call config and model config of prompt, to a chat completion request. Model of request is not set,
+// and TopK of model config is dropped, as they are not defined in prompt or not supported by OpenAI.
+func ToChatCompletionRequest(prompt *entity.Prompt, messages []*entity.Message) *ChatCompletionRequest {
+	req := &ChatCompletionRequest{
+		Messages: ToChatCompletionMessages(messages),
+	}
+	if prompt == nil {
+		return req
+	}
+	req.Tools = toTools(prompt.Tools)
+	if prompt.ToolCallConfig != nil && prompt.ToolCallConfig.ToolChoice != "" && len(req.Tools) > 0 {
+		req.ToolChoice = string(prompt.ToolCallConfig.ToolChoice)
+	}
+	if config := prompt.LLMConfig; config != nil {
+		req.MaxTokens = int(util.PtrValue(config.MaxTokens))
+		req.Temperature = float32(util.PtrValue(config.Temperature))
+		req.TopP = float32(util.PtrValue(config.TopP))
+		req.PresencePenalty = float32(util.PtrValue(config.PresencePenalty))
+		req.FrequencyPenalty = float32(util.PtrValue(config.FrequencyPenalty))
+		if util.PtrValue(config.JSONMode) {
+			req.ResponseFormat = &ChatCompletionResponseFormat{Type: ResponseFormatTypeJSONObject}
+		}
+	}
+	return req
+}
+
+// ToChatCompletionMessages converts messages to chat completion messages. Placeholder messages and
+// multi-part variables, which should have been replaced by PromptFormat, are dropped.
+func ToChatCompletionMessages(messages []*entity.Message) []ChatCompletionMessage {
+	result := make([]ChatCompletionMessage, 0, len(messages))
+	for _, message := range messages {
+		if message == nil || message.Role == entity.RolePlaceholder {
+			continue
+		}
+		result = append(result, ToChatCompletionMessage(message))
+	}
+	return result
+}
+
+// ToChatCompletionMessage converts a message to chat completion message. Base64 data of image should be
+// a data URL like "data:image/png;base64,...", which is sent as the url of image.
+func ToChatCompletionMessage(message *entity.Message) ChatCompletionMessage {
+	if message == nil {
+		return ChatCompletionMessage{}
+	}
+	msg := ChatCompletionMessage{
+		Role:             string(message.Role),
+		Content:          util.PtrValue(message.Content),
+		ReasoningContent: util.PtrValue(message.ReasoningContent),
+		ToolCallID:       util.PtrValue(message.ToolCallID),
+	}
+	if len(message.Parts) > 0 {
+		msg.MultiContent = toChatMessageParts(message.Parts)
+		// OpenAI does not support content and multi content at the same time
+		if msg.Content != "" {
+			msg.MultiContent = append([]ChatMessagePart{{Type: ChatMessagePartTypeText, Text: msg.Content}}, msg.MultiContent...)
+			msg.Content = ""
+		}
+	}
+	for _, toolCall := range message.ToolCalls {
+		if toolCall == nil {
+			continue
+		}
+		call := ToolCall{
+			ID:   toolCall.ID,
+			Type: string(toolCall.Type),
+		}
+		if call.Type == "" {
+			call.Type = ToolTypeFunction
+		}
+		if toolCall.FunctionCall != nil {
+			call.Function = FunctionCall{
+				Name:      toolCall.FunctionCall.Name,
+				Arguments: util.PtrValue(toolCall.FunctionCall.Arguments),
+			}
+		}
+		msg.ToolCalls = append(msg.ToolCalls, call)
+	}
+	return msg
+}
+
+func toChatMessageParts(parts []*entity.ContentPart) []ChatMessagePart {
+	var result []ChatMessagePart
+	for _, part := range parts {
+		if part == nil {
+			continue
+		}
+		switch part.Type {
+		case entity.ContentTypeText:
+			result = append(result, ChatMessagePart{Type: ChatMessagePartTypeText, Text: util.PtrValue(part.Text)})
+		case entity.ContentTypeImageURL:
+			result = append(result, ChatMessagePart{
+				Type:     ChatMessagePartTypeImageURL,
+				ImageURL: &ChatMessageImageURL{URL: util.PtrValue(part.ImageURL)},
+			})
+		case entity.ContentTypeBase64Data:
+			result = append(result, ChatMessagePart{
+				Type:     ChatMessagePartTypeImageURL,
+				ImageURL: &ChatMessageImageURL{URL: util.PtrValue(part.Base64Data)},
+			})
+		default:
+		}
+	}
+	return result
+}
+
+func toTools(tools []*entity.Tool) []Tool {
+	var result []Tool
+	for _, tool := range tools {
+		if tool == nil || tool.Function == nil {
+			continue
+		}
+		function := &FunctionDefinition{
+			Name:        tool.Function.Name,
+			Description: util.PtrValue(tool.Function.Description),
+		}
+		if parameters := util.PtrValue(tool.Function.Parameters); parameters != "" && json.Valid([]byte(parameters)) {
+			function.Parameters = json.RawMessage(parameters)
+		}
+		toolType := string(tool.Type)
+		if toolType == "" {
+			toolType = ToolTypeFunction
+		}
+		result = append(result, Tool{Type: toolType, Function: function})
+	}
+	return result
+}
+
+// FromChatCompletionMessage converts a chat completion message, such as the message of response, to message.
+// Image urls of data URL are converted to base64 data parts.
+func FromChatCompletionMessage(msg ChatCompletionMessage) *entity.Message {
+	message := &entity.Message{
+		Role: entity.Role(msg.Role),
+	}
+	if msg.Content != "" {
+		message.Content = util.Ptr(msg.Content)
+	}
+	if msg.ReasoningContent != "" {
+		message.ReasoningContent = util.Ptr(msg.ReasoningContent)
+	}
+	if msg.ToolCallID != "" {
+		message.ToolCallID = util.Ptr(msg.ToolCallID)
+	}
+	for _, part := range msg.MultiContent {
+		switch part.Type {
+		case ChatMessagePartTypeText:
+			message.Parts = append(message.Parts, &entity.ContentPart{Type: entity.ContentTypeText, Text: util.Ptr(part.Text)})
+		case ChatMessagePartTypeImageURL:
+			if part.ImageURL == nil {
+				continue
+			}
+			if strings.HasPrefix(part.ImageURL.URL, dataURLPrefix) {
+				message.Parts = append(message.Parts, &entity.ContentPart{Type: entity.ContentTypeBase64Data, Base64Data: util.Ptr(part.ImageURL.URL)})
+			} else {
+				message.Parts = append(message.Parts, &entity.ContentPart{Type: entity.ContentTypeImageURL, ImageURL: util.Ptr(part.ImageURL.URL)})
+			}
+		default:
+		}
+	}
+	for i, toolCall := range msg.ToolCalls {
+		index := i
+		if toolCall.Index != nil {
+			index = *toolCall.Index
+		}
+		message.ToolCalls = append(message.ToolCalls, &entity.ToolCall{
+			Index: int32(index),
+			ID:    toolCall.ID,
+			Type:  entity.ToolType(toolCall.Type),
+			FunctionCall: &entity.FunctionCall{
+				Name:      toolCall.Function.Name,
+				Arguments: util.Ptr(toolCall.Function.Arguments),
+			},
+		})
+	}
+	return message
+}
+
+// FromChatCompletionResponse converts the first choice and usage of response to the result, in the same
+// form as the result of executing prompt.
+func FromChatCompletionResponse(resp *ChatCompletionResponse) entity.ExecuteResult {
+	result := entity.ExecuteResult{}
+	if resp == nil {
+		return result
+	}
+	if len(resp.Choices) > 0 {
+		choice := resp.Choices[0]
+		result.Message = FromChatCompletionMessage(choice.Message)
+		if choice.FinishReason != "" {
+			result.FinishReason = util.Ptr(choice.FinishReason)
+		}
+	}
+	result.Usage = &entity.TokenUsage{
+		InputTokens:  resp.Usage.PromptTokens,
+		OutputTokens: resp.Usage.CompletionTokens,
+	}
+	return result
+}
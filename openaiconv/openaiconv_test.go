@@ -0,0 +1,89 @@
+// Copyright (c) 2025 Bytedance Ltd. and/or its affiliates
+// SPDX-License-Identifier: MIT
+
+package openaiconv
+
+import (
+	"encoding/json"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+
+	"github.com/coze-dev/cozeloop-go/entity"
+	"github.com/coze-dev/cozeloop-go/internal/util"
+)
+
+func TestToChatCompletionRequest(t *testing.T) {
+	Convey("Test convert prompt to request", t, func() {
+		prompt := &entity.Prompt{
+			Tools: []*entity.Tool{{
+				Type: entity.ToolTypeFunction,
+				Function: &entity.Function{
+					Name:        "get_weather",
+					Description: util.Ptr("get weather of city"),
+					Parameters:  util.Ptr(`{"type":"object","properties":{"city":{"type":"string"}}}`),
+				},
+			}},
+			ToolCallConfig: &entity.ToolCallConfig{ToolChoice: entity.ToolChoiceTypeAuto},
+			LLMConfig: &entity.LLMConfig{
+				Temperature: util.Ptr(0.5),
+				MaxTokens:   util.Ptr(int32(1024)),
+				TopK:        util.Ptr(int32(10)),
+				JSONMode:    util.Ptr(true),
+			},
+		}
+		messages := []*entity.Message{
+			{Role: entity.RoleSystem, Content: util.Ptr("You are a helpful assistant.")},
+			{Role: entity.RoleUser, Parts: []*entity.ContentPart{
+				{Type: entity.ContentTypeText, Text: util.Ptr("what is it?")},
+				{Type: entity.ContentTypeImageURL, ImageURL: util.Ptr("https://example.com/a.png")},
+			}},
+			{Role: entity.RolePlaceholder},
+		}
+
+		req := ToChatCompletionRequest(prompt, messages)
+		So(len(req.Messages), ShouldEqual, 2)
+		So(req.MaxTokens, ShouldEqual, 1024)
+		So(req.Temperature, ShouldEqual, float32(0.5))
+		So(req.ResponseFormat.Type, ShouldEqual, ResponseFormatTypeJSONObject)
+		So(req.ToolChoice, ShouldEqual, "auto")
+		So(req.Tools[0].Function.Name, ShouldEqual, "get_weather")
+
+		data, err := json.Marshal(req)
+		So(err, ShouldBeNil)
+		So(string(data), ShouldContainSubstring, `"content":"You are a helpful assistant."`)
+		So(string(data), ShouldContainSubstring, `"content":[{"type":"text","text":"what is it?"},{"type":"image_url","image_url":{"url":"https://example.com/a.png"}}]`)
+		So(string(data), ShouldContainSubstring, `"parameters":{"type":"object"`)
+
+		// multi content is kept in round trip
+		decoded := &ChatCompletionRequest{}
+		So(json.Unmarshal(data, decoded), ShouldBeNil)
+		So(decoded.Messages[0].Content, ShouldEqual, "You are a helpful assistant.")
+		So(len(decoded.Messages[1].MultiContent), ShouldEqual, 2)
+	})
+}
+
+func TestFromChatCompletionResponse(t *testing.T) {
+	Convey("Test convert response to result", t, func() {
+		data := `{"id":"1","model":"gpt-4o","choices":[{"index":0,"finish_reason":"tool_calls","message":{"role":"assistant","content":null,
+			"tool_calls":[{"id":"call_1","type":"function","function":{"name":"get_weather","arguments":"{\"city\":\"Beijing\"}"}}]}}],
+			"usage":{"prompt_tokens":10,"completion_tokens":5,"total_tokens":15}}`
+		resp := &ChatCompletionResponse{}
+		So(json.Unmarshal([]byte(data), resp), ShouldBeNil)
+
+		result := FromChatCompletionResponse(resp)
+		So(result.Message.Role, ShouldEqual, entity.RoleAssistant)
+		So(result.Message.Content, ShouldBeNil)
+		So(util.PtrValue(result.FinishReason), ShouldEqual, "tool_calls")
+		So(result.Usage.InputTokens, ShouldEqual, 10)
+		So(result.Usage.OutputTokens, ShouldEqual, 5)
+		So(len(result.Message.ToolCalls), ShouldEqual, 1)
+		So(result.Message.ToolCalls[0].FunctionCall.Name, ShouldEqual, "get_weather")
+		So(util.PtrValue(result.Message.ToolCalls[0].FunctionCall.Arguments), ShouldEqual, `{"city":"Beijing"}`)
+
+		// message converted back is the same as original
+		So(ToChatCompletionMessage(result.Message).ToolCalls, ShouldResemble, []ToolCall{{
+			ID: "call_1", Type: ToolTypeFunction, Function: FunctionCall{Name: "get_weather", Arguments: `{"city":"Beijing"}`},
+		}})
+	})
+}
@@ -5,6 +5,7 @@ package cozeloop
 
 import (
 	"context"
+	"time"
 
 	"github.com/coze-dev/cozeloop-go/entity"
 	"github.com/coze-dev/cozeloop-go/internal/prompt"
@@ -14,21 +15,175 @@ import (
 type PromptClient interface {
 	// GetPrompt get prompt by prompt key and version.
 	// if version is not set,  the latest version of the corresponding prompt will be obtained.
+	// The returned prompt is a copy which can be modified, unless WithPromptDeepCopy is disabled.
 	GetPrompt(ctx context.Context, param GetPromptParam, options ...GetPromptOption) (*entity.Prompt, error)
+	// MGetPrompts get prompts of params, keyed by the params, sharing the cache with GetPrompt. The prompts not
+	// cached are pulled in one request, so it is faster than calling GetPrompt for each, e.g. at startup.
+	// Prompts which do not exist are absent from the result, unless WithPromptNotFoundError is set, in which case
+	// ErrPromptNotFound is returned with the prompts found.
+	MGetPrompts(ctx context.Context, params []GetPromptParam, options ...GetPromptOption) (map[GetPromptParam]*entity.Prompt, error)
 	// PromptFormat format prompt with variables
 	PromptFormat(ctx context.Context, prompt *entity.Prompt, variables map[string]any, options ...PromptFormatOption) (messages []*entity.Message, err error)
+	// PromptFormatPartial format prompt with the variables which are ready, and return the unresolved variables and
+	// placeholders, which are kept in messages instead of rendered as empty, so that the messages can be formatted
+	// again at a later stage, such as when chat history is loaded.
+	PromptFormatPartial(ctx context.Context, prompt *entity.Prompt, variables map[string]any, options ...PromptFormatOption) (*entity.PartialFormatResult, error)
 	// Execute execute prompt and return result
 	Execute(ctx context.Context, param *entity.ExecuteParam, options ...ExecuteOption) (entity.ExecuteResult, error)
-	// ExecuteStreaming execute prompt in streaming mode and return stream reader
+	// ComparePromptVersions get the two versions of prompt, format both with the same variables, and return
+	// the message level and text level diff of them, to validate prompt upgrades before switching labels.
+	// Latest version is used if version is empty.
+	ComparePromptVersions(ctx context.Context, promptKey, versionA, versionB string, variables map[string]any,
+		options ...PromptFormatOption) (*entity.PromptVersionDiff, error)
+	// ExecuteStreaming execute prompt in streaming mode and return stream reader.
+	// The reader should be closed by entity.CloseStream if it is not read to the end. Use entity.StreamToChan
+	// or entity.StreamSeq (Go 1.23+) to consume it as channel or iterator.
 	ExecuteStreaming(ctx context.Context, param *entity.ExecuteParam, options ...ExecuteStreamingOption) (entity.StreamReader[entity.ExecuteResult], error)
+	// ListPromptVersions list all committed versions of prompt with labels, description and commit time,
+	// the latest first.
+	ListPromptVersions(ctx context.Context, promptKey string) ([]*entity.PromptVersion, error)
 }
 
 type GetPromptParam = prompt.GetPromptParam
 
+// PromptStats statistics of getting prompts, such as cache hits and misses, see Client.Stats.
+type PromptStats = prompt.Stats
+
 type GetPromptOption func(option *prompt.GetPromptOptions)
 
+// WithPromptWorkspaceID get prompt from the workspace, instead of the workspace of client.
+// Services serving multiple workspaces can use it rather than creating a client per workspace.
+func WithPromptWorkspaceID(workspaceID string) GetPromptOption {
+	return func(option *prompt.GetPromptOptions) {
+		option.WorkspaceID = workspaceID
+	}
+}
+
+// WithCallTimeout set how long the call waits for the prompts pulled from server, the deadline of ctx is also
+// honored, whichever is earlier. The pull is shared by concurrent calls and bounded by the timeout of client set by
+// WithTimeout, so the call stops waiting when it times out without failing the other calls.
+func WithCallTimeout(timeout time.Duration) GetPromptOption {
+	return func(option *prompt.GetPromptOptions) {
+		option.Timeout = timeout
+	}
+}
+
+// Fields of prompt which can be selected by WithPromptFields and WithoutPromptFields.
+const (
+	PromptFieldPromptTemplate = prompt.PromptFieldPromptTemplate
+	PromptFieldTools          = prompt.PromptFieldTools
+	PromptFieldToolCallConfig = prompt.PromptFieldToolCallConfig
+	PromptFieldLLMConfig      = prompt.PromptFieldLLMConfig
+)
+
+// WithPromptFields only pull the fields of prompt, such as PromptFieldPromptTemplate when only messages are needed.
+// It reduces network cost and cache memory of large prompts. Unselected fields of returned prompt are nil.
+func WithPromptFields(fields ...string) GetPromptOption {
+	return func(option *prompt.GetPromptOptions) {
+		if option.FieldMask == nil {
+			option.FieldMask = &prompt.FieldMask{}
+		}
+		option.FieldMask.Include = append(option.FieldMask.Include, fields...)
+	}
+}
+
+// WithoutPromptFields do not pull the fields of prompt, such as PromptFieldTools and PromptFieldLLMConfig.
+// Excluded fields of returned prompt are nil.
+func WithoutPromptFields(fields ...string) GetPromptOption {
+	return func(option *prompt.GetPromptOptions) {
+		if option.FieldMask == nil {
+			option.FieldMask = &prompt.FieldMask{}
+		}
+		option.FieldMask.Exclude = append(option.FieldMask.Exclude, fields...)
+	}
+}
+
+// PromptFormatCacheConf conf of the cache of PromptFormat results, see WithPromptFormatCache.
+type PromptFormatCacheConf = prompt.FormatCacheConf
+
+// PromptExecuteCacheConf conf of the cache of Execute results, see WithPromptExecuteCache.
+type PromptExecuteCacheConf = prompt.ExecuteCacheConf
+
+// PromptJinja2Conf conf of Jinja2 templates, see WithPromptJinja2Conf.
+type PromptJinja2Conf = prompt.Jinja2Conf
+
+// PromptTraceInputConf limits of the variables recorded in prompt template span, see WithPromptTraceInputConf.
+type PromptTraceInputConf = prompt.TraceInputConf
+
+// PromptPlaceholderPolicy guards the messages of placeholder variables against prompt injection, see
+// WithPlaceholderPolicy.
+type PromptPlaceholderPolicy = prompt.PlaceholderPolicy
+
+// PromptSuspiciousContent the suspicious content found in placeholder messages, see PromptPlaceholderPolicy.
+type PromptSuspiciousContent = prompt.SuspiciousContent
+
+// DefaultPromptSuspiciousPatterns the patterns used by PromptPlaceholderPolicy if SuspiciousPatterns is nil, which
+// can be extended with custom ones.
+var DefaultPromptSuspiciousPatterns = prompt.DefaultSuspiciousPatterns
+
 type PromptFormatOption func(option *prompt.PromptFormatOptions)
 
+// WithStrictVariables make PromptFormat fail with an error listing the missing and extra variables,
+// instead of rendering missing variables as empty string and leaving undefined ones as it is.
+func WithStrictVariables() PromptFormatOption {
+	return func(option *prompt.PromptFormatOptions) {
+		option.StrictVariables = true
+	}
+}
+
+// WithVariableStruct bind the fields of struct v to variables, by the name in `loop:"var_name"` tag or the field name,
+// see entity.BindVariables. The bound variables are merged with the variables map, and PromptFormat fails if any field
+// is not a defined variable of prompt, or has a type different from the variable definition.
+func WithVariableStruct(v any) PromptFormatOption {
+	return func(option *prompt.PromptFormatOptions) {
+		option.VariableStruct = v
+	}
+}
+
+// WithTraceExcludedVariables exclude the variables from the input of prompt template span, such as the ones with
+// sensitive or huge content. It only takes effect if WithPromptTrace is enabled.
+func WithTraceExcludedVariables(keys ...string) PromptFormatOption {
+	return func(option *prompt.PromptFormatOptions) {
+		option.TraceExcludedVariables = append(option.TraceExcludedVariables, keys...)
+	}
+}
+
+// WithEscapeVariables escape the template control sequences in variable values, such as "{{" and "{%", by inserting a
+// zero width space, so that the values supplied by users can not alter the structure of templates, e.g. when the
+// result of PromptFormatPartial is formatted again. The strings in slices, maps and placeholder messages are escaped
+// too. Use WithRawVariables to insert some of the variables as they are.
+func WithEscapeVariables() PromptFormatOption {
+	return func(option *prompt.PromptFormatOptions) {
+		option.EscapeVariables = true
+	}
+}
+
+// WithRawVariables insert the variables of keys as they are, even if WithEscapeVariables is set, such as the ones
+// which are trusted template snippets.
+func WithRawVariables(keys ...string) PromptFormatOption {
+	return func(option *prompt.PromptFormatOptions) {
+		option.RawVariables = append(option.RawVariables, keys...)
+	}
+}
+
+// WithPlaceholderPolicy validate the messages of placeholder variables by policy, which are usually conversation
+// history supplied by users. PromptFormat fails if any message is of the roles not allowed, e.g. system messages
+// spliced into the history, and the suspicious content is reported or removed. The result is not cached by the
+// prompt format cache.
+func WithPlaceholderPolicy(policy *PromptPlaceholderPolicy) PromptFormatOption {
+	return func(option *prompt.PromptFormatOptions) {
+		option.PlaceholderPolicy = policy
+	}
+}
+
 type ExecuteOption = prompt.ExecuteOption
 
+// WithExecuteTimeout set the timeout of Execute, which overrides the default timeout of 10 minutes. The deadline
+// of ctx is also honored, whichever is earlier.
+func WithExecuteTimeout(timeout time.Duration) ExecuteOption {
+	return func(option *prompt.ExecuteOptions) {
+		option.Timeout = timeout
+	}
+}
+
 type ExecuteStreamingOption = prompt.ExecuteStreamingOption
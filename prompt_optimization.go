@@ -0,0 +1,21 @@
+// Copyright (c) 2025 Bytedance Ltd. and/or its affiliates
+// SPDX-License-Identifier: MIT
+
+package cozeloop
+
+import (
+	"context"
+
+	"github.com/coze-dev/cozeloop-go/entity"
+)
+
+// PromptOptimizationClient interface of prompt optimization client.
+type PromptOptimizationClient interface {
+	// SubmitPromptOptimization submit a prompt version with scored samples of its outputs, the platform proposes
+	// better prompt templates from them asynchronously
+	SubmitPromptOptimization(ctx context.Context, param *entity.SubmitPromptOptimizationParam) (*entity.PromptOptimizationRun, error)
+	// GetPromptOptimization get the status and candidates of a prompt optimization
+	GetPromptOptimization(ctx context.Context, runID string) (*entity.PromptOptimizationRun, error)
+	// WaitPromptOptimization poll the prompt optimization until it is finished or ctx is done
+	WaitPromptOptimization(ctx context.Context, runID string, options ...WaitEvalRunOption) (*entity.PromptOptimizationRun, error)
+}
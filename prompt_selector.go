@@ -0,0 +1,127 @@
+// Copyright (c) 2025 Bytedance Ltd. and/or its affiliates
+// SPDX-License-Identifier: MIT
+
+package cozeloop
+
+import (
+	"context"
+	"fmt"
+	"hash/fnv"
+
+	"github.com/coze-dev/cozeloop-go/entity"
+	"github.com/coze-dev/cozeloop-go/internal/consts"
+	"github.com/coze-dev/cozeloop-go/internal/prompt"
+)
+
+// Tags of prompt hub span set by PromptSelector.
+const (
+	TagPromptExperiment    = consts.PromptExperiment
+	TagPromptExperimentArm = consts.PromptExperimentArm
+)
+
+// PromptVariant is an arm of prompt A/B test.
+type PromptVariant struct {
+	// Name name of the arm, default is Label if set, otherwise Version
+	Name string
+	// Version version of prompt, optional
+	Version string
+	// Label label of prompt, optional
+	Label string
+	// Weight weight of the arm, the arm is selected with probability Weight / sum of all weights
+	Weight int
+}
+
+// PromptSelector selects a prompt variant per user for A/B test. The same user always gets the same variant,
+// as long as the experiment and variants are not changed.
+type PromptSelector struct {
+	client      PromptClient
+	experiment  string
+	promptKey   string
+	variants    []PromptVariant
+	totalWeight int
+}
+
+type PromptSelectorOption func(s *PromptSelector)
+
+// WithPromptSelectorClient set the client used to get prompt. Default is the default client.
+func WithPromptSelectorClient(client PromptClient) PromptSelectorOption {
+	return func(s *PromptSelector) {
+		s.client = client
+	}
+}
+
+// NewPromptSelector creates a PromptSelector of the prompt. The experiment name is used as hash salt, so users
+// are assigned independently in different experiments.
+func NewPromptSelector(experiment, promptKey string, variants []PromptVariant, opts ...PromptSelectorOption) (*PromptSelector, error) {
+	if promptKey == "" {
+		return nil, ErrInvalidParam.Wrap(fmt.Errorf("prompt key is required"))
+	}
+	s := &PromptSelector{
+		experiment: experiment,
+		promptKey:  promptKey,
+		variants:   make([]PromptVariant, 0, len(variants)),
+	}
+	for _, variant := range variants {
+		if variant.Weight < 0 {
+			return nil, ErrInvalidParam.Wrap(fmt.Errorf("weight of variant %q is negative", variant.Name))
+		}
+		if variant.Weight == 0 {
+			continue
+		}
+		if variant.Name == "" {
+			variant.Name = variant.Label
+			if variant.Name == "" {
+				variant.Name = variant.Version
+			}
+		}
+		s.variants = append(s.variants, variant)
+		s.totalWeight += variant.Weight
+	}
+	if s.totalWeight == 0 {
+		return nil, ErrInvalidParam.Wrap(fmt.Errorf("at least one variant with positive weight is required"))
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s, nil
+}
+
+// Select returns the variant of the user.
+func (s *PromptSelector) Select(userID string) PromptVariant {
+	h := fnv.New32a()
+	_, _ = h.Write([]byte(s.experiment + ":" + userID))
+	bucket := int(h.Sum32() % uint32(s.totalWeight))
+	for _, variant := range s.variants {
+		if bucket < variant.Weight {
+			return variant
+		}
+		bucket -= variant.Weight
+	}
+	return s.variants[len(s.variants)-1]
+}
+
+// GetPrompt gets the prompt of the variant selected for the user. The prompt hub span is tagged with
+// TagPromptExperiment and TagPromptExperimentArm if prompt trace is enabled by WithPromptTrace.
+func (s *PromptSelector) GetPrompt(ctx context.Context, userID string, options ...GetPromptOption) (*entity.Prompt, PromptVariant, error) {
+	variant := s.Select(userID)
+	options = append(options, func(option *prompt.GetPromptOptions) {
+		if option.SpanTags == nil {
+			option.SpanTags = make(map[string]any)
+		}
+		option.SpanTags[TagPromptExperiment] = s.experiment
+		option.SpanTags[TagPromptExperimentArm] = variant.Name
+	})
+	param := GetPromptParam{
+		PromptKey: s.promptKey,
+		Version:   variant.Version,
+		Label:     variant.Label,
+	}
+	var p *entity.Prompt
+	var err error
+	if s.client != nil {
+		p, err = s.client.GetPrompt(ctx, param, options...)
+	} else {
+		p, err = GetPrompt(ctx, param, options...)
+	}
+	return p, variant, err
+}
@@ -0,0 +1,74 @@
+// Copyright (c) 2025 Bytedance Ltd. and/or its affiliates
+// SPDX-License-Identifier: MIT
+
+package cozeloop
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+
+	"github.com/coze-dev/cozeloop-go/entity"
+	"github.com/coze-dev/cozeloop-go/internal/prompt"
+)
+
+type selectorPromptClient struct {
+	PromptClient
+	param   GetPromptParam
+	options prompt.GetPromptOptions
+}
+
+func (c *selectorPromptClient) GetPrompt(ctx context.Context, param GetPromptParam, options ...GetPromptOption) (*entity.Prompt, error) {
+	c.param = param
+	c.options = prompt.GetPromptOptions{}
+	for _, opt := range options {
+		opt(&c.options)
+	}
+	return &entity.Prompt{PromptKey: param.PromptKey, Version: param.Version}, nil
+}
+
+func TestPromptSelector(t *testing.T) {
+	variants := []PromptVariant{
+		{Label: "production", Weight: 90},
+		{Label: "beta", Weight: 10},
+	}
+
+	Convey("Test invalid variants", t, func() {
+		_, err := NewPromptSelector("exp", "", variants)
+		So(err, ShouldNotBeNil)
+		_, err = NewPromptSelector("exp", "key", []PromptVariant{{Label: "production"}})
+		So(err, ShouldNotBeNil)
+		_, err = NewPromptSelector("exp", "key", []PromptVariant{{Label: "production", Weight: -1}})
+		So(err, ShouldNotBeNil)
+	})
+
+	Convey("Test Select is deterministic and follows weights", t, func() {
+		selector, err := NewPromptSelector("exp", "key", variants)
+		So(err, ShouldBeNil)
+		So(selector.Select("user_1"), ShouldResemble, selector.Select("user_1"))
+
+		counts := map[string]int{}
+		for i := 0; i < 10000; i++ {
+			counts[selector.Select(fmt.Sprintf("user_%d", i)).Name]++
+		}
+		So(counts["production"], ShouldBeBetween, 8500, 9500)
+		So(counts["beta"], ShouldBeBetween, 500, 1500)
+	})
+
+	Convey("Test GetPrompt tags experiment arm", t, func() {
+		client := &selectorPromptClient{}
+		selector, err := NewPromptSelector("exp", "key", []PromptVariant{{Version: "0.0.2", Weight: 1}},
+			WithPromptSelectorClient(client))
+		So(err, ShouldBeNil)
+
+		p, variant, err := selector.GetPrompt(context.Background(), "user_1")
+		So(err, ShouldBeNil)
+		So(p.Version, ShouldEqual, "0.0.2")
+		So(variant.Name, ShouldEqual, "0.0.2")
+		So(client.param.PromptKey, ShouldEqual, "key")
+		So(client.options.SpanTags[TagPromptExperiment], ShouldEqual, "exp")
+		So(client.options.SpanTags[TagPromptExperimentArm], ShouldEqual, "0.0.2")
+	})
+}
@@ -0,0 +1,225 @@
+// Copyright (c) 2025 Bytedance Ltd. and/or its affiliates
+// SPDX-License-Identifier: MIT
+
+// Package promptgen generates Go code from the variable definitions of prompt, so application code binds to
+// prompts type-safely, and breaks at compile time when the variables of prompt are changed.
+// The generated struct is bound by `loop` tags, see entity.BindVariables. Use command cozeloop-promptgen
+// to pull the prompt and generate code by go:generate.
+package promptgen
+
+import (
+	"bytes"
+	"fmt"
+	"go/format"
+	"strings"
+	"unicode"
+
+	"github.com/coze-dev/cozeloop-go/entity"
+	"github.com/coze-dev/cozeloop-go/internal/util"
+)
+
+// Options of code generation.
+type Options struct {
+	// PackageName package of generated code. Required.
+	PackageName string
+	// TypeName prefix of generated identifiers. Default is the prompt key in CamelCase.
+	TypeName string
+	// Generator name of the generator in the header of generated code. Default is "cozeloop-promptgen".
+	Generator string
+}
+
+// commonInitialisms are kept upper case in identifiers, as golint suggests.
+var commonInitialisms = map[string]bool{
+	"API": true, "HTML": true, "HTTP": true, "ID": true, "IP": true, "JSON": true, "LLM": true,
+	"SQL": true, "URI": true, "URL": true, "UUID": true,
+}
+
+// Generate generates the Go code of prompt, including constants of the prompt key, version, variable keys and
+// placeholder keys, and a struct of the variables to be bound by cozeloop.WithVariableStruct or
+// entity.ExecuteParam.VariableStruct.
+func Generate(prompt *entity.Prompt, options Options) ([]byte, error) {
+	if prompt == nil {
+		return nil, fmt.Errorf("prompt is nil")
+	}
+	if prompt.PromptKey == "" {
+		return nil, fmt.Errorf("prompt key is empty")
+	}
+	if options.PackageName == "" {
+		return nil, fmt.Errorf("package name is empty")
+	}
+	typeName := options.TypeName
+	if typeName == "" {
+		typeName = GoName(prompt.PromptKey)
+	}
+	generator := options.Generator
+	if generator == "" {
+		generator = "cozeloop-promptgen"
+	}
+
+	var variableDefs []*entity.VariableDef
+	var placeholders []string
+	if prompt.PromptTemplate != nil {
+		for _, variableDef := range prompt.PromptTemplate.VariableDefs {
+			if variableDef != nil && variableDef.Key != "" {
+				variableDefs = append(variableDefs, variableDef)
+			}
+		}
+		for _, message := range prompt.PromptTemplate.Messages {
+			if message != nil && message.Role == entity.RolePlaceholder && util.PtrValue(message.Content) != "" {
+				placeholders = append(placeholders, util.PtrValue(message.Content))
+			}
+		}
+	}
+
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "// Code generated by %s. DO NOT EDIT.\n", generator)
+	fmt.Fprintf(&buf, "// Prompt: %s, version: %s\n\n", prompt.PromptKey, prompt.Version)
+	fmt.Fprintf(&buf, "package %s\n\n", options.PackageName)
+
+	needEntity := false
+	for _, variableDef := range variableDefs {
+		if strings.Contains(goType(variableDef.Type), "entity.") {
+			needEntity = true
+		}
+	}
+	if needEntity {
+		buf.WriteString("import \"github.com/coze-dev/cozeloop-go/entity\"\n\n")
+	}
+
+	fmt.Fprintf(&buf, "const (\n")
+	fmt.Fprintf(&buf, "\t// %sPromptKey is the key of prompt %s.\n", typeName, prompt.PromptKey)
+	fmt.Fprintf(&buf, "\t%sPromptKey = %q\n", typeName, prompt.PromptKey)
+	fmt.Fprintf(&buf, "\t// %sPromptVersion is the version of prompt %s, which the code is generated from.\n", typeName, prompt.PromptKey)
+	fmt.Fprintf(&buf, "\t%sPromptVersion = %q\n", typeName, prompt.Version)
+	fmt.Fprintf(&buf, ")\n\n")
+
+	fieldNames := uniqueNames(len(variableDefs), func(i int) string { return GoName(variableDefs[i].Key) })
+	if len(variableDefs) > 0 {
+		fmt.Fprintf(&buf, "// Variable keys of prompt %s.\n", prompt.PromptKey)
+		fmt.Fprintf(&buf, "const (\n")
+		for i, variableDef := range variableDefs {
+			writeComment(&buf, "\t", variableDef.Desc)
+			fmt.Fprintf(&buf, "\t%sVar%s = %q\n", typeName, fieldNames[i], variableDef.Key)
+		}
+		fmt.Fprintf(&buf, ")\n\n")
+	}
+
+	if len(placeholders) > 0 {
+		placeholderNames := uniqueNames(len(placeholders), func(i int) string { return GoName(placeholders[i]) })
+		fmt.Fprintf(&buf, "// Placeholder keys of prompt %s, the messages of placeholder are passed as variable of the key.\n", prompt.PromptKey)
+		fmt.Fprintf(&buf, "const (\n")
+		for i, placeholder := range placeholders {
+			fmt.Fprintf(&buf, "\t%sPlaceholder%s = %q\n", typeName, placeholderNames[i], placeholder)
+		}
+		fmt.Fprintf(&buf, ")\n\n")
+	}
+
+	fmt.Fprintf(&buf, "// %sVariables is the variables of prompt %s, to be bound by cozeloop.WithVariableStruct\n", typeName, prompt.PromptKey)
+	fmt.Fprintf(&buf, "// or entity.ExecuteParam.VariableStruct.\n")
+	fmt.Fprintf(&buf, "type %sVariables struct {\n", typeName)
+	for i, variableDef := range variableDefs {
+		writeComment(&buf, "\t", variableDef.Desc)
+		fmt.Fprintf(&buf, "\t%s %s `loop:%q`\n", fieldNames[i], goType(variableDef.Type), variableDef.Key)
+	}
+	fmt.Fprintf(&buf, "}\n")
+
+	src, err := format.Source(buf.Bytes())
+	if err != nil {
+		return nil, fmt.Errorf("format generated code failed: %w", err)
+	}
+	return src, nil
+}
+
+// goType returns the Go type of variable, which passes the type validation of PromptFormat.
+func goType(variableType entity.VariableType) string {
+	switch variableType {
+	case entity.VariableTypeString:
+		return "string"
+	case entity.VariableTypeBoolean:
+		return "bool"
+	case entity.VariableTypeInteger:
+		return "int64"
+	case entity.VariableTypeFloat:
+		return "float64"
+	case entity.VariableTypeArrayString:
+		return "[]string"
+	case entity.VariableTypeArrayBoolean:
+		return "[]bool"
+	case entity.VariableTypeArrayInteger:
+		return "[]int64"
+	case entity.VariableTypeArrayFloat:
+		return "[]float64"
+	case entity.VariableTypeArrayObject:
+		return "[]any"
+	case entity.VariableTypePlaceholder:
+		return "[]*entity.Message"
+	case entity.VariableTypeMultiPart:
+		return "[]*entity.ContentPart"
+	default:
+		return "any"
+	}
+}
+
+// GoName converts the key of prompt or variable to an exported Go identifier in CamelCase,
+// e.g. "user_id" to "UserID", "customer-service" to "CustomerService".
+func GoName(key string) string {
+	var words []string
+	var word []rune
+	flush := func() {
+		if len(word) > 0 {
+			words = append(words, string(word))
+			word = nil
+		}
+	}
+	for _, r := range key {
+		if !unicode.IsLetter(r) && !unicode.IsDigit(r) {
+			flush()
+			continue
+		}
+		// split camel case, e.g. "userName" to "user" and "Name"
+		if unicode.IsUpper(r) && len(word) > 0 && unicode.IsLower(word[len(word)-1]) {
+			flush()
+		}
+		word = append(word, r)
+	}
+	flush()
+
+	var b strings.Builder
+	for _, w := range words {
+		if upper := strings.ToUpper(w); commonInitialisms[upper] {
+			b.WriteString(upper)
+			continue
+		}
+		runes := []rune(w)
+		b.WriteRune(unicode.ToUpper(runes[0]))
+		b.WriteString(string(runes[1:]))
+	}
+	name := b.String()
+	if name == "" || !unicode.IsLetter([]rune(name)[0]) {
+		name = "V" + name
+	}
+	return name
+}
+
+// uniqueNames returns the names of n items, appending a number to the duplicated ones.
+func uniqueNames(n int, name func(i int) string) []string {
+	names := make([]string, n)
+	used := make(map[string]bool, n)
+	for i := 0; i < n; i++ {
+		base := name(i)
+		names[i] = base
+		for k := 2; used[names[i]]; k++ {
+			names[i] = fmt.Sprintf("%s%d", base, k)
+		}
+		used[names[i]] = true
+	}
+	return names
+}
+
+func writeComment(buf *bytes.Buffer, indent, comment string) {
+	for _, line := range strings.Split(strings.TrimSpace(comment), "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			fmt.Fprintf(buf, "%s// %s\n", indent, line)
+		}
+	}
+}
@@ -0,0 +1,76 @@
+// Copyright (c) 2025 Bytedance Ltd. and/or its affiliates
+// SPDX-License-Identifier: MIT
+
+package promptgen
+
+import (
+	"strings"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+
+	"github.com/coze-dev/cozeloop-go/entity"
+	"github.com/coze-dev/cozeloop-go/internal/util"
+)
+
+func TestGenerate(t *testing.T) {
+	Convey("Test Generate", t, func() {
+		prompt := &entity.Prompt{
+			PromptKey: "customer_service",
+			Version:   "0.0.1",
+			PromptTemplate: &entity.PromptTemplate{
+				Messages: []*entity.Message{
+					{Role: entity.RoleSystem, Content: util.Ptr("You are serving {{user_id}}")},
+					{Role: entity.RolePlaceholder, Content: util.Ptr("history")},
+				},
+				VariableDefs: []*entity.VariableDef{
+					{Key: "user_id", Desc: "id of user", Type: entity.VariableTypeString},
+					{Key: "topK", Type: entity.VariableTypeInteger},
+					{Key: "history", Type: entity.VariableTypePlaceholder},
+					{Key: "profile", Type: entity.VariableTypeObject},
+				},
+			},
+		}
+
+		Convey("When prompt has variables and placeholders", func() {
+			src, err := Generate(prompt, Options{PackageName: "prompts"})
+			So(err, ShouldBeNil)
+			// ignore the alignment of gofmt
+			code := strings.Join(strings.Fields(string(src)), " ")
+			So(code, ShouldStartWith, "// Code generated by cozeloop-promptgen. DO NOT EDIT.")
+			So(code, ShouldContainSubstring, "package prompts")
+			So(code, ShouldContainSubstring, `import "github.com/coze-dev/cozeloop-go/entity"`)
+			So(code, ShouldContainSubstring, `CustomerServicePromptKey = "customer_service"`)
+			So(code, ShouldContainSubstring, `CustomerServicePromptVersion = "0.0.1"`)
+			So(code, ShouldContainSubstring, `CustomerServiceVarUserID = "user_id"`)
+			So(code, ShouldContainSubstring, `CustomerServicePlaceholderHistory = "history"`)
+			So(code, ShouldContainSubstring, "type CustomerServiceVariables struct {")
+			So(code, ShouldContainSubstring, "// id of user UserID string `loop:\"user_id\"`")
+			So(code, ShouldContainSubstring, "TopK int64 `loop:\"topK\"`")
+			So(code, ShouldContainSubstring, "History []*entity.Message `loop:\"history\"`")
+			So(code, ShouldContainSubstring, "Profile any `loop:\"profile\"`")
+		})
+
+		Convey("When type name is set and entity is not used", func() {
+			src, err := Generate(&entity.Prompt{PromptKey: "demo", Version: "1"}, Options{PackageName: "prompts", TypeName: "Demo2"})
+			So(err, ShouldBeNil)
+			So(string(src), ShouldNotContainSubstring, "import")
+			So(string(src), ShouldContainSubstring, "type Demo2Variables struct {")
+		})
+
+		Convey("When package name is empty", func() {
+			_, err := Generate(prompt, Options{})
+			So(err, ShouldNotBeNil)
+		})
+	})
+}
+
+func TestGoName(t *testing.T) {
+	Convey("Test GoName", t, func() {
+		So(GoName("user_id"), ShouldEqual, "UserID")
+		So(GoName("customer-service"), ShouldEqual, "CustomerService")
+		So(GoName("userName"), ShouldEqual, "UserName")
+		So(GoName("1st"), ShouldEqual, "V1st")
+		So(GoName(""), ShouldEqual, "V")
+	})
+}
@@ -0,0 +1,214 @@
+// Copyright (c) 2025 Bytedance Ltd. and/or its affiliates
+// SPDX-License-Identifier: MIT
+
+// Package qwenconv converts prompts of cozeloop to requests of Qwen models on DashScope generation API.
+// It does not depend on any DashScope SDK, the types are the same as the generation API in JSON.
+// For the OpenAI compatible mode of DashScope, and other OpenAI compatible APIs such as Volc ARK,
+// use package openaiconv instead.
+package qwenconv
+
+import (
+	"encoding/json"
+
+	"github.com/coze-dev/cozeloop-go/entity"
+	"github.com/coze-dev/cozeloop-go/internal/util"
+)
+
+const (
+	ResultFormatMessage = "message"
+
+	ResponseFormatTypeJSONObject = "json_object"
+
+	ToolTypeFunction = "function"
+)
+
+// GenerationRequest is the request of DashScope generation API, for both text generation
+// and multimodal generation of Qwen models.
+type GenerationRequest struct {
+	Model      string      `json:"model"`
+	Input      Input       `json:"input"`
+	Parameters *Parameters `json:"parameters,omitempty"`
+}
+
+type Input struct {
+	Messages []Message `json:"messages"`
+}
+
+// Message is a message of generation. Content and MultiContent are both marshaled to the content field,
+// only one of them can be set. MultiContent is only supported by multimodal models, such as qwen-vl.
+type Message struct {
+	Role         string        `json:"role"`
+	Content      string        `json:"content"`
+	MultiContent []ContentItem `json:"-"`
+	ToolCalls    []ToolCall    `json:"tool_calls,omitempty"`
+	ToolCallID   string        `json:"tool_call_id,omitempty"`
+}
+
+type message Message
+
+func (m Message) MarshalJSON() ([]byte, error) {
+	if len(m.MultiContent) == 0 {
+		return json.Marshal(message(m))
+	}
+	return json.Marshal(struct {
+		message
+		MultiContent []ContentItem `json:"content"`
+	}{
+		message:      message(m),
+		MultiContent: m.MultiContent,
+	})
+}
+
+// ContentItem is an item of multimodal content, only one of the fields can be set.
+type ContentItem struct {
+	Text  string `json:"text,omitempty"`
+	Image string `json:"image,omitempty"`
+}
+
+type ToolCall struct {
+	ID       string       `json:"id,omitempty"`
+	Type     string       `json:"type"`
+	Function FunctionCall `json:"function"`
+}
+
+type FunctionCall struct {
+	Name      string `json:"name"`
+	Arguments string `json:"arguments,omitempty"`
+}
+
+type Parameters struct {
+	ResultFormat    string          `json:"result_format,omitempty"`
+	MaxTokens       *int            `json:"max_tokens,omitempty"`
+	Temperature     *float64        `json:"temperature,omitempty"`
+	TopP            *float64        `json:"top_p,omitempty"`
+	TopK            *int            `json:"top_k,omitempty"`
+	PresencePenalty *float64        `json:"presence_penalty,omitempty"`
+	ResponseFormat  *ResponseFormat `json:"response_format,omitempty"`
+	Tools           []Tool          `json:"tools,omitempty"`
+	// ToolChoice is "auto" or "none"
+	ToolChoice string `json:"tool_choice,omitempty"`
+}
+
+type ResponseFormat struct {
+	Type string `json:"type"`
+}
+
+type Tool struct {
+	Type     string             `json:"type"`
+	Function FunctionDefinition `json:"function"`
+}
+
+type FunctionDefinition struct {
+	Name        string          `json:"name"`
+	Description string          `json:"description,omitempty"`
+	Parameters  json.RawMessage `json:"parameters,omitempty"`
+}
+
+// ToGenerationRequest converts the messages formatted from prompt by PromptFormat, with the tools, tool call config
+// and model config of prompt, to a generation request. Model of request is not set, and the result format is
+// message, which is required by tool calling. Reasoning content and frequency penalty are dropped,
+// as they are not supported.
+func ToGenerationRequest(prompt *entity.Prompt, messages []*entity.Message) *GenerationRequest {
+	req := &GenerationRequest{
+		Input: Input{Messages: ToMessages(messages)},
+		Parameters: &Parameters{
+			ResultFormat: ResultFormatMessage,
+		},
+	}
+	if prompt == nil {
+		return req
+	}
+	params := req.Parameters
+	params.Tools = toTools(prompt.Tools)
+	if prompt.ToolCallConfig != nil && prompt.ToolCallConfig.ToolChoice != "" && len(params.Tools) > 0 {
+		params.ToolChoice = string(prompt.ToolCallConfig.ToolChoice)
+	}
+	if config := prompt.LLMConfig; config != nil {
+		if config.MaxTokens != nil {
+			params.MaxTokens = util.Ptr(int(*config.MaxTokens))
+		}
+		if config.TopK != nil {
+			params.TopK = util.Ptr(int(*config.TopK))
+		}
+		params.Temperature = config.Temperature
+		params.TopP = config.TopP
+		params.PresencePenalty = config.PresencePenalty
+		if util.PtrValue(config.JSONMode) {
+			params.ResponseFormat = &ResponseFormat{Type: ResponseFormatTypeJSONObject}
+		}
+	}
+	return req
+}
+
+// ToMessages converts messages to generation messages. Placeholder messages and multi-part variables,
+// which should have been replaced by PromptFormat, are dropped.
+func ToMessages(messages []*entity.Message) []Message {
+	result := make([]Message, 0, len(messages))
+	for _, message := range messages {
+		if message == nil || message.Role == entity.RolePlaceholder {
+			continue
+		}
+		result = append(result, toMessage(message))
+	}
+	return result
+}
+
+func toMessage(message *entity.Message) Message {
+	msg := Message{
+		Role:       string(message.Role),
+		Content:    util.PtrValue(message.Content),
+		ToolCallID: util.PtrValue(message.ToolCallID),
+	}
+	if len(message.Parts) > 0 {
+		if msg.Content != "" {
+			msg.MultiContent = append(msg.MultiContent, ContentItem{Text: msg.Content})
+			msg.Content = ""
+		}
+		for _, part := range message.Parts {
+			if part == nil {
+				continue
+			}
+			switch part.Type {
+			case entity.ContentTypeText:
+				msg.MultiContent = append(msg.MultiContent, ContentItem{Text: util.PtrValue(part.Text)})
+			case entity.ContentTypeImageURL:
+				msg.MultiContent = append(msg.MultiContent, ContentItem{Image: util.PtrValue(part.ImageURL)})
+			case entity.ContentTypeBase64Data:
+				msg.MultiContent = append(msg.MultiContent, ContentItem{Image: util.PtrValue(part.Base64Data)})
+			default:
+			}
+		}
+	}
+	for _, toolCall := range message.ToolCalls {
+		if toolCall == nil || toolCall.FunctionCall == nil {
+			continue
+		}
+		msg.ToolCalls = append(msg.ToolCalls, ToolCall{
+			ID:   toolCall.ID,
+			Type: ToolTypeFunction,
+			Function: FunctionCall{
+				Name:      toolCall.FunctionCall.Name,
+				Arguments: util.PtrValue(toolCall.FunctionCall.Arguments),
+			},
+		})
+	}
+	return msg
+}
+
+func toTools(tools []*entity.Tool) []Tool {
+	var result []Tool
+	for _, tool := range tools {
+		if tool == nil || tool.Function == nil {
+			continue
+		}
+		function := FunctionDefinition{
+			Name:        tool.Function.Name,
+			Description: util.PtrValue(tool.Function.Description),
+		}
+		if parameters := util.PtrValue(tool.Function.Parameters); parameters != "" && json.Valid([]byte(parameters)) {
+			function.Parameters = json.RawMessage(parameters)
+		}
+		result = append(result, Tool{Type: ToolTypeFunction, Function: function})
+	}
+	return result
+}
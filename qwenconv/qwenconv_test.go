@@ -0,0 +1,43 @@
+// Copyright (c) 2025 Bytedance Ltd. and/or its affiliates
+// SPDX-License-Identifier: MIT
+
+package qwenconv
+
+import (
+	"encoding/json"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+
+	"github.com/coze-dev/cozeloop-go/entity"
+	"github.com/coze-dev/cozeloop-go/internal/util"
+)
+
+func TestToGenerationRequest(t *testing.T) {
+	Convey("Test convert prompt to request", t, func() {
+		prompt := &entity.Prompt{
+			LLMConfig: &entity.LLMConfig{
+				MaxTokens: util.Ptr(int32(512)),
+				TopK:      util.Ptr(int32(10)),
+				JSONMode:  util.Ptr(true),
+			},
+		}
+		messages := []*entity.Message{
+			{Role: entity.RoleSystem, Content: util.Ptr("You are a helpful assistant.")},
+			{Role: entity.RoleUser, Content: util.Ptr("what is it?"), Parts: []*entity.ContentPart{
+				{Type: entity.ContentTypeImageURL, ImageURL: util.Ptr("https://example.com/a.png")},
+			}},
+		}
+
+		req := ToGenerationRequest(prompt, messages)
+		So(req.Parameters.ResultFormat, ShouldEqual, ResultFormatMessage)
+		So(*req.Parameters.MaxTokens, ShouldEqual, 512)
+		So(*req.Parameters.TopK, ShouldEqual, 10)
+		So(req.Parameters.ResponseFormat.Type, ShouldEqual, ResponseFormatTypeJSONObject)
+
+		data, err := json.Marshal(req)
+		So(err, ShouldBeNil)
+		So(string(data), ShouldContainSubstring, `{"role":"system","content":"You are a helpful assistant."}`)
+		So(string(data), ShouldContainSubstring, `"content":[{"text":"what is it?"},{"image":"https://example.com/a.png"}]`)
+	})
+}
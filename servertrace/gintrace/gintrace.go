@@ -0,0 +1,44 @@
+// Copyright (c) 2025 Bytedance Ltd. and/or its affiliates
+// SPDX-License-Identifier: MIT
+
+// Package gintrace is the servertrace middleware of Gin. It is a separate module, so that the core module of
+// cozeloop does not depend on Gin.
+package gintrace
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/coze-dev/cozeloop-go/servertrace"
+)
+
+// Middleware starts a root span for every request and finishes it after the handlers return. The span is in the
+// context of request, so handlers can start child spans from c.Request.Context(). The last error of c.Errors is
+// recorded as error of span. A panic of handlers is recorded as error of span, and then panics again, so use it
+// after gin.Recovery.
+func Middleware(opts ...servertrace.Option) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx, span := servertrace.Start(c.Request.Context(), servertrace.Request{
+			Method: c.Request.Method,
+			Route:  c.FullPath(),
+			Path:   c.Request.URL.Path,
+			Header: c.GetHeader,
+			Raw:    c,
+		}, opts...)
+		c.Request = c.Request.WithContext(ctx)
+		defer func() {
+			if e := recover(); e != nil {
+				servertrace.Finish(ctx, span, http.StatusInternalServerError, fmt.Errorf("panic: %v", e))
+				panic(e)
+			}
+			var err error
+			if last := c.Errors.Last(); last != nil {
+				err = last
+			}
+			servertrace.Finish(ctx, span, c.Writer.Status(), err)
+		}()
+		c.Next()
+	}
+}
@@ -0,0 +1,97 @@
+// Copyright (c) 2025 Bytedance Ltd. and/or its affiliates
+// SPDX-License-Identifier: MIT
+
+package gintrace
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	. "github.com/smartystreets/goconvey/convey"
+
+	"github.com/coze-dev/cozeloop-go"
+	"github.com/coze-dev/cozeloop-go/entity"
+	"github.com/coze-dev/cozeloop-go/servertrace"
+)
+
+type spanRecorder struct {
+	mu    sync.Mutex
+	spans []*entity.UploadSpan
+}
+
+func (r *spanRecorder) ExportSpans(ctx context.Context, spans []*entity.UploadSpan) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.spans = append(r.spans, spans...)
+	return nil
+}
+
+func (r *spanRecorder) ExportFiles(ctx context.Context, files []*entity.UploadFile) error {
+	return nil
+}
+
+func (r *spanRecorder) find(name string) *entity.UploadSpan {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for i := len(r.spans) - 1; i >= 0; i-- {
+		if r.spans[i].SpanName == name {
+			return r.spans[i]
+		}
+	}
+	return nil
+}
+
+func TestMiddleware(t *testing.T) {
+	ctx := context.Background()
+	recorder := &spanRecorder{}
+	client, err := cozeloop.NewClient(
+		cozeloop.WithWorkspaceID("gintrace"),
+		cozeloop.WithAPIToken("token"),
+		cozeloop.WithExporter(recorder),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	gin.SetMode(gin.TestMode)
+	engine := gin.New()
+	engine.Use(Middleware(servertrace.WithClient(client)))
+	engine.GET("/users/:id", func(c *gin.Context) {
+		_, child := client.StartSpan(c.Request.Context(), "child", "custom")
+		child.Finish(c.Request.Context())
+		c.String(http.StatusOK, "ok")
+	})
+	engine.POST("/error", func(c *gin.Context) {
+		_ = c.Error(errors.New("backend unavailable"))
+		c.Status(http.StatusBadGateway)
+	})
+
+	Convey("Test root span is named by route and is the parent of spans of handler", t, func() {
+		w := httptest.NewRecorder()
+		engine.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/users/1", nil))
+		So(w.Code, ShouldEqual, http.StatusOK)
+
+		client.Flush(ctx)
+		span := recorder.find("GET /users/:id")
+		So(span, ShouldNotBeNil)
+		So(span.SpanType, ShouldEqual, servertrace.SpanTypeServer)
+		So(span.TagsString[servertrace.TagHTTPPath], ShouldEqual, "/users/1")
+		So(span.TagsLong[servertrace.TagHTTPStatusCode], ShouldEqual, http.StatusOK)
+		So(recorder.find("child").ParentID, ShouldEqual, span.SpanID)
+	})
+
+	Convey("Test error of gin context is recorded", t, func() {
+		w := httptest.NewRecorder()
+		engine.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/error", nil))
+		So(w.Code, ShouldEqual, http.StatusBadGateway)
+
+		client.Flush(ctx)
+		span := recorder.find("POST /error")
+		So(span, ShouldNotBeNil)
+		So(span.StatusCode, ShouldEqual, http.StatusBadGateway)
+	})
+}
@@ -0,0 +1,14 @@
+module github.com/coze-dev/cozeloop-go/servertrace/gintrace
+
+go 1.20
+
+require (
+	github.com/coze-dev/cozeloop-go v0.1.19
+	github.com/gin-gonic/gin v1.9.1
+	github.com/smartystreets/goconvey v1.8.1
+)
+
+replace (
+	github.com/coze-dev/cozeloop-go => ../..
+	github.com/coze-dev/cozeloop-go/spec => ../../spec
+)
@@ -0,0 +1,14 @@
+module github.com/coze-dev/cozeloop-go/servertrace/hertztrace
+
+go 1.18
+
+require (
+	github.com/cloudwego/hertz v0.7.3
+	github.com/coze-dev/cozeloop-go v0.1.19
+	github.com/smartystreets/goconvey v1.8.1
+)
+
+replace (
+	github.com/coze-dev/cozeloop-go => ../..
+	github.com/coze-dev/cozeloop-go/spec => ../../spec
+)
@@ -0,0 +1,44 @@
+// Copyright (c) 2025 Bytedance Ltd. and/or its affiliates
+// SPDX-License-Identifier: MIT
+
+// Package hertztrace is the servertrace middleware of Hertz. It is a separate module, so that the core module of
+// cozeloop does not depend on Hertz.
+package hertztrace
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+
+	"github.com/cloudwego/hertz/pkg/app"
+
+	"github.com/coze-dev/cozeloop-go/servertrace"
+)
+
+// Middleware starts a root span for every request and finishes it after the handlers return. The span is in the
+// ctx passed to the next handlers, so they can start child spans from it. The last error of c.Errors is recorded
+// as error of span. A panic of handlers is recorded as error of span, and then panics again, so use it after
+// recovery.Recovery.
+func Middleware(opts ...servertrace.Option) app.HandlerFunc {
+	return func(ctx context.Context, c *app.RequestContext) {
+		ctx, span := servertrace.Start(ctx, servertrace.Request{
+			Method: string(c.Method()),
+			Route:  c.FullPath(),
+			Path:   string(c.Path()),
+			Header: func(key string) string { return string(c.GetHeader(key)) },
+			Raw:    c,
+		}, opts...)
+		defer func() {
+			if e := recover(); e != nil {
+				servertrace.Finish(ctx, span, http.StatusInternalServerError, fmt.Errorf("panic: %v", e))
+				panic(e)
+			}
+			var err error
+			if last := c.Errors.Last(); last != nil {
+				err = last
+			}
+			servertrace.Finish(ctx, span, c.Response.StatusCode(), err)
+		}()
+		c.Next(ctx)
+	}
+}
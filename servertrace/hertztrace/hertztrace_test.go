@@ -0,0 +1,96 @@
+// Copyright (c) 2025 Bytedance Ltd. and/or its affiliates
+// SPDX-License-Identifier: MIT
+
+package hertztrace
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"sync"
+	"testing"
+
+	"github.com/cloudwego/hertz/pkg/app"
+	"github.com/cloudwego/hertz/pkg/common/config"
+	"github.com/cloudwego/hertz/pkg/common/ut"
+	"github.com/cloudwego/hertz/pkg/route"
+	. "github.com/smartystreets/goconvey/convey"
+
+	"github.com/coze-dev/cozeloop-go"
+	"github.com/coze-dev/cozeloop-go/entity"
+	"github.com/coze-dev/cozeloop-go/servertrace"
+)
+
+type spanRecorder struct {
+	mu    sync.Mutex
+	spans []*entity.UploadSpan
+}
+
+func (r *spanRecorder) ExportSpans(ctx context.Context, spans []*entity.UploadSpan) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.spans = append(r.spans, spans...)
+	return nil
+}
+
+func (r *spanRecorder) ExportFiles(ctx context.Context, files []*entity.UploadFile) error {
+	return nil
+}
+
+func (r *spanRecorder) find(name string) *entity.UploadSpan {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for i := len(r.spans) - 1; i >= 0; i-- {
+		if r.spans[i].SpanName == name {
+			return r.spans[i]
+		}
+	}
+	return nil
+}
+
+func TestMiddleware(t *testing.T) {
+	ctx := context.Background()
+	recorder := &spanRecorder{}
+	client, err := cozeloop.NewClient(
+		cozeloop.WithWorkspaceID("hertztrace"),
+		cozeloop.WithAPIToken("token"),
+		cozeloop.WithExporter(recorder),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	engine := route.NewEngine(config.NewOptions([]config.Option{}))
+	engine.Use(Middleware(servertrace.WithClient(client)))
+	engine.GET("/users/:id", func(ctx context.Context, c *app.RequestContext) {
+		_, child := client.StartSpan(ctx, "child", "custom")
+		child.Finish(ctx)
+		c.String(http.StatusOK, "ok")
+	})
+	engine.POST("/error", func(ctx context.Context, c *app.RequestContext) {
+		_ = c.Error(errors.New("backend unavailable"))
+		c.Status(http.StatusBadGateway)
+	})
+
+	Convey("Test root span is named by route and is the parent of spans of handler", t, func() {
+		w := ut.PerformRequest(engine, http.MethodGet, "/users/1", nil)
+		So(w.Result().StatusCode(), ShouldEqual, http.StatusOK)
+
+		client.Flush(ctx)
+		span := recorder.find("GET /users/:id")
+		So(span, ShouldNotBeNil)
+		So(span.SpanType, ShouldEqual, servertrace.SpanTypeServer)
+		So(span.TagsString[servertrace.TagHTTPPath], ShouldEqual, "/users/1")
+		So(span.TagsLong[servertrace.TagHTTPStatusCode], ShouldEqual, http.StatusOK)
+		So(recorder.find("child").ParentID, ShouldEqual, span.SpanID)
+	})
+
+	Convey("Test error of request context is recorded", t, func() {
+		w := ut.PerformRequest(engine, http.MethodPost, "/error", nil)
+		So(w.Result().StatusCode(), ShouldEqual, http.StatusBadGateway)
+
+		client.Flush(ctx)
+		span := recorder.find("POST /error")
+		So(span, ShouldNotBeNil)
+		So(span.StatusCode, ShouldEqual, http.StatusBadGateway)
+	})
+}
@@ -0,0 +1,133 @@
+// Copyright (c) 2025 Bytedance Ltd. and/or its affiliates
+// SPDX-License-Identifier: MIT
+
+package servertrace
+
+import (
+	"bufio"
+	"fmt"
+	"net"
+	"net/http"
+)
+
+// Middleware wraps handler of net/http, it starts a root span for every request and finishes it after handler
+// returns. The span is in the context of request, so handler can start child spans from r.Context(). A panic
+// of handler is recorded as error of span, and then panics again.
+func Middleware(handler http.Handler, opts ...Option) http.Handler {
+	o := newOptions(opts)
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		req := Request{
+			Method: r.Method,
+			Path:   r.URL.Path,
+			Header: r.Header.Get,
+			Raw:    r,
+		}
+		if o.route != nil {
+			req.Route = o.route(r)
+		}
+		ctx, span := start(r.Context(), req, o)
+		ww, rw := wrapResponseWriter(w)
+		defer func() {
+			if e := recover(); e != nil {
+				Finish(ctx, span, http.StatusInternalServerError, fmt.Errorf("panic: %v", e))
+				panic(e)
+			}
+			Finish(ctx, span, rw.status(), nil)
+		}()
+		handler.ServeHTTP(ww, r.WithContext(ctx))
+	})
+}
+
+// responseWriter records the status code written by handler.
+type responseWriter struct {
+	http.ResponseWriter
+	statusCode int
+}
+
+func (w *responseWriter) WriteHeader(statusCode int) {
+	if w.statusCode == 0 {
+		w.statusCode = statusCode
+	}
+	w.ResponseWriter.WriteHeader(statusCode)
+}
+
+func (w *responseWriter) Write(b []byte) (int, error) {
+	if w.statusCode == 0 {
+		w.statusCode = http.StatusOK
+	}
+	return w.ResponseWriter.Write(b)
+}
+
+func (w *responseWriter) flush() {
+	if w.statusCode == 0 {
+		w.statusCode = http.StatusOK
+	}
+	w.ResponseWriter.(http.Flusher).Flush()
+}
+
+func (w *responseWriter) hijack() (net.Conn, *bufio.ReadWriter, error) {
+	conn, buf, err := w.ResponseWriter.(http.Hijacker).Hijack()
+	if err == nil && w.statusCode == 0 {
+		// the response is written by handler on the connection, such as websocket
+		w.statusCode = http.StatusSwitchingProtocols
+	}
+	return conn, buf, err
+}
+
+// Unwrap is used by http.ResponseController to access the underlying writer.
+func (w *responseWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+func (w *responseWriter) status() int {
+	if w.statusCode == 0 {
+		return http.StatusOK
+	}
+	return w.statusCode
+}
+
+// wrapResponseWriter wraps w to record the status code. The wrapper implements http.Flusher and http.Hijacker only
+// if w does, so that handlers checking them, such as for Server-Sent Events and websocket, work as without it.
+func wrapResponseWriter(w http.ResponseWriter) (http.ResponseWriter, *responseWriter) {
+	rw := &responseWriter{ResponseWriter: w}
+	_, isFlusher := w.(http.Flusher)
+	_, isHijacker := w.(http.Hijacker)
+	switch {
+	case isFlusher && isHijacker:
+		return flushHijackResponseWriter{rw}, rw
+	case isFlusher:
+		return flushResponseWriter{rw}, rw
+	case isHijacker:
+		return hijackResponseWriter{rw}, rw
+	default:
+		return rw, rw
+	}
+}
+
+type flushResponseWriter struct {
+	*responseWriter
+}
+
+func (w flushResponseWriter) Flush() {
+	w.flush()
+}
+
+type hijackResponseWriter struct {
+	*responseWriter
+}
+
+func (w hijackResponseWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	return w.hijack()
+}
+
+type flushHijackResponseWriter struct {
+	*responseWriter
+}
+
+func (w flushHijackResponseWriter) Flush() {
+	w.flush()
+}
+
+func (w flushHijackResponseWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	return w.hijack()
+}
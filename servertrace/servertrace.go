@@ -0,0 +1,173 @@
+// Copyright (c) 2025 Bytedance Ltd. and/or its affiliates
+// SPDX-License-Identifier: MIT
+
+// Package servertrace starts a root span per incoming request of a web server. The span continues the trace
+// propagated by the upstream service in request headers, records the route, method and status code of request,
+// and is finished when the response is written.
+//
+// It does not depend on any web framework. Middleware wraps an http.Handler, and the middleware of Gin and Hertz
+// are in the submodules gintrace and hertztrace, so that this module does not depend on them. The middleware of
+// other frameworks is a few lines around Start and Finish.
+package servertrace
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+
+	"github.com/coze-dev/cozeloop-go"
+	"github.com/coze-dev/cozeloop-go/internal/consts"
+)
+
+// SpanTypeServer type of the root span of request.
+const SpanTypeServer = "server"
+
+// Tags for server span.
+const (
+	TagHTTPMethod     = "http.method"
+	TagHTTPRoute      = "http.route"
+	TagHTTPPath       = "http.path"
+	TagHTTPStatusCode = "http.status_code"
+)
+
+// Request describes an incoming request, independent of the web framework.
+type Request struct {
+	// Method http method of request, such as GET
+	Method string
+	// Route route pattern matched by request, such as /users/:id, which is used in span name. Path is used if it
+	// is empty, but it may make too many different span names.
+	Route string
+	// Path path of request
+	Path string
+	// Header returns the value of request header, which is used to continue the trace of upstream service
+	Header func(key string) string
+	// Raw the request of framework, such as *http.Request or *gin.Context, which is passed to UserIDExtractor
+	Raw any
+}
+
+// UserIDExtractor returns the user id of request, empty if it is unknown.
+type UserIDExtractor func(ctx context.Context, req *Request) string
+
+type options struct {
+	client          cozeloop.TraceClient
+	spanType        string
+	userIDExtractor UserIDExtractor
+	route           func(r *http.Request) string
+}
+
+type Option func(o *options)
+
+// WithClient set the client used to start spans. Default is the default client of cozeloop.
+func WithClient(client cozeloop.TraceClient) Option {
+	return func(o *options) {
+		o.client = client
+	}
+}
+
+// WithSpanType set the type of the root span. Default is SpanTypeServer.
+func WithSpanType(spanType string) Option {
+	return func(o *options) {
+		o.spanType = spanType
+	}
+}
+
+// WithUserIDExtractor set the func to get user id of request, such as from the session or token. The user id is
+// set as baggage, so it is passed to all child spans and downstream services. Default is nil.
+func WithUserIDExtractor(extractor UserIDExtractor) Option {
+	return func(o *options) {
+		o.userIDExtractor = extractor
+	}
+}
+
+// WithRoute set the func to get route pattern of request, which is only used by Middleware, as the router of
+// net/http before go1.22 doesn't expose it. Default is nil, and path of request is used in span name.
+func WithRoute(route func(r *http.Request) string) Option {
+	return func(o *options) {
+		o.route = route
+	}
+}
+
+// Start starts the root span of request, which continues the trace in request headers if there is one.
+// Finish must be called with the returned ctx and span after the request is handled.
+func Start(ctx context.Context, req Request, opts ...Option) (context.Context, cozeloop.Span) {
+	return start(ctx, req, newOptions(opts))
+}
+
+func start(ctx context.Context, req Request, o *options) (context.Context, cozeloop.Span) {
+	var startOpts []cozeloop.StartSpanOption
+	if req.Header != nil {
+		if parent := getSpanFromHeader(ctx, o.client, req.Header); parent != nil && parent.GetTraceID() != "" {
+			startOpts = append(startOpts, cozeloop.WithChildOf(parent))
+		}
+	}
+	route := req.Route
+	if route == "" {
+		route = req.Path
+	}
+	name := req.Method + " " + route
+	var span cozeloop.Span
+	if o.client != nil {
+		ctx, span = o.client.StartSpan(ctx, name, o.spanType, startOpts...)
+	} else {
+		ctx, span = cozeloop.StartSpan(ctx, name, o.spanType, startOpts...)
+	}
+
+	tags := map[string]any{
+		TagHTTPMethod: req.Method,
+		TagHTTPPath:   req.Path,
+	}
+	if req.Route != "" {
+		tags[TagHTTPRoute] = req.Route
+	}
+	span.SetTags(ctx, tags)
+	if o.userIDExtractor != nil {
+		if userID := o.userIDExtractor(ctx, &req); userID != "" {
+			span.SetUserIDBaggage(ctx, userID)
+		}
+	}
+	return ctx, span
+}
+
+// Finish records the status code and error of request, and finishes the span. Requests with status code 5xx or
+// error are marked as failed, 4xx are not as they are caused by the client.
+func Finish(ctx context.Context, span cozeloop.Span, statusCode int, err error) {
+	if span == nil {
+		return
+	}
+	if statusCode > 0 {
+		span.SetTags(ctx, map[string]any{TagHTTPStatusCode: statusCode})
+	}
+	if statusCode >= http.StatusInternalServerError {
+		span.SetStatusCode(ctx, statusCode)
+	}
+	if err != nil {
+		span.SetError(ctx, err)
+	} else if statusCode >= http.StatusInternalServerError {
+		span.SetError(ctx, fmt.Errorf("%d %s", statusCode, http.StatusText(statusCode)))
+	}
+	span.Finish(ctx)
+}
+
+func newOptions(opts []Option) *options {
+	o := &options{spanType: SpanTypeServer}
+	for _, opt := range opts {
+		opt(o)
+	}
+	return o
+}
+
+func getSpanFromHeader(ctx context.Context, client cozeloop.TraceClient, getHeader func(key string) string) cozeloop.SpanContext {
+	header := make(map[string]string, 2)
+	for _, key := range []string{consts.TraceContextHeaderParent, consts.TraceContextHeaderBaggage} {
+		if value := getHeader(key); value != "" {
+			header[key] = value
+		}
+	}
+	if len(header) == 0 {
+		return nil
+	}
+	if client != nil {
+		return client.GetSpanFromHeader(ctx, header)
+	}
+	return cozeloop.GetSpanFromHeader(ctx, header)
+}
@@ -0,0 +1,173 @@
+// Copyright (c) 2025 Bytedance Ltd. and/or its affiliates
+// SPDX-License-Identifier: MIT
+
+package servertrace
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+
+	"github.com/coze-dev/cozeloop-go"
+	"github.com/coze-dev/cozeloop-go/entity"
+	"github.com/coze-dev/cozeloop-go/internal/consts"
+	"github.com/coze-dev/cozeloop-go/spec/tracespec"
+)
+
+type spanRecorder struct {
+	mu    sync.Mutex
+	spans []*entity.UploadSpan
+}
+
+func (r *spanRecorder) ExportSpans(ctx context.Context, spans []*entity.UploadSpan) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.spans = append(r.spans, spans...)
+	return nil
+}
+
+func (r *spanRecorder) ExportFiles(ctx context.Context, files []*entity.UploadFile) error {
+	return nil
+}
+
+func (r *spanRecorder) find(name string) *entity.UploadSpan {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for i := len(r.spans) - 1; i >= 0; i-- {
+		if r.spans[i].SpanName == name {
+			return r.spans[i]
+		}
+	}
+	return nil
+}
+
+func TestMiddleware(t *testing.T) {
+	ctx := context.Background()
+	recorder := &spanRecorder{}
+	client, err := cozeloop.NewClient(
+		cozeloop.WithWorkspaceID("servertrace"),
+		cozeloop.WithAPIToken("token"),
+		cozeloop.WithExporter(recorder),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/users/", func(w http.ResponseWriter, r *http.Request) {
+		_, child := client.StartSpan(r.Context(), "child", tracespec.VToolSpanType)
+		child.Finish(r.Context())
+		_, _ = w.Write([]byte("ok"))
+	})
+	mux.HandleFunc("/error", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadGateway)
+	})
+	handler := Middleware(mux,
+		WithClient(client),
+		WithRoute(func(r *http.Request) string {
+			if r.URL.Path == "/error" {
+				return ""
+			}
+			return "/users/:id"
+		}),
+		WithUserIDExtractor(func(ctx context.Context, req *Request) string {
+			return req.Raw.(*http.Request).Header.Get("X-User-Id")
+		}),
+	)
+
+	Convey("Test root span continues upstream trace and records request", t, func() {
+		_, upstream := client.StartSpan(ctx, "upstream", "custom")
+		header, err := upstream.ToHeader()
+		So(err, ShouldBeNil)
+		r := httptest.NewRequest(http.MethodGet, "/users/1", nil)
+		for k, v := range header {
+			r.Header.Set(k, v)
+		}
+		r.Header.Set("X-User-Id", "u1")
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, r)
+		So(w.Code, ShouldEqual, http.StatusOK)
+
+		client.Flush(ctx)
+		span := recorder.find("GET /users/:id")
+		So(span, ShouldNotBeNil)
+		So(span.SpanType, ShouldEqual, SpanTypeServer)
+		So(span.TraceID, ShouldEqual, upstream.GetTraceID())
+		So(span.ParentID, ShouldEqual, upstream.GetSpanID())
+		So(span.StatusCode, ShouldEqual, 0)
+		So(span.TagsString[TagHTTPRoute], ShouldEqual, "/users/:id")
+		So(span.TagsString[TagHTTPPath], ShouldEqual, "/users/1")
+		So(span.TagsLong[TagHTTPStatusCode], ShouldEqual, http.StatusOK)
+		So(span.TagsString[consts.UserID], ShouldEqual, "u1")
+
+		// user id is passed to child spans as baggage
+		child := recorder.find("child")
+		So(child.ParentID, ShouldEqual, span.SpanID)
+		So(child.TagsString[consts.UserID], ShouldEqual, "u1")
+	})
+
+	Convey("Test request with 5xx is marked as failed", t, func() {
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/error", nil))
+		So(w.Code, ShouldEqual, http.StatusBadGateway)
+
+		client.Flush(ctx)
+		span := recorder.find("POST /error")
+		So(span, ShouldNotBeNil)
+		So(span.ParentID, ShouldEqual, "0")
+		So(span.StatusCode, ShouldEqual, http.StatusBadGateway)
+		So(span.TagsLong[TagHTTPStatusCode], ShouldEqual, http.StatusBadGateway)
+		So(span.TagsString[tracespec.Error], ShouldContainSubstring, "Bad Gateway")
+	})
+
+	Convey("Test response writer forwards Flusher and Hijacker of the underlying writer", t, func() {
+		var flushed, hijacked bool
+		streamHandler := Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			_, hijacked = w.(http.Hijacker)
+			if flusher, ok := w.(http.Flusher); ok {
+				flusher.Flush()
+				flushed = true
+			}
+		}), WithClient(client))
+
+		w := httptest.NewRecorder()
+		streamHandler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/stream", nil))
+		So(flushed, ShouldBeTrue)
+		So(hijacked, ShouldBeFalse)
+		So(w.Flushed, ShouldBeTrue)
+
+		flushed = false
+		streamHandler.ServeHTTP(writerOnly{w}, httptest.NewRequest(http.MethodGet, "/stream", nil))
+		So(flushed, ShouldBeFalse)
+		So(hijacked, ShouldBeFalse)
+
+		wsHandler := Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			conn, buf, err := w.(http.Hijacker).Hijack()
+			if err != nil {
+				return
+			}
+			defer conn.Close()
+			_, _ = buf.WriteString("HTTP/1.1 101 Switching Protocols\r\n\r\n")
+			_ = buf.Flush()
+		}), WithClient(client))
+		server := httptest.NewServer(wsHandler)
+		defer server.Close()
+		resp, err := http.Get(server.URL + "/ws")
+		So(err, ShouldBeNil)
+		_ = resp.Body.Close()
+		So(resp.StatusCode, ShouldEqual, http.StatusSwitchingProtocols)
+
+		client.Flush(ctx)
+		span := recorder.find("GET /ws")
+		So(span, ShouldNotBeNil)
+		So(span.TagsLong[TagHTTPStatusCode], ShouldEqual, http.StatusSwitchingProtocols)
+	})
+}
+
+// writerOnly hides the optional interfaces of the underlying writer.
+type writerOnly struct {
+	http.ResponseWriter
+}
@@ -0,0 +1,119 @@
+// Copyright (c) 2025 Bytedance Ltd. and/or its affiliates
+// SPDX-License-Identifier: MIT
+
+package cozeloop
+
+import (
+	"context"
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/coze-dev/cozeloop-go/internal/consts"
+	"github.com/coze-dev/cozeloop-go/internal/logger"
+)
+
+const defaultShutdownHookTimeout = 30 * time.Second
+
+type shutdownHookOptions struct {
+	signals []os.Signal
+	timeout time.Duration
+	reraise bool
+}
+
+type ShutdownHookOption func(o *shutdownHookOptions)
+
+// WithShutdownSignals set the signals which trigger the shutdown. Default is SIGINT and SIGTERM.
+func WithShutdownSignals(signals ...os.Signal) ShutdownHookOption {
+	return func(o *shutdownHookOptions) {
+		o.signals = signals
+	}
+}
+
+// WithShutdownTimeout set the max time to flush spans when the signal is received. Default is 30s.
+func WithShutdownTimeout(timeout time.Duration) ShutdownHookOption {
+	return func(o *shutdownHookOptions) {
+		o.timeout = timeout
+	}
+}
+
+// WithShutdownReraise set whether to stop listening and raise the signal again after shutdown, so that the
+// process is terminated by the default action of signal as if the hook is not registered. Disable it if the
+// application handles the signals and exits by itself. Default is true.
+func WithShutdownReraise(enable bool) ShutdownHookOption {
+	return func(o *shutdownHookOptions) {
+		o.reraise = enable
+	}
+}
+
+// RegisterShutdownHook shutdown the default client when the process receives SIGINT or SIGTERM, so that the spans
+// are flushed before the process terminates. The SDK never exits the process, after shutdown the signal is raised
+// again by default, see WithShutdownReraise. Applications with their own graceful shutdown can call Shutdown
+// instead. It returns a func to unregister the hook.
+func RegisterShutdownHook(opts ...ShutdownHookOption) (unregister func()) {
+	o := &shutdownHookOptions{
+		signals: []os.Signal{syscall.SIGINT, syscall.SIGTERM},
+		timeout: defaultShutdownHookTimeout,
+		reraise: true,
+	}
+	for _, opt := range opts {
+		opt(o)
+	}
+
+	sigChan := make(chan os.Signal, 1)
+	stopChan := make(chan struct{})
+	signal.Notify(sigChan, o.signals...)
+	go func() {
+		select {
+		case sig := <-sigChan:
+			signal.Stop(sigChan)
+			runShutdownHook(sig, o)
+		case <-stopChan:
+		}
+	}()
+
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			signal.Stop(sigChan)
+			close(stopChan)
+		})
+	}
+}
+
+// runShutdownHook shuts down the default client and raises the signal again if required.
+func runShutdownHook(sig os.Signal, o *shutdownHookOptions) {
+	ctx := context.Background()
+	if o.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, o.timeout)
+		defer cancel()
+	}
+
+	logger.CtxInfof(ctx, "Received signal: %v, starting graceful shutdown...", sig)
+	report, err := getDefaultClient().Shutdown(ctx)
+	if err != nil {
+		logger.CtxWarnf(ctx, "Graceful shutdown failed: %v", err)
+	}
+	if report != nil {
+		logger.CtxInfof(ctx, "Spans flushed: %d, dropped: %d, pending: %d, files pending: %d, cost: %v",
+			report.SpansFlushed, report.SpansDropped, report.SpansPending, report.FilesPending, report.Duration)
+	}
+	defaultClientLock.Lock()
+	defaultClient = &NoopClient{newClientError: consts.ErrClientClosed}
+	defaultClientLock.Unlock()
+	logger.CtxInfof(ctx, "Graceful shutdown finished.")
+
+	if !o.reraise {
+		return
+	}
+	process, err := os.FindProcess(os.Getpid())
+	if err == nil {
+		err = process.Signal(sig)
+	}
+	if err != nil {
+		logger.CtxWarnf(ctx, "Raise signal %v again failed: %v", sig, err)
+	}
+}
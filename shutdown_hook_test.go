@@ -0,0 +1,52 @@
+// Copyright (c) 2025 Bytedance Ltd. and/or its affiliates
+// SPDX-License-Identifier: MIT
+
+package cozeloop
+
+import (
+	"context"
+	"errors"
+	"os"
+	"testing"
+	"time"
+
+	. "github.com/smartystreets/goconvey/convey"
+
+	"github.com/coze-dev/cozeloop-go/internal/consts"
+)
+
+func TestRegisterShutdownHook(t *testing.T) {
+	Convey("Test spans are flushed on signal without exiting", t, func() {
+		exporter := &recordExporter{}
+		client, err := NewClient(WithWorkspaceID("shutdown_hook"), WithAPIToken("token"), WithExporter(exporter),
+			WithNoClientCache())
+		So(err, ShouldBeNil)
+		defaultClient := getDefaultClient()
+		SetDefaultClient(client)
+		defer SetDefaultClient(defaultClient)
+
+		unregister := RegisterShutdownHook(WithShutdownSignals(os.Interrupt), WithShutdownReraise(false),
+			WithShutdownTimeout(time.Second))
+		defer unregister()
+		ctx, span := StartSpan(context.Background(), "span", "custom")
+		span.Finish(ctx)
+
+		process, err := os.FindProcess(os.Getpid())
+		So(err, ShouldBeNil)
+		So(process.Signal(os.Interrupt), ShouldBeNil)
+		for i := 0; i < 100 && getDefaultClient() == client; i++ {
+			time.Sleep(10 * time.Millisecond)
+		}
+		_, err = getDefaultClient().GetPrompt(ctx, GetPromptParam{PromptKey: "key"})
+		So(errors.Is(err, consts.ErrClientClosed), ShouldBeTrue)
+		exporter.mu.Lock()
+		defer exporter.mu.Unlock()
+		So(len(exporter.spans), ShouldEqual, 1)
+	})
+
+	Convey("Test unregister stops listening", t, func() {
+		unregister := RegisterShutdownHook(WithShutdownSignals(os.Interrupt))
+		unregister()
+		unregister()
+	})
+}
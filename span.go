@@ -8,6 +8,7 @@ import (
 	"time"
 
 	"github.com/coze-dev/cozeloop-go/entity"
+	"github.com/coze-dev/cozeloop-go/internal/trace"
 	"github.com/coze-dev/cozeloop-go/spec/tracespec"
 )
 
@@ -19,14 +20,28 @@ type Span interface {
 	// SetTags sets business custom tags.
 	SetTags(ctx context.Context, tagKVs map[string]interface{})
 
+	// SetTagsE works like SetTags, and returns TagErrors listing the tags dropped, such as reserved tags
+	// of wrong type, too large tags with TruncationPolicyDrop, or tags exceeding the count limit. The keys
+	// of dropped tags are also recorded in the system tag `dropped_tags`.
+	SetTagsE(ctx context.Context, tagKVs map[string]interface{}) error
+
 	// SetBaggage sets tags and also passes these tags to other downstream spans (assuming
 	// the user uses ToHeader and FromHeader to handle header passing between services).
 	SetBaggage(ctx context.Context, baggageItems map[string]string)
 
+	// SetUltraLargeReport Set whether the large input and output of the span are uploaded as files instead of being
+	// truncated, which overrides WithUltraLargeTraceReport of client. It applies to the tags set after it, so call
+	// it before SetInput and SetOutput, or use WithUltraLargeReport when starting the span.
+	SetUltraLargeReport(enable bool)
+
 	// Finish The span will be reported only after an explicit call to Finish.
 	// Under the hood, it is actually placed in an asynchronous queue waiting to be reported.
 	Finish(ctx context.Context)
 
+	// FinishWithOptions Finish the span with the final error, status code, tags and finish time, which are set
+	// together with finishing, so that they are not raced by the fields set by other goroutines after finish.
+	FinishWithOptions(ctx context.Context, opts FinishOptions)
+
 	// GetStartTime returns the start time of the Span.
 	GetStartTime() time.Time
 
@@ -90,6 +105,34 @@ type commonSpanSetter interface {
 	// The recommended standard format is CallOption of spec package
 	SetModelCallOptions(ctx context.Context, callOptions interface{})
 
+	// SetStream key: `stream`
+	// Whether the output of LLM is streamed.
+	SetStream(ctx context.Context, stream bool)
+
+	// SetReasoningTokens key: `reasoning_tokens`
+	// The usage of tokens during the reasoning process of LLM.
+	SetReasoningTokens(ctx context.Context, reasoningTokens int)
+
+	// SetReasoningDuration key: `reasoning_duration`
+	// The duration of the reasoning process of LLM, which is reported in microseconds.
+	SetReasoningDuration(ctx context.Context, duration time.Duration)
+
+	// SetToolCallID key: `tool_call_id`
+	// The id of the tool call which the tool span executes, the same as the id in the tool calls of LLM output.
+	SetToolCallID(ctx context.Context, toolCallID string)
+
+	// SetRetrieverProvider key: `retriever_provider`
+	// The provider of retriever span, such as Elasticsearch, VikingDB, etc.
+	SetRetrieverProvider(ctx context.Context, provider string)
+
+	// SetRetrieverCallOptions key: `call_options`
+	// The call options of retriever span, such as top_k, min_score, etc.
+	SetRetrieverCallOptions(ctx context.Context, callOptions tracespec.RetrieverCallOption)
+
+	// SetRetrieverDocuments key: `output`
+	// The documents retrieved by retriever span, which are reported as the output in tracespec.RetrieverOutput.
+	SetRetrieverDocuments(ctx context.Context, documents []*tracespec.RetrieverDocument)
+
 	// SetInputTokens key: `input_tokens`
 	// The usage of input tokens. When the value of input_tokens is set,
 	// It will be automatically summed with output_tokens to calculate the tokens tag.
@@ -100,12 +143,23 @@ type commonSpanSetter interface {
 	// It will be automatically summed with input_tokens to calculate the tokens tag.
 	SetOutputTokens(ctx context.Context, outputTokens int)
 
+	// SetCost key: `cost`
+	// The cost of the LLM call. When it is not set, it is computed from input_tokens, output_tokens
+	// and model_name by the model pricing of client, see WithModelPricing.
+	SetCost(ctx context.Context, cost float64)
+
 	// SetStartTimeFirstResp key: `start_time_first_resp`
 	// Timestamp of the first packet return from LLM, unit: microseconds.
 	// When `start_time_first_resp` is set, a tag named `latency_first_resp` calculated
 	// based on the span's StartTime will be added, meaning the latency for the first packet.
 	SetStartTimeFirstResp(ctx context.Context, startTimeFirstResp int64)
 
+	// AddStreamChunk Record a chunk of streaming output of LLM received now, with the count of tokens in it.
+	// When the span finished, tags of stream_chunks, stream_duration, stream_tokens_per_second,
+	// stream_chunk_latency_p50 and stream_chunk_latency_p95 are computed from the chunks. The first chunk also
+	// sets `start_time_first_resp` if it is not set.
+	AddStreamChunk(ctx context.Context, tokenCount int)
+
 	// SetRuntime key: `runtime`
 	// The runtime of the LLM, such as language, library, scene, etc.
 	// The recommended standard format is Runtime of spec package
@@ -132,6 +186,9 @@ type commonSpanSetter interface {
 	SetDeploymentEnv(ctx context.Context, deploymentEnv string)
 }
 
+// FinishOptions the final fields of span set by Span.FinishWithOptions, the zero fields are not set.
+type FinishOptions = trace.FinishOptions
+
 // SpanContext is the interface for span Baggage transfer.
 type SpanContext interface {
 	GetSpanID() string
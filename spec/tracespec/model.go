@@ -83,13 +83,15 @@ type ModelFileURL struct {
 
 type ModelAudioURL struct {
 	Name string `json:"name,omitempty"`
-	// Required. You can enter a valid audio URL of audio.
+	// Required. You can enter a valid audio URL or MDN Base64 data of audio, such as data:audio/wav;base64,xxx.
+	// MDN: https://developer.mozilla.org/en-US/docs/Web/URI/Reference/Schemes/data#syntax
 	URL string `json:"url,omitempty"`
 }
 
 type ModelVideoURL struct {
 	Name string `json:"name,omitempty"`
-	// Required. You can enter a valid video URL of video.
+	// Required. You can enter a valid video URL or MDN Base64 data of video, such as data:video/mp4;base64,xxx.
+	// MDN: https://developer.mozilla.org/en-US/docs/Web/URI/Reference/Schemes/data#syntax
 	URL string `json:"url,omitempty"`
 }
 type ModelToolCall struct {
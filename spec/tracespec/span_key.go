@@ -9,6 +9,13 @@ const (
 	Stream            = "stream"             // Used to identify whether it is a streaming output.
 	ReasoningTokens   = "reasoning_tokens"   // The token usage during the reasoning process.
 	ReasoningDuration = "reasoning_duration" // The duration during the reasoning process. The unit is microseconds.
+
+	// Stats of streaming output computed from the chunks recorded by AddStreamChunk.
+	StreamChunks          = "stream_chunks"            // The count of chunks.
+	StreamDuration        = "stream_duration"          // The duration from the first chunk to the last one. The unit is microseconds.
+	StreamTokensPerSecond = "stream_tokens_per_second" // The tokens per second of the chunks after the first one.
+	StreamChunkLatencyP50 = "stream_chunk_latency_p50" // The p50 latency between chunks. The unit is microseconds.
+	StreamChunkLatencyP95 = "stream_chunk_latency_p95" // The p95 latency between chunks. The unit is microseconds.
 )
 
 // Tags for tool-type span.
@@ -32,6 +39,8 @@ const (
 	PromptKey      = "prompt_key"
 	PromptVersion  = "prompt_version"
 	PromptLabel    = "prompt_label"
+
+	ExecuteCacheHit = "execute_cache_hit" // Whether the result of prompt execute is returned from cache.
 )
 
 // Internal experimental field.
@@ -49,6 +58,7 @@ const (
 	InputCachedTokens   = "input_cached_tokens"
 	OutputTokens        = "output_tokens"
 	Tokens              = "tokens"
+	Cost                = "cost"
 	ModelPlatform       = "model_platform"
 	ModelIdentification = "model_identification"
 	TokenUsageBackup    = "token_usage_backup"
@@ -0,0 +1,232 @@
+// Copyright (c) 2025 Bytedance Ltd. and/or its affiliates
+// SPDX-License-Identifier: MIT
+
+// Package sse provides the decoder of Server-Sent Events, which ExecuteStreaming is built on. It can be used to
+// consume other streaming endpoints, such as the model services traced by cozeloop.
+//
+// The decoder follows the event stream format of the HTML standard: lines end with LF, CR or CRLF, lines starting
+// with colon are comments, multi-line data is joined with LF, and the last event id is kept across events. Events
+// are read incrementally, so partial reads and chunks split by proxies are handled.
+package sse
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/coze-dev/cozeloop-go/internal/util"
+)
+
+// ErrEventTooLarge is returned by Decoder.Next if the size of an event exceeds the limit set by WithMaxEventSize.
+var ErrEventTooLarge = errors.New("sse: event too large")
+
+// Event a Server-Sent Event.
+type Event struct {
+	Event string
+	Data  string
+	// ID the last event id of stream, which is kept until it is changed by a following event
+	ID string
+	// Retry the reconnection time in milliseconds, nil if it is not set by the event
+	Retry *int
+}
+
+// JSON unmarshals the Data field into v.
+func (e *Event) JSON(v interface{}) error {
+	if e.Data == "" {
+		return fmt.Errorf("empty data field")
+	}
+	return json.Unmarshal([]byte(e.Data), v)
+}
+
+// Result an event or error decoded by Decoder.Decode.
+type Result struct {
+	Event *Event
+	Err   error
+}
+
+// DecoderOption option of Decoder.
+type DecoderOption func(d *Decoder)
+
+// WithMaxEventSize limits the size in bytes of an event, including field names and line endings, Next returns
+// ErrEventTooLarge if it is exceeded. Default is unlimited.
+func WithMaxEventSize(size int) DecoderOption {
+	return func(d *Decoder) {
+		d.maxEventSize = size
+	}
+}
+
+// Decoder decodes Server-Sent Events from an io.Reader. It is not safe for concurrent use.
+type Decoder struct {
+	source       io.Reader
+	reader       *bufio.Reader
+	maxEventSize int
+	eventSize    int
+	lastEventID  string
+	line         []byte
+	skipLF       bool // the last line ends with CR, so the following LF is a part of CRLF
+	started      bool
+}
+
+// NewDecoder creates a decoder reading events from reader.
+func NewDecoder(reader io.Reader, opts ...DecoderOption) *Decoder {
+	d := &Decoder{
+		source: reader,
+		reader: bufio.NewReader(reader),
+	}
+	for _, opt := range opts {
+		opt(d)
+	}
+	return d
+}
+
+// LastEventID returns the last event id of stream, which is sent as Last-Event-ID header on reconnection.
+func (d *Decoder) LastEventID() string {
+	return d.lastEventID
+}
+
+// Next reads the next event, it returns io.EOF if the stream ends. An event without data is returned too if it has
+// any field, such as event type, so the events like "event: error" are not lost. The last event is returned even if
+// the stream ends without a blank line.
+func (d *Decoder) Next() (*Event, error) {
+	event := &Event{}
+	var dataLines []string
+	var hasField bool
+	d.eventSize = 0
+	for {
+		line, err := d.readLine()
+		if err != nil {
+			if err == io.EOF && hasField {
+				return d.dispatch(event, dataLines), nil
+			}
+			return nil, err
+		}
+		if line == "" {
+			if hasField {
+				return d.dispatch(event, dataLines), nil
+			}
+			continue
+		}
+		if line[0] == ':' {
+			// comment, such as keep-alive sent by server or proxy
+			continue
+		}
+		field, value := line, ""
+		if i := strings.IndexByte(line, ':'); i >= 0 {
+			field, value = line[:i], strings.TrimPrefix(line[i+1:], " ")
+		}
+		switch field {
+		case "event":
+			event.Event = value
+		case "data":
+			dataLines = append(dataLines, value)
+		case "id":
+			// ids with NULL are ignored by the standard
+			if !strings.ContainsRune(value, 0) {
+				d.lastEventID = value
+			}
+		case "retry":
+			// only digits are allowed by the standard
+			if strings.TrimLeft(value, "0123456789") != "" {
+				continue
+			}
+			retry, err := strconv.Atoi(value)
+			if err != nil {
+				continue
+			}
+			event.Retry = &retry
+		default:
+			// unknown fields are ignored
+			continue
+		}
+		hasField = true
+	}
+}
+
+func (d *Decoder) dispatch(event *Event, dataLines []string) *Event {
+	event.Data = strings.Join(dataLines, "\n")
+	event.ID = d.lastEventID
+	return event
+}
+
+// readLine reads a line without line ending. The last line without line ending is returned before io.EOF.
+func (d *Decoder) readLine() (string, error) {
+	d.line = d.line[:0]
+	for {
+		b, err := d.reader.ReadByte()
+		if err != nil {
+			if err == io.EOF && len(d.line) > 0 {
+				return d.takeLine(), nil
+			}
+			return "", err
+		}
+		if d.skipLF {
+			d.skipLF = false
+			if b == '\n' {
+				continue
+			}
+		}
+		switch b {
+		case '\r':
+			// don't wait for the following LF, or the event ending with CR is blocked until next event arrives
+			d.skipLF = true
+			return d.takeLine(), nil
+		case '\n':
+			return d.takeLine(), nil
+		}
+		d.line = append(d.line, b)
+		d.eventSize++
+		if d.maxEventSize > 0 && d.eventSize > d.maxEventSize {
+			return "", ErrEventTooLarge
+		}
+	}
+}
+
+func (d *Decoder) takeLine() string {
+	d.eventSize++
+	line := string(d.line)
+	if !d.started {
+		d.started = true
+		// the byte order mark at the beginning of stream is ignored
+		line = strings.TrimPrefix(line, "\ufeff")
+	}
+	return line
+}
+
+// Decode decodes events in a goroutine and sends them to the returned channel, the channel is closed after io.EOF,
+// an error or ctx is done. If the reader is an io.Closer, such as http response body, it is closed when ctx is done,
+// so the pending read returns and the goroutine exits.
+func (d *Decoder) Decode(ctx context.Context) <-chan Result {
+	ch := make(chan Result, 1)
+	done := make(chan struct{})
+	if closer, ok := d.source.(io.Closer); ok {
+		util.GoSafe(ctx, func() {
+			select {
+			case <-ctx.Done():
+				_ = closer.Close()
+			case <-done:
+			}
+		})
+	}
+	util.GoSafe(ctx, func() {
+		defer close(ch)
+		defer close(done)
+		for {
+			event, err := d.Next()
+			select {
+			case ch <- Result{Event: event, Err: err}:
+			case <-ctx.Done():
+				return
+			}
+			// no more events after EOF or read error
+			if err != nil {
+				return
+			}
+		}
+	})
+	return ch
+}
@@ -0,0 +1,123 @@
+// Copyright (c) 2025 Bytedance Ltd. and/or its affiliates
+// SPDX-License-Identifier: MIT
+
+package sse
+
+import (
+	"context"
+	"io"
+	"strings"
+	"testing"
+	"testing/iotest"
+	"time"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func decodeAll(reader io.Reader, opts ...DecoderOption) ([]*Event, error) {
+	d := NewDecoder(reader, opts...)
+	var events []*Event
+	for {
+		event, err := d.Next()
+		if err == io.EOF {
+			return events, nil
+		}
+		if err != nil {
+			return events, err
+		}
+		events = append(events, event)
+	}
+}
+
+func TestDecoder(t *testing.T) {
+	Convey("Test fields, multi-line data and comments", t, func() {
+		events, err := decodeAll(strings.NewReader("\ufeff: keep-alive\n" +
+			"event: message\nid: 1\nretry: 3000\ndata: line1\ndata:line2\nunknown: x\n\n" +
+			"data\n\n" +
+			"event: error\n\n" +
+			"retry: 1s\ndata: {\"a\":1}\n\n"))
+		So(err, ShouldBeNil)
+		So(len(events), ShouldEqual, 4)
+		So(events[0].Event, ShouldEqual, "message")
+		So(events[0].Data, ShouldEqual, "line1\nline2")
+		So(events[0].ID, ShouldEqual, "1")
+		So(*events[0].Retry, ShouldEqual, 3000)
+		// a field without colon has empty value, and the last event id is kept
+		So(events[1].Data, ShouldEqual, "")
+		So(events[1].ID, ShouldEqual, "1")
+		So(events[2].Event, ShouldEqual, "error")
+		So(events[3].Retry, ShouldBeNil)
+		var v map[string]int
+		So(events[3].JSON(&v), ShouldBeNil)
+		So(v["a"], ShouldEqual, 1)
+	})
+
+	Convey("Test line endings of CR and CRLF", t, func() {
+		events, err := decodeAll(strings.NewReader("data: a\r\ndata: b\r\n\r\ndata: c\rid: 2\r\rdata: d"))
+		So(err, ShouldBeNil)
+		So(len(events), ShouldEqual, 3)
+		So(events[0].Data, ShouldEqual, "a\nb")
+		So(events[1].Data, ShouldEqual, "c")
+		So(events[1].ID, ShouldEqual, "2")
+		// the last event without blank line is returned at EOF
+		So(events[2].Data, ShouldEqual, "d")
+	})
+
+	Convey("Test partial reads and long lines", t, func() {
+		long := strings.Repeat("x", 1<<20)
+		events, err := decodeAll(iotest.OneByteReader(strings.NewReader("data: " + long + "\r\n\r\ndata: b\n\n")))
+		So(err, ShouldBeNil)
+		So(len(events), ShouldEqual, 2)
+		So(events[0].Data, ShouldEqual, long)
+		So(events[1].Data, ShouldEqual, "b")
+
+		_, err = decodeAll(strings.NewReader("data: "+long+"\n\n"), WithMaxEventSize(1024))
+		So(err, ShouldEqual, ErrEventTooLarge)
+	})
+
+	Convey("Test read error is returned", t, func() {
+		events, err := decodeAll(io.MultiReader(strings.NewReader("data: a\n\ndata: b"), iotest.ErrReader(iotest.ErrTimeout)))
+		So(err, ShouldEqual, iotest.ErrTimeout)
+		So(len(events), ShouldEqual, 1)
+	})
+
+	Convey("Test event ending with CR is returned without waiting for following bytes", t, func() {
+		reader, writer := io.Pipe()
+		defer writer.Close()
+		go func() {
+			_, _ = writer.Write([]byte("data: a\r\r"))
+		}()
+		event, err := NewDecoder(reader).Next()
+		So(err, ShouldBeNil)
+		So(event.Data, ShouldEqual, "a")
+	})
+}
+
+func TestDecoderDecode(t *testing.T) {
+	Convey("Test decode events to channel until EOF", t, func() {
+		var events []*Event
+		for result := range NewDecoder(strings.NewReader("data: a\n\ndata: b\n\n")).Decode(context.Background()) {
+			if result.Err != nil {
+				So(result.Err, ShouldEqual, io.EOF)
+				break
+			}
+			events = append(events, result.Event)
+		}
+		So(len(events), ShouldEqual, 2)
+	})
+
+	Convey("Test pending read is interrupted when ctx is done", t, func() {
+		reader, writer := io.Pipe()
+		defer writer.Close()
+		ctx, cancel := context.WithCancel(context.Background())
+		ch := NewDecoder(reader).Decode(ctx)
+		cancel()
+		select {
+		case <-ch:
+		case <-time.After(time.Second):
+			t.Fatal("decoding goroutine is not stopped")
+		}
+		_, err := writer.Write([]byte("data: a\n\n"))
+		So(err, ShouldEqual, io.ErrClosedPipe)
+	})
+}
@@ -7,6 +7,7 @@ import (
 	"context"
 	"time"
 
+	"github.com/coze-dev/cozeloop-go/entity"
 	"github.com/coze-dev/cozeloop-go/internal/trace"
 )
 
@@ -22,8 +23,141 @@ type TraceClient interface {
 	GetSpanFromHeader(ctx context.Context, header map[string]string) SpanContext
 	// Flush Force the reporting of spans in the queue.
 	Flush(ctx context.Context)
+	// ImportSpans Report spans of the past, such as spans rebuilt from historical logs by backfill jobs, with the
+	// trace ids, span ids, parent ids and times provided. They are reported like the spans started by StartSpan,
+	// but regardless of WithTraceSampleRatio. All spans are validated before any of them is reported.
+	ImportSpans(ctx context.Context, spans []*ImportedSpan) error
+	// ReportFeedback Report end-user feedback, such as thumbs-up/down, rating and comment, of the specified span.
+	ReportFeedback(ctx context.Context, traceID, spanID string, feedback *entity.Feedback) error
+	// AnnotateSpan Attach tags, such as human review status and resolution code, to the span which has been reported.
+	// The values of tags must be string, bool or number.
+	AnnotateSpan(ctx context.Context, traceID, spanID string, tags map[string]any) error
 }
 
+// IDGenerator generates trace id and span id for new spans.
+// TraceID must be 32 hex chars and SpanID must be 16 hex chars, neither of them can be all zero. Invalid ids are
+// replaced by random ones with a warning.
+type IDGenerator = trace.IDGenerator
+
+// NewDeterministicIDGenerator returns an IDGenerator which always generates the same id sequence for the same seed.
+// It is useful for deterministic test fixtures and replay, DO NOT use it in production.
+func NewDeterministicIDGenerator(seed int64) IDGenerator {
+	return trace.NewDeterministicIDGenerator(seed)
+}
+
+// Clock returns the current time, which is the default start time and finish time of spans, see WithTraceClock.
+type Clock = trace.Clock
+
+// ManualClock is a Clock which only moves by Set and Add, for deterministic span time in tests.
+type ManualClock = trace.ManualClock
+
+// NewManualClock returns a ManualClock starting at now.
+func NewManualClock(now time.Time) *ManualClock {
+	return trace.NewManualClock(now)
+}
+
+// SpanRedactor is called for every span before export, and can modify input, output and tags of the span in place,
+// such as masking PII. Large input and output uploaded as file are redacted as the Input or Output of a span copy.
+type SpanRedactor = trace.SpanRedactor
+
+// NewTextRedactor returns a SpanRedactor which applies redact to input, output and string tags of span.
+func NewTextRedactor(redact func(text string) string) SpanRedactor {
+	return trace.NewTextRedactor(redact)
+}
+
+// NewPIIRedactor returns a SpanRedactor which masks email addresses, credit card numbers and phone numbers
+// in input, output and string tags of span.
+func NewPIIRedactor() SpanRedactor {
+	return trace.NewTextRedactor(trace.RedactPII)
+}
+
+// ShutdownReport statistics of span reporting returned by Shutdown, which tells how many spans and files
+// are flushed, dropped or still pending when shutdown finished.
+type ShutdownReport = trace.ShutdownReport
+
+// TraceStats statistics of span reporting at the moment, such as spans dropped and pending in queues, see Client.Stats.
+type TraceStats = trace.Stats
+
+// SpanLeakDetectionConf configures the detector of spans which are started but never finished, see WithSpanLeakDetection.
+type SpanLeakDetectionConf = trace.LeakDetectionConf
+
+// TagLintConf configures the lint of tags set by SetTags, see WithTagLint.
+type TagLintConf = trace.TagLintConf
+
+// TagLintWarning the suspicious tag reported by the lint of tags, see WithTagLint.
+type TagLintWarning = trace.TagLintWarning
+
+// LeakedSpanInfo the span not finished in SpanLeakDetectionConf.TTL, with the code site which started it.
+type LeakedSpanInfo = trace.LeakedSpanInfo
+
+// DebugConf configures the debug exporter printing spans locally, see WithDebugExporter.
+type DebugConf = trace.DebugConf
+
+// SpanProcessor processes spans when they are finished, see WithSpanProcessor. OnSpanEnd is called synchronously
+// in Span.Finish, it should not block.
+type SpanProcessor = trace.SpanProcessor
+
+// FinishedSpan the span passed to SpanProcessor.OnSpanEnd. Read it by the getters, such as GetTagMap, or take
+// the snapshot of it by ReadOnly.
+type FinishedSpan = trace.Span
+
+// ReadOnlySpan the snapshot of a finished span with ids, timings and all tags, which can be serialized by
+// encoding/json, see WithSpanConsumer.
+type ReadOnlySpan = trace.ReadOnlySpan
+
+// SpanConsumer consumes the snapshots of finished spans, see WithSpanConsumer.
+type SpanConsumer = trace.SpanConsumer
+
+// BaggageConf limits the baggage of span, and filters the baggage propagated by headers, see WithBaggageConf.
+type BaggageConf = trace.BaggageConf
+
+// TraceIDNormalizationConf accepts and normalizes the ids of header parent in other formats, see
+// WithTraceIDNormalization.
+type TraceIDNormalizationConf = trace.IDNormalizationConf
+
+// BaggageProcessor decides the tags and sampling of spans by the baggage inherited, see WithBaggageProcessor.
+type BaggageProcessor = trace.BaggageProcessor
+
+// BaggageDecision the tags and sampling decided by BaggageProcessor for a span.
+type BaggageDecision = trace.BaggageDecision
+
+// SamplingDecision decides whether a span is reported.
+type SamplingDecision = trace.SamplingDecision
+
+const (
+	SamplingDefault   = trace.SamplingDefault
+	SamplingDrop      = trace.SamplingDrop
+	SamplingRecordAll = trace.SamplingRecordAll
+)
+
+// BlobStore stores the files of spans, such as large input and output, and multi-modality attachments, instead of
+// uploading them through CozeLoop, see WithBlobStore.
+type BlobStore = trace.BlobStore
+
+// PresignFunc returns the pre-signed url to upload file by http PUT, see NewPresignedURLBlobStore.
+type PresignFunc = trace.PresignFunc
+
+// NewPresignedURLBlobStore returns a BlobStore uploading files directly to object storage, such as S3 or TOS,
+// by the urls pre-signed by presign. http.DefaultClient is used if httpClient is nil.
+func NewPresignedURLBlobStore(presign PresignFunc, httpClient HttpClient) BlobStore {
+	return trace.NewPresignedURLBlobStore(presign, httpClient)
+}
+
+// ModelPrice the price of model per 1k tokens, see WithModelPricing.
+type ModelPrice = trace.ModelPrice
+
+// ModelPricing the registry of model prices keyed by model name, prices can be updated at any time.
+type ModelPricing = trace.ModelPricing
+
+// NewModelPricing create the registry of model prices keyed by model name. The price of the longest model name
+// which is prefix of the model is used when there is no exact one, e.g. "gpt-4o" for "gpt-4o-2024-08-06".
+func NewModelPricing(prices map[string]ModelPrice) *ModelPricing {
+	return trace.NewModelPricing(prices)
+}
+
+// ImportedSpan a span of the past reported by ImportSpans.
+type ImportedSpan = trace.ImportedSpan
+
 type startSpanOptions = trace.StartSpanOptions
 
 // StartSpanOption is used to set options for the span.
@@ -65,14 +199,34 @@ func WithStartNewTrace() StartSpanOption {
 	}
 }
 
-// WithSpanWorkspaceID Set the workspaceID of the span.
-// This field is inner field. You should not set it.
+// WithSpanWorkspaceID Set the workspaceID of the span, instead of the workspace of client.
+// Child spans report to the same workspace of parent span by default. Services serving multiple workspaces
+// can set it on root span, rather than creating a client per workspace.
 func WithSpanWorkspaceID(workspaceID string) StartSpanOption {
 	return func(ops *startSpanOptions) {
 		ops.WorkspaceID = workspaceID
 	}
 }
 
+// WithUltraLargeReport Set whether the large input and output of the span are uploaded as files instead of being
+// truncated, which overrides WithUltraLargeTraceReport of client. Enable it only for spans with large inputs or
+// outputs, such as the spans of multi-MB documents, to avoid the cost of uploading files everywhere.
+func WithUltraLargeReport(enable bool) StartSpanOption {
+	return func(ops *startSpanOptions) {
+		ops.UltraLargeReport = &enable
+	}
+}
+
+// WithPriority Set the priority of the span when it is queued to report. Spans of each priority are queued
+// separately, and spans of low priority are dropped first when the queues are full. Set low priority on verbose
+// child spans. If it is not set, root spans and error spans are of high priority, and the others are of normal.
+// It is ignored if the spans are grouped by trace, see TraceQueueConf.GroupByTrace.
+func WithPriority(priority SpanPriority) StartSpanOption {
+	return func(ops *startSpanOptions) {
+		ops.Priority = priority
+	}
+}
+
 // WithSpanID Set the spanID of the span.
 // Only use when specifying a SpanID! By default, SDK can automatically generate a SpanID
 // SpanID must be a combination of 16 digits and letters.
@@ -81,3 +235,31 @@ func WithSpanID(spanID string) StartSpanOption {
 		ops.SpanID = spanID
 	}
 }
+
+type finishSpanOptions struct {
+	finishTime time.Time
+}
+
+// FinishSpanOption is used to set options when finishing the span.
+type FinishSpanOption = func(o *finishSpanOptions)
+
+// WithFinishTime Set the finish time of the span, such as the time in historical logs when backfilling spans.
+// This field is optional. If not specified, the time when the span is finished will be used as the default.
+func WithFinishTime(t time.Time) FinishSpanOption {
+	return func(o *finishSpanOptions) {
+		o.finishTime = t
+	}
+}
+
+// FinishSpan Finish the span with options, use it with WithStartTime and WithFinishTime to report spans of the
+// past, such as spans rebuilt from historical logs.
+func FinishSpan(ctx context.Context, span Span, opts ...FinishSpanOption) {
+	if span == nil {
+		return
+	}
+	o := &finishSpanOptions{}
+	for _, opt := range opts {
+		opt(o)
+	}
+	span.FinishWithOptions(ctx, FinishOptions{FinishTime: o.finishTime})
+}
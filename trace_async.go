@@ -0,0 +1,45 @@
+// Copyright (c) 2025 Bytedance Ltd. and/or its affiliates
+// SPDX-License-Identifier: MIT
+
+package cozeloop
+
+import (
+	"context"
+
+	"github.com/coze-dev/cozeloop-go/internal/util"
+)
+
+// DetachContext returns a context which carries the values of ctx, including the span and baggage, but is not
+// canceled when ctx is canceled and has no deadline. Use it for the work which outlives the request, such as
+// writing cache or sending notifications after the response is returned.
+func DetachContext(ctx context.Context) context.Context {
+	return util.DetachContext(ctx)
+}
+
+// StartLinkedSpan Start a span for the background work which outlives the span in ctx, such as fire-and-forget
+// goroutines. The span is a child of the span in ctx, so it is kept in the originating trace, and the returned
+// context is detached from ctx by DetachContext, so the work is not canceled with the request. It must be called
+// before the span in ctx is finished, usually before starting the goroutine:
+//
+//	bgCtx, span := cozeloop.StartLinkedSpan(ctx, "send_notification", "custom")
+//	go func() {
+//		defer cozeloop.RecoverSpan(bgCtx, span)
+//		// do the work with bgCtx
+//	}()
+func StartLinkedSpan(ctx context.Context, name, spanType string, opts ...StartSpanOption) (context.Context, Span) {
+	return StartSpan(DetachContext(ctx), name, spanType, opts...)
+}
+
+// GoWithSpan Run fn in a new goroutine with a span started by StartLinkedSpan. It returns immediately, the error
+// returned by fn is recorded on the span, and the span is finished after fn returns. If fn panics, the panic is
+// recorded on the span and re-panicked like WithSpan.
+func GoWithSpan(ctx context.Context, name, spanType string, fn func(ctx context.Context) error, opts ...StartSpanOption) {
+	ctx, span := StartLinkedSpan(ctx, name, spanType, opts...)
+	go func() {
+		defer RecoverSpan(ctx, span)
+		if err := fn(ctx); err != nil {
+			span.SetStatusCode(ctx, util.GetErrorCode(err))
+			span.SetError(ctx, err)
+		}
+	}()
+}
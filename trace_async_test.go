@@ -0,0 +1,76 @@
+// Copyright (c) 2025 Bytedance Ltd. and/or its affiliates
+// SPDX-License-Identifier: MIT
+
+package cozeloop
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+type ctxKey struct{}
+
+func TestDetachContext(t *testing.T) {
+	Convey("Test detached context keeps values but is not canceled", t, func() {
+		ctx, cancel := context.WithTimeout(context.WithValue(context.Background(), ctxKey{}, "value"), time.Minute)
+		detached := DetachContext(ctx)
+		cancel()
+		So(ctx.Err(), ShouldNotBeNil)
+		So(detached.Err(), ShouldBeNil)
+		So(detached.Done(), ShouldBeNil)
+		_, ok := detached.Deadline()
+		So(ok, ShouldBeFalse)
+		So(detached.Value(ctxKey{}), ShouldEqual, "value")
+	})
+}
+
+func TestStartLinkedSpan(t *testing.T) {
+	Convey("Test background spans are kept in the originating trace", t, func() {
+		exporter := &recordExporter{}
+		client, err := NewClient(WithWorkspaceID("linked_span"), WithAPIToken("token"), WithExporter(exporter))
+		So(err, ShouldBeNil)
+		defaultClient := getDefaultClient()
+		SetDefaultClient(client)
+		defer SetDefaultClient(defaultClient)
+
+		reqCtx, cancel := context.WithCancel(context.Background())
+		reqCtx, request := StartSpan(reqCtx, "request", "custom")
+
+		bgCtx, background := StartLinkedSpan(reqCtx, "write_cache", "custom")
+		done := make(chan error, 1)
+		GoWithSpan(reqCtx, "audit", "custom", func(ctx context.Context) error {
+			err := ctx.Err()
+			done <- err
+			return errors.New("audit failed")
+		})
+
+		// the request finishes before the background work
+		request.Finish(reqCtx)
+		cancel()
+		So(bgCtx.Err(), ShouldBeNil)
+		So(GetSpanFromContext(bgCtx).GetSpanID(), ShouldEqual, background.GetSpanID())
+		background.Finish(bgCtx)
+		So(<-done, ShouldBeNil)
+
+		// wait for the span of audit to be finished after fn returns
+		time.Sleep(50 * time.Millisecond)
+		client.Flush(context.Background())
+		exporter.mu.Lock()
+		defer exporter.mu.Unlock()
+		spans := map[string]int{}
+		for i, span := range exporter.spans {
+			spans[span.SpanName] = i
+		}
+		So(len(spans), ShouldEqual, 3)
+		for _, name := range []string{"write_cache", "audit"} {
+			span := exporter.spans[spans[name]]
+			So(span.TraceID, ShouldEqual, request.GetTraceID())
+			So(span.ParentID, ShouldEqual, request.GetSpanID())
+		}
+		So(exporter.spans[spans["audit"]].StatusCode, ShouldNotEqual, 0)
+	})
+}
@@ -0,0 +1,113 @@
+// Copyright (c) 2025 Bytedance Ltd. and/or its affiliates
+// SPDX-License-Identifier: MIT
+
+package cozeloop
+
+import (
+	"context"
+
+	"github.com/coze-dev/cozeloop-go/internal/logger"
+	"github.com/coze-dev/cozeloop-go/spec/tracespec"
+)
+
+// SpanTypeConsumer type of the span started by StartConsumerSpan.
+const SpanTypeConsumer = "consumer"
+
+// RetrieverDocument is a document recalled by retriever, see SetRetrieverDocuments.
+type RetrieverDocument = tracespec.RetrieverDocument
+
+// StartToolSpan Start a span of tool type named toolName, with args as the input of span.
+// Use WithToolCallID to link the span to the tool call of model, and set the result of tool by Span.SetOutput.
+func StartToolSpan(ctx context.Context, toolName string, args interface{}, opts ...StartSpanOption) (context.Context, Span) {
+	return startToolSpan(ctx, getDefaultClient(), toolName, args, opts...)
+}
+
+func startToolSpan(ctx context.Context, client TraceClient, toolName string, args interface{}, opts ...StartSpanOption) (context.Context, Span) {
+	ctx, span := client.StartSpan(ctx, toolName, tracespec.VToolSpanType, opts...)
+	if args != nil {
+		span.SetInput(ctx, args)
+	}
+	return ctx, span
+}
+
+// StartRetrieverSpan Start a span of retriever type, with query as the input of span.
+// Use WithRetrieverTopK and WithRetrieverProvider to set the call options, and SetRetrieverDocuments
+// to set the documents recalled.
+func StartRetrieverSpan(ctx context.Context, query string, opts ...StartSpanOption) (context.Context, Span) {
+	ctx, span := StartSpan(ctx, tracespec.VRetrieverSpanType, tracespec.VRetrieverSpanType, opts...)
+	span.SetInput(ctx, &tracespec.RetrieverInput{Query: query})
+	return ctx, span
+}
+
+// SetRetrieverDocuments Set the documents recalled as the output of retriever span.
+func SetRetrieverDocuments(ctx context.Context, span Span, documents []*RetrieverDocument) {
+	if span == nil {
+		return
+	}
+	span.SetOutput(ctx, &tracespec.RetrieverOutput{Documents: documents})
+}
+
+// InjectMessageHeaders Set the span context of the span in ctx into headers of message, such as the headers of
+// Kafka record, RocketMQ message properties, or SQS message attributes, so that the consumer can continue the trace
+// by StartConsumerSpan. headers is returned as it is if there is no span in ctx, and a new map is created if headers
+// is nil.
+func InjectMessageHeaders(ctx context.Context, headers map[string][]byte) map[string][]byte {
+	span := GetSpanFromContext(ctx)
+	if span == DefaultNoopSpan {
+		return headers
+	}
+	spanHeaders, err := span.ToHeader()
+	if err != nil {
+		logger.CtxWarnf(ctx, "inject message headers failed, %v", err)
+		return headers
+	}
+	if headers == nil {
+		headers = make(map[string][]byte, len(spanHeaders))
+	}
+	for k, v := range spanHeaders {
+		headers[k] = []byte(v)
+	}
+	return headers
+}
+
+// ExtractMessageHeaders Get the span context from headers of message set by InjectMessageHeaders. The keys of
+// headers are case-insensitive.
+func ExtractMessageHeaders(ctx context.Context, headers map[string][]byte) SpanContext {
+	header := make(map[string]string, len(headers))
+	for k, v := range headers {
+		header[k] = string(v)
+	}
+	return GetSpanFromHeader(ctx, header)
+}
+
+// StartConsumerSpan Start a span of consumer type when a message is consumed, which is a child of the span
+// producing the message, so that asynchronous pipelines are kept in the same trace. It starts a new trace if
+// there is no span context in headers.
+func StartConsumerSpan(ctx context.Context, name string, headers map[string][]byte, opts ...StartSpanOption) (context.Context, Span) {
+	opts = append([]StartSpanOption{WithChildOf(ExtractMessageHeaders(ctx, headers))}, opts...)
+	return StartSpan(ctx, name, SpanTypeConsumer, opts...)
+}
+
+// WithToolCallID Set the id of tool call from model, which the tool span executes.
+func WithToolCallID(toolCallID string) StartSpanOption {
+	return withStartTag(tracespec.ToolCallID, toolCallID)
+}
+
+// WithRetrieverTopK Set the max count of documents to recall, as the call options of retriever span.
+func WithRetrieverTopK(topK int64) StartSpanOption {
+	return withStartTag(tracespec.CallOptions, &tracespec.RetrieverCallOption{TopK: topK})
+}
+
+// WithRetrieverProvider Set the provider of retriever span, such as Elasticsearch, VikingDB, etc.
+func WithRetrieverProvider(provider string) StartSpanOption {
+	return withStartTag(tracespec.RetrieverProvider, provider)
+}
+
+func withStartTag(key string, value interface{}) StartSpanOption {
+	return func(ops *startSpanOptions) {
+		if ops.Tags == nil {
+			ops.Tags = make(map[string]interface{})
+		}
+		ops.Tags[key] = value
+	}
+}
@@ -0,0 +1,121 @@
+// Copyright (c) 2025 Bytedance Ltd. and/or its affiliates
+// SPDX-License-Identifier: MIT
+
+package cozeloop
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	. "github.com/smartystreets/goconvey/convey"
+
+	"github.com/coze-dev/cozeloop-go/spec/tracespec"
+)
+
+func TestStartStepSpans(t *testing.T) {
+	Convey("Test tool and retriever spans", t, func() {
+		ctx := context.Background()
+		exporter := &recordExporter{}
+		client, err := NewClient(WithWorkspaceID("step_span"), WithAPIToken("token"), WithExporter(exporter))
+		So(err, ShouldBeNil)
+		defaultClient := getDefaultClient()
+		SetDefaultClient(client)
+		defer SetDefaultClient(defaultClient)
+
+		toolCtx, toolSpan := StartToolSpan(ctx, "get_weather", map[string]string{"city": "Beijing"}, WithToolCallID("call_1"))
+		toolSpan.SetOutput(toolCtx, "sunny")
+		toolSpan.Finish(toolCtx)
+
+		retrieverCtx, retrieverSpan := StartRetrieverSpan(ctx, "weather of Beijing", WithRetrieverTopK(3), WithRetrieverProvider("es"))
+		SetRetrieverDocuments(retrieverCtx, retrieverSpan, []*RetrieverDocument{{ID: "doc_1", Content: "sunny", Score: 0.9}})
+		retrieverSpan.Finish(retrieverCtx)
+
+		client.Flush(ctx)
+		exporter.mu.Lock()
+		defer exporter.mu.Unlock()
+		So(len(exporter.spans), ShouldEqual, 2)
+
+		tool := exporter.spans[0]
+		So(tool.SpanName, ShouldEqual, "get_weather")
+		So(tool.SpanType, ShouldEqual, tracespec.VToolSpanType)
+		So(tool.TagsString[tracespec.ToolCallID], ShouldEqual, "call_1")
+		So(tool.Input, ShouldContainSubstring, "Beijing")
+
+		retriever := exporter.spans[1]
+		So(retriever.SpanType, ShouldEqual, tracespec.VRetrieverSpanType)
+		So(retriever.Input, ShouldContainSubstring, `"query":"weather of Beijing"`)
+		So(retriever.Output, ShouldContainSubstring, `"id":"doc_1"`)
+		So(retriever.TagsString[tracespec.CallOptions], ShouldContainSubstring, `"top_k":3`)
+		So(retriever.TagsString[tracespec.RetrieverProvider], ShouldEqual, "es")
+	})
+}
+
+func TestMessageHeaders(t *testing.T) {
+	Convey("Test consumer span continues the trace of producer", t, func() {
+		ctx := context.Background()
+		exporter := &recordExporter{}
+		client, err := NewClient(WithWorkspaceID("message_span"), WithAPIToken("token"), WithExporter(exporter))
+		So(err, ShouldBeNil)
+		defaultClient := getDefaultClient()
+		SetDefaultClient(client)
+		defer SetDefaultClient(defaultClient)
+
+		So(InjectMessageHeaders(ctx, nil), ShouldBeNil)
+
+		producerCtx, producer := StartSpan(ctx, "produce", "custom")
+		producer.SetBaggage(producerCtx, map[string]string{"tenant": "t1"})
+		headers := InjectMessageHeaders(producerCtx, map[string][]byte{"key": []byte("value")})
+		producer.Finish(producerCtx)
+		So(string(headers["key"]), ShouldEqual, "value")
+
+		// keys of headers may be lower-cased by message queue
+		consumed := make(map[string][]byte, len(headers))
+		for k, v := range headers {
+			consumed[strings.ToLower(k)] = v
+		}
+		consumerCtx, consumer := StartConsumerSpan(ctx, "consume", consumed)
+		consumer.Finish(consumerCtx)
+		So(consumer.GetTraceID(), ShouldEqual, producer.GetTraceID())
+		So(consumer.GetBaggage()["tenant"], ShouldEqual, "t1")
+
+		_, other := StartConsumerSpan(ctx, "consume", nil)
+		So(other.GetTraceID(), ShouldNotEqual, producer.GetTraceID())
+
+		client.Flush(ctx)
+		exporter.mu.Lock()
+		defer exporter.mu.Unlock()
+		span := exporter.spans[len(exporter.spans)-1]
+		So(span.SpanType, ShouldEqual, SpanTypeConsumer)
+		So(span.ParentID, ShouldEqual, producer.GetSpanID())
+	})
+}
+
+func TestFinishSpan(t *testing.T) {
+	Convey("Test spans are backfilled with start and finish time", t, func() {
+		ctx := context.Background()
+		exporter := &recordExporter{}
+		now := time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC)
+		clock := NewManualClock(now)
+		client, err := NewClient(WithWorkspaceID("finish_span"), WithAPIToken("token"), WithExporter(exporter), WithTraceClock(clock))
+		So(err, ShouldBeNil)
+
+		spanCtx, span := client.StartSpan(ctx, "live", "custom")
+		clock.Add(2 * time.Second)
+		FinishSpan(spanCtx, span)
+
+		spanCtx, span = client.StartSpan(ctx, "backfill", "custom", WithStartTime(now.Add(-time.Hour)))
+		FinishSpan(spanCtx, span, WithFinishTime(now.Add(-time.Hour+300*time.Millisecond)))
+		FinishSpan(ctx, nil)
+
+		client.Flush(ctx)
+		exporter.mu.Lock()
+		defer exporter.mu.Unlock()
+		So(len(exporter.spans), ShouldEqual, 2)
+		So(exporter.spans[0].StartedATMicros, ShouldEqual, now.UnixMicro())
+		So(exporter.spans[0].DurationMicros, ShouldEqual, int64(2*time.Second/time.Microsecond))
+		So(exporter.spans[1].StartedATMicros, ShouldEqual, now.Add(-time.Hour).UnixMicro())
+		So(exporter.spans[1].DurationMicros, ShouldEqual, int64(300*time.Millisecond/time.Microsecond))
+	})
+}
@@ -0,0 +1,16 @@
+// Copyright (c) 2025 Bytedance Ltd. and/or its affiliates
+// SPDX-License-Identifier: MIT
+
+package cozeloop
+
+import (
+	"context"
+
+	"github.com/coze-dev/cozeloop-go/entity"
+)
+
+// TraceQueryClient interface of trace query client, which reads trace data back from the platform.
+type TraceQueryClient interface {
+	// ListSpans list spans by trace id or time range, and filter them by span types and tags.
+	ListSpans(ctx context.Context, param *entity.ListSpansParam) (*entity.ListSpansResult, error)
+}